@@ -0,0 +1,392 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: api/gitguard/v1/scanner.proto
+
+package gitguardv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ScanDiffRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unified diff to scan.
+	Diff          []byte `protobuf:"bytes,1,opt,name=diff,proto3" json:"diff,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanDiffRequest) Reset() {
+	*x = ScanDiffRequest{}
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanDiffRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanDiffRequest) ProtoMessage() {}
+
+func (x *ScanDiffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanDiffRequest.ProtoReflect.Descriptor instead.
+func (*ScanDiffRequest) Descriptor() ([]byte, []int) {
+	return file_api_gitguard_v1_scanner_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ScanDiffRequest) GetDiff() []byte {
+	if x != nil {
+		return x.Diff
+	}
+	return nil
+}
+
+type ScanBlobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path of the file, used for path-scoped rules and reported in findings.
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Content       []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanBlobRequest) Reset() {
+	*x = ScanBlobRequest{}
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanBlobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanBlobRequest) ProtoMessage() {}
+
+func (x *ScanBlobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanBlobRequest.ProtoReflect.Descriptor instead.
+func (*ScanBlobRequest) Descriptor() ([]byte, []int) {
+	return file_api_gitguard_v1_scanner_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ScanBlobRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ScanBlobRequest) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type ScanRepositoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// HTTPS clone URL of the repository.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Branch name or full ref, e.g. refs/tags/v1.0.0, to scan; the default branch when empty.
+	Ref string `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	// Token used as the HTTPS password when cloning private repositories.
+	Token         string `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRepositoryRequest) Reset() {
+	*x = ScanRepositoryRequest{}
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRepositoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRepositoryRequest) ProtoMessage() {}
+
+func (x *ScanRepositoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRepositoryRequest.ProtoReflect.Descriptor instead.
+func (*ScanRepositoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_gitguard_v1_scanner_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ScanRepositoryRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *ScanRepositoryRequest) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *ScanRepositoryRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type Finding struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	RuleId      string                 `protobuf:"bytes,1,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	File        string                 `protobuf:"bytes,3,opt,name=file,proto3" json:"file,omitempty"`
+	StartLine   int32                  `protobuf:"varint,4,opt,name=start_line,json=startLine,proto3" json:"start_line,omitempty"`
+	EndLine     int32                  `protobuf:"varint,5,opt,name=end_line,json=endLine,proto3" json:"end_line,omitempty"`
+	StartColumn int32                  `protobuf:"varint,6,opt,name=start_column,json=startColumn,proto3" json:"start_column,omitempty"`
+	EndColumn   int32                  `protobuf:"varint,7,opt,name=end_column,json=endColumn,proto3" json:"end_column,omitempty"`
+	// Commit introducing the secret, when scanning a diff with commit headers.
+	Commit string `protobuf:"bytes,8,opt,name=commit,proto3" json:"commit,omitempty"`
+	// One of low, medium, high or critical.
+	Severity string `protobuf:"bytes,9,opt,name=severity,proto3" json:"severity,omitempty"`
+	// Stable identifier of the finding, a hash of the repository, file, rule and secret.
+	Fingerprint   string `protobuf:"bytes,10,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Finding) Reset() {
+	*x = Finding{}
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Finding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Finding) ProtoMessage() {}
+
+func (x *Finding) ProtoReflect() protoreflect.Message {
+	mi := &file_api_gitguard_v1_scanner_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Finding.ProtoReflect.Descriptor instead.
+func (*Finding) Descriptor() ([]byte, []int) {
+	return file_api_gitguard_v1_scanner_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Finding) GetRuleId() string {
+	if x != nil {
+		return x.RuleId
+	}
+	return ""
+}
+
+func (x *Finding) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Finding) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Finding) GetStartLine() int32 {
+	if x != nil {
+		return x.StartLine
+	}
+	return 0
+}
+
+func (x *Finding) GetEndLine() int32 {
+	if x != nil {
+		return x.EndLine
+	}
+	return 0
+}
+
+func (x *Finding) GetStartColumn() int32 {
+	if x != nil {
+		return x.StartColumn
+	}
+	return 0
+}
+
+func (x *Finding) GetEndColumn() int32 {
+	if x != nil {
+		return x.EndColumn
+	}
+	return 0
+}
+
+func (x *Finding) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *Finding) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Finding) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+var File_api_gitguard_v1_scanner_proto protoreflect.FileDescriptor
+
+const file_api_gitguard_v1_scanner_proto_rawDesc = "" +
+	"\n" +
+	"\x1dapi/gitguard/v1/scanner.proto\x12\vgitguard.v1\"%\n" +
+	"\x0fScanDiffRequest\x12\x12\n" +
+	"\x04diff\x18\x01 \x01(\fR\x04diff\"?\n" +
+	"\x0fScanBlobRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"Q\n" +
+	"\x15ScanRepositoryRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x10\n" +
+	"\x03ref\x18\x02 \x01(\tR\x03ref\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\"\xaa\x02\n" +
+	"\aFinding\x12\x17\n" +
+	"\arule_id\x18\x01 \x01(\tR\x06ruleId\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04file\x18\x03 \x01(\tR\x04file\x12\x1d\n" +
+	"\n" +
+	"start_line\x18\x04 \x01(\x05R\tstartLine\x12\x19\n" +
+	"\bend_line\x18\x05 \x01(\x05R\aendLine\x12!\n" +
+	"\fstart_column\x18\x06 \x01(\x05R\vstartColumn\x12\x1d\n" +
+	"\n" +
+	"end_column\x18\a \x01(\x05R\tendColumn\x12\x16\n" +
+	"\x06commit\x18\b \x01(\tR\x06commit\x12\x1a\n" +
+	"\bseverity\x18\t \x01(\tR\bseverity\x12 \n" +
+	"\vfingerprint\x18\n" +
+	" \x01(\tR\vfingerprint2\xe2\x01\n" +
+	"\x0eScannerService\x12@\n" +
+	"\bScanDiff\x12\x1c.gitguard.v1.ScanDiffRequest\x1a\x14.gitguard.v1.Finding0\x01\x12@\n" +
+	"\bScanBlob\x12\x1c.gitguard.v1.ScanBlobRequest\x1a\x14.gitguard.v1.Finding0\x01\x12L\n" +
+	"\x0eScanRepository\x12\".gitguard.v1.ScanRepositoryRequest\x1a\x14.gitguard.v1.Finding0\x01B9Z7github.com/omercnet/gitguard/api/gitguard/v1;gitguardv1b\x06proto3"
+
+var (
+	file_api_gitguard_v1_scanner_proto_rawDescOnce sync.Once
+	file_api_gitguard_v1_scanner_proto_rawDescData []byte
+)
+
+func file_api_gitguard_v1_scanner_proto_rawDescGZIP() []byte {
+	file_api_gitguard_v1_scanner_proto_rawDescOnce.Do(func() {
+		file_api_gitguard_v1_scanner_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_gitguard_v1_scanner_proto_rawDesc), len(file_api_gitguard_v1_scanner_proto_rawDesc)))
+	})
+	return file_api_gitguard_v1_scanner_proto_rawDescData
+}
+
+var file_api_gitguard_v1_scanner_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_api_gitguard_v1_scanner_proto_goTypes = []any{
+	(*ScanDiffRequest)(nil),       // 0: gitguard.v1.ScanDiffRequest
+	(*ScanBlobRequest)(nil),       // 1: gitguard.v1.ScanBlobRequest
+	(*ScanRepositoryRequest)(nil), // 2: gitguard.v1.ScanRepositoryRequest
+	(*Finding)(nil),               // 3: gitguard.v1.Finding
+}
+var file_api_gitguard_v1_scanner_proto_depIdxs = []int32{
+	0, // 0: gitguard.v1.ScannerService.ScanDiff:input_type -> gitguard.v1.ScanDiffRequest
+	1, // 1: gitguard.v1.ScannerService.ScanBlob:input_type -> gitguard.v1.ScanBlobRequest
+	2, // 2: gitguard.v1.ScannerService.ScanRepository:input_type -> gitguard.v1.ScanRepositoryRequest
+	3, // 3: gitguard.v1.ScannerService.ScanDiff:output_type -> gitguard.v1.Finding
+	3, // 4: gitguard.v1.ScannerService.ScanBlob:output_type -> gitguard.v1.Finding
+	3, // 5: gitguard.v1.ScannerService.ScanRepository:output_type -> gitguard.v1.Finding
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_api_gitguard_v1_scanner_proto_init() }
+func file_api_gitguard_v1_scanner_proto_init() {
+	if File_api_gitguard_v1_scanner_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_gitguard_v1_scanner_proto_rawDesc), len(file_api_gitguard_v1_scanner_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_gitguard_v1_scanner_proto_goTypes,
+		DependencyIndexes: file_api_gitguard_v1_scanner_proto_depIdxs,
+		MessageInfos:      file_api_gitguard_v1_scanner_proto_msgTypes,
+	}.Build()
+	File_api_gitguard_v1_scanner_proto = out.File
+	file_api_gitguard_v1_scanner_proto_goTypes = nil
+	file_api_gitguard_v1_scanner_proto_depIdxs = nil
+}
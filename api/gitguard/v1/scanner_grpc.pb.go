@@ -0,0 +1,220 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/gitguard/v1/scanner.proto
+
+package gitguardv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ScannerService_ScanDiff_FullMethodName       = "/gitguard.v1.ScannerService/ScanDiff"
+	ScannerService_ScanBlob_FullMethodName       = "/gitguard.v1.ScannerService/ScanBlob"
+	ScannerService_ScanRepository_FullMethodName = "/gitguard.v1.ScannerService/ScanRepository"
+)
+
+// ScannerServiceClient is the client API for ScannerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ScannerService runs GitGuard's secret detection on content supplied by the caller. Findings
+// are streamed as they are found and never include the secrets themselves.
+type ScannerServiceClient interface {
+	// ScanDiff scans the lines added by a unified diff, e.g. the output of `git diff` or
+	// `git log -p`.
+	ScanDiff(ctx context.Context, in *ScanDiffRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error)
+	// ScanBlob scans the content of a single file.
+	ScanBlob(ctx context.Context, in *ScanBlobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error)
+	// ScanRepository clones a repository and scans every file at a ref.
+	ScanRepository(ctx context.Context, in *ScanRepositoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error)
+}
+
+type scannerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScannerServiceClient(cc grpc.ClientConnInterface) ScannerServiceClient {
+	return &scannerServiceClient{cc}
+}
+
+func (c *scannerServiceClient) ScanDiff(ctx context.Context, in *ScanDiffRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScannerService_ServiceDesc.Streams[0], ScannerService_ScanDiff_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanDiffRequest, Finding]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScannerService_ScanDiffClient = grpc.ServerStreamingClient[Finding]
+
+func (c *scannerServiceClient) ScanBlob(ctx context.Context, in *ScanBlobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScannerService_ServiceDesc.Streams[1], ScannerService_ScanBlob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanBlobRequest, Finding]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScannerService_ScanBlobClient = grpc.ServerStreamingClient[Finding]
+
+func (c *scannerServiceClient) ScanRepository(ctx context.Context, in *ScanRepositoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScannerService_ServiceDesc.Streams[2], ScannerService_ScanRepository_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanRepositoryRequest, Finding]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScannerService_ScanRepositoryClient = grpc.ServerStreamingClient[Finding]
+
+// ScannerServiceServer is the server API for ScannerService service.
+// All implementations must embed UnimplementedScannerServiceServer
+// for forward compatibility.
+//
+// ScannerService runs GitGuard's secret detection on content supplied by the caller. Findings
+// are streamed as they are found and never include the secrets themselves.
+type ScannerServiceServer interface {
+	// ScanDiff scans the lines added by a unified diff, e.g. the output of `git diff` or
+	// `git log -p`.
+	ScanDiff(*ScanDiffRequest, grpc.ServerStreamingServer[Finding]) error
+	// ScanBlob scans the content of a single file.
+	ScanBlob(*ScanBlobRequest, grpc.ServerStreamingServer[Finding]) error
+	// ScanRepository clones a repository and scans every file at a ref.
+	ScanRepository(*ScanRepositoryRequest, grpc.ServerStreamingServer[Finding]) error
+	mustEmbedUnimplementedScannerServiceServer()
+}
+
+// UnimplementedScannerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedScannerServiceServer struct{}
+
+func (UnimplementedScannerServiceServer) ScanDiff(*ScanDiffRequest, grpc.ServerStreamingServer[Finding]) error {
+	return status.Errorf(codes.Unimplemented, "method ScanDiff not implemented")
+}
+func (UnimplementedScannerServiceServer) ScanBlob(*ScanBlobRequest, grpc.ServerStreamingServer[Finding]) error {
+	return status.Errorf(codes.Unimplemented, "method ScanBlob not implemented")
+}
+func (UnimplementedScannerServiceServer) ScanRepository(*ScanRepositoryRequest, grpc.ServerStreamingServer[Finding]) error {
+	return status.Errorf(codes.Unimplemented, "method ScanRepository not implemented")
+}
+func (UnimplementedScannerServiceServer) mustEmbedUnimplementedScannerServiceServer() {}
+func (UnimplementedScannerServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeScannerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScannerServiceServer will
+// result in compilation errors.
+type UnsafeScannerServiceServer interface {
+	mustEmbedUnimplementedScannerServiceServer()
+}
+
+func RegisterScannerServiceServer(s grpc.ServiceRegistrar, srv ScannerServiceServer) {
+	// If the following call pancis, it indicates UnimplementedScannerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ScannerService_ServiceDesc, srv)
+}
+
+func _ScannerService_ScanDiff_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanDiffRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScannerServiceServer).ScanDiff(m, &grpc.GenericServerStream[ScanDiffRequest, Finding]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScannerService_ScanDiffServer = grpc.ServerStreamingServer[Finding]
+
+func _ScannerService_ScanBlob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanBlobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScannerServiceServer).ScanBlob(m, &grpc.GenericServerStream[ScanBlobRequest, Finding]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScannerService_ScanBlobServer = grpc.ServerStreamingServer[Finding]
+
+func _ScannerService_ScanRepository_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRepositoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScannerServiceServer).ScanRepository(m, &grpc.GenericServerStream[ScanRepositoryRequest, Finding]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScannerService_ScanRepositoryServer = grpc.ServerStreamingServer[Finding]
+
+// ScannerService_ServiceDesc is the grpc.ServiceDesc for ScannerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ScannerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gitguard.v1.ScannerService",
+	HandlerType: (*ScannerServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ScanDiff",
+			Handler:       _ScannerService_ScanDiff_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ScanBlob",
+			Handler:       _ScannerService_ScanBlob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ScanRepository",
+			Handler:       _ScannerService_ScanRepository_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/gitguard/v1/scanner.proto",
+}
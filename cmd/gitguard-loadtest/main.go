@@ -0,0 +1,72 @@
+// Command gitguard-loadtest fires signed synthetic push events at a GitGuard instance at a
+// fixed rate and reports the latency and error distribution of its responses.
+//
+// Webhooks are handled before GitGuard responds, so latencies include the scans. Point the
+// target's github.api_url at a mock of the GitHub API to measure GitGuard alone; against
+// GitHub, the synthetic repositories do not exist and every scan fails.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/loadtest"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run runs the load test and returns the exit code: 1 when deliveries failed, 2 when the
+// test could not run.
+func run() int {
+	var opts loadtest.Options
+	flag.StringVar(&opts.URL, "url", "http://localhost:8080/", "webhook URL of the target")
+	flag.Float64Var(&opts.Rate, "rate", 10, "deliveries started per second")
+	flag.DurationVar(&opts.Duration, "duration", time.Minute, "how long to send deliveries for")
+	flag.IntVar(&opts.Concurrency, "concurrency", 100, "deliveries in flight at most; those due beyond it are skipped")
+	flag.IntVar(&opts.Commits, "commits", 1, "commits per push")
+	flag.IntVar(&opts.Repositories, "repos", 10, "synthetic repositories to spread pushes across")
+	flag.StringVar(&opts.Owner, "owner", "gitguard-loadtest", "owner of the synthetic repositories")
+	flag.Int64Var(&opts.Installation, "installation", 1, "installation ID of the pushes")
+	timeout := flag.Duration("timeout", time.Minute, "how long to wait for each response")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: %s [flags]\n\nDeliveries are signed with %s.\n\n", os.Args[0], config.GitHubWebhookSecretEnv)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	opts.Secret = os.Getenv(config.GitHubWebhookSecretEnv)
+	opts.Client = newClient(*timeout, opts.Concurrency)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report, err := loadtest.Run(ctx, opts)
+	if err == nil {
+		err = report.Write(os.Stdout)
+	}
+	switch {
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	case report.Failed() > 0:
+		return 1
+	}
+	return 0
+}
+
+// newClient creates a client keeping a connection per delivery in flight, so connection
+// setup is not measured once the test is warm.
+func newClient(timeout time.Duration, concurrency int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = concurrency
+	transport.MaxIdleConnsPerHost = concurrency
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
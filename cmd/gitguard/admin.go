@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/omercnet/gitguard/internal/metrics"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+)
+
+const usage = `Usage:
+  gitguard [--config file] [--port n] ...      Start the webhook server (gitguard --help lists its flags)
+  gitguard rebaseline owner/repo               Replace a repository's baseline with its current findings
+  gitguard findings owner/repo                 List a repository's tracked findings and their states
+  gitguard suppress owner/repo fingerprint     Suppress a tracked finding
+  gitguard unsuppress owner/repo fingerprint   Reopen a suppressed finding
+  gitguard metrics                             Print finding trends, time to resolve and top repositories and rules
+  gitguard scan-org [--concurrency n] org      Run a full scan of every repository of an organization
+  gitguard pre-receive                         Scan a push from a git pre-receive hook, rejecting secrets
+  gitguard pre-push                            Scan a push from a git pre-push hook, rejecting secrets
+  gitguard install-hook [--type pre-push]      Install a pre-commit (default) or pre-push hook in this repository
+  gitguard scan --stdin | --file path          Scan content, printing findings (--format table, json or sarif)
+  gitguard scan --staged                       Scan the changes staged for the next commit
+  gitguard config show                         Print the effective configuration with secrets masked
+  gitguard replay [--url url] fixture...       Deliver recorded webhook fixtures to a running server
+  gitguard version                             Print the version and the gitleaks rules version
+`
+
+// runCommand runs an administrative command and exits.
+func runCommand(args []string, logger zerolog.Logger) {
+	var err error
+	switch {
+	case args[0] == "pre-receive" && len(args) == 1:
+		preReceiveHook.run(logger)
+		return
+	case args[0] == "pre-push" && len(args) <= 3:
+		// git passes the remote's name and URL, which the scan does not need.
+		prePushHook.run(logger)
+		return
+	case args[0] == "install-hook":
+		err = installHook(args[1:], os.Stdout)
+	case args[0] == "replay":
+		err = replayFixtures(args[1:], os.Stdout)
+	case args[0] == "scan":
+		runScan(args[1:], logger)
+		return
+	case args[0] == "version" && len(args) == 1:
+		err = printVersion(os.Stdout)
+	case args[0] == "config" && len(args) == 2 && args[1] == "show":
+		err = showConfig(os.Stdout)
+	case args[0] == "rebaseline" && len(args) == 2:
+		err = rebaseline(args[1], logger)
+	case args[0] == "findings" && len(args) == 2:
+		err = listFindings(args[1], logger)
+	case args[0] == "suppress" && len(args) == 3:
+		err = setFindingState(args[1], args[2], store.StateSuppressed, logger)
+	case args[0] == "unsuppress" && len(args) == 3:
+		err = setFindingState(args[1], args[2], store.StateOpen, logger)
+	case args[0] == "metrics" && len(args) == 1:
+		err = printMetrics(logger)
+	case args[0] == "scan-org" && len(args) > 1:
+		err = runScanOrg(args[1:], logger)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Fatal().Err(err).Str("command", args[0]).Msg("Command failed")
+	}
+}
+
+// splitRepository splits "owner/repo".
+func splitRepository(fullName string) (string, string, error) {
+	owner, repo, ok := strings.Cut(fullName, "/")
+	if !ok || owner == "" || repo == "" {
+		return "", "", fmt.Errorf("expected owner/repo, got %q", fullName)
+	}
+	return owner, repo, nil
+}
+
+// rebaseline scans a repository's default branch and records the findings as its baseline.
+func rebaseline(fullName string, logger zerolog.Logger) error {
+	owner, repo, err := splitRepository(fullName)
+	if err != nil {
+		return err
+	}
+
+	cfg := mustLoadConfig(nil, logger)
+	overrides, err := cfg.GetPathOverrides()
+	if err != nil {
+		return err
+	}
+	detectorOpts, err := cfg.GetDetectorOptions()
+	if err != nil {
+		return err
+	}
+	plugins, err := newPlugins(cfg, logger)
+	if err != nil {
+		return err
+	}
+	names, err := cfg.GetFilenameRules()
+	if err != nil {
+		return err
+	}
+	baselines, _ := newStores(cfg, logger)
+
+	scanner := &handler.FullRepoScanHandler{
+		ClientCreator: newClientCreator(cfg, logger),
+		Detectors:     detector.NewFactory(detectorOpts, logger),
+		Plugins:       plugins,
+		Filenames:     names,
+		Overrides:     overrides,
+		Baseline:      baselines,
+		LFSMaxBytes:   cfg.FullScan.LFSMaxBytes,
+		Clone: handler.CloneOptions{
+			BaseURL:  cfg.GetCloneBaseURL(),
+			Rewrites: cfg.GetCloneRewrites(),
+			ProxyURL: cfg.FullScan.Clone.ProxyURL,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.FullScanTimeout)
+	defer cancel()
+
+	count, err := scanner.Rebaseline(ctx, owner, repo, logger)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Recorded %d finding(s) as the baseline of %s\n", count, fullName)
+	return nil
+}
+
+// openFindingStore opens the finding store, which requires finding tracking to be enabled.
+func openFindingStore(logger zerolog.Logger) (store.FindingStore, error) {
+	cfg := mustLoadConfig(nil, logger)
+	_, findings := newStores(cfg, logger)
+	if findings == nil {
+		return nil, errors.New("finding tracking is disabled; set " + config.FindingTrackingEnv + "=true")
+	}
+	return findings, nil
+}
+
+// listFindings prints the tracked findings of a repository.
+func listFindings(fullName string, logger zerolog.Logger) error {
+	if _, _, err := splitRepository(fullName); err != nil {
+		return err
+	}
+	findings, err := openFindingStore(logger)
+	if err != nil {
+		return err
+	}
+	tracked, err := findings.Findings(context.Background(), fullName)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FINGERPRINT\tSTATE\tRULE\tFILE\tFIRST SEEN\tLAST SEEN")
+	for _, f := range tracked {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s:%d\t%s\t%s\n",
+			f.Fingerprint, f.State, f.RuleID, f.File, f.Line,
+			f.FirstSeen.Format("2006-01-02"), f.LastSeen.Format("2006-01-02"))
+	}
+	return w.Flush()
+}
+
+// setFindingState changes the lifecycle state of a tracked finding.
+func setFindingState(fullName, fingerprint string, state store.State, logger zerolog.Logger) error {
+	if _, _, err := splitRepository(fullName); err != nil {
+		return err
+	}
+	findings, err := openFindingStore(logger)
+	if err != nil {
+		return err
+	}
+	transition, err := findings.SetState(context.Background(), fullName, fingerprint, state)
+	if err != nil {
+		return fmt.Errorf("finding %s in %s: %w", fingerprint, fullName, err)
+	}
+	fmt.Printf("Finding %s (%s in %s) is now %s\n", fingerprint, transition.Finding.RuleID, transition.Finding.File, state)
+	return nil
+}
+
+// printMetrics prints the finding trends of every tracked repository.
+func printMetrics(logger zerolog.Logger) error {
+	findings, err := openFindingStore(logger)
+	if err != nil {
+		return err
+	}
+	report, err := metrics.Load(context.Background(), findings, metrics.Options{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Open: %d  Resolved: %d  Suppressed: %d  Mean time to resolve: %.1fh\n\n",
+		report.Open, report.Resolved, report.Suppressed, report.MeanTimeToResolve)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "WEEK\tNEW\tRESOLVED")
+	for _, week := range report.Weeks {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", week.Start.Format("2006-01-02"), week.New, week.Resolved)
+	}
+	for _, ranking := range []struct {
+		title  string
+		counts []metrics.Count
+	}{{"REPOSITORY", report.TopRepositories}, {"RULE", report.TopRules}} {
+		fmt.Fprintf(w, "\n%s\tOPEN\n", ranking.title)
+		for _, count := range ranking.counts {
+			fmt.Fprintf(w, "%s\t%d\n", count.Name, count.Open)
+		}
+	}
+	return w.Flush()
+}
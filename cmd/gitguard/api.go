@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/omercnet/gitguard/internal/auth"
+	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/rs/zerolog"
+)
+
+// scanRequest is the body of POST /api/v1/repos/{owner}/{repo}/scans: exactly one of a ref
+// (branch, tag or fully qualified ref) or a commit SHA.
+type scanRequest struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+// scanJobs serves the API running full scans of a repository at a ref in the background
+// with the full scan handler of the current configuration, and reporting the status of every
+// scan of the server by its job ID.
+type scanJobs struct {
+	scanner atomic.Pointer[handler.FullRepoScanHandler]
+	jobs    *jobs.Registry
+	route   func(string) string
+	logger  zerolog.Logger
+}
+
+// create starts a scan of the repository of the request path and returns its job.
+func (s *scanJobs) create(w http.ResponseWriter, r *http.Request) {
+	var req scanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Ref == "") == (req.SHA == "") {
+		http.Error(w, "expected a JSON body with either a ref or a sha", http.StatusBadRequest)
+		return
+	}
+	owner, repo := r.PathValue("owner"), r.PathValue("repo")
+	ref := req.Ref
+	if ref == "" {
+		ref = req.SHA
+	}
+
+	job := s.jobs.Create(jobs.Job{Repository: owner + "/" + repo, Ref: req.Ref, Commit: req.SHA})
+	identity, _ := auth.FromContext(r.Context())
+	logger := s.logger.With().
+		Str("job", job.ID).
+		Str("repo", job.Repository).
+		Str("ref", ref).
+		Str("requested_by", identity.Subject).
+		Logger()
+	logger.Info().Msg("Scan job requested")
+	go func() {
+		s.jobs.Start(job.ID)
+		ctx := jobs.WithID(context.Background(), job.ID)
+		commit, findings, err := s.scanner.Load().ScanRef(ctx, owner, repo, ref, logger)
+		s.jobs.Finish(job.ID, commit, findings, err)
+		if err != nil {
+			logger.Error().Err(err).Msg("Scan job failed")
+			return
+		}
+		logger.Info().Int("findings", findings).Msg("Scan job completed")
+	}()
+
+	w.Header().Set("Location", s.route("/api/v1/scans/"+job.ID))
+	s.write(w, http.StatusAccepted, job)
+}
+
+// get reports the job of the request path.
+func (s *scanJobs) get(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "no such scan", http.StatusNotFound)
+		return
+	}
+	s.write(w, http.StatusOK, job)
+}
+
+func (s *scanJobs) write(w http.ResponseWriter, code int, job jobs.Job) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to write scan job response")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// showConfig prints the effective configuration, merged from the config file, environment
+// variables and defaults, with secrets masked. GitHub App credentials are not required, so a
+// configuration that fails to start the server can still be inspected.
+func showConfig(out io.Writer) error {
+	cfg, err := config.LoadLocalConfig(nil)
+	if err != nil {
+		return err
+	}
+	return writeConfig(out, cfg)
+}
+
+func writeConfig(out io.Writer, cfg *config.Config) error {
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Masked()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// configHandler serves the running configuration with secrets masked.
+func configHandler(current func() *config.Config, logger zerolog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		if err := writeConfig(w, current()); err != nil {
+			logger.Error().Err(err).Msg("Failed to write configuration response")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies the hooks install-hook writes, so reinstalling replaces them but
+// never a developer's own hook.
+const hookMarker = "# Installed by gitguard install-hook"
+
+// hookCommands are the gitguard commands each installable git hook runs.
+var hookCommands = map[string]string{
+	"pre-commit": "scan --staged",
+	"pre-push":   "pre-push",
+}
+
+// installHook writes a git hook running gitguard with the local rules to the hooks directory
+// of the repository in the working directory, and reports its path to out.
+func installHook(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("install-hook", flag.ContinueOnError)
+	hook := flags.String("type", "pre-commit", "hook to install: pre-commit scans staged changes, pre-push pushed commits")
+	force := flags.Bool("force", false, "replace an existing hook not installed by gitguard")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	command, ok := hookCommands[*hook]
+	if !ok || flags.NArg() != 0 {
+		return fmt.Errorf("expected --type pre-commit or pre-push, got %q", *hook)
+	}
+
+	binary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the gitguard binary: %w", err)
+	}
+	// git resolves core.hooksPath and worktrees; the path is relative to the working directory.
+	hooks, err := exec.CommandContext(context.Background(), "git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return fmt.Errorf("not in a git repository: %w", err)
+	}
+	dir := strings.TrimSpace(string(hooks))
+	path := filepath.Join(dir, *hook)
+
+	existing, err := os.ReadFile(path) // #nosec G304 -- The hook path comes from git
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	case !bytes.Contains(existing, []byte(hookMarker)) && !*force:
+		return fmt.Errorf("%s already exists; pass --force to replace it", path)
+	}
+
+	quoted := "'" + strings.ReplaceAll(binary, "'", `'\''`) + "'"
+	script := fmt.Sprintf("#!/bin/sh\n%s: rejects secrets before they leave this machine.\nexec %s %s\n",
+		hookMarker, quoted, command)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	// #nosec G306 -- git only runs executable hooks
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o755); err != nil { // #nosec G302 -- git only runs executable hooks
+		return err
+	}
+	fmt.Fprintf(out, "Installed the %s hook at %s\n", *hook, path)
+	return nil
+}
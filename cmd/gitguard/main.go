@@ -2,18 +2,43 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/gregjones/httpcache"
+	"github.com/omercnet/gitguard/internal/auth"
+	"github.com/omercnet/gitguard/internal/bus"
+	"github.com/omercnet/gitguard/internal/cache"
 	"github.com/omercnet/gitguard/internal/config"
-	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/graphql"
+	"github.com/omercnet/gitguard/internal/grpcserver"
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/omercnet/gitguard/internal/keyring"
 	"github.com/omercnet/gitguard/internal/logging"
+	"github.com/omercnet/gitguard/internal/metrics"
+	"github.com/omercnet/gitguard/internal/middleware"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/orgreport"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/scm/gitlab"
+	"github.com/omercnet/gitguard/internal/selfcheck"
+	"github.com/omercnet/gitguard/internal/siem"
+	"github.com/omercnet/gitguard/internal/store"
 	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -25,10 +50,28 @@ var (
 
 func main() {
 	logger := logging.SetupLogger()
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runCommand(os.Args[1:], logger)
+		return
+	}
+	flags := parseServerFlags(os.Args[1:])
 	printStartupInfo(logger)
-	cfg := mustLoadConfig(logger)
-	server := setupServer(cfg, logger)
-	runServer(server, cfg, logger)
+	cfg := mustLoadConfig(flags, logger)
+	server, grpcServer, deliveries := setupServer(cfg, flags, logger)
+	runServer(server, grpcServer, deliveries, cfg, logger)
+}
+
+// parseServerFlags parses the server's command-line flags, which override the config file
+// and environment variables, and exits on invalid ones.
+func parseServerFlags(args []string) *config.Flags {
+	flags := config.NewFlags("gitguard", os.Stderr)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	return flags
 }
 
 func printStartupInfo(logger zerolog.Logger) {
@@ -39,8 +82,10 @@ func printStartupInfo(logger zerolog.Logger) {
 		Msg("GitGuard starting")
 }
 
-func mustLoadConfig(logger zerolog.Logger) *config.Config {
-	cfg, err := config.LoadConfig()
+// mustLoadConfig loads the configuration with flags, which may be nil, and exits when it is
+// invalid.
+func mustLoadConfig(flags *config.Flags, logger zerolog.Logger) *config.Config {
+	cfg, err := config.LoadConfig(flags)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Configuration error")
 	}
@@ -48,34 +93,163 @@ func mustLoadConfig(logger zerolog.Logger) *config.Config {
 		Int("port", cfg.GetPort()).
 		Int64("app_id", cfg.GetAppID()).
 		Bool("webhook_secret_set", cfg.GetWebhookSecret() != "").
-		Bool("private_key_set", cfg.GetPrivateKey() != "").
+		Int("private_keys", len(cfg.GetPrivateKeys())).
+		Int("additional_apps", len(cfg.Github.Apps)).
 		Msg("Configuration loaded")
 	return cfg
 }
 
-func setupServer(cfg *config.Config, logger zerolog.Logger) *http.Server {
-	cc := githubapp.NewClientCreator(
-		cfg.GetAPIURL(),
-		cfg.GetGraphQLURL(),
-		cfg.GetAppID(),
-		[]byte(cfg.GetPrivateKey()),
-		githubapp.WithClientUserAgent("gitguard/"+version),
+// primaryApp returns the App configured under github.
+func primaryApp(cfg *config.Config) config.GitHubApp {
+	return config.GitHubApp{
+		AppID:       cfg.GetAppID(),
+		PrivateKeys: cfg.GetPrivateKeys(),
+		APIURL:      cfg.GetAPIURL(),
+		GraphQLURL:  cfg.GetGraphQLURL(),
+	}
+}
+
+// newClientCreator creates the client creator of the primary App rotating through its keys.
+func newClientCreator(cfg *config.Config, logger zerolog.Logger) *keyring.KeyRing {
+	return newAppClientCreator(cfg, primaryApp(cfg), logger)
+}
+
+// newAppClientCreator creates the client creator of an App rotating through its keys.
+func newAppClientCreator(cfg *config.Config, app config.GitHubApp, logger zerolog.Logger) *keyring.KeyRing {
+	return keyring.New(
+		app.APIURL,
+		app.GraphQLURL,
+		app.AppID,
+		app.Keys(),
+		cfg.Github.ClientCache,
+		logger,
+		clientOptions(cfg)...,
 	)
+}
 
-	secretHandler := &handler.SecretScanHandler{
-		ClientCreator: cc,
+// newGitHubApps creates the client creators of the configured Apps, the primary one first,
+// and checks that each App's keys authenticate.
+func newGitHubApps(cfg *config.Config, logger zerolog.Logger) []githubApp {
+	configured, err := cfg.GetGitHubApps()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Configuration error")
 	}
-	fullRepoHandler := &handler.FullRepoScanHandler{
-		ClientCreator: cc,
+	apps := make([]githubApp, 0, len(configured))
+	for _, app := range configured {
+		appLogger := logger.With().Int64("app_id", app.AppID).Logger()
+		clients := newAppClientCreator(cfg, app, appLogger)
+		verifyPrivateKeys(clients, appLogger)
+		apps = append(apps, githubApp{GitHubApp: app, clients: clients})
+	}
+	return apps
+}
+
+// setupServer creates the webhook server, the scheduler running its deliveries and, when
+// GRPC_PORT is set, the gRPC scanner server. Reloads apply flags again.
+func setupServer(
+	cfg *config.Config, flags *config.Flags, logger zerolog.Logger,
+) (*http.Server, *grpc.Server, *deliveryScheduler) {
+	apps := newGitHubApps(cfg, logger)
+	cc := apps[0].clients
+
+	svc := &services{
+		clientCreator: cc,
+		apps:          apps,
+		scheduler:     newScheduler(cfg),
+		jobs:          jobs.NewRegistry(jobs.DefaultCapacity),
+		contentCache:  newContentCache(cfg, logger),
+		scanCache:     newScanCache(cfg, logger),
+		alerts:        newAlertManager(cfg),
+	}
+	svc.baselines, svc.findings = newStores(cfg, logger)
+	checker := newSelfCheck(cfg, cc)
+	if cfg.Server.ReadinessDependencies {
+		checker.Dependencies = readinessDependencies(cfg, svc, logger)
+		checker.DependencyTimeout = cfg.Server.ReadinessTimeout
+	}
+	runSelfCheck(checker, logger)
+	if cfg.GitLabEnabled() {
+		provider, err := gitlab.New(cfg.GitLab.URL, cfg.GitLab.Token, cfg.GitLab.WebhookSecret)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Configuration error")
+		}
+		svc.gitlab = provider
 	}
-	dispatcher := githubapp.NewEventDispatcher(
-		[]githubapp.EventHandler{secretHandler, fullRepoHandler},
-		cfg.GetWebhookSecret(),
-	)
+
+	built, err := buildScanners(cfg, svc, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Configuration error")
+	}
+	warmDetector(built.detectors, logger)
+
+	secrets := make(map[int64]string, len(apps))
+	for _, app := range apps {
+		secrets[app.AppID] = app.WebhookSecret
+	}
+	webhook := &webhookHandler{primary: cfg.GetAppID(), deliveries: newDeliveryScheduler(svc.scheduler)}
+	webhook.update(built.github, secrets)
+	startSecretRefresh(cfg, cc, webhook, logger)
+	startReports(cfg, cc, svc.findings, logger)
+
+	webhookChain := middleware.MaxBodySize(cfg.Server.MaxPayloadBytes)(withRecording(webhook, cfg, logger))
+
+	reload := &reloader{
+		running:  cfg,
+		flags:    flags,
+		notifier: built.notifier,
+		svc:      svc,
+		webhook:  webhook,
+		logger:   logger,
+	}
+	reload.grpc = grpcserver.NewService(built.grpc)
 
 	mux := http.NewServeMux()
-	mux.Handle("/", dispatcher)
-	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+	mux.Handle(exactPattern(cfg.GetWebhookPath()), withHookAllowlist(webhookChain, cfg, logger))
+	if built.gitlab != nil {
+		reload.gitlab = &swapHandler{}
+		reload.gitlab.set(built.gitlab)
+		mux.Handle(exactPattern(cfg.GetGitLabWebhookPath()), middleware.MaxBodySize(cfg.Server.MaxPayloadBytes)(reload.gitlab))
+		logger.Info().Str("path", cfg.GetGitLabWebhookPath()).Msg("GitLab webhook enabled")
+	}
+	mux.Handle("/", http.NotFoundHandler())
+	if built.badges != nil {
+		reload.badges = &swapHandler{}
+		reload.badges.set(built.badges)
+		mux.Handle("GET "+cfg.Route("/badge/{owner}/{name}"), reload.badges)
+	}
+	mux.Handle(exactPattern(cfg.Route("/readyz")), checker)
+	mux.Handle(exactPattern(cfg.Route("/version")), versionHandler(reload.current, logger))
+	adminAuth, err := cfg.GetAdminAuth()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Configuration error")
+	}
+	if adminAuth.Enabled() {
+		admin := auth.New(adminAuth, nil, logger)
+		viewer, operator := admin.Require(auth.RoleViewer), admin.Require(auth.RoleAdmin)
+
+		mux.Handle(exactPattern(cfg.Route("/admin/config")), operator(configHandler(reload.current, logger)))
+		mux.Handle(exactPattern(cfg.Route("/admin/queue")), viewer(ratelimit.Handler(svc.scheduler, logger)))
+		if svc.findings != nil {
+			mux.Handle(exactPattern(cfg.Route("/admin/metrics")), viewer(metrics.Handler(svc.findings, logger)))
+			reload.export = &swapHandler{}
+			reload.export.set(built.export)
+			mux.Handle("GET "+cfg.Route("/api/v1/findings/export"), viewer(reload.export))
+		}
+		reload.orgScans = &orgScans{logger: logger}
+		reload.orgScans.scanner.Store(built.fullScan)
+		mux.Handle(exactPattern(cfg.Route("/admin/scan-org")),
+			admin.RequireByMethod(auth.RoleViewer, auth.RoleAdmin)(reload.orgScans))
+
+		reload.scanJobs = &scanJobs{jobs: svc.jobs, route: cfg.Route, logger: logger}
+		reload.scanJobs.scanner.Store(built.fullScan)
+		mux.Handle("POST "+cfg.Route("/api/v1/repos/{owner}/{repo}/scans"),
+			operator(http.HandlerFunc(reload.scanJobs.create)))
+		mux.Handle("GET "+cfg.Route("/api/v1/scans/{id}"), viewer(http.HandlerFunc(reload.scanJobs.get)))
+		mux.Handle("POST "+cfg.Route("/api/v1/scan"), viewer(reload.grpc))
+		schema := &graphql.Schema{Findings: svc.findings, Scans: svc.jobs}
+		mux.Handle(exactPattern(cfg.Route("/api/graphql")), viewer(graphql.Handler(schema, logger)))
+	}
+	mux.HandleFunc(exactPattern(cfg.Route("/health")), func(w http.ResponseWriter, _ *http.Request) {
 		logger.Debug().Msg("Health check requested")
 		w.WriteHeader(http.StatusOK)
 		if _, err := w.Write([]byte("OK")); err != nil {
@@ -85,17 +259,362 @@ func setupServer(cfg *config.Config, logger zerolog.Logger) *http.Server {
 
 	server := &http.Server{
 		Addr:           fmt.Sprintf(":%d", cfg.GetPort()),
-		Handler:        mux,
+		Handler:        middleware.RequestID(logger)(mux),
 		ReadTimeout:    30 * time.Second,
 		WriteTimeout:   60 * time.Second,
 		IdleTimeout:    120 * time.Second,
 		MaxHeaderBytes: 1 << 20,
 	}
-	return server
+
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Port > 0 {
+		grpcServer = grpcserver.New(reload.grpc, cfg.GRPC.AuthToken)
+	}
+	reload.start()
+	return server, grpcServer, webhook.deliveries
+}
+
+// clientOptions returns the options applied to every GitHub client.
+func clientOptions(cfg *config.Config) []githubapp.ClientOption {
+	opts := []githubapp.ClientOption{
+		githubapp.WithClientUserAgent("gitguard/" + version),
+	}
+	if size := cfg.Github.HTTPCache; size > 0 {
+		// Each client gets its own bounded cache; GitHub answers revalidated ETags with 304,
+		// which does not count against the rate limit.
+		opts = append(opts, githubapp.WithClientCaching(false, func() httpcache.Cache {
+			httpCache, err := cache.NewHTTPCache(size)
+			if err != nil {
+				return httpcache.NewMemoryCache()
+			}
+			return httpCache
+		}))
+	}
+	return opts
+}
+
+// newScheduler creates the scheduler sharing scan slots and rate limit budgets between
+// installations.
+func newScheduler(cfg *config.Config) *ratelimit.Scheduler {
+	scheduler := ratelimit.NewScheduler(cfg.RateLimit.Concurrency, cfg.RateLimit.MaxShare, cfg.RateLimit.Reserve)
+	scheduler.SetMaxQueued(cfg.RateLimit.MaxQueued)
+	return scheduler
+}
+
+// newContentCache creates the file content cache, or nil when disabled.
+func newContentCache(cfg *config.Config, logger zerolog.Logger) *cache.ContentCache {
+	if cfg.Github.ContentCache <= 0 {
+		return nil
+	}
+	contentCache, err := cache.NewContentCache(cfg.Github.ContentCache, int64(cfg.Github.ContentCacheMB)<<20)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Content cache disabled")
+		return nil
+	}
+	return contentCache
+}
+
+// newScanCache creates the commit scan result cache, or nil when disabled.
+func newScanCache(cfg *config.Config, logger zerolog.Logger) *cache.ScanCache {
+	if cfg.ScanCache.Size <= 0 && cfg.ScanCache.RedisURL == "" {
+		return nil
+	}
+	scanCache, err := cache.NewScanCache(cfg.ScanCache.Size, cfg.ScanCache.RedisURL, cfg.ScanCache.TTL)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Configuration error")
+	}
+	return scanCache
+}
+
+// newNotifier returns the notifier for scan events, or nil when none is configured.
+func newNotifier(cfg *config.Config) (notify.Notifier, error) {
+	var notifiers notify.Multi
+	for _, url := range cfg.Notify.WebhookURLs {
+		notifiers = append(notifiers, notify.NewWebhook(url, cfg.Notify.WebhookSecret))
+	}
+
+	if len(cfg.Notify.Chat) > 0 {
+		router, err := newChatRouter(cfg.Notify.Chat)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, router)
+	}
+
+	var sinks []siem.Sink
+	if cfg.SIEM.SyslogAddress != "" {
+		sink, err := siem.NewSyslog(cfg.SIEM.SyslogAddress)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.SIEM.SplunkHECURL != "" {
+		sinks = append(sinks, siem.NewSplunk(cfg.SIEM.SplunkHECURL, cfg.SIEM.SplunkHECToken))
+	}
+	for _, sink := range sinks {
+		exporter, err := siem.NewExporter(cfg.SIEM.Format, version, sink)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, exporter)
+	}
+
+	if cfg.EventBus.Backend != "" {
+		publisher, err := bus.NewPublisher(cfg.EventBus.Backend, bus.Options{
+			URL:   cfg.EventBus.URL,
+			Topic: cfg.EventBus.Topic,
+		})
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, bus.NewNotifier(publisher))
+	}
+
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	return notifiers, nil
+}
+
+// newChatRouter routes chat notifications to the webhooks configured for each installation.
+func newChatRouter(webhooks []config.ChatWebhook) (*notify.Router, error) {
+	var defaults notify.Multi
+	byInstallation := make(map[int64]notify.Multi)
+	for _, webhook := range webhooks {
+		chat, err := notify.NewChat(webhook.Format, webhook.URL)
+		if err != nil {
+			return nil, err
+		}
+		if webhook.Installation == 0 {
+			defaults = append(defaults, chat)
+			continue
+		}
+		byInstallation[webhook.Installation] = append(byInstallation[webhook.Installation], chat)
+	}
+
+	router := &notify.Router{ByInstallation: make(map[int64]notify.Notifier, len(byInstallation))}
+	if len(defaults) > 0 {
+		router.Default = defaults
+	}
+	for installation, chats := range byInstallation {
+		router.ByInstallation[installation] = chats
+	}
+	return router, nil
+}
+
+// newAlertManager returns the on-call alert manager, or nil when no alerting is configured.
+func newAlertManager(cfg *config.Config) *notify.AlertManager {
+	var alerters notify.MultiAlerter
+	if cfg.Alerts.PagerDutyRoutingKey != "" {
+		alerters = append(alerters, notify.NewPagerDuty(cfg.Alerts.PagerDutyRoutingKey))
+	}
+	if cfg.Alerts.OpsgenieAPIKey != "" {
+		alerters = append(alerters, notify.NewOpsgenie(cfg.Alerts.OpsgenieAPIKey, cfg.Alerts.OpsgenieAPIURL))
+	}
+	if len(alerters) == 0 {
+		return nil
+	}
+	return notify.NewAlertManager(alerters)
+}
+
+// newTicketer returns the ticketing system rotation tickets are filed in, or nil to file them
+// as repository issues.
+func newTicketer(cfg *config.Config) notify.Ticketer {
+	if cfg.Tickets.Rotation != constants.TicketsJira {
+		return nil
+	}
+	jira := cfg.Tickets.Jira
+	return notify.NewJira(jira.URL, jira.User, jira.Token, jira.Project, jira.IssueType)
+}
+
+// newStores opens the store and returns it for each enabled feature: repository baselines
+// and finding lifecycle tracking. Disabled features get nil.
+func newStores(cfg *config.Config, logger zerolog.Logger) (store.Store, store.FindingStore) {
+	if !cfg.Baseline.Enabled && !cfg.Findings.Tracking {
+		return nil, nil
+	}
+	st, err := store.Open(cfg.Store.Path)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Configuration error")
+	}
+
+	var baselines store.Store
+	var findings store.FindingStore
+	if cfg.Baseline.Enabled {
+		baselines = st
+	}
+	if cfg.Findings.Tracking {
+		findings = st
+	}
+	return baselines, findings
+}
+
+// startReports publishes the configured organization reports in the background.
+func startReports(cfg *config.Config, cc githubapp.ClientCreator, findings store.FindingStore, logger zerolog.Logger) {
+	if cfg.Reports.Repository == "" || findings == nil {
+		return
+	}
+	classifier, err := cfg.GetSeverityClassifier()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Configuration error")
+	}
+	sla, err := cfg.GetSLA()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Configuration error")
+	}
+	publisher := &orgreport.Publisher{
+		Clients:       cc,
+		Findings:      findings,
+		Classifier:    classifier,
+		SLA:           sla,
+		Repository:    cfg.Reports.Repository,
+		Organizations: cfg.Reports.Organizations,
+		Interval:      cfg.Reports.Interval,
+	}
+	publisher.Start(context.Background(), logger.With().Str("component", "reports").Logger())
+	logger.Info().
+		Str("repository", cfg.Reports.Repository).
+		Strs("organizations", cfg.Reports.Organizations).
+		Msg("Organization reports enabled")
+}
+
+// newPlugins loads the detector plugins compiled into the binary and those configured.
+func newPlugins(cfg *config.Config, logger zerolog.Logger) (plugin.Set, error) {
+	specs, err := cfg.GetDetectorPlugins()
+	if err != nil {
+		return nil, err
+	}
+	return plugin.Load(specs, logger)
+}
+
+// warmDetector builds the detector at startup so rule packs are downloaded before the first
+// delivery arrives.
+func warmDetector(detectors *detector.Factory, logger zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := detectors.Detector(ctx); err != nil {
+		logger.Error().Err(err).Msg("Failed to build secret detector")
+	}
+}
+
+// verifyPrivateKeys checks that at least one configured key authenticates as the App.
+func verifyPrivateKeys(ring *keyring.KeyRing, logger zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if err := ring.Verify(ctx); err != nil {
+		logger.Error().Err(err).Msg(constants.LogMsgPrivateKeyCheck)
+	}
+}
+
+// newSelfCheck returns the checker verifying installation permissions, including those
+// needed for remediation pull requests and commit comments when enabled. Checks are not
+// required when commit scans are only reported as comments and full scans are disabled.
+func newSelfCheck(cfg *config.Config, cc *keyring.KeyRing) *selfcheck.Checker {
+	required := maps.Clone(selfcheck.RequiredPermissions)
+	if cfg.Remediation.PullRequests {
+		required["contents"] = selfcheck.Write
+		required["pull_requests"] = selfcheck.Write
+	}
+	if cfg.Push.Reporting != constants.ReportingChecks || cfg.Dispatch.Enabled || len(cfg.Dispatch.Repositories) > 0 {
+		required["contents"] = selfcheck.Write
+	}
+	if cfg.PullRequests.Gate && required["pull_requests"] != selfcheck.Write {
+		required["pull_requests"] = selfcheck.Read
+	}
+	if cfg.FullScan.NativeAlerts {
+		required["secret_scanning_alerts"] = selfcheck.Read
+	}
+	if cfg.Push.Reporting == constants.ReportingComments && !cfg.FullScan.Enabled {
+		delete(required, "checks")
+	}
+	return &selfcheck.Checker{Clients: cc, Required: required, TTL: time.Minute}
+}
+
+// readinessDependencies returns the configured backends readiness probes ping: the scan
+// cache's Redis, the store and the event bus.
+func readinessDependencies(cfg *config.Config, svc *services, logger zerolog.Logger) []selfcheck.Dependency {
+	var dependencies []selfcheck.Dependency
+	if cfg.ScanCache.RedisURL != "" && svc.scanCache != nil {
+		dependencies = append(dependencies, selfcheck.Dependency{Name: "scan_cache", Pinger: svc.scanCache})
+	}
+	for _, st := range []any{svc.findings, svc.baselines} {
+		if pinger, ok := st.(selfcheck.Pinger); ok {
+			dependencies = append(dependencies, selfcheck.Dependency{Name: "store", Pinger: pinger})
+			break
+		}
+	}
+	if cfg.EventBus.Backend != "" {
+		publisher, err := bus.NewPublisher(cfg.EventBus.Backend, bus.Options{
+			URL:   cfg.EventBus.URL,
+			Topic: cfg.EventBus.Topic,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Configuration error")
+		}
+		dependencies = append(dependencies, selfcheck.Dependency{Name: "event_bus", Pinger: publisher})
+	}
+	return dependencies
+}
+
+// runSelfCheck logs App authentication failures and, for each installation, the permissions
+// it is missing.
+func runSelfCheck(checker *selfcheck.Checker, logger zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	report, err := checker.Cached(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg(constants.LogMsgSelfCheckFailed)
+		return
+	}
+	for _, problem := range report.Problems {
+		logger.Error().
+			Int64("installation_id", problem.InstallationID).
+			Str("account", problem.Account).
+			Str("problem", problem.String()).
+			Msg(constants.LogMsgInstallationMisconfigured)
+	}
+	logger.Info().
+		Str("app_slug", report.App).
+		Int("installations", report.Installations).
+		Int("problems", len(report.Problems)).
+		Msg(constants.LogMsgSelfCheckCompleted)
+}
+
+// exactPattern returns a ServeMux pattern matching only the given path, so unknown
+// paths fall through to the 404 handler instead of reaching the webhook dispatcher.
+func exactPattern(route string) string {
+	if strings.HasSuffix(route, "/") {
+		return route + "{$}"
+	}
+	return route
 }
 
-func runServer(server *http.Server, cfg *config.Config, logger zerolog.Logger) {
+// withHookAllowlist restricts webhook deliveries to GitHub's hook IP ranges when enabled.
+func withHookAllowlist(next http.Handler, cfg *config.Config, logger zerolog.Logger) http.Handler {
+	if !cfg.Server.HookIPAllowlist {
+		return next
+	}
+
+	allowlist, err := middleware.NewHookAllowlist(
+		cfg.GetAPIURL(), cfg.Server.TrustedProxies, cfg.Server.HookRangesRefresh, logger,
+	)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid webhook IP allowlist configuration")
+	}
+	allowlist.Start(context.Background())
+	return allowlist.Middleware(next)
+}
+
+func runServer(
+	server *http.Server, grpcServer *grpc.Server, deliveries *deliveryScheduler, cfg *config.Config,
+	logger zerolog.Logger,
+) {
 	logger.Info().Int("port", cfg.GetPort()).Msg("GitGuard server starting")
+	startGRPCServer(grpcServer, cfg, logger)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -114,11 +633,35 @@ func runServer(server *http.Server, cfg *config.Config, logger zerolog.Logger) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Error().Err(err).Msg("Server shutdown failed")
 	} else {
 		logger.Info().Msg("Server shut down gracefully")
 	}
+	if err := deliveries.wait(ctx); err != nil {
+		logger.Error().Err(err).Msg("Webhook deliveries still running at shutdown were abandoned")
+	}
 
 	<-done
 }
+
+// startGRPCServer serves the gRPC scanner API in the background.
+func startGRPCServer(grpcServer *grpc.Server, cfg *config.Config, logger zerolog.Logger) {
+	if grpcServer == nil {
+		return
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("gRPC server failed")
+	}
+	logger.Info().Int("port", cfg.GRPC.Port).Msg("gRPC server starting")
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			logger.Fatal().Err(err).Msg("gRPC server failed")
+		}
+	}()
+}
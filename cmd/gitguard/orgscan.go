@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/auth"
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/rs/zerolog"
+)
+
+// runScanOrg runs a full scan of every repository of an organization, printing each
+// repository as it completes and a summary at the end.
+func runScanOrg(args []string, logger zerolog.Logger) error {
+	flags := flag.NewFlagSet("scan-org", flag.ContinueOnError)
+	concurrency := flags.Int("concurrency", handler.DefaultOrgScanConcurrency, "repositories scanned at once")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one organization, got %d arguments", flags.NArg())
+	}
+
+	cfg := mustLoadConfig(nil, logger)
+	built, err := buildScanners(cfg, newCommandServices(cfg, logger), logger)
+	if err != nil {
+		return err
+	}
+
+	summary, err := built.fullScan.ScanOrganization(
+		context.Background(), flags.Arg(0), *concurrency,
+		func(result handler.OrgScanResult, done, total int) {
+			status := fmt.Sprintf("%d finding(s)", result.Findings)
+			if result.Error != "" {
+				status = "failed: " + result.Error
+			}
+			fmt.Printf("[%d/%d] %s: %s\n", done, total, result.Repository, status)
+		},
+		logger,
+	)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nScanned %d of %d repositories in %s (%d out of scope, %d failed): %d finding(s)\n",
+		summary.Scanned, summary.Repositories, summary.Organization, summary.Skipped, summary.Failed, summary.Findings)
+	return nil
+}
+
+// newCommandServices creates the services an administrative command scanning through the
+// GitHub App needs. Unlike the server's, they are not shared with other goroutines. Commands
+// only run as the primary App.
+func newCommandServices(cfg *config.Config, logger zerolog.Logger) *services {
+	cc := newClientCreator(cfg, logger)
+	svc := &services{
+		clientCreator: cc,
+		scheduler:     newScheduler(cfg),
+		alerts:        newAlertManager(cfg),
+		apps:          []githubApp{{GitHubApp: primaryApp(cfg), clients: cc}},
+	}
+	svc.baselines, svc.findings = newStores(cfg, logger)
+	return svc
+}
+
+// orgScanRequest is the body of POST /admin/scan-org.
+type orgScanRequest struct {
+	Organization string `json:"organization"`
+	Concurrency  int    `json:"concurrency"`
+}
+
+// orgScanStatus is the progress of an organization scan started through the admin API.
+type orgScanStatus struct {
+	Running    bool      `json:"running"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Done       int       `json:"done"`
+	Error      string    `json:"error,omitempty"`
+	handler.OrgScanSummary
+}
+
+// orgScans serves the admin API running organization scans in the background, one at a
+// time per organization, with the full scan handler of the current configuration.
+type orgScans struct {
+	scanner atomic.Pointer[handler.FullRepoScanHandler]
+	logger  zerolog.Logger
+
+	mu    sync.Mutex
+	scans map[string]*orgScanStatus
+}
+
+// ServeHTTP starts a scan on POST and reports the latest scan of the "organization" query
+// parameter on GET.
+func (o *orgScans) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req orgScanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Organization == "" {
+			http.Error(w, "expected a JSON body with an organization", http.StatusBadRequest)
+			return
+		}
+		identity, _ := auth.FromContext(r.Context())
+		o.logger.Info().
+			Str("organization", req.Organization).
+			Str("requested_by", identity.Subject).
+			Msg("Organization scan requested")
+		status, started := o.start(req)
+		code := http.StatusAccepted
+		if !started {
+			code = http.StatusConflict
+		}
+		o.write(w, code, status)
+	case http.MethodGet:
+		status := o.status(r.URL.Query().Get("organization"))
+		if status == nil {
+			http.Error(w, "no scan of this organization", http.StatusNotFound)
+			return
+		}
+		o.write(w, http.StatusOK, status)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// start runs the requested scan in the background unless one of the organization is running.
+// It returns the status of the organization's scan and whether it was started.
+func (o *orgScans) start(req orgScanRequest) (orgScanStatus, bool) {
+	key := strings.ToLower(req.Organization)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if current := o.scans[key]; current != nil && current.Running {
+		return *current, false
+	}
+	status := &orgScanStatus{
+		Running:        true,
+		StartedAt:      time.Now().UTC(),
+		OrgScanSummary: handler.OrgScanSummary{Organization: req.Organization},
+	}
+	if o.scans == nil {
+		o.scans = make(map[string]*orgScanStatus)
+	}
+	o.scans[key] = status
+
+	go func() {
+		summary, err := o.scanner.Load().ScanOrganization(
+			context.Background(), req.Organization, req.Concurrency,
+			func(result handler.OrgScanResult, done, total int) {
+				o.mu.Lock()
+				defer o.mu.Unlock()
+				status.Done, status.Repositories = done, total
+				status.Results = append(status.Results, result)
+				if result.Error != "" {
+					status.Failed++
+				} else {
+					status.Scanned++
+					status.Findings += result.Findings
+				}
+			},
+			o.logger,
+		)
+
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		status.Running = false
+		status.FinishedAt = time.Now().UTC()
+		if err != nil {
+			status.Error = err.Error()
+			return
+		}
+		status.OrgScanSummary = summary
+	}()
+	return *status, true
+}
+
+// status returns a copy of the latest scan status of an organization, or nil.
+func (o *orgScans) status(organization string) *orgScanStatus {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	current := o.scans[strings.ToLower(organization)]
+	if current == nil {
+		return nil
+	}
+	status := *current
+	status.Results = append([]handler.OrgScanResult(nil), current.Results...)
+	return &status
+}
+
+func (o *orgScans) write(w http.ResponseWriter, code int, status any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		o.logger.Error().Err(err).Msg("Failed to write organization scan response")
+	}
+}
@@ -0,0 +1,6 @@
+package main
+
+// Detectors compiled into GitGuard register themselves with plugin.Register from an init
+// function. Add a blank import of each detector package here, e.g.
+//
+//	import _ "github.com/acme/gitguard-detectors/internal"
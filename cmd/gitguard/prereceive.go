@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/prereceive"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/logging"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// errPushRejected makes the pre-receive and pre-push hooks exit non-zero without logging a
+// failure.
+var errPushRejected = errors.New("push rejected")
+
+// pushHook reads the ref updates of a push from a hook's standard input and scans the
+// commits they add.
+type pushHook struct {
+	parse func(io.Reader) ([]prereceive.Update, error)
+	scan  func(context.Context, config.Config, string, []prereceive.Update) ([]report.Finding, error)
+}
+
+var (
+	// preReceiveHook scans the commits a server receives that no ref has yet.
+	preReceiveHook = pushHook{parse: prereceive.ParseUpdates, scan: prereceive.Scan}
+	// prePushHook scans the commits a developer pushes that no remote-tracking ref has yet.
+	prePushHook = pushHook{parse: prereceive.ParsePushUpdates, scan: prereceive.ScanPush}
+)
+
+// check scans the commits of a push, read from the hook's standard input, and rejects
+// the push when they introduce secrets. Findings the severity policy concludes neutral are
+// reported without rejecting the push.
+func (h pushHook) check(stdin io.Reader, out io.Writer, logger zerolog.Logger) error {
+	scan, err := newLocalScan(logger)
+	if err != nil {
+		return err
+	}
+
+	updates, err := h.parse(stdin)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.FullScanTimeout)
+	defer cancel()
+
+	rules, err := scan.detectors.Config(ctx)
+	if err != nil {
+		return err
+	}
+	// The gitleaks progress logs would be relayed to the pusher.
+	logging.Logger = logging.Logger.Level(zerolog.WarnLevel)
+
+	findings, err := h.scan(ctx, rules, ".", updates)
+	if err != nil {
+		return err
+	}
+	findings = scan.overrides.Filter(findings)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	rejected := scan.blocks(findings)
+	printPreReceiveFindings(out, findings, scan.classifier, rejected)
+	if rejected {
+		return errPushRejected
+	}
+	return nil
+}
+
+// printPreReceiveFindings tells the pusher where the secrets are, without the secrets.
+func printPreReceiveFindings(out io.Writer, findings []report.Finding, classifier *severity.Classifier, rejected bool) {
+	if rejected {
+		fmt.Fprintf(out, "GitGuard: push rejected, found %d potential secret(s):\n", len(findings))
+	} else {
+		fmt.Fprintf(out, "GitGuard: found %d potential secret(s) below the blocking severity:\n", len(findings))
+	}
+	for _, finding := range findings {
+		commit := finding.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		fmt.Fprintf(out, "  %s %s:%d %s (%s)\n", commit, finding.File, finding.StartLine, finding.RuleID, classifier.Classify(finding))
+	}
+	if rejected {
+		fmt.Fprintln(out, "Remove the secrets from these commits, rotate them, and push again.")
+	}
+}
+
+// run runs the hook mode and exits with its status. Scan failures reject the push too, so an
+// unscanned push never gets through.
+func (h pushHook) run(logger zerolog.Logger) {
+	err := h.check(os.Stdin, os.Stderr, logger)
+	switch {
+	case errors.Is(err, errPushRejected):
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "GitGuard: scan failed: %v\n", err)
+		os.Exit(1)
+	}
+}
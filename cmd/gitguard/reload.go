@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/omercnet/gitguard/internal/badge"
+	"github.com/omercnet/gitguard/internal/cache"
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/export"
+	"github.com/omercnet/gitguard/internal/grpcserver"
+	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/omercnet/gitguard/internal/keyring"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/scm"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+)
+
+// reloadDebounce groups the bursts of file events editors and config map updates produce.
+const reloadDebounce = time.Second
+
+// services are the components built once per process and shared by every configuration
+// generation: GitHub clients, the scan scheduler, caches, stores and the GitLab provider.
+type services struct {
+	// clientCreator authenticates as the primary App, which also runs organization and API
+	// scans.
+	clientCreator *keyring.KeyRing
+	contentCache  *cache.ContentCache
+	scanCache     *cache.ScanCache
+	baselines     store.Store
+	findings      store.FindingStore
+	gitlab        scm.Provider
+	scheduler     *ratelimit.Scheduler
+	// jobs records the server's scans so their status can be polled.
+	jobs *jobs.Registry
+	// alerts keeps the open incidents, so it is only replaced when alerting changes.
+	alerts *notify.AlertManager
+	// apps are the configured Apps, the primary one first.
+	apps []githubApp
+}
+
+// githubApp is a GitHub App identity and the client creator authenticating as it.
+type githubApp struct {
+	config.GitHubApp
+	clients *keyring.KeyRing
+}
+
+// scanners are the handlers built from the reloadable configuration.
+type scanners struct {
+	detectors *detector.Factory
+	// github are the webhook event handlers of each App by App ID.
+	github map[int64][]githubapp.EventHandler
+	// fullScan is the primary App's full scan handler. It also runs organization and API
+	// scans, so it is built even when push full scans are disabled.
+	fullScan *handler.FullRepoScanHandler
+	gitlab   *handler.ProviderScanHandler
+	grpc     *grpcserver.Server
+	// badges serves repository status badges; nil without a findings store.
+	badges http.Handler
+	// export serves the findings export; nil without a findings store.
+	export http.Handler
+	// notifier is shared by the handlers; closed with closeNotifier once they are replaced.
+	notifier notify.Notifier
+}
+
+// buildScanners builds the handlers for a configuration.
+func buildScanners(cfg *config.Config, svc *services, logger zerolog.Logger) (*scanners, error) {
+	classifier, err := cfg.GetSeverityClassifier()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := cfg.GetCheckPolicy()
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := cfg.GetPathOverrides()
+	if err != nil {
+		return nil, err
+	}
+	detectorOpts, err := cfg.GetDetectorOptions()
+	if err != nil {
+		return nil, err
+	}
+	scope, err := cfg.GetRepositoryScope()
+	if err != nil {
+		return nil, err
+	}
+	headOnly, err := cfg.GetHeadOnlyThreshold()
+	if err != nil {
+		return nil, err
+	}
+	personal, err := cfg.GetPIISetting()
+	if err != nil {
+		return nil, err
+	}
+	names, err := cfg.GetFilenameRules()
+	if err != nil {
+		return nil, err
+	}
+	decisions, err := cfg.GetPolicyRules()
+	if err != nil {
+		return nil, err
+	}
+	dryRun, err := cfg.GetDryRunSetting()
+	if err != nil {
+		return nil, err
+	}
+	enforcement, err := cfg.GetEnforcementRollout()
+	if err != nil {
+		return nil, err
+	}
+	issueLabels, err := cfg.GetIssueLabels()
+	if err != nil {
+		return nil, err
+	}
+	sla, err := cfg.GetSLA()
+	if err != nil {
+		return nil, err
+	}
+	catalogs, err := cfg.GetMessages()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := cfg.GetTemplates()
+	if err != nil {
+		return nil, err
+	}
+	badges, err := cfg.GetBadgeSetting()
+	if err != nil {
+		return nil, err
+	}
+	dispatch, err := cfg.GetDispatchSetting()
+	if err != nil {
+		return nil, err
+	}
+	plugins, err := newPlugins(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	detectors := detector.NewFactory(detectorOpts, logger)
+
+	built := &scanners{
+		detectors: detectors,
+		github:    make(map[int64][]githubapp.EventHandler, len(svc.apps)),
+		notifier:  notifier,
+		grpc: &grpcserver.Server{
+			Detectors:  detectors,
+			Plugins:    plugins,
+			Severity:   classifier,
+			Overrides:  overrides,
+			Scheduler:  svc.scheduler,
+			MaxCloneMB: cfg.FullScan.MaxSizeMB,
+			Logger:     logger,
+		},
+	}
+	if svc.findings != nil {
+		built.badges = badge.Handler(svc.findings, badges, classifier, logger)
+		built.export = export.Handler(svc.findings, version, classifier, logger)
+	}
+	for _, app := range svc.apps {
+		clients := svc.scheduler.Clients(app.clients)
+		var handlers []githubapp.EventHandler
+		commitScan := &handler.SecretScanHandler{
+			ClientCreator:     clients,
+			Detectors:         detectors,
+			Plugins:           plugins,
+			Filenames:         names,
+			ContentCache:      svc.contentCache,
+			Severity:          classifier,
+			Policy:            policy,
+			Overrides:         overrides,
+			Baseline:          svc.baselines,
+			Findings:          svc.findings,
+			NewWithin:         cfg.GetNewFindingWindow(),
+			Deduplicate:       cfg.Findings.Deduplicate,
+			Notifier:          notifier,
+			Dispatch:          dispatch,
+			Scope:             scope,
+			HeadOnlyThreshold: headOnly,
+			ScanCache:         svc.scanCache,
+			RulesVersion:      scanVersion(cfg),
+			DetailsURL:        cfg.Checks.DetailsURL,
+			PII:               personal,
+			PolicyRules:       decisions,
+			DryRun:            dryRun,
+			Enforcement:       enforcement,
+			Messages:          catalogs,
+			Templates:         templates,
+			Redaction:         cfg.GetRedaction(),
+			Reporting:         cfg.Push.Reporting,
+			MaxFileBytes:      cfg.Push.MaxFileBytes,
+			SummaryCheck:      cfg.Push.SummaryCheck,
+			SummaryOnly:       cfg.Push.SummaryCheck && !cfg.Push.CommitChecks,
+			RotationTickets:   cfg.Tickets.Rotation != "",
+			Scheduler:         svc.scheduler,
+			Jobs:              svc.jobs,
+		}
+		if cfg.Push.CommitScans {
+			handlers = append(handlers, commitScan)
+		}
+		if cfg.PullRequests.Gate {
+			handlers = append(handlers, &handler.PullRequestGateHandler{Scanner: commitScan})
+		}
+		if cfg.Push.CommitScans && cfg.Tickets.Rotation != "" {
+			handlers = append(handlers, &handler.RotationTicketHandler{
+				ClientCreator: clients,
+				Tickets:       newTicketer(cfg),
+				Messages:      catalogs,
+			})
+		}
+		fullScan := &handler.FullRepoScanHandler{
+			ClientCreator: clients,
+			Detectors:     detectors,
+			Plugins:       plugins,
+			Filenames:     names,
+			Remediation:   cfg.Remediation.PullRequests,
+			IssueGrouping: cfg.FullScan.Issues,
+			Labels:        issueLabels,
+			SLA:           sla,
+			SLAMilestones: cfg.SLA.Milestones,
+			NativeAlerts:  cfg.FullScan.NativeAlerts,
+			Severity:      classifier,
+			Policy:        policy,
+			Overrides:     overrides,
+			Baseline:      svc.baselines,
+			Findings:      svc.findings,
+			NewWithin:     cfg.GetNewFindingWindow(),
+			Deduplicate:   cfg.Findings.Deduplicate,
+			Notifier:      notifier,
+			Dispatch:      dispatch,
+			Alerts:        svc.alerts,
+			Scope:         scope,
+			LFSMaxBytes:   cfg.FullScan.LFSMaxBytes,
+			ScanCache:     svc.scanCache,
+			RulesVersion:  scanVersion(cfg),
+			Limits:        handler.ScanLimits{SizeMB: cfg.FullScan.MaxSizeMB, Files: cfg.FullScan.MaxFiles},
+			Clone: handler.CloneOptions{
+				BaseURL:  cfg.GetAppCloneBaseURL(app.APIURL),
+				Rewrites: cfg.GetCloneRewrites(),
+				ProxyURL: cfg.FullScan.Clone.ProxyURL,
+			},
+			DetailsURL:  cfg.Checks.DetailsURL,
+			PolicyRules: decisions,
+			DryRun:      dryRun,
+			Enforcement: enforcement,
+			Messages:    catalogs,
+			Templates:   templates,
+			Redaction:   cfg.GetRedaction(),
+			Scheduler:   svc.scheduler,
+			Jobs:        svc.jobs,
+		}
+		if cfg.FullScan.Enabled {
+			handlers = append(handlers, fullScan)
+		}
+		if built.fullScan == nil {
+			built.fullScan = fullScan
+		}
+		built.github[app.AppID] = handlers
+	}
+	if svc.gitlab != nil {
+		built.gitlab = &handler.ProviderScanHandler{
+			Provider:    svc.gitlab,
+			Detectors:   detectors,
+			Plugins:     plugins,
+			Filenames:   names,
+			Severity:    classifier,
+			Policy:      policy,
+			Overrides:   overrides,
+			Baseline:    svc.baselines,
+			Findings:    svc.findings,
+			Deduplicate: cfg.Findings.Deduplicate,
+			Notifier:    notifier,
+			Scope:       scope,
+			PolicyRules: decisions,
+			DryRun:      dryRun,
+			Enforcement: enforcement,
+			Messages:    catalogs,
+			Templates:   templates,
+			Redaction:   cfg.GetRedaction(),
+			Logger:      logger,
+		}
+	}
+	return built, nil
+}
+
+// reloader applies configuration changes on SIGHUP or when the config file changes. Sections
+// that need a restart keep their running values.
+type reloader struct {
+	mu      sync.Mutex
+	running *config.Config
+	// flags are the command-line flags every reload applies.
+	flags *config.Flags
+	// notifier is the notifier of the running handlers, closed once a reload replaces them.
+	notifier notify.Notifier
+	svc      *services
+	webhook  *webhookHandler
+	gitlab   *swapHandler
+	grpc     *grpcserver.Service
+	badges   *swapHandler
+	export   *swapHandler
+	// orgScans, when the admin API is enabled, runs organization scans with the current
+	// full scan handler.
+	orgScans *orgScans
+	// scanJobs, when the admin API is enabled, runs scans requested through the API.
+	scanJobs *scanJobs
+	logger   zerolog.Logger
+}
+
+// current returns the running configuration.
+func (r *reloader) current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running
+}
+
+// start reloads on SIGHUP and on changes to the config file.
+func (r *reloader) start() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var fileEvents <-chan fsnotify.Event
+	file, err := filepath.Abs(config.FilePath(r.flags))
+	if err == nil {
+		var watcher *fsnotify.Watcher
+		watcher, err = fsnotify.NewWatcher()
+		if err == nil {
+			// Watch the directory: editors and Kubernetes config maps replace the file.
+			err = watcher.Add(filepath.Dir(file))
+			fileEvents = watcher.Events
+		}
+	}
+	if err != nil {
+		r.logger.Warn().Err(err).Msg(constants.LogMsgConfigWatchFailed)
+	}
+
+	go func() {
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-hup:
+				r.reload()
+			case event := <-fileEvents:
+				if filepath.Clean(event.Name) == file || filepath.Base(event.Name) == "..data" {
+					debounce = time.After(reloadDebounce)
+				}
+			case <-debounce:
+				r.reload()
+			}
+		}
+	}()
+}
+
+// reload loads the configuration and swaps in handlers built from it. Invalid
+// configurations, including rule packs that fail to load, leave the running one in place.
+func (r *reloader) reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.LoadConfig(r.flags)
+	if err != nil {
+		r.logger.Error().Err(err).Msg(constants.LogMsgConfigReloadFailed)
+		return
+	}
+	reloaded, restart := config.Changes(r.running, cfg)
+	if len(restart) > 0 {
+		r.logger.Warn().Strs("sections", restart).Msg(constants.LogMsgConfigReloadRestart)
+	}
+	if len(reloaded) == 0 {
+		r.logger.Info().Msg(constants.LogMsgConfigUnchanged)
+		return
+	}
+	cfg.Preserve(r.running)
+
+	svc := *r.svc
+	if slices.Contains(reloaded, "alerts") {
+		svc.alerts = newAlertManager(cfg)
+	}
+	built, err := buildScanners(cfg, &svc, r.logger)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err = built.detectors.Detector(ctx)
+		cancel()
+	}
+	if err != nil {
+		if built != nil {
+			closeNotifier(built.notifier, r.logger)
+		}
+		r.logger.Error().Err(err).Msg(constants.LogMsgConfigReloadFailed)
+		return
+	}
+
+	r.webhook.update(built.github, nil)
+	if r.gitlab != nil && built.gitlab != nil {
+		r.gitlab.set(built.gitlab)
+	}
+	if r.grpc != nil {
+		r.grpc.Set(built.grpc)
+	}
+	if r.badges != nil && built.badges != nil {
+		r.badges.set(built.badges)
+	}
+	if r.export != nil && built.export != nil {
+		r.export.set(built.export)
+	}
+	if r.orgScans != nil {
+		r.orgScans.scanner.Store(built.fullScan)
+	}
+	if r.scanJobs != nil {
+		r.scanJobs.scanner.Store(built.fullScan)
+	}
+	closeNotifier(r.notifier, r.logger)
+	r.notifier = built.notifier
+	r.running = cfg
+	*r.svc = svc
+	r.logger.Info().Strs("sections", reloaded).Msg(constants.LogMsgConfigReloaded)
+}
+
+// closeNotifier closes the connections of a notifier whose handlers were replaced. Scans still
+// running on them keep delivering events.
+func closeNotifier(notifier notify.Notifier, logger zerolog.Logger) {
+	closer, ok := notifier.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgNotifierCloseFailed)
+	}
+}
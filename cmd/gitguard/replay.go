@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/replay"
+	"github.com/rs/zerolog"
+)
+
+// replayTimeout bounds each replayed delivery, which the server answers once it is queued.
+const replayTimeout = 5 * time.Minute
+
+// withRecording saves the webhook deliveries the server accepts as replay fixtures when a
+// record directory is configured.
+func withRecording(next http.Handler, cfg *config.Config, logger zerolog.Logger) http.Handler {
+	dir := cfg.Server.RecordDir
+	if dir == "" {
+		return next
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid webhook record directory")
+	}
+	logger.Warn().Str("dir", dir).Msg("Recording webhook deliveries, payloads included, for replay")
+	return replay.Record(dir)(next)
+}
+
+// replayFixtures delivers recorded webhook fixtures, in order, to a running server, signed
+// with the configured webhook secret, and reports each response to out.
+func replayFixtures(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	url := flags.String("url", "", "webhook URL (default: the configured webhook path on localhost)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("expected at least one fixture")
+	}
+	cfg, err := config.LoadLocalConfig(nil)
+	if err != nil {
+		return err
+	}
+	target := *url
+	if target == "" {
+		target = fmt.Sprintf("http://localhost:%d%s", cfg.GetPort(), cfg.GetWebhookPath())
+	}
+
+	client := &http.Client{Timeout: replayTimeout}
+	failed := 0
+	for _, path := range flags.Args() {
+		fixture, err := replay.Load(path)
+		if err != nil {
+			return err
+		}
+		req, err := fixture.Request(context.Background(), target, cfg.GetWebhookSecret())
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to replay %s: %w", path, err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			failed++
+		}
+		fmt.Fprintf(out, "%s (%s): %s %s\n", path, fixture.Event, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deliveries failed", failed, flags.NArg())
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/output"
+	"github.com/omercnet/gitguard/internal/pathrules"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/prereceive"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/workflow"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/logging"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// errSecretsFound makes the scan command exit non-zero without logging a failure.
+var errSecretsFound = errors.New("secrets found")
+
+// localScan is the configuration of scans run from the command line, which need no GitHub
+// App credentials.
+type localScan struct {
+	classifier *severity.Classifier
+	policy     severity.Policy
+	overrides  *pathrules.Set
+	detectors  *detector.Factory
+	plugins    plugin.Set
+}
+
+func newLocalScan(logger zerolog.Logger) (*localScan, error) {
+	cfg, err := config.LoadLocalConfig(nil)
+	if err != nil {
+		return nil, err
+	}
+	classifier, err := cfg.GetSeverityClassifier()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := cfg.GetCheckPolicy()
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := cfg.GetPathOverrides()
+	if err != nil {
+		return nil, err
+	}
+	detectorOpts, err := cfg.GetDetectorOptions()
+	if err != nil {
+		return nil, err
+	}
+	plugins, err := newPlugins(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &localScan{
+		classifier: classifier,
+		policy:     policy,
+		overrides:  overrides,
+		detectors:  detector.NewFactory(detectorOpts, logger),
+		plugins:    plugins,
+	}, nil
+}
+
+// blocks reports whether findings fail the scan: any finding unless the severity policy
+// concludes neutral.
+func (s *localScan) blocks(findings []report.Finding) bool {
+	return len(findings) > 0 && s.policy.Conclusion(s.classifier.Max(findings)) != constants.ConclusionNeutral
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// scanContent scans standard input, files named on the command line or the changes staged
+// in the repository of the working directory and writes the findings to out.
+func scanContent(args []string, stdin io.Reader, out io.Writer, logger zerolog.Logger) error {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	useStdin := flags.Bool("stdin", false, "scan standard input")
+	staged := flags.Bool("staged", false, "scan the changes staged for the next commit, e.g. from a pre-commit hook")
+	stdinPath := flags.String("path", "", "file path reported for standard input, used by path overrides")
+	format := flags.String("format", output.FormatTable, "output format: "+strings.Join(output.Formats, ", "))
+	var files stringList
+	flags.Var(&files, "file", "file to scan; repeatable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	files = append(files, flags.Args()...)
+	sources := 0
+	for _, set := range []bool{*useStdin, *staged, len(files) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("scan requires one of --stdin, --staged or at least one --file")
+	}
+
+	scan, err := newLocalScan(logger)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.FullScanTimeout)
+	defer cancel()
+	if *staged {
+		return scanStaged(ctx, scan, out, *format)
+	}
+	d, err := scan.detectors.Detector(ctx)
+	if err != nil {
+		return err
+	}
+
+	var findings []report.Finding
+	detectIn := func(name string, r io.Reader) error {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		// Line numbers start at 1 and the path is matched by the rules' path allowlists, as
+		// when gitleaks scans files.
+		found := detector.Check(d, d.Detect(detect.Fragment{Raw: string(content), FilePath: name, StartLine: 1}))
+		found = append(found, workflow.Detect(name, string(content))...)
+		found = append(found, scan.plugins.ScanContent(name, string(content))...)
+		for i := range found {
+			found[i].File = name
+		}
+		findings = append(findings, scan.overrides.Filter(found)...)
+		return nil
+	}
+
+	if *useStdin {
+		if err := detectIn(*stdinPath, stdin); err != nil {
+			return err
+		}
+	}
+	for _, name := range files {
+		file, err := os.Open(name) // #nosec G304 -- Scanning files named by the user is the point
+		if err != nil {
+			return err
+		}
+		err = detectIn(name, file)
+		_ = file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := output.Write(out, *format, version, findings, scan.classifier); err != nil {
+		return err
+	}
+	if scan.blocks(findings) {
+		return errSecretsFound
+	}
+	return nil
+}
+
+// scanStaged scans the lines the changes staged in the repository of the working directory
+// add and writes the findings to out.
+func scanStaged(ctx context.Context, scan *localScan, out io.Writer, format string) error {
+	rules, err := scan.detectors.Config(ctx)
+	if err != nil {
+		return err
+	}
+	// The gitleaks progress logs would be mixed with the findings.
+	logging.Logger = logging.Logger.Level(zerolog.WarnLevel)
+
+	findings, err := prereceive.ScanStaged(ctx, rules, ".")
+	if err != nil {
+		return err
+	}
+	findings = scan.overrides.Filter(findings)
+	if err := output.Write(out, format, version, findings, scan.classifier); err != nil {
+		return err
+	}
+	if scan.blocks(findings) {
+		return errSecretsFound
+	}
+	return nil
+}
+
+// runScan runs the scan command and exits with its status: 1 when secrets are found, 2 on
+// errors.
+func runScan(args []string, logger zerolog.Logger) {
+	// Findings go to standard output, so logs must not.
+	err := scanContent(args, os.Stdin, os.Stdout, logger.Output(os.Stderr))
+	switch {
+	case err == nil:
+	case errors.Is(err, errSecretsFound):
+		os.Exit(1)
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "gitguard scan: %v\n", err)
+		os.Exit(2)
+	}
+}
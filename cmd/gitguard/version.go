@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/rs/zerolog"
+)
+
+// buildInfo identifies the running binary and the rules it detects secrets with.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+	// GitleaksVersion is the version of the gitleaks module, whose default rules are embedded.
+	GitleaksVersion string `json:"gitleaks_version"`
+	DefaultRules    int    `json:"default_rules"`
+	// RulesVersion is the hash of the configured rule packs, custom rules and path overrides,
+	// which, with the binary and the content of fetched rule packs, keys cached scan results.
+	// Empty when no configuration is loaded.
+	RulesVersion string `json:"rules_version,omitempty"`
+}
+
+// currentBuildInfo returns the build information, with the rules version of cfg when set.
+func currentBuildInfo(cfg *config.Config) buildInfo {
+	info := buildInfo{
+		Version:         version,
+		Commit:          commit,
+		Date:            date,
+		GoVersion:       runtime.Version(),
+		GitleaksVersion: detector.GitleaksVersion(),
+	}
+	if rules, err := detector.DefaultConfig(); err == nil {
+		info.DefaultRules = len(rules.Rules)
+	}
+	if cfg != nil {
+		info.RulesVersion = cfg.RulesVersion()
+	}
+	return info
+}
+
+// scanVersion keys the scan results cached with cfg: the binary, whose detectors and default
+// rules findings depend on, and the configured rules. Scanners add the digest of the rule
+// packs their detector was built from.
+func scanVersion(cfg *config.Config) string {
+	return version + "+" + commit + "/" + detector.GitleaksVersion() + "/" + cfg.RulesVersion()
+}
+
+// printVersion prints the build information, with the rules version of the local
+// configuration when it loads.
+func printVersion(out io.Writer) error {
+	// An invalid configuration only omits the rules version.
+	cfg, _ := config.LoadLocalConfig(nil)
+	info := currentBuildInfo(cfg)
+	_, err := fmt.Fprintf(out, "gitguard %s (commit %s, built %s, %s)\ngitleaks %s, %d default rules\n",
+		info.Version, info.Commit, info.Date, info.GoVersion, info.GitleaksVersion, info.DefaultRules)
+	if err == nil && info.RulesVersion != "" {
+		_, err = fmt.Fprintf(out, "rules version %s\n", info.RulesVersion)
+	}
+	return err
+}
+
+// versionHandler serves the build information with the rules version of the running
+// configuration.
+func versionHandler(current func() *config.Config, logger zerolog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(currentBuildInfo(current())); err != nil {
+			logger.Error().Err(err).Msg("Failed to write version response")
+		}
+	})
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/omercnet/gitguard/internal/keyring"
+	"github.com/omercnet/gitguard/internal/problem"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+)
+
+// saturatedRetryAfter is how long clients are asked to wait before redelivering an event
+// refused because the scan queue is full.
+const saturatedRetryAfter = 30 * time.Second
+
+// newDispatcher creates the webhook event dispatcher for the given handlers and secret,
+// running deliveries with scheduler.
+func newDispatcher(handlers []githubapp.EventHandler, secret string, scheduler githubapp.Scheduler) http.Handler {
+	return githubapp.NewEventDispatcher(
+		fanOutHandlers(handlers),
+		secret,
+		githubapp.WithErrorCallback(webhookErrorCallback),
+		githubapp.WithResponseCallback(webhookResponseCallback),
+		githubapp.WithScheduler(scheduler),
+	)
+}
+
+// deliveryScheduler runs webhook deliveries in the background, so that scans waiting for a
+// slot do not hold deliveries open past GitHub's timeout. A delivery whose scan the scan
+// scheduler could neither run nor queue is refused with ratelimit.ErrSaturated, answered with
+// 429 so that it can be redelivered. Accepted scans can still be refused or shed from the
+// queue for more urgent ones while they wait: they are then lost, as GitHub already got 202.
+type deliveryScheduler struct {
+	scans *ratelimit.Scheduler
+	async githubapp.Scheduler
+	// running tracks the deliveries running in the background.
+	running sync.WaitGroup
+}
+
+// newDeliveryScheduler creates a deliveryScheduler admitting scans with scans.
+func newDeliveryScheduler(scans *ratelimit.Scheduler) *deliveryScheduler {
+	return &deliveryScheduler{
+		scans: scans,
+		async: githubapp.AsyncScheduler(githubapp.WithAsyncErrorCallback(deliveryErrorCallback)),
+	}
+}
+
+func (s *deliveryScheduler) Schedule(ctx context.Context, d githubapp.Dispatch) error {
+	if h, ok := d.Handler.(handler.Prioritized); ok {
+		installationID, priority, scans := h.ScanPriority(d.EventType, d.Payload)
+		if scans && !s.scans.Admissible(installationID, priority) {
+			zerolog.Ctx(ctx).Warn().
+				Int64("installation_id", installationID).
+				Stringer("priority", priority).
+				Msg(constants.LogMsgScanQueueFull)
+			return ratelimit.ErrSaturated
+		}
+	}
+	s.running.Add(1)
+	d.Handler = &trackedHandler{EventHandler: d.Handler, done: s.running.Done}
+	return s.async.Schedule(ctx, d)
+}
+
+// wait waits until the deliveries running in the background finish or ctx ends.
+func (s *deliveryScheduler) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackedHandler calls done once its handler returns.
+type trackedHandler struct {
+	githubapp.EventHandler
+	done func()
+}
+
+func (h *trackedHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	defer h.done()
+	return h.EventHandler.Handle(ctx, eventType, deliveryID, payload)
+}
+
+// deliveryErrorCallback logs a delivery that failed in the background. Scans refused or shed
+// by the scan scheduler were logged when they were.
+func deliveryErrorCallback(ctx context.Context, _ githubapp.Dispatch, err error) {
+	if errors.Is(err, ratelimit.ErrSaturated) || errors.Is(err, ratelimit.ErrShed) {
+		return
+	}
+	zerolog.Ctx(ctx).Error().Err(err).Msg(constants.LogMsgWebhookFailed)
+}
+
+// webhookResponseCallback answers pings with 200 and other deliveries with 202, as handled
+// ones run in the background.
+func webhookResponseCallback(w http.ResponseWriter, _ *http.Request, event string, _ bool) {
+	if event == constants.PingEventType {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// fanOutHandlers returns one handler per event type running every handler of that type: the
+// dispatcher only calls the first handler registered for an event type, and commit and full
+// repository scans both handle pushes.
+func fanOutHandlers(handlers []githubapp.EventHandler) []githubapp.EventHandler {
+	byType := make(map[string]*fanOut)
+	var merged []githubapp.EventHandler
+	for _, h := range handlers {
+		for _, eventType := range h.Handles() {
+			fan, ok := byType[eventType]
+			if !ok {
+				fan = &fanOut{eventType: eventType}
+				byType[eventType] = fan
+				merged = append(merged, fan)
+			}
+			fan.handlers = append(fan.handlers, h)
+		}
+	}
+	return merged
+}
+
+// fanOut handles an event type with several handlers, run concurrently. It fails with the
+// errors of the handlers that failed.
+type fanOut struct {
+	eventType string
+	handlers  []githubapp.EventHandler
+}
+
+func (f *fanOut) Handles() []string {
+	return []string{f.eventType}
+}
+
+// ScanPriority returns the most urgent scan of the handlers a delivery scans with.
+func (f *fanOut) ScanPriority(eventType string, payload []byte) (int64, ratelimit.Priority, bool) {
+	var installationID int64
+	var urgent ratelimit.Priority
+	scans := false
+	for _, h := range f.handlers {
+		p, ok := h.(handler.Prioritized)
+		if !ok {
+			continue
+		}
+		if id, priority, ok := p.ScanPriority(eventType, payload); ok && (!scans || priority < urgent) {
+			installationID, urgent, scans = id, priority, true
+		}
+	}
+	return installationID, urgent, scans
+}
+
+func (f *fanOut) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	if len(f.handlers) == 1 {
+		return f.handlers[0].Handle(ctx, eventType, deliveryID, payload)
+	}
+	errs := make([]error, len(f.handlers))
+	var wg sync.WaitGroup
+	for i, h := range f.handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = h.Handle(ctx, eventType, deliveryID, payload)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// webhookErrorCallback logs a failed delivery and responds with a problem details body
+// carrying the delivery ID and an error code: 413 for payloads exceeding the body limit, 400
+// for invalid signatures or payloads, 503 when over capacity, 429 when the scan queue has no
+// room for the delivery's scan and 500 otherwise.
+func webhookErrorCallback(w http.ResponseWriter, r *http.Request, err error) {
+	logger := zerolog.Ctx(r.Context())
+
+	var ve githubapp.ValidationError
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &ve) && errors.As(ve.Cause, &maxErr):
+		logger.Warn().
+			Int64("limit_bytes", maxErr.Limit).
+			Msg(constants.LogMsgPayloadTooLarge)
+		problem.Write(w, r, http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge,
+			fmt.Sprintf("payload exceeds the %d byte limit", maxErr.Limit))
+	case errors.As(err, &ve):
+		logger.Warn().Err(ve.Cause).Msg(constants.LogMsgInvalidWebhook)
+		code := problem.CodeInvalidRequest
+		// go-github reports signature failures as plain errors.
+		if strings.Contains(ve.Cause.Error(), "signature") {
+			code = problem.CodeInvalidSignature
+		}
+		problem.Write(w, r, http.StatusBadRequest, code, ve.Cause.Error())
+	case errors.Is(err, ratelimit.ErrSaturated):
+		w.Header().Set("Retry-After", strconv.Itoa(int(saturatedRetryAfter.Seconds())))
+		problem.Write(w, r, http.StatusTooManyRequests, problem.CodeOverCapacity,
+			"the scan queue is full, redeliver the event later")
+	case errors.Is(err, githubapp.ErrCapacityExceeded):
+		logger.Warn().Msg(constants.LogMsgWebhookOverCapacity)
+		problem.Write(w, r, http.StatusServiceUnavailable, problem.CodeOverCapacity,
+			"no capacity available to process this event")
+	default:
+		logger.Error().Err(err).Msg(constants.LogMsgWebhookFailed)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal,
+			"unexpected error handling the event")
+	}
+}
+
+// swapHandler serves requests with a handler that can be replaced while the server runs.
+type swapHandler struct {
+	current atomic.Pointer[http.Handler]
+}
+
+func (w *swapHandler) set(h http.Handler) {
+	w.current.Store(&h)
+}
+
+func (w *swapHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	(*w.current.Load()).ServeHTTP(rw, r)
+}
+
+// webhookHandler serves webhooks with a dispatcher per GitHub App, which is rebuilt when the
+// App's webhook secret rotates or the configuration is reloaded.
+type webhookHandler struct {
+	swapHandler
+
+	// primary is the App deliveries that do not name one are dispatched to.
+	primary int64
+	// deliveries runs the deliveries of every App.
+	deliveries *deliveryScheduler
+
+	mu       sync.Mutex
+	handlers map[int64][]githubapp.EventHandler
+	secrets  map[int64]string
+}
+
+// update replaces the event handlers and webhook secrets of the Apps they are given for; the
+// other Apps keep their current ones.
+func (w *webhookHandler) update(handlers map[int64][]githubapp.EventHandler, secrets map[int64]string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.handlers == nil {
+		w.handlers = make(map[int64][]githubapp.EventHandler, len(handlers))
+		w.secrets = make(map[int64]string, len(secrets))
+	}
+	maps.Copy(w.handlers, handlers)
+	for appID, secret := range secrets {
+		if secret != "" {
+			w.secrets[appID] = secret
+		}
+	}
+
+	router := &appRouter{primary: w.primary, dispatchers: make(map[int64]http.Handler, len(w.handlers))}
+	for appID, appHandlers := range w.handlers {
+		router.dispatchers[appID] = newDispatcher(appHandlers, w.secrets[appID], w.deliveries)
+	}
+	w.set(router)
+}
+
+// Headers GitHub names the App a delivery is addressed to with.
+const (
+	hookTargetTypeHeader = "X-GitHub-Hook-Installation-Target-Type"
+	hookTargetIDHeader   = "X-GitHub-Hook-Installation-Target-ID"
+)
+
+// appRouter dispatches each webhook delivery with the dispatcher of the App it is addressed
+// to. Deliveries that do not name an App go to the primary one; those addressed to an App
+// that is not configured are rejected.
+type appRouter struct {
+	primary     int64
+	dispatchers map[int64]http.Handler
+}
+
+func (a *appRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	appID := a.primary
+	target := r.Header.Get(hookTargetIDHeader)
+	if target != "" && r.Header.Get(hookTargetTypeHeader) == "integration" {
+		id, err := strconv.ParseInt(target, 10, 64)
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid "+hookTargetIDHeader)
+			return
+		}
+		appID = id
+	}
+	dispatcher, ok := a.dispatchers[appID]
+	if !ok {
+		zerolog.Ctx(r.Context()).Warn().Int64("app_id", appID).Msg(constants.LogMsgUnknownGitHubApp)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("GitHub App %d is not configured", appID))
+		return
+	}
+	dispatcher.ServeHTTP(w, r)
+}
+
+// startSecretRefresh periodically re-reads credentials from the secret manager and applies changes.
+func startSecretRefresh(
+	cfg *config.Config,
+	ring *keyring.KeyRing,
+	webhook *webhookHandler,
+	logger zerolog.Logger,
+) {
+	if cfg.Secrets.Backend == "" || cfg.Secrets.RefreshInterval <= 0 {
+		return
+	}
+
+	current := *cfg
+	go func() {
+		ticker := time.NewTicker(cfg.Secrets.RefreshInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			refreshed := current
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			err := refreshed.LoadExternalSecrets(ctx)
+			cancel()
+			if err != nil {
+				logger.Error().Err(err).Msg(constants.LogMsgSecretsRefreshFailed)
+				continue
+			}
+
+			if refreshed.GetWebhookSecret() != current.GetWebhookSecret() {
+				webhook.update(nil, map[int64]string{refreshed.GetAppID(): refreshed.GetWebhookSecret()})
+				logger.Info().Msg(constants.LogMsgWebhookSecretRotated)
+			}
+			if refreshed.GetPrivateKey() != current.GetPrivateKey() {
+				ring.SetKeys(refreshed.GetPrivateKeys())
+				logger.Info().Msg(constants.LogMsgPrivateKeyRotated)
+			}
+			current = refreshed
+		}
+	}()
+}
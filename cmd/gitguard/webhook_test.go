@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/config"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/replay"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler counts the deliveries of the event types it handles. With scan set, its
+// deliveries scan with priority for installation 1.
+type recordingHandler struct {
+	events   []string
+	scan     bool
+	priority ratelimit.Priority
+	// block, when set, holds deliveries until it is closed.
+	block chan struct{}
+
+	mu         sync.Mutex
+	deliveries []string
+}
+
+func (h *recordingHandler) Handles() []string {
+	return h.events
+}
+
+func (h *recordingHandler) ScanPriority(string, []byte) (int64, ratelimit.Priority, bool) {
+	return 1, h.priority, h.scan
+}
+
+func (h *recordingHandler) Handle(_ context.Context, _, deliveryID string, _ []byte) error {
+	if h.block != nil {
+		<-h.block
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deliveries = append(h.deliveries, deliveryID)
+	return nil
+}
+
+func (h *recordingHandler) delivered() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.deliveries)
+}
+
+// deliver sends a signed delivery of event to the webhook server at url and returns the
+// response.
+func deliver(t *testing.T, url, secret, event string, payload []byte) *http.Response {
+	t.Helper()
+	fixture := &replay.Fixture{Event: event, Delivery: "delivery-1", Payload: payload}
+	req, err := fixture.Request(context.Background(), url, secret)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	return resp
+}
+
+func TestNewDispatcher_RunsEveryPushHandler(t *testing.T) {
+	const secret = "webhook-secret"
+	commitScan := &recordingHandler{events: []string{constants.PushEventType}}
+	fullScan := &recordingHandler{events: []string{constants.PushEventType}}
+	tickets := &recordingHandler{events: []string{constants.CheckRunEventType}}
+	deliveries := newDeliveryScheduler(nil)
+	handlers := []githubapp.EventHandler{commitScan, fullScan, tickets}
+	server := httptest.NewServer(newDispatcher(handlers, secret, deliveries))
+	defer server.Close()
+
+	resp := deliver(t, server.URL, secret, constants.PushEventType, []byte(`{"ref": "refs/heads/main"}`))
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.NoError(t, deliveries.wait(context.Background()))
+	assert.Equal(t, []string{"delivery-1"}, commitScan.delivered())
+	assert.Equal(t, []string{"delivery-1"}, fullScan.delivered(), "Both push handlers should run")
+	assert.Empty(t, tickets.delivered())
+}
+
+func TestNewDispatcher_RunsDeliveriesInBackground(t *testing.T) {
+	const secret = "webhook-secret"
+	scan := &recordingHandler{events: []string{constants.PushEventType}, scan: true, block: make(chan struct{})}
+	deliveries := newDeliveryScheduler(ratelimit.NewScheduler(1, 1, 0.1))
+	server := httptest.NewServer(newDispatcher([]githubapp.EventHandler{scan}, secret, deliveries))
+	defer server.Close()
+
+	resp := deliver(t, server.URL, secret, constants.PushEventType, []byte(`{}`))
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "The delivery should not wait for its scan")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, deliveries.wait(ctx), context.DeadlineExceeded)
+	assert.Empty(t, scan.delivered())
+
+	close(scan.block)
+	require.NoError(t, deliveries.wait(context.Background()))
+	assert.Equal(t, []string{"delivery-1"}, scan.delivered())
+
+	resp = deliver(t, server.URL, secret, constants.PingEventType, []byte(`{}`))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewDispatcher_RefusesScansWithoutRoom(t *testing.T) {
+	const secret = "webhook-secret"
+	scans := ratelimit.NewScheduler(1, 1, 0.1)
+	scans.SetMaxQueued(1)
+	release, ok := scans.TryAcquire(1, ratelimit.High)
+	require.True(t, ok)
+	queued := make(chan error, 1)
+	go func() {
+		release, err := scans.Acquire(context.Background(), 1, ratelimit.Normal)
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+	require.Eventually(t, func() bool { return scans.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	commitScan := &recordingHandler{events: []string{constants.PushEventType}, scan: true, priority: ratelimit.Normal}
+	fullScan := &recordingHandler{events: []string{constants.PushEventType}, scan: true, priority: ratelimit.Low}
+	tickets := &recordingHandler{events: []string{constants.CheckRunEventType}}
+	gate := &recordingHandler{events: []string{constants.PullRequestEventType}, scan: true, priority: ratelimit.High}
+	deliveries := newDeliveryScheduler(scans)
+	handlers := []githubapp.EventHandler{commitScan, fullScan, tickets, gate}
+	server := httptest.NewServer(newDispatcher(handlers, secret, deliveries))
+	defer server.Close()
+
+	resp := deliver(t, server.URL, secret, constants.PushEventType, []byte(`{}`))
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "No push scan can run or be queued")
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	resp = deliver(t, server.URL, secret, constants.CheckRunEventType, []byte(`{}`))
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "Deliveries that scan nothing should be accepted")
+	resp = deliver(t, server.URL, secret, constants.PullRequestEventType, []byte(`{}`))
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "A scan that can shed a queued one should be accepted")
+
+	require.NoError(t, deliveries.wait(context.Background()))
+	assert.Empty(t, commitScan.delivered())
+	assert.Empty(t, fullScan.delivered())
+	assert.Equal(t, []string{"delivery-1"}, tickets.delivered())
+	assert.Equal(t, []string{"delivery-1"}, gate.delivered())
+	assert.Equal(t, int64(1), scans.Stats().Rejected)
+
+	release()
+	require.NoError(t, <-queued)
+}
+
+func TestBuildScanners_PushScans(t *testing.T) {
+	t.Setenv(config.ConfigFileEnv, "")
+	cfg, err := config.LoadLocalConfig(nil)
+	require.NoError(t, err)
+	require.True(t, cfg.Push.CommitScans && cfg.FullScan.Enabled, "Both scans are enabled by default")
+
+	svc := &services{apps: []githubApp{{GitHubApp: config.GitHubApp{AppID: 1}}}}
+	built, err := buildScanners(cfg, svc, zerolog.Nop())
+	require.NoError(t, err)
+
+	var push *fanOut
+	for _, h := range fanOutHandlers(built.github[1]) {
+		if slices.Contains(h.Handles(), constants.PushEventType) {
+			require.Nil(t, push, "Pushes should have a single handler")
+			push = h.(*fanOut)
+		}
+	}
+	require.NotNil(t, push)
+	require.Len(t, push.handlers, 2)
+	assert.IsType(t, &handler.SecretScanHandler{}, push.handlers[0])
+	assert.IsType(t, &handler.FullRepoScanHandler{}, push.handlers[1])
+}
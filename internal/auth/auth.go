@@ -0,0 +1,172 @@
+// Package auth authenticates callers of the admin API, with static bearer tokens or OIDC ID
+// tokens, and authorizes them by role.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Role is what a caller may do. Roles are ordered: an admin may do what a viewer may.
+type Role int
+
+// Roles.
+const (
+	// RoleNone is granted nothing.
+	RoleNone Role = iota
+	// RoleViewer reads findings, metrics and scan status.
+	RoleViewer
+	// RoleAdmin also starts scans and reads the configuration.
+	RoleAdmin
+)
+
+// ParseRole parses "viewer" or "admin". An empty name is RoleNone.
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return RoleNone, nil
+	case "viewer":
+		return RoleViewer, nil
+	case "admin":
+		return RoleAdmin, nil
+	}
+	return RoleNone, fmt.Errorf("unknown role %q: expected viewer or admin", name)
+}
+
+// String returns the name of the role.
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "none"
+}
+
+// Token is a static bearer token and the role it grants.
+type Token struct {
+	Token string
+	Role  Role
+}
+
+// Config configures an Authenticator.
+type Config struct {
+	Tokens []Token
+	// OIDC, when set, accepts ID tokens of an OpenID Connect issuer.
+	OIDC *OIDC
+}
+
+// Enabled reports whether any caller can authenticate.
+func (c Config) Enabled() bool {
+	return len(c.Tokens) > 0 || c.OIDC != nil
+}
+
+// Identity is an authenticated caller.
+type Identity struct {
+	// Subject names the caller: "token" for static tokens, else the email or subject of the
+	// ID token.
+	Subject string
+	Role    Role
+}
+
+// errUnauthenticated is returned for requests without valid credentials.
+var errUnauthenticated = errors.New("missing or invalid bearer token")
+
+// Authenticator authenticates requests.
+type Authenticator struct {
+	tokens []Token
+	oidc   *verifier
+	logger zerolog.Logger
+}
+
+// New creates an Authenticator. OIDC keys are fetched with client, or the default client,
+// when the first ID token is verified.
+func New(cfg Config, client *http.Client, logger zerolog.Logger) *Authenticator {
+	a := &Authenticator{tokens: cfg.Tokens, logger: logger}
+	if cfg.OIDC != nil {
+		a.oidc = newVerifier(*cfg.OIDC, client)
+	}
+	return a
+}
+
+// Authenticate returns the identity of the bearer token of r.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || raw == "" || a == nil {
+		return Identity{}, errUnauthenticated
+	}
+	role := RoleNone
+	for _, token := range a.tokens {
+		// Every token is compared so the time taken does not tell which one matched.
+		if subtle.ConstantTimeCompare([]byte(raw), []byte(token.Token)) == 1 && token.Role > role {
+			role = token.Role
+		}
+	}
+	if role != RoleNone {
+		return Identity{Subject: "token", Role: role}, nil
+	}
+	if a.oidc != nil && strings.Count(raw, ".") == 2 {
+		return a.oidc.verify(r.Context(), raw)
+	}
+	return Identity{}, errUnauthenticated
+}
+
+// Require rejects requests whose caller is not authenticated with 401, and those whose role
+// is below role with 403. A nil Authenticator rejects every request, so endpoints are never
+// exposed unauthenticated.
+func (a *Authenticator) Require(role Role) func(http.Handler) http.Handler {
+	return a.RequireByMethod(role, role)
+}
+
+// RequireByMethod is Require with role read for GET and HEAD requests and role write for
+// requests of other methods.
+func (a *Authenticator) RequireByMethod(read, write Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := a.Authenticate(r)
+			if err != nil {
+				if a != nil {
+					a.logger.Debug().Err(err).Str("path", r.URL.Path).Msg("Rejected admin API request")
+				}
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			required := write
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				required = read
+			}
+			if identity.Role < required {
+				a.logger.Warn().
+					Str("subject", identity.Subject).
+					Str("role", identity.Role.String()).
+					Str("required", required.String()).
+					Str("path", r.URL.Path).
+					Msg("Forbidden admin API request")
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+type contextKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the caller's identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity of the caller of the request ctx serves.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(Identity)
+	return identity, ok
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler http.Handler, authorization, method string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, "/admin", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRequire_Tokens(t *testing.T) {
+	var got Identity
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	tokens := []Token{{Token: "view", Role: RoleViewer}, {Token: "root", Role: RoleAdmin}}
+	a := New(Config{Tokens: tokens}, nil, zerolog.Nop())
+	viewer := a.Require(RoleViewer)(ok)
+	admin := a.Require(RoleAdmin)(ok)
+	byMethod := a.RequireByMethod(RoleViewer, RoleAdmin)(ok)
+
+	assert.Equal(t, http.StatusOK, serve(t, viewer, "Bearer view", http.MethodGet))
+	assert.Equal(t, Identity{Subject: "token", Role: RoleViewer}, got)
+	assert.Equal(t, http.StatusOK, serve(t, admin, "Bearer root", http.MethodGet))
+	assert.Equal(t, http.StatusForbidden, serve(t, admin, "Bearer view", http.MethodGet))
+	assert.Equal(t, http.StatusOK, serve(t, byMethod, "Bearer view", http.MethodGet))
+	assert.Equal(t, http.StatusForbidden, serve(t, byMethod, "Bearer view", http.MethodPost))
+	assert.Equal(t, http.StatusOK, serve(t, byMethod, "Bearer root", http.MethodPost))
+
+	for _, authorization := range []string{"", "Bearer", "Bearer ", "Bearer wrong", "Basic root", "root"} {
+		assert.Equal(t, http.StatusUnauthorized, serve(t, viewer, authorization, http.MethodGet), authorization)
+	}
+
+	var unset *Authenticator
+	assert.Equal(t, http.StatusUnauthorized, serve(t, unset.Require(RoleViewer)(ok), "Bearer root", http.MethodGet))
+}
+
+// issuer is a test OpenID Connect issuer signing ID tokens with its current key.
+type issuer struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	kid     string
+	fetches atomic.Int32
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	iss := &issuer{kid: "key-1"}
+	iss.rotate(t, "key-1")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": iss.server.URL, "jwks_uri": iss.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		iss.fetches.Add(1)
+		ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": iss.kid, "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(iss.key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(iss.key.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec", "crv": "P-256",
+				"x": base64.RawURLEncoding.EncodeToString(ec.X.Bytes()),
+				"y": base64.RawURLEncoding.EncodeToString(ec.Y.Bytes()),
+			},
+			{"kty": "oct", "kid": "symmetric", "k": "c2VjcmV0"},
+		}})
+	})
+	iss.server = httptest.NewServer(mux)
+	t.Cleanup(iss.server.Close)
+	return iss
+}
+
+func (i *issuer) rotate(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	i.key, i.kid = key, kid
+}
+
+func (i *issuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	base := jwt.MapClaims{
+		"iss": i.server.URL,
+		"aud": "gitguard",
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(base, name)
+		} else {
+			base[name] = value
+		}
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, base)
+	token.Header["kid"] = i.kid
+	signed, err := token.SignedString(i.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuthenticate_OIDC(t *testing.T) {
+	iss := newIssuer(t)
+	a := New(Config{OIDC: &OIDC{
+		Issuer:   iss.server.URL + "/",
+		Audience: "gitguard",
+		Roles:    map[string]Role{"security": RoleAdmin, "engineering": RoleViewer},
+	}}, iss.server.Client(), zerolog.Nop())
+
+	authenticate := func(token string) (Identity, error) {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil).WithContext(context.Background())
+		req.Header.Set("Authorization", "Bearer "+token)
+		return a.Authenticate(req)
+	}
+
+	groups := []string{"engineering", "security"}
+	identity, err := authenticate(iss.sign(t, jwt.MapClaims{"groups": groups, "email": "a@example.com"}))
+	require.NoError(t, err)
+	assert.Equal(t, Identity{Subject: "a@example.com", Role: RoleAdmin}, identity)
+
+	identity, err = authenticate(iss.sign(t, jwt.MapClaims{"groups": "engineering"}))
+	require.NoError(t, err)
+	assert.Equal(t, Identity{Subject: "user-1", Role: RoleViewer}, identity)
+
+	identity, err = authenticate(iss.sign(t, jwt.MapClaims{"groups": []string{"marketing"}}))
+	require.NoError(t, err)
+	assert.Equal(t, RoleNone, identity.Role, "Unmapped callers get the default role")
+	assert.EqualValues(t, 1, iss.fetches.Load(), "Keys should be cached")
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"audience":  {"aud": "other"},
+		"issuer":    {"iss": "https://evil.example.com"},
+		"expired":   {"exp": time.Now().Add(-time.Minute).Unix()},
+		"no expiry": {"exp": nil},
+	} {
+		_, err := authenticate(iss.sign(t, claims))
+		assert.Error(t, err, name)
+	}
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": iss.server.URL, "aud": "gitguard"})
+	token, err := unsigned.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = authenticate(token)
+	assert.Error(t, err, "Symmetric signatures are rejected")
+
+	// A token signed with a new key refetches the keys, at most once a minute.
+	iss.rotate(t, "key-2")
+	_, err = authenticate(iss.sign(t, nil))
+	assert.ErrorContains(t, err, "unknown signing key")
+	a.oidc.fetched = time.Now().Add(-2 * keysMinRefresh)
+	_, err = authenticate(iss.sign(t, nil))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, iss.fetches.Load())
+}
+
+func TestParseRole(t *testing.T) {
+	for name, want := range map[string]Role{"": RoleNone, "viewer": RoleViewer, "Admin": RoleAdmin} {
+		role, err := ParseRole(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, role)
+	}
+	_, err := ParseRole("owner")
+	assert.Error(t, err)
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// DefaultRoleClaim is the ID token claim mapped to roles when none is configured.
+const DefaultRoleClaim = "groups"
+
+const (
+	// keysTTL is how long the issuer's signing keys are cached.
+	keysTTL = time.Hour
+	// keysMinRefresh is the minimum time between fetches of the keys, so tokens signed with
+	// unknown keys cannot make every request fetch them.
+	keysMinRefresh = time.Minute
+	// maxDocumentBytes bounds the size of discovery and key set documents.
+	maxDocumentBytes = 1 << 20
+)
+
+// signingMethods are the accepted ID token signature algorithms.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// OIDC configures the verification of ID tokens of an OpenID Connect issuer.
+type OIDC struct {
+	// Issuer is the issuer URL; its keys are discovered at
+	// {Issuer}/.well-known/openid-configuration.
+	Issuer string
+	// Audience is the client ID tokens must be issued to.
+	Audience string
+	// RoleClaim is the claim, a string or a list of strings such as groups, mapped to roles.
+	RoleClaim string
+	// Roles maps values of RoleClaim to roles. A caller is granted the highest role of its
+	// values.
+	Roles map[string]Role
+	// DefaultRole is granted to callers none of whose values is mapped.
+	DefaultRole Role
+}
+
+// verifier verifies ID tokens with the issuer's current signing keys.
+type verifier struct {
+	cfg    OIDC
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+func newVerifier(cfg OIDC, client *http.Client) *verifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = DefaultRoleClaim
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &verifier{cfg: cfg, client: client}
+}
+
+// verify checks the signature, issuer, audience and validity period of an ID token and maps
+// its claims to an identity.
+func (v *verifier) verify(ctx context.Context, raw string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods))
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("invalid ID token: %w", err)
+	}
+	switch {
+	case !claims.VerifyIssuer(v.cfg.Issuer, true) && !claims.VerifyIssuer(v.cfg.Issuer+"/", true):
+		return Identity{}, errors.New("invalid ID token: unexpected issuer")
+	case !claims.VerifyAudience(v.cfg.Audience, true):
+		return Identity{}, errors.New("invalid ID token: unexpected audience")
+	case claims["exp"] == nil:
+		return Identity{}, errors.New("invalid ID token: no expiry")
+	}
+
+	identity := Identity{Role: v.cfg.DefaultRole}
+	for _, name := range []string{"email", "sub"} {
+		if subject, ok := claims[name].(string); ok && subject != "" {
+			identity.Subject = subject
+			break
+		}
+	}
+	mapped := RoleNone
+	for _, value := range claimValues(claims[v.cfg.RoleClaim]) {
+		if role := v.cfg.Roles[value]; role > mapped {
+			mapped = role
+		}
+	}
+	if mapped != RoleNone {
+		identity.Role = mapped
+	}
+	return identity, nil
+}
+
+// claimValues returns the strings of a string or list claim.
+func claimValues(claim any) []string {
+	switch c := claim.(type) {
+	case string:
+		return []string{c}
+	case []any:
+		values := make([]string, 0, len(c))
+		for _, item := range c {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// key returns the signing key kid, fetching the keys when they are stale or kid is unknown.
+// An empty kid matches the only key of a single-key set.
+func (v *verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.lookup(kid)
+	stale := time.Since(v.fetched) > keysTTL
+	if (!ok && time.Since(v.fetched) > keysMinRefresh) || stale {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			if ok {
+				// Keep verifying with the cached keys while the issuer is unreachable.
+				return key, nil
+			}
+			return nil, err
+		}
+		v.keys, v.fetched = keys, time.Now()
+		key, ok = v.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (v *verifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// fetchKeys discovers the issuer's key set and fetches its signing keys.
+func (v *verifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, v.cfg.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC issuer: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != v.cfg.Issuer || discovery.JWKSURI == "" {
+		return nil, errors.New("failed to discover OIDC issuer: unexpected issuer or no jwks_uri")
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped rather than failing the whole set.
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (v *verifier) getJSON(ctx context.Context, url string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxDocumentBytes)).Decode(target)
+}
+
+// jsonWebKey is an RSA or elliptic curve public key of a JSON Web Key Set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,45 @@
+package awsauth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_Sign(t *testing.T) {
+	signer := &Signer{
+		Service:      "sqs",
+		Region:       "eu-west-1",
+		AccessKeyID:  "AKIDEXAMPLE",
+		SecretKey:    "example-secret",
+		SessionToken: "session",
+		Now:          func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+
+	newRequest := func(target string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "https://sqs.eu-west-1.amazonaws.com/", strings.NewReader("{}"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+		if target != "" {
+			req.Header.Set("X-Amz-Target", target)
+		}
+		signer.Sign(req, []byte("{}"))
+		return req
+	}
+
+	req := newRequest("AmazonSQS.SendMessageBatch")
+	auth := req.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, Algorithm+" Credential=AKIDEXAMPLE/20240102/eu-west-1/sqs/aws4_request, "), auth)
+	assert.Contains(t, auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target,")
+	assert.Equal(t, "20240102T030405Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "session", req.Header.Get("X-Amz-Security-Token"))
+	assert.Equal(t, auth, newRequest("AmazonSQS.SendMessageBatch").Header.Get("Authorization"), "Signing should be deterministic")
+
+	untargeted := newRequest("").Header.Get("Authorization")
+	assert.Contains(t, untargeted, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token,")
+	assert.NotEqual(t, auth, untargeted)
+}
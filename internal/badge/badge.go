@@ -0,0 +1,172 @@
+// Package badge renders the current GitGuard status of a repository, from its tracked
+// findings, as an SVG badge or a shields.io endpoint document, so teams can embed live
+// status in their READMEs.
+package badge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Label is the left-hand text of every badge.
+const Label = "gitguard"
+
+// colors are the shields.io color names and hex values of each highest severity of the open
+// findings; None is a clean repository.
+var colors = map[severity.Level]color{
+	severity.None:     {"brightgreen", "#4c1"},
+	severity.Low:      {"yellowgreen", "#a4a61d"},
+	severity.Medium:   {"yellow", "#dfb317"},
+	severity.High:     {"orange", "#fe7d37"},
+	severity.Critical: {"red", "#e05d44"},
+}
+
+type color struct {
+	name string
+	hex  string
+}
+
+// Status is the status of a repository a badge shows.
+type Status struct {
+	// Open is the number of open findings; resolved and suppressed findings do not count.
+	Open int
+	// Highest is the highest severity of the open findings, None when there are none.
+	Highest severity.Level
+}
+
+// Compute returns the status of a repository's tracked findings.
+func Compute(findings []store.Finding, classifier *severity.Classifier) Status {
+	var status Status
+	for _, finding := range findings {
+		if finding.State != store.StateOpen {
+			continue
+		}
+		status.Open++
+		level := classifier.Classify(report.Finding{RuleID: finding.RuleID, File: finding.File})
+		if level > status.Highest {
+			status.Highest = level
+		}
+	}
+	return status
+}
+
+// Message is the right-hand text of the badge: "clean" or the number of open findings.
+func (s Status) Message() string {
+	switch s.Open {
+	case 0:
+		return "clean"
+	case 1:
+		return "1 open finding"
+	}
+	return fmt.Sprintf("%d open findings", s.Open)
+}
+
+func (s Status) color() color {
+	if c, ok := colors[s.Highest]; ok {
+		return c
+	}
+	return colors[severity.High]
+}
+
+// SVG renders the status as a flat badge. Text widths are estimated, as shields.io does for
+// badges it cannot measure.
+func (s Status) SVG() string {
+	label, message := Label, s.Message()
+	labelWidth, messageWidth := textWidth(label), textWidth(message)
+	width := labelWidth + messageWidth
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+
+		`<title>%s: %s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/>`+
+		`<stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/>`+
+		`<rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%d" y="14">%s</text><text x="%d" y="14">%s</text></g></svg>`,
+		width, html.EscapeString(label), html.EscapeString(message),
+		html.EscapeString(label), html.EscapeString(message),
+		width, labelWidth,
+		labelWidth, messageWidth, s.color().hex, width,
+		labelWidth/2, html.EscapeString(label), labelWidth+messageWidth/2, html.EscapeString(message))
+}
+
+// textWidth estimates the width of text in 11px Verdana, padded on both sides.
+func textWidth(text string) int {
+	return len(text)*7 + 10
+}
+
+// Endpoint is the shields.io endpoint document of a status, see
+// https://shields.io/badges/endpoint-badge.
+type Endpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// Endpoint returns the shields.io endpoint document of the status.
+func (s Status) Endpoint() Endpoint {
+	return Endpoint{SchemaVersion: 1, Label: Label, Message: s.Message(), Color: s.color().name}
+}
+
+// Handler serves the badges of the repositories public is non-zero for at
+// /badge/{owner}/{name}, where name is the repository name followed by ".svg" for an SVG badge
+// or ".json" for a shields.io endpoint document. Other repositories are not found, so badges
+// never reveal the status of repositories that did not opt in. Badges are not cached, so
+// they show the live status.
+func Handler(
+	findings store.FindingStore, public *reposcope.Setting, classifier *severity.Classifier, logger zerolog.Logger,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		repo, format := strings.TrimSuffix(name, ".svg"), "svg"
+		if repo == name {
+			repo, format = strings.TrimSuffix(name, ".json"), "json"
+		}
+		repository := r.PathValue("owner") + "/" + repo
+		if repo == name || repo == "" || public.For(repository) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		status, err := Load(r.Context(), findings, repository, classifier)
+		if err != nil {
+			logger.Error().Err(err).Str("repository", repository).Msg("Failed to load tracked findings")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		if format == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(status.Endpoint()); err != nil {
+				logger.Error().Err(err).Msg("Failed to write badge response")
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml")
+		if _, err := w.Write([]byte(status.SVG())); err != nil {
+			logger.Error().Err(err).Msg("Failed to write badge response")
+		}
+	})
+}
+
+// Load computes the Status of a repository's findings tracked in findings.
+func Load(
+	ctx context.Context, findings store.FindingStore, repository string, classifier *severity.Classifier,
+) (Status, error) {
+	tracked, err := findings.Findings(ctx, repository)
+	if err != nil {
+		return Status{}, err
+	}
+	return Compute(tracked, classifier), nil
+}
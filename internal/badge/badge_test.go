@@ -0,0 +1,79 @@
+package badge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	classifier := severity.NewClassifier(map[string]severity.Level{"generic-api-key": severity.Medium})
+	findings := []store.Finding{
+		{RuleID: "generic-api-key", State: store.StateOpen},
+		{RuleID: "generic-api-key", State: store.StateOpen},
+		{RuleID: "private-key", State: store.StateResolved},
+		{RuleID: "private-key", State: store.StateSuppressed},
+	}
+
+	status := Compute(findings, classifier)
+	assert.Equal(t, Status{Open: 2, Highest: severity.Medium}, status)
+	assert.Equal(t, "2 open findings", status.Message())
+	assert.Equal(t, "yellow", status.Endpoint().Color)
+
+	assert.Equal(t, "1 open finding", Compute(findings[:1], classifier).Message())
+
+	clean := Compute(findings[2:], classifier)
+	assert.Equal(t, "clean", clean.Message())
+	assert.Equal(t, Endpoint{SchemaVersion: 1, Label: "gitguard", Message: "clean", Color: "brightgreen"},
+		clean.Endpoint())
+	assert.Contains(t, clean.SVG(), `aria-label="gitguard: clean"`)
+	assert.Contains(t, clean.SVG(), `fill="#4c1"`)
+}
+
+func TestHandler(t *testing.T) {
+	findings := store.NewMemory()
+	_, err := findings.RecordScan(context.Background(), store.Scan{
+		Repository: "acme/api",
+		Complete:   true,
+		Findings:   []store.Finding{{Fingerprint: "gitguard-a", File: ".env", RuleID: "aws-access-token"}},
+		Time:       time.Now(),
+	})
+	require.NoError(t, err)
+	public, err := reposcope.NewSetting(0, []reposcope.SettingOverride{{Repositories: []string{"acme/*"}, Value: 1}})
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.Handle("GET /badge/{owner}/{name}", Handler(findings, public, nil, zerolog.Nop()))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/badge/acme/api.svg")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.Contains(t, rec.Body.String(), "1 open finding")
+	assert.Contains(t, rec.Body.String(), `fill="#fe7d37"`, "A nil classifier rates findings high")
+
+	rec = get("/badge/acme/web.json")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var endpoint Endpoint
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&endpoint))
+	assert.Equal(t, "clean", endpoint.Message)
+
+	for _, path := range []string{"/badge/other/api.svg", "/badge/acme/api", "/badge/acme/api.png", "/badge/acme/.svg"} {
+		assert.Equal(t, http.StatusNotFound, get(path).Code, path)
+	}
+}
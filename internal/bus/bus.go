@@ -0,0 +1,92 @@
+// Package bus publishes GitGuard scan results to a message bus.
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/omercnet/gitguard/internal/notify"
+)
+
+// Supported bus backends.
+const (
+	BackendKafka = "kafka"
+	BackendSQS   = "sqs"
+	BackendNATS  = "nats"
+)
+
+// DefaultTopic is the Kafka topic or NATS subject used when none is configured.
+const DefaultTopic = "gitguard.events"
+
+// Message is a keyed message on the bus.
+type Message struct {
+	Key   string
+	Value []byte
+}
+
+// Publisher sends messages to a bus backend.
+type Publisher interface {
+	Publish(ctx context.Context, messages []Message) error
+	// Ping checks that the backend is reachable and accepts the publisher's credentials.
+	Ping(ctx context.Context) error
+}
+
+// Options configures a publisher.
+type Options struct {
+	// URL is the Kafka REST proxy URL, the SQS queue URL or the NATS server URL.
+	URL string
+	// Topic is the Kafka topic or NATS subject; unused for SQS.
+	Topic string
+}
+
+// NewPublisher creates the publisher for backend.
+func NewPublisher(backend string, opts Options) (Publisher, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("%s event bus requires a URL", backend)
+	}
+	if opts.Topic == "" {
+		opts.Topic = DefaultTopic
+	}
+
+	switch backend {
+	case BackendKafka:
+		return newKafkaPublisher(opts.URL, opts.Topic), nil
+	case BackendSQS:
+		return newSQSPublisher(opts.URL)
+	case BackendNATS:
+		return newNATSPublisher(opts.URL, opts.Topic)
+	default:
+		return nil, fmt.Errorf("unsupported event bus backend %q", backend)
+	}
+}
+
+// Notifier is a notify.Notifier publishing one message per completed scan and one per finding.
+// Scan messages are keyed by repository and finding messages by fingerprint, so partitioned
+// backends keep the history of a finding in order.
+type Notifier struct {
+	publisher Publisher
+}
+
+// NewNotifier creates a notifier publishing through publisher.
+func NewNotifier(publisher Publisher) *Notifier {
+	return &Notifier{publisher: publisher}
+}
+
+// Notify publishes the records of event.
+func (n *Notifier) Notify(ctx context.Context, event notify.Event) error {
+	records := notify.Records(event)
+	messages := make([]Message, 0, len(records))
+	for _, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode bus message: %w", err)
+		}
+		key := record.Repository
+		if record.Finding != nil {
+			key = record.Finding.Fingerprint
+		}
+		messages = append(messages, Message{Key: key, Value: value})
+	}
+	return n.publisher.Publish(ctx, messages)
+}
@@ -0,0 +1,175 @@
+package bus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	messages []Message
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, messages []Message) error {
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+func (p *recordingPublisher) Ping(context.Context) error {
+	return nil
+}
+
+func TestNotifier_PublishesScanAndFindings(t *testing.T) {
+	publisher := &recordingPublisher{}
+	event := notify.Event{
+		Type:       notify.EventScanCompleted,
+		Repository: "octo/repo",
+		Details:    []notify.Finding{{File: "a.env", RuleID: "private-key", Fingerprint: "gitguard-1"}},
+	}
+
+	require.NoError(t, NewNotifier(publisher).Notify(context.Background(), event))
+	require.Len(t, publisher.messages, 2)
+
+	assert.Equal(t, "octo/repo", publisher.messages[0].Key)
+	assert.Equal(t, "gitguard-1", publisher.messages[1].Key)
+
+	var record notify.Record
+	require.NoError(t, json.Unmarshal(publisher.messages[1].Value, &record))
+	assert.Equal(t, notify.KindFinding, record.Kind)
+}
+
+func TestNewPublisher_Invalid(t *testing.T) {
+	_, err := NewPublisher("rabbitmq", Options{URL: "amqp://localhost"})
+	assert.Error(t, err)
+
+	_, err = NewPublisher(BackendKafka, Options{})
+	assert.Error(t, err, "Should require a URL")
+
+	_, err = NewPublisher(BackendNATS, Options{URL: "http://localhost:4222"})
+	assert.Error(t, err)
+
+	t.Setenv(secrets.AWSRegionEnv, "")
+	_, err = NewPublisher(BackendSQS, Options{URL: "https://sqs.us-east-1.amazonaws.com/1/q"})
+	assert.ErrorContains(t, err, secrets.AWSRegionEnv)
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.URL.Path != "/topics/gitguard.events" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"gitguard.events"}`))
+			return
+		}
+		assert.Equal(t, "/topics/gitguard.events", r.URL.Path)
+		assert.Equal(t, kafkaContentType, r.Header.Get("Content-Type"))
+
+		var body struct {
+			Records []kafkaRecord `json:"records"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Len(t, body.Records, 1)
+		assert.Equal(t, "k", body.Records[0].Key)
+		assert.JSONEq(t, `{"a":1}`, string(body.Records[0].Value))
+
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":1}]}`))
+	}))
+	defer server.Close()
+
+	publisher, err := NewPublisher(BackendKafka, Options{URL: server.URL})
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), []Message{{Key: "k", Value: []byte(`{"a":1}`)}}))
+	require.NoError(t, publisher.Ping(context.Background()))
+
+	missing, err := NewPublisher(BackendKafka, Options{URL: server.URL, Topic: "missing"})
+	require.NoError(t, err)
+	assert.Error(t, missing.Ping(context.Background()))
+}
+
+func TestSQSPublisher_Batches(t *testing.T) {
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, sqsTarget, r.Header.Get("X-Amz-Target"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/sqs/aws4_request")
+
+		var body struct {
+			QueueURL string     `json:"QueueUrl"`
+			Entries  []sqsEntry `json:"Entries"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.True(t, strings.HasSuffix(body.QueueURL, "/123/events.fifo"))
+		assert.Equal(t, "repo", body.Entries[0].MessageGroupID, "FIFO queues need a group ID")
+		batches = append(batches, len(body.Entries))
+		_, _ = w.Write([]byte(`{"Successful":[]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(secrets.AWSRegionEnv, "us-east-1")
+	t.Setenv(secrets.AWSAccessKeyIDEnv, "AKIDEXAMPLE")
+	t.Setenv(secrets.AWSSecretKeyEnv, "example-secret")
+
+	publisher, err := NewPublisher(BackendSQS, Options{URL: server.URL + "/123/events.fifo"})
+	require.NoError(t, err)
+
+	messages := make([]Message, 12)
+	for i := range messages {
+		messages[i] = Message{Key: "repo", Value: []byte(`{}`)}
+	}
+	require.NoError(t, publisher.Publish(context.Background(), messages))
+	assert.Equal(t, []int{10, 2}, batches)
+}
+
+func TestNATSPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.WriteString(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+		var lines []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+			if line == "PING" {
+				_, _ = io.WriteString(conn, "PONG\r\n")
+				received <- lines
+				return
+			}
+		}
+	}()
+
+	publisher, err := NewPublisher(BackendNATS, Options{URL: "nats://s3cret@" + listener.Addr().String(), Topic: "scans"})
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), []Message{{Key: "k", Value: []byte(`{"a":1}`)}}))
+
+	lines := <-received
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], `"auth_token":"s3cret"`)
+	assert.Equal(t, "PUB scans 7", lines[1])
+	assert.Equal(t, `{"a":1}`, lines[2])
+}
@@ -0,0 +1,128 @@
+package bus
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// natsPublisher publishes to a NATS subject using the core text protocol. Each export opens a
+// short-lived connection and waits for the server's PONG, so publish errors are reported.
+type natsPublisher struct {
+	address  string
+	useTLS   bool
+	subject  string
+	user     string
+	password string
+	token    string
+}
+
+func newNATSPublisher(serverURL, subject string) (*natsPublisher, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q", serverURL)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("invalid NATS URL %q: scheme must be nats or tls", serverURL)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	p := &natsPublisher{address: address, useTLS: u.Scheme == "tls", subject: subject}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			p.user, p.password = u.User.Username(), password
+		} else {
+			p.token = u.User.Username()
+		}
+	}
+	return p, nil
+}
+
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+// Ping connects and authenticates without publishing, waiting for the server's PONG.
+func (p *natsPublisher) Ping(ctx context.Context) error {
+	return p.Publish(ctx, nil)
+}
+
+// Publish sends every message to the subject.
+func (p *natsPublisher) Publish(ctx context.Context, messages []Message) error {
+	dialer := &net.Dialer{Timeout: publishTimeout}
+	var conn net.Conn
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS %s: %w", p.address, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(publishTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	reader := bufio.NewReader(conn)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting from %s", p.address)
+	}
+
+	// NATS servers send INFO in plain text and upgrade the connection afterwards.
+	if p.useTLS {
+		host, _, _ := net.SplitHostPort(p.address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("failed to establish TLS with NATS %s: %w", p.address, err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		Name: "gitguard", User: p.user, Pass: p.password, Token: p.token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode NATS connect: %w", err)
+	}
+
+	writer := bufio.NewWriter(conn)
+	fmt.Fprintf(writer, "CONNECT %s\r\n", connect)
+	for _, message := range messages {
+		fmt.Fprintf(writer, "PUB %s %d\r\n", p.subject, len(message.Value))
+		_, _ = writer.Write(message.Value)
+		_, _ = writer.WriteString("\r\n")
+	}
+	_, _ = writer.WriteString("PING\r\n")
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to publish to NATS: %w", err)
+		}
+		switch {
+		case strings.HasPrefix(line, "PONG"):
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
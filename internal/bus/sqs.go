@@ -0,0 +1,157 @@
+package bus
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/awsauth"
+	"github.com/omercnet/gitguard/internal/secrets"
+)
+
+const (
+	sqsService     = "sqs"
+	sqsTarget      = "AmazonSQS.SendMessageBatch"
+	sqsPingTarget  = "AmazonSQS.GetQueueAttributes"
+	sqsContentType = "application/x-amz-json-1.0"
+	sqsMaxBatch    = 10
+)
+
+// sqsPublisher sends messages to an SQS queue using the AWS JSON protocol. Credentials are
+// read from the same AWS_* environment variables as the AWS secrets backend.
+type sqsPublisher struct {
+	queueURL string
+	endpoint string
+	fifo     bool
+	signer   *awsauth.Signer
+	client   *http.Client
+}
+
+func newSQSPublisher(queueURL string) (*sqsPublisher, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid SQS queue URL %q", queueURL)
+	}
+
+	region := os.Getenv(secrets.AWSRegionEnv)
+	accessKeyID := os.Getenv(secrets.AWSAccessKeyIDEnv)
+	secretKey := os.Getenv(secrets.AWSSecretKeyEnv)
+	if region == "" || accessKeyID == "" || secretKey == "" {
+		return nil, fmt.Errorf("SQS event bus requires %s, %s and %s",
+			secrets.AWSRegionEnv, secrets.AWSAccessKeyIDEnv, secrets.AWSSecretKeyEnv)
+	}
+
+	return &sqsPublisher{
+		queueURL: queueURL,
+		endpoint: u.Scheme + "://" + u.Host + "/",
+		fifo:     strings.HasSuffix(u.Path, ".fifo"),
+		signer: &awsauth.Signer{
+			Service:      sqsService,
+			Region:       region,
+			AccessKeyID:  accessKeyID,
+			SecretKey:    secretKey,
+			SessionToken: os.Getenv(secrets.AWSSessionTokenEnv),
+		},
+		client: &http.Client{Timeout: publishTimeout},
+	}, nil
+}
+
+type sqsEntry struct {
+	ID                     string `json:"Id"`
+	MessageBody            string `json:"MessageBody"`
+	MessageGroupID         string `json:"MessageGroupId,omitempty"`
+	MessageDeduplicationID string `json:"MessageDeduplicationId,omitempty"`
+}
+
+// Publish sends messages in batches of up to ten.
+func (p *sqsPublisher) Publish(ctx context.Context, messages []Message) error {
+	for start := 0; start < len(messages); start += sqsMaxBatch {
+		end := min(start+sqsMaxBatch, len(messages))
+		if err := p.sendBatch(ctx, messages[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping reads the queue's ARN, which fails when the queue does not exist or the credentials
+// are rejected.
+func (p *sqsPublisher) Ping(ctx context.Context) error {
+	payload, err := json.Marshal(map[string]any{"QueueUrl": p.queueURL, "AttributeNames": []string{"QueueArn"}})
+	if err != nil {
+		return fmt.Errorf("failed to encode SQS request: %w", err)
+	}
+	resp, err := p.call(ctx, sqsPingTarget, payload)
+	if err != nil {
+		return fmt.Errorf("failed to reach SQS: %w", err)
+	}
+	return resp.Body.Close()
+}
+
+// call sends a signed request for the AWS JSON protocol action target and returns the
+// response when its status is 200.
+func (p *sqsPublisher) call(ctx context.Context, target string, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", sqsContentType)
+	req.Header.Set("X-Amz-Target", target)
+	p.signer.Sign(req, payload)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("SQS responded with status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (p *sqsPublisher) sendBatch(ctx context.Context, messages []Message) error {
+	entries := make([]sqsEntry, 0, len(messages))
+	for i, message := range messages {
+		entry := sqsEntry{ID: strconv.Itoa(i), MessageBody: string(message.Value)}
+		if p.fifo {
+			sum := sha256.Sum256(message.Value)
+			entry.MessageGroupID = message.Key
+			entry.MessageDeduplicationID = hex.EncodeToString(sum[:])
+		}
+		entries = append(entries, entry)
+	}
+
+	payload, err := json.Marshal(map[string]any{"QueueUrl": p.queueURL, "Entries": entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode SQS batch: %w", err)
+	}
+
+	resp, err := p.call(ctx, sqsTarget, payload)
+	if err != nil {
+		return fmt.Errorf("failed to publish to SQS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Failed []struct {
+			ID      string `json:"Id"`
+			Message string `json:"Message"`
+		} `json:"Failed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode SQS response: %w", err)
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("SQS rejected %d messages: %s", len(result.Failed), result.Failed[0].Message)
+	}
+	return nil
+}
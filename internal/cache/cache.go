@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// HTTPCache is a bounded httpcache.Cache used for ETag-based conditional requests
+// against the GitHub API. Revalidated responses (304) do not count against the rate limit.
+type HTTPCache struct {
+	entries *lru.Cache[string, []byte]
+}
+
+// NewHTTPCache creates an HTTP response cache holding at most size responses.
+func NewHTTPCache(size int) (*HTTPCache, error) {
+	entries, err := lru.New[string, []byte](size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP cache: %w", err)
+	}
+	return &HTTPCache{entries: entries}, nil
+}
+
+// Get returns the cached response for key.
+func (c *HTTPCache) Get(key string) ([]byte, bool) {
+	return c.entries.Get(key)
+}
+
+// Set stores the response for key.
+func (c *HTTPCache) Set(key string, resp []byte) {
+	c.entries.Add(key, resp)
+}
+
+// Delete removes the response for key.
+func (c *HTTPCache) Delete(key string) {
+	c.entries.Remove(key)
+}
+
+// ContentCache caches file contents fetched from the GitHub contents API. Contents at a
+// given blob or commit SHA are immutable, so hits need no request at all.
+type ContentCache struct {
+	entries  *lru.Cache[string, string]
+	maxBytes int64
+
+	// mu guards bytes, the total size of the cached contents.
+	mu    sync.Mutex
+	bytes int64
+}
+
+// NewContentCache creates a content cache holding at most size files, of at most maxBytes
+// in total. Files are as large as the scan size limit, so the number of files alone does
+// not bound its memory; maxBytes of 0 leaves the total size unbounded.
+func NewContentCache(size int, maxBytes int64) (*ContentCache, error) {
+	c := &ContentCache{maxBytes: maxBytes}
+	entries, err := lru.NewWithEvict(size, func(_ string, content string) {
+		c.bytes -= int64(len(content))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create content cache: %w", err)
+	}
+	c.entries = entries
+	return c, nil
+}
+
+// Get returns the cached content of path in repo at sha. A nil cache never hits.
+func (c *ContentCache) Get(repo, path, sha string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	return c.entries.Get(contentKey(repo, path, sha))
+}
+
+// Add stores the content of path in repo at sha, evicting the least recently used files
+// until the cache is within its size. Contents larger than the whole cache and adding to a
+// nil cache are no-ops.
+func (c *ContentCache) Add(repo, path, sha, content string) {
+	size := int64(len(content))
+	if c == nil || c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := contentKey(repo, path, sha)
+	if c.entries.Contains(key) {
+		return
+	}
+	c.entries.Add(key, content)
+	c.bytes += size
+	for c.maxBytes > 0 && c.bytes > c.maxBytes {
+		c.entries.RemoveOldest()
+	}
+}
+
+// Len returns the number of cached files.
+func (c *ContentCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.entries.Len()
+}
+
+// Bytes returns the total size of the cached files.
+func (c *ContentCache) Bytes() int64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+func contentKey(repo, path, sha string) string {
+	return repo + "@" + sha + ":" + path
+}
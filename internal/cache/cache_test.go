@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentCache(t *testing.T) {
+	c, err := NewContentCache(2, 0)
+	require.NoError(t, err)
+
+	_, ok := c.Get("owner/repo", "a.txt", "sha1")
+	assert.False(t, ok, "Should miss on empty cache")
+
+	c.Add("owner/repo", "a.txt", "sha1", "content-a")
+	content, ok := c.Get("owner/repo", "a.txt", "sha1")
+	assert.True(t, ok)
+	assert.Equal(t, "content-a", content)
+
+	_, ok = c.Get("owner/repo", "a.txt", "sha2")
+	assert.False(t, ok, "Should key entries by SHA")
+	_, ok = c.Get("owner/other", "a.txt", "sha1")
+	assert.False(t, ok, "Should key entries by repository")
+
+	c.Add("owner/repo", "b.txt", "sha1", "content-b")
+	c.Add("owner/repo", "c.txt", "sha1", "content-c")
+	assert.Equal(t, 2, c.Len(), "Should evict beyond capacity")
+	_, ok = c.Get("owner/repo", "a.txt", "sha1")
+	assert.False(t, ok, "Should evict the least recently used entry")
+}
+
+func TestContentCache_MaxBytes(t *testing.T) {
+	c, err := NewContentCache(8, 10)
+	require.NoError(t, err)
+
+	c.Add("owner/repo", "a.txt", "sha1", "aaaa")
+	c.Add("owner/repo", "b.txt", "sha1", "bbbb")
+	c.Add("owner/repo", "a.txt", "sha1", "aaaa")
+	assert.Equal(t, int64(8), c.Bytes(), "Should count each file once")
+
+	c.Add("owner/repo", "c.txt", "sha1", "cccc")
+	assert.Equal(t, 2, c.Len(), "Should evict beyond the total size")
+	assert.Equal(t, int64(8), c.Bytes())
+	_, ok := c.Get("owner/repo", "a.txt", "sha1")
+	assert.False(t, ok, "Should evict the least recently used entry")
+
+	c.Add("owner/repo", "large.bin", "sha1", "0123456789a")
+	_, ok = c.Get("owner/repo", "large.bin", "sha1")
+	assert.False(t, ok, "Should not cache files larger than the cache")
+	assert.Equal(t, 2, c.Len(), "Should keep the cached files")
+}
+
+func TestContentCache_Nil(t *testing.T) {
+	var c *ContentCache
+	c.Add("owner/repo", "a.txt", "sha1", "content")
+	_, ok := c.Get("owner/repo", "a.txt", "sha1")
+	assert.False(t, ok, "Nil cache should never hit")
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestHTTPCache(t *testing.T) {
+	c, err := NewHTTPCache(1)
+	require.NoError(t, err)
+
+	c.Set("k1", []byte("v1"))
+	v, ok := c.Get("k1")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("v1"), v)
+
+	c.Set("k2", []byte("v2"))
+	_, ok = c.Get("k1")
+	assert.False(t, ok, "Should evict beyond capacity")
+
+	c.Delete("k2")
+	_, ok = c.Get("k2")
+	assert.False(t, ok)
+}
+
+func TestNewCache_InvalidSize(t *testing.T) {
+	_, err := NewContentCache(0, 0)
+	assert.Error(t, err)
+	_, err = NewHTTPCache(-1)
+	assert.Error(t, err)
+}
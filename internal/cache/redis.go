@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisTimeout bounds every Redis command, so a slow cache never holds up a scan for long.
+const redisTimeout = 2 * time.Second
+
+// errRedisNil is the reply to GET for a missing key.
+var errRedisNil = errors.New("redis: nil")
+
+// redisClient runs Redis commands over the RESP protocol. Each command opens a short-lived
+// connection, authenticating and selecting the database first.
+type redisClient struct {
+	address  string
+	useTLS   bool
+	username string
+	password string
+	db       int
+}
+
+// newRedisClient parses a redis:// or rediss:// (TLS) URL with optional credentials and
+// database, e.g. redis://:password@localhost:6379/1.
+func newRedisClient(redisURL string) (*redisClient, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q", redisURL)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid Redis URL %q: scheme must be redis or rediss", redisURL)
+	}
+
+	c := &redisClient{address: u.Host, useTLS: u.Scheme == "rediss"}
+	if u.Port() == "" {
+		c.address = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis URL %q: database must be a number", redisURL)
+		}
+	}
+	return c, nil
+}
+
+// get returns the value of key, or errRedisNil when it does not exist.
+func (c *redisClient) get(ctx context.Context, key string) ([]byte, error) {
+	return c.do(ctx, "GET", key)
+}
+
+// set stores value under key, expiring after ttl.
+func (c *redisClient) set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// do runs a command, preceded by AUTH and SELECT when configured, and returns its reply.
+func (c *redisClient) do(ctx context.Context, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if c.useTLS {
+		host, _, _ := net.SplitHostPort(c.address)
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", c.address)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", c.address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis %s: %w", c.address, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var commands [][]string
+	switch {
+	case c.password != "" && c.username != "":
+		commands = append(commands, []string{"AUTH", c.username, c.password})
+	case c.password != "":
+		commands = append(commands, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		commands = append(commands, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	commands = append(commands, args)
+
+	writer := bufio.NewWriter(conn)
+	for _, command := range commands {
+		fmt.Fprintf(writer, "*%d\r\n", len(command))
+		for _, arg := range command {
+			fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to send Redis command: %w", err)
+	}
+
+	// Commands are pipelined; the reply to the last one is the result.
+	reader := bufio.NewReader(conn)
+	var reply []byte
+	for range commands {
+		if reply, err = readRedisReply(reader); err != nil {
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+// readRedisReply reads a simple string, error, integer or bulk string reply.
+func readRedisReply(reader *bufio.Reader) ([]byte, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Redis reply: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, fmt.Errorf("redis error: %s", line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis reply %q", line)
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, fmt.Errorf("failed to read Redis reply: %w", err)
+		}
+		return value[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported Redis reply %q", line)
+	}
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// redisKeyPrefix namespaces scan results in a shared Redis.
+const redisKeyPrefix = "gitguard:scan:"
+
+// ScanResult is the outcome of scanning a commit or a tree. Findings carry their GitGuard
+// fingerprint in place of the secret, which is never cached.
+type ScanResult struct {
+	Findings     []report.Finding `json:"findings"`
+	FilesScanned int              `json:"files_scanned"`
+	// Revisit are the files of a tree that are scanned again when its result is reused:
+	// those with findings, to recover their secrets, and Git LFS pointers.
+	Revisit []string `json:"revisit,omitempty"`
+}
+
+// ScanCache caches commit scan results, so a commit pushed to several branches or pushed
+// again is scanned once. Results are kept in memory and, when configured, in Redis, shared
+// by every replica. A nil cache never hits.
+type ScanCache struct {
+	memory *expirable.LRU[string, ScanResult]
+	redis  *redisClient
+	ttl    time.Duration
+}
+
+// NewScanCache creates a scan cache holding at most size results in memory, and in Redis
+// when redisURL is set, each for ttl. A size of 0 disables the memory tier.
+func NewScanCache(size int, redisURL string, ttl time.Duration) (*ScanCache, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("failed to create scan cache: invalid size %d", size)
+	}
+	c := &ScanCache{ttl: ttl}
+	if size > 0 {
+		c.memory = expirable.NewLRU[string, ScanResult](size, nil, ttl)
+	}
+	if redisURL != "" {
+		redis, err := newRedisClient(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scan cache: %w", err)
+		}
+		c.redis = redis
+	}
+	return c, nil
+}
+
+// Ping checks that the cache's Redis, if any, answers.
+func (c *ScanCache) Ping(ctx context.Context) error {
+	if c == nil || c.redis == nil {
+		return nil
+	}
+	_, err := c.redis.do(ctx, "PING")
+	return err
+}
+
+// ScanKey identifies the scan of the diff from one commit to sha in repo with a version of
+// the rules. An empty from is the diff of sha with its parent.
+func ScanKey(rulesVersion, repo, from, sha string) string {
+	return rulesVersion + ":" + repo + ":" + from + ".." + sha
+}
+
+// TreeKey identifies the scan of the git tree at path, "" for the root, with a version of
+// the rules. Trees are content-addressed, so the key holds across commits and repositories.
+func TreeKey(rulesVersion, path, tree string) string {
+	return rulesVersion + ":tree:" + path + ":" + tree
+}
+
+// Get returns the cached result for key. Redis errors are returned alongside a miss.
+func (c *ScanCache) Get(ctx context.Context, key string) (ScanResult, bool, error) {
+	if c == nil {
+		return ScanResult{}, false, nil
+	}
+	if c.memory != nil {
+		if result, ok := c.memory.Get(key); ok {
+			return result, true, nil
+		}
+	}
+	if c.redis == nil {
+		return ScanResult{}, false, nil
+	}
+
+	data, err := c.redis.get(ctx, redisKeyPrefix+key)
+	if errors.Is(err, errRedisNil) {
+		return ScanResult{}, false, nil
+	}
+	if err != nil {
+		return ScanResult{}, false, err
+	}
+	var result ScanResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return ScanResult{}, false, fmt.Errorf("invalid cached scan result: %w", err)
+	}
+	if c.memory != nil {
+		c.memory.Add(key, result)
+	}
+	return result, true, nil
+}
+
+// Add stores the result for key. Adding to a nil cache is a no-op.
+func (c *ScanCache) Add(ctx context.Context, key string, result ScanResult) error {
+	if c == nil {
+		return nil
+	}
+	if c.memory != nil {
+		c.memory.Add(key, result)
+	}
+	if c.redis == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return c.redis.set(ctx, redisKeyPrefix+key, data, c.ttl)
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// fakeRedis serves GET, SET, AUTH and SELECT over RESP from an in-memory map.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	commands []string
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	f := &fakeRedis{values: map[string]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args[0])
+		switch args[0] {
+		case "GET":
+			if value, ok := f.values[args[1]]; ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+			} else {
+				fmt.Fprint(conn, "$-1\r\n")
+			}
+		case "SET":
+			f.values[args[1]] = args[2]
+			fmt.Fprint(conn, "+OK\r\n")
+		case "AUTH":
+			if args[len(args)-1] != "s3cret" {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+			} else {
+				fmt.Fprint(conn, "+OK\r\n")
+			}
+		default:
+			fmt.Fprint(conn, "+OK\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestScanCache_Memory(t *testing.T) {
+	c, err := NewScanCache(10, "", time.Hour)
+	require.NoError(t, err)
+	ctx := context.Background()
+	key := ScanKey("v1", "owner/repo", "", "abc")
+
+	_, ok, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	result := ScanResult{Findings: []report.Finding{{RuleID: "aws-access-token"}}, FilesScanned: 3}
+	require.NoError(t, c.Add(ctx, key, result))
+	cached, ok, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, result, cached)
+
+	_, ok, _ = c.Get(ctx, ScanKey("v2", "owner/repo", "", "abc"))
+	assert.False(t, ok, "Should key results by rules version")
+}
+
+func TestScanCache_Redis(t *testing.T) {
+	server, address := startFakeRedis(t)
+	ctx := context.Background()
+	key := ScanKey("v1", "owner/repo", "", "abc")
+	result := ScanResult{Findings: []report.Finding{{RuleID: "private-key", File: "id_rsa"}}, FilesScanned: 1}
+
+	writer, err := NewScanCache(0, "redis://:s3cret@"+address+"/2", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, writer.Add(ctx, key, result))
+
+	// Another replica, with its own memory tier, reads the result from Redis.
+	reader, err := NewScanCache(10, "redis://:s3cret@"+address+"/2", time.Hour)
+	require.NoError(t, err)
+	cached, ok, err := reader.Get(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, result, cached)
+	_, ok, err = reader.Get(ctx, ScanKey("v1", "owner/repo", "", "other"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	server.mu.Lock()
+	assert.Contains(t, server.commands, "SELECT")
+	server.mu.Unlock()
+
+	wrongPassword, err := NewScanCache(0, "redis://:nope@"+address, time.Hour)
+	require.NoError(t, err)
+	_, _, err = wrongPassword.Get(ctx, key)
+	assert.ErrorContains(t, err, "WRONGPASS")
+
+	require.NoError(t, reader.Ping(ctx))
+	assert.ErrorContains(t, wrongPassword.Ping(ctx), "WRONGPASS")
+}
+
+func TestScanCache_Nil(t *testing.T) {
+	var c *ScanCache
+	require.NoError(t, c.Ping(context.Background()))
+	require.NoError(t, c.Add(context.Background(), "key", ScanResult{}))
+	_, ok, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNewScanCache_InvalidRedisURL(t *testing.T) {
+	_, err := NewScanCache(1, "http://localhost", time.Hour)
+	assert.Error(t, err)
+}
@@ -1,10 +1,37 @@
+// Package config is GitGuard's single configuration system, used by the server, the
+// reloader and every command. Sources are layered: built-in defaults, then the YAML config
+// file, then environment variables, then the server's command-line flags, then the secret
+// manager backend.
 package config
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/auth"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/filenames"
+	"github.com/omercnet/gitguard/internal/labels"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/pathrules"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/policy"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/secrets"
+	"github.com/omercnet/gitguard/internal/severity"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -13,34 +40,611 @@ const (
 	GitHubWebhookSecretEnv     = "GITHUB_WEBHOOK_SECRET"      // #nosec G101 -- This is an env var name, not a secret
 	GitHubPrivateKeyFileEnv    = "GITHUB_PRIVATE_KEY_FILE"    // #nosec G101 -- This is an env var name, not a secret
 	GitHubPrivateKeyEnv        = "GITHUB_PRIVATE_KEY"         // #nosec G101 -- This is an env var name, not a secret
+	GitHubPrivateKeyFilesEnv   = "GITHUB_PRIVATE_KEY_FILES"   // #nosec G101 -- This is an env var name, not a secret
 	GitHubAppIDEnv             = "GITHUB_APP_ID"
 	PortEnv                    = "PORT"
+	ClientCacheSizeEnv         = "GITHUB_CLIENT_CACHE_SIZE"
+	HTTPCacheSizeEnv           = "GITHUB_HTTP_CACHE_SIZE"
+	ContentCacheSizeEnv        = "CONTENT_CACHE_SIZE"
+	ContentCacheMBEnv          = "CONTENT_CACHE_MB"
+	BasePathEnv                = "BASE_PATH"
+	WebhookPathEnv             = "WEBHOOK_PATH"
+	MaxPayloadBytesEnv         = "MAX_WEBHOOK_PAYLOAD_BYTES"
+	WebhookIPAllowlistEnv      = "WEBHOOK_IP_ALLOWLIST"
+	WebhookTrustedProxiesEnv   = "WEBHOOK_TRUSTED_PROXIES"
+	WebhookAllowlistRefreshEnv = "WEBHOOK_IP_ALLOWLIST_REFRESH"
+	ReadinessDependenciesEnv   = "READINESS_CHECK_DEPENDENCIES"
+	ReadinessTimeoutEnv        = "READINESS_TIMEOUT"
+	WebhookRecordDirEnv        = "WEBHOOK_RECORD_DIR"
+	SeverityDefaultEnv         = "SEVERITY_DEFAULT"
+	SeverityRulesEnv           = "SEVERITY_RULES"
+	SLAWindowsEnv              = "SLA_WINDOWS"
+	SLAMilestonesEnv           = "SLA_MILESTONES"
+	CheckNeutralMaxEnv         = "CHECK_NEUTRAL_MAX_SEVERITY"
+	CheckActionRequiredMinEnv  = "CHECK_ACTION_REQUIRED_MIN_SEVERITY"
+	SecretsBackendEnv          = "SECRETS_BACKEND"
+	SecretsWebhookSecretRefEnv = "SECRETS_WEBHOOK_SECRET_REF" // #nosec G101 -- This is an env var name, not a secret
+	SecretsPrivateKeyRefEnv    = "SECRETS_PRIVATE_KEY_REF"    // #nosec G101 -- This is an env var name, not a secret
+	SecretsRefreshIntervalEnv  = "SECRETS_REFRESH_INTERVAL"   // #nosec G101 -- This is an env var name, not a secret
+	RemediationPREnv           = "REMEDIATION_PR_ENABLED"
+	PullRequestGateEnv         = "PULL_REQUEST_GATE_ENABLED"
+	RotationTicketsEnv         = "ROTATION_TICKETS"
+	JiraURLEnv                 = "JIRA_URL"
+	JiraUserEnv                = "JIRA_USER"
+	JiraTokenEnv               = "JIRA_TOKEN" // #nosec G101 -- This is an env var name, not a secret
+	JiraProjectEnv             = "JIRA_PROJECT"
+	JiraIssueTypeEnv           = "JIRA_ISSUE_TYPE"
+	NotifyWebhookURLsEnv       = "NOTIFY_WEBHOOK_URLS"
+	NotifyWebhookSecretEnv     = "NOTIFY_WEBHOOK_SECRET" // #nosec G101 -- This is an env var name, not a secret
+	PagerDutyRoutingKeyEnv     = "PAGERDUTY_ROUTING_KEY"
+	OpsgenieAPIKeyEnv          = "OPSGENIE_API_KEY"
+	OpsgenieAPIURLEnv          = "OPSGENIE_API_URL"
+	SIEMFormatEnv              = "SIEM_FORMAT"
+	SIEMSyslogAddressEnv       = "SIEM_SYSLOG_ADDRESS"
+	SplunkHECURLEnv            = "SPLUNK_HEC_URL"
+	SplunkHECTokenEnv          = "SPLUNK_HEC_TOKEN" // #nosec G101 -- This is an env var name, not a secret
+	EventBusEnv                = "EVENT_BUS"
+	EventBusURLEnv             = "EVENT_BUS_URL"
+	EventBusTopicEnv           = "EVENT_BUS_TOPIC"
+	ChatWebhooksEnv            = "CHAT_WEBHOOKS"
+	RulePackURLsEnv            = "RULE_PACK_URLS"
+	RulePackCacheDirEnv        = "RULE_PACK_CACHE_DIR"
+	RulePackTTLEnv             = "RULE_PACK_TTL"
+	RulePackPublicKeyEnv       = "RULE_PACK_PUBLIC_KEY"
+	SecretAllowlistEnv         = "SECRET_ALLOWLIST"
+	SecretAllowlistURLsEnv     = "SECRET_ALLOWLIST_URLS"
+	ConfigFileEnv              = "CONFIG_FILE"
+	DisableGenericRulesEnv     = "DISABLE_GENERIC_RULES"
+	GenericRulesEntropyEnv     = "GENERIC_RULES_ENTROPY"
+	GenericRulesMinLengthEnv   = "GENERIC_RULES_MIN_LENGTH"
+	BaselineEnabledEnv         = "BASELINE_ENABLED"
+	StorePathEnv               = "STORE_PATH"
+	FindingTrackingEnv         = "FINDING_TRACKING_ENABLED"
+	FindingNewDaysEnv          = "FINDING_NEW_DAYS"
+	FindingDeduplicationEnv    = "FINDING_DEDUPLICATION_ENABLED"
+	FindingRedactionEnv        = "FINDING_REDACTION"
+	FindingMaskPercentEnv      = "FINDING_MASK_PERCENT"
+	ReportRepositoryEnv        = "REPORT_REPOSITORY"
+	ReportOrganizationsEnv     = "REPORT_ORGANIZATIONS"
+	ReportIntervalEnv          = "REPORT_INTERVAL"
+	GitLabURLEnv               = "GITLAB_URL"
+	GitLabTokenEnv             = "GITLAB_TOKEN"          // #nosec G101 -- This is an env var name, not a secret
+	GitLabWebhookSecretEnv     = "GITLAB_WEBHOOK_SECRET" // #nosec G101 -- This is an env var name, not a secret
+	GitLabWebhookPathEnv       = "GITLAB_WEBHOOK_PATH"
+	GRPCPortEnv                = "GRPC_PORT"
+	GRPCAuthTokenEnv           = "GRPC_AUTH_TOKEN"    // #nosec G101 -- This is an env var name, not a secret
+	AdminTokenEnv              = "ADMIN_TOKEN"        // #nosec G101 -- This is an env var name, not a secret
+	AdminViewerTokenEnv        = "ADMIN_VIEWER_TOKEN" // #nosec G101 -- This is an env var name, not a secret
+	AdminOIDCIssuerEnv         = "ADMIN_OIDC_ISSUER"
+	AdminOIDCAudienceEnv       = "ADMIN_OIDC_AUDIENCE"
+	RepositoriesIncludeEnv     = "REPOSITORIES_INCLUDE"
+	RepositoriesExcludeEnv     = "REPOSITORIES_EXCLUDE"
+	SkipArchivedEnv            = "SKIP_ARCHIVED_REPOSITORIES"
+	SkipForksEnv               = "SKIP_FORK_REPOSITORIES"
+	HeadOnlyThresholdEnv       = "HEAD_ONLY_COMMIT_THRESHOLD"
+	ScanCacheSizeEnv           = "SCAN_CACHE_SIZE"
+	ScanCacheRedisURLEnv       = "SCAN_CACHE_REDIS_URL"
+	ScanCacheTTLEnv            = "SCAN_CACHE_TTL"
+	LFSMaxBytesEnv             = "FULL_SCAN_LFS_MAX_BYTES"
+	CommitScanEnabledEnv       = "COMMIT_SCAN_ENABLED"
+	FullScanEnabledEnv         = "FULL_SCAN_ENABLED"
+	CloneBaseURLEnv            = "FULL_SCAN_CLONE_BASE_URL"
+	CloneProxyURLEnv           = "FULL_SCAN_CLONE_PROXY_URL"
+	IssueGroupingEnv           = "FULL_SCAN_ISSUES"
+	NativeAlertsEnv            = "FULL_SCAN_NATIVE_ALERTS"
+	FullScanMaxSizeEnv         = "FULL_SCAN_MAX_SIZE_MB"
+	FullScanMaxFilesEnv        = "FULL_SCAN_MAX_FILES"
+	CommitScanReportingEnv     = "COMMIT_SCAN_REPORTING"
+	CommitScanMaxFileBytesEnv  = "COMMIT_SCAN_MAX_FILE_BYTES"
+	CommitScanSummaryCheckEnv  = "COMMIT_SCAN_SUMMARY_CHECK"
+	CommitScanCommitChecksEnv  = "COMMIT_SCAN_COMMIT_CHECKS"
+	RateLimitConcurrencyEnv    = "RATE_LIMIT_CONCURRENCY"
+	RateLimitMaxShareEnv       = "RATE_LIMIT_MAX_SHARE"
+	RateLimitReserveEnv        = "RATE_LIMIT_RESERVE"
+	RateLimitMaxQueuedEnv      = "RATE_LIMIT_MAX_QUEUED"
+	CheckRunDetailsURLEnv      = "CHECK_RUN_DETAILS_URL"
+	PIIEnabledEnv              = "PII_DETECTION_ENABLED"
+	FilenameRulesEnabledEnv    = "FILENAME_RULES_ENABLED"
+	DryRunEnv                  = "DRY_RUN"
+	EnforcementRolloutEnv      = "ENFORCEMENT_ROLLOUT_PERCENT"
+	MessagesDirEnv             = "MESSAGES_DIR"
+	BadgesEnabledEnv           = "BADGES_ENABLED"
+	DispatchEnabledEnv         = "REPOSITORY_DISPATCH_ENABLED"
+	MessagesLocaleEnv          = "MESSAGES_LOCALE"
 
 	// Default values.
 	DefaultGitHubAPIURL     = "https://api.github.com/"
 	DefaultGitHubGraphQLURL = "https://api.github.com/graphql"
 	DefaultPort             = 8080
+	DefaultClientCacheSize  = 64
+	DefaultHTTPCacheSize    = 256
+	DefaultContentCacheSize = 1024
+	DefaultContentCacheMB   = 64
+	DefaultScanCacheSize    = 1024
+	DefaultScanCacheTTL     = 24 * time.Hour
+	DefaultRateLimitShare   = 0.5
+	DefaultRateLimitReserve = 0.1
+	DefaultRolloutPercent   = 100
+	DefaultMaskPercent      = 75
+	DefaultFullScanMaxSize  = 1024     // MB; full scans clone into memory.
+	DefaultMaxPayloadBytes  = 25 << 20 // GitHub caps webhook payloads at 25 MB.
+	DefaultWebhookPath      = "/"
+	DefaultRulePackCacheDir = "gitguard-rule-packs"
+	DefaultConfigFile       = "config.yml"
+	DefaultStorePath        = "gitguard-store.json"
+	DefaultGitLabURL        = "https://gitlab.com"
+	DefaultGitLabPath       = "/gitlab"
+	externalSecretsTimeout  = 30 * time.Second
 
 	// Error messages.
 	ErrWebhookSecretRequired = "GITHUB_WEBHOOK_SECRET is required" // #nosec G101 -- This is an error message, not a secret
 	ErrAppIDRequired         = "GITHUB_APP_ID is required"
-	ErrPrivateKeyRequired    = "either GITHUB_PRIVATE_KEY or GITHUB_PRIVATE_KEY_FILE is required"
+	ErrPrivateKeyRequired    = "one of GITHUB_PRIVATE_KEY, GITHUB_PRIVATE_KEY_FILE or GITHUB_PRIVATE_KEY_FILES is required"
+	ErrGRPCAuthTokenRequired = "GRPC_AUTH_TOKEN is required when GRPC_PORT is set" // #nosec G101 -- This is an error message, not a secret
+	ErrReadPrivateKeyFile    = "failed to read private key file %s: %w"
+	ErrExternalSecrets       = "failed to load secrets from %s backend: %w"
+	ErrInvalidSeverity       = "invalid severity configuration: %w"
+	ErrInvalidChatWebhook    = "invalid CHAT_WEBHOOKS entry %q: expected [installation:]format=url"
+	ErrReadConfigFile        = "failed to read config file %s: %w"
+	ErrInvalidRules          = "invalid rules configuration: %w"
+	ErrInvalidPathOverrides  = "invalid path overrides: %w"
+	ErrInvalidRepositories   = "invalid repositories configuration: %w"
+	ErrInvalidPush           = "invalid push configuration: %w"
+	ErrInvalidPII            = "invalid PII detection configuration: %w"
+	ErrInvalidFilenames      = "invalid file name rules: %w"
+	ErrInvalidDryRun         = "invalid dry run configuration: %w"
+	ErrInvalidBadges         = "invalid badges configuration: %w"
+	ErrInvalidDispatch       = "invalid repository dispatch configuration: %w"
+	ErrInvalidEnforcement    = "invalid enforcement configuration: %w"
+	ErrInvalidMessages       = "invalid messages configuration: %w"
+	ErrInvalidTemplates      = "invalid templates configuration: %w"
+	ErrInvalidPolicy         = "invalid policy configuration: %w"
+	ErrInvalidPlugins        = "invalid detector plugins: %w"
+	ErrInvalidClone          = "invalid full scan clone configuration: %w"
+	ErrInvalidIssueGrouping  = "invalid full_scan.issues %q: expected single, rule or directory"
+	ErrInvalidIssueLabels    = "invalid full_scan.labels: %w"
+	ErrInvalidSLA            = "invalid sla configuration: %w"
+	ErrInvalidReporting      = "invalid push.reporting %q: expected checks, comments or both"
+	ErrInvalidTickets        = "invalid tickets configuration: %w"
+	ErrInvalidRedaction      = "invalid findings.redaction %q: expected none, mask or hash"
+	ErrInvalidMaskPercent    = "invalid findings.mask_percent %d: expected %d to 100"
+	ErrInvalidReports        = "invalid reports configuration: %w"
+	ErrInvalidRateLimit      = "invalid rate limit configuration: %w"
+	ErrInvalidAdmin          = "invalid admin configuration: %w"
+	ErrInvalidGitHubApp      = "invalid github.apps[%d] configuration: %w"
 )
 
 // Config holds the application configuration.
 type Config struct {
 	Github struct {
-		WebhookSecret string `yaml:"webhook_secret"`
-		AppID         int64  `yaml:"app_id"`
-		PrivateKey    string `yaml:"private_key"`
-		APIURL        string `yaml:"api_url"`
-		GraphQLURL    string `yaml:"graphql_url"`
+		WebhookSecret string   `yaml:"webhook_secret" secret:"true"`
+		AppID         int64    `yaml:"app_id"`
+		PrivateKey    string   `yaml:"private_key" secret:"true"`
+		PrivateKeys   []string `yaml:"private_keys" secret:"true"`
+		APIURL        string   `yaml:"api_url"`
+		GraphQLURL    string   `yaml:"graphql_url"`
+		ClientCache   int      `yaml:"client_cache_size"`
+		HTTPCache     int      `yaml:"http_cache_size"`
+		ContentCache  int      `yaml:"content_cache_size"`
+		// ContentCacheMB bounds the total size of the cached contents; 0 is unbounded.
+		ContentCacheMB int `yaml:"content_cache_mb"`
+		// Apps are additional App identities, e.g. one per GitHub Enterprise Server instance.
+		Apps []GitHubApp `yaml:"apps"`
 	} `yaml:"github"`
 	Server struct {
-		Port int `yaml:"port"`
+		Port              int           `yaml:"port"`
+		BasePath          string        `yaml:"base_path"`
+		WebhookPath       string        `yaml:"webhook_path"`
+		MaxPayloadBytes   int64         `yaml:"max_payload_bytes"`
+		HookIPAllowlist   bool          `yaml:"hook_ip_allowlist"`
+		TrustedProxies    []string      `yaml:"trusted_proxies"`
+		HookRangesRefresh time.Duration `yaml:"hook_ranges_refresh"`
+		// ReadinessDependencies makes /readyz ping the scan cache's Redis, the store and the
+		// event bus, each for at most ReadinessTimeout.
+		ReadinessDependencies bool          `yaml:"readiness_dependencies"`
+		ReadinessTimeout      time.Duration `yaml:"readiness_timeout"`
+		// RecordDir, when set, is the directory accepted webhook deliveries are saved to as
+		// fixtures for gitguard replay. Payloads are saved as received: for development only.
+		RecordDir string `yaml:"record_dir"`
 	} `yaml:"server"`
+	Severity struct {
+		Default           string            `yaml:"default"`
+		Rules             map[string]string `yaml:"rules"`
+		NeutralMax        string            `yaml:"neutral_max"`
+		ActionRequiredMin string            `yaml:"action_required_min"`
+	} `yaml:"severity"`
+	// SLA sets how long findings of each severity may stay open: security issues get a due
+	// date and organization reports flag the findings past theirs.
+	SLA struct {
+		// Windows maps severities to how long their findings may stay open, such as 24h.
+		Windows map[string]time.Duration `yaml:"windows"`
+		// Milestones assigns security issues to a milestone due when their SLA ends instead
+		// of commenting their due date.
+		Milestones bool `yaml:"milestones"`
+	} `yaml:"sla"`
+	Secrets struct {
+		Backend          string        `yaml:"backend"`
+		WebhookSecretRef string        `yaml:"webhook_secret_ref"`
+		PrivateKeyRef    string        `yaml:"private_key_ref"`
+		RefreshInterval  time.Duration `yaml:"refresh_interval"`
+	} `yaml:"secrets"`
+	Remediation struct {
+		PullRequests bool `yaml:"pull_requests"`
+	} `yaml:"remediation"`
+	// PullRequests gates pull requests that add secrets until they are removed, rotated or
+	// dismissed with a justification.
+	PullRequests struct {
+		Gate bool `yaml:"gate"`
+	} `yaml:"pull_requests"`
+	// Tickets adds an action to commit check runs failing on findings that files a ticket to
+	// rotate the secrets: a repository issue ("github") or a Jira issue ("jira").
+	Tickets struct {
+		Rotation string `yaml:"rotation"`
+		Jira     struct {
+			URL       string `yaml:"url"`
+			User      string `yaml:"user"`
+			Token     string `yaml:"token" secret:"true"`
+			Project   string `yaml:"project"`
+			IssueType string `yaml:"issue_type"`
+		} `yaml:"jira"`
+	} `yaml:"tickets"`
+	Notify struct {
+		WebhookURLs   []string      `yaml:"webhook_urls" secret:"true"`
+		WebhookSecret string        `yaml:"webhook_secret" secret:"true"`
+		Chat          []ChatWebhook `yaml:"chat"`
+	} `yaml:"notify"`
+	Alerts struct {
+		PagerDutyRoutingKey string `yaml:"pagerduty_routing_key" secret:"true"`
+		OpsgenieAPIKey      string `yaml:"opsgenie_api_key" secret:"true"`
+		OpsgenieAPIURL      string `yaml:"opsgenie_api_url"`
+	} `yaml:"alerts"`
+	SIEM struct {
+		Format         string `yaml:"format"`
+		SyslogAddress  string `yaml:"syslog_address"`
+		SplunkHECURL   string `yaml:"splunk_hec_url"`
+		SplunkHECToken string `yaml:"splunk_hec_token" secret:"true"`
+	} `yaml:"siem"`
+	Detector struct {
+		RulePacks         []string      `yaml:"rule_packs"`
+		RulePackCacheDir  string        `yaml:"rule_pack_cache_dir"`
+		RulePackTTL       time.Duration `yaml:"rule_pack_ttl"`
+		RulePackPublicKey string        `yaml:"rule_pack_public_key"`
+		Generic           struct {
+			Disabled  bool    `yaml:"disabled"`
+			Entropy   float64 `yaml:"entropy"`
+			MinLength int     `yaml:"min_length"`
+		} `yaml:"generic"`
+		Allowlist struct {
+			Secrets []string `yaml:"secrets"`
+			URLs    []string `yaml:"urls"`
+		} `yaml:"allowlist"`
+		PII struct {
+			Enabled      bool          `yaml:"enabled"`
+			Repositories []PIIOverride `yaml:"repositories"`
+		} `yaml:"pii"`
+		Filenames struct {
+			Enabled      bool               `yaml:"enabled"`
+			Patterns     []string           `yaml:"patterns"`
+			Repositories []FilenameOverride `yaml:"repositories"`
+		} `yaml:"filenames"`
+		Plugins []Plugin `yaml:"plugins"`
+	} `yaml:"detector"`
+	EventBus struct {
+		Backend string `yaml:"backend"`
+		URL     string `yaml:"url"`
+		Topic   string `yaml:"topic"`
+	} `yaml:"event_bus"`
+	Store struct {
+		Path string `yaml:"path"`
+	} `yaml:"store"`
+	Baseline struct {
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"baseline"`
+	Findings struct {
+		Tracking bool `yaml:"tracking"`
+		// NewDays, when positive, is how many days findings count as new after they are first
+		// seen; older findings are long-standing.
+		NewDays int `yaml:"new_days"`
+		// Deduplicate tracks the branches each finding is detected on and notifies a finding
+		// once, however many branches and pull requests it is pushed to.
+		Deduplicate bool `yaml:"deduplicate"`
+		// Redaction decides how secrets appear in stored and reported findings: not at all
+		// ("none"), as a masked preview hiding MaskPercent of each secret ("mask") or as a
+		// SHA-256 hash ("hash").
+		Redaction   string `yaml:"redaction"`
+		MaskPercent int    `yaml:"mask_percent"`
+	} `yaml:"findings"`
+	// Reports publishes a markdown report of the tracked findings of each of Organizations
+	// to the private Repository, owner/name, every Interval: one file per organization per
+	// week.
+	Reports struct {
+		Repository    string        `yaml:"repository"`
+		Organizations []string      `yaml:"organizations"`
+		Interval      time.Duration `yaml:"interval"`
+	} `yaml:"reports"`
+	GitLab struct {
+		URL           string `yaml:"url"`
+		Token         string `yaml:"token" secret:"true"`
+		WebhookSecret string `yaml:"webhook_secret" secret:"true"`
+		WebhookPath   string `yaml:"webhook_path"`
+	} `yaml:"gitlab"`
+	GRPC struct {
+		Port      int    `yaml:"port"`
+		AuthToken string `yaml:"auth_token" secret:"true"`
+	} `yaml:"grpc"`
+	Admin struct {
+		// Token grants the admin role.
+		Token       string       `yaml:"token" secret:"true"`
+		ViewerToken string       `yaml:"viewer_token" secret:"true"`
+		Tokens      []AdminToken `yaml:"tokens"`
+		OIDC        struct {
+			Issuer    string `yaml:"issuer"`
+			Audience  string `yaml:"audience"`
+			RoleClaim string `yaml:"role_claim"`
+			// Roles maps values of the role claim to viewer or admin.
+			Roles       map[string]string `yaml:"roles"`
+			DefaultRole string            `yaml:"default_role"`
+		} `yaml:"oidc"`
+	} `yaml:"admin"`
+	Repositories struct {
+		Include       []string          `yaml:"include"`
+		Exclude       []string          `yaml:"exclude"`
+		SkipArchived  bool              `yaml:"skip_archived"`
+		SkipForks     bool              `yaml:"skip_forks"`
+		Installations []RepositoryScope `yaml:"installations"`
+	} `yaml:"repositories"`
+	Push struct {
+		CommitScans       bool           `yaml:"commit_scans"`
+		HeadOnlyThreshold int            `yaml:"head_only_threshold"`
+		Repositories      []PushOverride `yaml:"repositories"`
+		// Reporting reports commit scans on check runs ("checks"), as commit comments
+		// ("comments"), for Apps without the checks permission, or both ("both").
+		Reporting string `yaml:"reporting"`
+		// MaxFileBytes is the size up to which changed files over the 1 MB the contents API
+		// returns are downloaded from their blob and scanned; 0 leaves them unscanned.
+		MaxFileBytes int64 `yaml:"max_file_bytes"`
+		// SummaryCheck reports the results of the commits of pushes of several commits on a
+		// summary check run on the head commit; with CommitChecks false, the commits get no
+		// check run of their own.
+		SummaryCheck bool `yaml:"summary_check"`
+		CommitChecks bool `yaml:"commit_checks"`
+	} `yaml:"push"`
+	ScanCache struct {
+		Size     int           `yaml:"size"`
+		RedisURL string        `yaml:"redis_url"`
+		TTL      time.Duration `yaml:"ttl"`
+	} `yaml:"scan_cache"`
+	FullScan struct {
+		Enabled     bool  `yaml:"enabled"`
+		LFSMaxBytes int64 `yaml:"lfs_max_bytes"`
+		// Issues groups findings into security issues: one per repository ("single"), per
+		// rule ("rule") or per top-level directory ("directory").
+		Issues string `yaml:"issues"`
+		// Labels are the labels of security issues, created in repositories that lack them.
+		// Empty means the security label alone.
+		Labels []IssueLabel `yaml:"labels"`
+		// NativeAlerts leaves findings GitHub secret scanning already alerted on out of full
+		// scans.
+		NativeAlerts bool `yaml:"native_alerts"`
+		// MaxSizeMB and MaxFiles, when positive, skip full scans of larger repositories, which
+		// are reported on a neutral check run instead of being cloned.
+		MaxSizeMB int `yaml:"max_size_mb"`
+		MaxFiles  int `yaml:"max_files"`
+		Clone     struct {
+			BaseURL  string         `yaml:"base_url"`
+			ProxyURL string         `yaml:"proxy_url" secret:"true"`
+			Rewrites []CloneRewrite `yaml:"rewrites"`
+		} `yaml:"clone"`
+	} `yaml:"full_scan"`
+	RateLimit struct {
+		Concurrency int     `yaml:"concurrency"`
+		MaxShare    float64 `yaml:"max_share"`
+		Reserve     float64 `yaml:"reserve"`
+		MaxQueued   int     `yaml:"max_queued"`
+	} `yaml:"rate_limit"`
+	Checks struct {
+		DetailsURL string `yaml:"details_url"`
+	} `yaml:"checks"`
+	Policy struct {
+		Rules []PolicyRule `yaml:"rules"`
+	} `yaml:"policy"`
+	// DryRun reports findings without enforcing them, so new deployments can observe noise
+	// levels before checks fail.
+	DryRun struct {
+		Enabled      bool             `yaml:"enabled"`
+		Repositories []DryRunOverride `yaml:"repositories"`
+	} `yaml:"dry_run"`
+	// Enforcement ramps enforcement up gradually: repositories outside the rollout are scanned
+	// as dry runs.
+	Enforcement struct {
+		RolloutPercent int                   `yaml:"rollout_percent"`
+		Repositories   []EnforcementOverride `yaml:"repositories"`
+	} `yaml:"enforcement"`
+	// Badges serves the status of repositories as public badges, for those that opt in.
+	Badges struct {
+		Enabled      bool            `yaml:"enabled"`
+		Repositories []BadgeOverride `yaml:"repositories"`
+	} `yaml:"badges"`
+	// Dispatch fires a repository_dispatch event on repositories whose scans find secrets, for
+	// those that opt in, so their own workflows can follow up.
+	Dispatch struct {
+		Enabled      bool               `yaml:"enabled"`
+		Repositories []DispatchOverride `yaml:"repositories"`
+	} `yaml:"dispatch"`
+	// Messages translates or rewords check runs and issues with the message catalogs of Dir.
+	Messages struct {
+		Dir           string          `yaml:"dir"`
+		Locale        string          `yaml:"locale"`
+		Installations []MessageLocale `yaml:"installations"`
+	} `yaml:"messages"`
+	// Templates replace the security issue body and check run summaries with Go templates.
+	Templates struct {
+		IssueBody    string `yaml:"issue_body"`
+		CheckSummary string `yaml:"check_summary"`
+	} `yaml:"templates"`
+	Rules         []Rule         `yaml:"rules"`
+	PathOverrides []PathOverride `yaml:"path_overrides"`
+}
+
+// PushOverride sets how pushes to repositories matching its globs are scanned. The first
+// matching override wins.
+type PushOverride struct {
+	Repositories      []string `yaml:"repositories"`
+	HeadOnlyThreshold int      `yaml:"head_only_threshold"`
+}
+
+// GitHubApp is a GitHub App identity. Webhook deliveries are handled with the credentials of
+// the App they are addressed to. Empty URLs default to GitHub.com; the GraphQL URL of a
+// GitHub Enterprise Server API URL ending in /api/v3/ is derived from it.
+type GitHubApp struct {
+	AppID          int64    `yaml:"app_id"`
+	WebhookSecret  string   `yaml:"webhook_secret" secret:"true"`
+	PrivateKey     string   `yaml:"private_key" secret:"true"`
+	PrivateKeyFile string   `yaml:"private_key_file"`
+	PrivateKeys    []string `yaml:"private_keys" secret:"true"`
+	APIURL         string   `yaml:"api_url"`
+	GraphQLURL     string   `yaml:"graphql_url"`
+}
+
+// Keys returns the App's private key followed by any additional rotation keys.
+func (a GitHubApp) Keys() []string {
+	keys := make([]string, 0, 1+len(a.PrivateKeys))
+	if a.PrivateKey != "" {
+		keys = append(keys, a.PrivateKey)
+	}
+	return append(keys, a.PrivateKeys...)
+}
+
+// AdminToken is a static admin API token and the role, viewer or admin, it grants.
+type AdminToken struct {
+	Token string `yaml:"token" secret:"true"`
+	Role  string `yaml:"role"`
+}
+
+// PIIOverride enables or disables the PII rules for repositories matching its globs. The
+// first matching override wins.
+type PIIOverride struct {
+	Repositories []string `yaml:"repositories"`
+	Enabled      bool     `yaml:"enabled"`
+}
+
+// FilenameOverride enables or disables the file name rules for repositories matching its
+// globs. The first matching override wins.
+type FilenameOverride struct {
+	Repositories []string `yaml:"repositories"`
+	Enabled      bool     `yaml:"enabled"`
+}
+
+// DryRunOverride enables or disables dry runs for repositories matching its globs. The first
+// matching override wins.
+type DryRunOverride struct {
+	Repositories []string `yaml:"repositories"`
+	Enabled      bool     `yaml:"enabled"`
+}
+
+// BadgeOverride serves, or does not serve, the badges of repositories matching its globs.
+// The first matching override wins.
+type BadgeOverride struct {
+	Repositories []string `yaml:"repositories"`
+	Enabled      bool     `yaml:"enabled"`
+}
+
+// DispatchOverride fires, or does not fire, repository_dispatch events on repositories
+// matching its globs. The first matching override wins.
+type DispatchOverride struct {
+	Repositories []string `yaml:"repositories"`
+	Enabled      bool     `yaml:"enabled"`
+}
+
+// EnforcementOverride always enforces, or never enforces, findings in repositories matching
+// its globs, whatever the rollout percentage. The first matching override wins.
+type EnforcementOverride struct {
+	Repositories []string `yaml:"repositories"`
+	Enabled      bool     `yaml:"enabled"`
+}
+
+// MessageLocale sets the locale of an installation's check runs and issues.
+type MessageLocale struct {
+	Installation int64  `yaml:"installation_id"`
+	Locale       string `yaml:"locale"`
 }
 
+// IssueLabel is a label of security issues. A label with a severity only goes on issues
+// whose highest severity it is; the others go on every issue.
+type IssueLabel struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description"`
+	Severity    string `yaml:"severity"`
+}
+
+// CloneRewrite replaces the From prefix of clone URLs with To, like git's insteadOf.
+type CloneRewrite struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Plugin is an additional detector loaded at runtime: a Go plugin at Path or a Command run
+// for every scanned file.
+type Plugin struct {
+	Name    string        `yaml:"name"`
+	Path    string        `yaml:"path"`
+	Command []string      `yaml:"command"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// PolicyRule decides how scans matching its expression are reported. The first matching rule
+// wins; unset fields keep the default behaviour.
+type PolicyRule struct {
+	Name       string `yaml:"name"`
+	When       string `yaml:"when"`
+	Conclusion string `yaml:"conclusion"`
+	Issue      *bool  `yaml:"issue"`
+	Notify     *bool  `yaml:"notify"`
+}
+
+// RepositoryScope includes or excludes an installation's repositories by "owner/name" glob
+// and archived or fork status, replacing the default repository rules for that installation.
+type RepositoryScope struct {
+	Installation int64    `yaml:"installation_id"`
+	Include      []string `yaml:"include"`
+	Exclude      []string `yaml:"exclude"`
+	SkipArchived bool     `yaml:"skip_archived"`
+	SkipForks    bool     `yaml:"skip_forks"`
+}
+
+// PathOverride adjusts findings in files matching path globs, e.g. disabling a rule under
+// testdata/** or raising everything under infra/** to critical.
+type PathOverride struct {
+	Paths    []string `yaml:"paths"`
+	Rules    []string `yaml:"rules"`
+	Disable  bool     `yaml:"disable"`
+	Severity string   `yaml:"severity"`
+}
+
+// Rule is a custom detection rule added to the gitleaks rules, e.g. for company-internal
+// token formats.
+type Rule struct {
+	ID          string   `yaml:"id"`
+	Description string   `yaml:"description"`
+	Regex       string   `yaml:"regex"`
+	SecretGroup int      `yaml:"secret_group"`
+	Entropy     float64  `yaml:"entropy"`
+	Keywords    []string `yaml:"keywords"`
+	Severity    string   `yaml:"severity"`
+}
+
+// ChatWebhook is a chat incoming webhook receiving scan notifications. Webhooks with an
+// installation ID replace the default webhooks for that installation.
+type ChatWebhook struct {
+	Installation int64  `yaml:"installation_id"`
+	Format       string `yaml:"format"`
+	URL          string `yaml:"url" secret:"true"`
+}
+
+// newSecretsProvider creates the external secret manager client; replaced in tests.
+var newSecretsProvider = secrets.NewProvider
+
 // Simple config getters for backward compatibility.
 func (c *Config) GetPort() int {
 	return c.Server.Port
@@ -58,52 +662,1066 @@ func (c *Config) GetPrivateKey() string {
 	return c.Github.PrivateKey
 }
 
+// Route returns the URL path for an endpoint, mounted under the configured base path.
+func (c *Config) Route(endpoint string) string {
+	return path.Join("/", c.Server.BasePath, endpoint)
+}
+
+// GetWebhookPath returns the full URL path webhooks are served on.
+func (c *Config) GetWebhookPath() string {
+	return c.Route(c.Server.WebhookPath)
+}
+
+// GetSeverityClassifier returns the classifier assigning severities to findings.
+func (c *Config) GetSeverityClassifier() (*severity.Classifier, error) {
+	rules := make(map[string]severity.Level, len(c.Severity.Rules)+len(c.Rules))
+	for _, rule := range c.Rules {
+		if rule.Severity == "" {
+			continue
+		}
+		level, err := severity.Parse(rule.Severity)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidSeverity, err)
+		}
+		rules[rule.ID] = level
+	}
+	for ruleID, name := range c.Severity.Rules {
+		level, err := severity.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidSeverity, err)
+		}
+		rules[ruleID] = level
+	}
+
+	classifier := severity.NewClassifier(rules)
+	if len(c.PathOverrides) > 0 {
+		overrides, err := c.GetPathOverrides()
+		if err != nil {
+			return nil, err
+		}
+		classifier.Overrides = overrides
+	}
+	if c.Severity.Default != "" {
+		level, err := severity.Parse(c.Severity.Default)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidSeverity, err)
+		}
+		classifier.Default = level
+	}
+	return classifier, nil
+}
+
+// GetPathOverrides returns the path-scoped finding overrides.
+func (c *Config) GetPathOverrides() (*pathrules.Set, error) {
+	overrides := make([]pathrules.Override, 0, len(c.PathOverrides))
+	for _, o := range c.PathOverrides {
+		level, err := severity.Parse(o.Severity)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidPathOverrides, err)
+		}
+		overrides = append(overrides, pathrules.Override{
+			Paths:    o.Paths,
+			Rules:    o.Rules,
+			Disable:  o.Disable,
+			Severity: level,
+		})
+	}
+	set, err := pathrules.New(overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidPathOverrides, err)
+	}
+	return set, nil
+}
+
+// GetRepositoryScope returns the repositories that are scanned.
+func (c *Config) GetRepositoryScope() (*reposcope.Scope, error) {
+	installations := make(map[int64]reposcope.Rules, len(c.Repositories.Installations))
+	for _, scope := range c.Repositories.Installations {
+		installations[scope.Installation] = reposcope.Rules{
+			Include:      scope.Include,
+			Exclude:      scope.Exclude,
+			SkipArchived: scope.SkipArchived,
+			SkipForks:    scope.SkipForks,
+		}
+	}
+	scope, err := reposcope.New(reposcope.Rules{
+		Include:      c.Repositories.Include,
+		Exclude:      c.Repositories.Exclude,
+		SkipArchived: c.Repositories.SkipArchived,
+		SkipForks:    c.Repositories.SkipForks,
+	}, installations)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidRepositories, err)
+	}
+	return scope, nil
+}
+
+// RulesVersion returns a short hash of the settings that decide what a scan finds: the
+// detector options, custom rules and path overrides. Cached scan results are keyed by it.
+func (c *Config) RulesVersion() string {
+	data, err := yaml.Marshal(struct {
+		Detector      any
+		Rules         []Rule
+		PathOverrides []PathOverride
+	}{c.Detector, c.Rules, c.PathOverrides})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// GetHeadOnlyThreshold returns the per-repository number of commits above which pushes are
+// scanned as a single cumulative diff.
+func (c *Config) GetHeadOnlyThreshold() (*reposcope.Setting, error) {
+	overrides := make([]reposcope.SettingOverride, 0, len(c.Push.Repositories))
+	for _, o := range c.Push.Repositories {
+		overrides = append(overrides, reposcope.SettingOverride{Repositories: o.Repositories, Value: o.HeadOnlyThreshold})
+	}
+	setting, err := reposcope.NewSetting(c.Push.HeadOnlyThreshold, overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidPush, err)
+	}
+	return setting, nil
+}
+
+// GetPIISetting returns the per-repository PII rule toggle, non-zero where enabled.
+func (c *Config) GetPIISetting() (*reposcope.Setting, error) {
+	overrides := make([]reposcope.SettingOverride, 0, len(c.Detector.PII.Repositories))
+	for _, o := range c.Detector.PII.Repositories {
+		overrides = append(overrides, reposcope.SettingOverride{Repositories: o.Repositories, Value: boolToInt(o.Enabled)})
+	}
+	setting, err := reposcope.NewSetting(boolToInt(c.Detector.PII.Enabled), overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidPII, err)
+	}
+	return setting, nil
+}
+
+// GetFilenameRules returns the rules reporting sensitive file names, such as .env files and
+// private keys, in the repositories they are enabled for.
+func (c *Config) GetFilenameRules() (*filenames.Rules, error) {
+	overrides := make([]reposcope.SettingOverride, 0, len(c.Detector.Filenames.Repositories))
+	for _, o := range c.Detector.Filenames.Repositories {
+		overrides = append(overrides, reposcope.SettingOverride{Repositories: o.Repositories, Value: boolToInt(o.Enabled)})
+	}
+	setting, err := reposcope.NewSetting(boolToInt(c.Detector.Filenames.Enabled), overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidFilenames, err)
+	}
+	rules, err := filenames.New(c.Detector.Filenames.Patterns, setting)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidFilenames, err)
+	}
+	return rules, nil
+}
+
+// GetDryRunSetting returns the per-repository dry run toggle, non-zero where enabled.
+func (c *Config) GetDryRunSetting() (*reposcope.Setting, error) {
+	overrides := make([]reposcope.SettingOverride, 0, len(c.DryRun.Repositories))
+	for _, o := range c.DryRun.Repositories {
+		overrides = append(overrides, reposcope.SettingOverride{Repositories: o.Repositories, Value: boolToInt(o.Enabled)})
+	}
+	setting, err := reposcope.NewSetting(boolToInt(c.DryRun.Enabled), overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidDryRun, err)
+	}
+	return setting, nil
+}
+
+// GetBadgeSetting returns the per-repository badge toggle, non-zero where badges are served.
+func (c *Config) GetBadgeSetting() (*reposcope.Setting, error) {
+	overrides := make([]reposcope.SettingOverride, 0, len(c.Badges.Repositories))
+	for _, o := range c.Badges.Repositories {
+		overrides = append(overrides, reposcope.SettingOverride{Repositories: o.Repositories, Value: boolToInt(o.Enabled)})
+	}
+	setting, err := reposcope.NewSetting(boolToInt(c.Badges.Enabled), overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidBadges, err)
+	}
+	return setting, nil
+}
+
+// GetDispatchSetting returns the per-repository toggle of repository_dispatch events, non-zero
+// where scans with findings fire one.
+func (c *Config) GetDispatchSetting() (*reposcope.Setting, error) {
+	overrides := make([]reposcope.SettingOverride, 0, len(c.Dispatch.Repositories))
+	for _, o := range c.Dispatch.Repositories {
+		overrides = append(overrides, reposcope.SettingOverride{Repositories: o.Repositories, Value: boolToInt(o.Enabled)})
+	}
+	setting, err := reposcope.NewSetting(boolToInt(c.Dispatch.Enabled), overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidDispatch, err)
+	}
+	return setting, nil
+}
+
+// GetNewFindingWindow returns how long findings count as new after they are first seen, or
+// zero when findings are not aged.
+func (c *Config) GetNewFindingWindow() time.Duration {
+	if c.Findings.NewDays <= 0 {
+		return 0
+	}
+	return time.Duration(c.Findings.NewDays) * 24 * time.Hour
+}
+
+// GetRedaction returns how secrets appear in stored and reported findings, or nil when they
+// are left out.
+func (c *Config) GetRedaction() *redact.Policy {
+	return redact.New(c.Findings.Redaction, c.Findings.MaskPercent)
+}
+
+// GetEnforcementRollout returns the repositories whose findings are enforced; the others are
+// scanned as dry runs.
+func (c *Config) GetEnforcementRollout() (*reposcope.Rollout, error) {
+	overrides := make([]reposcope.SettingOverride, 0, len(c.Enforcement.Repositories))
+	for _, o := range c.Enforcement.Repositories {
+		overrides = append(overrides, reposcope.SettingOverride{Repositories: o.Repositories, Value: boolToInt(o.Enabled)})
+	}
+	rollout, err := reposcope.NewRollout(c.Enforcement.RolloutPercent, overrides)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidEnforcement, err)
+	}
+	return rollout, nil
+}
+
+// GetMessages loads the message catalogs and returns the locale of each installation.
+func (c *Config) GetMessages() (*messages.Locales, error) {
+	var catalogs map[string]*messages.Catalog
+	if c.Messages.Dir != "" {
+		loaded, err := messages.Load(c.Messages.Dir)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidMessages, err)
+		}
+		catalogs = loaded
+	}
+	installations := make(map[int64]string, len(c.Messages.Installations))
+	for _, i := range c.Messages.Installations {
+		installations[i.Installation] = i.Locale
+	}
+	locales, err := messages.NewLocales(catalogs, c.Messages.Locale, installations)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidMessages, err)
+	}
+	return locales, nil
+}
+
+// GetTemplates returns the parsed issue body and check run summary templates.
+func (c *Config) GetTemplates() (*messages.Templates, error) {
+	templates, err := messages.ParseTemplates(c.Templates.IssueBody, c.Templates.CheckSummary)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidTemplates, err)
+	}
+	return templates, nil
+}
+
+// GetPolicyRules returns the compiled policy rules deciding check conclusions, issues and
+// notifications.
+func (c *Config) GetPolicyRules() (*policy.Set, error) {
+	rules := make([]policy.Rule, 0, len(c.Policy.Rules))
+	for _, rule := range c.Policy.Rules {
+		rules = append(rules, policy.Rule{
+			Name:       rule.Name,
+			When:       rule.When,
+			Conclusion: rule.Conclusion,
+			Issue:      rule.Issue,
+			Notify:     rule.Notify,
+		})
+	}
+	set, err := policy.New(rules)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidPolicy, err)
+	}
+	return set, nil
+}
+
+// GetDetectorPlugins returns the detector plugins loaded in addition to those compiled in.
+func (c *Config) GetDetectorPlugins() ([]plugin.Spec, error) {
+	specs := make([]plugin.Spec, 0, len(c.Detector.Plugins))
+	for _, p := range c.Detector.Plugins {
+		spec := plugin.Spec{Name: p.Name, Path: p.Path, Command: p.Command, Timeout: p.Timeout}
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf(ErrInvalidPlugins, err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// GetCloneBaseURL returns the base URL full scans clone from: the configured one, or the host
+// of a GitHub Enterprise Server API URL, whose clone URLs may name an external host. Empty
+// keeps the clone URLs GitHub reports.
+func (c *Config) GetCloneBaseURL() string {
+	return c.GetAppCloneBaseURL(c.Github.APIURL)
+}
+
+// GetAppCloneBaseURL is GetCloneBaseURL for the App with the given API URL.
+func (c *Config) GetAppCloneBaseURL(apiURL string) string {
+	if c.FullScan.Clone.BaseURL != "" || apiURL == DefaultGitHubAPIURL {
+		return c.FullScan.Clone.BaseURL
+	}
+	api, err := url.Parse(apiURL)
+	if err != nil || api.Host == "" {
+		return ""
+	}
+	return api.Scheme + "://" + api.Host + "/"
+}
+
+// GetCloneRewrites returns the clone URL rewrites by prefix.
+func (c *Config) GetCloneRewrites() map[string]string {
+	if len(c.FullScan.Clone.Rewrites) == 0 {
+		return nil
+	}
+	rewrites := make(map[string]string, len(c.FullScan.Clone.Rewrites))
+	for _, rewrite := range c.FullScan.Clone.Rewrites {
+		rewrites[rewrite.From] = rewrite.To
+	}
+	return rewrites
+}
+
+// validateClone checks the clone base and proxy URLs and the rewrites. The proxy URL may hold
+// credentials, so values are not part of the errors.
+func (c *Config) validateClone() error {
+	urls := map[string]string{"base_url": c.FullScan.Clone.BaseURL, "proxy_url": c.FullScan.Clone.ProxyURL}
+	for name, value := range urls {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf(ErrInvalidClone, fmt.Errorf("%s is not an absolute URL", name))
+		}
+	}
+	for _, rewrite := range c.FullScan.Clone.Rewrites {
+		if rewrite.From == "" || rewrite.To == "" {
+			return fmt.Errorf(ErrInvalidClone, errors.New("rewrites need both from and to"))
+		}
+	}
+	return nil
+}
+
+// GetSLA returns the SLA window of each severity, or nil when none is configured.
+func (c *Config) GetSLA() (severity.SLA, error) {
+	if len(c.SLA.Windows) == 0 {
+		return nil, nil
+	}
+	sla := make(severity.SLA, len(c.SLA.Windows))
+	for name, window := range c.SLA.Windows {
+		level, err := severity.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidSLA, err)
+		}
+		if level == severity.None || window <= 0 {
+			return nil, fmt.Errorf(ErrInvalidSLA, fmt.Errorf("%s: expected a severity and a positive window", name))
+		}
+		sla[level] = window
+	}
+	return sla, nil
+}
+
+// GetIssueLabels returns the labels of security issues, or the security label alone when none
+// are configured.
+func (c *Config) GetIssueLabels() (labels.Set, error) {
+	if len(c.FullScan.Labels) == 0 {
+		return labels.Default(), nil
+	}
+	set := make(labels.Set, 0, len(c.FullScan.Labels))
+	for _, label := range c.FullScan.Labels {
+		level, err := severity.Parse(label.Severity)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidIssueLabels, fmt.Errorf("label %q: %w", label.Name, err))
+		}
+		set = append(set, labels.Label{
+			Name:        label.Name,
+			Color:       strings.TrimPrefix(label.Color, "#"),
+			Description: label.Description,
+			Severity:    level,
+		})
+	}
+	if err := set.Validate(); err != nil {
+		return nil, fmt.Errorf(ErrInvalidIssueLabels, err)
+	}
+	return set, nil
+}
+
+// validateIssueGrouping checks that full scan findings are grouped into issues in a known way.
+func (c *Config) validateIssueGrouping() error {
+	switch c.FullScan.Issues {
+	case constants.IssueGroupingSingle, constants.IssueGroupingRule, constants.IssueGroupingDirectory:
+		return nil
+	}
+	return fmt.Errorf(ErrInvalidIssueGrouping, c.FullScan.Issues)
+}
+
+// validateRedaction checks that secrets are redacted in a known way.
+func (c *Config) validateRedaction() error {
+	switch c.Findings.Redaction {
+	case constants.RedactionNone, constants.RedactionMask, constants.RedactionHash:
+	default:
+		return fmt.Errorf(ErrInvalidRedaction, c.Findings.Redaction)
+	}
+	if c.Findings.MaskPercent < redact.MinMaskPercent || c.Findings.MaskPercent > 100 {
+		return fmt.Errorf(ErrInvalidMaskPercent, c.Findings.MaskPercent, redact.MinMaskPercent)
+	}
+	return nil
+}
+
+// validateReports checks that organization reports are published from tracked findings to a
+// repository given by full name.
+func (c *Config) validateReports() error {
+	reports := c.Reports
+	if reports.Repository == "" {
+		return nil
+	}
+	owner, name, ok := strings.Cut(reports.Repository, "/")
+	switch {
+	case !ok || owner == "" || name == "" || strings.Contains(name, "/"):
+		return fmt.Errorf(ErrInvalidReports, fmt.Errorf("repository %q must be a full name, owner/name",
+			reports.Repository))
+	case len(reports.Organizations) == 0:
+		return fmt.Errorf(ErrInvalidReports, errors.New("reports need at least one organization"))
+	case !c.Findings.Tracking:
+		return fmt.Errorf(ErrInvalidReports, errors.New("reports need finding tracking enabled"))
+	case reports.Interval < 0:
+		return fmt.Errorf(ErrInvalidReports, fmt.Errorf("negative interval %s", reports.Interval))
+	}
+	return nil
+}
+
+// validateReporting checks that commit scans are reported in a known way.
+func (c *Config) validateReporting() error {
+	switch c.Push.Reporting {
+	case constants.ReportingChecks, constants.ReportingComments, constants.ReportingBoth:
+		return nil
+	}
+	return fmt.Errorf(ErrInvalidReporting, c.Push.Reporting)
+}
+
+// validateSummaryCheck checks that summary check runs are only enabled with check run
+// reporting, and that commit check runs are only disabled in favour of the summary.
+func (c *Config) validateSummaryCheck() error {
+	switch {
+	case c.Push.SummaryCheck && c.Push.Reporting == constants.ReportingComments:
+		return fmt.Errorf(ErrInvalidPush, errors.New("summary_check requires check run reporting"))
+	case !c.Push.CommitChecks && !c.Push.SummaryCheck:
+		return fmt.Errorf(ErrInvalidPush, errors.New("commit_checks can only be disabled with summary_check"))
+	}
+	return nil
+}
+
+// validateTickets checks that rotation tickets are filed in a known system, and that Jira
+// tickets have a site, credentials and a project.
+func (c *Config) validateTickets() error {
+	jira := c.Tickets.Jira
+	switch c.Tickets.Rotation {
+	case "", constants.TicketsGitHub:
+		return nil
+	case constants.TicketsJira:
+		if jira.URL == "" || jira.User == "" || jira.Token == "" || jira.Project == "" {
+			return fmt.Errorf(ErrInvalidTickets, errors.New("jira tickets need a url, user, token and project"))
+		}
+		if u, err := url.Parse(jira.URL); err != nil || !u.IsAbs() {
+			return fmt.Errorf(ErrInvalidTickets, fmt.Errorf("jira url %q is not an absolute URL", jira.URL))
+		}
+		return nil
+	}
+	return fmt.Errorf(ErrInvalidTickets, fmt.Errorf("rotation %q: expected github or jira", c.Tickets.Rotation))
+}
+
+// validateRateLimit checks that the scan concurrency is not negative, that an installation's
+// share is a fraction of it, that the reserve is a fraction of the rate limit and that the
+// queue bound is not negative.
+func (c *Config) validateRateLimit() error {
+	switch {
+	case c.RateLimit.Concurrency < 0:
+		return fmt.Errorf(ErrInvalidRateLimit, errors.New("concurrency must not be negative"))
+	case c.RateLimit.MaxShare <= 0 || c.RateLimit.MaxShare > 1:
+		return fmt.Errorf(ErrInvalidRateLimit, errors.New("max_share must be greater than 0 and at most 1"))
+	case c.RateLimit.Reserve < 0 || c.RateLimit.Reserve >= 1:
+		return fmt.Errorf(ErrInvalidRateLimit, errors.New("reserve must be at least 0 and less than 1"))
+	case c.RateLimit.MaxQueued < 0:
+		return fmt.Errorf(ErrInvalidRateLimit, errors.New("max_queued must not be negative"))
+	}
+	return nil
+}
+
+// GetAdminAuth returns the credentials the admin API accepts: the admin and viewer tokens,
+// the configured tokens and the OIDC issuer, if any.
+func (c *Config) GetAdminAuth() (auth.Config, error) {
+	var cfg auth.Config
+	tokens := append([]AdminToken{
+		{Token: c.Admin.Token, Role: "admin"},
+		{Token: c.Admin.ViewerToken, Role: "viewer"},
+	}, c.Admin.Tokens...)
+	for i, token := range tokens {
+		if token.Token == "" {
+			if i >= 2 {
+				return auth.Config{}, fmt.Errorf(ErrInvalidAdmin, fmt.Errorf("tokens[%d] has no token", i-2))
+			}
+			continue
+		}
+		role, err := auth.ParseRole(token.Role)
+		if err == nil && role == auth.RoleNone {
+			err = errors.New("a token needs a role")
+		}
+		if err != nil {
+			return auth.Config{}, fmt.Errorf(ErrInvalidAdmin, err)
+		}
+		cfg.Tokens = append(cfg.Tokens, auth.Token{Token: token.Token, Role: role})
+	}
+
+	oidc := c.Admin.OIDC
+	if oidc.Issuer == "" {
+		return cfg, nil
+	}
+	if u, err := url.Parse(oidc.Issuer); err != nil || u.Scheme == "" || u.Host == "" {
+		return auth.Config{}, fmt.Errorf(ErrInvalidAdmin, errors.New("oidc issuer is not an absolute URL"))
+	}
+	if oidc.Audience == "" {
+		return auth.Config{}, fmt.Errorf(ErrInvalidAdmin, errors.New("oidc audience is required"))
+	}
+	defaultRole, err := auth.ParseRole(oidc.DefaultRole)
+	if err != nil {
+		return auth.Config{}, fmt.Errorf(ErrInvalidAdmin, err)
+	}
+	cfg.OIDC = &auth.OIDC{
+		Issuer:      oidc.Issuer,
+		Audience:    oidc.Audience,
+		RoleClaim:   oidc.RoleClaim,
+		Roles:       make(map[string]auth.Role, len(oidc.Roles)),
+		DefaultRole: defaultRole,
+	}
+	for value, name := range oidc.Roles {
+		role, err := auth.ParseRole(name)
+		if err != nil {
+			return auth.Config{}, fmt.Errorf(ErrInvalidAdmin, err)
+		}
+		cfg.OIDC.Roles[value] = role
+	}
+	return cfg, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// GetDetectorOptions returns the rule configuration for building detectors.
+func (c *Config) GetDetectorOptions() (detector.Options, error) {
+	opts := detector.Options{
+		CacheDir:  c.Detector.RulePackCacheDir,
+		TTL:       c.Detector.RulePackTTL,
+		PublicKey: c.Detector.RulePackPublicKey,
+	}
+	if opts.CacheDir == "" {
+		opts.CacheDir = filepath.Join(os.TempDir(), DefaultRulePackCacheDir)
+	}
+	for _, value := range c.Detector.RulePacks {
+		pack, err := detector.ParseRulePack(value)
+		if err != nil {
+			return detector.Options{}, err
+		}
+		opts.RulePacks = append(opts.RulePacks, pack)
+	}
+	for _, value := range c.Detector.Allowlist.URLs {
+		list, err := detector.ParseRulePack(value)
+		if err != nil {
+			return detector.Options{}, err
+		}
+		opts.AllowlistURLs = append(opts.AllowlistURLs, list)
+	}
+	opts.Allowlist = c.Detector.Allowlist.Secrets
+	if _, err := detector.AllowlistConfig(opts.Allowlist); err != nil {
+		return detector.Options{}, err
+	}
+	opts.Rules = c.detectorRules()
+	opts.Generic = detector.GenericTuning{
+		Disabled:  c.Detector.Generic.Disabled,
+		Entropy:   c.Detector.Generic.Entropy,
+		MinLength: c.Detector.Generic.MinLength,
+	}
+	return opts, nil
+}
+
+// detectorRules converts the custom rules to detector rules.
+func (c *Config) detectorRules() []detector.Rule {
+	rules := make([]detector.Rule, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		rules = append(rules, detector.Rule{
+			ID:          rule.ID,
+			Description: rule.Description,
+			Regex:       rule.Regex,
+			SecretGroup: rule.SecretGroup,
+			Entropy:     rule.Entropy,
+			Keywords:    rule.Keywords,
+		})
+	}
+	return rules
+}
+
+// GetCheckPolicy returns the policy mapping finding severities to check conclusions.
+func (c *Config) GetCheckPolicy() (severity.Policy, error) {
+	neutralMax, err := severity.Parse(c.Severity.NeutralMax)
+	if err != nil {
+		return severity.Policy{}, fmt.Errorf(ErrInvalidSeverity, err)
+	}
+	actionRequiredMin, err := severity.Parse(c.Severity.ActionRequiredMin)
+	if err != nil {
+		return severity.Policy{}, fmt.Errorf(ErrInvalidSeverity, err)
+	}
+	return severity.Policy{NeutralMax: neutralMax, ActionRequiredMin: actionRequiredMin}, nil
+}
+
+// GetPrivateKeys returns the primary private key followed by any additional rotation keys.
+func (c *Config) GetPrivateKeys() []string {
+	keys := make([]string, 0, 1+len(c.Github.PrivateKeys))
+	if c.Github.PrivateKey != "" {
+		keys = append(keys, c.Github.PrivateKey)
+	}
+	return append(keys, c.Github.PrivateKeys...)
+}
+
 func (c *Config) GetAPIURL() string {
 	return c.Github.APIURL
 }
 
+// GitLabEnabled reports whether GitLab projects are guarded.
+func (c *Config) GitLabEnabled() bool {
+	return c.GitLab.Token != "" && c.GitLab.WebhookSecret != ""
+}
+
+// GetGitLabWebhookPath returns the full URL path GitLab webhooks are served on.
+func (c *Config) GetGitLabWebhookPath() string {
+	return c.Route(c.GitLab.WebhookPath)
+}
+
 func (c *Config) GetGraphQLURL() string {
 	return c.Github.GraphQLURL
 }
 
-func LoadConfig() (*Config, error) {
-	cfg := &Config{}
+// GetGitHubApps returns the primary App followed by the additional ones, with their key files
+// read and their URLs defaulted.
+func (c *Config) GetGitHubApps() ([]GitHubApp, error) {
+	apps := []GitHubApp{{
+		AppID:         c.Github.AppID,
+		WebhookSecret: c.Github.WebhookSecret,
+		PrivateKey:    c.Github.PrivateKey,
+		PrivateKeys:   c.Github.PrivateKeys,
+		APIURL:        c.Github.APIURL,
+		GraphQLURL:    c.Github.GraphQLURL,
+	}}
+	seen := map[int64]bool{c.Github.AppID: true}
+	for i, app := range c.Github.Apps {
+		if app.PrivateKeyFile != "" {
+			keys, err := readKeyFiles(app.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf(ErrInvalidGitHubApp, i, err)
+			}
+			app.PrivateKeys = append(keys, app.PrivateKeys...)
+		}
+		if app.APIURL == "" {
+			app.APIURL = DefaultGitHubAPIURL
+		}
+		if app.GraphQLURL == "" {
+			app.GraphQLURL = graphQLURL(app.APIURL)
+		}
 
-	// Set defaults
+		var err error
+		switch {
+		case app.AppID == 0:
+			err = errors.New("app_id is required")
+		case seen[app.AppID]:
+			err = fmt.Errorf("app %d is configured more than once", app.AppID)
+		case app.WebhookSecret == "":
+			err = errors.New("webhook_secret is required")
+		case len(app.Keys()) == 0:
+			err = errors.New("one of private_key, private_key_file or private_keys is required")
+		case app.GraphQLURL == "":
+			err = errors.New("graphql_url is required when api_url is not a GitHub Enterprise Server API URL")
+		}
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidGitHubApp, i, err)
+		}
+		seen[app.AppID] = true
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// graphQLURL returns the GraphQL URL of GitHub.com or of a GitHub Enterprise Server API URL
+// ending in /api/v3/, and "" for other API URLs.
+func graphQLURL(apiURL string) string {
+	if apiURL == DefaultGitHubAPIURL {
+		return DefaultGitHubGraphQLURL
+	}
+	if base, ok := strings.CutSuffix(strings.TrimSuffix(apiURL, "/"), "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return ""
+}
+
+// LoadConfig loads the configuration with LoadLocalConfig and requires the GitHub App
+// credentials the server needs.
+func LoadConfig(flags *Flags) (*Config, error) {
+	cfg, err := LoadLocalConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate required fields
+	if cfg.Github.WebhookSecret == "" {
+		return nil, errors.New(ErrWebhookSecretRequired)
+	}
+	if cfg.Github.AppID == 0 {
+		return nil, errors.New(ErrAppIDRequired)
+	}
+	if len(cfg.GetPrivateKeys()) == 0 {
+		return nil, errors.New(ErrPrivateKeyRequired)
+	}
+	if cfg.GRPC.Port > 0 && cfg.GRPC.AuthToken == "" {
+		return nil, errors.New(ErrGRPCAuthTokenRequired)
+	}
+	if _, err := cfg.GetGitHubApps(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadLocalConfig loads the configuration without requiring GitHub App credentials, for
+// commands that scan locally. Settings are layered: defaults, the YAML config file,
+// environment variables, flags and the secret manager, each overriding the one before.
+// flags may be nil.
+func LoadLocalConfig(flags *Flags) (*Config, error) {
+	cfg := defaultConfig()
+	if err := loadConfigFile(cfg, flags); err != nil {
+		return nil, err
+	}
+	if err := loadEnv(cfg); err != nil {
+		return nil, err
+	}
+	flags.apply(cfg)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Secrets.Backend != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), externalSecretsTimeout)
+		defer cancel()
+		if err := cfg.LoadExternalSecrets(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// defaultConfig returns the configuration used where nothing overrides it.
+func defaultConfig() *Config {
+	cfg := &Config{}
 	cfg.Github.APIURL = DefaultGitHubAPIURL
 	cfg.Github.GraphQLURL = DefaultGitHubGraphQLURL
+	cfg.Github.ClientCache = DefaultClientCacheSize
+	cfg.Github.HTTPCache = DefaultHTTPCacheSize
+	cfg.Github.ContentCache = DefaultContentCacheSize
+	cfg.Github.ContentCacheMB = DefaultContentCacheMB
+	cfg.ScanCache.Size = DefaultScanCacheSize
+	cfg.ScanCache.TTL = DefaultScanCacheTTL
+	cfg.Push.CommitScans = true
+	cfg.Push.Reporting = constants.ReportingChecks
+	cfg.Push.MaxFileBytes = constants.MaxScanFileBytes
+	cfg.Push.CommitChecks = true
+	cfg.FullScan.Enabled = true
+	cfg.FullScan.Issues = constants.IssueGroupingSingle
+	cfg.FullScan.MaxSizeMB = DefaultFullScanMaxSize
+	cfg.Findings.Redaction = constants.RedactionNone
+	cfg.Findings.MaskPercent = DefaultMaskPercent
+	cfg.RateLimit.MaxShare = DefaultRateLimitShare
+	cfg.RateLimit.Reserve = DefaultRateLimitReserve
+	cfg.Enforcement.RolloutPercent = DefaultRolloutPercent
 	cfg.Server.Port = DefaultPort
+	cfg.Server.MaxPayloadBytes = DefaultMaxPayloadBytes
+	cfg.Server.WebhookPath = DefaultWebhookPath
+	cfg.Store.Path = DefaultStorePath
+	cfg.GitLab.URL = DefaultGitLabURL
+	cfg.GitLab.WebhookPath = DefaultGitLabPath
+	return cfg
+}
 
-	// Override with environment variables
+// loadEnv overrides the settings of cfg with their environment variables, section by
+// section.
+func loadEnv(cfg *Config) error {
+	if err := loadGitHubFromEnv(cfg); err != nil {
+		return err
+	}
+	loadServerFromEnv(cfg)
+	if err := loadSeverityFromEnv(cfg); err != nil {
+		return err
+	}
+	loadFindingsFromEnv(cfg)
+	loadIntegrationsFromEnv(cfg)
+	loadScansFromEnv(cfg)
+	if err := loadNotifyFromEnv(cfg); err != nil {
+		return err
+	}
+	loadDetectorFromEnv(cfg)
+	loadFeaturesFromEnv(cfg)
+	return nil
+}
+
+// loadGitHubFromEnv reads the GitHub App credentials and client caches from the environment.
+func loadGitHubFromEnv(cfg *Config) error {
 	if secret, err := getSecret(GitHubWebhookSecretFileEnv, GitHubWebhookSecretEnv); err == nil && secret != "" {
 		cfg.Github.WebhookSecret = secret
 	}
 	if key, err := getSecret(GitHubPrivateKeyFileEnv, GitHubPrivateKeyEnv); err == nil && key != "" {
 		cfg.Github.PrivateKey = key
 	}
-	if appID := os.Getenv(GitHubAppIDEnv); appID != "" {
-		if id, err := strconv.ParseInt(appID, 10, 64); err == nil {
-			cfg.Github.AppID = id
+	if files := os.Getenv(GitHubPrivateKeyFilesEnv); files != "" {
+		keys, err := readKeyFiles(files)
+		if err != nil {
+			return err
 		}
+		cfg.Github.PrivateKeys = keys
 	}
-	if port := os.Getenv(PortEnv); port != "" {
-		if p, err := strconv.Atoi(port); err == nil {
-			cfg.Server.Port = p
+	setInt64FromEnv(&cfg.Github.AppID, GitHubAppIDEnv)
+	setIntFromEnv(&cfg.Github.ClientCache, ClientCacheSizeEnv)
+	setIntFromEnv(&cfg.Github.HTTPCache, HTTPCacheSizeEnv)
+	setIntFromEnv(&cfg.Github.ContentCache, ContentCacheSizeEnv)
+	setIntFromEnv(&cfg.Github.ContentCacheMB, ContentCacheMBEnv)
+	return nil
+}
+
+// loadServerFromEnv reads the HTTP server settings from the environment.
+func loadServerFromEnv(cfg *Config) {
+	setIntFromEnv(&cfg.Server.Port, PortEnv)
+	setStringFromEnv(&cfg.Server.BasePath, BasePathEnv)
+	setStringFromEnv(&cfg.Server.WebhookPath, WebhookPathEnv)
+	setInt64FromEnv(&cfg.Server.MaxPayloadBytes, MaxPayloadBytesEnv)
+	setBoolFromEnv(&cfg.Server.HookIPAllowlist, WebhookIPAllowlistEnv)
+	setListFromEnv(&cfg.Server.TrustedProxies, WebhookTrustedProxiesEnv)
+	setDurationFromEnv(&cfg.Server.HookRangesRefresh, WebhookAllowlistRefreshEnv)
+	setBoolFromEnv(&cfg.Server.ReadinessDependencies, ReadinessDependenciesEnv)
+	setDurationFromEnv(&cfg.Server.ReadinessTimeout, ReadinessTimeoutEnv)
+	setStringFromEnv(&cfg.Server.RecordDir, WebhookRecordDirEnv)
+}
+
+// loadFindingsFromEnv reads how findings are remediated, gated, tracked, redacted, reported
+// and stored from the environment.
+func loadFindingsFromEnv(cfg *Config) {
+	setBoolFromEnv(&cfg.Remediation.PullRequests, RemediationPREnv)
+	setBoolFromEnv(&cfg.PullRequests.Gate, PullRequestGateEnv)
+	setBoolFromEnv(&cfg.Baseline.Enabled, BaselineEnabledEnv)
+	setBoolFromEnv(&cfg.Findings.Tracking, FindingTrackingEnv)
+	setIntFromEnv(&cfg.Findings.NewDays, FindingNewDaysEnv)
+	setBoolFromEnv(&cfg.Findings.Deduplicate, FindingDeduplicationEnv)
+	setStringFromEnv(&cfg.Findings.Redaction, FindingRedactionEnv)
+	setIntFromEnv(&cfg.Findings.MaskPercent, FindingMaskPercentEnv)
+	setStringFromEnv(&cfg.Reports.Repository, ReportRepositoryEnv)
+	setListFromEnv(&cfg.Reports.Organizations, ReportOrganizationsEnv)
+	setDurationFromEnv(&cfg.Reports.Interval, ReportIntervalEnv)
+	setStringFromEnv(&cfg.Store.Path, StorePathEnv)
+}
+
+// loadIntegrationsFromEnv reads the GitLab, gRPC, admin API, event bus and secret manager
+// settings from the environment.
+func loadIntegrationsFromEnv(cfg *Config) {
+	setStringFromEnv(&cfg.GitLab.URL, GitLabURLEnv)
+	setStringFromEnv(&cfg.GitLab.Token, GitLabTokenEnv)
+	setStringFromEnv(&cfg.GitLab.WebhookSecret, GitLabWebhookSecretEnv)
+	setStringFromEnv(&cfg.GitLab.WebhookPath, GitLabWebhookPathEnv)
+	setIntFromEnv(&cfg.GRPC.Port, GRPCPortEnv)
+	setStringFromEnv(&cfg.GRPC.AuthToken, GRPCAuthTokenEnv)
+	setStringFromEnv(&cfg.Admin.Token, AdminTokenEnv)
+	setStringFromEnv(&cfg.Admin.ViewerToken, AdminViewerTokenEnv)
+	setStringFromEnv(&cfg.Admin.OIDC.Issuer, AdminOIDCIssuerEnv)
+	setStringFromEnv(&cfg.Admin.OIDC.Audience, AdminOIDCAudienceEnv)
+
+	setStringFromEnv(&cfg.EventBus.Backend, EventBusEnv)
+	setStringFromEnv(&cfg.EventBus.URL, EventBusURLEnv)
+	setStringFromEnv(&cfg.EventBus.Topic, EventBusTopicEnv)
+
+	setStringFromEnv(&cfg.Secrets.Backend, SecretsBackendEnv)
+	setStringFromEnv(&cfg.Secrets.WebhookSecretRef, SecretsWebhookSecretRefEnv)
+	setStringFromEnv(&cfg.Secrets.PrivateKeyRef, SecretsPrivateKeyRefEnv)
+	setDurationFromEnv(&cfg.Secrets.RefreshInterval, SecretsRefreshIntervalEnv)
+}
+
+// loadScansFromEnv reads which repositories are scanned and how commit and full repository
+// scans, their cache and their rate limits behave from the environment.
+func loadScansFromEnv(cfg *Config) {
+	setListFromEnv(&cfg.Repositories.Include, RepositoriesIncludeEnv)
+	setListFromEnv(&cfg.Repositories.Exclude, RepositoriesExcludeEnv)
+	setBoolFromEnv(&cfg.Repositories.SkipArchived, SkipArchivedEnv)
+	setBoolFromEnv(&cfg.Repositories.SkipForks, SkipForksEnv)
+
+	setBoolFromEnv(&cfg.Push.CommitScans, CommitScanEnabledEnv)
+	setIntFromEnv(&cfg.Push.HeadOnlyThreshold, HeadOnlyThresholdEnv)
+	setStringFromEnv(&cfg.Push.Reporting, CommitScanReportingEnv)
+	setInt64FromEnv(&cfg.Push.MaxFileBytes, CommitScanMaxFileBytesEnv)
+	setBoolFromEnv(&cfg.Push.SummaryCheck, CommitScanSummaryCheckEnv)
+	setBoolFromEnv(&cfg.Push.CommitChecks, CommitScanCommitChecksEnv)
+	setIntFromEnv(&cfg.ScanCache.Size, ScanCacheSizeEnv)
+	setStringFromEnv(&cfg.ScanCache.RedisURL, ScanCacheRedisURLEnv)
+	setDurationFromEnv(&cfg.ScanCache.TTL, ScanCacheTTLEnv)
+
+	setBoolFromEnv(&cfg.FullScan.Enabled, FullScanEnabledEnv)
+	setBoolFromEnv(&cfg.FullScan.NativeAlerts, NativeAlertsEnv)
+	setStringFromEnv(&cfg.FullScan.Clone.BaseURL, CloneBaseURLEnv)
+	setStringFromEnv(&cfg.FullScan.Clone.ProxyURL, CloneProxyURLEnv)
+	setStringFromEnv(&cfg.FullScan.Issues, IssueGroupingEnv)
+	setInt64FromEnv(&cfg.FullScan.LFSMaxBytes, LFSMaxBytesEnv)
+	setIntFromEnv(&cfg.FullScan.MaxSizeMB, FullScanMaxSizeEnv)
+	setIntFromEnv(&cfg.FullScan.MaxFiles, FullScanMaxFilesEnv)
+
+	setIntFromEnv(&cfg.RateLimit.Concurrency, RateLimitConcurrencyEnv)
+	setFloatFromEnv(&cfg.RateLimit.MaxShare, RateLimitMaxShareEnv)
+	setFloatFromEnv(&cfg.RateLimit.Reserve, RateLimitReserveEnv)
+	setIntFromEnv(&cfg.RateLimit.MaxQueued, RateLimitMaxQueuedEnv)
+	setStringFromEnv(&cfg.Checks.DetailsURL, CheckRunDetailsURLEnv)
+}
+
+// loadNotifyFromEnv reads the notification, ticket, alerting and SIEM settings from the
+// environment.
+func loadNotifyFromEnv(cfg *Config) error {
+	setListFromEnv(&cfg.Notify.WebhookURLs, NotifyWebhookURLsEnv)
+	setStringFromEnv(&cfg.Notify.WebhookSecret, NotifyWebhookSecretEnv)
+	if webhooks := os.Getenv(ChatWebhooksEnv); webhooks != "" {
+		chat, err := parseChatWebhooks(webhooks)
+		if err != nil {
+			return err
 		}
+		cfg.Notify.Chat = chat
 	}
+	setStringFromEnv(&cfg.Tickets.Rotation, RotationTicketsEnv)
+	setStringFromEnv(&cfg.Tickets.Jira.URL, JiraURLEnv)
+	setStringFromEnv(&cfg.Tickets.Jira.User, JiraUserEnv)
+	setStringFromEnv(&cfg.Tickets.Jira.Token, JiraTokenEnv)
+	setStringFromEnv(&cfg.Tickets.Jira.Project, JiraProjectEnv)
+	setStringFromEnv(&cfg.Tickets.Jira.IssueType, JiraIssueTypeEnv)
+	setStringFromEnv(&cfg.Alerts.PagerDutyRoutingKey, PagerDutyRoutingKeyEnv)
+	setStringFromEnv(&cfg.Alerts.OpsgenieAPIKey, OpsgenieAPIKeyEnv)
+	setStringFromEnv(&cfg.Alerts.OpsgenieAPIURL, OpsgenieAPIURLEnv)
+	setStringFromEnv(&cfg.SIEM.Format, SIEMFormatEnv)
+	setStringFromEnv(&cfg.SIEM.SyslogAddress, SIEMSyslogAddressEnv)
+	setStringFromEnv(&cfg.SIEM.SplunkHECURL, SplunkHECURLEnv)
+	setStringFromEnv(&cfg.SIEM.SplunkHECToken, SplunkHECTokenEnv)
+	return nil
+}
 
-	// Validate required fields
-	if cfg.Github.WebhookSecret == "" {
-		return nil, errors.New(ErrWebhookSecretRequired)
+// loadDetectorFromEnv reads the rule packs, allowlist and optional rules of the detector from
+// the environment.
+func loadDetectorFromEnv(cfg *Config) {
+	setListFromEnv(&cfg.Detector.RulePacks, RulePackURLsEnv)
+	setStringFromEnv(&cfg.Detector.RulePackCacheDir, RulePackCacheDirEnv)
+	setDurationFromEnv(&cfg.Detector.RulePackTTL, RulePackTTLEnv)
+	setStringFromEnv(&cfg.Detector.RulePackPublicKey, RulePackPublicKeyEnv)
+	setListFromEnv(&cfg.Detector.Allowlist.Secrets, SecretAllowlistEnv)
+	setListFromEnv(&cfg.Detector.Allowlist.URLs, SecretAllowlistURLsEnv)
+	setBoolFromEnv(&cfg.Detector.Generic.Disabled, DisableGenericRulesEnv)
+	setFloatFromEnv(&cfg.Detector.Generic.Entropy, GenericRulesEntropyEnv)
+	setIntFromEnv(&cfg.Detector.Generic.MinLength, GenericRulesMinLengthEnv)
+	setBoolFromEnv(&cfg.Detector.PII.Enabled, PIIEnabledEnv)
+	setBoolFromEnv(&cfg.Detector.Filenames.Enabled, FilenameRulesEnabledEnv)
+}
+
+// loadFeaturesFromEnv reads the dry run, badge, dispatch, enforcement rollout and message
+// settings from the environment.
+func loadFeaturesFromEnv(cfg *Config) {
+	setBoolFromEnv(&cfg.DryRun.Enabled, DryRunEnv)
+	setBoolFromEnv(&cfg.Badges.Enabled, BadgesEnabledEnv)
+	setBoolFromEnv(&cfg.Dispatch.Enabled, DispatchEnabledEnv)
+	setIntFromEnv(&cfg.Enforcement.RolloutPercent, EnforcementRolloutEnv)
+	setStringFromEnv(&cfg.Messages.Dir, MessagesDirEnv)
+	setStringFromEnv(&cfg.Messages.Locale, MessagesLocaleEnv)
+}
+
+// validate checks the settings of every section once all layers but the secret manager are
+// applied.
+func (c *Config) validate() error {
+	if _, err := detector.RulesConfig(c.detectorRules()); err != nil {
+		return fmt.Errorf(ErrInvalidRules, err)
 	}
-	if cfg.Github.AppID == 0 {
-		return nil, errors.New(ErrAppIDRequired)
+	checks := []func() error{
+		func() error { _, err := c.GetPolicyRules(); return err },
+		func() error { _, err := c.GetDetectorPlugins(); return err },
+		c.validateRedaction,
+		c.validateReports,
+		c.validateReporting,
+		c.validateSummaryCheck,
+		c.validateClone,
+		c.validateIssueGrouping,
+		func() error { _, err := c.GetIssueLabels(); return err },
+		func() error { _, err := c.GetAdminAuth(); return err },
+		c.validateRateLimit,
+		c.validateTickets,
+		func() error { _, err := c.GetEnforcementRollout(); return err },
 	}
-	if cfg.Github.PrivateKey == "" {
-		return nil, errors.New(ErrPrivateKeyRequired)
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return cfg, nil
+// LoadExternalSecrets fetches the webhook secret and private key from the configured
+// secret manager backend, overriding values from files and environment variables.
+func (c *Config) LoadExternalSecrets(ctx context.Context) error {
+	provider, err := newSecretsProvider(c.Secrets.Backend)
+	if err != nil {
+		return fmt.Errorf(ErrExternalSecrets, c.Secrets.Backend, err)
+	}
+
+	if ref := c.Secrets.WebhookSecretRef; ref != "" {
+		secret, err := provider.Fetch(ctx, ref)
+		if err != nil {
+			return fmt.Errorf(ErrExternalSecrets, c.Secrets.Backend, err)
+		}
+		c.Github.WebhookSecret = secret
+	}
+	if ref := c.Secrets.PrivateKeyRef; ref != "" {
+		key, err := provider.Fetch(ctx, ref)
+		if err != nil {
+			return fmt.Errorf(ErrExternalSecrets, c.Secrets.Backend, err)
+		}
+		c.Github.PrivateKey = key
+	}
+
+	return nil
+}
+
+// FilePath returns the path of the YAML config file: the --config flag of flags, CONFIG_FILE,
+// or config.yml in the working directory.
+func FilePath(flags *Flags) string {
+	if flags.given(FlagConfig) {
+		return flags.file
+	}
+	if file := os.Getenv(ConfigFileEnv); file != "" {
+		return file
+	}
+	return DefaultConfigFile
+}
+
+// loadConfigFile reads the YAML config file named by the --config flag or CONFIG_FILE, or
+// config.yml in the working directory when present. Environment variables override values
+// from the file.
+func loadConfigFile(cfg *Config, flags *Flags) error {
+	file := FilePath(flags)
+	required := flags.given(FlagConfig) || os.Getenv(ConfigFileEnv) != ""
+
+	data, err := os.ReadFile(file) // #nosec G304 -- Path comes from operator configuration.
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf(ErrReadConfigFile, file, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf(ErrReadConfigFile, file, err)
+	}
+	return nil
 }
 
 func getSecret(fileEnv, directEnv string) (string, error) {
@@ -121,3 +1739,145 @@ func getSecret(fileEnv, directEnv string) (string, error) {
 	}
 	return "", errors.New("secret not found in file or environment variable")
 }
+
+// readKeyFiles reads a comma-separated list of private key files used for key rotation.
+func readKeyFiles(files string) ([]string, error) {
+	var keys []string
+	for _, path := range splitList(files) {
+		data, err := os.ReadFile(path) // #nosec G304 -- Path comes from operator configuration.
+		if err != nil {
+			return nil, fmt.Errorf(ErrReadPrivateKeyFile, path, err)
+		}
+		keys = append(keys, string(data))
+	}
+	return keys, nil
+}
+
+// splitList splits a comma-separated environment value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseChatWebhooks parses comma-separated "[installation:]format=url" entries.
+func parseChatWebhooks(value string) ([]ChatWebhook, error) {
+	var webhooks []ChatWebhook
+	for _, entry := range splitList(value) {
+		target, url, ok := strings.Cut(entry, "=")
+		if !ok || url == "" {
+			return nil, fmt.Errorf(ErrInvalidChatWebhook, entry)
+		}
+
+		webhook := ChatWebhook{Format: target, URL: url}
+		if installation, format, ok := strings.Cut(target, ":"); ok {
+			id, err := strconv.ParseInt(installation, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf(ErrInvalidChatWebhook, entry)
+			}
+			webhook.Installation = id
+			webhook.Format = format
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// setIntFromEnv overrides target with the integer value of env when it is set and valid.
+// setStringFromEnv overrides target with the environment variable, if set.
+func setStringFromEnv(target *string, env string) {
+	if value := os.Getenv(env); value != "" {
+		*target = value
+	}
+}
+
+func setIntFromEnv(target *int, env string) {
+	if value := os.Getenv(env); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			*target = n
+		}
+	}
+}
+
+func setInt64FromEnv(target *int64, env string) {
+	if value := os.Getenv(env); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			*target = n
+		}
+	}
+}
+
+func setBoolFromEnv(target *bool, env string) {
+	if b, err := strconv.ParseBool(os.Getenv(env)); err == nil {
+		*target = b
+	}
+}
+
+func setDurationFromEnv(target *time.Duration, env string) {
+	if value := os.Getenv(env); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			*target = d
+		}
+	}
+}
+
+// setListFromEnv sets target to the comma-separated list in env, when set.
+func setListFromEnv(target *[]string, env string) {
+	if value := os.Getenv(env); value != "" {
+		*target = splitList(value)
+	}
+}
+
+func setFloatFromEnv(target *float64, env string) {
+	if value := os.Getenv(env); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			*target = f
+		}
+	}
+}
+
+// loadSeverityFromEnv reads the severity mapping, SLA and check policy from the environment
+// and validates them.
+func loadSeverityFromEnv(cfg *Config) error {
+	setStringFromEnv(&cfg.Severity.Default, SeverityDefaultEnv)
+	setStringFromEnv(&cfg.Severity.NeutralMax, CheckNeutralMaxEnv)
+	setStringFromEnv(&cfg.Severity.ActionRequiredMin, CheckActionRequiredMinEnv)
+	if rules := os.Getenv(SeverityRulesEnv); rules != "" {
+		cfg.Severity.Rules = make(map[string]string)
+		for _, pair := range splitList(rules) {
+			ruleID, level, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf(ErrInvalidSeverity, fmt.Errorf("expected rule-id=level, got %q", pair))
+			}
+			cfg.Severity.Rules[strings.TrimSpace(ruleID)] = strings.TrimSpace(level)
+		}
+	}
+
+	if windows := os.Getenv(SLAWindowsEnv); windows != "" {
+		cfg.SLA.Windows = make(map[string]time.Duration)
+		for _, pair := range splitList(windows) {
+			level, value, ok := strings.Cut(pair, "=")
+			window, err := time.ParseDuration(strings.TrimSpace(value))
+			if !ok || err != nil {
+				return fmt.Errorf(ErrInvalidSLA, fmt.Errorf("expected severity=duration, got %q", pair))
+			}
+			cfg.SLA.Windows[strings.TrimSpace(level)] = window
+		}
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv(SLAMilestonesEnv)); err == nil {
+		cfg.SLA.Milestones = enabled
+	}
+
+	if _, err := cfg.GetSeverityClassifier(); err != nil {
+		return err
+	}
+	if _, err := cfg.GetSLA(); err != nil {
+		return err
+	}
+	_, err := cfg.GetCheckPolicy()
+	return err
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// Command-line flags of the server, each overriding the environment variable of the same
+// setting.
+const (
+	FlagConfig      = "config"
+	FlagPort        = "port"
+	FlagBasePath    = "base-path"
+	FlagWebhookPath = "webhook-path"
+	FlagAppID       = "app-id"
+)
+
+// Flags are the server's command-line flags, the configuration layer applied after
+// environment variables and before the secret manager by LoadConfig and LoadLocalConfig.
+// Flags that are not given change nothing, and a nil *Flags gives none.
+type Flags struct {
+	set *flag.FlagSet
+
+	file        string
+	port        int
+	basePath    string
+	webhookPath string
+	appID       int64
+}
+
+// NewFlags defines the server's flags on a flag set named name, which writes usage and
+// errors to output.
+func NewFlags(name string, output io.Writer) *Flags {
+	f := &Flags{set: flag.NewFlagSet(name, flag.ContinueOnError)}
+	f.set.SetOutput(output)
+	f.set.StringVar(&f.file, FlagConfig, "", "YAML config file (overrides "+ConfigFileEnv+")")
+	f.set.IntVar(&f.port, FlagPort, 0, "port to listen on (overrides "+PortEnv+")")
+	f.set.StringVar(&f.basePath, FlagBasePath, "", "path prefix of all endpoints (overrides "+BasePathEnv+")")
+	f.set.StringVar(&f.webhookPath, FlagWebhookPath, "",
+		"webhook path, relative to the base path (overrides "+WebhookPathEnv+")")
+	f.set.Int64Var(&f.appID, FlagAppID, 0, "GitHub App ID (overrides "+GitHubAppIDEnv+")")
+	return f
+}
+
+// Parse parses args, which hold nothing but flags. Errors are also written to the flag
+// set's output, followed by the usage.
+func (f *Flags) Parse(args []string) error {
+	if err := f.set.Parse(args); err != nil {
+		return err
+	}
+	if f.set.NArg() > 0 {
+		err := fmt.Errorf("unexpected argument %q", f.set.Arg(0))
+		fmt.Fprintln(f.set.Output(), err)
+		f.set.Usage()
+		return err
+	}
+	return nil
+}
+
+// given reports whether the flag name was given.
+func (f *Flags) given(name string) bool {
+	if f == nil {
+		return false
+	}
+	given := false
+	f.set.Visit(func(fl *flag.Flag) {
+		given = given || fl.Name == name
+	})
+	return given
+}
+
+// apply overrides the settings of cfg that the given flags set.
+func (f *Flags) apply(cfg *Config) {
+	if f == nil {
+		return
+	}
+	f.set.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case FlagPort:
+			cfg.Server.Port = f.port
+		case FlagBasePath:
+			cfg.Server.BasePath = f.basePath
+		case FlagWebhookPath:
+			cfg.Server.WebhookPath = f.webhookPath
+		case FlagAppID:
+			cfg.Github.AppID = f.appID
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parseFlags parses args as the server's flags.
+func parseFlags(t *testing.T, args ...string) *Flags {
+	t.Helper()
+	flags := NewFlags("gitguard", io.Discard)
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return flags
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("PORT", "")
+	t.Setenv("WEBHOOK_PATH", "")
+	t.Setenv("BASE_PATH", "")
+	file := filepath.Join(t.TempDir(), "config.yml")
+	content := "server:\n  port: 9000\n  webhook_path: /file\n  base_path: /file\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var flags *Flags
+	load := func(want int, layer string) *Config {
+		t.Helper()
+		cfg, err := LoadLocalConfig(flags)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.GetPort() != want {
+			t.Errorf("Expected %s to set port %d, got %d", layer, want, cfg.GetPort())
+		}
+		return cfg
+	}
+
+	load(DefaultPort, "the defaults")
+	t.Setenv("CONFIG_FILE", file)
+	load(9000, "the file")
+	t.Setenv("PORT", "9100")
+	t.Setenv("WEBHOOK_PATH", "/env")
+	load(9100, "the environment")
+	flags = parseFlags(t, "--port", "9200")
+	cfg := load(9200, "the flags")
+
+	if cfg.Server.WebhookPath != "/env" {
+		t.Errorf("Expected the environment to set the webhook path no flag sets, got %q", cfg.Server.WebhookPath)
+	}
+	if cfg.Server.BasePath != "/file" {
+		t.Errorf("Expected the file to set the base path nothing overrides, got %q", cfg.Server.BasePath)
+	}
+}
+
+func TestLoadConfigFlags(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "12345")
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yml"))
+	file := filepath.Join(t.TempDir(), "flags.yml")
+	if err := os.WriteFile(file, []byte("server:\n  port: 9000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := parseFlags(t, "--config", file, "--app-id", "67890", "--base-path", "/gitguard", "--webhook-path", "/hooks")
+	cfg, err := LoadLocalConfig(flags)
+	if err != nil {
+		t.Fatalf("Expected the config flag to override CONFIG_FILE, got: %v", err)
+	}
+	if FilePath(flags) != file || cfg.GetPort() != 9000 {
+		t.Errorf("Expected the config file of the flag, got %s with port %d", FilePath(flags), cfg.GetPort())
+	}
+	if cfg.GetAppID() != 67890 {
+		t.Errorf("Expected the flag to override GITHUB_APP_ID, got %d", cfg.GetAppID())
+	}
+	if cfg.GetWebhookPath() != "/gitguard/hooks" {
+		t.Errorf("Expected the base path and webhook path of the flags, got %s", cfg.GetWebhookPath())
+	}
+
+	flags = parseFlags(t, "--config", filepath.Join(t.TempDir(), "missing.yml"))
+	if _, err := LoadLocalConfig(flags); err == nil {
+		t.Error("Expected an error for a missing config file named by the flag")
+	}
+}
+
+func TestFlagsParse(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want error
+	}{
+		{"flags", []string{"--port", "9000"}, nil},
+		{"help", []string{"--help"}, flag.ErrHelp},
+		{"unknown flag", []string{"--verbose"}, errors.New("flag provided but not defined: -verbose")},
+		{"invalid value", []string{"--port", "http"}, errors.New(`invalid value "http" for flag -port: parse error`)},
+		{"argument", []string{"--port", "9000", "serve"}, errors.New(`unexpected argument "serve"`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewFlags("gitguard", io.Discard).Parse(tt.args)
+			switch {
+			case tt.want == nil && err != nil:
+				t.Errorf("Expected no error, got: %v", err)
+			case tt.want != nil && (err == nil || err.Error() != tt.want.Error()):
+				t.Errorf("Expected error %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
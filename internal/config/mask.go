@@ -0,0 +1,64 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+)
+
+// MaskedValue replaces secrets in masked configurations.
+const MaskedValue = "********"
+
+// Masked returns a copy of the configuration that is safe to print: fields tagged
+// `secret:"true"` are replaced with MaskedValue when set, and passwords in other URLs, such
+// as an event bus URL with credentials, are redacted.
+func (c *Config) Masked() *Config {
+	masked := &Config{}
+	maskValue(reflect.ValueOf(masked).Elem(), reflect.ValueOf(c).Elem(), false)
+	return masked
+}
+
+// maskValue copies src into dst, masking strings when secret is set.
+func maskValue(dst, src reflect.Value, secret bool) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := range src.NumField() {
+			field := src.Type().Field(i)
+			maskValue(dst.Field(i), src.Field(i), secret || field.Tag.Get("secret") == "true")
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := range src.Len() {
+			maskValue(dst.Index(i), src.Index(i), secret)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		for iter := src.MapRange(); iter.Next(); {
+			value := reflect.New(src.Type().Elem()).Elem()
+			maskValue(value, iter.Value(), secret)
+			dst.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.String:
+		dst.SetString(maskString(src.String(), secret))
+	default:
+		dst.Set(src)
+	}
+}
+
+func maskString(value string, secret bool) string {
+	if value == "" {
+		return ""
+	}
+	if secret {
+		return MaskedValue
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
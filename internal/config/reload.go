@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// restartSections are the configuration sections that only take effect on restart: the
+// listeners, the App credentials (rotated by the secrets backend instead), the stores, the
+// scan cache, the rate limit scheduler and the scheduled organization reports.
+var restartSections = map[string]bool{
+	"github":     true,
+	"server":     true,
+	"secrets":    true,
+	"store":      true,
+	"baseline":   true,
+	"findings":   true,
+	"gitlab":     true,
+	"grpc":       true,
+	"admin":      true,
+	"scan_cache": true,
+	"rate_limit": true,
+	"reports":    true,
+}
+
+// Changes compares two configurations section by section and returns the names of the
+// changed sections a reload applies and of those that require a restart. Values are not
+// returned, so secrets never end up in logs.
+func Changes(old, updated *Config) (reloaded, restart []string) {
+	oldValue := reflect.ValueOf(old).Elem()
+	updatedValue := reflect.ValueOf(updated).Elem()
+	for i := range oldValue.NumField() {
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), updatedValue.Field(i).Interface()) {
+			continue
+		}
+		name := sectionName(oldValue.Type().Field(i))
+		if restartSections[name] {
+			restart = append(restart, name)
+		} else {
+			reloaded = append(reloaded, name)
+		}
+	}
+	return reloaded, restart
+}
+
+// Preserve copies the sections that require a restart from the running configuration, so a
+// reloaded configuration only changes what a reload applies.
+func (c *Config) Preserve(running *Config) {
+	value := reflect.ValueOf(c).Elem()
+	runningValue := reflect.ValueOf(running).Elem()
+	for i := range value.NumField() {
+		if restartSections[sectionName(value.Type().Field(i))] {
+			value.Field(i).Set(runningValue.Field(i))
+		}
+	}
+}
+
+// sectionName returns the configuration file name of a Config field.
+func sectionName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return name
+}
@@ -7,26 +7,46 @@ const (
 	CheckRunName    = "gitguard/secret-scan"
 	MaxFileChanges  = 1000
 	EmptyTreeSHA    = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	ZeroSHA         = "0000000000000000000000000000000000000000"
 	BranchRefPrefix = "refs/heads/"
 
 	// GitHub event types.
-	PushEventType = "push"
+	PushEventType         = "push"
+	PullRequestEventType  = "pull_request"
+	IssueCommentEventType = "issue_comment"
+	CheckRunEventType     = "check_run"
+	PingEventType         = "ping"
+
+	// CommitScanConcurrency is the number of commits of a push scanned at once, as far as the
+	// installation's share of the scan slots and its rate limit allow.
+	CommitScanConcurrency = 4
 
 	// File statuses.
 	FileStatusRemoved = "removed"
 
+	// Contents API types and encodings: only files have content, and files over 1 MB are
+	// returned without it, with the "none" encoding.
+	ContentTypeFile     = "file"
+	ContentEncodingNone = "none"
+
 	// Check run statuses and conclusions.
 	StatusInProgress  = "in_progress"
 	StatusCompleted   = "completed"
 	ConclusionSuccess = "success"
 	ConclusionFailure = "failure"
 
+	ConclusionNeutral        = "neutral"
+	ConclusionActionRequired = "action_required"
+
 	// Check run titles and summaries.
 	CheckRunTitleInProgress = "GitGuard Secret Scan"
 	CheckRunTitleError      = "GitGuard Secret Scan - Error"
 	CheckRunTitleClean      = "GitGuard Secret Scan - Clean"
 	CheckRunTitleSecrets    = "GitGuard Secret Scan - Secrets Detected"
 
+	CheckRunTitleNeutral        = "GitGuard Secret Scan - Low Severity Findings"
+	CheckRunTitleActionRequired = "GitGuard Secret Scan - Action Required"
+
 	CheckRunSummaryInProgress = "🔍 Scanning commit for secrets and sensitive information..."
 	CheckRunSummaryError      = "❌ Failed to scan commit for secrets. Please try again."
 	CheckRunSummaryClean      = "✅ No secrets or sensitive information detected in this commit."
@@ -34,6 +54,20 @@ const (
 		"Please review and remove sensitive information." // #nosec G101 -- Not a credential, just a user-facing message.
 	CheckRunSummaryTypes = "\n\n**Types of secrets found:**\n"
 
+	CheckRunSummarySeverity = "\n\n**Highest severity:** %s\n"
+
+	CheckRunSummaryWorkflows = "\n⚙️ **%d finding(s) in GitHub Actions workflows**, which run with repository credentials.\n"
+
+	// Check run report text. GitHub rejects output text over 65535 characters.
+	CheckRunTextMaxChars  = 65000
+	CheckRunTableMax      = 50
+	CheckRunTableHeader   = "| File | Line | Rule | Severity | Fingerprint |\n| --- | --- | --- | --- | --- |\n"
+	CheckRunTableMore     = "\n_...and %d more, see the JSON report below._\n"
+	CheckRunReportHeading = "\n### Machine-readable report\n\n"
+	CheckRunReportVersion = 1
+	CheckRunTableSecret   = "| File | Line | Rule | Severity | Fingerprint | Secret |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n"
+
 	// Error messages.
 	ErrCreateGitleaksConfig = "failed to create gitleaks config: %w"
 	ErrUnmarshalPushEvent   = "failed to unmarshal push event: %w"
@@ -41,11 +75,82 @@ const (
 	ErrGetCommitDiff        = "failed to get commit diff: %w"
 	ErrCreateCheckRun       = "failed to create check run: %w"
 	ErrUpdateCheckRun       = "failed to update check run: %w"
+	ErrUnmarshalPREvent     = "failed to unmarshal pull request event: %w"
+	ErrUnmarshalComment     = "failed to unmarshal issue comment event: %w"
+	ErrGetPullRequest       = "failed to get pull request: %w"
+	ErrListPRComments       = "failed to list pull request comments: %w"
+	ErrGetApp               = "failed to get GitHub App: %w"
+	ErrGetPermission        = "failed to get repository permission: %w"
+	ErrUnmarshalCheckRun    = "failed to unmarshal check run event: %w"
 
 	// Full repository scan configuration.
-	FullScanTimeout = 60 * time.Second
-	IssueTitle      = "🚨 GitGuard: Secrets Detected in Repository"
-	IssueLabel      = "security"
+	FullScanTimeout    = 60 * time.Second
+	TokenRevokeTimeout = 10 * time.Second
+	IssueTitle         = "🚨 GitGuard: Secrets Detected in Repository"
+	IssueLabel         = "security"
+
+	// Issue grouping: one issue per repository, per rule or per top-level directory. Issues
+	// are found again by the hidden marker in their body, whatever their title.
+	IssueGroupingSingle    = "single"
+	IssueGroupingRule      = "rule"
+	IssueGroupingDirectory = "directory"
+	IssueMarker            = "<!-- gitguard:issue %s -->\n"
+	IssueTitleRule         = "🚨 GitGuard: %s Secrets Detected"
+	IssueTitleDirectory    = "🚨 GitGuard: Secrets Detected in %s"
+	IssueRootDirectory     = "/"
+	IssueListMax           = 100
+	IssueListMaxPages      = 10
+	LogMsgIssueExists      = "Security issue already exists, skipping creation"
+	LogMsgIssueListFailed  = "Failed to check for existing security issues, proceeding to create new issues"
+	LogMsgIssueLabelFailed = "Failed to label security issue"
+	LogMsgIssueSLAFailed   = "Failed to set the SLA due date of security issue"
+	LogMsgIssueMigrated    = "Added the GitGuard marker to an existing security issue"
+	LogMsgIssueMigrateFail = "Failed to add the GitGuard marker to an existing security issue"
+
+	// Issue size limits. GitHub rejects issue bodies and comments over 65536 characters.
+	IssueBodyMaxChars     = 65000
+	IssueLocationsMax     = 200
+	IssueLocationsMore    = "- ...and %d more, listed in the comments below\n"
+	IssueBodyTruncated    = "\n_This report was truncated to fit GitHub's issue size limit._\n"
+	IssueReportPageHeader = "### Full Report: File Locations (%d/%d)\n\n"
+
+	// SLA due dates of security issues: a comment, or a milestone per due date.
+	IssueSLADue       = "⏰ **SLA:** secrets of %s severity must be rotated and removed by **%s**.\n"
+	SLAMilestoneTitle = "GitGuard SLA %s"
+	SLAMilestoneDesc  = "Security issues whose secrets scanning SLA ends on %s."
+
+	// Issue body.
+	IssueIntro = "## 🚨 Security Alert: Secrets Detected\n\n" +
+		"GitGuard has detected potential secrets in your repository during a full scan. " +
+		"Please review these findings and take appropriate action.\n\n" +
+		"**Total findings:** %d\n\n"
+	IssueTypesHeading     = "### Detected Secret Types\n\n"
+	IssueTypeCount        = "- **%s**: %d occurrence(s)\n"
+	IssueLocationsHeading = "\n### File Locations\n\n"
+	IssueLocation         = "- `%s` (line %d)\n"
+	IssueLocationBy       = "- `%s` (line %d), introduced by %s in `%s` on %s\n"
+	IssueLocationSecret   = "  - Secret: `%s`\n"
+	IssueUnknownFile      = "unknown file"
+	IssueActions          = "\n### Recommended Actions\n\n" +
+		"1. **Immediately rotate** any exposed credentials\n" +
+		"2. **Remove secrets** from the repository history\n" +
+		"3. **Use environment variables** or secure secret management\n" +
+		"4. **Add secrets to .gitignore** to prevent future commits\n" +
+		"5. **Review commit history** for other potential exposures\n\n" +
+		"### Important Notes\n\n" +
+		"- This issue was created automatically by GitGuard\n" +
+		"- Secrets may be visible in commit history even after removal\n" +
+		"- Use `git filter-repo` or `BFG Repo-Cleaner` to purge secrets from history\n"
+	IssueBranchesHeading = "\n### Also Detected on Other Branches\n\n" +
+		"These secrets were also pushed to other branches, where they stay readable after their removal " +
+		"from the default branch:\n\n"
+	IssueBranch       = "- `%s` (%s): %s\n"
+	IssueBranchesMore = "- ...and %d more\n"
+	IssueBranchesMax  = 50
+	IssueCleanupIntro = "\n### History Cleanup\n\n" +
+		"Removing a secret in a new commit leaves it readable in history. " +
+		"Run these commands in a fresh mirror clone (`git clone --mirror`), " +
+		"then force-push all branches and tags and ask collaborators to re-clone.\n"
 
 	// Full repository scan error messages.
 	ErrCloneRepository      = "failed to clone repository: %w"
@@ -55,11 +160,23 @@ const (
 	ErrInvalidCloneURL      = "invalid clone URL"
 	ErrScanTimeout          = "repository scan timed out"
 	ErrGetInstallationToken = "failed to get installation token: %w"
+	ErrScheduleScan         = "failed to wait for a scan slot: %w"
+	ErrScanCommits          = "failed to scan %d of %d commits: %w"
 
 	// Log messages.
 	LogMsgSkippingEvent      = "Skipping event - no commits or not a branch push"
 	LogMsgSkippingNonDefault = "Skipping event - not a push to default branch"
+	LogMsgSkippingOutOfScope = "Skipping event - repository excluded from scanning"
 	LogMsgProcessingCommits  = "Processing commits for secret scanning"
+	LogMsgScanningHeadOnly   = "Scanning cumulative diff of push on head commit"
+	LogMsgScanCacheHit       = "Reusing cached scan result for commit"
+	LogMsgTreeCacheHit       = "Reusing cached scan result for tree"
+	LogMsgScanCacheFailed    = "Scan cache unavailable, scanning without it"
+	LogMsgIgnoreFileFailed   = "Failed to read the ignore file, scanning every file"
+	LogMsgFileContentFailed  = "Failed to read file content, skipping file"
+	LogMsgFileOverSizeLimit  = "File exceeds the commit scan size limit, skipping file"
+	LogMsgFileTooManyChanges = "File has too many changed lines to scan without blob downloads, skipping file"
+	LogMsgInitialCommit      = "Commit has no parent, comparing with the empty tree"
 	LogMsgFailedScanCommit   = "Failed to scan commit"
 	LogMsgCreatedCheckRun    = "Created check run"
 	LogMsgUpdatedCheckRun    = "Updated check run with scan results"
@@ -69,4 +186,269 @@ const (
 	LogMsgCreatedIssue       = "Created security issue for detected secrets"
 	LogMsgNoSecretsFound     = "No secrets found in full repository scan"
 	LogMsgCloningRepository  = "Cloning repository for full scan"
+	LogMsgTokenRevokeFailed  = "Failed to revoke installation token used for cloning"
+	LogMsgScanDeferred       = "Installation rate limit budget is low, deferring scan until it resets"
+	LogMsgScanQueueFull      = "Scan queue is full, rejecting scan"
+	LogMsgScanShed           = "Dropped queued low-priority scan for a higher-priority scan"
+	LogMsgLFSFetchFailed     = "Failed to download Git LFS object, skipping it"
+	LogMsgFileSkipped        = "Failed to read file, skipping it"
+	LogMsgIssueReportFailed  = "Failed to post full report to security issue"
+
+	// Credential log messages.
+	LogMsgPrivateKeyVerified   = "GitHub App private key verified"
+	LogMsgPrivateKeyFallback   = "GitHub rejected private key, falling back to next configured key"
+	LogMsgPrivateKeyCheck      = "GitHub App private key self-check failed"
+	LogMsgSecretsRefreshFailed = "Failed to refresh secrets from secret manager"
+	LogMsgWebhookSecretRotated = "Webhook secret changed in secret manager, dispatcher updated"
+	LogMsgPrivateKeyRotated    = "Private key changed in secret manager, clients updated"
+	LogMsgClientCacheDisabled  = "Failed to create installation client cache, caching disabled"
+
+	// Webhook middleware log messages.
+	LogMsgHookRangesLoaded    = "Loaded GitHub hook IP ranges"
+	LogMsgHookRangesFailed    = "Failed to refresh GitHub hook IP ranges"
+	LogMsgRejectedHookSource  = "Rejected webhook from address outside GitHub hook ranges"
+	LogMsgPayloadTooLarge     = "Rejected webhook payload exceeding size limit"
+	LogMsgInvalidWebhook      = "Received invalid webhook headers or payload"
+	LogMsgWebhookOverCapacity = "Dropping webhook event due to over-capacity scheduler"
+	LogMsgWebhookFailed       = "Unexpected error handling webhook"
+	LogMsgUnknownGitHubApp    = "Rejected webhook addressed to an unconfigured GitHub App"
+	LogMsgWebhookRecorded     = "Recorded webhook delivery as a replay fixture"
+	LogMsgWebhookRecordFailed = "Failed to record webhook delivery as a replay fixture"
+
+	// Remediation pull requests.
+	RedactionPlaceholder     = "<REDACTED-BY-GITGUARD>"
+	RemediationBranchPrefix  = "gitguard/redact-"
+	RemediationPRTitle       = "🔒 GitGuard: Redact detected secrets"
+	RemediationCommitMessage = "Redact secrets detected by GitGuard"
+	RemediationIntro         = "## 🔒 GitGuard Secret Redaction\n\n" +
+		"This pull request replaces secrets detected by GitGuard with `%s`.\n\n"
+	RemediationIssue            = "Related security issue: #%d\n\n"
+	RemediationLocationsHeading = "### Redacted Locations\n\n"
+	RemediationLocation         = "- `%s` (line %d, %s)\n"
+	RemediationActions          = "\n### Before Merging\n\n" +
+		"1. **Rotate** every redacted credential - merging does not revoke it\n" +
+		"2. Replace the placeholders with references to environment variables or a secret manager\n" +
+		"3. Secrets remain in the repository history until it is rewritten\n"
+	ErrCreateRemediation     = "failed to create remediation pull request: %w"
+	LogMsgCreatedRemediation = "Created remediation pull request"
+	LogMsgRemediationExists  = "Remediation branch already exists, skipping pull request"
+	LogMsgNothingToRedact    = "No redactable secrets found, skipping remediation pull request"
+
+	// History cleanup instructions.
+	MaxCleanupTargets        = 20 // Keeps the issue body well below GitHub's size limit.
+	LogMsgCleanupTraceFailed = "Failed to trace secret history, omitting cleanup instructions"
+
+	// Finding attribution.
+	AttributionMaxSecrets    = 200 // Bounds the history walks of a full scan.
+	AttributionMaxLookups    = 50  // Bounds the API requests resolving commit authors.
+	LogMsgAttributionFailed  = "Failed to trace the commit that introduced a secret"
+	LogMsgAuthorLookupFailed = "Failed to look up the GitHub account of a commit author"
+
+	// Notification log messages.
+	LogMsgNotificationFailed = "Failed to deliver notification"
+	LogMsgAlertFailed        = "Failed to reconcile on-call alerts"
+	AlertSummary             = "GitGuard: critical %s secret exposed in public repository %s"
+
+	// Detector log messages.
+	LogMsgDetectorBuilt        = "Built secret detector"
+	LogMsgRulePackReloadFailed = "Failed to reload rule packs, keeping previous rules"
+	LogMsgRulePackFallback     = "Failed to load rule packs, using default rules"
+	LogMsgPluginFailed         = "Detector plugin failed"
+
+	// Chunked detection: contents are scanned in chunks overlapping by enough to hold any
+	// secret spanning a chunk boundary, and at most MaxScanFileBytes of a file is read.
+	DetectChunkBytes   = 1 << 20
+	DetectChunkOverlap = 16 << 10
+	MaxScanFileBytes   = 32 << 20
+	LogMsgFileTooLarge = "File exceeds the scan size limit, only its beginning was scanned"
+
+	// Baselines.
+	CheckRunSummaryBaselined  = "\n\nℹ️ %d pre-existing finding(s) are in the repository baseline and do not fail this check.\n"
+	CheckRunSummaryCumulative = "\n\nℹ️ This push had %d commits; their cumulative diff was scanned on the head commit.\n"
+	ErrBaselineDisabled       = "baselines are disabled; set BASELINE_ENABLED=true"
+	ErrFindInstallation       = "failed to find installation for %s/%s: %w"
+	LogMsgBaselineRecorded    = "Recorded repository baseline"
+	LogMsgBaselineApplied     = "Excluded baselined findings"
+	LogMsgBaselineFailed      = "Failed to apply repository baseline"
+
+	// GitHub secret scanning alert correlation: findings whose secret has a native alert, in
+	// any state, are left out of full scans, as they are triaged on GitHub.
+	NativeAlertsListMax      = 100
+	NativeAlertsListMaxPages = 10
+	LogMsgNativeAlertsFailed = "Failed to list GitHub secret scanning alerts, reporting every finding"
+	LogMsgNativeCorrelated   = "Correlated findings with GitHub secret scanning alerts"
+
+	// Finding lifecycle.
+	LogMsgTrackFindingsFailed = "Failed to record finding lifecycle states"
+	LogMsgFindingTransitions  = "Findings changed lifecycle state"
+	LogMsgFindingsRepeated    = "Every finding was already reported on this or another branch, skipping notification"
+	CheckRunSummaryAges       = "\n\n🕰️ %d new finding(s); %d long-standing, first seen over %d day(s) ago.\n"
+	CheckRunTableNew          = " 🆕"
+
+	// Privacy check run.
+	PrivacyCheckRunName      = "gitguard/privacy"
+	PrivacyTitleClean        = "GitGuard Privacy Scan - Clean"
+	PrivacyTitleFindings     = "GitGuard Privacy Scan - Personal Data Detected"
+	PrivacySummaryClean      = "✅ No personal or internal data detected in this commit."
+	PrivacySummaryFindings   = "🔎 **%d finding(s) of personal or internal data** in this commit:\n\n"
+	LogMsgPrivacyCheckFailed = "Failed to report privacy check run"
+
+	// Push summary check run: the results of the commits of a push scanned one by one, on its
+	// head commit.
+	PushSummaryCheckRunName = "gitguard/push-summary"
+	PushSummaryMaxCommits   = 200
+	PushSummaryClean        = "✅ No secrets or sensitive information detected in the %d commits of this push."
+	PushSummarySecrets      = "🚨 **%d secret(s) detected** in %d of the %d commits of this push." +
+		" Please review and remove sensitive information." // #nosec G101 -- Not a credential, just a user-facing message.
+	PushSummaryErrors       = "\n\n❌ %d commit(s) could not be scanned. Please try again.\n"
+	PushSummaryTable        = "\n\n| Commit | Findings | Result |\n| --- | --- | --- |\n"
+	PushSummaryMore         = "\n_...and %d more commit(s)._\n"
+	LogMsgPushSummaryFailed = "Failed to report push summary check run"
+
+	// Commit scan reporting: on check runs, as commit comments or both. Comments are found
+	// again by the marker at the top of their body, so a commit is commented on once.
+	ReportingChecks      = "checks"
+	ReportingComments    = "comments"
+	ReportingBoth        = "both"
+	CommitCommentMarker  = "<!-- gitguard:commit-comment -->\n"
+	CommitCommentHeading = "🚨 **GitGuard detected %d secret(s) in this commit.**"
+	CommitCommentMore    = "\n_...and %d more._\n"
+	CommitCommentActions = "\n### Recommended Actions\n\n" +
+		"1. **Immediately rotate** the exposed credentials; they are compromised once pushed\n" +
+		"2. **Remove the secrets** and load them from environment variables or a secret manager\n" +
+		"3. **Purge them from history** with `git filter-repo` or `BFG Repo-Cleaner` " +
+		"if the commit is not widely shared\n"
+	LogMsgCommitCommented     = "Commented on commit with findings"
+	LogMsgCommitCommentFailed = "Failed to comment on commit"
+	LogMsgCommitCommentExists = "Commit already has a GitGuard comment, skipping"
+
+	// How secrets appear in stored and reported findings: not at all, as a masked preview
+	// or as a SHA-256 hash.
+	RedactionNone = "none"
+	RedactionMask = "mask"
+	RedactionHash = "hash"
+
+	// Pull request gate: a check run concluding action_required and a comment, found again by
+	// its marker, while a pull request adds secrets the severity policy fails on. Findings are
+	// dismissed by commenting the dismiss command with a justification; GitGuard records the
+	// dismissed fingerprints in a marked reply, which later scans of the pull request honor.
+	PullRequestCheckRunName    = "gitguard/pre-merge"
+	PullRequestCommentMarker   = "<!-- gitguard:pull-request -->\n"
+	PullRequestDismissalMarker = "<!-- gitguard:dismissal %s -->\n"
+	PullRequestDismissCommand  = "/gitguard dismiss"
+	PullRequestCommentListMax  = 100
+	PullRequestCommentMaxPages = 10
+	PullRequestCommentHeading  = "🛑 **GitGuard blocked this pull request: it adds %d secret(s).**\n\n"
+	PullRequestCommentMore     = "\n_...and %d more._\n"
+	PullRequestCommentOptions  = "\n### Resolve before merging\n\n" +
+		"1. **Remove** the secrets from the branch and load them from environment variables or a secret manager; " +
+		"rewrite the branch history if its commits are shared\n" +
+		"2. **Rotate** the exposed credentials: they are compromised once pushed, even to a branch\n" +
+		"3. **Dismiss** false positives and test fixtures by commenting `/gitguard dismiss <justification>`; " +
+		"this needs write access and the justification is recorded on this pull request\n"
+	PullRequestSummarySecrets   = "🚨 **%d secret(s) added** by this pull request." // #nosec G101 -- A user-facing message.
+	PullRequestSummaryClean     = "✅ This pull request adds no secrets or sensitive information."
+	PullRequestCommentResolved  = "✅ **GitGuard no longer finds blocking secrets in this pull request.**\n"
+	PullRequestSummaryBlocked   = "\n\nResolve them as listed in the pull request comment before merging.\n"
+	PullRequestSummaryDismissed = "\n\nℹ️ %d finding(s) were dismissed on the pull request with a justification.\n"
+	PullRequestDismissed        = "✅ @%s dismissed %d finding(s) with this justification:\n\n%s\n"
+	PullRequestDismissDenied    = "❌ @%s, dismissing GitGuard findings requires write access to the repository.\n"
+	PullRequestDismissReason    = "❌ @%s, a dismissal needs a justification: `/gitguard dismiss <why this is safe to merge>`.\n"
+	PullRequestDismissNothing   = "ℹ️ @%s, GitGuard finds nothing to dismiss in this pull request.\n"
+	LogMsgPullRequestGated      = "Gated pull request"
+	LogMsgPullRequestFailed     = "Failed to gate pull request"
+	LogMsgPullRequestComment    = "Failed to update the pull request comment"
+	LogMsgFindingsDismissed     = "Dismissed pull request findings"
+
+	// Rotation tickets: failing commit check runs offer an action filing a ticket to rotate the
+	// secrets they list, as an issue in the repository or in Jira. The ticket is recorded in
+	// the check run summary behind its marker, so a check run files a single ticket. GitHub
+	// limits action labels to 20 characters and descriptions to 40.
+	TicketsGitHub             = "github"
+	TicketsJira               = "jira"
+	RotationActionID          = "rotation-ticket"
+	RotationActionLabel       = "Create ticket"
+	RotationActionDescription = "File a ticket to rotate these secrets"
+	RotationTicketMarker      = "<!-- gitguard:rotation-ticket -->"
+	RotationTicketLabel       = "secret-rotation"
+	RotationTicketMax         = 50
+	RotationTicketTitle       = "🔑 GitGuard: Rotate %d secret(s) exposed in %s"
+	RotationTicketIntro       = "GitGuard detected %d secret(s) in commit %s of %s. " +
+		"They are compromised once pushed: rotate each one, then revoke the exposed value.\n\n" +
+		"**Check run:** %s\n\n"
+	RotationTicketFinding        = "- [ ] `%s` line %d: %s (%s severity, fingerprint `%s`)\n"
+	RotationTicketMore           = "- ...and %d more, listed on the check run\n"
+	RotationTicketFiled          = "\n\n🎫 **Rotation ticket:** %s\n"
+	ErrReadCheckRunReport        = "check run %d has no GitGuard findings report"
+	ErrCreateRotationTicket      = "failed to create rotation ticket: %w"
+	LogMsgRotationTicketCreated  = "Created rotation ticket"
+	LogMsgRotationTicketExists   = "Check run already has a rotation ticket, skipping"
+	LogMsgRotationTicketNoUpdate = "Failed to record the rotation ticket on the check run"
+
+	// Repository dispatch: the event fired on repositories whose scans find secrets, with at
+	// most DispatchMaxDetails findings in its payload.
+	DispatchEventType    = "gitguard-scan-completed"
+	DispatchMaxDetails   = 100
+	LogMsgDispatched     = "Fired repository_dispatch event"
+	LogMsgDispatchFailed = "Failed to fire repository_dispatch event"
+
+	// Policy rules.
+	LogMsgPolicyFailed  = "Failed to evaluate policy rules"
+	LogMsgPolicyMatched = "Policy rule matched"
+	LogMsgDryRun        = "Dry run: findings reported without enforcement"
+
+	// Templates.
+	LogMsgTemplateFailed = "Failed to render template, using the built-in text"
+
+	// Organization and on-demand scans.
+	ErrFindOrgInstallation = "failed to find installation for organization %s: %w"
+	ErrListRepositories    = "failed to list installation repositories: %w"
+	ErrGetBranch           = "failed to get default branch head: %w"
+	ErrResolveRef          = "failed to resolve ref %s: %w"
+	ErrCommitNotCloned     = "commit %s is not reachable from the cloned branches: %w"
+	LogMsgOrgScanStarted   = "Starting organization scan"
+	LogMsgOrgScanRepo      = "Organization scan finished repository"
+	LogMsgOrgScanComplete  = "Organization scan completed"
+
+	// Full scan check run.
+	FullScanCheckRunName       = "gitguard/full-scan"
+	FullScanTitleInProgress    = "GitGuard Full Repository Scan"
+	FullScanSummaryStarting    = "🔍 Cloning the repository..."
+	FullScanSummaryProgress    = "🔍 Scanned %d of %d files, %d finding(s) so far. About %s remaining."
+	FullScanSummaryClean       = "✅ No secrets or sensitive information detected on the default branch."
+	FullScanSummarySecrets     = "🚨 **%d secret(s) detected** on the default branch."
+	FullScanSummaryIssue       = "\n\nSee %s for the affected files and remediation steps.\n"
+	FullScanSummaryIssues      = "\n\nSee these issues for the affected files and remediation steps:\n"
+	FullScanSummaryNotScanned  = "\n\n⚠️ Not scanned: %d submodule(s) and %d Git LFS object(s).\n"
+	FullScanSummarySkipped     = "\n\n⚠️ %d file(s) skipped due to errors:\n"
+	FullScanSummarySkippedMore = "- and %d more\n"
+	FullScanSummaryAlerted     = "\n\nℹ️ %d finding(s) already have a GitHub secret scanning alert and are not reported again.\n"
+	FullScanSummaryUnalerted   = "\n\n🔎 %d finding(s) were not detected by GitHub secret scanning.\n"
+	FullScanSkippedListMax     = 20
+	FullScanSummaryError       = "❌ Full repository scan failed. It will run again on the next push to the default branch."
+	FullScanTitleTooLarge      = "GitGuard Full Repository Scan - Too Large"
+	FullScanSummaryTooLarge    = "⏭️ The repository is %d MB, over the full scan limit of %d MB, so it was not scanned."
+	FullScanSummaryManyFiles   = "⏭️ The repository has more than %d files, the full scan limit, so it was not scanned."
+	FullScanProgressInterval   = 10 * time.Second
+	LogMsgFullScanCheckFailed  = "Failed to report full scan check run"
+	LogMsgRepoTooLarge         = "Skipping full scan - repository exceeds scan limits"
+	LogMsgRepoTreeFailed       = "Failed to count repository files, scanning anyway"
+
+	// SCM providers.
+	LogMsgProviderUnauthorized = "Rejected webhook with invalid token"
+	LogMsgProviderInvalidHook  = "Failed to parse webhook"
+	LogMsgReportedScan         = "Reported scan result"
+
+	// Configuration reload.
+	LogMsgConfigReloaded      = "Configuration reloaded"
+	LogMsgConfigUnchanged     = "Configuration reload requested, nothing to apply"
+	LogMsgConfigReloadFailed  = "Configuration reload failed, keeping the running configuration"
+	LogMsgConfigReloadRestart = "Changed configuration sections take effect only after a restart"
+	LogMsgConfigWatchFailed   = "Cannot watch the config file; reload with SIGHUP instead"
+	LogMsgNotifierCloseFailed = "Failed to close the notifiers of the replaced configuration"
+
+	// Startup self-check.
+	LogMsgSelfCheckFailed           = "GitHub App self-check failed: cannot authenticate or list installations"
+	LogMsgSelfCheckCompleted        = "GitHub App self-check completed"
+	LogMsgInstallationMisconfigured = "Installation is missing permissions; its scans will fail"
 )
@@ -0,0 +1,86 @@
+package detector
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// SecretHashPrefix marks allowlist entries holding the hex SHA-256 of a secret rather than
+// the secret itself.
+const SecretHashPrefix = "sha256:"
+
+// secretHashesDescription identifies the allowlist carrying the hashes of allowlisted
+// secrets as stop words. gitleaks matches stop words within secrets, which a hash never is;
+// Check drops the findings whose secret hashes to one of them.
+const secretHashesDescription = "gitguard allowlisted secret hashes"
+
+// ParseAllowlist parses an allowlist document: one secret or SecretHashPrefix hash per line.
+// Blank lines and lines starting with # are ignored.
+func ParseAllowlist(data []byte) []string {
+	var entries []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// AllowlistConfig returns a config whose allowlists drop the findings of the allowlisted
+// secrets: secrets listed as such are matched by gitleaks, hashed secrets by Check.
+func AllowlistConfig(entries []string) (config.Config, error) {
+	var cfg config.Config
+	var values []*regexp.Regexp
+	var hashes []string
+	for _, entry := range entries {
+		digest, ok := strings.CutPrefix(entry, SecretHashPrefix)
+		if !ok {
+			values = append(values, regexp.MustCompile("^"+regexp.QuoteMeta(entry)+"$"))
+			continue
+		}
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return config.Config{}, fmt.Errorf("invalid allowlisted secret hash %q", entry)
+		}
+		hashes = append(hashes, SecretHashPrefix+strings.ToLower(digest))
+	}
+	if len(values) > 0 {
+		cfg.Allowlists = append(cfg.Allowlists, &config.Allowlist{
+			Description: "allowlisted secrets",
+			Regexes:     values,
+		})
+	}
+	if len(hashes) > 0 {
+		cfg.Allowlists = append(cfg.Allowlists, &config.Allowlist{
+			Description: secretHashesDescription,
+			StopWords:   hashes,
+		})
+	}
+	return cfg, nil
+}
+
+// Check applies the checks GitGuard adds to the findings d reported: secrets allowlisted by
+// hash are dropped and PEM blocks are checked with ValidatePEM.
+func Check(d *detect.Detector, findings []report.Finding) []report.Finding {
+	for _, allowlist := range d.Config.Allowlists {
+		if allowlist.Description != secretHashesDescription {
+			continue
+		}
+		findings = slices.DeleteFunc(findings, func(finding report.Finding) bool {
+			sum := sha256.Sum256([]byte(finding.Secret))
+			return slices.Contains(allowlist.StopWords, SecretHashPrefix+hex.EncodeToString(sum[:]))
+		})
+	}
+	return ValidatePEM(findings)
+}
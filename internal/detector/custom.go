@@ -0,0 +1,66 @@
+package detector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+// Rule is a custom detection rule defined in GitGuard's own configuration.
+type Rule struct {
+	ID          string
+	Description string
+	Regex       string
+	SecretGroup int
+	Entropy     float64
+	Keywords    []string
+}
+
+// RulesConfig translates custom rules into a gitleaks config that can be merged into the
+// default rules.
+func RulesConfig(rules []Rule) (config.Config, error) {
+	cfg := config.Config{
+		Rules:    make(map[string]config.Rule, len(rules)),
+		Keywords: make(map[string]struct{}),
+	}
+
+	for _, r := range rules {
+		regex, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("custom rule %q: %w", r.ID, err)
+		}
+		if r.Regex == "" {
+			regex = nil
+		}
+
+		keywords := make([]string, 0, len(r.Keywords))
+		for _, keyword := range r.Keywords {
+			keyword = strings.ToLower(keyword)
+			keywords = append(keywords, keyword)
+			cfg.Keywords[keyword] = struct{}{}
+		}
+
+		rule := config.Rule{
+			RuleID:      r.ID,
+			Description: r.Description,
+			Regex:       regex,
+			SecretGroup: r.SecretGroup,
+			Entropy:     r.Entropy,
+			Keywords:    keywords,
+			Tags:        []string{},
+		}
+		if err := rule.Validate(); err != nil {
+			return config.Config{}, fmt.Errorf("custom rule: %w", err)
+		}
+		if _, exists := cfg.Rules[r.ID]; exists {
+			return config.Config{}, fmt.Errorf("custom rule %q is defined more than once", r.ID)
+		}
+
+		cfg.Rules[r.ID] = rule
+		cfg.OrderedRules = append(cfg.OrderedRules, r.ID)
+	}
+
+	return cfg, nil
+}
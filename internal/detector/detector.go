@@ -0,0 +1,322 @@
+// Package detector builds the gitleaks detectors shared by all handlers.
+package detector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/detect"
+)
+
+// gitleaksModule is the module whose embedded default rules DefaultConfig returns.
+const gitleaksModule = "github.com/zricethezav/gitleaks/v8"
+
+// DefaultRulePackTTL is how long downloaded rule packs are used before they are fetched again.
+const DefaultRulePackTTL = time.Hour
+
+// GenericRules are the catch-all high-entropy rules adjusted by GenericTuning.
+var GenericRules = []string{"generic-api-key"}
+
+// GenericTuning adjusts the generic rules, the dominant source of false positives.
+type GenericTuning struct {
+	// Disabled removes the generic rules.
+	Disabled bool
+	// Entropy replaces the minimum Shannon entropy of generic secrets; zero keeps the default.
+	Entropy float64
+	// MinLength ignores generic secrets shorter than this many characters.
+	MinLength int
+}
+
+// Options configures the rules detectors are built from.
+type Options struct {
+	// RulePacks are remote gitleaks TOML configs whose rules extend the default rules.
+	RulePacks []RulePack
+	// CacheDir stores downloaded rule packs; empty disables the on-disk cache.
+	CacheDir string
+	// TTL is how long downloaded rule packs are reused; zero selects DefaultRulePackTTL.
+	TTL time.Duration
+	// PublicKey, when set, is a base64 ed25519 key every rule pack must be signed with.
+	PublicKey string
+	// Rules are custom rules added after the rule packs; they replace rules with the same ID.
+	Rules []Rule
+	// Generic tunes the generic rules of the default config and rule packs.
+	Generic GenericTuning
+	// Allowlist lists known test and dummy secrets, or their SecretHashPrefix hashes, that
+	// are never reported.
+	Allowlist []string
+	// AllowlistURLs are remote allowlists in the format of ParseAllowlist, fetched, pinned
+	// and verified like rule packs.
+	AllowlistURLs []RulePack
+}
+
+// Factory builds detectors from the default gitleaks rules extended with the configured rule
+// packs and custom rules, and rebuilds them when the rule packs expire.
+type Factory struct {
+	opts    Options
+	fetcher *fetcher
+	logger  zerolog.Logger
+
+	mu       sync.Mutex
+	detector *detect.Detector
+	digest   string
+	built    time.Time
+}
+
+// NewFactory creates a detector factory.
+func NewFactory(opts Options, logger zerolog.Logger) *Factory {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultRulePackTTL
+	}
+	return &Factory{
+		opts:    opts,
+		fetcher: newFetcher(opts),
+		logger:  logger,
+	}
+}
+
+// Detector returns the current detector. When rule packs cannot be loaded the previous
+// detector is kept, or the default rules are used until the next attempt.
+func (f *Factory) Detector(ctx context.Context) (*detect.Detector, error) {
+	d, _, err := f.VersionedDetector(ctx)
+	return d, err
+}
+
+// VersionedDetector returns the current detector like Detector, with the digest of the rule
+// packs and remote allowlists it was built from as fetched. Unpinned rule packs change
+// without the configuration changing, so results cached under the digest are not reused
+// once they do. The digest is empty for detectors built without remote rules.
+func (f *Factory) VersionedDetector(ctx context.Context) (*detect.Detector, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	remote := len(f.opts.RulePacks) > 0 || len(f.opts.AllowlistURLs) > 0
+	if f.detector != nil && (!remote || time.Since(f.built) < f.opts.TTL) {
+		return f.detector, f.digest, nil
+	}
+
+	cfg, digest, err := f.config(ctx)
+	if err != nil {
+		if f.detector != nil {
+			f.logger.Error().Err(err).Msg(constants.LogMsgRulePackReloadFailed)
+			f.built = time.Now()
+			return f.detector, f.digest, nil
+		}
+		f.logger.Error().Err(err).Msg(constants.LogMsgRulePackFallback)
+		if cfg, err = DefaultConfig(); err != nil {
+			return nil, "", err
+		}
+		digest = ""
+	}
+
+	f.detector = detect.NewDetector(cfg)
+	f.digest = digest
+	f.built = time.Now()
+	f.logger.Info().
+		Int("rules", len(cfg.Rules)).
+		Int("rule_packs", len(f.opts.RulePacks)).
+		Msg(constants.LogMsgDetectorBuilt)
+	return f.detector, f.digest, nil
+}
+
+// Config assembles the default rules, every rule pack, the custom rules and the allowlists.
+func (f *Factory) Config(ctx context.Context) (config.Config, error) {
+	cfg, _, err := f.config(ctx)
+	return cfg, err
+}
+
+// config assembles the rules like Config, with the digest of the fetched rule packs and
+// remote allowlists, or an empty digest when there are none.
+func (f *Factory) config(ctx context.Context) (config.Config, string, error) {
+	cfg, err := DefaultConfig()
+	if err != nil {
+		return config.Config{}, "", err
+	}
+	digest := sha256.New()
+
+	for _, pack := range f.opts.RulePacks {
+		data, err := f.fetcher.fetch(ctx, pack)
+		if err != nil {
+			return config.Config{}, "", err
+		}
+		digest.Write(data)
+		packCfg, err := ParseConfig(data)
+		if err != nil {
+			return config.Config{}, "", fmt.Errorf("rule pack %s: %w", pack.URL, err)
+		}
+		Merge(&cfg, packCfg)
+	}
+
+	Tune(&cfg, f.opts.Generic)
+
+	if len(f.opts.Rules) > 0 {
+		custom, err := RulesConfig(f.opts.Rules)
+		if err != nil {
+			return config.Config{}, "", err
+		}
+		Merge(&cfg, custom)
+	}
+
+	entries := slices.Clone(f.opts.Allowlist)
+	for _, list := range f.opts.AllowlistURLs {
+		data, err := f.fetcher.fetch(ctx, list)
+		if err != nil {
+			return config.Config{}, "", err
+		}
+		digest.Write(data)
+		entries = append(entries, ParseAllowlist(data)...)
+	}
+	if len(entries) > 0 {
+		allowlist, err := AllowlistConfig(entries)
+		if err != nil {
+			return config.Config{}, "", err
+		}
+		Merge(&cfg, allowlist)
+	}
+
+	if len(f.opts.RulePacks) == 0 && len(f.opts.AllowlistURLs) == 0 {
+		return cfg, "", nil
+	}
+	return cfg, hex.EncodeToString(digest.Sum(nil)[:8]), nil
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultConfig config.Config
+	defaultErr    error
+)
+
+// DefaultConfig returns a copy of the built-in gitleaks rules. gitleaks only extends the
+// default config a limited number of times per process, so it is translated once.
+func DefaultConfig() (config.Config, error) {
+	defaultOnce.Do(func() {
+		viperConfig := config.ViperConfig{
+			Extend: config.Extend{
+				UseDefault: true,
+			},
+		}
+		defaultConfig, defaultErr = viperConfig.Translate()
+	})
+	if defaultErr != nil {
+		return config.Config{}, fmt.Errorf(constants.ErrCreateGitleaksConfig, defaultErr)
+	}
+	return clone(defaultConfig), nil
+}
+
+// GitleaksVersion returns the version of the gitleaks module compiled into the binary, which
+// identifies the default rules, or "unknown" when the binary carries no module information.
+func GitleaksVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == gitleaksModule {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// clone copies the rule and keyword collections of cfg so that merging into the copy leaves
+// cfg unchanged.
+func clone(cfg config.Config) config.Config {
+	rules := make(map[string]config.Rule, len(cfg.Rules))
+	for id, rule := range cfg.Rules {
+		rules[id] = rule
+	}
+	keywords := make(map[string]struct{}, len(cfg.Keywords))
+	for keyword := range cfg.Keywords {
+		keywords[keyword] = struct{}{}
+	}
+	cfg.Rules = rules
+	cfg.Keywords = keywords
+	cfg.OrderedRules = append([]string(nil), cfg.OrderedRules...)
+	cfg.Allowlists = append([]*config.Allowlist(nil), cfg.Allowlists...)
+	return cfg
+}
+
+// ParseConfig translates a gitleaks TOML document into a config. Extend directives are
+// ignored: rule packs only contribute their own rules and allowlists.
+func ParseConfig(data []byte) (cfg config.Config, err error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.ReadConfig(strings.NewReader(string(data))); err != nil {
+		return config.Config{}, fmt.Errorf("failed to parse rule pack: %w", err)
+	}
+
+	var viperConfig config.ViperConfig
+	if err := v.Unmarshal(&viperConfig); err != nil {
+		return config.Config{}, fmt.Errorf("failed to decode rule pack: %w", err)
+	}
+	viperConfig.Extend = config.Extend{}
+
+	// gitleaks compiles rule regexes with MustCompile.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid rule pack: %v", r)
+		}
+	}()
+	return viperConfig.Translate()
+}
+
+// Tune applies the generic rule tuning to cfg.
+func Tune(cfg *config.Config, tuning GenericTuning) {
+	for _, ruleID := range GenericRules {
+		rule, ok := cfg.Rules[ruleID]
+		if !ok {
+			continue
+		}
+
+		if tuning.Disabled {
+			delete(cfg.Rules, ruleID)
+			cfg.OrderedRules = slices.DeleteFunc(cfg.OrderedRules, func(id string) bool { return id == ruleID })
+			continue
+		}
+		if tuning.Entropy > 0 {
+			rule.Entropy = tuning.Entropy
+		}
+		if tuning.MinLength > 1 {
+			// Allowlist regexes match the secret unless a different target is set. RE2 caps
+			// repetition counts at 1000, far above the longest generic match.
+			maxLength := min(tuning.MinLength-1, 1000)
+			rule.Allowlists = append(slices.Clip(rule.Allowlists), &config.Allowlist{
+				Description: "secrets shorter than the minimum length",
+				Regexes:     []*regexp.Regexp{regexp.MustCompile(fmt.Sprintf(`(?s)^.{0,%d}$`, maxLength))},
+			})
+		}
+		cfg.Rules[ruleID] = rule
+	}
+}
+
+// Merge adds the rules and allowlists of extension to cfg. Rules with an existing ID replace
+// the existing rule.
+func Merge(cfg *config.Config, extension config.Config) {
+	for _, ruleID := range extension.OrderedRules {
+		rule := extension.Rules[ruleID]
+		if _, exists := cfg.Rules[ruleID]; !exists {
+			cfg.OrderedRules = append(cfg.OrderedRules, ruleID)
+		}
+		cfg.Rules[ruleID] = rule
+		for _, keyword := range rule.Keywords {
+			cfg.Keywords[keyword] = struct{}{}
+		}
+	}
+	cfg.Allowlists = append(cfg.Allowlists, extension.Allowlists...)
+	sort.Strings(cfg.OrderedRules)
+}
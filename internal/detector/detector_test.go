@@ -0,0 +1,234 @@
+package detector
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/detect"
+)
+
+const testPack = `
+[[rules]]
+id = "acme-internal-token"
+description = "ACME internal token"
+regex = '''acme_[a-z0-9]{32}'''
+keywords = ["acme_"]
+`
+
+// newPackServer serves the test pack and its signature, counting pack downloads.
+func newPackServer(t *testing.T, pack string, signature string) (*httptest.Server, *int) {
+	t.Helper()
+	downloads := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pack.toml":
+			downloads++
+			_, _ = w.Write([]byte(pack))
+		case "/pack.toml" + SignatureSuffix:
+			_, _ = w.Write([]byte(signature))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &downloads
+}
+
+func newTestFactory(server *httptest.Server, opts Options) *Factory {
+	factory := NewFactory(opts, zerolog.Nop())
+	factory.fetcher.client = server.Client()
+	return factory
+}
+
+func TestParseRulePack(t *testing.T) {
+	digest := hex.EncodeToString(make([]byte, sha256.Size))
+
+	pack, err := ParseRulePack("https://rules.example.com/pack.toml#sha256=" + digest)
+	require.NoError(t, err)
+	assert.Equal(t, RulePack{URL: "https://rules.example.com/pack.toml", SHA256: digest}, pack)
+
+	for _, invalid := range []string{
+		"http://rules.example.com/pack.toml",
+		"https://rules.example.com/pack.toml#md5=abc",
+		"https://rules.example.com/pack.toml#sha256=abc",
+	} {
+		_, err := ParseRulePack(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestFactory_ExtendsDefaultRules(t *testing.T) {
+	server, _ := newPackServer(t, testPack, "")
+	factory := newTestFactory(server, Options{RulePacks: []RulePack{{URL: server.URL + "/pack.toml"}}})
+
+	detector, err := factory.Detector(context.Background())
+	require.NoError(t, err)
+
+	assert.Contains(t, detector.Config.Rules, "acme-internal-token")
+	assert.Contains(t, detector.Config.Rules, "aws-access-token", "Should keep the default rules")
+
+	findings := detector.DetectString("token = acme_0123456789abcdef0123456789abcdef")
+	require.NotEmpty(t, findings)
+	assert.Equal(t, "acme-internal-token", findings[0].RuleID)
+}
+
+func TestFactory_SHA256Pin(t *testing.T) {
+	server, _ := newPackServer(t, testPack, "")
+	sum := sha256.Sum256([]byte(testPack))
+
+	valid := newTestFactory(server, Options{RulePacks: []RulePack{{URL: server.URL + "/pack.toml", SHA256: hex.EncodeToString(sum[:])}}})
+	_, err := valid.Config(context.Background())
+	require.NoError(t, err)
+
+	wrong := newTestFactory(server, Options{RulePacks: []RulePack{{URL: server.URL + "/pack.toml", SHA256: hex.EncodeToString(make([]byte, sha256.Size))}}})
+	_, err = wrong.Config(context.Background())
+	assert.ErrorContains(t, err, "sha256 pin")
+
+	detector, err := wrong.Detector(context.Background())
+	require.NoError(t, err, "Should fall back to the default rules")
+	assert.NotContains(t, detector.Config.Rules, "acme-internal-token")
+}
+
+func TestFactory_Signature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(testPack)))
+	publicKey := base64.StdEncoding.EncodeToString(public)
+
+	server, _ := newPackServer(t, testPack, signature)
+	factory := newTestFactory(server, Options{RulePacks: []RulePack{{URL: server.URL + "/pack.toml"}}, PublicKey: publicKey})
+	_, err = factory.Config(context.Background())
+	require.NoError(t, err)
+
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	factory = newTestFactory(server, Options{
+		RulePacks: []RulePack{{URL: server.URL + "/pack.toml"}},
+		PublicKey: base64.StdEncoding.EncodeToString(otherPublic),
+	})
+	_, err = factory.Config(context.Background())
+	assert.ErrorContains(t, err, "invalid signature")
+}
+
+func TestFactory_CachesWithTTL(t *testing.T) {
+	server, downloads := newPackServer(t, testPack, "")
+	opts := Options{
+		RulePacks: []RulePack{{URL: server.URL + "/pack.toml"}},
+		CacheDir:  t.TempDir(),
+		TTL:       time.Hour,
+	}
+
+	_, err := newTestFactory(server, opts).Config(context.Background())
+	require.NoError(t, err)
+	_, err = newTestFactory(server, opts).Config(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, *downloads, "Should reuse the cached pack within the TTL")
+
+	server.Close()
+	opts.TTL = time.Nanosecond
+	cfg, err := newTestFactory(server, opts).Config(context.Background())
+	require.NoError(t, err, "Should fall back to the stale cached copy when the download fails")
+	assert.Contains(t, cfg.Rules, "acme-internal-token")
+}
+
+func TestFactory_VersionedDetector(t *testing.T) {
+	_, digest, err := NewFactory(Options{}, zerolog.Nop()).VersionedDetector(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, digest, "Detectors without remote rules have no digest")
+
+	versioned := func(pack string) string {
+		server, _ := newPackServer(t, pack, "")
+		factory := newTestFactory(server, Options{RulePacks: []RulePack{{URL: server.URL + "/pack.toml"}}})
+		_, digest, err := factory.VersionedDetector(context.Background())
+		require.NoError(t, err)
+		return digest
+	}
+	digest = versioned(testPack)
+	assert.NotEmpty(t, digest)
+	assert.Equal(t, digest, versioned(testPack))
+	assert.NotEqual(t, digest, versioned(testPack+"# revised\n"), "The digest should follow the pack's content")
+}
+
+func TestParseConfig_InvalidRegex(t *testing.T) {
+	_, err := ParseConfig([]byte("[[rules]]\nid = \"bad\"\nregex = '''(unclosed'''\n"))
+	assert.Error(t, err)
+}
+
+func TestFactory_CustomRules(t *testing.T) {
+	factory := NewFactory(Options{Rules: []Rule{{
+		ID:       "acme-internal-token",
+		Regex:    `acme_[a-z0-9]{32}`,
+		Keywords: []string{"ACME_"},
+	}}}, zerolog.Nop())
+
+	detector, err := factory.Detector(context.Background())
+	require.NoError(t, err)
+
+	assert.Contains(t, detector.Config.Rules, "aws-access-token", "Should keep the default rules")
+	assert.Contains(t, detector.Config.Keywords, "acme_")
+
+	findings := detector.DetectString("token = acme_0123456789abcdef0123456789abcdef")
+	require.Len(t, findings, 1)
+	assert.Equal(t, "acme-internal-token", findings[0].RuleID)
+}
+
+func TestRulesConfig_Invalid(t *testing.T) {
+	for name, rules := range map[string][]Rule{
+		"invalid regex":   {{ID: "broken", Regex: `acme_[`}},
+		"missing id":      {{Regex: `acme_[a-z]+`}},
+		"missing regex":   {{ID: "empty"}},
+		"secret group":    {{ID: "group", Regex: `acme_[a-z]+`, SecretGroup: 1}},
+		"duplicate rules": {{ID: "dup", Regex: `a+`}, {ID: "dup", Regex: `b+`}},
+	} {
+		_, err := RulesConfig(rules)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestTune(t *testing.T) {
+	const content = `api_key = "Xk9mQ2vR7tLp"`
+
+	base, err := DefaultConfig()
+	require.NoError(t, err)
+	require.Len(t, detect.NewDetector(base).DetectString(content), 1)
+
+	tests := []struct {
+		name     string
+		tuning   GenericTuning
+		findings int
+	}{
+		{"defaults", GenericTuning{}, 1},
+		{"disabled", GenericTuning{Disabled: true}, 0},
+		{"entropy cutoff", GenericTuning{Entropy: 4.5}, 0},
+		{"min length", GenericTuning{MinLength: 16}, 0},
+		{"min length met", GenericTuning{MinLength: 12}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := DefaultConfig()
+			require.NoError(t, err)
+			Tune(&cfg, tt.tuning)
+			assert.Len(t, detect.NewDetector(cfg).DetectString(content), tt.findings)
+		})
+	}
+
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	assert.Len(t, cfg.Rules["generic-api-key"].Allowlists, len(base.Rules["generic-api-key"].Allowlists),
+		"Tuning should not modify the shared default config")
+}
+
+func TestGitleaksVersion(t *testing.T) {
+	assert.Regexp(t, `^v8\.`, GitleaksVersion())
+}
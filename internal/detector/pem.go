@@ -0,0 +1,64 @@
+package detector
+
+import (
+	"encoding/pem"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"golang.org/x/crypto/ssh"
+)
+
+// pemBegin starts every PEM block.
+const pemBegin = "-----BEGIN "
+
+// pemPlaceholder matches the bodies of sample keys in documentation and tests, which are
+// elided, redacted or made up rather than encoded keys.
+var pemPlaceholder = regexp.MustCompile(
+	`(?i)\.\.\.|…|x{8,}|\*{4,}|redacted|example|your[ _-]?(private[ _-]?)?key|<[^>]*>`)
+
+// pemLine matches any line of an embedded PEM block, capturing it without the indentation,
+// quotes, concatenation operators and line continuations around it.
+var pemLine = regexp.MustCompile("^\\s*[\"'`]?(.*?)(?:[\"'`]\\s*[+,]?)?\\s*\\\\?\\s*$")
+
+// ValidatePEM checks the findings whose secret is a PEM block by parsing it. Public keys and
+// certificates, which are not secrets, and sample keys whose body is a placeholder are
+// dropped. Private keys that parse are tagged severity.TagVerified; encrypted keys and keys
+// that cannot be parsed are kept as they are. Other findings are left unchanged.
+func ValidatePEM(findings []report.Finding) []report.Finding {
+	kept := findings[:0]
+	for _, finding := range findings {
+		start := strings.Index(finding.Secret, pemBegin)
+		if start < 0 {
+			kept = append(kept, finding)
+			continue
+		}
+		block, _ := pem.Decode([]byte(unquotePEM(finding.Secret[start:])))
+		switch {
+		case block == nil:
+			if pemPlaceholder.MatchString(finding.Secret[start+len(pemBegin):]) {
+				continue
+			}
+		case !strings.Contains(block.Type, "PRIVATE KEY"):
+			continue
+		default:
+			if _, err := ssh.ParseRawPrivateKey(pem.EncodeToMemory(block)); err == nil {
+				finding.Tags = append(slices.Clone(finding.Tags), severity.TagVerified)
+			}
+		}
+		kept = append(kept, finding)
+	}
+	return kept
+}
+
+// unquotePEM undoes the quoting of PEM blocks embedded in source code and configuration
+// files: escaped newlines, indentation and the quotes and concatenation around each line.
+func unquotePEM(block string) string {
+	lines := strings.Split(block, "\n")
+	for i, line := range lines {
+		lines[i] = pemLine.FindStringSubmatch(line)[1]
+	}
+	return strings.ReplaceAll(strings.Join(lines, "\n"), `\n`, "\n")
+}
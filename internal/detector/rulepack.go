@@ -0,0 +1,198 @@
+package detector
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRulePackSize bounds downloaded rule packs.
+	maxRulePackSize = 10 << 20
+	fetchTimeout    = 30 * time.Second
+	// SignatureSuffix is appended to a rule pack URL to locate its detached signature.
+	SignatureSuffix = ".sig"
+)
+
+// RulePack is a remote gitleaks TOML config, optionally pinned to a SHA-256 digest.
+type RulePack struct {
+	URL    string
+	SHA256 string
+}
+
+// ParseRulePack parses "url" or "url#sha256=<hex>".
+func ParseRulePack(value string) (RulePack, error) {
+	rawURL, fragment, _ := strings.Cut(value, "#")
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return RulePack{}, fmt.Errorf("rule pack %q must be an https URL", value)
+	}
+
+	pack := RulePack{URL: rawURL}
+	if fragment != "" {
+		digest, ok := strings.CutPrefix(fragment, "sha256=")
+		if !ok {
+			return RulePack{}, fmt.Errorf("rule pack %q: unsupported pin %q", value, fragment)
+		}
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return RulePack{}, fmt.Errorf("rule pack %q: invalid sha256 pin", value)
+		}
+		pack.SHA256 = strings.ToLower(digest)
+	}
+	return pack, nil
+}
+
+// fetcher downloads rule packs, caching verified copies on disk.
+type fetcher struct {
+	client    *http.Client
+	cacheDir  string
+	ttl       time.Duration
+	publicKey string
+}
+
+func newFetcher(opts Options) *fetcher {
+	return &fetcher{
+		client:    &http.Client{Timeout: fetchTimeout},
+		cacheDir:  opts.CacheDir,
+		ttl:       opts.TTL,
+		publicKey: opts.PublicKey,
+	}
+}
+
+// fetch returns the verified content of pack, from the cache while it is fresh. When the
+// download fails a stale cached copy is used.
+func (f *fetcher) fetch(ctx context.Context, pack RulePack) ([]byte, error) {
+	cached, modTime, cacheErr := f.readCache(pack)
+	if cacheErr == nil && time.Since(modTime) < f.ttl {
+		return cached, nil
+	}
+
+	data, err := f.download(ctx, pack)
+	if err != nil {
+		if cacheErr == nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	f.writeCache(pack, data)
+	return data, nil
+}
+
+func (f *fetcher) download(ctx context.Context, pack RulePack) ([]byte, error) {
+	data, err := f.get(ctx, pack.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.verify(ctx, pack, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// verify checks the SHA-256 pin and, when a public key is configured, the detached signature.
+func (f *fetcher) verify(ctx context.Context, pack RulePack, data []byte) error {
+	if pack.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != pack.SHA256 {
+			return fmt.Errorf("rule pack %s does not match its sha256 pin", pack.URL)
+		}
+	}
+
+	if f.publicKey == "" {
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(f.publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("rule pack public key must be a base64 ed25519 public key")
+	}
+	encoded, err := f.get(ctx, pack.URL+SignatureSuffix)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || !ed25519.Verify(key, data, signature) {
+		return fmt.Errorf("rule pack %s has an invalid signature", pack.URL)
+	}
+	return nil
+}
+
+func (f *fetcher) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", rawURL, err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %d", rawURL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRulePackSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	if len(data) > maxRulePackSize {
+		return nil, fmt.Errorf("rule pack %s exceeds %d bytes", rawURL, maxRulePackSize)
+	}
+	return data, nil
+}
+
+// cachePath returns the cache file for pack; the pin is part of the key so changing it
+// never serves content verified against an older pin.
+func (f *fetcher) cachePath(pack RulePack) string {
+	sum := sha256.Sum256([]byte(pack.URL + "#" + pack.SHA256 + "#" + f.publicKey))
+	return filepath.Join(f.cacheDir, hex.EncodeToString(sum[:16])+".toml")
+}
+
+func (f *fetcher) readCache(pack RulePack) ([]byte, time.Time, error) {
+	if f.cacheDir == "" {
+		return nil, time.Time{}, os.ErrNotExist
+	}
+	path := f.cachePath(pack)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path is derived from a hash inside the cache directory
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return data, info.ModTime(), nil
+}
+
+func (f *fetcher) writeCache(pack RulePack, data []byte) {
+	if f.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(f.cacheDir, 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(f.cacheDir, "pack-*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), f.cachePath(pack))
+}
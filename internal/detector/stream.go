@@ -0,0 +1,75 @@
+package detector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// ErrContentTooLarge is returned for contents over the scan size limit.
+var ErrContentTooLarge = errors.New("content exceeds the scan size limit")
+
+// chunkBytes and chunkOverlap size the chunks of DetectReader; tests shrink them.
+var (
+	chunkBytes   = constants.DetectChunkBytes
+	chunkOverlap = constants.DetectChunkOverlap
+)
+
+// DetectReader scans r with d in chunks of constants.DetectChunkBytes, so memory stays bounded
+// however large the content is. Each chunk is scanned together with the last
+// constants.DetectChunkOverlap bytes before it, from the start of a line where possible, so
+// secrets spanning a chunk boundary are found; findings in the overlap are reported once. Line
+// numbers are those of the whole content. Findings are checked with Check. At most maxBytes
+// are read: for longer contents it returns the findings of the first maxBytes with
+// ErrContentTooLarge.
+func DetectReader(d *detect.Detector, r io.Reader, maxBytes int64) ([]report.Finding, error) {
+	limited := &io.LimitedReader{R: r, N: maxBytes}
+	chunk := make([]byte, chunkBytes)
+	seen := make(map[string]bool)
+	var (
+		findings []report.Finding
+		carry    []byte
+		lines    int // lines before the start of carry
+	)
+	for {
+		n, err := io.ReadFull(limited, chunk)
+		if n > 0 {
+			window := append(carry, chunk[:n]...)
+			for _, finding := range d.DetectBytes(window) {
+				finding.StartLine += lines
+				finding.EndLine += lines
+				key := fmt.Sprintf("%s\x00%s\x00%d", finding.RuleID, finding.Secret, finding.StartLine)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				findings = append(findings, finding)
+			}
+
+			start := max(0, len(window)-chunkOverlap)
+			if i := bytes.IndexByte(window[start:], '\n'); i >= 0 && start > 0 {
+				start += i + 1
+			}
+			lines += bytes.Count(window[:start], []byte("\n"))
+			carry = bytes.Clone(window[start:])
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return Check(d, findings), err
+		}
+	}
+
+	if limited.N == 0 {
+		if n, _ := r.Read(make([]byte, 1)); n > 0 {
+			return Check(d, findings), ErrContentTooLarge
+		}
+	}
+	return Check(d, findings), nil
+}
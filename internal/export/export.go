@@ -0,0 +1,145 @@
+// Package export serves tracked findings as CSV, JSON or SARIF, filtered by organization,
+// repository, rule, state and detection date, so security teams can pull them into
+// spreadsheets and GRC tooling.
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/output"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// contentTypes are the response content types of each format.
+var contentTypes = map[string]string{
+	output.FormatCSV:   "text/csv; charset=utf-8",
+	output.FormatJSON:  "application/json",
+	output.FormatSARIF: "application/sarif+json",
+}
+
+// Filter selects tracked findings. Zero fields match every finding.
+type Filter struct {
+	// Org is the owner of the repositories.
+	Org string
+	// Repository is the full name of a repository.
+	Repository string
+	Rule       string
+	State      store.State
+	// Since and Until bound the time findings were first detected: from Since, inclusive, to
+	// Until, exclusive.
+	Since time.Time
+	Until time.Time
+}
+
+// ParseFilter reads a filter from the org, repo, rule, state, since and until query
+// parameters. Dates are RFC 3339 times or YYYY-MM-DD days, UTC.
+func ParseFilter(query url.Values) (Filter, error) {
+	filter := Filter{
+		Org:        query.Get("org"),
+		Repository: query.Get("repo"),
+		Rule:       query.Get("rule"),
+		State:      store.State(strings.ToLower(query.Get("state"))),
+	}
+	if filter.State != "" &&
+		!slices.Contains([]store.State{store.StateOpen, store.StateResolved, store.StateSuppressed}, filter.State) {
+		return Filter{}, fmt.Errorf("unknown finding state %q", filter.State)
+	}
+	if filter.Repository != "" && !strings.Contains(filter.Repository, "/") {
+		return Filter{}, fmt.Errorf("repo %q must be a full name, owner/name", filter.Repository)
+	}
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid %s %q: expected an RFC 3339 time or YYYY-MM-DD", name, value)
+		}
+		*target = t
+	}
+	return filter, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// Match reports whether a finding is selected. Organizations and repositories match
+// regardless of case, as GitHub names do.
+func (f Filter) Match(finding store.Finding) bool {
+	owner, _, _ := strings.Cut(finding.Repository, "/")
+	switch {
+	case f.Org != "" && !strings.EqualFold(owner, f.Org),
+		f.Repository != "" && !strings.EqualFold(finding.Repository, f.Repository),
+		f.Rule != "" && finding.RuleID != f.Rule,
+		f.State != "" && finding.State != f.State,
+		!f.Since.IsZero() && finding.FirstSeen.Before(f.Since),
+		!f.Until.IsZero() && !finding.FirstSeen.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Load returns the tracked findings filter selects, ordered by repository, file and rule.
+func Load(ctx context.Context, findings store.FindingStore, filter Filter) ([]store.Finding, error) {
+	all, err := findings.AllFindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]store.Finding, 0, len(all))
+	for _, finding := range all {
+		if filter.Match(finding) {
+			selected = append(selected, finding)
+		}
+	}
+	return selected, nil
+}
+
+// Handler serves the tracked findings selected by the request's filter in the format of its
+// format query parameter, csv, json or sarif, as a download. version is reported as the
+// SARIF tool version.
+func Handler(
+	findings store.FindingStore, version string, classifier *severity.Classifier, logger zerolog.Logger,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = output.FormatJSON
+		}
+		contentType, ok := contentTypes[format]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown format %q, expected one of %v", format, output.TrackedFormats),
+				http.StatusBadRequest)
+			return
+		}
+		filter, err := ParseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		selected, err := Load(r.Context(), findings, filter)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to load tracked findings")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="gitguard-findings.%s"`, format))
+		if err := output.WriteTracked(w, format, version, selected, classifier); err != nil {
+			logger.Error().Err(err).Msg("Failed to write findings export")
+		}
+	})
+}
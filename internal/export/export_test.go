@@ -0,0 +1,115 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/output"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(url.Values{
+		"org":   {"acme"},
+		"repo":  {"acme/api"},
+		"rule":  {"aws-access-token"},
+		"state": {"Open"},
+		"since": {"2026-01-01"},
+		"until": {"2026-02-01T12:00:00Z"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Filter{
+		Org:        "acme",
+		Repository: "acme/api",
+		Rule:       "aws-access-token",
+		State:      store.StateOpen,
+		Since:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:      time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC),
+	}, filter)
+
+	for _, query := range []url.Values{
+		{"state": {"fixed"}},
+		{"repo": {"api"}},
+		{"since": {"yesterday"}},
+		{"until": {"01/02/2026"}},
+	} {
+		_, err := ParseFilter(query)
+		assert.Error(t, err, query.Encode())
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	finding := store.Finding{
+		Repository: "Acme/API",
+		RuleID:     "aws-access-token",
+		State:      store.StateOpen,
+		FirstSeen:  time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, Filter{}.Match(finding))
+	assert.True(t, Filter{Org: "acme", Repository: "acme/api", Since: jan, Until: feb}.Match(finding))
+	assert.True(t, Filter{Since: finding.FirstSeen}.Match(finding), "Since is inclusive")
+	assert.False(t, Filter{Until: finding.FirstSeen}.Match(finding), "Until is exclusive")
+	assert.False(t, Filter{Org: "other"}.Match(finding))
+	assert.False(t, Filter{Repository: "acme/web"}.Match(finding))
+	assert.False(t, Filter{Rule: "private-key"}.Match(finding))
+	assert.False(t, Filter{State: store.StateResolved}.Match(finding))
+	assert.False(t, Filter{Since: feb}.Match(finding))
+}
+
+func TestHandler(t *testing.T) {
+	findings := store.NewMemory()
+	for _, scan := range []store.Scan{
+		{Repository: "acme/api", Findings: []store.Finding{
+			{Fingerprint: "gitguard-a", File: ".env", RuleID: "aws-access-token"},
+			{Fingerprint: "gitguard-b", File: "main.tf", RuleID: "private-key"},
+		}},
+		{Repository: "other/web", Findings: []store.Finding{
+			{Fingerprint: "gitguard-c", File: "app.js", RuleID: "private-key"},
+		}},
+	} {
+		scan.Complete, scan.Time = true, time.Now()
+		_, err := findings.RecordScan(context.Background(), scan)
+		require.NoError(t, err)
+	}
+	handler := Handler(findings, "1.0.0", nil, zerolog.Nop())
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/findings/export?"+query, nil))
+		return rec
+	}
+
+	rec := get("org=acme")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="gitguard-findings.json"`, rec.Header().Get("Content-Disposition"))
+	var tracked []output.TrackedFinding
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&tracked))
+	assert.Len(t, tracked, 2)
+
+	rec = get("format=csv&rule=private-key")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "acme/api,main.tf")
+	assert.Contains(t, rec.Body.String(), "other/web,app.js")
+	assert.NotContains(t, rec.Body.String(), ".env")
+
+	rec = get("format=sarif&repo=other/web")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/sarif+json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "app.js")
+
+	assert.Equal(t, http.StatusBadRequest, get("format=xml").Code)
+	assert.Equal(t, http.StatusBadRequest, get("state=fixed").Code)
+}
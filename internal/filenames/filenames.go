@@ -0,0 +1,93 @@
+// Package filenames reports files whose name alone makes committing them a policy
+// violation, such as .env files, SSH private keys and kubeconfigs. They are reported
+// regardless of their content, so they are caught even when empty or when no rule matches.
+package filenames
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/pathrules"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// RuleID identifies findings of sensitive file names.
+const RuleID = "sensitive-filename"
+
+// Tag is set on every file name finding.
+const Tag = "filename"
+
+// DefaultPatterns are the file names reported when no patterns are configured.
+var DefaultPatterns = []string{
+	".env", ".env.*",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore",
+	"kubeconfig", "**/.kube/config",
+	".netrc", ".pgpass", ".htpasswd", ".git-credentials",
+}
+
+// templateSuffixes mark files documenting the expected variables, e.g. .env.example, which
+// are meant to be committed.
+var templateSuffixes = []string{".example", ".sample", ".template", ".dist"}
+
+// Rules match file names against globs, matched like path override globs: a glob without a
+// slash matches file names in any directory. A nil Rules reports nothing.
+type Rules struct {
+	patterns     []string
+	repositories *reposcope.Setting
+}
+
+// New validates the globs and returns Rules for the repositories that repositories is
+// non-zero for. Empty patterns select DefaultPatterns.
+func New(patterns []string, repositories *reposcope.Setting) (*Rules, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns
+	}
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid file name glob %q: %w", pattern, err)
+			}
+		}
+	}
+	return &Rules{patterns: patterns, repositories: repositories}, nil
+}
+
+// For returns the rules applying to a repository, "owner/name", or nil where they are
+// disabled.
+func (r *Rules) For(repository string) *Rules {
+	if r == nil || r.repositories.For(repository) == 0 {
+		return nil
+	}
+	return r
+}
+
+// Detect returns a finding for the file name when it matches a pattern, unless it is a
+// template such as .env.example. The finding covers the whole file, from its first line, and
+// has no secret.
+func (r *Rules) Detect(name string) []report.Finding {
+	if r == nil {
+		return nil
+	}
+	for _, suffix := range templateSuffixes {
+		if strings.HasSuffix(strings.ToLower(name), suffix) {
+			return nil
+		}
+	}
+	for _, pattern := range r.patterns {
+		if pathrules.Match(pattern, name) {
+			return []report.Finding{{
+				RuleID:      RuleID,
+				Description: "Sensitive file committed to the repository",
+				File:        name,
+				StartLine:   1,
+				EndLine:     1,
+				Match:       path.Base(name),
+				Tags:        []string{Tag},
+			}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package filenames
+
+import (
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	rules, err := New(nil, nil)
+	require.NoError(t, err)
+
+	for _, name := range []string{".env", "app/.env.production", "home/.ssh/id_rsa", "certs/server.pem", "kubeconfig",
+		"deploy/.kube/config"} {
+		findings := rules.Detect(name)
+		require.Len(t, findings, 1, name)
+		assert.Equal(t, RuleID, findings[0].RuleID)
+		assert.Equal(t, name, findings[0].File)
+		assert.Equal(t, 1, findings[0].StartLine)
+		assert.Empty(t, findings[0].Secret)
+	}
+	for _, name := range []string{"app/.env.example", "id_rsa.pub", "config", "main.go", "env.go"} {
+		assert.Empty(t, rules.Detect(name), name)
+	}
+
+	var disabled *Rules
+	assert.Empty(t, disabled.Detect(".env"))
+}
+
+func TestNewPatterns(t *testing.T) {
+	rules, err := New([]string{"secrets/**", "*.tfstate"}, nil)
+	require.NoError(t, err)
+	assert.Len(t, rules.Detect("infra/terraform.tfstate"), 1)
+	assert.Len(t, rules.Detect("secrets/prod/db.txt"), 1)
+	assert.Empty(t, rules.Detect(".env"), "Configured patterns replace the defaults")
+
+	_, err = New([]string{"[invalid"}, nil)
+	assert.Error(t, err)
+}
+
+func TestFor(t *testing.T) {
+	setting, err := reposcope.NewSetting(1, []reposcope.SettingOverride{{Repositories: []string{"acme/fixtures"}}})
+	require.NoError(t, err)
+	rules, err := New(nil, setting)
+	require.NoError(t, err)
+
+	assert.Same(t, rules, rules.For("acme/app"))
+	assert.Nil(t, rules.For("acme/fixtures"))
+	assert.Nil(t, (*Rules)(nil).For("acme/app"))
+}
@@ -0,0 +1,344 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Object is a value of an object type: the resolvers of its fields by name.
+type Object struct {
+	Type   string
+	Fields map[string]Resolver
+}
+
+// Resolver resolves a field from its arguments. It returns nil, a string, bool, int,
+// float64 or time.Time, an *Object, or a []*Object or []any of those.
+type Resolver func(ctx context.Context, args Args) (any, error)
+
+// Args are the arguments of a field, with variables substituted. Values are nil, bool, int,
+// float64, string, []any or map[string]any; enum literals are strings.
+type Args map[string]any
+
+// String returns the string argument name, or "" when it is absent or null.
+func (a Args) String(name string) (string, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("argument %s must be a string", name)
+	}
+}
+
+// Int returns the integer argument name, or fallback when it is absent or null.
+func (a Args) Int(name string, fallback int) (int, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return fallback, nil
+	case int:
+		return v, nil
+	case float64:
+		// Variables decoded from JSON are floats.
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %s must be an integer", name)
+}
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is nil when the request could not be executed.
+type Response struct {
+	Data   any     `json:"data,omitempty"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Error is an error of a request, located by the response path of the field that failed.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Execute runs the query of req against root, the query type. Fields that fail are null and
+// reported in the errors of the response, which still holds the other fields.
+func Execute(ctx context.Context, root *Object, req Request) Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{doc: doc, variables: make(map[string]any), declared: make(map[string]bool)}
+	for _, v := range op.variables {
+		e.declared[v.name] = true
+		if value, ok := req.Variables[v.name]; ok {
+			e.variables[v.name] = value
+		} else if v.defaultValue != nil {
+			e.variables[v.name], _ = e.resolve(v.defaultValue)
+		}
+	}
+	data := e.object(ctx, root, op.selection, nil)
+	return Response{Data: data, Errors: e.errors}
+}
+
+// operation returns the operation of the document to run.
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("the document has several queries: an operation name is required")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %s", name)
+}
+
+type executor struct {
+	doc       *document
+	variables map[string]any
+	declared  map[string]bool
+	errors    []Error
+}
+
+func (e *executor) fail(path []any, format string, args ...any) {
+	e.errors = append(e.errors, Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+// object resolves a selection of obj's fields.
+func (e *executor) object(ctx context.Context, obj *Object, selections []selection, path []any) fields {
+	var groups []*fieldGroup
+	e.collect(obj.Type, selections, &groups, make(map[string]bool), path)
+
+	result := make(fields, 0, len(groups))
+	for _, group := range groups {
+		fieldPath := append(append([]any(nil), path...), group.key)
+		result = append(result, entry{key: group.key, value: e.field(ctx, obj, group, fieldPath)})
+	}
+	return result
+}
+
+// fieldGroup is the fields of a selection reported under the same key, whose selections are
+// merged.
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+// collect flattens fragments out of selections into groups of fields by response key,
+// leaving out the fields skipped by directives.
+func (e *executor) collect(
+	typeName string, selections []selection, groups *[]*fieldGroup, visited map[string]bool, path []any,
+) {
+	for _, sel := range selections {
+		if !e.included(sel.directives, path) {
+			continue
+		}
+		switch {
+		case sel.field != nil:
+			key := sel.field.responseKey()
+			var group *fieldGroup
+			for _, g := range *groups {
+				if g.key == key {
+					group = g
+				}
+			}
+			if group == nil {
+				group = &fieldGroup{key: key}
+				*groups = append(*groups, group)
+			}
+			group.fields = append(group.fields, sel.field)
+		case sel.spread != "":
+			frag, ok := e.doc.fragments[sel.spread]
+			if !ok {
+				e.fail(path, "unknown fragment %s", sel.spread)
+				continue
+			}
+			if visited[sel.spread] || frag.typeCondition != typeName {
+				continue
+			}
+			visited[sel.spread] = true
+			e.collect(typeName, frag.selection, groups, visited, path)
+		default:
+			if sel.typeCondition == "" || sel.typeCondition == typeName {
+				e.collect(typeName, sel.inline, groups, visited, path)
+			}
+		}
+	}
+}
+
+// included evaluates the @include and @skip directives of a selection.
+func (e *executor) included(directives []directive, path []any) bool {
+	for _, d := range directives {
+		if d.name != "include" && d.name != "skip" {
+			e.fail(path, "unknown directive @%s", d.name)
+			return false
+		}
+		value, err := e.resolve(d.arguments["if"])
+		condition, ok := value.(bool)
+		if err != nil || !ok {
+			e.fail(path, "directive @%s requires a boolean if argument", d.name)
+			return false
+		}
+		if condition == (d.name == "skip") {
+			return false
+		}
+	}
+	return true
+}
+
+// field resolves a group of fields of obj and completes its value.
+func (e *executor) field(ctx context.Context, obj *Object, group *fieldGroup, path []any) any {
+	f := group.fields[0]
+	if f.name == "__typename" {
+		return obj.Type
+	}
+	resolver, ok := obj.Fields[f.name]
+	if !ok {
+		e.fail(path, "cannot query field %s on type %s", f.name, obj.Type)
+		return nil
+	}
+	args := make(Args, len(f.arguments))
+	for name, literal := range f.arguments {
+		value, err := e.resolve(literal)
+		if err != nil {
+			e.fail(path, "%s", err)
+			return nil
+		}
+		args[name] = value
+	}
+	value, err := resolver(ctx, args)
+	if err != nil {
+		e.fail(path, "%s", err)
+		return nil
+	}
+
+	var selections []selection
+	for _, f := range group.fields {
+		selections = append(selections, f.selection...)
+	}
+	return e.complete(ctx, f.name, value, selections, path)
+}
+
+// complete resolves the selection of an object value, or checks that a scalar has none.
+func (e *executor) complete(ctx context.Context, name string, value any, selections []selection, path []any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *Object:
+		if v == nil {
+			return nil
+		}
+		if len(selections) == 0 {
+			e.fail(path, "field %s of type %s must have a selection of subfields", name, v.Type)
+			return nil
+		}
+		return e.object(ctx, v, selections, path)
+	case []*Object:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = e.complete(ctx, name, item, selections, append(append([]any(nil), path...), i))
+		}
+		return list
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = e.complete(ctx, name, item, selections, append(append([]any(nil), path...), i))
+		}
+		return list
+	}
+	if len(selections) > 0 {
+		e.fail(path, "field %s is a scalar and cannot have a selection of subfields", name)
+		return nil
+	}
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// resolve substitutes variables in a literal.
+func (e *executor) resolve(literal value) (any, error) {
+	switch v := literal.(type) {
+	case variableRef:
+		if !e.declared[string(v)] {
+			return nil, fmt.Errorf("variable $%s is not defined", v)
+		}
+		return e.variables[string(v)], nil
+	case enumValue:
+		return string(v), nil
+	case []value:
+		list := make([]any, len(v))
+		for i, item := range v {
+			value, err := e.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	case map[string]value:
+		object := make(map[string]any, len(v))
+		for name, item := range v {
+			value, err := e.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = value
+		}
+		return object, nil
+	}
+	return literal, nil
+}
+
+// fields is the result of a selection set, encoded as a JSON object in selection order.
+type fields []entry
+
+type entry struct {
+	key   string
+	value any
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f fields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range f {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(e.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,210 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// execute runs query against schema and returns the response as JSON.
+func execute(t *testing.T, schema *Schema, query string, variables map[string]any) string {
+	t.Helper()
+	resp := Execute(context.Background(), schema.Query(), Request{Query: query, Variables: variables})
+	out, err := json.Marshal(resp)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	findings := store.NewMemory()
+	seen := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	for repository, files := range map[string][]string{
+		"acme/api": {".env", "config.yml", "main.go"},
+		"acme/web": {"app.js"},
+	} {
+		scan := store.Scan{Repository: repository, Complete: true, Time: seen}
+		for i, file := range files {
+			scan.Findings = append(scan.Findings, store.Finding{
+				Fingerprint: repository + ":" + file, File: file, RuleID: "aws-access-token", Line: i + 1,
+			})
+		}
+		_, err := findings.RecordScan(context.Background(), scan)
+		require.NoError(t, err)
+	}
+	_, err := findings.SetState(context.Background(), "acme/api", "acme/api:main.go", store.StateSuppressed)
+	require.NoError(t, err)
+
+	scans := jobs.NewRegistry(0)
+	done := scans.Create(jobs.Job{Repository: "acme/api", Commit: "abc123"})
+	scans.Finish(done.ID, "", 2, nil)
+	failed := scans.Create(jobs.Job{Repository: "acme/web", Ref: "refs/heads/main"})
+	scans.Finish(failed.ID, "", 0, errors.New("clone failed"))
+	return &Schema{Findings: findings, Scans: scans}
+}
+
+func TestExecute_Findings(t *testing.T) {
+	schema := testSchema(t)
+
+	out := execute(t, schema, `query Open($repo: String, $first: Int = 1) {
+		findings(repository: $repo, state: OPEN, first: $first) {
+			totalCount
+			nodes { file line ruleId state firstSeen resolvedAt }
+			pageInfo { endCursor hasNextPage }
+		}
+	}`, map[string]any{"repo": "acme/api"})
+	assert.JSONEq(t, `{"data": {"findings": {
+		"totalCount": 2,
+		"nodes": [{"file": ".env", "line": 1, "ruleId": "aws-access-token", "state": "open",
+			"firstSeen": "2026-03-16T00:00:00Z", "resolvedAt": null}],
+		"pageInfo": {"endCursor": "`+encodeCursor(0)+`", "hasNextPage": true}
+	}}}`, out)
+
+	out = execute(t, schema, `{ findings(repository: "acme/api", state: "open", after: "`+encodeCursor(0)+`") {
+		nodes { file } pageInfo { hasNextPage }
+	} }`, nil)
+	assert.JSONEq(t, `{"data": {"findings": {"nodes": [{"file": "config.yml"}], "pageInfo": {"hasNextPage": false}}}}`, out)
+
+	out = execute(t, schema, `{ finding(repository: "acme/api", fingerprint: "acme/api:main.go") { state } }`, nil)
+	assert.JSONEq(t, `{"data": {"finding": {"state": "suppressed"}}}`, out)
+}
+
+func TestExecute_Repositories(t *testing.T) {
+	out := execute(t, testSchema(t), `{
+		repositories { totalCount nodes { ...counts } }
+		web: repository(name: "acme/web") {
+			__typename
+			name
+			scans { nodes { status error findings } }
+		}
+	}
+	fragment counts on Repository { name openFindings suppressedFindings }`, nil)
+	assert.JSONEq(t, `{"data": {
+		"repositories": {"totalCount": 2, "nodes": [
+			{"name": "acme/api", "openFindings": 2, "suppressedFindings": 1},
+			{"name": "acme/web", "openFindings": 1, "suppressedFindings": 0}
+		]},
+		"web": {"__typename": "Repository", "name": "acme/web",
+			"scans": {"nodes": [{"status": "failed", "error": "clone failed", "findings": 0}]}}
+	}}`, out)
+}
+
+func TestExecute_Scans(t *testing.T) {
+	out := execute(t, testSchema(t), `query($skip: Boolean!) {
+		scans(status: "completed") { nodes { repository commit findings ref @skip(if: $skip) } }
+		... on Query { all: scans { totalCount } }
+	}`, map[string]any{"skip": true})
+	assert.JSONEq(t, `{"data": {
+		"scans": {"nodes": [{"repository": "acme/api", "commit": "abc123", "findings": 2}]},
+		"all": {"totalCount": 2}
+	}}`, out)
+}
+
+func TestExecute_Errors(t *testing.T) {
+	schema := testSchema(t)
+
+	out := execute(t, schema, `{ findings(state: "fixed") { totalCount } scans { totalCount } }`, nil)
+	assert.JSONEq(t, `{
+		"data": {"findings": null, "scans": {"totalCount": 2}},
+		"errors": [{"message": "unknown finding state fixed", "path": ["findings"]}]
+	}`, out, "A failed field should not fail the others")
+
+	for query, message := range map[string]string{
+		`{ findings { nodes { secret } } }`:             "cannot query field secret on type Finding",
+		`{ findings }`:                                  "must have a selection of subfields",
+		`{ findings { totalCount { value } } }`:         "is a scalar",
+		`{ findings(first: 1000) { totalCount } }`:      "first must be between",
+		`{ findings(after: "bogus") { totalCount } }`:   "invalid cursor",
+		`{ findings(file: $undefined) { totalCount } }`: "variable $undefined is not defined",
+		`{ scan { id } }`:                               "argument id is required",
+		`{ scans { totalCount @defer } }`:               "unknown directive @defer",
+		`{ findings { ...missing } }`:                   "unknown fragment missing",
+	} {
+		assert.Contains(t, execute(t, schema, query, nil), message, query)
+	}
+
+	for query, message := range map[string]string{
+		`mutation { delete }`:     "only queries are supported",
+		`{ findings( }`:           "syntax error",
+		`{ findings { } }`:        "empty selection set",
+		`{ a } { b }`:             "operation name is required",
+		`{ a(x: "unterminated) }`: "unterminated string",
+	} {
+		resp := Execute(context.Background(), schema.Query(), Request{Query: query})
+		assert.Nil(t, resp.Data, query)
+		require.Len(t, resp.Errors, 1, query)
+		assert.Contains(t, resp.Errors[0].Message, message, query)
+	}
+
+	out = execute(t, &Schema{}, `{ findings { totalCount } scans { totalCount } }`, nil)
+	assert.JSONEq(t, `{
+		"data": {"findings": null, "scans": {"totalCount": 0}},
+		"errors": [{"message": "finding tracking is disabled", "path": ["findings"]}]
+	}`, out)
+}
+
+func TestParse_Values(t *testing.T) {
+	doc, err := parse(`
+		# comment
+		query Q($a: [String!]! = ["x"]) {
+			f(int: -12, float: 1.5e3, str: "a\"bé\n", block: """raw "quotes" """, list: [1, 2],
+				object: {key: VALUE, nested: {on: true}}, null: null)
+		}`)
+	require.NoError(t, err)
+	op := doc.operations[0]
+	assert.Equal(t, "Q", op.name)
+	assert.Equal(t, []value{"x"}, op.variables[0].defaultValue)
+	args := op.selection[0].field.arguments
+	assert.Equal(t, -12, args["int"])
+	assert.Equal(t, 1500.0, args["float"])
+	assert.Equal(t, "a\"bé\n", args["str"])
+	assert.Equal(t, `raw "quotes" `, args["block"])
+	assert.Equal(t, []value{1, 2}, args["list"])
+	assert.Equal(t, map[string]value{"key": enumValue("VALUE"), "nested": map[string]value{"on": true}}, args["object"])
+	assert.Nil(t, args["null"])
+}
+
+func TestHandler(t *testing.T) {
+	handler := Handler(testSchema(t), zerolog.Nop())
+
+	body := `{"query": "query($r: String) { findings(repository: $r) { totalCount } }", "variables": {"r": "acme/web"}}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"data": {"findings": {"totalCount": 1}}}`, rec.Body.String())
+
+	query := url.Values{"query": {"{ scans(first: $n) { totalCount } }"}, "variables": {`{"n": 1}`}}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/graphql?"+query.Encode(), nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "Undefined variables are field errors")
+	assert.Contains(t, rec.Body.String(), "not defined")
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader("not json")),
+		httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(`{"query": ""}`)),
+		httptest.NewRequest(http.MethodGet, "/api/graphql?query=%7B", nil),
+		httptest.NewRequest(http.MethodGet, "/api/graphql?query=%7Ba%7D&variables=nope", nil),
+	} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, req.URL.String())
+		assert.Contains(t, rec.Body.String(), `"errors"`)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/graphql", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
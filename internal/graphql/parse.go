@@ -0,0 +1,537 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed GraphQL executable document.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a query of a document.
+type operation struct {
+	name      string
+	variables []variable
+	selection []selection
+}
+
+// variable is a variable definition of an operation. Its type is not checked: resolvers
+// validate the values they receive.
+type variable struct {
+	name         string
+	defaultValue value
+}
+
+// fragment is a named fragment definition.
+type fragment struct {
+	typeCondition string
+	selection     []selection
+}
+
+// selection is a field, a fragment spread or an inline fragment.
+type selection struct {
+	// field is set for fields.
+	field *field
+	// spread is the name of a spread fragment.
+	spread string
+	// inline is the selection of an inline fragment, whose type is typeCondition.
+	inline        []selection
+	typeCondition string
+	directives    []directive
+}
+
+// field is a field selection.
+type field struct {
+	alias     string
+	name      string
+	arguments map[string]value
+	selection []selection
+}
+
+// responseKey returns the key the field is reported under.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// directive is an @include or @skip directive.
+type directive struct {
+	name      string
+	arguments map[string]value
+}
+
+// value is an input value literal: nil, bool, int, float64, string, enumValue, variableRef,
+// []value or map[string]value.
+type value any
+
+// enumValue is an enum literal.
+type enumValue string
+
+// variableRef is a reference to a variable.
+type variableRef string
+
+// parseError reports a syntax error at a position of the source.
+type parseError struct {
+	pos     int
+	message string
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d: %s", e.pos, e.message)
+}
+
+// parse parses a GraphQL executable document.
+func parse(source string) (doc *document, err error) {
+	p := &parser{lexer: lexer{source: source}}
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(*parseError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, perr
+		}
+	}()
+	p.next()
+	return p.parseDocument(), nil
+}
+
+type parser struct {
+	lexer
+	tok token
+}
+
+func (p *parser) fail(format string, args ...any) {
+	panic(&parseError{pos: p.tok.pos, message: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) next() {
+	tok, err := p.lex()
+	if err != nil {
+		panic(err)
+	}
+	p.tok = tok
+}
+
+// peek reports whether the current token is the punctuator or name text.
+func (p *parser) peek(kind tokenKind, text string) bool {
+	return p.tok.kind == kind && p.tok.text == text
+}
+
+// skip consumes the current token if it is the punctuator or name text.
+func (p *parser) skip(kind tokenKind, text string) bool {
+	if p.peek(kind, text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, text string) {
+	if !p.skip(kind, text) {
+		p.fail("expected %q, found %s", text, p.tok)
+	}
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokenName {
+		p.fail("expected a name, found %s", p.tok)
+	}
+	name := p.tok.text
+	p.next()
+	return name
+}
+
+func (p *parser) parseDocument() *document {
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunct, "{"):
+			doc.operations = append(doc.operations, &operation{selection: p.parseSelectionSet()})
+		case p.peek(tokenName, "query"):
+			p.next()
+			doc.operations = append(doc.operations, p.parseOperation())
+		case p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			p.fail("only queries are supported")
+		case p.peek(tokenName, "fragment"):
+			p.next()
+			name := p.name()
+			if name == "on" {
+				p.fail("a fragment cannot be named on")
+			}
+			if _, ok := doc.fragments[name]; ok {
+				p.fail("fragment %s is defined twice", name)
+			}
+			p.expect(tokenName, "on")
+			frag := &fragment{typeCondition: p.name()}
+			p.parseDirectives()
+			frag.selection = p.parseSelectionSet()
+			doc.fragments[name] = frag
+		default:
+			p.fail("expected a query or fragment, found %s", p.tok)
+		}
+	}
+	if len(doc.operations) == 0 {
+		p.fail("the document has no query")
+	}
+	return doc
+}
+
+func (p *parser) parseOperation() *operation {
+	op := &operation{}
+	if p.tok.kind == tokenName {
+		op.name = p.name()
+	}
+	if p.skip(tokenPunct, "(") {
+		for !p.skip(tokenPunct, ")") {
+			p.expect(tokenPunct, "$")
+			v := variable{name: p.name()}
+			p.expect(tokenPunct, ":")
+			p.parseType()
+			if p.skip(tokenPunct, "=") {
+				v.defaultValue = p.parseValue(true)
+			}
+			op.variables = append(op.variables, v)
+		}
+	}
+	p.parseDirectives()
+	op.selection = p.parseSelectionSet()
+	return op
+}
+
+// parseType skips a variable type.
+func (p *parser) parseType() {
+	if p.skip(tokenPunct, "[") {
+		p.parseType()
+		p.expect(tokenPunct, "]")
+	} else {
+		p.name()
+	}
+	p.skip(tokenPunct, "!")
+}
+
+func (p *parser) parseSelectionSet() []selection {
+	p.expect(tokenPunct, "{")
+	var selections []selection
+	for !p.skip(tokenPunct, "}") {
+		selections = append(selections, p.parseSelection())
+	}
+	if len(selections) == 0 {
+		p.fail("empty selection set")
+	}
+	return selections
+}
+
+func (p *parser) parseSelection() selection {
+	if p.skip(tokenPunct, "...") {
+		if p.tok.kind == tokenName && p.tok.text != "on" {
+			return selection{spread: p.name(), directives: p.parseDirectives()}
+		}
+		var sel selection
+		if p.skip(tokenName, "on") {
+			sel.typeCondition = p.name()
+		}
+		sel.directives = p.parseDirectives()
+		sel.inline = p.parseSelectionSet()
+		return sel
+	}
+
+	f := &field{name: p.name()}
+	if p.skip(tokenPunct, ":") {
+		f.alias, f.name = f.name, p.name()
+	}
+	f.arguments = p.parseArguments()
+	sel := selection{field: f, directives: p.parseDirectives()}
+	if p.peek(tokenPunct, "{") {
+		f.selection = p.parseSelectionSet()
+	}
+	return sel
+}
+
+func (p *parser) parseArguments() map[string]value {
+	if !p.skip(tokenPunct, "(") {
+		return nil
+	}
+	arguments := make(map[string]value)
+	for !p.skip(tokenPunct, ")") {
+		name := p.name()
+		if _, ok := arguments[name]; ok {
+			p.fail("argument %s is given twice", name)
+		}
+		p.expect(tokenPunct, ":")
+		arguments[name] = p.parseValue(false)
+	}
+	return arguments
+}
+
+func (p *parser) parseDirectives() []directive {
+	var directives []directive
+	for p.skip(tokenPunct, "@") {
+		directives = append(directives, directive{name: p.name(), arguments: p.parseArguments()})
+	}
+	return directives
+}
+
+// parseValue parses an input value; constant values cannot reference variables.
+func (p *parser) parseValue(constant bool) value {
+	tok := p.tok
+	switch tok.kind {
+	case tokenPunct:
+		switch tok.text {
+		case "$":
+			if constant {
+				p.fail("a default value cannot reference a variable")
+			}
+			p.next()
+			return variableRef(p.name())
+		case "[":
+			p.next()
+			list := []value{}
+			for !p.skip(tokenPunct, "]") {
+				list = append(list, p.parseValue(constant))
+			}
+			return list
+		case "{":
+			p.next()
+			object := map[string]value{}
+			for !p.skip(tokenPunct, "}") {
+				name := p.name()
+				p.expect(tokenPunct, ":")
+				object[name] = p.parseValue(constant)
+			}
+			return object
+		}
+	case tokenInt:
+		p.next()
+		n, err := strconv.Atoi(tok.text)
+		if err != nil {
+			p.fail("invalid integer %s", tok.text)
+		}
+		return n
+	case tokenFloat:
+		p.next()
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			p.fail("invalid float %s", tok.text)
+		}
+		return f
+	case tokenString:
+		p.next()
+		return tok.text
+	case tokenName:
+		p.next()
+		switch tok.text {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumValue(tok.text)
+	}
+	p.fail("expected a value, found %s", tok)
+	return nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of document"
+	}
+	return strconv.Quote(t.text)
+}
+
+// byteOrderMark is ignored like whitespace.
+const byteOrderMark = "\uFEFF"
+
+type lexer struct {
+	source string
+	pos    int
+}
+
+// lex returns the next token, skipping whitespace, commas and comments.
+func (l *lexer) lex() (token, error) {
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' && l.source[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.source[l.pos:], byteOrderMark):
+			l.pos += len(byteOrderMark)
+		default:
+			return l.token()
+		}
+	}
+	return token{kind: tokenEOF, pos: l.pos}, nil
+}
+
+func (l *lexer) token() (token, error) {
+	start := l.pos
+	c := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunct, text: "...", pos: start}, nil
+	case strings.ContainsRune("!$()[]{}:=@|&", rune(c)):
+		l.pos++
+		return token{kind: tokenPunct, text: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.source) && isNameChar(l.source[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokenName, text: l.source[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		if strings.HasPrefix(l.source[l.pos:], `"""`) {
+			return l.blockString()
+		}
+		return l.string()
+	}
+	r, _ := utf8.DecodeRuneInString(l.source[l.pos:])
+	return token{}, &parseError{pos: start, message: fmt.Sprintf("unexpected character %q", r)}
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.source[l.pos] == '-' {
+		l.pos++
+	}
+	if !l.digits() {
+		return token{}, &parseError{pos: start, message: "invalid number"}
+	}
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if !l.digits() {
+			return token{}, &parseError{pos: start, message: "invalid number"}
+		}
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.pos++
+		}
+		if !l.digits() {
+			return token{}, &parseError{pos: start, message: "invalid number"}
+		}
+	}
+	return token{kind: kind, text: l.source[start:l.pos], pos: start}, nil
+}
+
+// digits consumes a run of digits and reports whether there was one.
+func (l *lexer) digits() bool {
+	start := l.pos
+	for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+		l.pos++
+	}
+	return l.pos > start
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return token{kind: tokenString, text: b.String(), pos: start}, nil
+		case '\n', '\r':
+			return token{}, &parseError{pos: l.pos, message: "unterminated string"}
+		case '\\':
+			if l.pos+1 >= len(l.source) {
+				return token{}, &parseError{pos: l.pos, message: "unterminated string"}
+			}
+			escape := l.source[l.pos+1]
+			l.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.source) {
+					return token{}, &parseError{pos: l.pos, message: "invalid unicode escape"}
+				}
+				code, err := strconv.ParseUint(l.source[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, &parseError{pos: l.pos, message: "invalid unicode escape"}
+				}
+				b.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, &parseError{pos: l.pos - 1, message: fmt.Sprintf("invalid escape \\%c", escape)}
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, &parseError{pos: start, message: "unterminated string"}
+}
+
+// blockString lexes a block string. Its raw content is kept: neither indentation nor escaped
+// quotes are processed.
+func (l *lexer) blockString() (token, error) {
+	start := l.pos
+	l.pos += 3
+	end := strings.Index(l.source[l.pos:], `"""`)
+	if end < 0 {
+		return token{}, &parseError{pos: start, message: "unterminated block string"}
+	}
+	text := l.source[l.pos : l.pos+end]
+	l.pos += end + 3
+	return token{kind: tokenString, text: text, pos: start}, nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || isLetter(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
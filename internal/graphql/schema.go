@@ -0,0 +1,416 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// Page sizes of the connections of the schema.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// maxRequestBytes bounds the size of a request body.
+const maxRequestBytes = 1 << 20
+
+// errFindingsDisabled is returned when finding tracking is disabled.
+var errFindingsDisabled = errors.New("finding tracking is disabled")
+
+// Schema is the query type of the API. Its fields are:
+//
+//	findings(repository, state, rule, file, first, after): FindingConnection
+//	finding(repository!, fingerprint!): Finding
+//	repositories(first, after): RepositoryConnection
+//	repository(name!): Repository
+//	scans(repository, status, first, after): ScanConnection
+//	scan(id!): Scan
+//
+// Finding has fingerprint, repository, file, ruleId, line, state, firstSeen, lastSeen and
+// resolvedAt. Repository, one per repository with tracked findings, has name,
+// openFindings, resolvedFindings, suppressedFindings, lastSeen and the findings and scans
+// connections filtered to it. Scan has id, repository, ref, commit, status, createdAt,
+// startedAt, finishedAt, findings and error. Connections have totalCount, nodes and
+// pageInfo { endCursor hasNextPage }, and are paged with first and after.
+type Schema struct {
+	// Findings, when set, is the store of tracked findings.
+	Findings store.FindingStore
+	// Scans, when set, holds the recent scan jobs.
+	Scans *jobs.Registry
+}
+
+// Query returns the query type.
+func (s *Schema) Query() *Object {
+	return &Object{Type: "Query", Fields: map[string]Resolver{
+		"findings": func(ctx context.Context, args Args) (any, error) {
+			return s.findings(ctx, "", args)
+		},
+		"finding": func(ctx context.Context, args Args) (any, error) {
+			repository, fingerprint, err := requiredPair(args, "repository", "fingerprint")
+			if err != nil {
+				return nil, err
+			}
+			all, err := s.repositoryFindings(ctx, repository)
+			if err != nil {
+				return nil, err
+			}
+			for _, finding := range all {
+				if finding.Fingerprint == fingerprint {
+					return findingObject(finding), nil
+				}
+			}
+			return nil, nil
+		},
+		"repositories": func(ctx context.Context, args Args) (any, error) {
+			repositories, err := s.repositories(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return connection(args, "Repository", repositories)
+		},
+		"repository": func(ctx context.Context, args Args) (any, error) {
+			name, err := required(args, "name")
+			if err != nil {
+				return nil, err
+			}
+			repositories, err := s.repositories(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, repository := range repositories {
+				if strings.EqualFold(repository.name, name) {
+					return repository.object, nil
+				}
+			}
+			return nil, nil
+		},
+		"scans": func(_ context.Context, args Args) (any, error) {
+			return s.scans("", args)
+		},
+		"scan": func(_ context.Context, args Args) (any, error) {
+			id, err := required(args, "id")
+			if err != nil {
+				return nil, err
+			}
+			job, ok := s.Scans.Get(id)
+			if !ok {
+				return nil, nil
+			}
+			return scanObject(job), nil
+		},
+	}}
+}
+
+// findings resolves a connection of the tracked findings, of repository when set.
+func (s *Schema) findings(ctx context.Context, repository string, args Args) (any, error) {
+	if repository == "" {
+		var err error
+		if repository, err = args.String("repository"); err != nil {
+			return nil, err
+		}
+	}
+	filters := map[string]string{}
+	for _, name := range []string{"state", "rule", "file"} {
+		value, err := args.String(name)
+		if err != nil {
+			return nil, err
+		}
+		filters[name] = value
+	}
+	state := store.State(strings.ToLower(filters["state"]))
+	switch state {
+	case "", store.StateOpen, store.StateResolved, store.StateSuppressed:
+	default:
+		return nil, fmt.Errorf("unknown finding state %s", filters["state"])
+	}
+
+	all, err := s.repositoryFindings(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []node
+	for _, finding := range all {
+		if (state != "" && finding.State != state) ||
+			(filters["rule"] != "" && finding.RuleID != filters["rule"]) ||
+			(filters["file"] != "" && finding.File != filters["file"]) {
+			continue
+		}
+		nodes = append(nodes, node{object: findingObject(finding)})
+	}
+	return connection(args, "Finding", nodes)
+}
+
+// repositoryFindings returns the tracked findings of repository, or of every repository.
+func (s *Schema) repositoryFindings(ctx context.Context, repository string) ([]store.Finding, error) {
+	if s.Findings == nil {
+		return nil, errFindingsDisabled
+	}
+	if repository == "" {
+		return s.Findings.AllFindings(ctx)
+	}
+	return s.Findings.Findings(ctx, repository)
+}
+
+// repositories returns the repositories with tracked findings, by name.
+func (s *Schema) repositories(ctx context.Context) ([]node, error) {
+	all, err := s.repositoryFindings(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	type counts struct {
+		open, resolved, suppressed int
+		lastSeen                   time.Time
+	}
+	byName := make(map[string]*counts)
+	var names []string
+	for _, finding := range all {
+		c := byName[finding.Repository]
+		if c == nil {
+			c = &counts{lastSeen: finding.LastSeen}
+			byName[finding.Repository] = c
+			names = append(names, finding.Repository)
+		}
+		switch finding.State {
+		case store.StateOpen:
+			c.open++
+		case store.StateResolved:
+			c.resolved++
+		case store.StateSuppressed:
+			c.suppressed++
+		}
+		if c.lastSeen.Before(finding.LastSeen) {
+			c.lastSeen = finding.LastSeen
+		}
+	}
+	sort.Strings(names)
+
+	nodes := make([]node, len(names))
+	for i, name := range names {
+		c := byName[name]
+		nodes[i] = node{name: name, object: &Object{Type: "Repository", Fields: map[string]Resolver{
+			"name":               constant(name),
+			"openFindings":       constant(c.open),
+			"resolvedFindings":   constant(c.resolved),
+			"suppressedFindings": constant(c.suppressed),
+			"lastSeen":           constant(c.lastSeen),
+			"findings": func(ctx context.Context, args Args) (any, error) {
+				return s.findings(ctx, name, args)
+			},
+			"scans": func(_ context.Context, args Args) (any, error) {
+				return s.scans(name, args)
+			},
+		}}}
+	}
+	return nodes, nil
+}
+
+// scans resolves a connection of the recent scan jobs, most recent first, of repository when
+// set.
+func (s *Schema) scans(repository string, args Args) (any, error) {
+	if repository == "" {
+		var err error
+		if repository, err = args.String("repository"); err != nil {
+			return nil, err
+		}
+	}
+	status, err := args.String("status")
+	if err != nil {
+		return nil, err
+	}
+	var nodes []node
+	for _, job := range s.Scans.List() {
+		if (repository != "" && !strings.EqualFold(job.Repository, repository)) ||
+			(status != "" && !strings.EqualFold(string(job.Status), status)) {
+			continue
+		}
+		nodes = append(nodes, node{object: scanObject(job)})
+	}
+	return connection(args, "Scan", nodes)
+}
+
+func findingObject(finding store.Finding) *Object {
+	return &Object{Type: "Finding", Fields: map[string]Resolver{
+		"fingerprint": constant(finding.Fingerprint),
+		"repository":  constant(finding.Repository),
+		"file":        constant(finding.File),
+		"ruleId":      constant(finding.RuleID),
+		"line":        constant(finding.Line),
+		"state":       constant(string(finding.State)),
+		"firstSeen":   constant(finding.FirstSeen),
+		"lastSeen":    constant(finding.LastSeen),
+		"resolvedAt":  constant(finding.ResolvedAt),
+	}}
+}
+
+func scanObject(job jobs.Job) *Object {
+	var jobError any
+	if job.Error != "" {
+		jobError = job.Error
+	}
+	return &Object{Type: "Scan", Fields: map[string]Resolver{
+		"id":         constant(job.ID),
+		"repository": constant(job.Repository),
+		"ref":        constant(job.Ref),
+		"commit":     constant(job.Commit),
+		"status":     constant(string(job.Status)),
+		"createdAt":  constant(job.CreatedAt),
+		"startedAt":  constant(job.StartedAt),
+		"finishedAt": constant(job.FinishedAt),
+		"findings":   constant(job.Findings),
+		"error":      constant(jobError),
+	}}
+}
+
+// node is an item of a connection; name is set for repositories.
+type node struct {
+	name   string
+	object *Object
+}
+
+// connection resolves the page of nodes of type nodeType selected by the first and after
+// arguments.
+func connection(args Args, nodeType string, nodes []node) (any, error) {
+	first, err := args.Int("first", DefaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+	if first < 0 || first > MaxPageSize {
+		return nil, fmt.Errorf("first must be between 0 and %d", MaxPageSize)
+	}
+	after, err := args.String("after")
+	if err != nil {
+		return nil, err
+	}
+	start := 0
+	if after != "" {
+		offset, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		start = min(offset+1, len(nodes))
+	}
+	end := min(start+first, len(nodes))
+
+	page := make([]*Object, 0, end-start)
+	for _, n := range nodes[start:end] {
+		page = append(page, n.object)
+	}
+	var endCursor any
+	if end > start {
+		endCursor = encodeCursor(end - 1)
+	}
+	pageInfo := &Object{Type: "PageInfo", Fields: map[string]Resolver{
+		"endCursor":   constant(endCursor),
+		"hasNextPage": constant(end < len(nodes)),
+	}}
+	return &Object{Type: nodeType + "Connection", Fields: map[string]Resolver{
+		"totalCount": constant(len(nodes)),
+		"nodes":      constant(page),
+		"pageInfo":   constant(pageInfo),
+	}}, nil
+}
+
+// encodeCursor returns the opaque cursor of the node at offset.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("cursor:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		offset, ok := strings.CutPrefix(string(raw), "cursor:")
+		if n, err := strconv.Atoi(offset); ok && err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor %s", cursor)
+}
+
+// constant resolves a field to value.
+func constant(value any) Resolver {
+	return func(context.Context, Args) (any, error) {
+		return value, nil
+	}
+}
+
+// required returns the non-empty string argument name.
+func required(args Args, name string) (string, error) {
+	value, err := args.String(name)
+	if err == nil && value == "" {
+		err = fmt.Errorf("argument %s is required", name)
+	}
+	return value, err
+}
+
+func requiredPair(args Args, first, second string) (string, string, error) {
+	a, err := required(args, first)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := required(args, second)
+	return a, b, err
+}
+
+// Handler serves queries of schema, POSTed as JSON or sent as the query, operationName and
+// variables parameters of a GET request. Requests that cannot be parsed are answered with
+// 400; errors of fields are reported in the response.
+func Handler(schema *Schema, logger zerolog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+			if variables := query.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					writeResponse(w, http.StatusBadRequest, errorResponse("invalid variables"), logger)
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+				writeResponse(w, http.StatusBadRequest, errorResponse("expected a JSON body with a query"), logger)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if req.Query == "" {
+			writeResponse(w, http.StatusBadRequest, errorResponse("missing query"), logger)
+			return
+		}
+
+		resp := Execute(r.Context(), schema.Query(), req)
+		code := http.StatusOK
+		if resp.Data == nil {
+			code = http.StatusBadRequest
+		}
+		writeResponse(w, code, resp, logger)
+	})
+}
+
+func errorResponse(message string) Response {
+	return Response{Errors: []Error{{Message: message}}}
+}
+
+func writeResponse(w http.ResponseWriter, code int, resp Response, logger zerolog.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error().Err(err).Msg("Failed to write GraphQL response")
+	}
+}
@@ -0,0 +1,154 @@
+package grpcserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ScanRequest is the JSON body of POST /api/v1/scan: a unified diff, files, or both.
+// Requests of any other content type carry a raw unified diff.
+type ScanRequest struct {
+	Diff  string     `json:"diff,omitempty"`
+	Files []ScanFile `json:"files,omitempty"`
+}
+
+// ScanFile is the content of a file to scan.
+type ScanFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// ScanResponse lists the findings of a scan. Findings never include the secret.
+type ScanResponse struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Finding is a finding of a scan, as the gRPC API reports it.
+type Finding struct {
+	RuleID      string `json:"rule_id"`
+	Description string `json:"description"`
+	File        string `json:"file"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	StartColumn int    `json:"start_column"`
+	EndColumn   int    `json:"end_column"`
+	Commit      string `json:"commit,omitempty"`
+	Severity    string `json:"severity"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// ServeHTTP scans the diff or files of a POST request with the current scanner.
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.current.Load().ServeHTTP(w, r)
+}
+
+// ServeHTTP scans the diff or files of a POST request of at most MaxMessageBytes and
+// responds with the findings as a ScanResponse, so CI jobs and editor plugins can scan with
+// the server's rules, path overrides and severities without GitHub access.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := readScanRequest(http.MaxBytesReader(w, r.Body, MaxMessageBytes), r.Header.Get("Content-Type"))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "expected a unified diff, or a JSON body with a diff or files", http.StatusBadRequest)
+		return
+	}
+
+	findings, err := s.scan(r, req)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			code = http.StatusBadRequest
+		case codes.Unavailable:
+			code = http.StatusServiceUnavailable
+		}
+		http.Error(w, status.Convert(err).Message(), code)
+		return
+	}
+
+	response := ScanResponse{Findings: make([]Finding, 0, len(findings))}
+	for _, finding := range s.Overrides.Filter(findings) {
+		p := s.toProto("", finding)
+		response.Findings = append(response.Findings, Finding{
+			RuleID:      p.GetRuleId(),
+			Description: p.GetDescription(),
+			File:        p.GetFile(),
+			StartLine:   int(p.GetStartLine()),
+			EndLine:     int(p.GetEndLine()),
+			StartColumn: int(p.GetStartColumn()),
+			EndColumn:   int(p.GetEndColumn()),
+			Commit:      p.GetCommit(),
+			Severity:    p.GetSeverity(),
+			Fingerprint: p.GetFingerprint(),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to write scan response")
+	}
+}
+
+// readScanRequest reads a JSON ScanRequest, or a raw diff of any other content type.
+func readScanRequest(body io.Reader, contentType string) (ScanRequest, error) {
+	var req ScanRequest
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/json" {
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			return req, err
+		}
+	} else {
+		diff, err := io.ReadAll(body)
+		if err != nil {
+			return req, err
+		}
+		req.Diff = string(diff)
+	}
+	if req.Diff == "" && len(req.Files) == 0 {
+		return req, errors.New("nothing to scan")
+	}
+	return req, nil
+}
+
+// scan returns the findings of the request's diff and files.
+func (s *Server) scan(r *http.Request, req ScanRequest) ([]report.Finding, error) {
+	d, err := s.detector(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	var findings []report.Finding
+	if req.Diff != "" {
+		err := s.detectDiff(d, []byte(req.Diff), func(detected []report.Finding) error {
+			findings = append(findings, detected...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range req.Files {
+		if file.Path == "" {
+			return nil, status.Error(codes.InvalidArgument, "every file needs a path")
+		}
+		detected, err := s.detectBlob(d, file.Path, []byte(file.Content))
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, detected...)
+	}
+	return findings, nil
+}
@@ -0,0 +1,345 @@
+// Package grpcserver exposes the scanning core over gRPC as the gitguard.v1 ScannerService,
+// and over HTTP at /api/v1/scan, so CI systems, editor plugins and other services can scan
+// content without going through GitHub webhooks.
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	gitguardv1 "github.com/omercnet/gitguard/api/gitguard/v1"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/handler"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/pathrules"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/workflow"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MaxMessageBytes is the largest request accepted, bounding the diffs and blobs callers send.
+const MaxMessageBytes = 25 << 20
+
+// Server runs the scans of the ScannerService with one configuration.
+type Server struct {
+	// Detectors provides detectors built from the configured rules.
+	Detectors *detector.Factory
+	// Plugins are additional detectors run on every scanned file.
+	Plugins plugin.Set
+	// Severity classifies findings; nil rates every finding high.
+	Severity *severity.Classifier
+	// Overrides, when set, drops findings disabled for their file path.
+	Overrides *pathrules.Set
+	// Scheduler, when set, gives repository scans a low-priority scan slot before they clone,
+	// so they compete with full scans instead of running beside them.
+	Scheduler *ratelimit.Scheduler
+	// MaxCloneMB, when positive, fails repository scans whose clone grows larger.
+	MaxCloneMB int
+	// Logger logs the calls.
+	Logger zerolog.Logger
+}
+
+// Service serves the scanner through the Server most recently set, so its configuration can
+// be replaced while the gRPC and HTTP servers run.
+type Service struct {
+	gitguardv1.UnimplementedScannerServiceServer
+	current atomic.Pointer[Server]
+}
+
+// NewService creates a service serving the scanner.
+func NewService(scanner *Server) *Service {
+	s := &Service{}
+	s.Set(scanner)
+	return s
+}
+
+// Set replaces the scanner serving new calls.
+func (s *Service) Set(scanner *Server) {
+	s.current.Store(scanner)
+}
+
+// ScanDiff implements gitguardv1.ScannerServiceServer.
+func (s *Service) ScanDiff(req *gitguardv1.ScanDiffRequest, stream gitguardv1.ScannerService_ScanDiffServer) error {
+	return s.current.Load().ScanDiff(req, stream)
+}
+
+// ScanBlob implements gitguardv1.ScannerServiceServer.
+func (s *Service) ScanBlob(req *gitguardv1.ScanBlobRequest, stream gitguardv1.ScannerService_ScanBlobServer) error {
+	return s.current.Load().ScanBlob(req, stream)
+}
+
+// ScanRepository implements gitguardv1.ScannerServiceServer.
+func (s *Service) ScanRepository(
+	req *gitguardv1.ScanRepositoryRequest,
+	stream gitguardv1.ScannerService_ScanRepositoryServer,
+) error {
+	return s.current.Load().ScanRepository(req, stream)
+}
+
+// New creates a gRPC server for the service, which callers must send token to as
+// "authorization: Bearer <token>" metadata. An empty token rejects every call.
+func New(service *Service, token string) *grpc.Server {
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(MaxMessageBytes), grpc.StreamInterceptor(tokenAuth(token)))
+	gitguardv1.RegisterScannerServiceServer(server, service)
+	return server
+}
+
+// tokenAuth rejects streams without the bearer token, and every stream when it is empty.
+func tokenAuth(token string) grpc.StreamServerInterceptor {
+	want := []byte("Bearer " + token)
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		values := md.Get("authorization")
+		if token == "" || len(values) != 1 || subtle.ConstantTimeCompare([]byte(values[0]), want) != 1 {
+			return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+		}
+		return next(srv, ss)
+	}
+}
+
+// ScanDiff implements gitguardv1.ScannerServiceServer.
+func (s *Server) ScanDiff(req *gitguardv1.ScanDiffRequest, stream gitguardv1.ScannerService_ScanDiffServer) error {
+	d, err := s.detector(stream.Context())
+	if err != nil {
+		return err
+	}
+	return s.detectDiff(d, req.GetDiff(), func(findings []report.Finding) error {
+		return s.send(stream, "", findings)
+	})
+}
+
+// ScanBlob implements gitguardv1.ScannerServiceServer.
+func (s *Server) ScanBlob(req *gitguardv1.ScanBlobRequest, stream gitguardv1.ScannerService_ScanBlobServer) error {
+	d, err := s.detector(stream.Context())
+	if err != nil {
+		return err
+	}
+	findings, err := s.detectBlob(d, req.GetPath(), req.GetContent())
+	if err != nil {
+		return err
+	}
+	return s.send(stream, "", findings)
+}
+
+// detectDiff scans the lines added by a unified diff, passing the findings of each fragment
+// to emit until it fails.
+func (s *Server) detectDiff(d *detect.Detector, diff []byte, emit func([]report.Finding) error) error {
+	files, err := gitdiff.Parse(bytes.NewReader(diff))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid diff: %v", err)
+	}
+	// Drain the parser even when emit fails, so its goroutine exits.
+	var emitErr error
+	for file := range files {
+		if emitErr != nil || file.IsDelete || file.IsBinary {
+			continue
+		}
+		commit := ""
+		if file.PatchHeader != nil {
+			commit = file.PatchHeader.SHA
+		}
+		for _, fragment := range file.TextFragments {
+			findings := detector.Check(d, d.Detect(detect.Fragment{
+				Raw:       fragment.Raw(gitdiff.OpAdd),
+				FilePath:  file.NewName,
+				CommitSHA: commit,
+				StartLine: int(fragment.NewPosition),
+			}))
+			for i := range findings {
+				findings[i].File = file.NewName
+				findings[i].Commit = commit
+			}
+			if emitErr = emit(findings); emitErr != nil {
+				break
+			}
+		}
+	}
+	return emitErr
+}
+
+// detectBlob scans the content of the file at path.
+func (s *Server) detectBlob(d *detect.Detector, path string, content []byte) ([]report.Finding, error) {
+	findings, err := detector.DetectReader(d, bytes.NewReader(content), constants.MaxScanFileBytes)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	findings = append(findings, workflow.Detect(path, string(content))...)
+	findings = append(findings, s.Plugins.ScanContent(path, string(content))...)
+	for i := range findings {
+		findings[i].File = path
+	}
+	return findings, nil
+}
+
+// ScanRepository implements gitguardv1.ScannerServiceServer.
+func (s *Server) ScanRepository(
+	req *gitguardv1.ScanRepositoryRequest,
+	stream gitguardv1.ScannerService_ScanRepositoryServer,
+) error {
+	ctx := stream.Context()
+	u, err := url.Parse(req.GetUrl())
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return status.Error(codes.InvalidArgument, "url must be an https clone URL")
+	}
+	d, err := s.detector(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := &git.CloneOptions{URL: req.GetUrl(), Depth: 1, SingleBranch: true}
+	if ref := req.GetRef(); ref != "" {
+		opts.ReferenceName = plumbing.ReferenceName(ref)
+		if !strings.HasPrefix(ref, "refs/") {
+			opts.ReferenceName = plumbing.NewBranchReferenceName(ref)
+		}
+	}
+	if token := req.GetToken(); token != "" {
+		opts.Auth = &githttp.BasicAuth{Username: "git", Password: token}
+	}
+	release, err := s.Scheduler.Acquire(s.Logger.WithContext(ctx), 0, ratelimit.Low)
+	if err != nil {
+		if errors.Is(err, ratelimit.ErrSaturated) || errors.Is(err, ratelimit.ErrShed) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return status.FromContextError(err).Err()
+	}
+	defer release()
+	gitRepo, err := s.clone(ctx, opts)
+	if err != nil {
+		if errors.Is(err, errCloneTooLarge) {
+			return status.Errorf(codes.FailedPrecondition, "repository is larger than %d MB", s.MaxCloneMB)
+		}
+		return status.Errorf(codes.FailedPrecondition, "failed to clone repository: %v", err)
+	}
+
+	head, err := gitRepo.Head()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get head reference: %v", err)
+	}
+	commit, err := gitRepo.CommitObject(head.Hash())
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get commit object: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get tree: %v", err)
+	}
+
+	repository := strings.TrimSuffix(strings.TrimPrefix(u.Path, "/"), ".git")
+	err = tree.Files().ForEach(func(file *object.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if handler.SkipFile(file.Name, file.Size) {
+			return nil
+		}
+		content, err := file.Reader()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		findings, err := handler.ScanFile(ctx, d, s.Plugins, file.Name, content, false)
+		content.Close()
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", file.Name, err)
+		}
+		for i := range findings {
+			findings[i].File = file.Name
+			findings[i].Commit = head.Hash().String()
+		}
+		return s.send(stream, repository, findings)
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "failed to scan repository: %v", err)
+	}
+	return nil
+}
+
+// errCloneTooLarge fails clones growing beyond MaxCloneMB.
+var errCloneTooLarge = errors.New("repository is too large to clone")
+
+// clone clones a repository into memory, failing with errCloneTooLarge once its objects
+// exceed MaxCloneMB.
+func (s *Server) clone(ctx context.Context, opts *git.CloneOptions) (*git.Repository, error) {
+	storage := &cappedStorage{Storage: memory.NewStorage(), left: -1}
+	if s.MaxCloneMB > 0 {
+		storage.left = int64(s.MaxCloneMB) << 20
+	}
+	return git.CloneContext(ctx, storage, nil, opts)
+}
+
+// cappedStorage stores objects in memory until their total size would exceed left bytes;
+// a negative left is unlimited.
+type cappedStorage struct {
+	*memory.Storage
+	left int64
+}
+
+// SetEncodedObject stores obj, or fails with errCloneTooLarge when there is no room for it.
+func (s *cappedStorage) SetEncodedObject(obj plumbing.EncodedObject) (plumbing.Hash, error) {
+	if s.left >= 0 {
+		if obj.Size() > s.left {
+			return plumbing.ZeroHash, errCloneTooLarge
+		}
+		s.left -= obj.Size()
+	}
+	return s.Storage.SetEncodedObject(obj)
+}
+
+func (s *Server) detector(ctx context.Context) (*detect.Detector, error) {
+	d, err := s.Detectors.Detector(ctx)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Failed to build secret detector")
+		return nil, status.Error(codes.Unavailable, "secret detector unavailable")
+	}
+	return d, nil
+}
+
+// send streams the findings not disabled by path overrides.
+func (s *Server) send(stream grpc.ServerStreamingServer[gitguardv1.Finding], repository string, findings []report.Finding) error {
+	for _, finding := range s.Overrides.Filter(findings) {
+		if err := stream.Send(s.toProto(repository, finding)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toProto converts a finding without its secret.
+func (s *Server) toProto(repository string, finding report.Finding) *gitguardv1.Finding {
+	return &gitguardv1.Finding{
+		RuleId:      finding.RuleID,
+		Description: finding.Description,
+		File:        finding.File,
+		StartLine:   int32(finding.StartLine),   // #nosec G115 -- line numbers fit in int32
+		EndLine:     int32(finding.EndLine),     // #nosec G115 -- line numbers fit in int32
+		StartColumn: int32(finding.StartColumn), // #nosec G115 -- column numbers fit in int32
+		EndColumn:   int32(finding.EndColumn),   // #nosec G115 -- column numbers fit in int32
+		Commit:      finding.Commit,
+		Severity:    s.Severity.Classify(finding).String(),
+		Fingerprint: notify.Fingerprint(repository, finding),
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// attributeFindings records on each finding the commit that introduced its secret, like
+// git log -S would: the oldest commit of the unbroken run of commits on the scanned branch
+// whose version of the file contains it. Author is set to the GitHub login of the commit's
+// author, prefixed with @, when GitHub links the commit to an account, and to the git author
+// name otherwise. Findings whose history cannot be traced are left as they are.
+func attributeFindings(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo string,
+	gitRepo *git.Repository,
+	findings []report.Finding,
+	logger zerolog.Logger,
+) {
+	if len(findings) == 0 {
+		return
+	}
+	ref, err := gitRepo.Head()
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgAttributionFailed)
+		return
+	}
+
+	introduced := make(map[string]string)
+	logins := make(map[string]string)
+	for i := range findings {
+		finding := &findings[i]
+		if finding.File == "" || finding.Secret == "" {
+			continue
+		}
+		key := finding.File + "\x00" + finding.Secret
+		sha, traced := introduced[key]
+		if !traced {
+			if len(introduced) == constants.AttributionMaxSecrets {
+				continue
+			}
+			target := cleanupTarget{Path: finding.File}
+			if err := traceSecretHistory(gitRepo, ref.Hash(), finding.Secret, &target); err != nil {
+				logger.Warn().Err(err).Str("file", finding.File).Msg(constants.LogMsgAttributionFailed)
+			}
+			sha = target.FirstCommit
+			introduced[key] = sha
+		}
+		if sha == "" {
+			continue
+		}
+		commit, err := gitRepo.CommitObject(plumbing.NewHash(sha))
+		if err != nil {
+			continue
+		}
+
+		login, looked := logins[sha]
+		if !looked && len(logins) < constants.AttributionMaxLookups {
+			login = commitAuthorLogin(ctx, client, owner, repo, sha, logger)
+			logins[sha] = login
+		}
+		finding.Commit = sha
+		finding.Author = commit.Author.Name
+		finding.Email = commit.Author.Email
+		finding.Date = commit.Author.When.UTC().Format(time.RFC3339)
+		if login != "" {
+			finding.Author = "@" + login
+		}
+	}
+}
+
+// commitAuthorLogin returns the login of the GitHub account that authored sha, or an empty
+// string when its author email is not linked to an account.
+func commitAuthorLogin(
+	ctx context.Context, client *github.Client, owner, repo, sha string, logger zerolog.Logger,
+) string {
+	commit, _, err := client.Repositories.GetCommit(ctx, owner, repo, sha, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		logger.Warn().Err(err).Str("commit", sha).Msg(constants.LogMsgAuthorLookupFailed)
+		return ""
+	}
+	return commit.GetAuthor().GetLogin()
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// splitBaseline separates the findings recorded in the repository's baseline from new ones.
+// Without a baseline every finding is new.
+func splitBaseline(
+	ctx context.Context, baselines store.Store, repository string, findings []report.Finding,
+) (fresh, baselined []report.Finding, err error) {
+	if baselines == nil || len(findings) == 0 {
+		return findings, nil, nil
+	}
+
+	baseline, err := baselines.Baseline(ctx, repository)
+	if errors.Is(err, store.ErrNotFound) {
+		return findings, nil, nil
+	}
+	if err != nil {
+		return findings, nil, err
+	}
+
+	for _, finding := range findings {
+		if baseline.Contains(notify.Fingerprint(repository, finding)) {
+			baselined = append(baselined, finding)
+		} else {
+			fresh = append(fresh, finding)
+		}
+	}
+	return fresh, baselined, nil
+}
+
+// newBaseline builds a baseline grandfathering the findings.
+func newBaseline(repository string, findings []report.Finding) store.Baseline {
+	baseline := store.Baseline{Repository: repository, CreatedAt: time.Now().UTC()}
+	for _, finding := range findings {
+		baseline.Fingerprints = append(baseline.Fingerprints, notify.Fingerprint(repository, finding))
+	}
+	return baseline
+}
+
+// applyBaseline returns the findings of a full scan that are not grandfathered. The first
+// full scan of a repository records its findings as the baseline.
+func (h *FullRepoScanHandler) applyBaseline(
+	ctx context.Context, repository string, findings []report.Finding, logger zerolog.Logger,
+) []report.Finding {
+	if h.Baseline == nil {
+		return findings
+	}
+
+	_, err := h.Baseline.Baseline(ctx, repository)
+	if errors.Is(err, store.ErrNotFound) {
+		if err := h.Baseline.SaveBaseline(ctx, newBaseline(repository, findings)); err != nil {
+			logger.Warn().Err(err).Msg(constants.LogMsgBaselineFailed)
+			return findings
+		}
+		logger.Info().Int("findings", len(findings)).Msg(constants.LogMsgBaselineRecorded)
+		return nil
+	}
+
+	fresh, baselined, err := splitBaseline(ctx, h.Baseline, repository, findings)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgBaselineFailed)
+		return findings
+	}
+	logger.Debug().Int("baselined", len(baselined)).Msg(constants.LogMsgBaselineApplied)
+	return fresh
+}
+
+// Rebaseline scans the default branch of a repository and replaces its baseline with the
+// current findings. It returns the number of grandfathered findings.
+func (h *FullRepoScanHandler) Rebaseline(ctx context.Context, owner, repo string, logger zerolog.Logger) (int, error) {
+	if h.Baseline == nil {
+		return 0, errors.New(constants.ErrBaselineDisabled)
+	}
+
+	client, installationID, err := h.repositoryClient(ctx, owner, repo)
+	if err != nil {
+		return 0, err
+	}
+
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return 0, fmt.Errorf(constants.ErrGetDefaultBranch, err)
+	}
+	if repository.GetCloneURL() == "" {
+		return 0, errors.New(constants.ErrInvalidCloneURL)
+	}
+	gitRepo, lfsClient, revoke, err := h.cloneRepository(ctx, client, installationID, repository, logger)
+	if err != nil {
+		return 0, err
+	}
+	names := h.Filenames.For(repository.GetFullName())
+	scan, err := h.scanGitRepository(logger.WithContext(ctx), gitRepo, names, lfsClient, nil)
+	revoke()
+	if err != nil {
+		return 0, fmt.Errorf(constants.ErrScanRepository, err)
+	}
+	findings := scan.Findings
+
+	if err := h.Baseline.SaveBaseline(ctx, newBaseline(repository.GetFullName(), findings)); err != nil {
+		return 0, err
+	}
+	logger.Info().
+		Str("repo", repository.GetFullName()).
+		Int("findings", len(findings)).
+		Msg(constants.LogMsgBaselineRecorded)
+	return len(findings), nil
+}
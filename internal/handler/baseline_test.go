@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestFullRepoScanHandler_applyBaseline(t *testing.T) {
+	ctx := context.Background()
+	h := &FullRepoScanHandler{Baseline: store.NewMemory()}
+	old := report.Finding{File: "config.yml", RuleID: "generic-api-key", Secret: "old-secret"}
+	leaked := report.Finding{File: "app.env", RuleID: "aws-access-token", Secret: "new-secret"}
+
+	assert.Empty(t, h.applyBaseline(ctx, "owner/repo", []report.Finding{old}, zerolog.Nop()),
+		"The first scan should grandfather every finding")
+
+	moved := old
+	moved.StartLine = 42
+	fresh := h.applyBaseline(ctx, "owner/repo", []report.Finding{moved, leaked}, zerolog.Nop())
+	require.Len(t, fresh, 1)
+	assert.Equal(t, "aws-access-token", fresh[0].RuleID)
+
+	fresh, baselined, err := splitBaseline(ctx, h.Baseline, "owner/other", []report.Finding{old})
+	require.NoError(t, err)
+	assert.Len(t, fresh, 1, "Baselines are per repository")
+	assert.Empty(t, baselined)
+
+	h.Baseline = nil
+	assert.Len(t, h.applyBaseline(ctx, "owner/repo", []report.Finding{old}, zerolog.Nop()), 1)
+}
+
+func TestFullRepoScanHandler_RebaselineDisabled(t *testing.T) {
+	h := &FullRepoScanHandler{}
+	_, err := h.Rebaseline(context.Background(), "owner", "repo", zerolog.Nop())
+	assert.Error(t, err)
+}
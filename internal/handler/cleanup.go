@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// cleanupTarget describes where a secret lives in history so it can be purged.
+type cleanupTarget struct {
+	Path        string
+	RuleID      string
+	Line        int
+	Blobs       []string // versions of the file that contain the secret, newest first
+	FirstCommit string   // oldest commit on the scanned branch containing the secret
+	LastCommit  string   // scanned head commit
+}
+
+// buildCleanupTargets locates, for each finding, the commit that introduced the secret and
+// every blob that contains it, by walking back through the history of the finding's file.
+func buildCleanupTargets(gitRepo *git.Repository, findings []report.Finding) ([]cleanupTarget, error) {
+	ref, err := gitRepo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get head reference: %w", err)
+	}
+	head := ref.Hash().String()
+
+	seen := make(map[string]bool)
+	var targets []cleanupTarget
+	for _, finding := range findings {
+		if finding.File == "" || finding.Secret == "" {
+			continue
+		}
+		key := finding.File + "\x00" + finding.Secret
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if len(targets) == constants.MaxCleanupTargets {
+			break
+		}
+
+		target := cleanupTarget{
+			Path:       finding.File,
+			RuleID:     finding.RuleID,
+			Line:       finding.StartLine,
+			LastCommit: head,
+		}
+		if err := traceSecretHistory(gitRepo, ref.Hash(), finding.Secret, &target); err != nil {
+			return nil, err
+		}
+		if target.FirstCommit == "" {
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// traceSecretHistory walks the commits touching target.Path from head backwards until the
+// file no longer contains the secret, recording the blobs and the introducing commit.
+func traceSecretHistory(gitRepo *git.Repository, head plumbing.Hash, secret string, target *cleanupTarget) error {
+	path := target.Path
+	commits, err := gitRepo.Log(&git.LogOptions{From: head, FileName: &path})
+	if err != nil {
+		return fmt.Errorf("failed to read history of %s: %w", path, err)
+	}
+	defer commits.Close()
+
+	seenBlobs := make(map[plumbing.Hash]bool)
+	err = commits.ForEach(func(commit *object.Commit) error {
+		file, err := commit.File(path)
+		if errors.Is(err, object.ErrFileNotFound) {
+			return storer.ErrStop
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s at %s: %w", path, commit.Hash, err)
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return fmt.Errorf("failed to read %s at %s: %w", path, commit.Hash, err)
+		}
+		if !strings.Contains(content, secret) {
+			return storer.ErrStop
+		}
+
+		target.FirstCommit = commit.Hash.String()
+		if !seenBlobs[file.Hash] {
+			seenBlobs[file.Hash] = true
+			target.Blobs = append(target.Blobs, file.Hash.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to trace history of %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// buildCleanupInstructions renders git filter-repo and BFG commands for each target.
+// Secret values are never included; the replacement file has to be filled in locally.
+func buildCleanupInstructions(catalog *messages.Catalog, targets []cleanupTarget) string {
+	if len(targets) == 0 {
+		return ""
+	}
+
+	body := catalog.Text(messages.IssueCleanupIntro)
+
+	for _, target := range targets {
+		blobsFile := "gitguard-blobs.txt"
+		body += fmt.Sprintf("\n#### `%s` (line %d, %s)\n\n", target.Path, target.Line, target.RuleID)
+		body += fmt.Sprintf("- **Commit range:** `%s`..`%s` (introduced in the first, still present in the second)\n",
+			shortSHA(target.FirstCommit), shortSHA(target.LastCommit))
+		body += fmt.Sprintf("- **Blobs:** %s\n\n", "`"+strings.Join(target.Blobs, "`, `")+"`")
+
+		body += "```bash\n"
+		body += fmt.Sprintf("# Review the affected commits\ngit log --oneline --boundary %s..%s -- %s\n",
+			target.FirstCommit, target.LastCommit, shellQuote(target.Path))
+		body += "\n# Option 1: drop every version of the file containing the secret\n"
+		body += "# (commit a clean version of the file first; BFG leaves the current HEAD untouched)\n"
+		body += fmt.Sprintf("printf '%%s\\n' %s > %s\n", strings.Join(target.Blobs, " "), blobsFile)
+		body += fmt.Sprintf("git filter-repo --strip-blobs-with-ids %s\n", blobsFile)
+		body += fmt.Sprintf("# or: bfg --strip-blobs-with-ids %s\n", blobsFile)
+		body += "\n# Option 2: keep the file and replace the secret value everywhere\n"
+		body += "echo '<paste the secret here>==>" + constants.RedactionPlaceholder + "' > gitguard-replacements.txt\n"
+		body += "git filter-repo --replace-text gitguard-replacements.txt\n"
+		body += "# or: bfg --replace-text gitguard-replacements.txt\n"
+		body += "```\n"
+	}
+
+	return body
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
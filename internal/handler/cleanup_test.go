@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestBuildCleanupTargets(t *testing.T) {
+	repo := newTestRepository(t, map[string]string{"app.env": "DEBUG=true\n"})
+	introduced := commitFiles(t, repo, map[string]string{"app.env": "API_KEY=supersecretvalue123\n"})
+	head := commitFiles(t, repo, map[string]string{"app.env": "API_KEY=supersecretvalue123\nDEBUG=false\n"})
+
+	findings := []report.Finding{
+		{File: "app.env", Secret: "supersecretvalue123", RuleID: "generic-api-key", StartLine: 1},
+		{File: "app.env", Secret: "supersecretvalue123", RuleID: "generic-api-key", StartLine: 1},
+	}
+
+	targets, err := buildCleanupTargets(repo, findings)
+	require.NoError(t, err)
+	require.Len(t, targets, 1, "Should deduplicate findings of the same secret in the same file")
+
+	target := targets[0]
+	assert.Equal(t, "app.env", target.Path)
+	assert.Equal(t, introduced, target.FirstCommit, "Should find the commit that introduced the secret")
+	assert.Equal(t, head, target.LastCommit)
+	assert.Len(t, target.Blobs, 2, "Should list every file version containing the secret")
+}
+
+func TestBuildCleanupTargets_SecretInRootCommit(t *testing.T) {
+	repo := newTestRepository(t, map[string]string{"key.pem": "PRIVATEKEYMATERIAL\n"})
+
+	targets, err := buildCleanupTargets(repo, []report.Finding{{File: "key.pem", Secret: "PRIVATEKEYMATERIAL"}})
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, targets[0].LastCommit, targets[0].FirstCommit)
+	assert.Len(t, targets[0].Blobs, 1)
+}
+
+func TestBuildCleanupInstructions(t *testing.T) {
+	assert.Empty(t, buildCleanupInstructions(nil, nil))
+
+	body := buildCleanupInstructions(nil, []cleanupTarget{{
+		Path:        "it's.env",
+		RuleID:      "generic-api-key",
+		Line:        4,
+		Blobs:       []string{"1111111111111111111111111111111111111111"},
+		FirstCommit: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		LastCommit:  "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+	}})
+
+	assert.Contains(t, body, "### History Cleanup")
+	assert.Contains(t, body, "`aaaaaaaaaaaa`..`bbbbbbbbbbbb`")
+	assert.Contains(t, body, "git filter-repo --strip-blobs-with-ids")
+	assert.Contains(t, body, "bfg --strip-blobs-with-ids")
+	assert.Contains(t, body, "1111111111111111111111111111111111111111")
+	assert.Contains(t, body, `'it'\''s.env'`, "Should shell-quote paths")
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// CloneOptions adjusts how full scans reach the git server, e.g. behind a proxy or on GitHub
+// Enterprise Server with different internal and external URLs. The zero value clones from
+// the URL GitHub reports.
+type CloneOptions struct {
+	// BaseURL, when set, replaces the scheme, host and any path prefix of clone URLs, keeping
+	// the repository path, e.g. "https://ghes.internal:8443/".
+	BaseURL string
+	// Rewrites replace clone URL prefixes after BaseURL is applied, like git's
+	// url.<base>.insteadOf. The longest matching prefix wins.
+	Rewrites map[string]string
+	// ProxyURL, when set, is the HTTP proxy used for clones and LFS downloads.
+	ProxyURL string
+}
+
+// URL returns the URL to clone from for the clone URL GitHub reports.
+func (o CloneOptions) URL(cloneURL string) (string, error) {
+	if o.BaseURL != "" {
+		base, err := url.Parse(o.BaseURL)
+		if err != nil || base.Host == "" {
+			return "", fmt.Errorf("invalid clone base URL %q", o.BaseURL)
+		}
+		original, err := url.Parse(cloneURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid clone URL: %w", err)
+		}
+		base.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(original.Path, "/")
+		cloneURL = base.String()
+	}
+
+	var from string
+	for prefix := range o.Rewrites {
+		if strings.HasPrefix(cloneURL, prefix) && len(prefix) > len(from) {
+			from = prefix
+		}
+	}
+	if from != "" {
+		cloneURL = o.Rewrites[from] + strings.TrimPrefix(cloneURL, from)
+	}
+	return cloneURL, nil
+}
+
+// proxy returns the go-git proxy options, empty without a proxy.
+func (o CloneOptions) proxy() transport.ProxyOptions {
+	return transport.ProxyOptions{URL: o.ProxyURL}
+}
+
+// transport returns an HTTP transport using the proxy, for LFS downloads, or nil without a
+// proxy.
+func (o CloneOptions) transport() (*http.Transport, error) {
+	if o.ProxyURL == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(o.ProxyURL)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid clone proxy URL %q", o.ProxyURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	return transport, nil
+}
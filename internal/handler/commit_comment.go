@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// commentOnCommit posts the findings of a commit, masked, and the steps to remediate them as
+// a comment on the commit, unless it already has one: a commit pushed to several branches is
+// commented on once. Failures are logged and never fail the scan.
+func commentOnCommit(
+	ctx context.Context,
+	repos ReposService,
+	owner, repo, sha string,
+	findings []report.Finding,
+	classifier *severity.Classifier,
+	redaction *redact.Policy,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) {
+	comments, _, err := repos.ListCommitComments(ctx, owner, repo, sha, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgCommitCommentFailed)
+		return
+	}
+	for _, comment := range comments {
+		if strings.HasPrefix(comment.GetBody(), constants.CommitCommentMarker) {
+			logger.Debug().Int64("comment_id", comment.GetID()).Msg(constants.LogMsgCommitCommentExists)
+			return
+		}
+	}
+
+	body := commitCommentBody(catalog, owner+"/"+repo, findings, classifier, redaction)
+	comment, _, err := repos.CreateComment(ctx, owner, repo, sha, &github.RepositoryComment{Body: &body})
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgCommitCommentFailed)
+		return
+	}
+	logger.Info().
+		Int64("comment_id", comment.GetID()).
+		Int("findings", len(findings)).
+		Msg(constants.LogMsgCommitCommented)
+}
+
+// commitCommentBody describes the findings of a commit, with their secrets only as redaction
+// allows, and how to remediate them.
+func commitCommentBody(
+	catalog *messages.Catalog,
+	repository string,
+	findings []report.Finding,
+	classifier *severity.Classifier,
+	redaction *redact.Policy,
+) string {
+	body := constants.CommitCommentMarker + catalog.Format(messages.CommitCommentHeading, len(findings))
+	body += findingsSummary(catalog, classifier.Max(findings), findings) + "\n"
+	details := sortedDetails(repository, findings, classifier, redaction)
+	body += findingsTable(catalog, details, messages.CommitCommentMore)
+	return body + catalog.Text(messages.CommitCommentActions)
+}
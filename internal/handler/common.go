@@ -1,30 +1,120 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v72/github"
 	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/omercnet/gitguard/internal/logging"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/pii"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/policy"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/workflow"
 	"github.com/palantir/go-githubapp/githubapp"
-	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/rs/zerolog"
 	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
 )
 
 // initializeDetector creates a new gitleaks detector with default configuration.
 func initializeDetector() (*detect.Detector, error) {
-	viperConfig := config.ViperConfig{
-		Extend: config.Extend{
-			UseDefault: true,
-		},
-	}
-	cfg, err := viperConfig.Translate()
+	cfg, err := detector.DefaultConfig()
 	if err != nil {
-		return nil, fmt.Errorf(constants.ErrCreateGitleaksConfig, err)
+		return nil, err
 	}
 	return detect.NewDetector(cfg), nil
 }
 
+// defaultDetector is built once, on first use, and shared by the handlers without a factory.
+// Detectors are safe for concurrent scans.
+var defaultDetector = sync.OnceValues(initializeDetector)
+
+// loadDetector returns the factory's current detector or, when no factory is configured, the
+// detector injected in the handler or the default one. Handlers call it for each scan rather
+// than keeping the result, as concurrent deliveries share the handler.
+func loadDetector(ctx context.Context, factory *detector.Factory, injected *detect.Detector) (*detect.Detector, error) {
+	if factory != nil {
+		return factory.Detector(ctx)
+	}
+	if injected != nil {
+		return injected, nil
+	}
+	return defaultDetector()
+}
+
+// loadVersionedDetector returns the detector of loadDetector with the digest of the remote
+// rules it was built from, which keys cached scan results.
+func loadVersionedDetector(
+	ctx context.Context, factory *detector.Factory, injected *detect.Detector,
+) (*detect.Detector, string, error) {
+	if factory != nil {
+		return factory.VersionedDetector(ctx)
+	}
+	d, err := loadDetector(ctx, nil, injected)
+	return d, "", err
+}
+
+// rulesVersion returns the version of cached scan results: version, with the digest of the
+// detector's remote rules when it has any.
+func rulesVersion(version, digest string) string {
+	if digest == "" {
+		return version
+	}
+	return version + "+" + digest
+}
+
+// ScanFile scans the content r reads of the file name. r is streamed to d in chunks unless a
+// detector needs the whole content: workflow checks of workflow files, plugins when any are
+// configured and PII detection when personal. Only then is the content read into memory, up
+// to the scan size limit. Contents cut at the limit are logged; errors reading r are returned.
+func ScanFile(
+	ctx context.Context, d *detect.Detector, plugins plugin.Set, name string, r io.Reader, personal bool,
+) ([]report.Finding, error) {
+	if !workflow.IsWorkflow(name) && len(plugins) == 0 && !personal {
+		return detectReader(ctx, d, name, r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, constants.MaxScanFileBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	findings, _ := detectReader(ctx, d, name, strings.NewReader(content))
+	findings = append(findings, workflow.Detect(name, content)...)
+	findings = append(findings, plugins.ScanContent(name, content)...)
+	if personal {
+		findings = append(findings, pii.Detect(content)...)
+	}
+	return findings, nil
+}
+
+// detectReader scans r with d in chunks, logging contents cut at the scan size limit.
+func detectReader(ctx context.Context, d *detect.Detector, name string, r io.Reader) ([]report.Finding, error) {
+	findings, err := detector.DetectReader(d, r, constants.MaxScanFileBytes)
+	if errors.Is(err, detector.ErrContentTooLarge) {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("file", name).Msg(constants.LogMsgFileTooLarge)
+		return findings, nil
+	}
+	return findings, err
+}
+
 // parsePushEvent parses a GitHub push event from the webhook payload.
 func parsePushEvent(payload []byte) (*github.PushEvent, error) {
 	var event github.PushEvent
@@ -34,6 +124,65 @@ func parsePushEvent(payload []byte) (*github.PushEvent, error) {
 	return &event, nil
 }
 
+// pushPriority returns the priority of scanning a push: high for the default branch, which
+// others build on, and normal for other branches.
+func pushPriority(event *github.PushEvent) ratelimit.Priority {
+	if strings.TrimPrefix(event.GetRef(), constants.BranchRefPrefix) == event.GetRepo().GetDefaultBranch() {
+		return ratelimit.High
+	}
+	return ratelimit.Normal
+}
+
+// Prioritized is implemented by handlers whose deliveries wait for a scan slot, so that
+// deliveries can be refused while their scan could neither run nor be queued.
+type Prioritized interface {
+	// ScanPriority returns the installation and priority of the scan a delivery of eventType
+	// waits for, and false when it scans nothing.
+	ScanPriority(eventType string, payload []byte) (int64, ratelimit.Priority, bool)
+}
+
+// scannedPush parses a push a handler scans: one adding commits to a branch of a repository
+// in scope.
+func scannedPush(scope *reposcope.Scope, eventType string, payload []byte) (*github.PushEvent, bool) {
+	if eventType != constants.PushEventType {
+		return nil, false
+	}
+	event, err := parsePushEvent(payload)
+	if err != nil || len(event.Commits) == 0 || !strings.HasPrefix(event.GetRef(), constants.BranchRefPrefix) {
+		return nil, false
+	}
+	return event, inScope(scope, event, zerolog.Nop())
+}
+
+// schedule waits until scheduler admits a scan of the event's installation with priority and
+// returns the function releasing its slot.
+func schedule(
+	ctx context.Context, scheduler *ratelimit.Scheduler, event *github.PushEvent, priority ratelimit.Priority,
+	logger zerolog.Logger,
+) (func(), error) {
+	release, err := scheduler.Acquire(logger.WithContext(ctx), githubapp.GetInstallationIDFromEvent(event), priority)
+	if err != nil {
+		return nil, fmt.Errorf(constants.ErrScheduleScan, err)
+	}
+	return release, nil
+}
+
+// startJob records a scan as a running job of registry and returns ctx carrying its ID and a
+// function recording the scan's outcome. Scans that already run as a job, and scans without
+// a registry, are not recorded again.
+func startJob(
+	ctx context.Context, registry *jobs.Registry, job jobs.Job,
+) (context.Context, func(commit string, findings int, err error)) {
+	if registry == nil || jobs.ID(ctx) != "" {
+		return ctx, func(string, int, error) {}
+	}
+	job = registry.Create(job)
+	registry.Start(job.ID)
+	return jobs.WithID(ctx, job.ID), func(commit string, findings int, err error) {
+		registry.Finish(job.ID, commit, findings, err)
+	}
+}
+
 // createGitHubClient creates a GitHub client for the given push event.
 func createGitHubClient(clientCreator githubapp.ClientCreator, event *github.PushEvent) (*github.Client, error) {
 	installationID := githubapp.GetInstallationIDFromEvent(event)
@@ -43,3 +192,274 @@ func createGitHubClient(clientCreator githubapp.ClientCreator, event *github.Pus
 	}
 	return client, nil
 }
+
+// sendNotification delivers an event to the notifier, if any. Delivery failures are logged
+// and never fail the scan.
+func sendNotification(ctx context.Context, notifier notify.Notifier, event notify.Event, logger zerolog.Logger) {
+	if notifier == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if event.RequestID == "" {
+		event.RequestID = logging.RequestID(ctx)
+	}
+	if err := notifier.Notify(ctx, event); err != nil {
+		logger.Warn().Err(err).Str("event", event.Type).Msg(constants.LogMsgNotificationFailed)
+	}
+}
+
+// decide evaluates the policy rules against a completed scan. Evaluation errors are logged and
+// leave the failing rules out.
+func decide(rules *policy.Set, event notify.Event, logger zerolog.Logger) policy.Decision {
+	decision, err := rules.Decide(event)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgPolicyFailed)
+	}
+	if decision.Rule != "" {
+		logger.Debug().
+			Str("rule", decision.Rule).
+			Str("conclusion", decision.Conclusion).
+			Bool("issue", decision.Issue).
+			Bool("notify", decision.Notify).
+			Msg(constants.LogMsgPolicyMatched)
+	}
+	return decision
+}
+
+// isDryRun reports whether findings in a repository are reported without being enforced:
+// dry runs are enabled for it, or it is outside the enforcement rollout.
+func isDryRun(dryRun *reposcope.Setting, enforcement *reposcope.Rollout, fullName string) bool {
+	return dryRun.For(fullName) > 0 || !enforcement.Includes(fullName)
+}
+
+// reportOnly turns a decision into that of a dry run: a check with findings concludes neutral
+// instead of failing, and no issue is opened and no notification sent. Findings are still
+// tracked, logged and counted in metrics.
+func reportOnly(decision policy.Decision, event notify.Event, logger zerolog.Logger) policy.Decision {
+	if event.Findings.Total > 0 {
+		logger.Info().
+			Int("findings", event.Findings.Total).
+			Str("conclusion", decision.Conclusion).
+			Bool("issue", decision.Issue).
+			Bool("notify", decision.Notify).
+			Msg(constants.LogMsgDryRun)
+	}
+	return policy.Decision{Rule: decision.Rule, Conclusion: constants.ConclusionNeutral}
+}
+
+// checkSummary renders a check run summary with the operator's template, keeping the
+// built-in summary when the template fails.
+func checkSummary(
+	templates *messages.Templates, event notify.Event, summary string, logger zerolog.Logger,
+) string {
+	rendered, err := templates.CheckSummary(event, summary)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgTemplateFailed)
+	}
+	return rendered
+}
+
+// inScope reports whether the pushed repository is scanned, logging skipped repositories.
+func inScope(scope *reposcope.Scope, event *github.PushEvent, logger zerolog.Logger) bool {
+	repo := reposcope.Repository{
+		FullName: event.GetRepo().GetFullName(),
+		Archived: event.GetRepo().GetArchived(),
+		Fork:     event.GetRepo().GetFork(),
+	}
+	return repositoryInScope(scope, githubapp.GetInstallationIDFromEvent(event), repo, logger)
+}
+
+// repositoryInScope reports whether scope allows scanning repo, logging the repositories it
+// skips.
+func repositoryInScope(
+	scope *reposcope.Scope, installationID int64, repo reposcope.Repository, logger zerolog.Logger,
+) bool {
+	if scope.Allowed(installationID, repo) {
+		return true
+	}
+	logger.Debug().
+		Str("repo", repo.FullName).
+		Bool("archived", repo.Archived).
+		Bool("fork", repo.Fork).
+		Msg(constants.LogMsgSkippingOutOfScope)
+	return false
+}
+
+// externalID returns the check run external ID: the ID of the scan job, so a check run can be
+// traced to its status at /api/v1/scans/{id}, or else of the request being served; nil
+// outside both.
+func externalID(ctx context.Context) *string {
+	if id := jobs.ID(ctx); id != "" {
+		return github.Ptr(id)
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		return github.Ptr(id)
+	}
+	return nil
+}
+
+// checkRunTitle returns the check run title for a scan with findings.
+func checkRunTitle(catalog *messages.Catalog, conclusion string) string {
+	switch conclusion {
+	case constants.ConclusionNeutral:
+		return catalog.Text(messages.CheckRunTitleNeutral)
+	case constants.ConclusionActionRequired:
+		return catalog.Text(messages.CheckRunTitleActionRequired)
+	default:
+		return catalog.Text(messages.CheckRunTitleSecrets)
+	}
+}
+
+// findingsSummary describes the highest severity and the types of secrets found, without
+// exposing the secrets themselves.
+func findingsSummary(catalog *messages.Catalog, highest severity.Level, findings []report.Finding) string {
+	summary := catalog.Format(messages.CheckRunSummarySeverity, highest)
+
+	leakTypes := make(map[string]bool)
+	for _, finding := range findings {
+		if finding.RuleID != "" {
+			leakTypes[finding.RuleID] = true
+		}
+	}
+
+	workflows := 0
+	for _, finding := range findings {
+		if workflow.IsWorkflowFinding(finding) {
+			workflows++
+		}
+	}
+	if workflows > 0 {
+		summary += catalog.Format(messages.CheckRunSummaryWorkflows, workflows)
+	}
+
+	if len(leakTypes) > 0 {
+		summary += catalog.Text(messages.CheckRunSummaryTypes)
+		for leakType := range leakTypes {
+			summary += "- " + leakType + "\n"
+		}
+	}
+	return summary
+}
+
+// checkRunReport is the machine-readable report of a scan in the check run text.
+type checkRunReport struct {
+	Version   int              `json:"version"`
+	Total     int              `json:"total"`
+	Truncated bool             `json:"truncated,omitempty"`
+	Findings  []notify.Finding `json:"findings"`
+}
+
+// checkRunText renders the details of findings as a markdown table followed by a JSON report
+// in a fenced code block, for automation reading check runs. Secrets are only included as
+// their details carry them, redacted; the table and report are cut to fit GitHub's output
+// size limit.
+func checkRunText(catalog *messages.Catalog, details []notify.Finding) string {
+	details = sortDetails(slices.Clone(details))
+	text := findingsTable(catalog, details, messages.CheckRunTableMore)
+	text += catalog.Text(messages.CheckRunReportHeading)
+
+	// Drop findings from the report until it fits; the total still counts all of them.
+	budget := constants.CheckRunTextMaxChars - len(text)
+	listed := details
+	for {
+		data, err := json.MarshalIndent(checkRunReport{
+			Version:   constants.CheckRunReportVersion,
+			Total:     len(details),
+			Truncated: len(listed) < len(details),
+			Findings:  listed,
+		}, "", "  ")
+		if err != nil {
+			return text
+		}
+		block := "```json\n" + string(data) + "\n```\n"
+		if len(block) <= budget || len(listed) == 0 {
+			return text + block
+		}
+		listed = listed[:len(listed)/2]
+	}
+}
+
+// parseCheckRunReport reads the machine-readable report back from a check run text written
+// by checkRunText. It reports false when the text has no report.
+func parseCheckRunReport(text string) (checkRunReport, bool) {
+	var parsed checkRunReport
+	start := strings.LastIndex(text, "```json\n")
+	if start < 0 {
+		return parsed, false
+	}
+	block, _, _ := strings.Cut(text[start+len("```json\n"):], "\n```")
+	if err := json.Unmarshal([]byte(block), &parsed); err != nil {
+		return parsed, false
+	}
+	return parsed, true
+}
+
+// sortedDetails returns the details of findings sorted by file, line and rule.
+func sortedDetails(
+	repository string, findings []report.Finding, classifier *severity.Classifier, redaction *redact.Policy,
+) []notify.Finding {
+	return sortDetails(notify.Details(repository, findings, classifier, redaction))
+}
+
+// sortDetails sorts details by file, line and rule and returns them.
+func sortDetails(details []notify.Finding) []notify.Finding {
+	sort.SliceStable(details, func(i, j int) bool {
+		if details[i].File != details[j].File {
+			return details[i].File < details[j].File
+		}
+		if details[i].Line != details[j].Line {
+			return details[i].Line < details[j].Line
+		}
+		return details[i].RuleID < details[j].RuleID
+	})
+	return details
+}
+
+// findingsTable renders at most constants.CheckRunTableMax findings as a markdown table,
+// followed by the more message with the number left out. New findings of aged details are
+// marked next to their severity, and redacted secrets are listed in their own column.
+func findingsTable(catalog *messages.Catalog, details []notify.Finding, more messages.Key) string {
+	secrets := slices.ContainsFunc(details, func(finding notify.Finding) bool { return finding.Secret != "" })
+	table := catalog.Text(messages.CheckRunTableHeader)
+	if secrets {
+		table = catalog.Text(messages.CheckRunTableSecret)
+	}
+	for i, finding := range details {
+		if i == constants.CheckRunTableMax {
+			table += catalog.Format(more, len(details)-i)
+			break
+		}
+		level := finding.Severity
+		if finding.Age == notify.AgeNew {
+			level += catalog.Text(messages.CheckRunTableNew)
+		}
+		table += fmt.Sprintf("| `%s` | %d | %s | %s | `%s` |",
+			strings.ReplaceAll(finding.File, "|", "\\|"), finding.Line, finding.RuleID, level, finding.Fingerprint)
+		if secrets {
+			table += fmt.Sprintf(" `%s` |", strings.ReplaceAll(finding.Secret, "|", "\\|"))
+		}
+		table += "\n"
+	}
+	return table
+}
+
+// ageSummary counts the new and long-standing findings of an aged scan for its check run
+// summary, or returns an empty string when the scan was not aged.
+func ageSummary(catalog *messages.Catalog, findings notify.Summary, newWithin time.Duration) string {
+	if findings.New+findings.LongStanding == 0 {
+		return ""
+	}
+	days := int(newWithin / (24 * time.Hour))
+	return catalog.Format(messages.CheckRunSummaryAges, findings.New, findings.LongStanding, days)
+}
+
+// checkRunDetailsURL returns the details URL of a check run on sha, replacing "{repository}"
+// and "{sha}" in template, or nil when no template is configured.
+func checkRunDetailsURL(template, repository, sha string) *string {
+	if template == "" {
+		return nil
+	}
+	return github.Ptr(strings.NewReplacer("{repository}", repository, "{sha}", sha).Replace(template))
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/logging"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/rs/zerolog"
+)
+
+// dispatchPayload is the client_payload of the repository_dispatch event. GitHub allows at most
+// ten top-level properties.
+type dispatchPayload struct {
+	Scan       string           `json:"scan"`
+	Ref        string           `json:"ref,omitempty"`
+	Commit     string           `json:"commit,omitempty"`
+	Conclusion string           `json:"conclusion,omitempty"`
+	Findings   notify.Summary   `json:"findings"`
+	Details    []notify.Finding `json:"details,omitempty"`
+	Truncated  bool             `json:"truncated,omitempty"`
+	Links      notify.Links     `json:"links"`
+	RequestID  string           `json:"request_id,omitempty"`
+}
+
+// dispatchScan fires a repository_dispatch event of type constants.DispatchEventType on the
+// scanned repository, when enabled for it and the scan found secrets, so the repository's own
+// workflows can follow up, e.g. by rotating the credentials. The payload summarizes the
+// findings without their secrets. Failures are logged and never fail the scan.
+func dispatchScan(
+	ctx context.Context,
+	client *github.Client,
+	enabled *reposcope.Setting,
+	owner, repo string,
+	event notify.Event,
+	logger zerolog.Logger,
+) {
+	if event.Findings.Total == 0 || enabled.For(event.Repository) == 0 {
+		return
+	}
+	payload := dispatchPayload{
+		Scan:       event.Scan,
+		Ref:        event.Ref,
+		Commit:     event.Commit,
+		Conclusion: event.Conclusion,
+		Findings:   event.Findings,
+		Details:    event.Details,
+		Links:      event.Links,
+		RequestID:  event.RequestID,
+	}
+	if payload.RequestID == "" {
+		payload.RequestID = logging.RequestID(ctx)
+	}
+	if len(payload.Details) > constants.DispatchMaxDetails {
+		payload.Details, payload.Truncated = payload.Details[:constants.DispatchMaxDetails], true
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgDispatchFailed)
+		return
+	}
+	raw := json.RawMessage(data)
+	opts := github.DispatchRequestOptions{EventType: constants.DispatchEventType, ClientPayload: &raw}
+	if _, _, err := client.Repositories.Dispatch(ctx, owner, repo, opts); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgDispatchFailed)
+		return
+	}
+	logger.Info().Int("findings", event.Findings.Total).Msg(constants.LogMsgDispatched)
+}
@@ -2,15 +2,41 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/cache"
 	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/filenames"
+	"github.com/omercnet/gitguard/internal/ignore"
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/omercnet/gitguard/internal/labels"
+	"github.com/omercnet/gitguard/internal/lfs"
+	"github.com/omercnet/gitguard/internal/logging"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/pathrules"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/policy"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
 	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/rs/zerolog"
 	"github.com/zricethezav/gitleaks/v8/detect"
@@ -29,17 +55,111 @@ var (
 		".woff", ".woff2", ".ttf", ".eot",
 	}
 
-	// skipPaths contains directory paths that should be skipped during scanning.
-	skipPaths = []string{
-		"node_modules/", "vendor/", ".git/", "dist/", "build/",
-		"target/", "bin/", "obj/", ".gradle/", "__pycache__/",
+	// skipDirectories are the names of the dependency and build directories skipped during
+	// scanning, at any depth and in any case.
+	skipDirectories = []string{
+		"node_modules", "vendor", ".git", "dist", "build",
+		"target", "bin", "obj", ".gradle", "__pycache__",
 	}
 )
 
 // FullRepoScanHandler handles push events to default branch for full repository scanning.
 type FullRepoScanHandler struct {
 	githubapp.ClientCreator
+	// Remediation opens a pull request that redacts detected secrets in addition to the issue.
+	Remediation bool
+	// IssueGrouping groups findings into security issues: constants.IssueGroupingSingle, the
+	// default, opens one per repository, IssueGroupingRule one per rule and
+	// IssueGroupingDirectory one per top-level directory.
+	IssueGrouping string
+	// Labels are the labels of security issues, created in repositories that lack them; nil
+	// uses labels.Default().
+	Labels labels.Set
+	// SLA, when set, gives security issues the due date of their highest severity: a comment
+	// or, with SLAMilestones, a milestone due then.
+	SLA           severity.SLA
+	SLAMilestones bool
+	// Severity classifies findings; nil rates every finding high.
+	Severity *severity.Classifier
+	// Policy maps the highest severity of a scan to the full scan check run conclusion.
+	Policy severity.Policy
+	// Notifier, when set, receives an event for every completed scan.
+	Notifier notify.Notifier
+	// Dispatch, when set, fires a repository_dispatch event on the repositories it is non-zero
+	// for when a scan finds secrets and notifications are not suppressed.
+	Dispatch *reposcope.Setting
+	// Alerts, when set, pages on critical findings in public repositories.
+	Alerts *notify.AlertManager
+	// Overrides, when set, drops findings disabled for their file path.
+	Overrides *pathrules.Set
+	// Baseline, when set, stores each repository's grandfathered findings, which no longer
+	// fail checks or open issues.
+	Baseline store.Store
+	// Findings, when set, tracks whether each finding is open, resolved or suppressed.
+	// Suppressed findings no longer fail checks or open issues.
+	Findings store.FindingStore
+	// NewWithin, when positive, marks findings first seen within it as new and the others as
+	// long-standing, in check runs, notifications and policy rules.
+	NewWithin time.Duration
+	// Deduplicate, with Findings set, tracks the branches each finding is detected on, lists
+	// the other branches of findings in security issues and notifies scans only when they
+	// detect a finding for the first time or change the lifecycle state of one.
+	Deduplicate bool
+	// NativeAlerts leaves findings GitHub secret scanning already alerted on out of checks,
+	// issues and notifications, and reports those it missed on the check run.
+	NativeAlerts bool
+	// Detectors, when set, provides detectors built from the configured rule packs.
+	Detectors *detector.Factory
+	// Plugins are additional detectors run on every scanned file.
+	Plugins plugin.Set
+	// Filenames, when set, reports files whose name is sensitive, such as .env files and
+	// private keys, regardless of their content.
+	Filenames *filenames.Rules
+	// Scope, when set, limits scans to the repositories it allows.
+	Scope *reposcope.Scope
+	// LFSMaxBytes, when positive, downloads and scans Git LFS objects up to this size.
+	// Larger objects, and all objects when zero, are reported as not scanned.
+	LFSMaxBytes int64
+	// Limits skip full scans of repositories too large to clone within the scan timeout,
+	// reported on a neutral check run.
+	Limits ScanLimits
+	// ScanCache, when set, caches the scans of the root tree and top-level directories by
+	// their hash, so files of unchanged trees are not read and scanned again.
+	ScanCache *cache.ScanCache
+	// RulesVersion identifies the build, rules and path overrides in scan cache keys, with
+	// the digest of the detector's rule packs added.
+	RulesVersion string
+	// Clone adjusts the URL and proxy repositories are cloned through.
+	Clone CloneOptions
+	// DetailsURL, when set, is linked from the full scan check run. "{repository}" and "{sha}"
+	// are replaced with the repository's full name and the commit.
+	DetailsURL string
+	// PolicyRules, when set, can override the check run conclusion and suppress the issue
+	// and notifications.
+	PolicyRules *policy.Set
+	// DryRun, when set, reports findings without enforcing them in the repositories it is
+	// non-zero for: check runs never fail, no issue or remediation pull request is opened and
+	// no notification or page is sent.
+	DryRun *reposcope.Setting
+	// Enforcement, when set, limits enforcement to the repositories in the rollout; the others
+	// are scanned as dry runs.
+	Enforcement *reposcope.Rollout
+	// Messages, when set, selects the locale of each installation's check runs and issues.
+	Messages *messages.Locales
+	// Templates, when set, replace the built-in check run summaries and the issue body.
+	Templates *messages.Templates
+	// Redaction, when set, includes secrets in check runs, issues, notifications and tracked
+	// findings as a masked preview or a hash. Raw secrets are never reported or stored.
+	Redaction *redact.Policy
+	// Scheduler, when set, limits concurrent scans per installation. Full scans have low
+	// priority and wait while an installation's rate limit budget is low.
+	Scheduler *ratelimit.Scheduler
+	// Jobs, when set, records every full scan as a job whose ID is the check run's external ID.
+	Jobs *jobs.Registry
+	// detector, when set and Detectors is not, replaces the default detector.
 	detector *detect.Detector
+	// services, when set, replaces the GitHub APIs scans are reported through.
+	services func(*github.Client) services
 }
 
 // Handles returns the list of event types this handler can process.
@@ -47,6 +167,16 @@ func (h *FullRepoScanHandler) Handles() []string {
 	return []string{constants.PushEventType}
 }
 
+// ScanPriority returns the installation and priority of the full scan of a push to the
+// default branch.
+func (h *FullRepoScanHandler) ScanPriority(eventType string, payload []byte) (int64, ratelimit.Priority, bool) {
+	event, ok := scannedPush(h.Scope, eventType, payload)
+	if !ok || strings.TrimPrefix(event.GetRef(), constants.BranchRefPrefix) != event.GetRepo().GetDefaultBranch() {
+		return 0, 0, false
+	}
+	return githubapp.GetInstallationIDFromEvent(event), ratelimit.Low, true
+}
+
 // Handle processes push events to default branch for full repository scanning.
 func (h *FullRepoScanHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
 	logger := zerolog.Ctx(ctx).With().
@@ -55,15 +185,6 @@ func (h *FullRepoScanHandler) Handle(ctx context.Context, eventType, deliveryID
 		Str("handler", "full_repo_scan").
 		Logger()
 
-	// Initialize detector if needed
-	if h.detector == nil {
-		detector, err := initializeDetector()
-		if err != nil {
-			return err
-		}
-		h.detector = detector
-	}
-
 	// Parse push event
 	event, err := parsePushEvent(payload)
 	if err != nil {
@@ -75,6 +196,9 @@ func (h *FullRepoScanHandler) Handle(ctx context.Context, eventType, deliveryID
 		logger.Debug().Msg(constants.LogMsgSkippingEvent)
 		return nil
 	}
+	if !inScope(h.Scope, event, logger) {
+		return nil
+	}
 
 	// Check if this is a push to the default branch
 	defaultBranch := event.GetRepo().GetDefaultBranch()
@@ -88,6 +212,12 @@ func (h *FullRepoScanHandler) Handle(ctx context.Context, eventType, deliveryID
 		return nil
 	}
 
+	release, err := schedule(ctx, h.Scheduler, event, ratelimit.Low, logger)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Create GitHub client
 	client, err := createGitHubClient(h.ClientCreator, event)
 	if err != nil {
@@ -106,7 +236,14 @@ func (h *FullRepoScanHandler) Handle(ctx context.Context, eventType, deliveryID
 	ctx, cancel := context.WithTimeout(ctx, constants.FullScanTimeout)
 	defer cancel()
 
-	err = h.scanFullRepository(ctx, client, owner, repo, event, logger)
+	target := fullScanTarget{
+		Installation: githubapp.GetInstallationIDFromEvent(event),
+		Owner:        owner,
+		Repo:         repo,
+		Ref:          event.GetRef(),
+		Commit:       event.GetAfter(),
+	}
+	_, err = h.scanFullRepository(ctx, client, target, logger)
 	if err != nil {
 		// Check for timeout error and return a more specific error message
 		if ctx.Err() == context.DeadlineExceeded {
@@ -118,32 +255,353 @@ func (h *FullRepoScanHandler) Handle(ctx context.Context, eventType, deliveryID
 	return nil
 }
 
+// fullScanTarget is the default branch commit a full scan reports on.
+type fullScanTarget struct {
+	Installation int64
+	Owner        string
+	Repo         string
+	Ref          string
+	Commit       string
+	// Checkout scans Commit rather than the head of the default branch, for scans of other
+	// refs. Remediation pull requests are then not opened.
+	Checkout bool
+}
+
+// scanFullRepository scans the default branch of target's repository and reports on its
+// commit. It returns the number of findings reported, baselined ones excluded.
 func (h *FullRepoScanHandler) scanFullRepository(
 	ctx context.Context,
 	client *github.Client,
-	owner, repo string,
-	event *github.PushEvent,
+	target fullScanTarget,
 	logger zerolog.Logger,
-) error {
+) (count int, err error) {
+	owner, repo := target.Owner, target.Repo
+	ctx, finish := startJob(ctx, h.Jobs, jobs.Job{Repository: owner + "/" + repo, Ref: target.Ref, Commit: target.Commit})
+	defer func() { finish(target.Commit, count, err) }()
+
 	// Get repository details for clone URL and token
 	repository, _, err := client.Repositories.Get(ctx, owner, repo)
 	if err != nil {
-		return fmt.Errorf(constants.ErrGetDefaultBranch, err)
+		return 0, fmt.Errorf(constants.ErrGetDefaultBranch, err)
 	}
 
 	cloneURL := repository.GetCloneURL()
 	if cloneURL == "" {
-		return fmt.Errorf(constants.ErrInvalidCloneURL)
+		return 0, fmt.Errorf(constants.ErrInvalidCloneURL)
+	}
+
+	detailsURL := checkRunDetailsURL(h.DetailsURL, repository.GetFullName(), target.Commit)
+	catalog := h.Messages.For(target.Installation)
+	svc := servicesFor(client, h.services)
+	check := startFullScanCheck(ctx, svc.checks, owner, repo, target.Commit, detailsURL, catalog, logger)
+	if summary := h.Limits.exceeded(ctx, client, repository, target.Commit, catalog, logger); summary != "" {
+		check.complete(ctx, constants.ConclusionNeutral, catalog.Text(messages.FullScanTitleTooLarge), summary, "")
+		return 0, nil
+	}
+
+	gitRepo, lfsClient, revoke, err := h.cloneRepository(ctx, client, target.Installation, repository, logger)
+	if err != nil {
+		check.fail(ctx)
+		return 0, err
+	}
+	if target.Checkout {
+		if err := checkoutCommit(gitRepo, target.Commit); err != nil {
+			revoke()
+			check.fail(ctx)
+			return 0, err
+		}
+	}
+
+	// Scan repository for secrets
+	names := h.Filenames.For(repository.GetFullName())
+	scan, err := h.scanGitRepository(logger.WithContext(ctx), gitRepo, names, lfsClient, check.progress(ctx))
+	revoke()
+	if err != nil {
+		check.fail(ctx)
+		return 0, fmt.Errorf(constants.ErrScanRepository, err)
+	}
+	findings := scan.Findings
+
+	logger.Info().
+		Int("findings", len(findings)).
+		Int("submodules", len(scan.Submodules)).
+		Int("lfs_pointers", len(scan.LFSPointers)).
+		Int("skipped", len(scan.Skipped)).
+		Msg(constants.LogMsgFullScanComplete)
+
+	findings, transitions := trackFindings(ctx, h.Findings, repository.GetFullName(), findings, h.Redaction, logger)
+
+	dryRun := isDryRun(h.DryRun, h.Enforcement, repository.GetFullName())
+	if !dryRun {
+		// Pages are not grandfathered: a critical secret in a public repository stays exposed.
+		h.reconcileAlerts(ctx, repository, target.Commit, findings, logger)
+	}
+	findings = h.applyBaseline(ctx, repository.GetFullName(), findings, logger)
+	findings, correlation := h.correlateNativeAlerts(ctx, client, owner, repo, findings, logger)
+	attributeFindings(ctx, client, owner, repo, gitRepo, findings, logger)
+	notes := scan.notScanned(catalog) + correlation.summary(catalog)
+
+	notification := notify.Event{
+		Type:          notify.EventScanCompleted,
+		Scan:          notify.ScanFullRepository,
+		Repository:    repository.GetFullName(),
+		Private:       repository.GetPrivate(),
+		Installation:  target.Installation,
+		Ref:           target.Ref,
+		DefaultBranch: repository.GetDefaultBranch(),
+		Commit:        target.Commit,
+		Findings:      notify.Summarize(findings, h.Severity),
+		Details:       notify.Details(repository.GetFullName(), findings, h.Severity, h.Redaction),
+		Transitions:   transitions,
+		Links:         notify.Links{Repository: repository.GetHTMLURL()},
+	}
+	ageFindings(ctx, h.Findings, &notification, findings, h.NewWithin, logger)
+	repeated := h.Deduplicate && recordOccurrences(ctx, h.Findings, &notification, logger) && len(transitions) == 0
+	decision := decide(h.PolicyRules, notification, logger)
+	if dryRun {
+		decision = reportOnly(decision, notification, logger)
+	}
+
+	if len(findings) == 0 {
+		logger.Info().Msg(constants.LogMsgNoSecretsFound)
+		summary := catalog.Text(messages.FullScanSummaryClean) + notes
+		clean := notification
+		clean.Conclusion = constants.ConclusionSuccess
+		summary = checkSummary(h.Templates, clean, summary, logger)
+		check.complete(ctx, constants.ConclusionSuccess, catalog.Text(messages.CheckRunTitleClean), summary, "")
+		if decision.Notify {
+			sendNotification(ctx, h.Notifier, notification, logger)
+		}
+		return 0, nil
+	}
+
+	// Create issues if secrets are found
+	var issues []*github.Issue
+	if decision.Issue {
+		issues, err = h.createSecurityIssues(ctx, svc.issues, owner, repo, gitRepo, findings, notification, catalog, logger)
+	}
+	var issue *github.Issue
+	if len(issues) > 0 {
+		issue = issues[0]
+	}
+	notification.Links.Issue = issue.GetHTMLURL()
+	h.completeFullScanCheck(
+		ctx, check, catalog, notification, findings, issues, decision.Conclusion, notes, logger,
+	)
+	if err != nil {
+		return len(findings), err
+	}
+
+	switch {
+	case decision.Notify && repeated:
+		logger.Info().Int("findings", len(findings)).Msg(constants.LogMsgFindingsRepeated)
+	case decision.Notify:
+		sendNotification(ctx, h.Notifier, notification, logger)
+		dispatchScan(ctx, client, h.Dispatch, owner, repo, notification, logger)
+	}
+
+	if h.Remediation && !target.Checkout && !dryRun {
+		return len(findings), h.openRemediationPR(
+			ctx, client, owner, repo, repository.GetDefaultBranch(), gitRepo, findings, issue.GetNumber(), catalog, logger,
+		)
+	}
+
+	return len(findings), nil
+}
+
+// checkoutCommit points the HEAD of a cloned repository at commit.
+func checkoutCommit(gitRepo *git.Repository, commit string) error {
+	hash := plumbing.NewHash(commit)
+	if _, err := gitRepo.CommitObject(hash); err != nil {
+		return fmt.Errorf(constants.ErrCommitNotCloned, commit, err)
+	}
+	return gitRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hash))
+}
+
+// ScanRef runs a full scan of a repository at ref, a branch, tag or commit SHA, reported on
+// the commit ref resolves to like the full scan of a push. It returns the commit and the
+// number of findings reported.
+func (h *FullRepoScanHandler) ScanRef(
+	ctx context.Context, owner, repo, ref string, logger zerolog.Logger,
+) (string, int, error) {
+	client, installationID, err := h.repositoryClient(ctx, owner, repo)
+	if err != nil {
+		return "", 0, err
+	}
+	commit, _, err := client.Repositories.GetCommitSHA1(ctx, owner, repo, ref, "")
+	if err != nil {
+		return "", 0, fmt.Errorf(constants.ErrResolveRef, ref, err)
+	}
+	target := fullScanTarget{
+		Installation: installationID,
+		Owner:        owner,
+		Repo:         repo,
+		Ref:          ref,
+		Commit:       commit,
+		Checkout:     true,
+	}
+	count, err := h.scanTarget(ctx, client, target, logger)
+	return commit, count, err
+}
+
+// repositoryClient returns a client of the App's installation on a repository and the
+// installation ID, for scans that are not triggered by a webhook.
+func (h *FullRepoScanHandler) repositoryClient(
+	ctx context.Context, owner, repo string,
+) (*github.Client, int64, error) {
+	appClient, err := h.NewAppClient()
+	if err != nil {
+		return nil, 0, fmt.Errorf(constants.ErrCreateGitHubClient, err)
+	}
+	installation, _, err := appClient.Apps.FindRepositoryInstallation(ctx, owner, repo)
+	if err != nil {
+		return nil, 0, fmt.Errorf(constants.ErrFindInstallation, owner, repo, err)
+	}
+	client, err := h.NewInstallationClient(installation.GetID())
+	if err != nil {
+		return nil, 0, fmt.Errorf(constants.ErrCreateGitHubClient, err)
+	}
+	return client, installation.GetID(), nil
+}
+
+// scanTarget waits for a scan slot of the target's installation and scans it within the full
+// scan timeout.
+func (h *FullRepoScanHandler) scanTarget(
+	ctx context.Context, client *github.Client, target fullScanTarget, logger zerolog.Logger,
+) (int, error) {
+	release, err := h.Scheduler.Acquire(logger.WithContext(ctx), target.Installation, ratelimit.Low)
+	if err != nil {
+		return 0, fmt.Errorf(constants.ErrScheduleScan, err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, constants.FullScanTimeout)
+	defer cancel()
+
+	count, err := h.scanFullRepository(ctx, client, target, logger)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return count, fmt.Errorf(constants.ErrScanTimeout)
+	}
+	return count, err
+}
+
+// completeFullScanCheck concludes the full scan check run following the severity policy,
+// unless override is set, with the findings of the event's repository in the output text and
+// links to its issues, if any, in the summary. notes, such as what was not scanned, are
+// appended to the summary.
+func (h *FullRepoScanHandler) completeFullScanCheck(
+	ctx context.Context,
+	check *fullScanCheck,
+	catalog *messages.Catalog,
+	event notify.Event,
+	findings []report.Finding,
+	issues []*github.Issue,
+	override, notes string,
+	logger zerolog.Logger,
+) {
+	highest := h.Severity.Max(findings)
+	conclusion := h.Policy.Conclusion(highest)
+	if override != "" {
+		conclusion = override
+	}
+	summary := catalog.Format(messages.FullScanSummarySecrets, len(findings)) + findingsSummary(catalog, highest, findings)
+	switch len(issues) {
+	case 0:
+	case 1:
+		summary += catalog.Format(messages.FullScanSummaryIssue, issues[0].GetHTMLURL())
+	default:
+		summary += catalog.Text(messages.FullScanSummaryIssues)
+		for _, issue := range issues {
+			summary += "- " + issue.GetHTMLURL() + "\n"
+		}
+	}
+	summary += ageSummary(catalog, event.Findings, h.NewWithin) + notes
+	event.Conclusion = conclusion
+	summary = checkSummary(h.Templates, event, summary, logger)
+	text := checkRunText(catalog, event.Details)
+	check.complete(ctx, conclusion, checkRunTitle(catalog, conclusion), summary, text)
+}
+
+// reconcileAlerts pages on critical findings of a public repository and resolves pages whose
+// finding is gone from the default branch.
+func (h *FullRepoScanHandler) reconcileAlerts(
+	ctx context.Context,
+	repository *github.Repository,
+	sha string,
+	findings []report.Finding,
+	logger zerolog.Logger,
+) {
+	if h.Alerts == nil || repository.GetPrivate() {
+		return
+	}
+
+	var alerts []notify.Alert
+	for _, finding := range findings {
+		if h.Severity.Classify(finding) < severity.Critical {
+			continue
+		}
+		alerts = append(alerts, notify.Alert{
+			DedupKey:   notify.Fingerprint(repository.GetFullName(), finding),
+			Summary:    fmt.Sprintf(constants.AlertSummary, finding.RuleID, repository.GetFullName()),
+			Repository: repository.GetFullName(),
+			File:       finding.File,
+			RuleID:     finding.RuleID,
+			Severity:   severity.Critical.String(),
+			Link:       fmt.Sprintf("%s/blob/%s/%s#L%d", repository.GetHTMLURL(), sha, finding.File, finding.StartLine),
+		})
+	}
+
+	if err := h.Alerts.Reconcile(ctx, repository.GetFullName(), alerts); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgAlertFailed)
 	}
+}
 
+// cloneRepository clones a repository in memory using an installation token scoped to the
+// repository with read access to its contents. It also returns a client for the repository's
+// LFS objects with the same token, or nil when LFS objects are not resolved, and a function
+// revoking the token that the caller must call once the LFS objects have been fetched.
+func (h *FullRepoScanHandler) cloneRepository(
+	ctx context.Context,
+	client *github.Client,
+	installationID int64,
+	repository *github.Repository,
+	logger zerolog.Logger,
+) (*git.Repository, *lfs.Client, func(), error) {
 	// Get installation token for cloning
-	token, err := h.getInstallationToken(ctx, client, event)
+	token, err := h.getInstallationToken(ctx, client, installationID, repository.GetID())
 	if err != nil {
-		return fmt.Errorf(constants.ErrGetInstallationToken, err)
+		return nil, nil, nil, fmt.Errorf(constants.ErrGetInstallationToken, err)
 	}
+	revoke := func() { h.revokeInstallationToken(ctx, token, logger) }
+
+	gitRepo, lfsClient, err := h.clone(ctx, repository.GetCloneURL(), token, logger)
+	if err != nil {
+		revoke()
+		return nil, nil, nil, err
+	}
+	if lfsClient == nil {
+		// Nothing else needs the token.
+		revoke()
+		revoke = func() {}
+	}
+	return gitRepo, lfsClient, revoke, nil
+}
 
+// clone clones cloneURL in memory with token.
+func (h *FullRepoScanHandler) clone(
+	ctx context.Context, cloneURL, token string, logger zerolog.Logger,
+) (*git.Repository, *lfs.Client, error) {
+	cloneURL, err := h.Clone.URL(cloneURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf(constants.ErrCloneRepository, err)
+	}
+	proxyTransport, err := h.Clone.transport()
+	if err != nil {
+		return nil, nil, fmt.Errorf(constants.ErrCloneRepository, err)
+	}
 	logger.Debug().
-		Str("clone_url", cloneURL).
+		Str("clone_url", logging.ScrubURL(cloneURL)).
+		Bool("proxy", h.Clone.ProxyURL != "").
 		Msg(constants.LogMsgCloningRepository)
 
 	// Clone repository in memory
@@ -155,47 +613,119 @@ func (h *FullRepoScanHandler) scanFullRepository(
 			Username: "git",
 			Password: token,
 		},
+		ProxyOptions: h.Clone.proxy(),
 	})
 	if err != nil {
-		return fmt.Errorf(constants.ErrCloneRepository, err)
+		// go-git errors may echo the URL and credentials it was given.
+		return nil, nil, fmt.Errorf(constants.ErrCloneRepository, logging.ScrubError(err, token))
 	}
 
-	// Scan repository for secrets
-	findings, err := h.scanGitRepository(gitRepo)
+	var lfsClient *lfs.Client
+	if h.LFSMaxBytes > 0 {
+		lfsClient = lfs.NewClient(cloneURL, "x-access-token", token)
+		if proxyTransport != nil {
+			lfsClient.HTTPClient.Transport = proxyTransport
+		}
+	}
+	return gitRepo, lfsClient, nil
+}
+
+// getInstallationToken creates a token for installationID that can only read the contents of
+// the repository repositoryID.
+func (h *FullRepoScanHandler) getInstallationToken(
+	ctx context.Context, client *github.Client, installationID, repositoryID int64,
+) (string, error) {
+	token, _, err := client.Apps.CreateInstallationToken(ctx, installationID, &github.InstallationTokenOptions{
+		RepositoryIDs: []int64{repositoryID},
+		Permissions:   &github.InstallationPermissions{Contents: github.Ptr("read")},
+	})
 	if err != nil {
-		return fmt.Errorf(constants.ErrScanRepository, err)
+		return "", fmt.Errorf("failed to create installation token for installation %d: %w",
+			installationID, logging.ScrubError(err))
 	}
 
-	logger.Info().
-		Int("findings", len(findings)).
-		Msg(constants.LogMsgFullScanComplete)
+	return token.GetToken(), nil
+}
 
-	// Create issue if secrets are found
-	if len(findings) > 0 {
-		return h.createSecurityIssue(ctx, client, owner, repo, findings, logger)
+// revokeInstallationToken revokes token so that it is useless should it leak. The token is
+// revoked through a client of the App's client creator, with its transport, and even once ctx
+// ends, within constants.TokenRevokeTimeout. The token expires on its own within the hour, so
+// a failure is only logged.
+func (h *FullRepoScanHandler) revokeInstallationToken(ctx context.Context, token string, logger zerolog.Logger) {
+	tokenClient, err := h.ClientCreator.NewTokenClient(token)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), constants.TokenRevokeTimeout)
+		defer cancel()
+		_, err = tokenClient.Apps.RevokeInstallationToken(ctx)
 	}
+	if err != nil {
+		logger.Warn().Err(logging.ScrubError(err, token)).Msg(constants.LogMsgTokenRevokeFailed)
+	}
+}
 
-	logger.Info().Msg(constants.LogMsgNoSecretsFound)
-	return nil
+// scanProgress receives the number of files scanned out of total and the findings so far.
+type scanProgress func(scanned, total, findings int)
+
+// repositoryScan is the result of scanning a repository tree.
+type repositoryScan struct {
+	Findings []report.Finding
+	// Submodules are the submodules in the tree; their contents are not scanned.
+	Submodules []submodule
+	// LFSPointers are the paths of Git LFS pointers whose objects were not scanned.
+	LFSPointers []string
+	// Skipped are the files that could not be read and so were not scanned.
+	Skipped []skippedFile
 }
 
-func (h *FullRepoScanHandler) getInstallationToken(
-	ctx context.Context, client *github.Client, event *github.PushEvent,
-) (string, error) {
-	// Get installation ID from the webhook event
-	installationID := githubapp.GetInstallationIDFromEvent(event)
+// skippedFile is a file the scan could not read and the reason.
+type skippedFile struct {
+	Path string
+	Err  error
+}
 
-	// Create access token for this installation
-	token, _, err := client.Apps.CreateInstallationToken(ctx, installationID, &github.InstallationTokenOptions{})
-	if err != nil {
-		return "", fmt.Errorf("failed to create installation token for installation %d: %w", installationID, err)
+// submodule is a submodule path and the commit it is pinned to.
+type submodule struct {
+	Path   string
+	Commit string
+}
+
+// notScanned summarizes the submodules, LFS objects and unreadable files the scan did not
+// cover, or returns an empty string when it covered everything.
+func (s *repositoryScan) notScanned(catalog *messages.Catalog) string {
+	var summary string
+	if len(s.Submodules) > 0 || len(s.LFSPointers) > 0 {
+		summary += catalog.Format(messages.FullScanSummaryNotScanned, len(s.Submodules), len(s.LFSPointers))
+	}
+	if len(s.Skipped) == 0 {
+		return summary
 	}
 
-	return token.GetToken(), nil
+	summary += catalog.Format(messages.FullScanSummarySkipped, len(s.Skipped))
+	for i, skipped := range s.Skipped {
+		if i == constants.FullScanSkippedListMax {
+			summary += catalog.Format(messages.FullScanSummarySkippedMore, len(s.Skipped)-i)
+			break
+		}
+		summary += fmt.Sprintf("- `%s`: %v\n", skipped.Path, skipped.Err)
+	}
+	return summary
 }
 
-func (h *FullRepoScanHandler) scanGitRepository(gitRepo *git.Repository) ([]report.Finding, error) {
-	var allFindings []report.Finding
+// scanGitRepository scans the files of the head commit. Symlinks are not followed and
+// submodules are recorded without being scanned. Git LFS pointers are resolved through lfsClient
+// when their object is at most LFSMaxBytes, and recorded otherwise. Files that cannot be read
+// are recorded and skipped rather than failing the scan. Files of trees found clean in
+// ScanCache are not scanned again. Files whose name matches names are reported whatever
+// their content, even when they are skipped. Files the root ignore file ignores are neither
+// scanned nor reported.
+func (h *FullRepoScanHandler) scanGitRepository(
+	ctx context.Context, gitRepo *git.Repository, names *filenames.Rules, lfsClient *lfs.Client, progress scanProgress,
+) (*repositoryScan, error) {
+	scan := &repositoryScan{}
+	d, digest, err := loadVersionedDetector(ctx, h.Detectors, h.detector)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get the head reference
 	ref, err := gitRepo.Head()
@@ -215,170 +745,628 @@ func (h *FullRepoScanHandler) scanGitRepository(gitRepo *git.Repository) ([]repo
 		return nil, fmt.Errorf("failed to get tree: %w", err)
 	}
 
+	total := 0
+	if progress != nil {
+		if err := walkTree(tree, func(string, object.TreeEntry) error { total++; return nil }); err != nil {
+			return nil, fmt.Errorf("failed to list repository files: %w", err)
+		}
+	}
+
+	ignored, ignoreHash := ignoreFile(tree)
+	trees := h.lookupTreeScans(ctx, tree, digest, ignoreHash)
+
 	// Walk through all files in the repository
-	err = tree.Files().ForEach(func(file *object.File) error {
-		// Skip files we shouldn't scan
-		if h.shouldSkipFile(file) {
+	scanned := 0
+	err = walkTree(tree, func(name string, entry object.TreeEntry) error {
+		if progress != nil {
+			defer func() {
+				scanned++
+				progress(scanned, total, len(scan.Findings))
+			}()
+		}
+
+		switch entry.Mode {
+		case filemode.Submodule:
+			scan.Submodules = append(scan.Submodules, submodule{Path: name, Commit: entry.Hash.String()})
+			return nil
+		case filemode.Symlink:
+			// The blob is the link target; following it could leave the repository.
+			return nil
+		}
+		if ignored.Ignored(name) {
+			return nil
+		}
+		scan.Findings = append(scan.Findings, h.Overrides.Filter(names.Detect(name))...)
+		if trees.skip(name) {
 			return nil
 		}
 
-		content, err := file.Contents()
+		blob, err := gitRepo.BlobObject(entry.Hash)
 		if err != nil {
-			// Skip files we can't read
-			return fmt.Errorf("failed to read file contents: %w", err)
+			scan.skip(ctx, trees, name, fmt.Errorf("failed to read blob: %w", err))
+			return nil
 		}
+		file := object.NewFile(name, entry.Mode, blob)
 
-		// Create a temporary finding with file information for gitleaks
-		findings := h.detector.DetectString(content)
+		// Skip files we shouldn't scan; files over the size cap are listed as skipped.
+		if SkipPath(file.Name) {
+			return nil
+		}
+		if file.Size > constants.MaxScanFileBytes {
+			scan.skip(ctx, trees, name, fmt.Errorf("%w: %d bytes", detector.ErrContentTooLarge, file.Size))
+			return nil
+		}
+
+		content, err := h.openFile(ctx, lfsClient, file)
+		if err != nil {
+			scan.skip(ctx, trees, name, fmt.Errorf("failed to read file contents: %w", err))
+			return nil
+		}
+		if content == nil {
+			scan.LFSPointers = append(scan.LFSPointers, name)
+			trees.revisit(name)
+			return nil
+		}
+
+		findings, err := ScanFile(ctx, d, h.Plugins, name, content, false)
+		content.Close()
+		if err != nil {
+			scan.skip(ctx, trees, name, fmt.Errorf("failed to read file contents: %w", err))
+			return nil
+		}
 
 		// Update the file path in findings
 		for i := range findings {
 			findings[i].File = file.Name
 		}
 
-		allFindings = append(allFindings, findings...)
+		findings = h.Overrides.Filter(findings)
+		if len(findings) > 0 {
+			trees.revisit(name)
+		}
+		scan.Findings = append(scan.Findings, findings...)
 		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan repository files: %w", err)
 	}
+	h.saveTreeScans(ctx, trees)
 
-	return allFindings, nil
+	return scan, nil
 }
 
-func (h *FullRepoScanHandler) createSecurityIssue(
+// skip records a file that could not be read, to be read again by later scans of its tree.
+func (s *repositoryScan) skip(ctx context.Context, trees treeScans, name string, err error) {
+	zerolog.Ctx(ctx).Warn().Err(err).Str("file", name).Msg(constants.LogMsgFileSkipped)
+	s.Skipped = append(s.Skipped, skippedFile{Path: name, Err: err})
+	trees.revisit(name)
+}
+
+// walkTree calls fn for every file, symlink and submodule entry in tree and its subtrees.
+func walkTree(tree *object.Tree, fn func(name string, entry object.TreeEntry) error) error {
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		if err := fn(name, entry); err != nil {
+			return err
+		}
+	}
+}
+
+// openFile opens the content of file for scanning, streaming its blob. Files small enough to
+// be LFS pointers are read whole, and pointers are replaced by their object with resolveLFS;
+// pointers that are not resolved return nil.
+func (h *FullRepoScanHandler) openFile(
+	ctx context.Context, lfsClient *lfs.Client, file *object.File,
+) (io.ReadCloser, error) {
+	if file.Size > lfs.MaxPointerSize {
+		return file.Reader()
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	if pointer, ok := lfs.ParsePointer(content); ok {
+		if content, ok = h.resolveLFS(ctx, lfsClient, file.Name, pointer); !ok {
+			return nil, nil
+		}
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+// resolveLFS downloads the object of an LFS pointer when resolution is enabled and the object
+// is small enough, reporting false when its content is not available.
+func (h *FullRepoScanHandler) resolveLFS(
+	ctx context.Context, lfsClient *lfs.Client, name string, pointer lfs.Pointer,
+) (string, bool) {
+	if lfsClient == nil || pointer.Size > min(h.LFSMaxBytes, constants.MaxScanFileBytes) || SkipPath(name) {
+		return "", false
+	}
+	data, err := lfsClient.Fetch(ctx, pointer)
+	if err != nil {
+		err = logging.ScrubError(err, lfsClient.Password)
+		zerolog.Ctx(ctx).Warn().Err(err).Str("file", name).Msg(constants.LogMsgLFSFetchFailed)
+		return "", false
+	}
+	return string(data), true
+}
+
+// issueGroup is the findings of one security issue, found again by the marker of its key.
+type issueGroup struct {
+	key      string
+	title    string
+	findings []report.Finding
+}
+
+// groupIssueFindings splits findings into the issues of grouping, sorted by key. Findings
+// without a rule are grouped under "unknown", and files at the root of the repository under
+// constants.IssueRootDirectory.
+func groupIssueFindings(catalog *messages.Catalog, grouping string, findings []report.Finding) []issueGroup {
+	if grouping != constants.IssueGroupingRule && grouping != constants.IssueGroupingDirectory {
+		return []issueGroup{{key: "repository", title: catalog.Text(messages.IssueTitle), findings: findings}}
+	}
+
+	byName := make(map[string][]report.Finding)
+	for _, finding := range findings {
+		var name string
+		if grouping == constants.IssueGroupingRule {
+			name = finding.RuleID
+			if name == "" {
+				name = "unknown"
+			}
+		} else if dir, _, ok := strings.Cut(finding.File, "/"); ok {
+			name = dir
+		} else {
+			name = constants.IssueRootDirectory
+		}
+		byName[name] = append(byName[name], finding)
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make([]issueGroup, 0, len(names))
+	for _, name := range names {
+		group := issueGroup{key: grouping + ":" + name, findings: byName[name]}
+		if grouping == constants.IssueGroupingRule {
+			group.title = catalog.Format(messages.IssueTitleRule, name)
+		} else {
+			group.title = catalog.Format(messages.IssueTitleDirectory, name)
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// createSecurityIssues opens a security issue for every group of findings under
+// h.IssueGrouping, reusing the open issue of a group when there is one. It returns the
+// issues of the groups opened or found before an error.
+func (h *FullRepoScanHandler) createSecurityIssues(
 	ctx context.Context,
-	client *github.Client,
+	service IssuesService,
 	owner, repo string,
+	gitRepo *git.Repository,
 	findings []report.Finding,
+	event notify.Event,
+	catalog *messages.Catalog,
 	logger zerolog.Logger,
-) error {
-	// Check if a GitGuard security issue already exists
-	existingIssue, err := h.findExistingSecurityIssue(ctx, client, owner, repo)
-	if err != nil {
-		logger.Warn().Err(err).Msg("Failed to check for existing security issues, proceeding to create new issue")
-	} else if existingIssue != nil {
-		logger.Info().
-			Int("existing_issue_number", existingIssue.GetNumber()).
-			Msg("Security issue already exists, skipping creation")
-		return nil
+) ([]*github.Issue, error) {
+	existing, err := listSecurityIssues(ctx, service, owner, repo, h.issueLabels().Tracking())
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgIssueListFailed)
 	}
 
+	var issues []*github.Issue
+	for _, group := range groupIssueFindings(catalog, h.IssueGrouping, findings) {
+		if issue, marked := findSecurityIssue(existing, group, catalog); issue != nil {
+			logger.Info().
+				Int("existing_issue_number", issue.GetNumber()).
+				Str("issue_group", group.key).
+				Msg(constants.LogMsgIssueExists)
+			if !marked {
+				markSecurityIssue(ctx, service, owner, repo, issue, group, catalog, logger)
+			}
+			h.labelSecurityIssue(ctx, service, owner, repo, issue, group, logger)
+			issues = append(issues, issue)
+			continue
+		}
+		issue, err := h.createSecurityIssue(ctx, service, owner, repo, gitRepo, group, event, catalog, logger)
+		if err != nil {
+			return issues, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// createSecurityIssue opens the security issue of a group, with the group's marker at the top
+// of its body.
+func (h *FullRepoScanHandler) createSecurityIssue(
+	ctx context.Context,
+	service IssuesService,
+	owner, repo string,
+	gitRepo *git.Repository,
+	group issueGroup,
+	event notify.Event,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) (*github.Issue, error) {
+	findings := group.findings
+
 	// Create issue body
-	body := h.buildIssueBody(findings)
+	body := h.buildIssueBody(catalog, findings)
+	targets, err := buildCleanupTargets(gitRepo, findings)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgCleanupTraceFailed)
+	}
+	body += buildCleanupInstructions(catalog, targets)
+	event.Findings = notify.Summarize(findings, h.Severity)
+	event.Details = withBranches(notify.Details(event.Repository, findings, h.Severity, h.Redaction), event.Details)
+	body += issueBranches(catalog, event.Details, event.DefaultBranch)
+	body, err = h.Templates.IssueBody(event, body)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgTemplateFailed)
+	}
+	marker := fmt.Sprintf(constants.IssueMarker, group.key)
 
+	highest := h.Severity.Max(findings)
+	issueLabels := h.issueLabels().For(highest)
+	if err := issueLabels.Ensure(ctx, service, owner, repo); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgIssueLabelFailed)
+	}
 	issueRequest := &github.IssueRequest{
-		Title:  github.Ptr(constants.IssueTitle),
-		Body:   github.Ptr(body),
-		Labels: &[]string{constants.IssueLabel},
+		Title:  github.Ptr(group.title),
+		Body:   github.Ptr(truncateIssueBody(catalog, marker+body)),
+		Labels: github.Ptr(issueLabels.Names()),
+	}
+	due, hasSLA := h.slaDue(highest)
+	if hasSLA {
+		h.withSLAMilestone(ctx, service, owner, repo, issueRequest, due, logger)
 	}
 
-	issue, _, err := client.Issues.Create(ctx, owner, repo, issueRequest)
+	issue, _, err := service.Create(ctx, owner, repo, issueRequest)
 	if err != nil {
-		return fmt.Errorf(constants.ErrCreateIssue, err)
+		return nil, fmt.Errorf(constants.ErrCreateIssue, err)
 	}
 
 	logger.Info().
 		Int("issue_number", issue.GetNumber()).
 		Int("findings", len(findings)).
+		Str("issue_group", group.key).
 		Msg(constants.LogMsgCreatedIssue)
+	if hasSLA {
+		h.commentSLADue(ctx, service, owner, repo, issue, highest, due, catalog, logger)
+	}
 
-	return nil
+	// Post the locations the body leaves out as comments, so the issue holds the full report.
+	for _, page := range issueReportPages(catalog, findings, h.Redaction) {
+		comment := &github.IssueComment{Body: github.Ptr(page)}
+		if _, _, err := service.CreateComment(ctx, owner, repo, issue.GetNumber(), comment); err != nil {
+			logger.Warn().Err(err).Int("issue_number", issue.GetNumber()).Msg(constants.LogMsgIssueReportFailed)
+			break
+		}
+	}
+
+	return issue, nil
 }
 
-func (h *FullRepoScanHandler) buildIssueBody(findings []report.Finding) string {
-	body := "## 🚨 Security Alert: Secrets Detected\n\n"
-	body += "GitGuard has detected potential secrets in your repository during a full scan. "
-	body += "Please review these findings and take appropriate action.\n\n"
-	body += fmt.Sprintf("**Total findings:** %d\n\n", len(findings))
+// withBranches copies the branches of the findings in from to the same findings in details,
+// by fingerprint, and returns details.
+func withBranches(details, from []notify.Finding) []notify.Finding {
+	branches := make(map[string][]string, len(from))
+	for _, finding := range from {
+		branches[finding.Fingerprint] = finding.Branches
+	}
+	for i := range details {
+		details[i].Branches = branches[details[i].Fingerprint]
+	}
+	return details
+}
+
+// issueBranches lists the findings also detected on branches other than the default branch,
+// at most constants.IssueBranchesMax of them, or returns an empty string when there are none.
+func issueBranches(catalog *messages.Catalog, details []notify.Finding, defaultBranch string) string {
+	var lines []string
+	for _, finding := range sortDetails(slices.Clone(details)) {
+		var others []string
+		for _, branch := range finding.Branches {
+			if branch != defaultBranch {
+				others = append(others, "`"+branch+"`")
+			}
+		}
+		if len(others) > 0 {
+			lines = append(lines, catalog.Format(messages.IssueBranch, finding.File, finding.RuleID, strings.Join(others, ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	body := catalog.Text(messages.IssueBranchesHeading)
+	for i, line := range lines {
+		if i == constants.IssueBranchesMax {
+			body += catalog.Format(messages.IssueBranchesMore, len(lines)-i)
+			break
+		}
+		body += line
+	}
+	return body
+}
+
+// buildIssueBody describes the findings in a deterministic order, listing at most
+// IssueLocationsMax file locations.
+func (h *FullRepoScanHandler) buildIssueBody(catalog *messages.Catalog, findings []report.Finding) string {
+	body := catalog.Format(messages.IssueIntro, len(findings))
 
 	// Group findings by rule ID
-	ruleGroups := make(map[string][]report.Finding)
+	ruleCounts := make(map[string]int)
 	for _, finding := range findings {
 		ruleID := finding.RuleID
 		if ruleID == "" {
 			ruleID = "unknown"
 		}
-		ruleGroups[ruleID] = append(ruleGroups[ruleID], finding)
+		ruleCounts[ruleID]++
+	}
+	ruleIDs := make([]string, 0, len(ruleCounts))
+	for ruleID := range ruleCounts {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Slice(ruleIDs, func(i, j int) bool {
+		if ruleCounts[ruleIDs[i]] != ruleCounts[ruleIDs[j]] {
+			return ruleCounts[ruleIDs[i]] > ruleCounts[ruleIDs[j]]
+		}
+		return ruleIDs[i] < ruleIDs[j]
+	})
+
+	body += catalog.Text(messages.IssueTypesHeading)
+	for _, ruleID := range ruleIDs {
+		body += catalog.Format(messages.IssueTypeCount, ruleID, ruleCounts[ruleID])
 	}
 
-	body += "### Detected Secret Types\n\n"
-	for ruleID, ruleFindings := range ruleGroups {
-		body += fmt.Sprintf("- **%s**: %d occurrence(s)\n", ruleID, len(ruleFindings))
+	body += catalog.Text(messages.IssueLocationsHeading)
+	locations := issueLocations(catalog, findings, h.Redaction)
+	for i, location := range locations {
+		if i == constants.IssueLocationsMax {
+			body += catalog.Format(messages.IssueLocationsMore, len(locations)-i)
+			break
+		}
+		body += location
 	}
 
-	body += "\n### File Locations\n\n"
-	for _, finding := range findings {
+	body += catalog.Text(messages.IssueActions)
+	return body
+}
+
+// issueLocations returns one list item per finding, sorted by file, line and rule, with the
+// commit that introduced it when it is attributed and its secret as redaction allows.
+func issueLocations(catalog *messages.Catalog, findings []report.Finding, redaction *redact.Policy) []string {
+	sorted := make([]report.Finding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		if sorted[i].StartLine != sorted[j].StartLine {
+			return sorted[i].StartLine < sorted[j].StartLine
+		}
+		return sorted[i].RuleID < sorted[j].RuleID
+	})
+
+	locations := make([]string, 0, len(sorted))
+	for _, finding := range sorted {
 		filename := finding.File
 		if filename == "" {
-			filename = "unknown file"
+			filename = catalog.Text(messages.IssueUnknownFile)
+		}
+		location := catalog.Format(messages.IssueLocation, filename, finding.StartLine)
+		if finding.Commit != "" {
+			date, _, _ := strings.Cut(finding.Date, "T")
+			location = catalog.Format(
+				messages.IssueLocationBy, filename, finding.StartLine, finding.Author, shortSHA(finding.Commit), date,
+			)
 		}
-		body += fmt.Sprintf("- `%s` (line %d)\n", filename, finding.StartLine)
+		if secret := notify.RedactedSecret(finding, redaction); secret != "" {
+			location += catalog.Format(messages.IssueLocationSecret, secret)
+		}
+		locations = append(locations, location)
 	}
+	return locations
+}
 
-	body += "\n### Recommended Actions\n\n"
-	body += "1. **Immediately rotate** any exposed credentials\n"
-	body += "2. **Remove secrets** from the repository history\n"
-	body += "3. **Use environment variables** or secure secret management\n"
-	body += "4. **Add secrets to .gitignore** to prevent future commits\n"
-	body += "5. **Review commit history** for other potential exposures\n\n"
-	body += "### Important Notes\n\n"
-	body += "- This issue was created automatically by GitGuard\n"
-	body += "- Secrets may be visible in commit history even after removal\n"
-	body += "- Consider using tools like `git filter-branch` or `BFG Repo-Cleaner` for history cleanup\n"
+// issueReportPages splits the full list of file locations into issue comments that fit
+// GitHub's size limit. It returns nil when the issue body already lists every location.
+func issueReportPages(catalog *messages.Catalog, findings []report.Finding, redaction *redact.Policy) []string {
+	locations := issueLocations(catalog, findings, redaction)
+	if len(locations) <= constants.IssueLocationsMax {
+		return nil
+	}
 
-	return body
+	// There are never more pages than locations, so this bounds the header of every page.
+	header := catalog.Format(messages.IssueReportPageHeader, len(locations), len(locations))
+	budget := constants.IssueBodyMaxChars - len(header)
+
+	var pages []string
+	page := ""
+	for _, location := range locations {
+		if len(page)+len(location) > budget {
+			pages = append(pages, page)
+			page = ""
+		}
+		page += location
+	}
+	pages = append(pages, page)
+
+	for i := range pages {
+		pages[i] = catalog.Format(messages.IssueReportPageHeader, i+1, len(pages)) + pages[i]
+	}
+	return pages
+}
+
+// truncateIssueBody cuts body at a line boundary so it fits GitHub's size limit.
+func truncateIssueBody(catalog *messages.Catalog, body string) string {
+	if len(body) <= constants.IssueBodyMaxChars {
+		return body
+	}
+	truncated := catalog.Text(messages.IssueBodyTruncated)
+	cut := strings.LastIndex(body[:constants.IssueBodyMaxChars-len(truncated)], "\n")
+	return body[:cut+1] + truncated
+}
+
+// issueLabels returns the labels of security issues.
+func (h *FullRepoScanHandler) issueLabels() labels.Set {
+	if len(h.Labels) == 0 {
+		return labels.Default()
+	}
+	return h.Labels
 }
 
-func (h *FullRepoScanHandler) findExistingSecurityIssue(
+// labelSecurityIssue adds the labels of its group's highest severity an open issue lacks, such
+// as when the group's findings became more severe since the issue was opened.
+func (h *FullRepoScanHandler) labelSecurityIssue(
 	ctx context.Context,
-	client *github.Client,
+	service IssuesService,
 	owner, repo string,
-) (*github.Issue, error) {
-	// Search for open issues with our title and label
+	issue *github.Issue,
+	group issueGroup,
+	logger zerolog.Logger,
+) {
+	var missing labels.Set
+	for _, label := range h.issueLabels().For(h.Severity.Max(group.findings)) {
+		if !slices.ContainsFunc(issue.Labels, func(l *github.Label) bool { return l.GetName() == label.Name }) {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	if err := missing.Ensure(ctx, service, owner, repo); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgIssueLabelFailed)
+	}
+	if _, _, err := service.AddLabelsToIssue(ctx, owner, repo, issue.GetNumber(), missing.Names()); err != nil {
+		logger.Warn().Err(err).Int("issue_number", issue.GetNumber()).Msg(constants.LogMsgIssueLabelFailed)
+	}
+}
+
+// listSecurityIssues lists the open issues with label, up to constants.IssueListMaxPages pages
+// of them.
+func listSecurityIssues(
+	ctx context.Context, service IssuesService, owner, repo, label string,
+) ([]*github.Issue, error) {
 	opts := &github.IssueListByRepoOptions{
 		State:  "open",
-		Labels: []string{constants.IssueLabel},
+		Labels: []string{label},
 		ListOptions: github.ListOptions{
-			PerPage: 10, // We only need to check a few recent issues
+			PerPage: constants.IssueListMax,
 		},
 	}
 
-	issues, _, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list repository issues: %w", err)
+	var issues []*github.Issue
+	for range constants.IssueListMaxPages {
+		page, resp, err := service.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repository issues: %w", err)
+		}
+		issues = append(issues, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.ListOptions.Page = resp.NextPage
 	}
+	return issues, nil
+}
 
-	// Look for issues with our specific title
+// findSecurityIssue returns the issue of a group among the open security issues: the one
+// whose body holds the group's marker, wherever users moved it, and reports it as marked.
+// The single repository issue opened before markers were added is found, unmarked, by its
+// title or the English one.
+func findSecurityIssue(issues []*github.Issue, group issueGroup, catalog *messages.Catalog) (*github.Issue, bool) {
+	marker := strings.TrimSuffix(fmt.Sprintf(constants.IssueMarker, group.key), "\n")
 	for _, issue := range issues {
-		if issue.GetTitle() == constants.IssueTitle {
-			return issue, nil
+		if strings.Contains(issue.GetBody(), marker) {
+			return issue, true
 		}
 	}
+	if group.title != catalog.Text(messages.IssueTitle) {
+		return nil, false
+	}
+	for _, issue := range issues {
+		if issue.GetTitle() == group.title || issue.GetTitle() == constants.IssueTitle {
+			return issue, false
+		}
+	}
+	return nil, false
+}
 
-	return nil, nil
+// markSecurityIssue adds the marker of its group to an issue found by its title, so it is
+// found by the marker once its title changes.
+func markSecurityIssue(
+	ctx context.Context,
+	service IssuesService,
+	owner, repo string,
+	issue *github.Issue,
+	group issueGroup,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) {
+	body := truncateIssueBody(catalog, fmt.Sprintf(constants.IssueMarker, group.key)+issue.GetBody())
+	request := &github.IssueRequest{Body: &body}
+	if _, _, err := service.Edit(ctx, owner, repo, issue.GetNumber(), request); err != nil {
+		logger.Warn().Err(err).Int("issue_number", issue.GetNumber()).Msg(constants.LogMsgIssueMigrateFail)
+		return
+	}
+	logger.Info().Int("issue_number", issue.GetNumber()).Msg(constants.LogMsgIssueMigrated)
 }
 
-func (h *FullRepoScanHandler) shouldSkipFile(file *object.File) bool {
-	// Skip large files
-	if file.Size > constants.MaxFileChanges {
-		return true
+// ignoreFile reads the ignore file at the root of tree and returns it with its blob hash, or
+// nil and an empty hash when there is none.
+func ignoreFile(tree *object.Tree) (*ignore.Matcher, string) {
+	file, err := tree.File(ignore.FileName)
+	if err != nil {
+		return nil, ""
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, ""
 	}
+	return ignore.Parse(content), file.Hash.String()
+}
 
-	filename := file.Name
+// SkipFile reports whether a repository file is larger than constants.MaxScanFileBytes or
+// is skipped by SkipPath, and so is not scanned.
+func SkipFile(filename string, size int64) bool {
+	return size > constants.MaxScanFileBytes || SkipPath(filename)
+}
 
-	for _, ext := range binaryExtensions {
-		if strings.HasSuffix(strings.ToLower(filename), ext) {
-			return true
-		}
+// SkipPath reports whether a repository file is binary or in a dependency or build directory,
+// whatever its size.
+func SkipPath(filename string) bool {
+	// Extensions are compared with Unicode case folding, which unlike lowercasing the whole
+	// name never changes its length or depends on the language.
+	ext := path.Ext(filename)
+	if slices.ContainsFunc(binaryExtensions, func(binary string) bool { return strings.EqualFold(binary, ext) }) {
+		return true
 	}
 
-	// Skip common directories that usually contain binaries or dependencies
-	for _, skipPath := range skipPaths {
-		if strings.Contains(filename, skipPath) {
+	// Skip common directories that usually contain binaries or dependencies, matching whole
+	// path segments so that "mynode_modules_backup" is scanned but "NODE_MODULES" is not.
+	segments := strings.Split(filename, "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if slices.ContainsFunc(skipDirectories, func(dir string) bool { return strings.EqualFold(dir, segment) }) {
 			return true
 		}
 	}
-
 	return false
 }
@@ -2,22 +2,138 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/cache"
 	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/filenames"
+	"github.com/omercnet/gitguard/internal/ignore"
+	"github.com/omercnet/gitguard/internal/jobs"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/pathrules"
+	"github.com/omercnet/gitguard/internal/pii"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/policy"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/omercnet/gitguard/internal/workflow"
 	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/rs/zerolog"
 	"github.com/zricethezav/gitleaks/v8/detect"
 	"github.com/zricethezav/gitleaks/v8/report"
+	"golang.org/x/sync/errgroup"
 )
 
 // SecretScanHandler handles push events to scan commits for secrets.
 type SecretScanHandler struct {
 	githubapp.ClientCreator
+	// ContentCache, when set, caches fetched file contents by repository, path and SHA.
+	ContentCache *cache.ContentCache
+	// Severity classifies findings; nil rates every finding high.
+	Severity *severity.Classifier
+	// Policy maps the highest severity of a scan to the check run conclusion.
+	Policy severity.Policy
+	// Notifier, when set, receives an event for every completed scan.
+	Notifier notify.Notifier
+	// Dispatch, when set, fires a repository_dispatch event on the repositories it is non-zero
+	// for when a scan finds secrets and notifications are not suppressed.
+	Dispatch *reposcope.Setting
+	// Overrides, when set, drops findings disabled for their file path.
+	Overrides *pathrules.Set
+	// Baseline, when set, stores each repository's grandfathered findings, which no longer
+	// fail checks or open issues.
+	Baseline store.Store
+	// Findings, when set, is consulted so that suppressed findings no longer fail checks.
+	// Lifecycle states are recorded by full scans of the default branch.
+	Findings store.FindingStore
+	// NewWithin, when positive, marks findings first seen within it as new and the others as
+	// long-standing, in check runs, notifications and policy rules.
+	NewWithin time.Duration
+	// Deduplicate, with Findings set, tracks the branches each finding is detected on and
+	// notifies it once, when it is first detected on any branch: scans whose findings were
+	// all detected before send no notification.
+	Deduplicate bool
+	// Detectors, when set, provides detectors built from the configured rule packs.
+	Detectors *detector.Factory
+	// Plugins are additional detectors run on every scanned file.
+	Plugins plugin.Set
+	// Filenames, when set, reports files whose name is sensitive, such as .env files and
+	// private keys, regardless of their content.
+	Filenames *filenames.Rules
+	// Scope, when set, limits scans to the repositories it allows.
+	Scope *reposcope.Scope
+	// MaxFileBytes is the size up to which changed files over 1 MB, which the contents API
+	// returns without content, are downloaded from their blob and scanned. 0 leaves them
+	// unscanned, and at most MaxScanFileBytes is scanned whatever the setting.
+	MaxFileBytes int64
+	// HeadOnlyThreshold, when set, is the number of commits above which a push is scanned
+	// as the cumulative diff on its head commit, with a single check run, instead of one
+	// check run per commit. 0 scans every commit.
+	HeadOnlyThreshold *reposcope.Setting
+	// SummaryCheck reports the results of the commits of pushes of several commits, scanned
+	// one by one, on a summary check run on the head commit.
+	SummaryCheck bool
+	// SummaryOnly, with SummaryCheck, leaves the commits of such pushes without a check run of
+	// their own, so the summary is the one place to look.
+	SummaryOnly bool
+	// ScanCache, when set, caches scan results by commit, so a commit pushed to several
+	// branches is fetched and scanned once; the check run is still created for every push.
+	ScanCache *cache.ScanCache
+	// RulesVersion identifies the build, rules and path overrides in scan cache keys, so
+	// results are not reused after an upgrade or a rules change. The digest of the rule packs
+	// the detector was built from is added to it.
+	RulesVersion string
+	// Redaction, when set, includes secrets in check runs, comments and notifications as a
+	// masked preview or a hash. Raw secrets are never reported.
+	Redaction *redact.Policy
+	// DetailsURL, when set, is linked from check runs. "{repository}" and "{sha}" are
+	// replaced with the repository's full name and the commit.
+	DetailsURL string
+	// PII, when set, enables the PII rules for repositories it is non-zero for. PII findings
+	// are reported on their own check run and never fail the secret scan check.
+	PII *reposcope.Setting
+	// PolicyRules, when set, can override the check run conclusion and suppress notifications.
+	PolicyRules *policy.Set
+	// DryRun, when set, reports findings without enforcing them in the repositories it is
+	// non-zero for: check runs never fail and no notification is sent.
+	DryRun *reposcope.Setting
+	// Enforcement, when set, limits enforcement to the repositories in the rollout; the others
+	// are scanned as dry runs.
+	Enforcement *reposcope.Rollout
+	// Messages, when set, selects the locale of each installation's check runs.
+	Messages *messages.Locales
+	// Templates, when set, replace the built-in check run summaries.
+	Templates *messages.Templates
+	// Reporting selects how scans are reported: constants.ReportingChecks, the default, on
+	// check runs, ReportingComments as comments on commits with findings, for Apps without the
+	// checks permission, and ReportingBoth on both. Only check runs report PII findings.
+	Reporting string
+	// RotationTickets adds an action filing a ticket to rotate the secrets found to check
+	// runs failing on findings; RotationTicketHandler handles it.
+	RotationTickets bool
+	// Scheduler, when set, limits concurrent scans per installation. Commit scans have high
+	// priority and only wait once an installation's rate limit is exhausted.
+	Scheduler *ratelimit.Scheduler
+	// Jobs, when set, records every commit scan as a job whose ID is the check run's
+	// external ID.
+	Jobs *jobs.Registry
+	// detector, when set and Detectors is not, replaces the default detector.
 	detector *detect.Detector
+	// services, when set, replaces the GitHub APIs scans are reported through.
+	services func(*github.Client) services
 }
 
 // Handles returns the list of event types this handler can process.
@@ -25,6 +141,15 @@ func (h *SecretScanHandler) Handles() []string {
 	return []string{constants.PushEventType}
 }
 
+// ScanPriority returns the installation and priority of the commit scan of a push.
+func (h *SecretScanHandler) ScanPriority(eventType string, payload []byte) (int64, ratelimit.Priority, bool) {
+	event, ok := scannedPush(h.Scope, eventType, payload)
+	if !ok {
+		return 0, 0, false
+	}
+	return githubapp.GetInstallationIDFromEvent(event), pushPriority(event), true
+}
+
 // Handle processes push events to scan commits for secrets.
 func (h *SecretScanHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
 	logger := zerolog.Ctx(ctx).With().
@@ -32,15 +157,6 @@ func (h *SecretScanHandler) Handle(ctx context.Context, eventType, deliveryID st
 		Str("delivery_id", deliveryID).
 		Logger()
 
-	// Initialize detector if needed
-	if h.detector == nil {
-		detector, err := initializeDetector()
-		if err != nil {
-			return err
-		}
-		h.detector = detector
-	}
-
 	// Parse push event
 	event, err := parsePushEvent(payload)
 	if err != nil {
@@ -52,6 +168,16 @@ func (h *SecretScanHandler) Handle(ctx context.Context, eventType, deliveryID st
 		logger.Debug().Msg(constants.LogMsgSkippingEvent)
 		return nil
 	}
+	if !inScope(h.Scope, event, logger) {
+		return nil
+	}
+
+	priority := pushPriority(event)
+	release, err := schedule(ctx, h.Scheduler, event, priority, logger)
+	if err != nil {
+		return err
+	}
+	defer release()
 
 	// Create GitHub client
 	client, err := createGitHubClient(h.ClientCreator, event)
@@ -67,79 +193,229 @@ func (h *SecretScanHandler) Handle(ctx context.Context, eventType, deliveryID st
 		Int("commit_count", len(event.Commits)).
 		Msg(constants.LogMsgProcessingCommits)
 
-	// Process each commit
-	for _, commit := range event.Commits {
-		commitSHA := commit.GetID()
-		commitLogger := logger.With().Str("commit_sha", commitSHA).Logger()
+	base := notify.Event{
+		Type:          notify.EventScanCompleted,
+		Scan:          notify.ScanCommit,
+		Repository:    event.GetRepo().GetFullName(),
+		Private:       event.GetRepo().GetPrivate(),
+		Installation:  githubapp.GetInstallationIDFromEvent(event),
+		Ref:           event.GetRef(),
+		DefaultBranch: event.GetRepo().GetDefaultBranch(),
+		Links:         notify.Links{Repository: event.GetRepo().GetHTMLURL()},
+	}
+
+	if threshold := h.HeadOnlyThreshold.For(event.GetRepo().GetFullName()); threshold > 0 && len(event.Commits) > threshold {
+		head := event.Commits[len(event.Commits)-1].GetID()
+		from := commitBases(event)[0]
+		if from == "" {
+			if from, err = commitParent(ctx, client, owner, repo, event.Commits[0].GetID()); err != nil {
+				logger.Error().Err(err).Str("commit_sha", head).Msg(constants.LogMsgFailedScanCommit)
+				return nil
+			}
+		}
+		headLogger := logger.With().Str("commit_sha", head).Str("from", from).Logger()
+		headLogger.Info().Int("threshold", threshold).Msg(constants.LogMsgScanningHeadOnly)
+		_, err := h.scanRange(ctx, client, owner, repo, from, head, len(event.Commits), true, base, headLogger)
+		if err != nil {
+			headLogger.Error().Err(err).Msg(constants.LogMsgFailedScanCommit)
+		}
+		return nil
+	}
 
-		if err := h.scanCommit(ctx, client, owner, repo, commitSHA, commitLogger); err != nil {
-			commitLogger.Error().Err(err).Msg(constants.LogMsgFailedScanCommit)
-			// Continue with other commits
+	// Scan the commits concurrently: the push holds one scan slot and each further worker
+	// takes another while the installation's share and rate limit allow it.
+	workers := 1
+	for workers < min(len(event.Commits), constants.CommitScanConcurrency) {
+		release, ok := h.Scheduler.TryAcquire(base.Installation, priority)
+		if !ok {
+			break
 		}
+		defer release()
+		workers++
 	}
 
+	var mu sync.Mutex
+	var errs []error
+	var group errgroup.Group
+	group.SetLimit(workers)
+	// Pushes of several commits are summarized on their head commit, and with SummaryOnly the
+	// commits get no check run of their own.
+	summarize := h.SummaryCheck && len(event.Commits) > 1
+	commitChecks := !summarize || !h.SummaryOnly
+	bases := commitBases(event)
+	scans := make([]commitScan, len(event.Commits))
+	for i, commit := range event.Commits {
+		commitSHA := commit.GetID()
+		group.Go(func() error {
+			commitLogger := logger.With().Str("commit_sha", commitSHA).Logger()
+			result, err := h.scanCommit(ctx, client, owner, repo, bases[i], commitSHA, commitChecks, base, commitLogger)
+			scans[i] = commitScan{sha: commitSHA, event: result, err: err}
+			if err != nil {
+				commitLogger.Error().Err(err).Msg(constants.LogMsgFailedScanCommit)
+				// Continue with other commits
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", commitSHA, err))
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = group.Wait()
+	if summarize {
+		h.reportPushSummary(ctx, servicesFor(client, h.services).checks, owner, repo, scans, base, logger)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf(constants.ErrScanCommits, len(errs), len(event.Commits), errors.Join(errs...))
+	}
 	return nil
 }
 
+// scanCommit scans the changes of the commit sha since from, or since its parent when from
+// is empty, reporting on a check run on sha unless checkRun is false.
 func (h *SecretScanHandler) scanCommit(
 	ctx context.Context,
 	client *github.Client,
-	owner, repo, sha string,
+	owner, repo, from, sha string,
+	checkRun bool,
+	base notify.Event,
 	logger zerolog.Logger,
-) error {
+) (notify.Event, error) {
+	return h.scanRange(ctx, client, owner, repo, from, sha, 1, checkRun, base, logger)
+}
+
+// commitBases returns the comparison base of each commit of a push: the commit pushed before
+// it, and for the first one the branch's previous head. The first commit of a new branch, or
+// of a force push whose previous head need not be its ancestor, gets an empty base and is
+// compared with its parent.
+func commitBases(event *github.PushEvent) []string {
+	bases := make([]string, len(event.Commits))
+	if before := event.GetBefore(); before != "" && before != constants.ZeroSHA && !event.GetForced() {
+		bases[0] = before
+	}
+	for i := 1; i < len(event.Commits); i++ {
+		bases[i] = event.Commits[i-1].GetID()
+	}
+	return bases
+}
+
+// scanRange scans the diff from one commit to sha, reporting on a check run on sha unless
+// checkRun is false or commit scans are only reported as comments, and returns the event
+// describing its results. An empty from scans the changes of sha alone; commits is the number
+// of commits the diff covers.
+func (h *SecretScanHandler) scanRange(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo, from, sha string,
+	commits int,
+	checkRun bool,
+	base notify.Event,
+	logger zerolog.Logger,
+) (_ notify.Event, err error) {
+	var reported int
+	ctx, finish := startJob(ctx, h.Jobs, jobs.Job{Repository: base.Repository, Ref: base.Ref, Commit: sha})
+	defer func() { finish(sha, reported, err) }()
+
 	// Create check run
-	checkRunID, err := h.createCheckRun(ctx, client, owner, repo, sha, logger)
-	if err != nil {
-		return err
+	catalog := h.Messages.For(base.Installation)
+	checks := checkRun && h.Reporting != constants.ReportingComments
+	comments := h.Reporting == constants.ReportingComments || h.Reporting == constants.ReportingBoth
+	svc := servicesFor(client, h.services)
+	var checkRunID int64
+	if checks {
+		checkRunID, err = h.createCheckRun(ctx, svc.checks, owner, repo, sha, catalog, logger)
+		if err != nil {
+			return notify.Event{}, err
+		}
 	}
 
-	// Get commit diff
-	comparison, err := h.getCommitDiff(ctx, client, owner, repo, sha)
+	allFindings, filesScanned, err := h.scanDiffCached(ctx, client, owner, repo, from, sha, logger)
 	if err != nil {
-		h.updateCheckRunWithError(ctx, client, owner, repo, checkRunID, logger)
-		return fmt.Errorf(constants.ErrGetCommitDiff, err)
+		if checks {
+			h.updateCheckRunWithError(ctx, svc.checks, owner, repo, checkRunID, catalog, logger)
+		}
+		return notify.Event{}, err
 	}
 
-	// Scan changed files
-	var allFindings []report.Finding
-	filesScanned := 0
+	allFindings, personal := pii.Split(allFindings)
+	if checks && h.PII.For(base.Repository) > 0 {
+		detailsURL := checkRunDetailsURL(h.DetailsURL, base.Repository, sha)
+		reportPrivacyCheck(ctx, svc.checks, owner, repo, sha, personal, h.Redaction, detailsURL, catalog, logger)
+	}
 
-	for _, file := range comparison.Files {
-		if h.shouldSkipFile(file) {
-			continue
-		}
+	allFindings = filterSuppressed(ctx, h.Findings, base.Repository, allFindings, logger)
+	fresh, baselined, err := splitBaseline(ctx, h.Baseline, base.Repository, allFindings)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgBaselineFailed)
+	}
+	allFindings = fresh
+
+	base.Commit = sha
+	base.Findings = notify.Summarize(allFindings, h.Severity)
+	base.Details = notify.Details(base.Repository, allFindings, h.Severity, h.Redaction)
+	ageFindings(ctx, h.Findings, &base, allFindings, h.NewWithin, logger)
+	repeated := h.Deduplicate && recordOccurrences(ctx, h.Findings, &base, logger)
+	decision := decide(h.PolicyRules, base, logger)
+	if isDryRun(h.DryRun, h.Enforcement, base.Repository) {
+		decision = reportOnly(decision, base, logger)
+	}
 
-		content, err := h.getFileContent(ctx, client, owner, repo, sha, file.GetFilename())
-		if err != nil || content == "" {
-			continue
+	// Update check run with results
+	if checks {
+		checkRun, err := h.updateCheckRunWithResults(
+			ctx, svc.checks, owner, repo, checkRunID, allFindings, decision.Conclusion, len(baselined), filesScanned, commits,
+			base, catalog, logger,
+		)
+		if err != nil {
+			return notify.Event{}, err
 		}
+		base.Conclusion = checkRun.GetConclusion()
+		base.Links.CheckRun = checkRun.GetHTMLURL()
+	} else {
+		base.Conclusion, _, _ = h.buildCheckRunOutput(catalog, allFindings)
+		if decision.Conclusion != "" && len(allFindings) > 0 {
+			base.Conclusion = decision.Conclusion
+		}
+	}
+	if comments && len(allFindings) > 0 {
+		commentOnCommit(ctx, svc.repos, owner, repo, sha, allFindings, h.Severity, h.Redaction, catalog, logger)
+	}
 
-		findings := h.detector.DetectString(content)
-		allFindings = append(allFindings, findings...)
-		filesScanned++
+	reported = len(allFindings)
+	if base.Links.Repository != "" {
+		base.Links.Commit = base.Links.Repository + "/commit/" + sha
+	}
+	switch {
+	case decision.Notify && repeated:
+		logger.Info().Int("findings", len(allFindings)).Msg(constants.LogMsgFindingsRepeated)
+	case decision.Notify:
+		sendNotification(ctx, h.Notifier, base, logger)
+		dispatchScan(ctx, client, h.Dispatch, owner, repo, base, logger)
 	}
 
-	// Update check run with results
-	return h.updateCheckRunWithResults(ctx, client, owner, repo, checkRunID, allFindings, filesScanned, logger)
+	return base, nil
 }
 
 func (h *SecretScanHandler) createCheckRun(
 	ctx context.Context,
-	client *github.Client,
+	checks ChecksService,
 	owner, repo, sha string,
+	catalog *messages.Catalog,
 	logger zerolog.Logger,
 ) (int64, error) {
 	checkRun := &github.CreateCheckRunOptions{
-		Name:    constants.CheckRunName,
-		HeadSHA: sha,
-		Status:  github.Ptr(constants.StatusInProgress),
+		Name:       constants.CheckRunName,
+		HeadSHA:    sha,
+		DetailsURL: checkRunDetailsURL(h.DetailsURL, owner+"/"+repo, sha),
+		ExternalID: externalID(ctx),
+		Status:     github.Ptr(constants.StatusInProgress),
 		Output: &github.CheckRunOutput{
-			Title:   github.Ptr(constants.CheckRunTitleInProgress),
-			Summary: github.Ptr(constants.CheckRunSummaryInProgress),
+			Title:   github.Ptr(catalog.Text(messages.CheckRunTitleInProgress)),
+			Summary: github.Ptr(catalog.Text(messages.CheckRunSummaryInProgress)),
 		},
 	}
 
-	createdCheck, _, err := client.Checks.CreateCheckRun(ctx, owner, repo, *checkRun)
+	createdCheck, _, err := checks.CreateCheckRun(ctx, owner, repo, *checkRun)
 	if err != nil {
 		return 0, fmt.Errorf(constants.ErrCreateCheckRun, err)
 	}
@@ -148,83 +424,317 @@ func (h *SecretScanHandler) createCheckRun(
 	return createdCheck.GetID(), nil
 }
 
+// scanDiffCached returns the findings of scanDiff, reusing the cached result for the same
+// commits and rules. Scans that could not read every changed file are not cached, so a
+// failed fetch is scanned again rather than remembered as clean.
+func (h *SecretScanHandler) scanDiffCached(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo, from, sha string,
+	logger zerolog.Logger,
+) ([]report.Finding, int, error) {
+	d, digest, err := loadVersionedDetector(ctx, h.Detectors, h.detector)
+	if err != nil {
+		return nil, 0, err
+	}
+	fullName := owner + "/" + repo
+	// Cached findings hold their redacted secrets, so results are not reused across policies.
+	key := cache.ScanKey(rulesVersion(h.RulesVersion, digest)+"/"+h.Redaction.String(), fullName, from, sha)
+	result, ok, err := h.ScanCache.Get(ctx, key)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgScanCacheFailed)
+	}
+	if ok {
+		logger.Debug().Msg(constants.LogMsgScanCacheHit)
+		return slices.Clone(result.Findings), result.FilesScanned, nil
+	}
+
+	findings, filesScanned, complete, err := h.scanDiffWith(ctx, d, client, owner, repo, from, sha)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !complete {
+		return findings, filesScanned, nil
+	}
+	result = cache.ScanResult{Findings: withoutSecrets(fullName, findings, h.Redaction), FilesScanned: filesScanned}
+	if err := h.ScanCache.Add(ctx, key, result); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgScanCacheFailed)
+	}
+	return findings, filesScanned, nil
+}
+
+// scanDiff fetches and scans the files changed between from and sha with the current
+// detector, reporting whether every changed file to scan could be read.
+func (h *SecretScanHandler) scanDiff(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo, from, sha string,
+) ([]report.Finding, int, bool, error) {
+	d, err := loadDetector(ctx, h.Detectors, h.detector)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return h.scanDiffWith(ctx, d, client, owner, repo, from, sha)
+}
+
+// scanDiffWith fetches and scans the files changed between from and sha with d, reporting
+// whether every changed file to scan could be read.
+func (h *SecretScanHandler) scanDiffWith(
+	ctx context.Context,
+	d *detect.Detector,
+	client *github.Client,
+	owner, repo, from, sha string,
+) ([]report.Finding, int, bool, error) {
+	// Get commit diff
+	comparison, err := h.getCommitDiff(ctx, client, owner, repo, from, sha)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf(constants.ErrGetCommitDiff, err)
+	}
+
+	// Scan changed files
+	var allFindings []report.Finding
+	filesScanned := 0
+	complete := true
+	personal := h.PII.For(owner+"/"+repo) > 0
+	names := h.Filenames.For(owner + "/" + repo)
+	var ignored *ignore.Matcher
+	if len(comparison.Files) > 0 {
+		ignored = loadIgnoreFile(ctx, client, owner, repo, sha)
+	}
+
+	for _, file := range comparison.Files {
+		if ignored.Ignored(file.GetFilename()) {
+			continue
+		}
+		// Sensitive file names are reported even when their content is skipped or empty.
+		if file.GetStatus() != constants.FileStatusRemoved {
+			allFindings = append(allFindings, h.Overrides.Filter(names.Detect(file.GetFilename()))...)
+		}
+		if h.shouldSkipFile(ctx, file) {
+			continue
+		}
+
+		content, err := h.getCachedFileContent(ctx, client, owner, repo, sha, file)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("file", file.GetFilename()).Msg(constants.LogMsgFileContentFailed)
+			complete = false
+			continue
+		}
+		if content == nil {
+			continue
+		}
+
+		findings, err := ScanFile(ctx, d, h.Plugins, file.GetFilename(), content, personal)
+		content.Close()
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("file", file.GetFilename()).Msg(constants.LogMsgFileContentFailed)
+			complete = false
+			continue
+		}
+		for i := range findings {
+			findings[i].File = file.GetFilename()
+		}
+		allFindings = append(allFindings, h.Overrides.Filter(findings)...)
+		filesScanned++
+	}
+	return allFindings, filesScanned, complete, nil
+}
+
+// withoutSecrets returns copies of findings that can be cached: the secret and the matched
+// text are dropped, and the fingerprint derived from them and the secret redacted by
+// redaction are kept instead.
+func withoutSecrets(repository string, findings []report.Finding, redaction *redact.Policy) []report.Finding {
+	cached := make([]report.Finding, len(findings))
+	for i, finding := range findings {
+		cached[i] = notify.WithoutSecret(repository, finding, redaction)
+	}
+	return cached
+}
+
+// getCommitDiff compares sha with from, or with its parent when from is empty.
 func (h *SecretScanHandler) getCommitDiff(
 	ctx context.Context,
 	client *github.Client,
-	owner, repo, sha string,
+	owner, repo, from, sha string,
 ) (*github.CommitsComparison, error) {
-	// Try to get diff with previous commit
-	comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repo, sha+"~1", sha, nil)
-	if err == nil {
-		return comparison, nil
+	if from == "" {
+		parent, err := commitParent(ctx, client, owner, repo, sha)
+		if err != nil {
+			return nil, err
+		}
+		from = parent
 	}
 
-	// For initial commits, compare with empty tree
-	comparison, _, err = client.Repositories.CompareCommits(ctx, owner, repo, constants.EmptyTreeSHA, sha, nil)
+	comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repo, from, sha, nil)
 	if err != nil {
-		return nil, fmt.Errorf(constants.ErrGetCommitDiff, err)
+		return nil, err
 	}
 	return comparison, nil
 }
 
-func (h *SecretScanHandler) shouldSkipFile(file *github.CommitFile) bool {
-	return file.GetStatus() == constants.FileStatusRemoved || file.GetChanges() > constants.MaxFileChanges
+// commitParent returns the first parent of the commit sha, or the empty tree when sha is a
+// root commit.
+func commitParent(ctx context.Context, client *github.Client, owner, repo, sha string) (string, error) {
+	commit, _, err := client.Git.GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		return "", err
+	}
+	if len(commit.Parents) == 0 {
+		zerolog.Ctx(ctx).Debug().Str("commit_sha", sha).Msg(constants.LogMsgInitialCommit)
+		return constants.EmptyTreeSHA, nil
+	}
+	return commit.Parents[0].GetSHA(), nil
 }
 
+// loadIgnoreFile fetches the ignore file at the root of the commit sha, or returns nil when
+// the commit has none or it cannot be read.
+func loadIgnoreFile(ctx context.Context, client *github.Client, owner, repo, sha string) *ignore.Matcher {
+	opts := &github.RepositoryContentGetOptions{Ref: sha}
+	file, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, ignore.FileName, opts)
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg(constants.LogMsgIgnoreFileFailed)
+		}
+		return nil
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg(constants.LogMsgIgnoreFileFailed)
+		return nil
+	}
+	return ignore.Parse(content)
+}
+
+// shouldSkipFile reports whether a changed file is not scanned: removed files and, when large
+// files are not downloaded from their blob, files other than workflows with more than
+// MaxFileChanges changed lines, whose skip is logged. Otherwise the size of large files is
+// checked once their content is fetched.
+func (h *SecretScanHandler) shouldSkipFile(ctx context.Context, file *github.CommitFile) bool {
+	if file.GetStatus() == constants.FileStatusRemoved {
+		return true
+	}
+	if h.MaxFileBytes > 0 || file.GetChanges() <= constants.MaxFileChanges || workflow.IsWorkflow(file.GetFilename()) {
+		return false
+	}
+	zerolog.Ctx(ctx).Warn().Str("file", file.GetFilename()).Int("changes", file.GetChanges()).
+		Msg(constants.LogMsgFileTooManyChanges)
+	return true
+}
+
+// getCachedFileContent opens the content of a changed file, consulting the content cache
+// first. Contents returned by the contents API are cached; larger files are streamed from their
+// raw blob and not cached. It returns nil when the file has no content to scan.
+func (h *SecretScanHandler) getCachedFileContent(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo, sha string,
+	file *github.CommitFile,
+) (io.ReadCloser, error) {
+	fullName := owner + "/" + repo
+	key := file.GetSHA()
+	if key == "" {
+		key = sha
+	}
+
+	if content, ok := h.ContentCache.Get(fullName, file.GetFilename(), key); ok {
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+
+	content, blob, err := h.getFileContent(ctx, client, owner, repo, sha, file.GetFilename())
+	if err != nil || blob != nil {
+		return blob, err
+	}
+	if content == "" {
+		return nil, nil
+	}
+
+	h.ContentCache.Add(fullName, file.GetFilename(), key, content)
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+// getFileContent returns the content of filename at sha. Directories, submodules and
+// symlinks leaving the repository have no content to scan and return an empty string. Files
+// over 1 MB, which the contents API returns without content, return a reader streaming their
+// raw blob when they are at most MaxFileBytes, and are logged and left unscanned otherwise.
 func (h *SecretScanHandler) getFileContent(
 	ctx context.Context,
 	client *github.Client,
 	owner, repo, sha, filename string,
-) (string, error) {
+) (string, io.ReadCloser, error) {
 	opts := &github.RepositoryContentGetOptions{Ref: sha}
 	fileContent, _, _, err := client.Repositories.GetContents(ctx, owner, repo, filename, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to get file contents for %s: %w", filename, err)
+		return "", nil, fmt.Errorf("failed to get file contents for %s: %w", filename, err)
+	}
+	switch {
+	case fileContent == nil, fileContent.GetType() != constants.ContentTypeFile:
+		// A directory is listed instead; submodules and symlinks are returned without content.
+		return "", nil, nil
+	case fileContent.GetEncoding() == constants.ContentEncodingNone:
+		if int64(fileContent.GetSize()) > min(h.MaxFileBytes, constants.MaxScanFileBytes) {
+			zerolog.Ctx(ctx).Warn().Str("file", filename).Int("size", fileContent.GetSize()).
+				Msg(constants.LogMsgFileOverSizeLimit)
+			return "", nil, nil
+		}
+		blob, err := openBlob(ctx, client, owner, repo, fileContent.GetSHA())
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get blob for file %s: %w", filename, err)
+		}
+		return "", blob, nil
 	}
 
 	content, err := fileContent.GetContent()
 	if err != nil {
-		return "", fmt.Errorf("failed to get content for file %s: %w", filename, err)
+		return "", nil, fmt.Errorf("failed to get content for file %s: %w", filename, err)
 	}
 
-	return content, nil
+	return content, nil, nil
+}
+
+// openBlob streams the raw blob sha. Detection reads one byte more than MaxScanFileBytes, so
+// an oversized file is still reported without the rest being downloaded.
+func openBlob(ctx context.Context, client *github.Client, owner, repo, sha string) (io.ReadCloser, error) {
+	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/git/blobs/%s", owner, repo, sha), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.raw")
+	resp, err := client.BareDo(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
 }
 
 func (h *SecretScanHandler) updateCheckRunWithResults(
 	ctx context.Context,
-	client *github.Client,
+	checks ChecksService,
 	owner, repo string,
 	checkRunID int64,
 	findings []report.Finding,
+	override string,
+	baselined int,
 	filesScanned int,
+	commits int,
+	event notify.Event,
+	catalog *messages.Catalog,
 	logger zerolog.Logger,
-) error {
-	var conclusion, title, summary string
-
-	if len(findings) == 0 {
-		conclusion = constants.ConclusionSuccess
-		title = constants.CheckRunTitleClean
-		summary = constants.CheckRunSummaryClean
-	} else {
-		conclusion = constants.ConclusionFailure
-		title = constants.CheckRunTitleSecrets
-		summary = fmt.Sprintf(constants.CheckRunSummarySecrets, len(findings))
-
-		// Add leak types summary (without exposing actual secrets)
-		leakTypes := make(map[string]bool)
-		for _, finding := range findings {
-			if finding.RuleID != "" {
-				leakTypes[finding.RuleID] = true
-			}
-		}
-
-		if len(leakTypes) > 0 {
-			summary += constants.CheckRunSummaryTypes
-			for leakType := range leakTypes {
-				summary += "- " + leakType + "\n"
-			}
-		}
+) (*github.CheckRun, error) {
+	conclusion, title, summary := h.buildCheckRunOutput(catalog, findings)
+	if override != "" && len(findings) > 0 {
+		conclusion, title = override, checkRunTitle(catalog, override)
+	}
+	if baselined > 0 {
+		summary += catalog.Format(messages.CheckRunSummaryBaselined, baselined)
 	}
+	if commits > 1 {
+		summary += catalog.Format(messages.CheckRunSummaryCumulative, commits)
+	}
+	if len(findings) > 0 {
+		summary += ageSummary(catalog, event.Findings, h.NewWithin)
+	}
+	event.Conclusion = conclusion
+	summary = checkSummary(h.Templates, event, summary, logger)
 
 	updateCheck := &github.UpdateCheckRunOptions{
 		Name:        constants.CheckRunName,
@@ -236,27 +746,52 @@ func (h *SecretScanHandler) updateCheckRunWithResults(
 			Summary: github.Ptr(summary),
 		},
 	}
+	if len(findings) > 0 {
+		updateCheck.Output.Text = github.Ptr(checkRunText(catalog, event.Details))
+	}
+	failing := conclusion == constants.ConclusionFailure || conclusion == constants.ConclusionActionRequired
+	if h.RotationTickets && failing && len(findings) > 0 {
+		updateCheck.Actions = rotationActions(catalog)
+	}
 
-	_, _, err := client.Checks.UpdateCheckRun(ctx, owner, repo, checkRunID, *updateCheck)
+	checkRun, _, err := checks.UpdateCheckRun(ctx, owner, repo, checkRunID, *updateCheck)
 	if err != nil {
-		return fmt.Errorf(constants.ErrUpdateCheckRun, err)
+		return nil, fmt.Errorf(constants.ErrUpdateCheckRun, err)
 	}
 
 	logger.Info().
 		Int64("check_run_id", checkRunID).
 		Str("conclusion", conclusion).
 		Int("findings", len(findings)).
+		Int("baselined", baselined).
 		Int("files_scanned", filesScanned).
 		Msg(constants.LogMsgUpdatedCheckRun)
 
-	return nil
+	return checkRun, nil
+}
+
+// buildCheckRunOutput returns the conclusion, title and summary for a scan. The conclusion
+// follows the handler's severity policy; without one any finding fails the check.
+func (h *SecretScanHandler) buildCheckRunOutput(
+	catalog *messages.Catalog, findings []report.Finding,
+) (string, string, string) {
+	if len(findings) == 0 {
+		title, summary := catalog.Text(messages.CheckRunTitleClean), catalog.Text(messages.CheckRunSummaryClean)
+		return constants.ConclusionSuccess, title, summary
+	}
+
+	highest := h.Severity.Max(findings)
+	conclusion := h.Policy.Conclusion(highest)
+	summary := catalog.Format(messages.CheckRunSummarySecrets, len(findings)) + findingsSummary(catalog, highest, findings)
+	return conclusion, checkRunTitle(catalog, conclusion), summary
 }
 
 func (h *SecretScanHandler) updateCheckRunWithError(
 	ctx context.Context,
-	client *github.Client,
+	checks ChecksService,
 	owner, repo string,
 	checkRunID int64,
+	catalog *messages.Catalog,
 	logger zerolog.Logger,
 ) {
 	updateCheck := &github.UpdateCheckRunOptions{
@@ -264,12 +799,12 @@ func (h *SecretScanHandler) updateCheckRunWithError(
 		Status:     github.Ptr(constants.StatusCompleted),
 		Conclusion: github.Ptr(constants.ConclusionFailure),
 		Output: &github.CheckRunOutput{
-			Title:   github.Ptr(constants.CheckRunTitleError),
-			Summary: github.Ptr(constants.CheckRunSummaryError),
+			Title:   github.Ptr(catalog.Text(messages.CheckRunTitleError)),
+			Summary: github.Ptr(catalog.Text(messages.CheckRunSummaryError)),
 		},
 	}
 
-	if _, _, err := client.Checks.UpdateCheckRun(ctx, owner, repo, checkRunID, *updateCheck); err != nil {
+	if _, _, err := checks.UpdateCheckRun(ctx, owner, repo, checkRunID, *updateCheck); err != nil {
 		logger.Error().Err(err).Msg(constants.LogMsgErrorUpdateFailed)
 	} else {
 		logger.Debug().Int64("check_run_id", checkRunID).Msg(constants.LogMsgCreatedCheckRun)
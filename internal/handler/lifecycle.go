@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// trackFindings records a full scan of the default branch in the finding store, with secrets
+// redacted by redaction: findings that are no longer detected are resolved. It returns the
+// findings that are not suppressed and the lifecycle transitions caused by the scan. Store
+// failures are logged and leave the findings unchanged.
+func trackFindings(
+	ctx context.Context,
+	findingStore store.FindingStore,
+	repository string,
+	findings []report.Finding,
+	redaction *redact.Policy,
+	logger zerolog.Logger,
+) ([]report.Finding, []notify.Transition) {
+	if findingStore == nil {
+		return findings, nil
+	}
+
+	scan := store.Scan{Repository: repository, Complete: true, Time: time.Now().UTC()}
+	for _, finding := range findings {
+		scan.Findings = append(scan.Findings, store.Finding{
+			Fingerprint: notify.Fingerprint(repository, finding),
+			Secret:      notify.RedactedSecret(finding, redaction),
+			File:        finding.File,
+			RuleID:      finding.RuleID,
+			Line:        finding.StartLine,
+		})
+	}
+
+	transitions, err := findingStore.RecordScan(ctx, scan)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgTrackFindingsFailed)
+		return findings, nil
+	}
+	if len(transitions) > 0 {
+		logger.Info().Int("transitions", len(transitions)).Msg(constants.LogMsgFindingTransitions)
+	}
+	return filterSuppressed(ctx, findingStore, repository, findings, logger), toNotifyTransitions(transitions)
+}
+
+// ageFindings annotates the findings of event as new or long-standing when newWithin is set.
+// A finding was first seen when finding tracking first recorded it or, when earlier, at the
+// date of the commit that introduced it; findings seen for the first time are new. Store
+// failures are logged and leave the findings aged by their commit alone.
+func ageFindings(
+	ctx context.Context,
+	findingStore store.FindingStore,
+	event *notify.Event,
+	findings []report.Finding,
+	newWithin time.Duration,
+	logger zerolog.Logger,
+) {
+	if newWithin <= 0 || len(findings) == 0 {
+		return
+	}
+
+	firstSeen := make(map[string]time.Time)
+	if findingStore != nil {
+		tracked, err := findingStore.Findings(ctx, event.Repository)
+		if err != nil {
+			logger.Warn().Err(err).Msg(constants.LogMsgTrackFindingsFailed)
+		}
+		for _, finding := range tracked {
+			firstSeen[finding.Fingerprint] = finding.FirstSeen
+		}
+	}
+	for _, finding := range findings {
+		introduced, err := time.Parse(time.RFC3339, finding.Date)
+		if err != nil {
+			continue
+		}
+		fingerprint := notify.Fingerprint(event.Repository, finding)
+		if seen, ok := firstSeen[fingerprint]; !ok || introduced.Before(seen) {
+			firstSeen[fingerprint] = introduced
+		}
+	}
+	event.Age(firstSeen, newWithin, time.Now().UTC())
+}
+
+// recordOccurrences records that the findings of event were detected on its branch and lists
+// the branches each was detected on in its details. It reports whether every finding had
+// already been detected, on this branch or another, so that the event only repeats earlier
+// ones. Store failures are logged and report the findings as new.
+func recordOccurrences(
+	ctx context.Context, findingStore store.FindingStore, event *notify.Event, logger zerolog.Logger,
+) bool {
+	if findingStore == nil || len(event.Details) == 0 {
+		return false
+	}
+
+	branch := strings.TrimPrefix(event.Ref, constants.BranchRefPrefix)
+	fingerprints := make([]string, 0, len(event.Details))
+	for _, detail := range event.Details {
+		fingerprints = append(fingerprints, detail.Fingerprint)
+	}
+	previous, err := findingStore.RecordOccurrences(ctx, event.Repository, branch, fingerprints, time.Now().UTC())
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgTrackFindingsFailed)
+		return false
+	}
+
+	repeated := true
+	for i, detail := range event.Details {
+		occurrence, ok := previous[detail.Fingerprint]
+		repeated = repeated && ok
+		branches := occurrence.Branches
+		if !slices.Contains(branches, branch) {
+			branches = append(branches, branch)
+			slices.Sort(branches)
+		}
+		event.Details[i].Branches = branches
+	}
+	return repeated
+}
+
+// filterSuppressed drops the findings an administrator suppressed.
+func filterSuppressed(
+	ctx context.Context,
+	findingStore store.FindingStore,
+	repository string,
+	findings []report.Finding,
+	logger zerolog.Logger,
+) []report.Finding {
+	if findingStore == nil || len(findings) == 0 {
+		return findings
+	}
+
+	tracked, err := findingStore.Findings(ctx, repository)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgTrackFindingsFailed)
+		return findings
+	}
+	suppressed := make(map[string]bool)
+	for _, finding := range tracked {
+		if finding.State == store.StateSuppressed {
+			suppressed[finding.Fingerprint] = true
+		}
+	}
+
+	active := make([]report.Finding, 0, len(findings))
+	for _, finding := range findings {
+		if !suppressed[notify.Fingerprint(repository, finding)] {
+			active = append(active, finding)
+		}
+	}
+	return active
+}
+
+func toNotifyTransitions(transitions []store.Transition) []notify.Transition {
+	converted := make([]notify.Transition, 0, len(transitions))
+	for _, t := range transitions {
+		converted = append(converted, notify.Transition{
+			Fingerprint: t.Finding.Fingerprint,
+			File:        t.Finding.File,
+			RuleID:      t.Finding.RuleID,
+			From:        string(t.From),
+			To:          string(t.To),
+		})
+	}
+	return converted
+}
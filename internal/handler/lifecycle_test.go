@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestTrackFindings(t *testing.T) {
+	ctx := context.Background()
+	findingStore := store.NewMemory()
+	key := report.Finding{File: "deploy.sh", RuleID: "aws-access-token", Secret: "AKIA0000000000000000"}
+	token := report.Finding{File: "config.yml", RuleID: "generic-api-key", Secret: "token-value"}
+
+	active, transitions := trackFindings(ctx, findingStore, "owner/repo", []report.Finding{key, token}, nil, zerolog.Nop())
+	assert.Len(t, active, 2)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, "open", transitions[0].To)
+
+	_, err := findingStore.SetState(ctx, "owner/repo", notify.Fingerprint("owner/repo", token), store.StateSuppressed)
+	require.NoError(t, err)
+
+	active, transitions = trackFindings(ctx, findingStore, "owner/repo", []report.Finding{token}, nil, zerolog.Nop())
+	assert.Empty(t, active, "Suppressed findings should be dropped")
+	require.Len(t, transitions, 1)
+	assert.Equal(t, "deploy.sh", transitions[0].File)
+	assert.Equal(t, "resolved", transitions[0].To)
+
+	assert.Empty(t, filterSuppressed(ctx, findingStore, "owner/repo", []report.Finding{token}, zerolog.Nop()))
+	assert.Len(t, filterSuppressed(ctx, nil, "owner/repo", []report.Finding{token}, zerolog.Nop()), 1)
+}
+
+func TestAgeFindings(t *testing.T) {
+	ctx := context.Background()
+	findingStore := store.NewMemory()
+	tracked := report.Finding{File: "deploy.sh", RuleID: "aws-access-token", Secret: "AKIA0000000000000000"}
+	old := report.Finding{
+		File: "config.yml", RuleID: "generic-api-key", Secret: "token-value", Date: "2020-01-02T03:04:05Z",
+	}
+	fresh := report.Finding{File: "app.env", RuleID: "generic-api-key", Secret: "other-value"}
+	_, err := findingStore.RecordScan(ctx, store.Scan{
+		Repository: "owner/repo",
+		Complete:   true,
+		Findings:   []store.Finding{{Fingerprint: notify.Fingerprint("owner/repo", tracked)}},
+		Time:       time.Now().Add(-90 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	findings := []report.Finding{tracked, old, fresh}
+	event := notify.Event{Repository: "owner/repo", Details: notify.Details("owner/repo", findings, nil, nil)}
+	ageFindings(ctx, findingStore, &event, findings, 30*24*time.Hour, zerolog.Nop())
+	assert.Equal(t, notify.AgeLongStanding, event.Details[0].Age, "Tracked findings age from when they were first seen")
+	assert.Equal(t, notify.AgeLongStanding, event.Details[1].Age, "Attributed findings age from their commit")
+	assert.Equal(t, 2020, event.Details[1].FirstSeen.Year())
+	assert.Equal(t, notify.AgeNew, event.Details[2].Age)
+	assert.Equal(t, 1, event.Findings.New)
+	assert.Equal(t, 2, event.Findings.LongStanding)
+
+	unaged := notify.Event{Repository: "owner/repo", Details: notify.Details("owner/repo", findings, nil, nil)}
+	ageFindings(ctx, findingStore, &unaged, findings, 0, zerolog.Nop())
+	assert.Empty(t, unaged.Details[0].Age, "Findings are not aged without a window")
+	assert.Contains(t, findingsTable(nil, event.Details, "more"), "| high 🆕 |")
+	assert.Equal(t, "", ageSummary(nil, unaged.Findings, 0))
+	assert.Contains(t, ageSummary(nil, event.Findings, 30*24*time.Hour), "1 new finding(s); 2 long-standing, first seen over 30 day(s) ago")
+}
+
+func TestRecordOccurrences(t *testing.T) {
+	ctx := context.Background()
+	findingStore := store.NewMemory()
+	key := report.Finding{File: "deploy.sh", RuleID: "aws-access-token", Secret: "AKIA0000000000000000"}
+	token := report.Finding{File: "config.yml", RuleID: "generic-api-key", Secret: "token-value"}
+	event := func(ref string, findings ...report.Finding) *notify.Event {
+		return &notify.Event{Repository: "owner/repo", Ref: ref, Details: notify.Details("owner/repo", findings, nil, nil)}
+	}
+
+	first := event("refs/heads/feature", key)
+	assert.False(t, recordOccurrences(ctx, findingStore, first, zerolog.Nop()), "New findings are not repeated")
+	assert.Equal(t, []string{"feature"}, first.Details[0].Branches)
+
+	pushed := event("refs/heads/main", key)
+	assert.True(t, recordOccurrences(ctx, findingStore, pushed, zerolog.Nop()), "Findings seen on another branch repeat")
+	assert.Equal(t, []string{"feature", "main"}, pushed.Details[0].Branches)
+
+	mixed := event("refs/heads/main", key, token)
+	assert.False(t, recordOccurrences(ctx, findingStore, mixed, zerolog.Nop()), "Any new finding is notified")
+
+	assert.False(t, recordOccurrences(ctx, nil, event("refs/heads/main", key), zerolog.Nop()))
+	assert.False(t, recordOccurrences(ctx, findingStore, event("refs/heads/main"), zerolog.Nop()))
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/rs/zerolog"
+)
+
+// ScanLimits bound the repositories full scans clone. Zero fields are unlimited.
+type ScanLimits struct {
+	// SizeMB is the largest repository size, as reported by GitHub, that is cloned.
+	SizeMB int
+	// Files is the largest number of files at the scanned commit.
+	Files int
+}
+
+// exceeded returns the check run summary explaining why repository is over the limits, or
+// an empty string when it may be scanned. The size GitHub reports is checked first; files
+// are counted from the tree of commit, which costs a single API call. When the tree cannot
+// be listed the repository is scanned anyway.
+func (l ScanLimits) exceeded(
+	ctx context.Context,
+	client *github.Client,
+	repository *github.Repository,
+	commit string,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) string {
+	// GitHub reports repository sizes in kilobytes.
+	if sizeMB := repository.GetSize() / 1024; l.SizeMB > 0 && sizeMB > l.SizeMB {
+		logger.Info().Int("size_mb", sizeMB).Int("max_size_mb", l.SizeMB).Msg(constants.LogMsgRepoTooLarge)
+		return catalog.Format(messages.FullScanSummaryTooLarge, sizeMB, l.SizeMB)
+	}
+	if l.Files <= 0 {
+		return ""
+	}
+
+	owner, repo := repository.GetOwner().GetLogin(), repository.GetName()
+	tree, _, err := client.Git.GetTree(ctx, owner, repo, commit, true)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgRepoTreeFailed)
+		return ""
+	}
+	files := 0
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			files++
+		}
+	}
+	// A truncated tree lists only part of the repository, which is over the limit only if
+	// that part already is.
+	if files <= l.Files {
+		return ""
+	}
+	logger.Info().Int("files", files).Int("max_files", l.Files).Msg(constants.LogMsgRepoTooLarge)
+	return catalog.Format(messages.FullScanSummaryManyFiles, l.Files)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanLimits_exceeded(t *testing.T) {
+	treeStatus := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/git/trees/abc123", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("recursive"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(treeStatus)
+		_, _ = w.Write([]byte(`{"sha": "abc123", "truncated": true, "tree": [
+			{"path": "src", "type": "tree"},
+			{"path": "src/a.go", "type": "blob"},
+			{"path": "src/b.go", "type": "blob"},
+			{"path": "lib", "type": "commit"}
+		]}`))
+	}))
+	defer server.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	repository := &github.Repository{
+		Name:  github.Ptr("repo"),
+		Owner: &github.User{Login: github.Ptr("owner")},
+		Size:  github.Ptr(3 * 1024),
+	}
+	exceeded := func(limits ScanLimits) string {
+		return limits.exceeded(context.Background(), client, repository, "abc123", nil, zerolog.Nop())
+	}
+
+	assert.Empty(t, exceeded(ScanLimits{}), "Zero limits never skip a scan")
+	assert.Empty(t, exceeded(ScanLimits{SizeMB: 3}))
+	assert.Equal(t,
+		"⏭️ The repository is 3 MB, over the full scan limit of 2 MB, so it was not scanned.",
+		exceeded(ScanLimits{SizeMB: 2, Files: 1}), "The size is checked before listing files")
+	assert.Empty(t, exceeded(ScanLimits{Files: 2}), "Only files are counted")
+	assert.Equal(t,
+		"⏭️ The repository has more than 1 files, the full scan limit, so it was not scanned.",
+		exceeded(ScanLimits{Files: 1}))
+
+	treeStatus = http.StatusNotFound
+	assert.Empty(t, exceeded(ScanLimits{Files: 1}), "Repositories are scanned when the tree cannot be listed")
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// nativeCorrelation counts the findings of a full scan GitHub secret scanning also alerted on,
+// and those it missed.
+type nativeCorrelation struct {
+	// Alerted findings have a GitHub alert for their secret and are left out of the scan.
+	Alerted int
+	// Unalerted findings have no GitHub alert.
+	Unalerted int
+}
+
+// summary describes the correlation in a check run summary, or returns an empty string when
+// the findings were not correlated.
+func (c *nativeCorrelation) summary(catalog *messages.Catalog) string {
+	if c == nil {
+		return ""
+	}
+	var summary string
+	if c.Alerted > 0 {
+		summary += catalog.Format(messages.FullScanSummaryAlerted, c.Alerted)
+	}
+	if c.Unalerted > 0 {
+		summary += catalog.Format(messages.FullScanSummaryUnalerted, c.Unalerted)
+	}
+	return summary
+}
+
+// correlateNativeAlerts leaves the findings whose secret has a GitHub secret scanning alert,
+// open or resolved, out of a full scan, so the same leak is not triaged twice, and counts
+// those GitHub missed. Without NativeAlerts, or when the alerts cannot be listed, e.g. because
+// secret scanning is disabled for the repository, every finding is kept and the correlation
+// is nil.
+func (h *FullRepoScanHandler) correlateNativeAlerts(
+	ctx context.Context, client *github.Client, owner, repo string, findings []report.Finding, logger zerolog.Logger,
+) ([]report.Finding, *nativeCorrelation) {
+	if !h.NativeAlerts || len(findings) == 0 {
+		return findings, nil
+	}
+	secrets, err := listNativeAlertSecrets(ctx, client, owner, repo)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgNativeAlertsFailed)
+		return findings, nil
+	}
+
+	correlation := &nativeCorrelation{}
+	unalerted := make([]report.Finding, 0, len(findings))
+	for _, finding := range findings {
+		if _, ok := secrets[strings.TrimSpace(finding.Secret)]; ok {
+			correlation.Alerted++
+			continue
+		}
+		unalerted = append(unalerted, finding)
+	}
+	correlation.Unalerted = len(unalerted)
+	logger.Info().
+		Int("alerted", correlation.Alerted).
+		Int("unalerted", correlation.Unalerted).
+		Msg(constants.LogMsgNativeCorrelated)
+	return unalerted, correlation
+}
+
+// listNativeAlertSecrets returns the secrets of a repository's GitHub secret scanning alerts.
+func listNativeAlertSecrets(ctx context.Context, client *github.Client, owner, repo string) (map[string]struct{}, error) {
+	opts := &github.SecretScanningAlertListOptions{
+		ListCursorOptions: github.ListCursorOptions{PerPage: constants.NativeAlertsListMax},
+	}
+	secrets := make(map[string]struct{})
+	for range constants.NativeAlertsListMaxPages {
+		alerts, resp, err := client.SecretScanning.ListAlertsForRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secret scanning alerts: %w", err)
+		}
+		for _, alert := range alerts {
+			if secret := strings.TrimSpace(alert.GetSecret()); secret != "" {
+				secrets[secret] = struct{}{}
+			}
+		}
+		if resp.After == "" {
+			break
+		}
+		opts.After = resp.After
+	}
+	return secrets, nil
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/rs/zerolog"
+)
+
+// DefaultOrgScanConcurrency is the number of repositories an organization scan scans at once
+// when none is given.
+const DefaultOrgScanConcurrency = 4
+
+// OrgScanResult is the outcome of the full scan of one repository of an organization.
+type OrgScanResult struct {
+	Repository string `json:"repository"`
+	Findings   int    `json:"findings"`
+	Error      string `json:"error,omitempty"`
+}
+
+// OrgScanSummary is the outcome of an organization scan.
+type OrgScanSummary struct {
+	Organization string `json:"organization"`
+	// Repositories is the number of repositories in scope; Skipped those out of scope.
+	Repositories int             `json:"repositories"`
+	Skipped      int             `json:"skipped"`
+	Scanned      int             `json:"scanned"`
+	Failed       int             `json:"failed"`
+	Findings     int             `json:"findings"`
+	Results      []OrgScanResult `json:"results"`
+}
+
+// ScanOrganization runs a full scan of the default branch of every repository in scope that
+// the App's installation on org can access, concurrency at a time. Each scan reports like a
+// push to the default branch: check run, security issue and notifications. progress, when
+// set, is called after each repository with its result, the number of repositories done
+// and their total. Failed repositories are counted in the summary rather than returned.
+func (h *FullRepoScanHandler) ScanOrganization(
+	ctx context.Context,
+	org string,
+	concurrency int,
+	progress func(result OrgScanResult, done, total int),
+	logger zerolog.Logger,
+) (OrgScanSummary, error) {
+	summary := OrgScanSummary{Organization: org}
+	if concurrency <= 0 {
+		concurrency = DefaultOrgScanConcurrency
+	}
+
+	appClient, err := h.NewAppClient()
+	if err != nil {
+		return summary, fmt.Errorf(constants.ErrCreateGitHubClient, err)
+	}
+	installation, _, err := appClient.Apps.FindOrganizationInstallation(ctx, org)
+	if err != nil {
+		return summary, fmt.Errorf(constants.ErrFindOrgInstallation, org, err)
+	}
+	client, err := h.NewInstallationClient(installation.GetID())
+	if err != nil {
+		return summary, fmt.Errorf(constants.ErrCreateGitHubClient, err)
+	}
+
+	repositories, err := listInstallationRepositories(ctx, client)
+	if err != nil {
+		return summary, err
+	}
+	var queue []*github.Repository
+	for _, repository := range repositories {
+		scoped := reposcope.Repository{
+			FullName: repository.GetFullName(),
+			Archived: repository.GetArchived(),
+			Fork:     repository.GetFork(),
+		}
+		if h.Scope.Allowed(installation.GetID(), scoped) {
+			queue = append(queue, repository)
+		} else {
+			summary.Skipped++
+		}
+	}
+	summary.Repositories = len(queue)
+	logger.Info().
+		Str("organization", org).
+		Int("repositories", len(queue)).
+		Int("skipped", summary.Skipped).
+		Int("concurrency", concurrency).
+		Msg(constants.LogMsgOrgScanStarted)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for _, repository := range queue {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result := h.scanOrgRepository(ctx, client, installation.GetID(), repository, logger)
+			mu.Lock()
+			defer mu.Unlock()
+			summary.Results = append(summary.Results, result)
+			if result.Error != "" {
+				summary.Failed++
+			} else {
+				summary.Scanned++
+				summary.Findings += result.Findings
+			}
+			if progress != nil {
+				progress(result, len(summary.Results), len(queue))
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(summary.Results, func(i, j int) bool {
+		return summary.Results[i].Repository < summary.Results[j].Repository
+	})
+	logger.Info().
+		Str("organization", org).
+		Int("scanned", summary.Scanned).
+		Int("failed", summary.Failed).
+		Int("findings", summary.Findings).
+		Msg(constants.LogMsgOrgScanComplete)
+	return summary, nil
+}
+
+// scanOrgRepository runs the full scan of one repository of an organization scan.
+func (h *FullRepoScanHandler) scanOrgRepository(
+	ctx context.Context,
+	client *github.Client,
+	installationID int64,
+	repository *github.Repository,
+	logger zerolog.Logger,
+) OrgScanResult {
+	result := OrgScanResult{Repository: repository.GetFullName()}
+	logger = logger.With().Str("repo", repository.GetFullName()).Logger()
+
+	count, err := h.scanDefaultBranch(ctx, client, installationID, repository, logger)
+	result.Findings = count
+	if err != nil {
+		result.Error = err.Error()
+		logger.Warn().Err(err).Msg(constants.LogMsgOrgScanRepo)
+	} else {
+		logger.Info().Int("findings", count).Msg(constants.LogMsgOrgScanRepo)
+	}
+	return result
+}
+
+// scanDefaultBranch scans the head of the repository's default branch.
+func (h *FullRepoScanHandler) scanDefaultBranch(
+	ctx context.Context,
+	client *github.Client,
+	installationID int64,
+	repository *github.Repository,
+	logger zerolog.Logger,
+) (int, error) {
+	owner, repo := repository.GetOwner().GetLogin(), repository.GetName()
+	branch, _, err := client.Repositories.GetBranch(ctx, owner, repo, repository.GetDefaultBranch(), 1)
+	if err != nil {
+		return 0, fmt.Errorf(constants.ErrGetBranch, err)
+	}
+	return h.scanTarget(ctx, client, fullScanTarget{
+		Installation: installationID,
+		Owner:        owner,
+		Repo:         repo,
+		Ref:          constants.BranchRefPrefix + repository.GetDefaultBranch(),
+		Commit:       branch.GetCommit().GetSHA(),
+	}, logger)
+}
+
+// listInstallationRepositories returns every repository the installation client can access.
+func listInstallationRepositories(ctx context.Context, client *github.Client) ([]*github.Repository, error) {
+	var repositories []*github.Repository
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Apps.ListRepos(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf(constants.ErrListRepositories, err)
+		}
+		repositories = append(repositories, page.Repositories...)
+		if resp.NextPage == 0 {
+			return repositories, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullRepoScanHandler_ScanOrganization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/orgs/acme/installation":
+			_, _ = w.Write([]byte(`{"id": 7}`))
+		case "/installation/repositories":
+			if r.URL.Query().Get("page") == "2" {
+				_, _ = w.Write([]byte(`{"total_count": 3, "repositories": [
+					{"name": "fork", "full_name": "acme/fork", "fork": true, "owner": {"login": "acme"}}
+				]}`))
+				return
+			}
+			w.Header().Set("Link", `<`+"http://"+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+			_, _ = w.Write([]byte(`{"total_count": 3, "repositories": [
+				{"name": "api", "full_name": "acme/api", "default_branch": "main", "owner": {"login": "acme"}},
+				{"name": "web", "full_name": "acme/web", "default_branch": "main", "owner": {"login": "acme"}}
+			]}`))
+		case "/repos/acme/web/branches/main":
+			_, _ = w.Write([]byte(`{"name": "main", "commit": {"sha": "abc123"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	scope, err := reposcope.New(reposcope.Rules{SkipForks: true}, nil)
+	require.NoError(t, err)
+	h := &FullRepoScanHandler{
+		ClientCreator: testClientCreator{baseURL: server.URL},
+		Scope:         scope,
+		detector:      mustDetector(t),
+	}
+
+	var mu sync.Mutex
+	var progress []int
+	summary, err := h.ScanOrganization(context.Background(), "acme", 2,
+		func(_ OrgScanResult, done, total int) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, 2, total)
+			progress = append(progress, done)
+		},
+		zerolog.Nop(),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "acme", summary.Organization)
+	assert.Equal(t, 2, summary.Repositories)
+	assert.Equal(t, 1, summary.Skipped, "Forks are out of scope")
+	assert.Equal(t, 2, summary.Failed)
+	assert.ElementsMatch(t, []int{1, 2}, progress)
+	require.Len(t, summary.Results, 2)
+	assert.Equal(t, "acme/api", summary.Results[0].Repository)
+	assert.Contains(t, summary.Results[0].Error, "default branch head")
+	assert.Equal(t, "acme/web", summary.Results[1].Repository)
+	assert.Contains(t, summary.Results[1].Error, "failed to get default branch")
+
+	_, err = h.ScanOrganization(context.Background(), "unknown", 0, nil, zerolog.Nop())
+	assert.ErrorContains(t, err, "organization unknown")
+}
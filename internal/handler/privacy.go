@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// reportPrivacyCheck reports PII findings on sha through their own check run, which is
+// neutral rather than failing so that privacy findings never block merges on their own.
+// Reporting is best effort: failures are logged.
+func reportPrivacyCheck(
+	ctx context.Context,
+	checks ChecksService,
+	owner, repo, sha string,
+	findings []report.Finding,
+	redaction *redact.Policy,
+	detailsURL *string,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) {
+	opts := github.CreateCheckRunOptions{
+		Name:        constants.PrivacyCheckRunName,
+		HeadSHA:     sha,
+		DetailsURL:  detailsURL,
+		ExternalID:  externalID(ctx),
+		Status:      github.Ptr(constants.StatusCompleted),
+		Conclusion:  github.Ptr(constants.ConclusionSuccess),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.Ptr(catalog.Text(messages.PrivacyTitleClean)),
+			Summary: github.Ptr(catalog.Text(messages.PrivacySummaryClean)),
+		},
+	}
+	if len(findings) > 0 {
+		opts.Conclusion = github.Ptr(constants.ConclusionNeutral)
+		opts.Output = &github.CheckRunOutput{
+			Title:   github.Ptr(catalog.Text(messages.PrivacyTitleFindings)),
+			Summary: github.Ptr(privacySummary(catalog, findings)),
+			Text:    github.Ptr(checkRunText(catalog, notify.Details(owner+"/"+repo, findings, nil, redaction))),
+		}
+	}
+
+	if _, _, err := checks.CreateCheckRun(ctx, owner, repo, opts); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgPrivacyCheckFailed)
+	}
+}
+
+// privacySummary counts the PII findings per rule, without the data itself.
+func privacySummary(catalog *messages.Catalog, findings []report.Finding) string {
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[finding.RuleID]++
+	}
+	ruleIDs := make([]string, 0, len(counts))
+	for ruleID := range counts {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+
+	summary := catalog.Format(messages.PrivacySummaryFindings, len(findings))
+	for _, ruleID := range ruleIDs {
+		summary += fmt.Sprintf("- %s: %d\n", ruleID, counts[ruleID])
+	}
+	return summary
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/rs/zerolog"
+)
+
+// checkUpdateTimeout bounds the final check run update, which may run after the scan's own
+// context expired.
+const checkUpdateTimeout = 10 * time.Second
+
+// fullScanCheck reports a full scan through a check run on the scanned commit, updating it
+// with progress while the scan runs. Reporting is best effort: failures are logged and a nil
+// check ignores all updates.
+type fullScanCheck struct {
+	checks      ChecksService
+	owner, repo string
+	id          int64
+	interval    time.Duration
+	started     time.Time
+	lastUpdate  time.Time
+	messages    *messages.Catalog
+	logger      zerolog.Logger
+}
+
+// startFullScanCheck creates the in-progress full scan check run, linking detailsURL when set.
+func startFullScanCheck(
+	ctx context.Context,
+	checks ChecksService,
+	owner, repo, sha string,
+	detailsURL *string,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) *fullScanCheck {
+	checkRun, _, err := checks.CreateCheckRun(ctx, owner, repo, github.CreateCheckRunOptions{
+		Name:       constants.FullScanCheckRunName,
+		HeadSHA:    sha,
+		DetailsURL: detailsURL,
+		ExternalID: externalID(ctx),
+		Status:     github.Ptr(constants.StatusInProgress),
+		Output: &github.CheckRunOutput{
+			Title:   github.Ptr(catalog.Text(messages.FullScanTitleInProgress)),
+			Summary: github.Ptr(catalog.Text(messages.FullScanSummaryStarting)),
+		},
+	})
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgFullScanCheckFailed)
+		return nil
+	}
+
+	logger.Debug().Int64("check_run_id", checkRun.GetID()).Msg(constants.LogMsgCreatedCheckRun)
+	now := time.Now()
+	return &fullScanCheck{
+		checks:     checks,
+		owner:      owner,
+		repo:       repo,
+		id:         checkRun.GetID(),
+		interval:   constants.FullScanProgressInterval,
+		started:    now,
+		lastUpdate: now,
+		messages:   catalog,
+		logger:     logger,
+	}
+}
+
+// progress returns a scanProgress updating the check run at most once per interval.
+func (c *fullScanCheck) progress(ctx context.Context) scanProgress {
+	if c == nil {
+		return nil
+	}
+	return func(scanned, total, findings int) {
+		if scanned >= total || time.Since(c.lastUpdate) < c.interval {
+			return
+		}
+		c.lastUpdate = time.Now()
+		summary := c.messages.Format(messages.FullScanSummaryProgress, scanned, total, findings, c.eta(scanned, total))
+		c.update(ctx, github.UpdateCheckRunOptions{
+			Name: constants.FullScanCheckRunName,
+			Output: &github.CheckRunOutput{
+				Title:   github.Ptr(c.messages.Text(messages.FullScanTitleInProgress)),
+				Summary: github.Ptr(summary),
+			},
+		})
+	}
+}
+
+// eta estimates the remaining scan time from the average time per file so far.
+func (c *fullScanCheck) eta(scanned, total int) time.Duration {
+	if scanned == 0 {
+		return 0
+	}
+	perFile := time.Since(c.started) / time.Duration(scanned)
+	return (perFile * time.Duration(total-scanned)).Round(time.Second)
+}
+
+// complete concludes the check run. An empty text leaves the output text unset.
+func (c *fullScanCheck) complete(ctx context.Context, conclusion, title, summary, text string) {
+	if c == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkUpdateTimeout)
+	defer cancel()
+
+	c.update(ctx, github.UpdateCheckRunOptions{
+		Name:        constants.FullScanCheckRunName,
+		Status:      github.Ptr(constants.StatusCompleted),
+		Conclusion:  github.Ptr(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.Ptr(title),
+			Summary: github.Ptr(summary),
+			Text:    textOrNil(text),
+		},
+	})
+}
+
+func textOrNil(text string) *string {
+	if text == "" {
+		return nil
+	}
+	return github.Ptr(text)
+}
+
+// fail concludes the check run after an error.
+func (c *fullScanCheck) fail(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	title, summary := c.messages.Text(messages.CheckRunTitleError), c.messages.Text(messages.FullScanSummaryError)
+	c.complete(ctx, constants.ConclusionFailure, title, summary, "")
+}
+
+func (c *fullScanCheck) update(ctx context.Context, opts github.UpdateCheckRunOptions) {
+	if _, _, err := c.checks.UpdateCheckRun(ctx, c.owner, c.repo, c.id, opts); err != nil {
+		c.logger.Warn().Err(err).Msg(constants.LogMsgFullScanCheckFailed)
+	}
+}
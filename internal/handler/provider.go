@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/detector"
+	"github.com/omercnet/gitguard/internal/filenames"
+	"github.com/omercnet/gitguard/internal/ignore"
+	"github.com/omercnet/gitguard/internal/logging"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/pathrules"
+	"github.com/omercnet/gitguard/internal/plugin"
+	"github.com/omercnet/gitguard/internal/policy"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/scm"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// ProviderScanHandler scans pushes delivered by an scm.Provider other than GitHub, which is
+// served by SecretScanHandler through the GitHub App. It serves the provider's webhook.
+type ProviderScanHandler struct {
+	// Provider parses the provider's webhooks and reports the results.
+	Provider scm.Provider
+	// Severity classifies findings; nil rates every finding high.
+	Severity *severity.Classifier
+	// Policy maps the highest severity of a scan to the reported conclusion.
+	Policy severity.Policy
+	// Notifier, when set, receives an event for every completed scan.
+	Notifier notify.Notifier
+	// Overrides, when set, drops findings disabled for their file path.
+	Overrides *pathrules.Set
+	// Baseline, when set, stores each repository's grandfathered findings.
+	Baseline store.Store
+	// Findings, when set, is consulted so that suppressed findings no longer fail scans.
+	Findings store.FindingStore
+	// Deduplicate, with Findings set, notifies each finding once, when it is first detected
+	// on any branch.
+	Deduplicate bool
+	// Detectors, when set, provides detectors built from the configured rule packs.
+	Detectors *detector.Factory
+	// Plugins are additional detectors run on every scanned file.
+	Plugins plugin.Set
+	// Filenames, when set, reports files whose name is sensitive, such as .env files and
+	// private keys, regardless of their content.
+	Filenames *filenames.Rules
+	// Scope, when set, limits scans to the repositories its default rules allow.
+	Scope *reposcope.Scope
+	// PolicyRules, when set, can override the reported conclusion and suppress notifications.
+	PolicyRules *policy.Set
+	// DryRun, when set, reports findings without enforcing them in the projects it is
+	// non-zero for: results never fail and no notification is sent.
+	DryRun *reposcope.Setting
+	// Enforcement, when set, limits enforcement to the projects in the rollout; the others
+	// are scanned as dry runs.
+	Enforcement *reposcope.Rollout
+	// Messages, when set, selects the locale of the reported results; projects get the
+	// default locale.
+	Messages *messages.Locales
+	// Templates, when set, replace the built-in check run summaries.
+	Templates *messages.Templates
+	// Redaction, when set, includes secrets in notifications as a masked preview or a hash.
+	Redaction *redact.Policy
+	// Logger logs the deliveries.
+	Logger zerolog.Logger
+
+	// detector, when set and Detectors is not, replaces the default detector.
+	detector *detect.Detector
+	wg       sync.WaitGroup
+}
+
+// ServeHTTP validates and acknowledges a webhook delivery, then scans the pushed commits in
+// the background so the provider does not time the delivery out.
+func (h *ProviderScanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.Logger.With().Str("provider", h.Provider.Name()).Logger()
+	if id := logging.RequestID(r.Context()); id != "" {
+		logger = logger.With().Str("request_id", id).Logger()
+	}
+
+	push, err := h.Provider.ParsePush(r)
+	switch {
+	case errors.Is(err, scm.ErrUnauthorized):
+		logger.Warn().Msg(constants.LogMsgProviderUnauthorized)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	case err != nil:
+		logger.Warn().Err(err).Msg(constants.LogMsgProviderInvalidHook)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	if push == nil || len(push.Commits) == 0 || !strings.HasPrefix(push.Ref, constants.BranchRefPrefix) {
+		logger.Debug().Msg(constants.LogMsgSkippingEvent)
+		return
+	}
+	if !h.Scope.Allowed(0, reposcope.Repository{FullName: push.Repository.FullName}) {
+		logger.Debug().Str("repo", push.Repository.FullName).Msg(constants.LogMsgSkippingOutOfScope)
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		h.HandlePush(ctx, push, logger)
+	}()
+}
+
+// Wait blocks until the scans started by ServeHTTP have finished.
+func (h *ProviderScanHandler) Wait() {
+	h.wg.Wait()
+}
+
+// HandlePush scans each commit of a push and reports the results to the provider.
+func (h *ProviderScanHandler) HandlePush(ctx context.Context, push *scm.Push, logger zerolog.Logger) {
+	logger = logger.With().Str("repo", push.Repository.FullName).Logger()
+
+	d, err := loadDetector(ctx, h.Detectors, h.detector)
+	if err != nil {
+		logger.Error().Err(err).Msg(constants.LogMsgFailedScanCommit)
+		return
+	}
+
+	logger.Info().Int("commit_count", len(push.Commits)).Msg(constants.LogMsgProcessingCommits)
+
+	base := notify.Event{
+		Type:          notify.EventScanCompleted,
+		Scan:          notify.ScanCommit,
+		Repository:    push.Repository.FullName,
+		Ref:           push.Ref,
+		DefaultBranch: push.Repository.DefaultBranch,
+		Links:         notify.Links{Repository: push.Repository.WebURL},
+	}
+	for _, sha := range push.Commits {
+		commitLogger := logger.With().Str("commit_sha", sha).Logger()
+		if err := h.scanCommit(ctx, d, push.Repository, sha, base, commitLogger); err != nil {
+			commitLogger.Error().Err(err).Msg(constants.LogMsgFailedScanCommit)
+		}
+	}
+}
+
+func (h *ProviderScanHandler) scanCommit(
+	ctx context.Context,
+	d *detect.Detector,
+	repo scm.Repository,
+	sha string,
+	base notify.Event,
+	logger zerolog.Logger,
+) error {
+	if err := h.Provider.StartScan(ctx, repo, sha); err != nil {
+		return err
+	}
+
+	files, err := h.Provider.ChangedFiles(ctx, repo, sha)
+	if err != nil {
+		h.reportError(ctx, repo, sha, logger)
+		return fmt.Errorf(constants.ErrGetCommitDiff, err)
+	}
+
+	var allFindings []report.Finding
+	filesScanned := 0
+	names := h.Filenames.For(repo.FullName)
+	var ignored *ignore.Matcher
+	if len(files) > 0 {
+		// A missing ignore file is an error from the provider, and ignores nothing.
+		if content, err := h.Provider.FileContent(ctx, repo, sha, ignore.FileName); err == nil {
+			ignored = ignore.Parse(content)
+		}
+	}
+	for _, file := range files {
+		if file.Removed || ignored.Ignored(file.Path) {
+			continue
+		}
+		// Sensitive file names are reported even when their content is empty.
+		allFindings = append(allFindings, h.Overrides.Filter(names.Detect(file.Path))...)
+		content, err := h.Provider.FileContent(ctx, repo, sha, file.Path)
+		if err != nil || content == "" {
+			continue
+		}
+
+		findings, _ := detectReader(ctx, d, file.Path, strings.NewReader(content))
+		findings = append(findings, h.Plugins.ScanContent(file.Path, content)...)
+		for i := range findings {
+			findings[i].File = file.Path
+		}
+		allFindings = append(allFindings, h.Overrides.Filter(findings)...)
+		filesScanned++
+	}
+
+	allFindings = filterSuppressed(ctx, h.Findings, repo.FullName, allFindings, logger)
+	fresh, baselined, err := splitBaseline(ctx, h.Baseline, repo.FullName, allFindings)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgBaselineFailed)
+	}
+	allFindings = fresh
+
+	base.Commit = sha
+	base.Findings = notify.Summarize(allFindings, h.Severity)
+	decision := decide(h.PolicyRules, base, logger)
+	if isDryRun(h.DryRun, h.Enforcement, repo.FullName) {
+		decision = reportOnly(decision, base, logger)
+	}
+
+	result := h.result(allFindings, decision.Conclusion, len(baselined))
+	base.Conclusion = result.Conclusion
+	base.Details = notify.Details(base.Repository, allFindings, h.Severity, h.Redaction)
+	repeated := h.Deduplicate && recordOccurrences(ctx, h.Findings, &base, logger)
+	result.Summary = checkSummary(h.Templates, base, result.Summary, logger)
+	if err := h.Provider.Report(ctx, repo, sha, result); err != nil {
+		return err
+	}
+
+	logger.Info().
+		Str("conclusion", result.Conclusion).
+		Int("findings", len(allFindings)).
+		Int("baselined", len(baselined)).
+		Int("files_scanned", filesScanned).
+		Msg(constants.LogMsgReportedScan)
+
+	if base.Links.Repository != "" {
+		base.Links.Commit = base.Links.Repository + "/-/commit/" + sha
+	}
+	switch {
+	case decision.Notify && repeated:
+		logger.Info().Int("findings", len(allFindings)).Msg(constants.LogMsgFindingsRepeated)
+	case decision.Notify:
+		sendNotification(ctx, h.Notifier, base, logger)
+	}
+	return nil
+}
+
+// result builds the scan result reported to the provider, following the severity policy
+// unless override is set.
+func (h *ProviderScanHandler) result(findings []report.Finding, override string, baselined int) scm.Result {
+	catalog := h.Messages.For(0)
+	result := scm.Result{
+		Conclusion: constants.ConclusionSuccess,
+		Title:      catalog.Text(messages.CheckRunTitleClean),
+		Summary:    catalog.Text(messages.CheckRunSummaryClean),
+		Findings:   len(findings),
+	}
+	if len(findings) > 0 {
+		highest := h.Severity.Max(findings)
+		result.Conclusion = h.Policy.Conclusion(highest)
+		if override != "" {
+			result.Conclusion = override
+		}
+		result.Title = checkRunTitle(catalog, result.Conclusion)
+		result.Summary = catalog.Format(messages.CheckRunSummarySecrets, len(findings)) +
+			findingsSummary(catalog, highest, findings)
+	}
+	if baselined > 0 {
+		result.Summary += catalog.Format(messages.CheckRunSummaryBaselined, baselined)
+	}
+	return result
+}
+
+func (h *ProviderScanHandler) reportError(ctx context.Context, repo scm.Repository, sha string, logger zerolog.Logger) {
+	catalog := h.Messages.For(0)
+	result := scm.Result{
+		Conclusion: constants.ConclusionFailure,
+		Title:      catalog.Text(messages.CheckRunTitleError),
+		Summary:    catalog.Text(messages.CheckRunSummaryError),
+	}
+	if err := h.Provider.Report(ctx, repo, sha, result); err != nil {
+		logger.Error().Err(err).Msg(constants.LogMsgErrorUpdateFailed)
+	}
+}
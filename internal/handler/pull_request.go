@@ -0,0 +1,530 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/pii"
+	"github.com/omercnet/gitguard/internal/ratelimit"
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/reposcope"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// PullRequestGateHandler gates pull requests the way GitHub push protection gates pushes, for
+// organizations without GitHub Advanced Security. While a pull request adds secrets the
+// severity policy fails on, its gitguard/pre-merge check run concludes action_required and a
+// comment lists them with the ways to resolve them: remove them, rotate them, or dismiss them
+// by commenting the dismiss command with a justification. Only collaborators with write
+// access can dismiss findings.
+type PullRequestGateHandler struct {
+	// Scanner scans pull requests with the commit scan configuration: its detectors, path
+	// overrides, caches, severity policy, baseline, suppressed findings, scope, dry runs,
+	// messages and scheduler.
+	Scanner *SecretScanHandler
+	// BotLogin is the login of the App's bot user, "<slug>[bot]". Only its comments are taken
+	// for the GitGuard comment and dismissal records, so other Apps and Actions workflows
+	// cannot dismiss findings. When empty it is looked up from the App once.
+	BotLogin string
+
+	botMu    sync.Mutex
+	botLogin string
+}
+
+// Handles returns the list of event types this handler can process.
+func (h *PullRequestGateHandler) Handles() []string {
+	return []string{constants.PullRequestEventType, constants.IssueCommentEventType}
+}
+
+// ScanPriority returns the installation of the scan of an opened or updated pull request, or
+// of one whose findings are dismissed, with high priority.
+func (h *PullRequestGateHandler) ScanPriority(eventType string, payload []byte) (int64, ratelimit.Priority, bool) {
+	var installation int64
+	var repository *github.Repository
+	switch eventType {
+	case constants.PullRequestEventType:
+		var event github.PullRequestEvent
+		if err := json.Unmarshal(payload, &event); err != nil || !gatesPullRequest(&event) {
+			return 0, 0, false
+		}
+		installation, repository = githubapp.GetInstallationIDFromEvent(&event), event.GetRepo()
+	case constants.IssueCommentEventType:
+		var event github.IssueCommentEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return 0, 0, false
+		}
+		if _, ok := dismissRequest(&event); !ok {
+			return 0, 0, false
+		}
+		installation, repository = githubapp.GetInstallationIDFromEvent(&event), event.GetRepo()
+	default:
+		return 0, 0, false
+	}
+	repo := reposcope.Repository{
+		FullName: repository.GetFullName(),
+		Archived: repository.GetArchived(),
+		Fork:     repository.GetFork(),
+	}
+	return installation, ratelimit.High, repositoryInScope(h.Scanner.Scope, installation, repo, zerolog.Nop())
+}
+
+// gatesPullRequest reports whether a pull request event is gated: the pull request was opened,
+// reopened or updated.
+func gatesPullRequest(event *github.PullRequestEvent) bool {
+	switch event.GetAction() {
+	case "opened", "reopened", "synchronize":
+		return true
+	}
+	return false
+}
+
+// dismissRequest returns the justification of a pull request comment requesting the dismissal
+// of its findings, and false for other comments.
+func dismissRequest(event *github.IssueCommentEvent) (string, bool) {
+	justification, ok := parseDismissCommand(event.GetComment().GetBody())
+	if event.GetAction() != "created" || !event.GetIssue().IsPullRequest() || !ok {
+		return "", false
+	}
+	return justification, true
+}
+
+// Handle gates opened and updated pull requests and dismisses their findings on request.
+func (h *PullRequestGateHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	logger := zerolog.Ctx(ctx).With().
+		Str("event_type", eventType).
+		Str("delivery_id", deliveryID).
+		Logger()
+
+	switch eventType {
+	case constants.PullRequestEventType:
+		return h.handlePullRequest(ctx, payload, logger)
+	case constants.IssueCommentEventType:
+		return h.handleComment(ctx, payload, logger)
+	}
+	return nil
+}
+
+func (h *PullRequestGateHandler) handlePullRequest(ctx context.Context, payload []byte, logger zerolog.Logger) error {
+	var event github.PullRequestEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf(constants.ErrUnmarshalPREvent, err)
+	}
+	if !gatesPullRequest(&event) {
+		logger.Debug().Msg(constants.LogMsgSkippingEvent)
+		return nil
+	}
+
+	installation := githubapp.GetInstallationIDFromEvent(&event)
+	client, release, err := h.start(ctx, installation, event.GetRepo(), logger)
+	if client == nil || err != nil {
+		return err
+	}
+	defer release()
+
+	pr := event.GetPullRequest()
+	logger = logger.With().Int("pull_request", pr.GetNumber()).Str("commit_sha", pr.GetHead().GetSHA()).Logger()
+	return h.gate(ctx, client, installation, event.GetRepo(), pr, logger)
+}
+
+// start returns a client for a repository in scope, with the detector loaded and a scan slot
+// acquired, and the function releasing the slot. Repositories out of scope get no client.
+func (h *PullRequestGateHandler) start(
+	ctx context.Context, installation int64, repository *github.Repository, logger zerolog.Logger,
+) (*github.Client, func(), error) {
+	s := h.Scanner
+	repo := reposcope.Repository{
+		FullName: repository.GetFullName(),
+		Archived: repository.GetArchived(),
+		Fork:     repository.GetFork(),
+	}
+	if !repositoryInScope(s.Scope, installation, repo, logger) {
+		return nil, nil, nil
+	}
+
+	release, err := s.Scheduler.Acquire(logger.WithContext(ctx), installation, ratelimit.High)
+	if err != nil {
+		return nil, nil, fmt.Errorf(constants.ErrScheduleScan, err)
+	}
+	client, err := s.ClientCreator.NewInstallationClient(installation)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf(constants.ErrCreateGitHubClient, err)
+	}
+	return client, release, nil
+}
+
+// pullRequestScan is the outcome of scanning a pull request.
+type pullRequestScan struct {
+	// Findings are the findings the pull request adds, dismissed ones excluded.
+	Findings []report.Finding
+	// Dismissed is the number of findings dismissed on the pull request.
+	Dismissed int
+	// Comments are the App's own comments on the pull request.
+	Comments []*github.IssueComment
+}
+
+// scanPullRequest scans the changes of a pull request against its base, leaving out PII, the
+// repository's suppressed and baselined findings, and the findings dismissed on the pull
+// request.
+func (h *PullRequestGateHandler) scanPullRequest(
+	ctx context.Context, client *github.Client, owner, repo string, pr *github.PullRequest, logger zerolog.Logger,
+) (*pullRequestScan, error) {
+	s := h.Scanner
+	fullName := owner + "/" + repo
+	findings, _, err := s.scanDiffCached(ctx, client, owner, repo, pr.GetBase().GetSHA(), pr.GetHead().GetSHA(), logger)
+	if err != nil {
+		return nil, err
+	}
+	findings, _ = pii.Split(findings)
+	findings = filterSuppressed(ctx, s.Findings, fullName, findings, logger)
+	findings, _, err = splitBaseline(ctx, s.Baseline, fullName, findings)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgBaselineFailed)
+	}
+
+	bot, err := h.appBot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := listPullRequestComments(ctx, servicesFor(client, s.services).issues, owner, repo, pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+	comments = commentsBy(comments, bot)
+	dismissed := dismissedFingerprints(comments)
+	scan := &pullRequestScan{Comments: comments}
+	for _, finding := range findings {
+		if dismissed[notify.Fingerprint(fullName, finding)] {
+			scan.Dismissed++
+			continue
+		}
+		scan.Findings = append(scan.Findings, finding)
+	}
+	return scan, nil
+}
+
+// gate scans a pull request and reports it on a check run on its head commit. Findings the
+// severity policy fails on conclude it action_required and are listed, with the ways to
+// resolve them, in the pull request's GitGuard comment, which is marked resolved once they
+// are gone. Dry runs conclude neutral without commenting.
+func (h *PullRequestGateHandler) gate(
+	ctx context.Context,
+	client *github.Client,
+	installation int64,
+	repository *github.Repository,
+	pr *github.PullRequest,
+	logger zerolog.Logger,
+) error {
+	s := h.Scanner
+	owner, repo, fullName := repository.GetOwner().GetLogin(), repository.GetName(), repository.GetFullName()
+	catalog := s.Messages.For(installation)
+	svc := servicesFor(client, s.services)
+	checkRun, _, err := svc.checks.CreateCheckRun(ctx, owner, repo, github.CreateCheckRunOptions{
+		Name:       constants.PullRequestCheckRunName,
+		HeadSHA:    pr.GetHead().GetSHA(),
+		DetailsURL: github.Ptr(pr.GetHTMLURL()),
+		ExternalID: externalID(ctx),
+		Status:     github.Ptr(constants.StatusInProgress),
+		Output: &github.CheckRunOutput{
+			Title:   github.Ptr(catalog.Text(messages.CheckRunTitleInProgress)),
+			Summary: github.Ptr(catalog.Text(messages.CheckRunSummaryInProgress)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateCheckRun, err)
+	}
+
+	scan, err := h.scanPullRequest(ctx, client, owner, repo, pr, logger)
+	if err != nil {
+		completeCheckRun(ctx, svc.checks, owner, repo, checkRun.GetID(), constants.ConclusionFailure,
+			catalog.Text(messages.CheckRunTitleError), catalog.Text(messages.CheckRunSummaryError), "", logger)
+		return err
+	}
+
+	findings := scan.Findings
+	highest := s.Severity.Max(findings)
+	conclusion := s.Policy.Conclusion(highest)
+	title, summary, text := catalog.Text(messages.CheckRunTitleClean), catalog.Text(messages.PullRequestSummaryClean), ""
+	if len(findings) > 0 {
+		summary = catalog.Format(messages.PullRequestSummarySecrets, len(findings)) +
+			findingsSummary(catalog, highest, findings)
+		text = checkRunText(catalog, notify.Details(fullName, findings, s.Severity, s.Redaction))
+	}
+	blocked := conclusion == constants.ConclusionFailure || conclusion == constants.ConclusionActionRequired
+	dryRun := isDryRun(s.DryRun, s.Enforcement, fullName)
+	existing := gateComment(scan.Comments)
+	switch {
+	case blocked && dryRun:
+		conclusion = constants.ConclusionNeutral
+	case blocked:
+		conclusion = constants.ConclusionActionRequired
+		summary += catalog.Text(messages.PullRequestSummaryBlocked)
+		body := gateCommentBody(catalog, fullName, findings, s.Severity, s.Redaction)
+		updateGateComment(ctx, svc.issues, owner, repo, pr.GetNumber(), existing, body, logger)
+	case existing != nil:
+		resolved := constants.PullRequestCommentMarker + catalog.Text(messages.PullRequestCommentResolved)
+		updateGateComment(ctx, svc.issues, owner, repo, pr.GetNumber(), existing, resolved, logger)
+	}
+	if len(findings) > 0 {
+		title = checkRunTitle(catalog, conclusion)
+	}
+	if scan.Dismissed > 0 {
+		summary += catalog.Format(messages.PullRequestSummaryDismissed, scan.Dismissed)
+	}
+
+	completeCheckRun(ctx, svc.checks, owner, repo, checkRun.GetID(), conclusion, title, summary, text, logger)
+	logger.Info().
+		Str("conclusion", conclusion).
+		Int("findings", len(findings)).
+		Int("dismissed", scan.Dismissed).
+		Msg(constants.LogMsgPullRequestGated)
+	return nil
+}
+
+// completeCheckRun concludes the pull request check run. Failures are logged.
+func completeCheckRun(
+	ctx context.Context,
+	checks ChecksService,
+	owner, repo string,
+	checkRunID int64,
+	conclusion, title, summary, text string,
+	logger zerolog.Logger,
+) {
+	output := &github.CheckRunOutput{Title: github.Ptr(title), Summary: github.Ptr(summary)}
+	if text != "" {
+		output.Text = github.Ptr(text)
+	}
+	_, _, err := checks.UpdateCheckRun(ctx, owner, repo, checkRunID, github.UpdateCheckRunOptions{
+		Name:        constants.PullRequestCheckRunName,
+		Status:      github.Ptr(constants.StatusCompleted),
+		Conclusion:  github.Ptr(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      output,
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg(constants.LogMsgPullRequestFailed)
+	}
+}
+
+// handleComment dismisses the findings of a pull request when a collaborator with write access
+// comments the dismiss command followed by a justification. The dismissal, its author and the
+// justification are recorded in a reply listing the dismissed fingerprints, and the pull
+// request is gated again.
+func (h *PullRequestGateHandler) handleComment(ctx context.Context, payload []byte, logger zerolog.Logger) error {
+	var event github.IssueCommentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf(constants.ErrUnmarshalComment, err)
+	}
+	justification, ok := dismissRequest(&event)
+	if !ok {
+		return nil
+	}
+
+	installation := githubapp.GetInstallationIDFromEvent(&event)
+	client, release, err := h.start(ctx, installation, event.GetRepo(), logger)
+	if client == nil || err != nil {
+		return err
+	}
+	defer release()
+
+	owner, repo, number := event.GetRepo().GetOwner().GetLogin(), event.GetRepo().GetName(), event.GetIssue().GetNumber()
+	user := event.GetComment().GetUser().GetLogin()
+	logger = logger.With().Int("pull_request", number).Str("user", user).Logger()
+	catalog := h.Scanner.Messages.For(installation)
+	svc := servicesFor(client, h.Scanner.services)
+	reply := func(body string) error {
+		_, _, err := svc.issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &body})
+		if err != nil {
+			logger.Warn().Err(err).Msg(constants.LogMsgPullRequestComment)
+		}
+		return err
+	}
+
+	if justification == "" {
+		_ = reply(catalog.Format(messages.PullRequestDismissReason, user))
+		return nil
+	}
+	permission, _, err := svc.repos.GetPermissionLevel(ctx, owner, repo, user)
+	if err != nil {
+		return fmt.Errorf(constants.ErrGetPermission, err)
+	}
+	if level := permission.GetPermission(); level != "admin" && level != "write" {
+		_ = reply(catalog.Format(messages.PullRequestDismissDenied, user))
+		return nil
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf(constants.ErrGetPullRequest, err)
+	}
+	scan, err := h.scanPullRequest(ctx, client, owner, repo, pr, logger)
+	if err != nil {
+		return err
+	}
+	if len(scan.Findings) == 0 {
+		_ = reply(catalog.Format(messages.PullRequestDismissNothing, user))
+		return nil
+	}
+
+	fullName := event.GetRepo().GetFullName()
+	fingerprints := make([]string, 0, len(scan.Findings))
+	for _, finding := range scan.Findings {
+		fingerprints = append(fingerprints, notify.Fingerprint(fullName, finding))
+	}
+	quoted := "> " + strings.ReplaceAll(justification, "\n", "\n> ")
+	record := fmt.Sprintf(constants.PullRequestDismissalMarker, strings.Join(fingerprints, " ")) +
+		catalog.Format(messages.PullRequestDismissed, user, len(fingerprints), quoted)
+	if err := reply(record); err != nil {
+		return err
+	}
+	logger.Info().
+		Int("findings", len(fingerprints)).
+		Str("justification", justification).
+		Msg(constants.LogMsgFindingsDismissed)
+	return h.gate(ctx, client, installation, event.GetRepo(), pr, logger)
+}
+
+// parseDismissCommand returns the justification of a dismiss command, and whether body is one.
+func parseDismissCommand(body string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(body), constants.PullRequestDismissCommand)
+	if !ok || rest != "" && !unicode.IsSpace(rune(rest[0])) {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// listPullRequestComments returns the comments of a pull request, oldest first.
+func listPullRequestComments(
+	ctx context.Context, issues IssuesService, owner, repo string, number int,
+) ([]*github.IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: constants.PullRequestCommentListMax},
+	}
+	var comments []*github.IssueComment
+	for range constants.PullRequestCommentMaxPages {
+		page, resp, err := issues.ListComments(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf(constants.ErrListPRComments, err)
+		}
+		comments = append(comments, page...)
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.ListOptions.Page = resp.NextPage
+	}
+	return comments, nil
+}
+
+// appBot returns the login of the App's bot user: BotLogin, or the App's slug with the
+// "[bot]" suffix, looked up once.
+func (h *PullRequestGateHandler) appBot(ctx context.Context) (string, error) {
+	if h.BotLogin != "" {
+		return h.BotLogin, nil
+	}
+	h.botMu.Lock()
+	defer h.botMu.Unlock()
+	if h.botLogin != "" {
+		return h.botLogin, nil
+	}
+	client, err := h.Scanner.ClientCreator.NewAppClient()
+	if err != nil {
+		return "", fmt.Errorf(constants.ErrCreateGitHubClient, err)
+	}
+	app, _, err := client.Apps.Get(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf(constants.ErrGetApp, err)
+	}
+	h.botLogin = app.GetSlug() + "[bot]"
+	return h.botLogin, nil
+}
+
+// commentsBy returns the comments the bot user login wrote. Markers copied into the comments
+// of people or of other Apps are ignored, so they cannot dismiss findings.
+func commentsBy(comments []*github.IssueComment, login string) []*github.IssueComment {
+	var written []*github.IssueComment
+	for _, comment := range comments {
+		user := comment.GetUser()
+		if user.GetType() == "Bot" && strings.EqualFold(user.GetLogin(), login) {
+			written = append(written, comment)
+		}
+	}
+	return written
+}
+
+// gateComment returns the GitGuard comment among the App's comments on a pull request, or nil.
+func gateComment(comments []*github.IssueComment) *github.IssueComment {
+	for _, comment := range comments {
+		if strings.HasPrefix(comment.GetBody(), constants.PullRequestCommentMarker) {
+			return comment
+		}
+	}
+	return nil
+}
+
+// dismissedFingerprints returns the fingerprints recorded in the dismissals among the App's
+// comments on a pull request.
+func dismissedFingerprints(comments []*github.IssueComment) map[string]bool {
+	prefix, suffix, _ := strings.Cut(strings.TrimSuffix(constants.PullRequestDismissalMarker, "\n"), "%s")
+	dismissed := make(map[string]bool)
+	for _, comment := range comments {
+		rest, ok := strings.CutPrefix(comment.GetBody(), prefix)
+		if !ok {
+			continue
+		}
+		list, _, _ := strings.Cut(rest, suffix)
+		for _, fingerprint := range strings.Fields(list) {
+			dismissed[fingerprint] = true
+		}
+	}
+	return dismissed
+}
+
+// gateCommentBody lists the findings blocking a pull request, with their secrets only as
+// redaction allows, and the ways to resolve them.
+func gateCommentBody(
+	catalog *messages.Catalog,
+	repository string,
+	findings []report.Finding,
+	classifier *severity.Classifier,
+	redaction *redact.Policy,
+) string {
+	body := constants.PullRequestCommentMarker + catalog.Format(messages.PullRequestCommentHeading, len(findings))
+	details := sortedDetails(repository, findings, classifier, redaction)
+	body += findingsTable(catalog, details, messages.PullRequestCommentMore)
+	return body + catalog.Text(messages.PullRequestCommentOptions)
+}
+
+// updateGateComment posts body as the GitGuard comment of a pull request, editing the existing
+// one, if any, rather than commenting again. Failures are logged.
+func updateGateComment(
+	ctx context.Context,
+	issues IssuesService,
+	owner, repo string,
+	number int,
+	existing *github.IssueComment,
+	body string,
+	logger zerolog.Logger,
+) {
+	var err error
+	switch {
+	case existing == nil:
+		_, _, err = issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &body})
+	case existing.GetBody() != body:
+		_, _, err = issues.EditComment(ctx, owner, repo, existing.GetID(), &github.IssueComment{Body: &body})
+	}
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgPullRequestComment)
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/rs/zerolog"
+)
+
+// commitScan is the result of the scan of one commit of a push: the event describing it, or
+// the error that stopped it.
+type commitScan struct {
+	sha   string
+	event notify.Event
+	err   error
+}
+
+// conclusionRanks orders check run conclusions from the least to the most severe.
+var conclusionRanks = map[string]int{
+	constants.ConclusionSuccess:        0,
+	constants.ConclusionNeutral:        1,
+	constants.ConclusionActionRequired: 2,
+	constants.ConclusionFailure:        3,
+}
+
+// reportPushSummary creates a completed check run on the head commit of a push listing the
+// findings and result of each of its commits, so reviewers have one place to look. Its
+// conclusion is the most severe of theirs, and a commit that failed to scan fails it.
+func (h *SecretScanHandler) reportPushSummary(
+	ctx context.Context,
+	checks ChecksService,
+	owner, repo string,
+	scans []commitScan,
+	base notify.Event,
+	logger zerolog.Logger,
+) {
+	catalog := h.Messages.For(base.Installation)
+	head := scans[len(scans)-1].sha
+	conclusion, total, withFindings, failed := constants.ConclusionSuccess, 0, 0, 0
+	var rows strings.Builder
+	for i, scan := range scans {
+		result := scan.event.Conclusion
+		if scan.err != nil {
+			result = constants.ConclusionFailure
+			failed++
+		}
+		if conclusionRanks[result] > conclusionRanks[conclusion] {
+			conclusion = result
+		}
+		total += scan.event.Findings.Total
+		if scan.event.Findings.Total > 0 {
+			withFindings++
+		}
+		if i < constants.PushSummaryMaxCommits {
+			rows.WriteString(pushSummaryRow(base.Links.Repository, scan))
+		}
+	}
+
+	var summary string
+	switch {
+	case total > 0:
+		summary = catalog.Format(messages.PushSummarySecrets, total, withFindings, len(scans))
+	case failed == 0:
+		summary = catalog.Format(messages.PushSummaryClean, len(scans))
+	}
+	if failed > 0 {
+		summary += catalog.Format(messages.PushSummaryErrors, failed)
+	}
+	summary += catalog.Text(messages.PushSummaryTable) + rows.String()
+	if more := len(scans) - constants.PushSummaryMaxCommits; more > 0 {
+		summary += catalog.Format(messages.PushSummaryMore, more)
+	}
+
+	title := checkRunTitle(catalog, conclusion)
+	switch {
+	case conclusion == constants.ConclusionSuccess:
+		title = catalog.Text(messages.CheckRunTitleClean)
+	case total == 0:
+		title = catalog.Text(messages.CheckRunTitleError)
+	}
+	opts := github.CreateCheckRunOptions{
+		Name:        constants.PushSummaryCheckRunName,
+		HeadSHA:     head,
+		DetailsURL:  checkRunDetailsURL(h.DetailsURL, base.Repository, head),
+		ExternalID:  externalID(ctx),
+		Status:      github.Ptr(constants.StatusCompleted),
+		Conclusion:  github.Ptr(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      &github.CheckRunOutput{Title: github.Ptr(title), Summary: github.Ptr(summary)},
+	}
+	if _, _, err := checks.CreateCheckRun(ctx, owner, repo, opts); err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgPushSummaryFailed)
+	}
+}
+
+// pushSummaryRow renders the row of a commit in the push summary table, linking the commit
+// and its check run when known.
+func pushSummaryRow(repository string, scan commitScan) string {
+	commit := "`" + scan.sha[:min(len(scan.sha), 7)] + "`"
+	if repository != "" {
+		commit = "[" + commit + "](" + repository + "/commit/" + scan.sha + ")"
+	}
+	result := scan.event.Conclusion
+	if scan.err != nil {
+		result = "error"
+	}
+	if link := scan.event.Links.CheckRun; link != "" {
+		result = "[" + result + "](" + link + ")"
+	}
+	return fmt.Sprintf("| %s | %d | %s |\n", commit, scan.event.Findings.Total, result)
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// minRedactableSecretLength guards against replacing short values that are likely to
+// appear elsewhere in the file.
+const minRedactableSecretLength = 8
+
+// openRemediationPR opens a pull request against the default branch that replaces every
+// detected secret value with a placeholder. The branch name is derived from the scanned
+// head commit, so repeated scans of the same commit do not open duplicate pull requests.
+func (h *FullRepoScanHandler) openRemediationPR(
+	ctx context.Context,
+	client *github.Client,
+	owner, repo, baseBranch string,
+	gitRepo *git.Repository,
+	findings []report.Finding,
+	issueNumber int,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) error {
+	ref, err := gitRepo.Head()
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateRemediation, err)
+	}
+	headSHA := ref.Hash().String()
+
+	entries, err := buildRemediationEntries(gitRepo, findings)
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateRemediation, err)
+	}
+	if len(entries) == 0 {
+		logger.Info().Msg(constants.LogMsgNothingToRedact)
+		return nil
+	}
+
+	commit, err := gitRepo.CommitObject(ref.Hash())
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateRemediation, err)
+	}
+
+	tree, _, err := client.Git.CreateTree(ctx, owner, repo, commit.TreeHash.String(), entries)
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateRemediation, err)
+	}
+
+	newCommit, _, err := client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message: github.Ptr(constants.RemediationCommitMessage),
+		Tree:    tree,
+		Parents: []*github.Commit{{SHA: github.Ptr(headSHA)}},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateRemediation, err)
+	}
+
+	branch := constants.RemediationBranchPrefix + headSHA[:12]
+	_, resp, err := client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.Ptr(constants.BranchRefPrefix + branch),
+		Object: &github.GitObject{SHA: newCommit.SHA},
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+			logger.Info().Str("branch", branch).Msg(constants.LogMsgRemediationExists)
+			return nil
+		}
+		return fmt.Errorf(constants.ErrCreateRemediation, err)
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.Ptr(catalog.Text(messages.RemediationTitle)),
+		Head:  github.Ptr(branch),
+		Base:  github.Ptr(baseBranch),
+		Body:  github.Ptr(buildRemediationBody(catalog, findings, issueNumber)),
+	})
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateRemediation, err)
+	}
+
+	logger.Info().
+		Int("pull_request", pr.GetNumber()).
+		Int("files", len(entries)).
+		Msg(constants.LogMsgCreatedRemediation)
+	return nil
+}
+
+// buildRemediationEntries returns tree entries for every file whose content changes after
+// redacting the secrets found in it.
+func buildRemediationEntries(gitRepo *git.Repository, findings []report.Finding) ([]*github.TreeEntry, error) {
+	ref, err := gitRepo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get head reference: %w", err)
+	}
+	commit, err := gitRepo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit object: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	secretsByFile := make(map[string][]string)
+	for _, finding := range findings {
+		if finding.File == "" || len(finding.Secret) < minRedactableSecretLength {
+			continue
+		}
+		secretsByFile[finding.File] = append(secretsByFile[finding.File], finding.Secret)
+	}
+
+	paths := make([]string, 0, len(secretsByFile))
+	for path := range secretsByFile {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var entries []*github.TreeEntry
+	for _, path := range paths {
+		file, err := tree.File(path)
+		if err != nil {
+			if errors.Is(err, object.ErrFileNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		redacted, changed := redactContent(content, secretsByFile[path])
+		if !changed {
+			continue
+		}
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.Ptr(path),
+			Mode:    github.Ptr(fmt.Sprintf("%06o", uint32(file.Mode))),
+			Type:    github.Ptr("blob"),
+			Content: github.Ptr(redacted),
+		})
+	}
+
+	return entries, nil
+}
+
+// redactContent replaces every occurrence of secrets in content with the redaction placeholder.
+func redactContent(content string, secrets []string) (string, bool) {
+	redacted := content
+	for _, secret := range secrets {
+		redacted = strings.ReplaceAll(redacted, secret, constants.RedactionPlaceholder)
+	}
+	return redacted, redacted != content
+}
+
+// buildRemediationBody describes the redaction pull request in the language of catalog,
+// without exposing secret values.
+func buildRemediationBody(catalog *messages.Catalog, findings []report.Finding, issueNumber int) string {
+	body := catalog.Format(messages.RemediationIntro, constants.RedactionPlaceholder)
+	if issueNumber > 0 {
+		body += catalog.Format(messages.RemediationIssue, issueNumber)
+	}
+
+	body += catalog.Text(messages.RemediationLocationsHeading)
+	for _, finding := range findings {
+		if finding.File == "" || len(finding.Secret) < minRedactableSecretLength {
+			continue
+		}
+		body += catalog.Format(messages.RemediationLocation, finding.File, finding.StartLine, finding.RuleID)
+	}
+
+	body += catalog.Text(messages.RemediationActions)
+	return body
+}
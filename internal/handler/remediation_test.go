@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func newTestRepository(t *testing.T, files map[string]string) *git.Repository {
+	t.Helper()
+
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(t, err)
+	commitFiles(t, repo, files)
+	return repo
+}
+
+// commitFiles writes files to the worktree of repo and commits them, returning the commit hash.
+func commitFiles(t *testing.T, repo *git.Repository, files map[string]string) string {
+	t.Helper()
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	for name, content := range files {
+		f, err := worktree.Filesystem.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		_, err = worktree.Add(name)
+		require.NoError(t, err)
+	}
+
+	hash, err := worktree.Commit("update", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	return hash.String()
+}
+
+func TestRedactContent(t *testing.T) {
+	content := "token=ghp_abcdefghijklmnop\nother=ghp_abcdefghijklmnop\n"
+
+	redacted, changed := redactContent(content, []string{"ghp_abcdefghijklmnop"})
+	assert.True(t, changed)
+	assert.Equal(t, "token="+constants.RedactionPlaceholder+"\nother="+constants.RedactionPlaceholder+"\n", redacted)
+
+	_, changed = redactContent(content, []string{"not-present-secret"})
+	assert.False(t, changed, "Should report unchanged content when no secret matches")
+}
+
+func TestBuildRemediationEntries(t *testing.T) {
+	repo := newTestRepository(t, map[string]string{
+		"config.env": "API_KEY=supersecretvalue123\n",
+		"short.env":  "PIN=1234\n",
+		"clean.txt":  "nothing here\n",
+	})
+
+	findings := []report.Finding{
+		{File: "config.env", Secret: "supersecretvalue123", RuleID: "generic-api-key", StartLine: 1},
+		{File: "short.env", Secret: "1234", RuleID: "generic-api-key", StartLine: 1},
+		{File: "deleted.env", Secret: "anothersecretvalue", RuleID: "generic-api-key", StartLine: 1},
+	}
+
+	entries, err := buildRemediationEntries(repo, findings)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "Should skip short secrets and files missing from the head tree")
+
+	assert.Equal(t, "config.env", entries[0].GetPath())
+	assert.Equal(t, "100644", entries[0].GetMode())
+	assert.Equal(t, "blob", entries[0].GetType())
+	assert.Equal(t, "API_KEY="+constants.RedactionPlaceholder+"\n", entries[0].GetContent())
+}
+
+func TestBuildRemediationBody(t *testing.T) {
+	findings := []report.Finding{
+		{File: "config.env", Secret: "supersecretvalue123", RuleID: "generic-api-key", StartLine: 3},
+	}
+
+	body := buildRemediationBody(nil, findings, 42)
+	assert.Contains(t, body, "#42", "Should link the security issue")
+	assert.Contains(t, body, "`config.env` (line 3, generic-api-key)")
+	assert.NotContains(t, body, "supersecretvalue123", "Should never include the secret value")
+
+	assert.NotContains(t, buildRemediationBody(nil, findings, 0), "Related security issue")
+}
+
+func TestBuildRemediationBody_Localized(t *testing.T) {
+	catalog, err := messages.Parse("de", []byte(
+		"remediation.issue: \"Zugehöriges Sicherheitsproblem: #%d\\n\\n\"\n"+
+			"remediation.location: \"- `%s` (Zeile %d, %s)\\n\"\n",
+	))
+	require.NoError(t, err)
+	findings := []report.Finding{
+		{File: "config.env", Secret: "supersecretvalue123", RuleID: "generic-api-key", StartLine: 3},
+	}
+
+	body := buildRemediationBody(catalog, findings, 42)
+	assert.Contains(t, body, "Zugehöriges Sicherheitsproblem: #42")
+	assert.Contains(t, body, "`config.env` (Zeile 3, generic-api-key)")
+	assert.Contains(t, body, "### Before Merging", "Should fall back to English for untranslated messages")
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+)
+
+// RotationTicketHandler files a ticket to rotate the secrets of a failing commit check run
+// when its "Create ticket" action is clicked, pre-populated with the findings of the check
+// run's report: an issue in the repository, or a ticket in the downstream ticketing system.
+// The ticket is then linked from the check run summary, and later clicks file no other.
+type RotationTicketHandler struct {
+	githubapp.ClientCreator
+	// Tickets, when set, files tickets in a ticketing system such as Jira instead of as
+	// repository issues.
+	Tickets notify.Ticketer
+	// Messages, when set, selects the locale of each installation's tickets.
+	Messages *messages.Locales
+	// services, when set, replaces the GitHub APIs tickets are filed and linked through.
+	services func(*github.Client) services
+}
+
+// Handles returns the list of event types this handler can process.
+func (h *RotationTicketHandler) Handles() []string {
+	return []string{constants.CheckRunEventType}
+}
+
+// Handle files the rotation ticket of a check run whose rotation action was requested.
+func (h *RotationTicketHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
+	var event github.CheckRunEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf(constants.ErrUnmarshalCheckRun, err)
+	}
+	action := event.GetRequestedAction()
+	if event.GetAction() != "requested_action" || action == nil || action.Identifier != constants.RotationActionID {
+		return nil
+	}
+
+	checkRun := event.GetCheckRun()
+	repository := event.GetRepo()
+	logger := zerolog.Ctx(ctx).With().
+		Str("event_type", eventType).
+		Str("delivery_id", deliveryID).
+		Str("repository", repository.GetFullName()).
+		Int64("check_run_id", checkRun.GetID()).
+		Logger()
+	if strings.Contains(checkRun.GetOutput().GetSummary(), constants.RotationTicketMarker) {
+		logger.Info().Msg(constants.LogMsgRotationTicketExists)
+		return nil
+	}
+	findings, ok := parseCheckRunReport(checkRun.GetOutput().GetText())
+	if !ok || findings.Total == 0 {
+		return fmt.Errorf(constants.ErrReadCheckRunReport, checkRun.GetID())
+	}
+
+	installation := githubapp.GetInstallationIDFromEvent(&event)
+	client, err := h.NewInstallationClient(installation)
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateGitHubClient, err)
+	}
+	owner, repo := repository.GetOwner().GetLogin(), repository.GetName()
+	catalog := h.Messages.For(installation)
+	ticket := rotationTicket(catalog, repository.GetFullName(), checkRun, findings)
+
+	svc := servicesFor(client, h.services)
+	link, err := h.file(ctx, svc.issues, owner, repo, ticket)
+	if err != nil {
+		return fmt.Errorf(constants.ErrCreateRotationTicket, err)
+	}
+	logger.Info().Str("ticket", link).Int("findings", findings.Total).Msg(constants.LogMsgRotationTicketCreated)
+
+	summary := checkRun.GetOutput().GetSummary() + catalog.Format(messages.RotationTicketFiled, link) +
+		constants.RotationTicketMarker
+	_, _, err = svc.checks.UpdateCheckRun(ctx, owner, repo, checkRun.GetID(), github.UpdateCheckRunOptions{
+		Name: checkRun.GetName(),
+		Output: &github.CheckRunOutput{
+			Title:   checkRun.GetOutput().Title,
+			Summary: github.Ptr(summary),
+			Text:    checkRun.GetOutput().Text,
+		},
+	})
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgRotationTicketNoUpdate)
+	}
+	return nil
+}
+
+// file files the ticket with the configured ticketer, or as an issue in the repository, and
+// returns its URL.
+func (h *RotationTicketHandler) file(
+	ctx context.Context, service IssuesService, owner, repo string, ticket notify.Ticket,
+) (string, error) {
+	if h.Tickets != nil {
+		return h.Tickets.File(ctx, ticket)
+	}
+	issue, _, err := service.Create(ctx, owner, repo, &github.IssueRequest{
+		Title:  github.Ptr(ticket.Title),
+		Body:   github.Ptr(ticket.Body),
+		Labels: &ticket.Labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	return issue.GetHTMLURL(), nil
+}
+
+// rotationTicket describes the findings of a check run's report as a checklist of secrets to
+// rotate, listing at most constants.RotationTicketMax of them. Secrets are never included,
+// even redacted, as tickets may leave GitHub.
+func rotationTicket(
+	catalog *messages.Catalog, repository string, checkRun *github.CheckRun, findings checkRunReport,
+) notify.Ticket {
+	body := catalog.Format(messages.RotationTicketIntro,
+		findings.Total, shortSHA(checkRun.GetHeadSHA()), repository, checkRun.GetHTMLURL())
+	listed := findings.Findings[:min(len(findings.Findings), constants.RotationTicketMax)]
+	for _, finding := range listed {
+		body += catalog.Format(messages.RotationTicketFinding,
+			finding.File, finding.Line, finding.RuleID, finding.Severity, finding.Fingerprint)
+	}
+	if findings.Total > len(listed) {
+		body += catalog.Format(messages.RotationTicketMore, findings.Total-len(listed))
+	}
+	return notify.Ticket{
+		Title:  catalog.Format(messages.RotationTicketTitle, findings.Total, repository),
+		Body:   body,
+		Labels: []string{constants.IssueLabel, constants.RotationTicketLabel},
+	}
+}
+
+// rotationActions returns the check run action requesting a rotation ticket.
+func rotationActions(catalog *messages.Catalog) []*github.CheckRunAction {
+	return []*github.CheckRunAction{{
+		Label:       catalog.Text(messages.RotationActionLabel),
+		Description: catalog.Text(messages.RotationActionDescription),
+		Identifier:  constants.RotationActionID,
+	}}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/labels"
+)
+
+// ChecksService creates and updates check runs. *github.ChecksService implements it.
+type ChecksService interface {
+	CreateCheckRun(
+		ctx context.Context, owner, repo string, opts github.CreateCheckRunOptions,
+	) (*github.CheckRun, *github.Response, error)
+	UpdateCheckRun(
+		ctx context.Context, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions,
+	) (*github.CheckRun, *github.Response, error)
+}
+
+// ReposService comments on commits and reads collaborator permissions.
+// *github.RepositoriesService implements it.
+type ReposService interface {
+	ListCommitComments(
+		ctx context.Context, owner, repo, sha string, opts *github.ListOptions,
+	) ([]*github.RepositoryComment, *github.Response, error)
+	CreateComment(
+		ctx context.Context, owner, repo, sha string, comment *github.RepositoryComment,
+	) (*github.RepositoryComment, *github.Response, error)
+	GetPermissionLevel(
+		ctx context.Context, owner, repo, user string,
+	) (*github.RepositoryPermissionLevel, *github.Response, error)
+}
+
+// IssuesService opens, lists, labels and edits issues, assigns them to milestones, and
+// posts and edits issue and pull request comments. *github.IssuesService implements it.
+type IssuesService interface {
+	labels.Service
+	Create(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	Edit(
+		ctx context.Context, owner, repo string, number int, issue *github.IssueRequest,
+	) (*github.Issue, *github.Response, error)
+	ListByRepo(
+		ctx context.Context, owner, repo string, opts *github.IssueListByRepoOptions,
+	) ([]*github.Issue, *github.Response, error)
+	AddLabelsToIssue(
+		ctx context.Context, owner, repo string, number int, names []string,
+	) ([]*github.Label, *github.Response, error)
+	ListMilestones(
+		ctx context.Context, owner, repo string, opts *github.MilestoneListOptions,
+	) ([]*github.Milestone, *github.Response, error)
+	CreateMilestone(
+		ctx context.Context, owner, repo string, milestone *github.Milestone,
+	) (*github.Milestone, *github.Response, error)
+	ListComments(
+		ctx context.Context, owner, repo string, number int, opts *github.IssueListCommentsOptions,
+	) ([]*github.IssueComment, *github.Response, error)
+	CreateComment(
+		ctx context.Context, owner, repo string, number int, comment *github.IssueComment,
+	) (*github.IssueComment, *github.Response, error)
+	EditComment(
+		ctx context.Context, owner, repo string, commentID int64, comment *github.IssueComment,
+	) (*github.IssueComment, *github.Response, error)
+}
+
+// services are the GitHub APIs scans report through.
+type services struct {
+	checks ChecksService
+	repos  ReposService
+	issues IssuesService
+}
+
+// clientServices returns the services of client.
+func clientServices(client *github.Client) services {
+	return services{checks: client.Checks, repos: client.Repositories, issues: client.Issues}
+}
+
+// servicesFor returns the services scans report through for client: those of the injected
+// function when set, and otherwise the client's own.
+func servicesFor(client *github.Client, injected func(*github.Client) services) services {
+	if injected != nil {
+		return injected(client)
+	}
+	return clientServices(client)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/messages"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/rs/zerolog"
+)
+
+// slaDue returns when the secrets of a security issue opened now, whose findings' highest
+// severity is highest, must be resolved, and whether the severity has an SLA.
+func (h *FullRepoScanHandler) slaDue(highest severity.Level) (time.Time, bool) {
+	return h.SLA.Due(highest, time.Now())
+}
+
+// withSLAMilestone assigns a security issue about to be opened to the milestone of its due
+// date when SLA milestones are enabled.
+func (h *FullRepoScanHandler) withSLAMilestone(
+	ctx context.Context,
+	service IssuesService,
+	owner, repo string,
+	request *github.IssueRequest,
+	due time.Time,
+	logger zerolog.Logger,
+) {
+	if !h.SLAMilestones {
+		return
+	}
+	milestone, err := slaMilestone(ctx, service, owner, repo, due)
+	if err != nil {
+		logger.Warn().Err(err).Msg(constants.LogMsgIssueSLAFailed)
+		return
+	}
+	request.Milestone = milestone.Number
+}
+
+// commentSLADue comments the due date of a security issue when SLA milestones are disabled.
+func (h *FullRepoScanHandler) commentSLADue(
+	ctx context.Context,
+	service IssuesService,
+	owner, repo string,
+	issue *github.Issue,
+	highest severity.Level,
+	due time.Time,
+	catalog *messages.Catalog,
+	logger zerolog.Logger,
+) {
+	if h.SLAMilestones {
+		return
+	}
+	comment := &github.IssueComment{
+		Body: github.Ptr(catalog.Format(messages.IssueSLADue, highest, due.UTC().Format(time.DateOnly))),
+	}
+	if _, _, err := service.CreateComment(ctx, owner, repo, issue.GetNumber(), comment); err != nil {
+		logger.Warn().Err(err).Int("issue_number", issue.GetNumber()).Msg(constants.LogMsgIssueSLAFailed)
+	}
+}
+
+// slaMilestone returns the open milestone of the security issues due on the day of due,
+// creating it when missing. At most constants.IssueListMaxPages pages of open milestones
+// are searched.
+func slaMilestone(
+	ctx context.Context, service IssuesService, owner, repo string, due time.Time,
+) (*github.Milestone, error) {
+	day := due.UTC().Format(time.DateOnly)
+	title := fmt.Sprintf(constants.SLAMilestoneTitle, day)
+	opts := &github.MilestoneListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: constants.IssueListMax},
+	}
+	for range constants.IssueListMaxPages {
+		milestones, resp, err := service.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list milestones: %w", err)
+		}
+		for _, milestone := range milestones {
+			if milestone.GetTitle() == title {
+				return milestone, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.ListOptions.Page = resp.NextPage
+	}
+
+	milestone, _, err := service.CreateMilestone(ctx, owner, repo, &github.Milestone{
+		Title:       github.Ptr(title),
+		Description: github.Ptr(fmt.Sprintf(constants.SLAMilestoneDesc, day)),
+		DueOn:       &github.Timestamp{Time: due.UTC()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create milestone %q: %w", title, err)
+	}
+	return milestone, nil
+}
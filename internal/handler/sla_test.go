@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// slaAPI serves the issues, labels and milestones of owner/repo, recording the issues,
+// comments and milestones created.
+type slaAPI struct {
+	t          *testing.T
+	milestones []*github.Milestone
+	issues     []github.IssueRequest
+	comments   []string
+}
+
+func (a *slaAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/")
+	switch {
+	case r.Method == http.MethodGet && path == "issues":
+		_, _ = w.Write([]byte(`[]`))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "labels/"):
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodGet && path == "milestones":
+		_ = json.NewEncoder(w).Encode(a.milestones)
+	case r.Method == http.MethodPost && path == "milestones":
+		var milestone github.Milestone
+		assert.NoError(a.t, json.NewDecoder(r.Body).Decode(&milestone))
+		milestone.Number = github.Ptr(len(a.milestones) + 1)
+		a.milestones = append(a.milestones, &milestone)
+		_ = json.NewEncoder(w).Encode(milestone)
+	case r.Method == http.MethodPost && path == "issues":
+		var req github.IssueRequest
+		assert.NoError(a.t, json.NewDecoder(r.Body).Decode(&req))
+		a.issues = append(a.issues, req)
+		_, _ = w.Write([]byte(`{"number": 1}`))
+	case r.Method == http.MethodPost && path == "issues/1/comments":
+		var comment github.IssueComment
+		assert.NoError(a.t, json.NewDecoder(r.Body).Decode(&comment))
+		a.comments = append(a.comments, comment.GetBody())
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		a.t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// openSecurityIssues runs createSecurityIssues for findings against api.
+func openSecurityIssues(t *testing.T, h *FullRepoScanHandler, api *slaAPI, findings []report.Finding) {
+	t.Helper()
+	server := httptest.NewServer(api)
+	defer server.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	gitRepo := newTestRepository(t, map[string]string{"README.md": "hello"})
+	_, err := h.createSecurityIssues(
+		context.Background(), client.Issues, "owner", "repo", gitRepo, findings, notify.Event{}, nil, zerolog.Nop(),
+	)
+	require.NoError(t, err)
+}
+
+func TestFullRepoScanHandler_SLADueComment(t *testing.T) {
+	h := &FullRepoScanHandler{
+		IssueGrouping: constants.IssueGroupingRule,
+		Severity:      severity.NewClassifier(map[string]severity.Level{"generic-api-key": severity.Low}),
+		SLA:           severity.SLA{severity.Critical: 48 * time.Hour},
+	}
+	api := &slaAPI{t: t}
+	openSecurityIssues(t, h, api, []report.Finding{{RuleID: "private-key", File: "id_rsa"}})
+
+	due := time.Now().Add(48 * time.Hour).UTC().Format(time.DateOnly)
+	require.Len(t, api.comments, 1)
+	assert.Contains(t, api.comments[0], "secrets of critical severity must be rotated and removed by **"+due+"**")
+	assert.Nil(t, api.issues[0].Milestone)
+
+	api = &slaAPI{t: t}
+	openSecurityIssues(t, h, api, []report.Finding{{RuleID: "generic-api-key", File: "config.yml"}})
+	require.Len(t, api.issues, 1)
+	assert.Empty(t, api.comments, "Severities without an SLA get no due date")
+}
+
+func TestFullRepoScanHandler_SLAMilestones(t *testing.T) {
+	h := &FullRepoScanHandler{
+		IssueGrouping: constants.IssueGroupingRule,
+		Severity:      severity.NewClassifier(nil),
+		SLA:           severity.SLA{severity.Critical: 24 * time.Hour, severity.High: 7 * 24 * time.Hour},
+		SLAMilestones: true,
+	}
+	day := time.Now().Add(24 * time.Hour).UTC().Format(time.DateOnly)
+	api := &slaAPI{t: t, milestones: []*github.Milestone{
+		{Number: github.Ptr(7), Title: github.Ptr("GitGuard SLA " + day)},
+	}}
+	openSecurityIssues(t, h, api, []report.Finding{
+		{RuleID: "private-key", File: "id_rsa"},
+		{RuleID: "github-pat", File: "ci.env"},
+	})
+
+	require.Len(t, api.issues, 2)
+	milestones := make(map[string]int)
+	for _, issue := range api.issues {
+		milestones[issue.GetTitle()] = issue.GetMilestone()
+	}
+	require.Len(t, api.milestones, 2, "The high issue gets a new milestone")
+	created := api.milestones[1]
+	assert.Equal(t, "GitGuard SLA "+time.Now().Add(7*24*time.Hour).UTC().Format(time.DateOnly), created.GetTitle())
+	assert.False(t, created.GetDueOn().IsZero())
+	assert.Equal(t, map[string]int{
+		"🚨 GitGuard: private-key Secrets Detected": 7,
+		"🚨 GitGuard: github-pat Secrets Detected":  created.GetNumber(),
+	}, milestones, "The critical issue joins the open milestone of its due date")
+	assert.Empty(t, api.comments, "Milestones replace the due date comment")
+}
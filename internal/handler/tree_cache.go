@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/omercnet/gitguard/internal/cache"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/rs/zerolog"
+)
+
+// treeScan is the cached scan of the root tree or of a top-level directory. A hit skips
+// every file of the tree but those to revisit; a miss records the files to revisit so the
+// next scan of the same tree can skip the others.
+type treeScan struct {
+	key     string
+	hit     bool
+	revisit map[string]bool
+}
+
+// treeScans are the cached scans of a repository tree, by the path of the tree: "" for the
+// root and "dir/" for the top-level directories.
+type treeScans map[string]*treeScan
+
+// lookupTreeScans fetches the cached scans of tree and of its top-level directories. Only
+// the root is looked up when it hits, and nothing when the cache is disabled. digest, that
+// of the remote rules the scan's detector was built from, and ignoreHash, the blob hash of
+// the root's ignore file, are part of the keys, since directories are scanned again when
+// the rules or the files it ignores change.
+func (h *FullRepoScanHandler) lookupTreeScans(
+	ctx context.Context, tree *object.Tree, digest, ignoreHash string,
+) treeScans {
+	if h.ScanCache == nil {
+		return nil
+	}
+	version := rulesVersion(h.RulesVersion, digest)
+	if ignoreHash != "" {
+		version += "+" + ignoreHash
+	}
+	scans := treeScans{"": h.lookupTreeScan(ctx, version, "", tree.Hash.String())}
+	if scans[""].hit {
+		return scans
+	}
+	for _, entry := range tree.Entries {
+		if entry.Mode == filemode.Dir {
+			path := entry.Name + "/"
+			scans[path] = h.lookupTreeScan(ctx, version, path, entry.Hash.String())
+		}
+	}
+	return scans
+}
+
+func (h *FullRepoScanHandler) lookupTreeScan(ctx context.Context, version, path, hash string) *treeScan {
+	scan := &treeScan{key: cache.TreeKey(version, path, hash), revisit: make(map[string]bool)}
+	result, ok, err := h.ScanCache.Get(ctx, scan.key)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg(constants.LogMsgScanCacheFailed)
+	}
+	if ok {
+		zerolog.Ctx(ctx).Debug().Str("tree", path).Int("revisit", len(result.Revisit)).Msg(constants.LogMsgTreeCacheHit)
+		scan.hit = true
+		for _, name := range result.Revisit {
+			scan.revisit[name] = true
+		}
+	}
+	return scan
+}
+
+// of returns the scans covering the file name: the root's and its top-level directory's.
+func (s treeScans) of(name string) []*treeScan {
+	if s == nil {
+		return nil
+	}
+	scans := []*treeScan{s[""]}
+	if dir, _, ok := strings.Cut(name, "/"); ok && s[dir+"/"] != nil {
+		scans = append(scans, s[dir+"/"])
+	}
+	return scans
+}
+
+// skip reports whether the file name is known to be clean from a cached scan of a tree
+// covering it.
+func (s treeScans) skip(name string) bool {
+	for _, scan := range s.of(name) {
+		if scan.hit && !scan.revisit[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// revisit records that the file name has findings, is an unscanned LFS pointer or was
+// skipped, so that it is reported again.
+func (s treeScans) revisit(name string) {
+	for _, scan := range s.of(name) {
+		scan.revisit[name] = true
+	}
+}
+
+// saveTreeScans caches the scans of the trees that missed.
+func (h *FullRepoScanHandler) saveTreeScans(ctx context.Context, scans treeScans) {
+	for _, scan := range scans {
+		if scan.hit {
+			continue
+		}
+		result := cache.ScanResult{Revisit: slices.Sorted(maps.Keys(scan.revisit))}
+		if err := h.ScanCache.Add(ctx, scan.key, result); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg(constants.LogMsgScanCacheFailed)
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package ignore reads .gitguardignore files: the repository paths scans leave alone, in
+// .gitignore syntax, so teams can exclude fixtures and generated code without tuning rules.
+package ignore
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// FileName is the name of the ignore file, read from the root of the scanned commit.
+const FileName = ".gitguardignore"
+
+// Matcher reports whether paths are ignored. A nil Matcher ignores nothing.
+type Matcher struct {
+	matcher gitignore.Matcher
+}
+
+// Parse reads patterns with .gitignore semantics, one per line: blank lines and lines
+// starting with "#" are skipped, "*", "?" and "[...]" match within a path segment and "**"
+// across segments, a pattern containing a "/" other than a trailing one is anchored to the
+// root, a trailing "/" only matches directories and "!" re-includes paths an earlier pattern
+// excluded. The last matching pattern wins, and as in git a path inside an excluded
+// directory cannot be re-included. "\#" and "\!" start patterns with a literal "#" or "!";
+// the backslash of "\!" is kept, since the pattern itself reads it as an escape while a
+// bare "!" would negate it.
+func Parse(content string) *Matcher {
+	var patterns []gitignore.Pattern
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	return &Matcher{matcher: gitignore.NewMatcher(patterns)}
+}
+
+// Ignored reports whether the repository-relative file path name, or a directory containing
+// it, is ignored.
+func (m *Matcher) Ignored(name string) bool {
+	if m == nil || name == "" {
+		return false
+	}
+	segments := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		if m.matcher.Match(segments[:i], true) {
+			return true
+		}
+	}
+	return m.matcher.Match(segments, false)
+}
@@ -0,0 +1,63 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_Ignored(t *testing.T) {
+	matcher := Parse(`# Test fixtures hold fake keys.
+testdata/
+*.snap
+/generated.go
+docs/**/*.md
+
+fixtures/*
+!fixtures/keep.env
+\#literal
+\!important.env
+`)
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"testdata/keys.pem", true},
+		{"internal/testdata/keys.pem", true},
+		{"testdata", false},
+		{"mytestdata/keys.pem", false},
+		{"ui/__snapshots__/app.snap", true},
+		{"generated.go", true},
+		{"pkg/generated.go", false},
+		{"docs/api/v1/index.md", true},
+		{"docs/index.md", true},
+		{"src/docs/index.md", false},
+		{"fixtures/aws.env", true},
+		{"fixtures/keep.env", false},
+		{"#literal", true},
+		{"!important.env", true},
+		{"important.env", false},
+		{"# Test fixtures hold fake keys.", false},
+		{"src/main.go", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matcher.Ignored(tt.name))
+		})
+	}
+}
+
+func TestMatcher_IgnoredDirectory(t *testing.T) {
+	// Files in an excluded directory cannot be re-included, as in git.
+	matcher := Parse("build/\n!build/config.yml\n")
+	assert.True(t, matcher.Ignored("build/config.yml"))
+	assert.True(t, matcher.Ignored("src/build/out.js"))
+}
+
+func TestParse_Empty(t *testing.T) {
+	matcher := Parse("# nothing ignored\n\n")
+	assert.Nil(t, matcher)
+	assert.False(t, matcher.Ignored("src/main.go"))
+}
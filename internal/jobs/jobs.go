@@ -0,0 +1,159 @@
+// Package jobs keeps track of scan jobs so their status can be polled. Jobs are held in
+// memory: a restart forgets them.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity is the number of jobs a Registry keeps when none is given.
+const DefaultCapacity = 1000
+
+// Status is the state of a job.
+type Status string
+
+// Job states.
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Job is a scan of a repository at a ref or commit.
+type Job struct {
+	ID         string    `json:"id"`
+	Repository string    `json:"repository"`
+	Ref        string    `json:"ref,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	Status     Status    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Findings   int       `json:"findings"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Registry holds the most recent jobs. A nil Registry records nothing.
+type Registry struct {
+	capacity int
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	order []string
+}
+
+// NewRegistry creates a Registry keeping the capacity most recently created jobs.
+func NewRegistry(capacity int) *Registry {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Registry{capacity: capacity, jobs: make(map[string]*Job)}
+}
+
+// Create records job as queued under a new ID and returns it. The oldest job is forgotten
+// once the registry is full.
+func (r *Registry) Create(job Job) Job {
+	job.ID = newID()
+	job.Status = StatusQueued
+	job.CreatedAt = time.Now().UTC()
+	if r == nil {
+		return job
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.order) >= r.capacity {
+		delete(r.jobs, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.jobs[job.ID] = &job
+	r.order = append(r.order, job.ID)
+	return job
+}
+
+// Start marks a job as running.
+func (r *Registry) Start(id string) {
+	r.update(id, func(job *Job) {
+		job.Status = StatusRunning
+		job.StartedAt = time.Now().UTC()
+	})
+}
+
+// Finish records the outcome of a job: its commit, when resolved, and its findings, or err.
+func (r *Registry) Finish(id, commit string, findings int, err error) {
+	r.update(id, func(job *Job) {
+		job.FinishedAt = time.Now().UTC()
+		if commit != "" {
+			job.Commit = commit
+		}
+		job.Findings = findings
+		job.Status = StatusCompleted
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+		}
+	})
+}
+
+// Get returns the job with the given ID.
+func (r *Registry) Get(id string) (Job, bool) {
+	if r == nil {
+		return Job{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns the jobs, most recently created first.
+func (r *Registry) List() []Job {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]Job, 0, len(r.order))
+	for i := len(r.order) - 1; i >= 0; i-- {
+		list = append(list, *r.jobs[r.order[i]])
+	}
+	return list
+}
+
+func (r *Registry) update(id string, apply func(*Job)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok {
+		apply(job)
+	}
+}
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the ID of the job it runs.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the ID of the job ctx runs, or "".
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(0)
+	job := r.Create(Job{Repository: "acme/api", Ref: "main"})
+	assert.Len(t, job.ID, 32)
+	assert.Equal(t, StatusQueued, job.Status)
+	assert.False(t, job.CreatedAt.IsZero())
+
+	r.Start(job.ID)
+	got, ok := r.Get(job.ID)
+	require.True(t, ok)
+	assert.Equal(t, StatusRunning, got.Status)
+	assert.False(t, got.StartedAt.IsZero())
+
+	r.Finish(job.ID, "abc123", 2, nil)
+	got, _ = r.Get(job.ID)
+	assert.Equal(t, StatusCompleted, got.Status)
+	assert.Equal(t, "abc123", got.Commit)
+	assert.Equal(t, 2, got.Findings)
+
+	failed := r.Create(Job{Repository: "acme/api", Commit: "def456"})
+	r.Finish(failed.ID, "", 0, errors.New("clone failed"))
+	got, _ = r.Get(failed.ID)
+	assert.Equal(t, StatusFailed, got.Status)
+	assert.Equal(t, "def456", got.Commit, "An unresolved commit keeps the requested one")
+	assert.Equal(t, "clone failed", got.Error)
+
+	_, ok = r.Get("unknown")
+	assert.False(t, ok)
+}
+
+func TestRegistry_Capacity(t *testing.T) {
+	r := NewRegistry(2)
+	first := r.Create(Job{Repository: "acme/a"})
+	second := r.Create(Job{Repository: "acme/b"})
+	third := r.Create(Job{Repository: "acme/c"})
+
+	_, ok := r.Get(first.ID)
+	assert.False(t, ok, "The oldest job is forgotten")
+	assert.Equal(t, []Job{third, second}, r.List())
+	for _, job := range []Job{second, third} {
+		_, ok = r.Get(job.ID)
+		assert.True(t, ok)
+	}
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	var r *Registry
+	job := r.Create(Job{Repository: "acme/api"})
+	assert.NotEmpty(t, job.ID)
+	r.Start(job.ID)
+	r.Finish(job.ID, "", 0, nil)
+	_, ok := r.Get(job.ID)
+	assert.False(t, ok)
+	assert.Empty(t, r.List())
+}
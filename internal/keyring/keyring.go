@@ -0,0 +1,258 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// ErrNoValidKey is returned by Verify when none of the configured keys authenticate.
+var ErrNoValidKey = errors.New("no configured private key authenticates against the GitHub App")
+
+// FailedKeyRetryAfter is how long a key GitHub rejected is passed over before the ring tries
+// it again, so a transient rejection does not retire a key until restart.
+const FailedKeyRetryAfter = 10 * time.Minute
+
+// KeyRing is a githubapp.ClientCreator backed by several private keys of the same App.
+// Clients are created with the active key; when GitHub rejects the JWT signed by that key
+// the ring falls back to the next key, which allows rotating keys without downtime.
+type KeyRing struct {
+	newCreator func(index int, key string) githubapp.ClientCreator
+	appID      int64
+	logger     zerolog.Logger
+	// appPath is the path of the JWT-authenticated /app endpoints under the API URL.
+	appPath string
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	creators []githubapp.ClientCreator
+	active   int
+	// failed holds when GitHub last rejected each key, zero for keys in good standing.
+	failed []time.Time
+}
+
+// New creates a KeyRing for the given App. Keys are tried in order, the first one being preferred.
+// When cacheCapacity is positive, installation clients (and therefore their installation tokens)
+// are cached per key in an LRU of that size and reused across deliveries.
+func New(
+	apiURL, graphQLURL string,
+	appID int64,
+	keys []string,
+	cacheCapacity int,
+	logger zerolog.Logger,
+	opts ...githubapp.ClientOption,
+) *KeyRing {
+	r := &KeyRing{
+		appID:   appID,
+		logger:  logger,
+		appPath: "/app",
+		now:     time.Now,
+	}
+	if base, err := url.Parse(apiURL); err == nil {
+		r.appPath = path.Join("/", base.Path, "app")
+	}
+	r.newCreator = func(index int, key string) githubapp.ClientCreator {
+		keyOpts := append([]githubapp.ClientOption{}, opts...)
+		keyOpts = append(keyOpts, githubapp.WithClientMiddleware(r.failoverMiddleware(index)))
+		creator := githubapp.NewClientCreator(apiURL, graphQLURL, appID, []byte(key), keyOpts...)
+		if cacheCapacity <= 0 {
+			return creator
+		}
+		cached, err := githubapp.NewCachingClientCreator(creator, cacheCapacity)
+		if err != nil {
+			logger.Warn().Err(err).Msg(constants.LogMsgClientCacheDisabled)
+			return creator
+		}
+		return cached
+	}
+	r.SetKeys(keys)
+
+	return r
+}
+
+// SetKeys replaces the configured keys, e.g. after they were refreshed from a secret manager.
+// The first key becomes active again.
+func (r *KeyRing) SetKeys(keys []string) {
+	creators := make([]githubapp.ClientCreator, 0, len(keys))
+	for i, key := range keys {
+		creators = append(creators, r.newCreator(i, key))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.creators = creators
+	r.failed = make([]time.Time, len(keys))
+	r.active = 0
+}
+
+// Active returns the index of the key currently used to create clients.
+func (r *KeyRing) Active() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// Verify authenticates as the App with each key in order and activates the first key
+// that GitHub accepts for the configured App ID.
+func (r *KeyRing) Verify(ctx context.Context) error {
+	var errs []error
+
+	r.mu.RLock()
+	creators := r.creators
+	r.mu.RUnlock()
+
+	for i, creator := range creators {
+		client, err := creator.NewAppClient()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %d: %w", i, err))
+			continue
+		}
+
+		app, _, err := client.Apps.Get(ctx, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %d: %w", i, err))
+			continue
+		}
+
+		if app.GetID() != r.appID {
+			errs = append(errs, fmt.Errorf("key %d: authenticated as app %d, expected %d", i, app.GetID(), r.appID))
+			continue
+		}
+
+		r.mu.Lock()
+		r.active = i
+		r.failed[i] = time.Time{}
+		r.mu.Unlock()
+
+		r.logger.Info().
+			Int("key_index", i).
+			Str("app_slug", app.GetSlug()).
+			Msg(constants.LogMsgPrivateKeyVerified)
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrNoValidKey, errors.Join(errs...))
+}
+
+// failoverMiddleware reports authentication failures of the key at index to the ring.
+func (r *KeyRing) failoverMiddleware(index int) githubapp.ClientMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if r.isKeyRejected(req, resp, err) {
+				r.markFailed(index)
+			}
+			return resp, err
+		})
+	}
+}
+
+// markFailed records that GitHub rejected the key at index and activates the next key in
+// good standing, or whose rejection is older than FailedKeyRetryAfter.
+func (r *KeyRing) markFailed(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if index >= len(r.failed) {
+		return
+	}
+	now := r.now()
+	r.failed[index] = now
+	if index != r.active {
+		return
+	}
+
+	for offset := 1; offset < len(r.creators); offset++ {
+		next := (index + offset) % len(r.creators)
+		if failedAt := r.failed[next]; failedAt.IsZero() || now.Sub(failedAt) >= FailedKeyRetryAfter {
+			r.active = next
+			r.logger.Warn().
+				Int("failed_key_index", index).
+				Int("key_index", next).
+				Msg(constants.LogMsgPrivateKeyFallback)
+			return
+		}
+	}
+}
+
+func (r *KeyRing) current() githubapp.ClientCreator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.creators[r.active]
+}
+
+// NewAppClient returns an app client using the active key.
+func (r *KeyRing) NewAppClient() (*github.Client, error) {
+	return r.current().NewAppClient()
+}
+
+// NewAppV4Client returns an app v4 client using the active key.
+func (r *KeyRing) NewAppV4Client() (*githubv4.Client, error) {
+	return r.current().NewAppV4Client()
+}
+
+// NewInstallationClient returns an installation client using the active key.
+func (r *KeyRing) NewInstallationClient(installationID int64) (*github.Client, error) {
+	return r.current().NewInstallationClient(installationID)
+}
+
+// NewInstallationV4Client returns an installation v4 client using the active key.
+func (r *KeyRing) NewInstallationV4Client(installationID int64) (*githubv4.Client, error) {
+	return r.current().NewInstallationV4Client(installationID)
+}
+
+// NewTokenSourceClient returns a client authenticated with the given token source.
+func (r *KeyRing) NewTokenSourceClient(ts oauth2.TokenSource) (*github.Client, error) {
+	return r.current().NewTokenSourceClient(ts)
+}
+
+// NewTokenSourceV4Client returns a v4 client authenticated with the given token source.
+func (r *KeyRing) NewTokenSourceV4Client(ts oauth2.TokenSource) (*githubv4.Client, error) {
+	return r.current().NewTokenSourceV4Client(ts)
+}
+
+// NewTokenClient returns a client authenticated with the given token.
+func (r *KeyRing) NewTokenClient(token string) (*github.Client, error) {
+	return r.current().NewTokenClient(token)
+}
+
+// NewTokenV4Client returns a v4 client authenticated with the given token.
+func (r *KeyRing) NewTokenV4Client(token string) (*githubv4.Client, error) {
+	return r.current().NewTokenV4Client(token)
+}
+
+// isKeyRejected reports whether a round trip failed because GitHub rejected the App JWT,
+// either while minting an installation token or on a JWT-authenticated /app endpoint.
+// Other 401s, such as those of expired or revoked installation and user tokens, say
+// nothing about the key.
+func (r *KeyRing) isKeyRejected(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		var httpErr *ghinstallation.HTTPError
+		return errors.As(err, &httpErr) && httpErr.Response != nil &&
+			httpErr.Response.StatusCode == http.StatusUnauthorized
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	return req.URL.Path == r.appPath || strings.HasPrefix(req.URL.Path, r.appPath+"/")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,204 @@
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAppID = 42
+
+func generateKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	return key, string(pem.EncodeToMemory(block))
+}
+
+// newAppServer returns a fake GitHub API that only accepts JWTs signed by the accepted key.
+func newAppServer(t *testing.T, accepted *rsa.PublicKey) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !verifyJWT(token, accepted) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id": %d, "slug": "gitguard"}`, testAppID)
+	}))
+}
+
+func verifyJWT(token string, pub *rsa.PublicKey) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+}
+
+func TestKeyRing_VerifySelectsWorkingKey(t *testing.T) {
+	_, oldKey := generateKey(t)
+	newPriv, newKey := generateKey(t)
+
+	server := newAppServer(t, &newPriv.PublicKey)
+	defer server.Close()
+
+	ring := New(server.URL, server.URL+"/graphql", testAppID, []string{oldKey, newKey}, 0, zerolog.Nop())
+
+	require.NoError(t, ring.Verify(context.Background()))
+	assert.Equal(t, 1, ring.Active(), "Should activate the key GitHub accepts")
+}
+
+func TestKeyRing_VerifyNoValidKey(t *testing.T) {
+	_, key := generateKey(t)
+	otherPriv, _ := generateKey(t)
+
+	server := newAppServer(t, &otherPriv.PublicKey)
+	defer server.Close()
+
+	ring := New(server.URL, server.URL+"/graphql", testAppID, []string{key}, 0, zerolog.Nop())
+
+	err := ring.Verify(context.Background())
+	assert.ErrorIs(t, err, ErrNoValidKey)
+}
+
+func TestKeyRing_VerifyWrongAppID(t *testing.T) {
+	priv, key := generateKey(t)
+
+	server := newAppServer(t, &priv.PublicKey)
+	defer server.Close()
+
+	ring := New(server.URL, server.URL+"/graphql", testAppID+1, []string{key}, 0, zerolog.Nop())
+
+	err := ring.Verify(context.Background())
+	assert.ErrorIs(t, err, ErrNoValidKey)
+}
+
+func TestKeyRing_FallsBackOnUnauthorized(t *testing.T) {
+	_, oldKey := generateKey(t)
+	newPriv, newKey := generateKey(t)
+
+	server := newAppServer(t, &newPriv.PublicKey)
+	defer server.Close()
+
+	ring := New(server.URL, server.URL+"/graphql", testAppID, []string{oldKey, newKey}, 0, zerolog.Nop())
+	assert.Equal(t, 0, ring.Active(), "First key should be active initially")
+
+	client, err := ring.NewAppClient()
+	require.NoError(t, err)
+	_, _, err = client.Apps.Get(context.Background(), "")
+	assert.Error(t, err, "Old key should be rejected")
+	assert.Equal(t, 1, ring.Active(), "Should fall back to the next key after a 401")
+
+	client, err = ring.NewAppClient()
+	require.NoError(t, err)
+	app, _, err := client.Apps.Get(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(testAppID), app.GetID())
+}
+
+func TestKeyRing_IgnoresTokenUnauthorized(t *testing.T) {
+	oldPriv, oldKey := generateKey(t)
+	_, newKey := generateKey(t)
+
+	server := newAppServer(t, &oldPriv.PublicKey)
+	defer server.Close()
+
+	ring := New(server.URL+"/api/v3/", server.URL+"/api/graphql", testAppID, []string{oldKey, newKey}, 0, zerolog.Nop())
+
+	client, err := ring.NewTokenClient("revoked-token")
+	require.NoError(t, err)
+	_, _, err = client.Repositories.Get(context.Background(), "owner", "repo")
+	assert.Error(t, err, "The revoked token should be rejected")
+	assert.Equal(t, 0, ring.Active(), "A rejected token says nothing about the key")
+
+	client, err = ring.NewAppClient()
+	require.NoError(t, err)
+	_, _, err = client.Apps.Get(context.Background(), "")
+	require.NoError(t, err, "The key should still authenticate under the API path prefix")
+}
+
+func TestKeyRing_AllKeysFailedKeepsActive(t *testing.T) {
+	now := time.Now()
+	ring := &KeyRing{
+		creators: make([]githubapp.ClientCreator, 2),
+		failed:   make([]time.Time, 2),
+		logger:   zerolog.Nop(),
+		now:      func() time.Time { return now },
+	}
+
+	ring.markFailed(0)
+	assert.Equal(t, 1, ring.Active())
+
+	ring.markFailed(1)
+	assert.Equal(t, 1, ring.Active(), "Should keep the last key when every key has failed")
+
+	now = now.Add(FailedKeyRetryAfter)
+	ring.markFailed(1)
+	assert.Equal(t, 0, ring.Active(), "Should retry a failed key after the cool-down")
+}
+
+func TestKeyRing_SetKeysResetsState(t *testing.T) {
+	_, oldKey := generateKey(t)
+	newPriv, newKey := generateKey(t)
+
+	server := newAppServer(t, &newPriv.PublicKey)
+	defer server.Close()
+
+	ring := New(server.URL, server.URL+"/graphql", testAppID, []string{oldKey}, 0, zerolog.Nop())
+	assert.ErrorIs(t, ring.Verify(context.Background()), ErrNoValidKey)
+
+	ring.SetKeys([]string{newKey})
+	assert.Equal(t, 0, ring.Active())
+	require.NoError(t, ring.Verify(context.Background()), "Should authenticate with the replaced key")
+}
+
+func TestKeyRing_CachesInstallationClients(t *testing.T) {
+	_, key := generateKey(t)
+
+	cached := New("https://api.github.com/", "https://api.github.com/graphql", testAppID, []string{key}, 8, zerolog.Nop())
+	first, err := cached.NewInstallationClient(1)
+	require.NoError(t, err)
+	second, err := cached.NewInstallationClient(1)
+	require.NoError(t, err)
+	assert.Same(t, first, second, "Should reuse the installation client for the same installation")
+
+	other, err := cached.NewInstallationClient(2)
+	require.NoError(t, err)
+	assert.NotSame(t, first, other, "Should create separate clients per installation")
+
+	cached.SetKeys([]string{key})
+	rotated, err := cached.NewInstallationClient(1)
+	require.NoError(t, err)
+	assert.NotSame(t, first, rotated, "Should drop cached clients when keys are replaced")
+
+	uncached := New("https://api.github.com/", "https://api.github.com/graphql", testAppID, []string{key}, 0, zerolog.Nop())
+	first, err = uncached.NewInstallationClient(1)
+	require.NoError(t, err)
+	second, err = uncached.NewInstallationClient(1)
+	require.NoError(t, err)
+	assert.NotSame(t, first, second, "Should not cache clients when caching is disabled")
+}
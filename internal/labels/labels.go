@@ -0,0 +1,126 @@
+// Package labels describes the labels of security issues and creates those a repository
+// lacks, with their color and description, so issues fit existing triage boards.
+package labels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/severity"
+)
+
+// Default color and description of the security label.
+const (
+	DefaultColor       = "d73a4a"
+	DefaultDescription = "Secrets detected by GitGuard"
+)
+
+// colorPattern matches a label color: six hex digits, without the leading #.
+var colorPattern = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+// Label is a label of security issues.
+type Label struct {
+	Name        string
+	Color       string
+	Description string
+	// Severity, when set, limits the label to issues whose highest severity it is.
+	Severity severity.Level
+}
+
+// Set is the labels of security issues. Labels without a severity go on every issue.
+type Set []Label
+
+// Default returns the labels used when none are configured: the security label alone.
+func Default() Set {
+	return Set{{Name: constants.IssueLabel, Color: DefaultColor, Description: DefaultDescription}}
+}
+
+// Validate checks that every label has a name and a valid color, and that at least one goes
+// on every issue, so GitGuard can find its issues again.
+func (s Set) Validate() error {
+	for _, label := range s {
+		if strings.TrimSpace(label.Name) == "" {
+			return errors.New("labels need a name")
+		}
+		if label.Color != "" && !colorPattern.MatchString(label.Color) {
+			return fmt.Errorf("label %q: color %q is not six hex digits", label.Name, label.Color)
+		}
+	}
+	if s.Tracking() == "" {
+		return errors.New("at least one label must have no severity")
+	}
+	return nil
+}
+
+// Tracking returns the first label that goes on every issue, by which open security issues
+// are listed.
+func (s Set) Tracking() string {
+	for _, label := range s {
+		if label.Severity == severity.None {
+			return label.Name
+		}
+	}
+	return ""
+}
+
+// For returns the labels of an issue whose findings' highest severity is highest.
+func (s Set) For(highest severity.Level) Set {
+	var labels Set
+	for _, label := range s {
+		if label.Severity == severity.None || label.Severity == highest {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// Names returns the names of the labels.
+func (s Set) Names() []string {
+	names := make([]string, len(s))
+	for i, label := range s {
+		names[i] = label.Name
+	}
+	return names
+}
+
+// Service is the part of the GitHub issues API labels are created with.
+type Service interface {
+	GetLabel(ctx context.Context, owner, repo, name string) (*github.Label, *github.Response, error)
+	CreateLabel(ctx context.Context, owner, repo string, label *github.Label) (*github.Label, *github.Response, error)
+}
+
+// Ensure creates the labels owner/repo lacks. Labels that exist are left as they are, so
+// colors and descriptions changed in the repository are kept.
+func (s Set) Ensure(ctx context.Context, service Service, owner, repo string) error {
+	var errs []error
+	for _, label := range s {
+		_, resp, err := service.GetLabel(ctx, owner, repo, label.Name)
+		if err == nil {
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			errs = append(errs, fmt.Errorf("failed to get label %q: %w", label.Name, err))
+			continue
+		}
+		created := &github.Label{Name: github.Ptr(label.Name)}
+		if label.Color != "" {
+			created.Color = github.Ptr(label.Color)
+		}
+		if label.Description != "" {
+			created.Description = github.Ptr(label.Description)
+		}
+		// A concurrent scan may have created the label since: GitHub rejects duplicates with
+		// 422, which leaves the label in place.
+		if _, resp, err := service.CreateLabel(ctx, owner, repo, created); err != nil &&
+			(resp == nil || resp.StatusCode != http.StatusUnprocessableEntity) {
+			errs = append(errs, fmt.Errorf("failed to create label %q: %w", label.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
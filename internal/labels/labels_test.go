@@ -0,0 +1,87 @@
+package labels
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeService holds the labels of a repository.
+type fakeService struct {
+	labels map[string]*github.Label
+	// getErr fails every GetLabel call; created counts the created labels.
+	getErr  error
+	created int
+}
+
+func (f *fakeService) GetLabel(_ context.Context, _, _, name string) (*github.Label, *github.Response, error) {
+	if f.getErr != nil {
+		return nil, &github.Response{Response: &http.Response{StatusCode: http.StatusBadGateway}}, f.getErr
+	}
+	if label, ok := f.labels[name]; ok {
+		return label, &github.Response{Response: &http.Response{StatusCode: http.StatusOK}}, nil
+	}
+	return nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("not found")
+}
+
+func (f *fakeService) CreateLabel(
+	_ context.Context, _, _ string, label *github.Label,
+) (*github.Label, *github.Response, error) {
+	f.labels[label.GetName()] = label
+	f.created++
+	return label, &github.Response{Response: &http.Response{StatusCode: http.StatusCreated}}, nil
+}
+
+func TestSet_For(t *testing.T) {
+	set := Set{
+		{Name: "security"},
+		{Name: "sev:critical", Severity: severity.Critical},
+		{Name: "triage"},
+		{Name: "sev:high", Severity: severity.High},
+	}
+	assert.Equal(t, "security", set.Tracking())
+	assert.Equal(t, []string{"security", "sev:critical", "triage"}, set.For(severity.Critical).Names())
+	assert.Equal(t, []string{"security", "triage"}, set.For(severity.Low).Names())
+}
+
+func TestSet_Validate(t *testing.T) {
+	require.NoError(t, Default().Validate())
+	assert.Equal(t, "security", Default().Tracking())
+
+	for name, set := range map[string]Set{
+		"no name":        {{Name: " "}},
+		"invalid color":  {{Name: "security", Color: "red"}},
+		"severity alone": {{Name: "sev:high", Severity: severity.High}},
+	} {
+		assert.Error(t, set.Validate(), name)
+	}
+}
+
+func TestSet_Ensure(t *testing.T) {
+	existing := &github.Label{Name: github.Ptr("security"), Color: github.Ptr("000000")}
+	service := &fakeService{labels: map[string]*github.Label{"security": existing}}
+	set := Set{
+		{Name: "security", Color: DefaultColor},
+		{Name: "sev:critical", Color: "b60205", Description: "Critical secrets", Severity: severity.Critical},
+	}
+
+	require.NoError(t, set.Ensure(context.Background(), service, "acme", "api"))
+	assert.Equal(t, 1, service.created)
+	assert.Same(t, existing, service.labels["security"], "Existing labels should be left as they are")
+	created := service.labels["sev:critical"]
+	assert.Equal(t, "b60205", created.GetColor())
+	assert.Equal(t, "Critical secrets", created.GetDescription())
+
+	require.NoError(t, set.Ensure(context.Background(), service, "acme", "api"))
+	assert.Equal(t, 1, service.created, "Labels should be created once")
+
+	service.getErr = errors.New("bad gateway")
+	assert.Error(t, set.Ensure(context.Background(), service, "acme", "api"))
+	assert.Equal(t, 1, service.created, "Labels should not be created when their existence is unknown")
+}
@@ -0,0 +1,193 @@
+// Package lfs recognizes Git LFS pointer files and downloads the objects they point to
+// through the LFS batch API.
+package lfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxPointerSize is the size above which a file is never an LFS pointer.
+	MaxPointerSize = 1024
+
+	pointerVersion = "version https://git-lfs.github.com/spec/v1"
+	oidPrefix      = "sha256:"
+	mediaType      = "application/vnd.git-lfs+json"
+	fetchTimeout   = 30 * time.Second
+)
+
+// Pointer identifies an LFS object by its SHA-256 and size.
+type Pointer struct {
+	OID  string
+	Size int64
+}
+
+// ParsePointer parses the contents of an LFS pointer file. It reports false for any other
+// content.
+func ParsePointer(content string) (Pointer, bool) {
+	if len(content) > MaxPointerSize || !strings.HasPrefix(content, pointerVersion+"\n") {
+		return Pointer{}, false
+	}
+
+	var p Pointer
+	for _, line := range strings.Split(content, "\n")[1:] {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, oidPrefix)
+			if !ok || len(oid) != sha256.Size*2 {
+				return Pointer{}, false
+			}
+			p.OID = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return Pointer{}, false
+			}
+			p.Size = size
+		}
+	}
+	return p, p.OID != ""
+}
+
+// Client downloads LFS objects of one repository.
+type Client struct {
+	// Endpoint is the LFS server URL of the repository, e.g.
+	// "https://github.com/owner/repo.git/info/lfs".
+	Endpoint   string
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the repository cloned from cloneURL, authenticating with
+// basic auth.
+func NewClient(cloneURL, username, password string) *Client {
+	endpoint := strings.TrimSuffix(cloneURL, "/")
+	if !strings.HasSuffix(endpoint, ".git") {
+		endpoint += ".git"
+	}
+	return &Client{
+		Endpoint:   endpoint + "/info/lfs",
+		Username:   username,
+		Password:   password,
+		HTTPClient: &http.Client{Timeout: fetchTimeout},
+	}
+}
+
+type batchRequest struct {
+	Operation string         `json:"operation"`
+	Transfers []string       `json:"transfers"`
+	Objects   []batchPointer `json:"objects"`
+}
+
+type batchPointer struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+type batchResponse struct {
+	Objects []struct {
+		Actions struct {
+			Download *struct {
+				Href   string            `json:"href"`
+				Header map[string]string `json:"header"`
+			} `json:"download"`
+		} `json:"actions"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"objects"`
+}
+
+// Fetch downloads the object p points to and verifies its hash.
+func (c *Client) Fetch(ctx context.Context, p Pointer) ([]byte, error) {
+	body, err := json.Marshal(batchRequest{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects:   []batchPointer{{OID: p.OID, Size: p.Size}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LFS batch request: %w", err)
+	}
+	req.Header.Set("Accept", mediaType)
+	req.Header.Set("Content-Type", mediaType)
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	var batch batchResponse
+	if err := c.doJSON(req, &batch); err != nil {
+		return nil, fmt.Errorf("LFS batch request failed: %w", err)
+	}
+	if len(batch.Objects) != 1 {
+		return nil, errors.New("LFS batch response has no object")
+	}
+	object := batch.Objects[0]
+	if object.Error != nil {
+		return nil, fmt.Errorf("LFS object %s: %d %s", p.OID, object.Error.Code, object.Error.Message)
+	}
+	if object.Actions.Download == nil {
+		return nil, fmt.Errorf("LFS object %s has no download action", p.OID)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, object.Actions.Download.Href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LFS download request: %w", err)
+	}
+	for name, value := range object.Actions.Download.Header {
+		req.Header.Set(name, value)
+	}
+	return c.download(req, p)
+}
+
+func (c *Client) doJSON(req *http.Request, v any) error {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// download reads at most p.Size bytes of the object and checks them against p.OID.
+func (c *Client) download(req *http.Request, p Pointer) ([]byte, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("LFS download failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LFS download failed: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.Size+1))
+	if err != nil {
+		return nil, fmt.Errorf("LFS download failed: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if int64(len(data)) != p.Size || hex.EncodeToString(sum[:]) != p.OID {
+		return nil, fmt.Errorf("LFS object %s does not match its pointer", p.OID)
+	}
+	return data, nil
+}
@@ -0,0 +1,84 @@
+package lfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pointerFor(data string) (Pointer, string) {
+	sum := sha256.Sum256([]byte(data))
+	p := Pointer{OID: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+	content := "version https://git-lfs.github.com/spec/v1\noid sha256:" + p.OID + "\nsize " + strconv.Itoa(len(data)) + "\n"
+	return p, content
+}
+
+func TestParsePointer(t *testing.T) {
+	want, content := pointerFor("secret")
+
+	p, ok := ParsePointer(content)
+	require.True(t, ok)
+	assert.Equal(t, want, p)
+
+	for _, content := range []string{
+		"plain text",
+		"version https://git-lfs.github.com/spec/v1\nsize 10\n",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 10\n",
+		"version https://git-lfs.github.com/spec/v1\noid md5:" + want.OID + "\nsize 10\n",
+	} {
+		_, ok := ParsePointer(content)
+		assert.False(t, ok, content)
+	}
+}
+
+func TestClientFetch(t *testing.T) {
+	const data = "token=ghp_abcdefghijklmnop"
+	p, _ := pointerFor(data)
+	stored := data
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/owner/repo.git/info/lfs/objects/batch":
+			user, password, _ := r.BasicAuth()
+			assert.Equal(t, "x-access-token", user)
+			assert.Equal(t, "token", password)
+			assert.Equal(t, "application/vnd.git-lfs+json", r.Header.Get("Accept"))
+
+			var req batchRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "download", req.Operation)
+			if req.Objects[0].OID != p.OID {
+				_, _ = w.Write([]byte(`{"objects": [{"error": {"code": 404, "message": "Object does not exist"}}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"objects": [{"actions": {"download": {"href": "` + server.URL +
+				`/objects/1", "header": {"Authorization": "RemoteAuth abc"}}}}]}`))
+		case "/objects/1":
+			assert.Equal(t, "RemoteAuth abc", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(stored))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/owner/repo", "x-access-token", "token")
+	got, err := client.Fetch(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(got))
+
+	_, err = client.Fetch(context.Background(), Pointer{OID: "0000", Size: 1})
+	assert.ErrorContains(t, err, "Object does not exist")
+
+	stored = "tampered content of other size"
+	_, err = client.Fetch(context.Background(), p)
+	assert.ErrorContains(t, err, "does not match")
+}
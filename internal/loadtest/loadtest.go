@@ -0,0 +1,255 @@
+// Package loadtest fires signed synthetic push events at a GitGuard webhook at a fixed rate
+// and summarizes their latencies and errors, for capacity planning.
+package loadtest
+
+import (
+	"context"
+	"crypto/sha1" // #nosec G505 -- Synthetic commit IDs, not a security use
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/replay"
+)
+
+// Options configure a load test.
+type Options struct {
+	// URL is the webhook URL deliveries are sent to.
+	URL string
+	// Secret signs the deliveries; it must be the target's webhook secret.
+	Secret string
+	// Rate is the number of deliveries started per second.
+	Rate float64
+	// Duration is how long deliveries are started for. Those in flight when it ends are
+	// awaited.
+	Duration time.Duration
+	// Concurrency bounds the deliveries in flight. Deliveries due while it is reached are
+	// skipped and counted, so a saturated target shows as skipped deliveries rather than a
+	// lower rate.
+	Concurrency int
+	// Commits is the number of commits of each push.
+	Commits int
+	// Repositories is the number of synthetic repositories pushes are spread across.
+	Repositories int
+	// Owner owns the synthetic repositories.
+	Owner string
+	// Installation is the installation ID pushes are delivered for.
+	Installation int64
+	// Client sends the deliveries; nil uses a client with a one minute timeout.
+	Client *http.Client
+}
+
+func (o *Options) validate() error {
+	switch {
+	case o.URL == "":
+		return errors.New("a target URL is required")
+	case o.Rate <= 0:
+		return errors.New("the rate must be positive")
+	case o.Duration <= 0:
+		return errors.New("the duration must be positive")
+	case o.Concurrency <= 0 || o.Commits <= 0 || o.Repositories <= 0:
+		return errors.New("concurrency, commits and repositories must be positive")
+	}
+	return nil
+}
+
+// Report summarizes a load test.
+type Report struct {
+	// Sent is the number of deliveries sent, and Skipped the number not sent because
+	// Concurrency deliveries were in flight.
+	Sent, Skipped int
+	// Statuses counts the responses by status code.
+	Statuses map[int]int
+	// TransportErrors counts the deliveries that got no response.
+	TransportErrors int
+	// Elapsed is the time from the first delivery to the last response.
+	Elapsed time.Duration
+
+	latencies []time.Duration
+}
+
+// Failed returns the number of deliveries that got no response or an error status.
+func (r *Report) Failed() int {
+	failed := r.TransportErrors
+	for status, count := range r.Statuses {
+		if status >= http.StatusBadRequest {
+			failed += count
+		}
+	}
+	return failed
+}
+
+// Percentile returns the latency under which p percent of the responses arrived.
+func (r *Report) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(p/100*float64(len(r.latencies))+0.5) - 1
+	return r.latencies[min(max(i, 0), len(r.latencies)-1)]
+}
+
+// Write prints the report to w.
+func (r *Report) Write(w io.Writer) error {
+	rate := 0.0
+	if r.Elapsed > 0 {
+		rate = float64(r.Sent) / r.Elapsed.Seconds()
+	}
+	statuses := make([]int, 0, len(r.Statuses))
+	for status := range r.Statuses {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	responses := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		responses = append(responses, fmt.Sprintf("%d x%d", status, r.Statuses[status]))
+	}
+	if len(responses) == 0 {
+		responses = append(responses, "none")
+	}
+
+	_, err := fmt.Fprintf(w,
+		"Sent %d deliveries in %s (%.2f/s), skipped %d\n"+
+			"Responses: %s; transport errors: %d; failed: %d\n"+
+			"Latency: p50 %s, p90 %s, p99 %s, max %s\n",
+		r.Sent, r.Elapsed.Round(time.Millisecond), rate, r.Skipped,
+		strings.Join(responses, ", "), r.TransportErrors, r.Failed(),
+		r.Percentile(50), r.Percentile(90), r.Percentile(99), r.Percentile(100))
+	return err
+}
+
+// outcome is the result of one delivery.
+type outcome struct {
+	status  int
+	latency time.Duration
+	err     error
+}
+
+// Run sends synthetic pushes at opts.Rate for opts.Duration, or until ctx is done, and
+// reports the responses.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+
+	report := &Report{Statuses: make(map[int]int)}
+	results := make(chan outcome, opts.Concurrency)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for result := range results {
+			if result.err != nil {
+				report.TransportErrors++
+				continue
+			}
+			report.Statuses[result.status]++
+			report.latencies = append(report.latencies, result.latency)
+		}
+	}()
+
+	run := strconv.FormatInt(time.Now().UnixNano(), 36)
+	interval := time.Duration(float64(time.Second) / opts.Rate)
+	ticker := time.NewTicker(max(interval, time.Microsecond))
+	defer ticker.Stop()
+	deadline := time.NewTimer(opts.Duration)
+	defer deadline.Stop()
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+
+loop:
+	for seq := 0; ; seq++ {
+		select {
+		case slots <- struct{}{}:
+			report.Sent++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- deliver(ctx, client, &opts, run, seq)
+				<-slots
+			}()
+		default:
+			report.Skipped++
+		}
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+		}
+	}
+
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+	close(results)
+	<-collected
+	slices.Sort(report.latencies)
+	return report, nil
+}
+
+// deliver sends the push numbered seq.
+func deliver(ctx context.Context, client *http.Client, opts *Options, run string, seq int) outcome {
+	fixture := &replay.Fixture{
+		Event:    "push",
+		Delivery: fmt.Sprintf("loadtest-%s-%d", run, seq),
+		Payload:  opts.push(run, seq),
+	}
+	req, err := fixture.Request(ctx, opts.URL, opts.Secret)
+	if err != nil {
+		return outcome{err: err}
+	}
+	started := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return outcome{err: err}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return outcome{status: resp.StatusCode, latency: time.Since(started)}
+}
+
+// push returns the payload of the synthetic push numbered seq: Commits commits to the default
+// branch of one of the repositories. Commit IDs are derived from run and seq, so every push
+// is new to the target.
+func (o *Options) push(run string, seq int) json.RawMessage {
+	sha := func(i int) string {
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s/%d/%d", run, seq, i))) // #nosec G401 -- Synthetic commit IDs
+		return hex.EncodeToString(sum[:])
+	}
+	type commit struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+	list := make([]commit, o.Commits)
+	for i := range list {
+		list[i] = commit{ID: sha(i + 1), Message: fmt.Sprintf("Load test commit %d of push %d", i+1, seq)}
+	}
+	name := fmt.Sprintf("repo-%d", seq%o.Repositories)
+	payload, _ := json.Marshal(map[string]any{
+		"ref":         "refs/heads/main",
+		"before":      sha(0),
+		"after":       list[len(list)-1].ID,
+		"commits":     list,
+		"head_commit": list[len(list)-1],
+		"repository": map[string]any{
+			"name":           name,
+			"full_name":      o.Owner + "/" + name,
+			"default_branch": "main",
+			"owner":          map[string]string{"login": o.Owner},
+		},
+		"installation": map[string]int64{"id": o.Installation},
+	})
+	return payload
+}
@@ -0,0 +1,126 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	deliveries := make(map[string]bool)
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := github.ValidatePayload(r, []byte("s3cret"))
+		if !assert.NoError(t, err, "Deliveries are signed") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, "push", github.WebHookType(r))
+		var event github.PushEvent
+		assert.NoError(t, json.Unmarshal(body, &event))
+		assert.Len(t, event.Commits, 3)
+		assert.Equal(t, "acme", event.GetRepo().GetOwner().GetLogin())
+		assert.Equal(t, int64(42), event.GetInstallation().GetID())
+
+		mu.Lock()
+		assert.False(t, deliveries[github.DeliveryID(r)], "Delivery IDs are unique")
+		deliveries[github.DeliveryID(r)] = true
+		mu.Unlock()
+		if received.Add(1)%4 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	report, err := Run(context.Background(), Options{
+		URL:          server.URL,
+		Secret:       "s3cret",
+		Rate:         200,
+		Duration:     100 * time.Millisecond,
+		Concurrency:  10,
+		Commits:      3,
+		Repositories: 2,
+		Owner:        "acme",
+		Installation: 42,
+	})
+	require.NoError(t, err)
+	assert.Positive(t, report.Sent)
+	assert.Equal(t, report.Sent, report.Statuses[http.StatusOK]+report.Statuses[http.StatusServiceUnavailable])
+	assert.Equal(t, report.Statuses[http.StatusServiceUnavailable], report.Failed())
+	assert.Equal(t, report.Sent, int(received.Load()))
+	assert.LessOrEqual(t, report.Percentile(50), report.Percentile(100))
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "Latency: p50 ")
+	assert.Contains(t, out.String(), "transport errors: 0")
+}
+
+func TestRunSkipsBeyondConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	time.AfterFunc(100*time.Millisecond, func() { close(release) })
+
+	report, err := Run(context.Background(), Options{
+		URL: server.URL, Rate: 100, Duration: 50 * time.Millisecond, Concurrency: 1, Commits: 1, Repositories: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Sent)
+	assert.Positive(t, report.Skipped, "Deliveries due while the target is saturated are skipped")
+	assert.Equal(t, map[int]int{http.StatusOK: 1}, report.Statuses)
+}
+
+func TestRunTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	report, err := Run(context.Background(), Options{
+		URL: server.URL, Rate: 100, Duration: 20 * time.Millisecond, Concurrency: 5, Commits: 1, Repositories: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, report.Sent, report.TransportErrors)
+	assert.Equal(t, report.Sent, report.Failed())
+	assert.Zero(t, report.Percentile(99))
+}
+
+func TestRunInvalidOptions(t *testing.T) {
+	valid := Options{URL: "http://localhost/", Rate: 1, Duration: time.Second, Concurrency: 1, Commits: 1, Repositories: 1}
+	for name, mutate := range map[string]func(*Options){
+		"url":          func(o *Options) { o.URL = "" },
+		"rate":         func(o *Options) { o.Rate = 0 },
+		"duration":     func(o *Options) { o.Duration = 0 },
+		"concurrency":  func(o *Options) { o.Concurrency = 0 },
+		"commits":      func(o *Options) { o.Commits = 0 },
+		"repositories": func(o *Options) { o.Repositories = 0 },
+	} {
+		opts := valid
+		mutate(&opts)
+		_, err := Run(context.Background(), opts)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReportPercentile(t *testing.T) {
+	report := &Report{}
+	for i := 1; i <= 100; i++ {
+		report.latencies = append(report.latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, report.Percentile(50))
+	assert.Equal(t, 99*time.Millisecond, report.Percentile(99))
+	assert.Equal(t, 100*time.Millisecond, report.Percentile(100))
+	assert.Equal(t, time.Millisecond, report.Percentile(0))
+}
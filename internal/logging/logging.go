@@ -10,12 +10,13 @@ import (
 func SetupLogger() zerolog.Logger {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 
-	// Use console writer for prettier output in development
+	// Use console writer for prettier output in development. Credentials are scrubbed from
+	// every event in case an error message carries one.
 	var logger zerolog.Logger
 	if os.Getenv("LOG_PRETTY") != "" {
-		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr})
+		logger = zerolog.New(zerolog.ConsoleWriter{Out: ScrubWriter{Out: os.Stderr}})
 	} else {
-		logger = zerolog.New(os.Stdout)
+		logger = zerolog.New(ScrubWriter{Out: os.Stdout})
 	}
 
 	// Set log level from environment, default to info
@@ -0,0 +1,16 @@
+package logging
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the request being served.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces scrubbed credentials.
+const Redacted = "[REDACTED]"
+
+var (
+	// githubTokens matches GitHub token formats: personal, OAuth, user-to-server,
+	// server-to-server (installation), refresh and fine-grained personal access tokens.
+	githubTokens = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)
+	// urlCredentials matches the password of credentials embedded in URLs.
+	urlCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@"]+):[^@\s/"]+@`)
+)
+
+// Scrub removes credentials from s: each of secrets, GitHub tokens and passwords embedded
+// in URLs.
+func Scrub(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	s = githubTokens.ReplaceAllString(s, Redacted)
+	return urlCredentials.ReplaceAllString(s, "${1}:"+Redacted+"@")
+}
+
+// ScrubURL removes the password from a URL for logging.
+func ScrubURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Scrub(raw)
+	}
+	return u.Redacted()
+}
+
+// scrubbedError reports a scrubbed message while errors.Is and errors.As still see the
+// original error.
+type scrubbedError struct {
+	msg string
+	err error
+}
+
+func (e *scrubbedError) Error() string { return e.msg }
+func (e *scrubbedError) Unwrap() error { return e.err }
+
+// ScrubError returns err with credentials removed from its message, as Scrub does. It
+// returns nil for a nil err.
+func ScrubError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &scrubbedError{msg: Scrub(err.Error(), secrets...), err: err}
+}
+
+// ScrubWriter scrubs GitHub tokens and URL passwords from everything written to Out. zerolog
+// writes one event per call, so credentials are never split across writes.
+type ScrubWriter struct {
+	Out io.Writer
+}
+
+// Write scrubs p and writes it to Out, reporting the length of p on success.
+func (w ScrubWriter) Write(p []byte) (int, error) {
+	scrubbed := githubTokens.ReplaceAll(p, []byte(Redacted))
+	scrubbed = urlCredentials.ReplaceAll(scrubbed, []byte("${1}:"+Redacted+"@"))
+	if bytes.Equal(scrubbed, p) {
+		return w.Out.Write(p)
+	}
+	if _, err := w.Out.Write(scrubbed); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,386 @@
+// Package messages holds the text GitGuard writes to check runs and issues in message
+// catalogs, so it can be translated or reworded per installation, and the templates
+// operators can replace it with.
+package messages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"gopkg.in/yaml.v3"
+)
+
+// English is the locale of the built-in catalog.
+const English = "en"
+
+// Key identifies a message. Messages with format verbs are rendered with Catalog.Format.
+type Key string
+
+// Check run messages.
+const (
+	CheckRunTitleInProgress     Key = "check_run.title.in_progress"
+	CheckRunTitleError          Key = "check_run.title.error"
+	CheckRunTitleClean          Key = "check_run.title.clean"
+	CheckRunTitleSecrets        Key = "check_run.title.secrets"
+	CheckRunTitleNeutral        Key = "check_run.title.neutral"
+	CheckRunTitleActionRequired Key = "check_run.title.action_required"
+	CheckRunSummaryInProgress   Key = "check_run.summary.in_progress"
+	CheckRunSummaryError        Key = "check_run.summary.error"
+	CheckRunSummaryClean        Key = "check_run.summary.clean"
+	CheckRunSummarySecrets      Key = "check_run.summary.secrets"
+	CheckRunSummaryTypes        Key = "check_run.summary.types"
+	CheckRunSummarySeverity     Key = "check_run.summary.severity"
+	CheckRunSummaryWorkflows    Key = "check_run.summary.workflows"
+	CheckRunSummaryBaselined    Key = "check_run.summary.baselined"
+	CheckRunSummaryCumulative   Key = "check_run.summary.cumulative"
+	CheckRunSummaryAges         Key = "check_run.summary.ages"
+	CheckRunTableHeader         Key = "check_run.table.header"
+	CheckRunTableSecret         Key = "check_run.table.header_secret"
+	CheckRunTableMore           Key = "check_run.table.more"
+	CheckRunTableNew            Key = "check_run.table.new"
+	CheckRunReportHeading       Key = "check_run.report.heading"
+)
+
+// Privacy check run messages.
+const (
+	PrivacyTitleClean      Key = "privacy.title.clean"
+	PrivacyTitleFindings   Key = "privacy.title.findings"
+	PrivacySummaryClean    Key = "privacy.summary.clean"
+	PrivacySummaryFindings Key = "privacy.summary.findings"
+)
+
+// Push summary check run messages.
+const (
+	PushSummaryClean   Key = "push_summary.clean"
+	PushSummarySecrets Key = "push_summary.secrets"
+	PushSummaryErrors  Key = "push_summary.errors"
+	PushSummaryTable   Key = "push_summary.table"
+	PushSummaryMore    Key = "push_summary.more"
+)
+
+// Commit comment messages.
+const (
+	CommitCommentHeading Key = "commit_comment.heading"
+	CommitCommentMore    Key = "commit_comment.more"
+	CommitCommentActions Key = "commit_comment.actions"
+)
+
+// Pull request gate messages.
+const (
+	PullRequestCommentHeading   Key = "pull_request.comment.heading"
+	PullRequestCommentMore      Key = "pull_request.comment.more"
+	PullRequestCommentOptions   Key = "pull_request.comment.options"
+	PullRequestCommentResolved  Key = "pull_request.comment.resolved"
+	PullRequestSummarySecrets   Key = "pull_request.summary.secrets"
+	PullRequestSummaryClean     Key = "pull_request.summary.clean"
+	PullRequestSummaryBlocked   Key = "pull_request.summary.blocked"
+	PullRequestSummaryDismissed Key = "pull_request.summary.dismissed"
+	PullRequestDismissed        Key = "pull_request.dismiss.done"
+	PullRequestDismissDenied    Key = "pull_request.dismiss.denied"
+	PullRequestDismissReason    Key = "pull_request.dismiss.reason"
+	PullRequestDismissNothing   Key = "pull_request.dismiss.nothing"
+)
+
+// Rotation ticket messages.
+const (
+	RotationActionLabel       Key = "rotation_ticket.action.label"
+	RotationActionDescription Key = "rotation_ticket.action.description"
+	RotationTicketTitle       Key = "rotation_ticket.title"
+	RotationTicketIntro       Key = "rotation_ticket.intro"
+	RotationTicketFinding     Key = "rotation_ticket.finding"
+	RotationTicketMore        Key = "rotation_ticket.more"
+	RotationTicketFiled       Key = "rotation_ticket.filed"
+)
+
+// Full scan check run messages.
+const (
+	FullScanTitleInProgress    Key = "full_scan.title.in_progress"
+	FullScanSummaryStarting    Key = "full_scan.summary.starting"
+	FullScanSummaryProgress    Key = "full_scan.summary.progress"
+	FullScanSummaryClean       Key = "full_scan.summary.clean"
+	FullScanSummarySecrets     Key = "full_scan.summary.secrets"
+	FullScanSummaryIssue       Key = "full_scan.summary.issue"
+	FullScanSummaryIssues      Key = "full_scan.summary.issues"
+	FullScanSummaryNotScanned  Key = "full_scan.summary.not_scanned"
+	FullScanSummarySkipped     Key = "full_scan.summary.skipped"
+	FullScanSummarySkippedMore Key = "full_scan.summary.skipped_more"
+	FullScanSummaryAlerted     Key = "full_scan.summary.alerted"
+	FullScanSummaryUnalerted   Key = "full_scan.summary.unalerted"
+	FullScanSummaryError       Key = "full_scan.summary.error"
+	FullScanTitleTooLarge      Key = "full_scan.title.too_large"
+	FullScanSummaryTooLarge    Key = "full_scan.summary.too_large"
+	FullScanSummaryManyFiles   Key = "full_scan.summary.many_files"
+)
+
+// Security issue messages.
+const (
+	IssueTitle            Key = "issue.title"
+	IssueTitleRule        Key = "issue.title_rule"
+	IssueTitleDirectory   Key = "issue.title_directory"
+	IssueIntro            Key = "issue.intro"
+	IssueTypesHeading     Key = "issue.types_heading"
+	IssueTypeCount        Key = "issue.type_count"
+	IssueLocationsHeading Key = "issue.locations_heading"
+	IssueLocation         Key = "issue.location"
+	IssueLocationBy       Key = "issue.location_introduced"
+	IssueLocationSecret   Key = "issue.location_secret"
+	IssueUnknownFile      Key = "issue.unknown_file"
+	IssueLocationsMore    Key = "issue.locations_more"
+	IssueActions          Key = "issue.actions"
+	IssueBranchesHeading  Key = "issue.branches_heading"
+	IssueBranch           Key = "issue.branch"
+	IssueBranchesMore     Key = "issue.branches_more"
+	IssueCleanupIntro     Key = "issue.cleanup_intro"
+	IssueBodyTruncated    Key = "issue.truncated"
+	IssueReportPageHeader Key = "issue.report_page_header"
+	IssueSLADue           Key = "issue.sla_due"
+)
+
+// Remediation pull request messages.
+const (
+	RemediationTitle            Key = "remediation.title"
+	RemediationIntro            Key = "remediation.intro"
+	RemediationIssue            Key = "remediation.issue"
+	RemediationLocationsHeading Key = "remediation.locations_heading"
+	RemediationLocation         Key = "remediation.location"
+	RemediationActions          Key = "remediation.actions"
+)
+
+// english is the built-in catalog, which other catalogs fall back to.
+var english = map[Key]string{
+	CheckRunTitleInProgress:     constants.CheckRunTitleInProgress,
+	CheckRunTitleError:          constants.CheckRunTitleError,
+	CheckRunTitleClean:          constants.CheckRunTitleClean,
+	CheckRunTitleSecrets:        constants.CheckRunTitleSecrets,
+	CheckRunTitleNeutral:        constants.CheckRunTitleNeutral,
+	CheckRunTitleActionRequired: constants.CheckRunTitleActionRequired,
+	CheckRunSummaryInProgress:   constants.CheckRunSummaryInProgress,
+	CheckRunSummaryError:        constants.CheckRunSummaryError,
+	CheckRunSummaryClean:        constants.CheckRunSummaryClean,
+	CheckRunSummarySecrets:      constants.CheckRunSummarySecrets,
+	CheckRunSummaryTypes:        constants.CheckRunSummaryTypes,
+	CheckRunSummarySeverity:     constants.CheckRunSummarySeverity,
+	CheckRunSummaryWorkflows:    constants.CheckRunSummaryWorkflows,
+	CheckRunSummaryBaselined:    constants.CheckRunSummaryBaselined,
+	CheckRunSummaryCumulative:   constants.CheckRunSummaryCumulative,
+	CheckRunSummaryAges:         constants.CheckRunSummaryAges,
+	CheckRunTableHeader:         constants.CheckRunTableHeader,
+	CheckRunTableSecret:         constants.CheckRunTableSecret,
+	CheckRunTableMore:           constants.CheckRunTableMore,
+	CheckRunTableNew:            constants.CheckRunTableNew,
+	CheckRunReportHeading:       constants.CheckRunReportHeading,
+	PrivacyTitleClean:           constants.PrivacyTitleClean,
+	PrivacyTitleFindings:        constants.PrivacyTitleFindings,
+	PrivacySummaryClean:         constants.PrivacySummaryClean,
+	PrivacySummaryFindings:      constants.PrivacySummaryFindings,
+	PushSummaryClean:            constants.PushSummaryClean,
+	PushSummarySecrets:          constants.PushSummarySecrets,
+	PushSummaryErrors:           constants.PushSummaryErrors,
+	PushSummaryTable:            constants.PushSummaryTable,
+	PushSummaryMore:             constants.PushSummaryMore,
+	CommitCommentHeading:        constants.CommitCommentHeading,
+	CommitCommentMore:           constants.CommitCommentMore,
+	CommitCommentActions:        constants.CommitCommentActions,
+	PullRequestCommentHeading:   constants.PullRequestCommentHeading,
+	PullRequestCommentMore:      constants.PullRequestCommentMore,
+	PullRequestCommentOptions:   constants.PullRequestCommentOptions,
+	PullRequestCommentResolved:  constants.PullRequestCommentResolved,
+	PullRequestSummarySecrets:   constants.PullRequestSummarySecrets,
+	PullRequestSummaryClean:     constants.PullRequestSummaryClean,
+	PullRequestSummaryBlocked:   constants.PullRequestSummaryBlocked,
+	PullRequestSummaryDismissed: constants.PullRequestSummaryDismissed,
+	PullRequestDismissed:        constants.PullRequestDismissed,
+	PullRequestDismissDenied:    constants.PullRequestDismissDenied,
+	PullRequestDismissReason:    constants.PullRequestDismissReason,
+	PullRequestDismissNothing:   constants.PullRequestDismissNothing,
+	RotationActionLabel:         constants.RotationActionLabel,
+	RotationActionDescription:   constants.RotationActionDescription,
+	RotationTicketTitle:         constants.RotationTicketTitle,
+	RotationTicketIntro:         constants.RotationTicketIntro,
+	RotationTicketFinding:       constants.RotationTicketFinding,
+	RotationTicketMore:          constants.RotationTicketMore,
+	RotationTicketFiled:         constants.RotationTicketFiled,
+	FullScanTitleInProgress:     constants.FullScanTitleInProgress,
+	FullScanSummaryStarting:     constants.FullScanSummaryStarting,
+	FullScanSummaryProgress:     constants.FullScanSummaryProgress,
+	FullScanSummaryClean:        constants.FullScanSummaryClean,
+	FullScanSummarySecrets:      constants.FullScanSummarySecrets,
+	FullScanSummaryIssue:        constants.FullScanSummaryIssue,
+	FullScanSummaryIssues:       constants.FullScanSummaryIssues,
+	FullScanSummaryNotScanned:   constants.FullScanSummaryNotScanned,
+	FullScanSummarySkipped:      constants.FullScanSummarySkipped,
+	FullScanSummarySkippedMore:  constants.FullScanSummarySkippedMore,
+	FullScanSummaryAlerted:      constants.FullScanSummaryAlerted,
+	FullScanSummaryUnalerted:    constants.FullScanSummaryUnalerted,
+	FullScanSummaryError:        constants.FullScanSummaryError,
+	FullScanTitleTooLarge:       constants.FullScanTitleTooLarge,
+	FullScanSummaryTooLarge:     constants.FullScanSummaryTooLarge,
+	FullScanSummaryManyFiles:    constants.FullScanSummaryManyFiles,
+	IssueTitle:                  constants.IssueTitle,
+	IssueTitleRule:              constants.IssueTitleRule,
+	IssueTitleDirectory:         constants.IssueTitleDirectory,
+	IssueIntro:                  constants.IssueIntro,
+	IssueTypesHeading:           constants.IssueTypesHeading,
+	IssueTypeCount:              constants.IssueTypeCount,
+	IssueLocationsHeading:       constants.IssueLocationsHeading,
+	IssueLocation:               constants.IssueLocation,
+	IssueLocationBy:             constants.IssueLocationBy,
+	IssueLocationSecret:         constants.IssueLocationSecret,
+	IssueUnknownFile:            constants.IssueUnknownFile,
+	IssueLocationsMore:          constants.IssueLocationsMore,
+	IssueActions:                constants.IssueActions,
+	IssueBranchesHeading:        constants.IssueBranchesHeading,
+	IssueBranch:                 constants.IssueBranch,
+	IssueBranchesMore:           constants.IssueBranchesMore,
+	IssueCleanupIntro:           constants.IssueCleanupIntro,
+	IssueBodyTruncated:          constants.IssueBodyTruncated,
+	IssueReportPageHeader:       constants.IssueReportPageHeader,
+	IssueSLADue:                 constants.IssueSLADue,
+	RemediationTitle:            constants.RemediationPRTitle,
+	RemediationIntro:            constants.RemediationIntro,
+	RemediationIssue:            constants.RemediationIssue,
+	RemediationLocationsHeading: constants.RemediationLocationsHeading,
+	RemediationLocation:         constants.RemediationLocation,
+	RemediationActions:          constants.RemediationActions,
+}
+
+// verbPattern matches the format verbs of a message, "%%" included so it is skipped.
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+// Catalog is the text of one locale. Messages it does not define are the English ones; a nil
+// Catalog is the English catalog.
+type Catalog struct {
+	locale string
+	text   map[Key]string
+}
+
+// Parse parses a YAML catalog mapping keys to messages. Unknown keys are rejected, as are
+// messages whose format verbs differ from those of the English message, in number, type or
+// order, so a translation cannot break the text it is rendered into.
+func Parse(locale string, data []byte) (*Catalog, error) {
+	var text map[Key]string
+	if err := yaml.Unmarshal(data, &text); err != nil {
+		return nil, fmt.Errorf("invalid %s catalog: %w", locale, err)
+	}
+	for key, message := range text {
+		fallback, ok := english[key]
+		if !ok {
+			return nil, fmt.Errorf("invalid %s catalog: unknown message %q", locale, key)
+		}
+		if !slices.Equal(verbs(message), verbs(fallback)) {
+			return nil, fmt.Errorf("invalid %s catalog: message %q must use the format verbs %v",
+				locale, key, verbs(fallback))
+		}
+	}
+	return &Catalog{locale: locale, text: text}, nil
+}
+
+func verbs(message string) []string {
+	var found []string
+	for _, verb := range verbPattern.FindAllString(message, -1) {
+		if verb != "%%" {
+			found = append(found, verb[len(verb)-1:])
+		}
+	}
+	return found
+}
+
+// Locale returns the locale of the catalog.
+func (c *Catalog) Locale() string {
+	if c == nil {
+		return English
+	}
+	return c.locale
+}
+
+// Text returns a message.
+func (c *Catalog) Text(key Key) string {
+	if c != nil {
+		if message, ok := c.text[key]; ok {
+			return message
+		}
+	}
+	return english[key]
+}
+
+// Format renders a message with format verbs.
+func (c *Catalog) Format(key Key, args ...any) string {
+	return fmt.Sprintf(c.Text(key), args...)
+}
+
+// Load reads the catalogs of a directory, one <locale>.yml or <locale>.yaml file per locale.
+// A catalog for "en" rewords the built-in English messages.
+func Load(dir string) (map[string]*Catalog, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message catalogs: %w", err)
+	}
+	catalogs := make(map[string]*Catalog)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		locale := strings.TrimSuffix(entry.Name(), ext)
+		if _, ok := catalogs[locale]; ok {
+			return nil, fmt.Errorf("duplicate %s catalog", locale)
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path) // #nosec G304 -- Path comes from operator configuration.
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message catalog: %w", err)
+		}
+		catalog, err := Parse(locale, data)
+		if err != nil {
+			return nil, err
+		}
+		catalogs[locale] = catalog
+	}
+	return catalogs, nil
+}
+
+// Locales selects the catalog of each installation. A nil Locales selects English for all.
+type Locales struct {
+	catalogs      map[string]*Catalog
+	defaultLocale string
+	installations map[int64]string
+}
+
+// NewLocales checks that the default locale and those of installations have a catalog and
+// returns the Locales. English always has one; an empty default locale is English.
+func NewLocales(catalogs map[string]*Catalog, defaultLocale string, installations map[int64]string) (*Locales, error) {
+	if defaultLocale == "" {
+		defaultLocale = English
+	}
+	all := map[string]*Catalog{English: nil}
+	for locale, catalog := range catalogs {
+		all[locale] = catalog
+	}
+	if _, ok := all[defaultLocale]; !ok {
+		return nil, fmt.Errorf("no catalog for the default locale %s", defaultLocale)
+	}
+	for installation, locale := range installations {
+		if _, ok := all[locale]; !ok {
+			return nil, fmt.Errorf("no catalog for locale %s of installation %d", locale, installation)
+		}
+	}
+	return &Locales{catalogs: all, defaultLocale: defaultLocale, installations: installations}, nil
+}
+
+// For returns the catalog of an installation, the default one when it has no locale of its
+// own.
+func (l *Locales) For(installation int64) *Catalog {
+	if l == nil {
+		return nil
+	}
+	locale, ok := l.installations[installation]
+	if !ok {
+		locale = l.defaultLocale
+	}
+	return l.catalogs[locale]
+}
@@ -0,0 +1,106 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	de, err := Parse("de", []byte(`
+check_run.title.clean: "GitGuard Secret Scan - Sauber"
+check_run.summary.secrets: "🚨 **%d Secret(s) gefunden** in diesem Commit."
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "de", de.Locale())
+	assert.Equal(t, "GitGuard Secret Scan - Sauber", de.Text(CheckRunTitleClean))
+	assert.Equal(t, "🚨 **2 Secret(s) gefunden** in diesem Commit.", de.Format(CheckRunSummarySecrets, 2))
+	assert.Equal(t, constants.IssueTitle, de.Text(IssueTitle), "Missing messages fall back to English")
+
+	var english *Catalog
+	assert.Equal(t, English, english.Locale())
+	assert.Equal(t, constants.CheckRunTitleClean, english.Text(CheckRunTitleClean))
+
+	for name, data := range map[string]string{
+		"unknown key":   `check_run.title.unknown: "x"`,
+		"missing verb":  `check_run.summary.secrets: "Secrets gefunden"`,
+		"other verb":    `check_run.summary.secrets: "%s Secrets gefunden"`,
+		"swapped verbs": `issue.type_count: "- %d: **%s**"`,
+		"not a mapping": `- check_run.title.clean`,
+	} {
+		_, err := Parse("de", []byte(data))
+		assert.Error(t, err, name)
+	}
+	_, err = Parse("de", []byte(`issue.location: "- %s (Zeile %d, 100%%)\n"`))
+	assert.NoError(t, err, "Escaped percent signs are not verbs")
+}
+
+func TestLoadAndLocales(t *testing.T) {
+	dir := t.TempDir()
+	de := []byte(`issue.title: "GitGuard: Secrets gefunden"`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "de.yml"), de, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en.yaml"), []byte(`issue.title: "Leaked secrets"`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a catalog"), 0o600))
+
+	catalogs, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, catalogs, 2)
+
+	locales, err := NewLocales(catalogs, "", map[int64]string{42: "de"})
+	require.NoError(t, err)
+	assert.Equal(t, "GitGuard: Secrets gefunden", locales.For(42).Text(IssueTitle))
+	assert.Equal(t, "Leaked secrets", locales.For(7).Text(IssueTitle), "An en catalog rewords English")
+
+	var unset *Locales
+	assert.Equal(t, constants.IssueTitle, unset.For(42).Text(IssueTitle))
+
+	_, err = NewLocales(catalogs, "fr", nil)
+	assert.Error(t, err)
+	_, err = NewLocales(nil, "", map[int64]string{42: "de"})
+	assert.Error(t, err)
+	_, err = Load(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestTemplates(t *testing.T) {
+	templates, err := ParseTemplates(
+		"Runbook: https://wiki.acme.dev/secrets\n{{range .Details}}- {{.File}}:{{.Line}} ({{.Severity}})\n{{end}}",
+		"{{.Default}}\nContact #security about {{.Repository}}@{{.Commit}} ({{.Findings.HighestSeverity}}).",
+	)
+	require.NoError(t, err)
+	event := notify.Event{
+		Repository: "acme/api",
+		Commit:     "abc123",
+		Findings:   notify.Summary{Total: 1, HighestSeverity: "high"},
+		Details:    []notify.Finding{{File: "main.go", Line: 3, Severity: "high"}},
+	}
+
+	body, err := templates.IssueBody(event, "built-in body")
+	require.NoError(t, err)
+	assert.Equal(t, "Runbook: https://wiki.acme.dev/secrets\n- main.go:3 (high)\n", body)
+	summary, err := templates.CheckSummary(event, "built-in summary")
+	require.NoError(t, err)
+	assert.Equal(t, "built-in summary\nContact #security about acme/api@abc123 (high).", summary)
+
+	failing, err := ParseTemplates("{{.Missing}}", "")
+	require.NoError(t, err)
+	body, err = failing.IssueBody(event, "built-in body")
+	assert.Error(t, err)
+	assert.Equal(t, "built-in body", body, "A failing template should keep the built-in text")
+	summary, err = failing.CheckSummary(event, "built-in summary")
+	require.NoError(t, err)
+	assert.Equal(t, "built-in summary", summary)
+
+	var unset *Templates
+	summary, err = unset.CheckSummary(event, "built-in summary")
+	require.NoError(t, err)
+	assert.Equal(t, "built-in summary", summary)
+
+	_, err = ParseTemplates("{{if}}", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package messages
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/omercnet/gitguard/internal/notify"
+)
+
+// Templates replace the security issue body and the check run summaries with Go templates,
+// so organizations can add their own runbooks, links and branding. A nil Templates, or one
+// without a template, keeps the built-in text.
+type Templates struct {
+	issueBody    *template.Template
+	checkSummary *template.Template
+}
+
+// TemplateData is what templates render: the scan's event, whose findings never include the
+// secrets, and Default, the built-in text the template replaces.
+type TemplateData struct {
+	notify.Event
+	Default string
+}
+
+// ParseTemplates parses the issue body and check run summary templates. An empty template
+// keeps the built-in text.
+func ParseTemplates(issueBody, checkSummary string) (*Templates, error) {
+	t := &Templates{}
+	var err error
+	if t.issueBody, err = parseTemplate("issue_body", issueBody); err != nil {
+		return nil, err
+	}
+	if t.checkSummary, err = parseTemplate("check_summary", checkSummary); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// IssueBody renders the issue body of a full scan. It returns fallback, the built-in body,
+// when no template is set or the template fails.
+func (t *Templates) IssueBody(event notify.Event, fallback string) (string, error) {
+	if t == nil {
+		return fallback, nil
+	}
+	return render(t.issueBody, event, fallback)
+}
+
+// CheckSummary renders the summary of a completed check run. It returns fallback, the
+// built-in summary, when no template is set or the template fails.
+func (t *Templates) CheckSummary(event notify.Event, fallback string) (string, error) {
+	if t == nil {
+		return fallback, nil
+	}
+	return render(t.checkSummary, event, fallback)
+}
+
+func render(tmpl *template.Template, event notify.Event, fallback string) (string, error) {
+	if tmpl == nil {
+		return fallback, nil
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, TemplateData{Event: event, Default: fallback}); err != nil {
+		return fallback, fmt.Errorf("failed to render %s template: %w", tmpl.Name(), err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,192 @@
+// Package metrics summarizes tracked findings into the trends security teams report on: new
+// and resolved findings per week, the mean time to resolve a finding and the repositories and
+// rules with the most open findings.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// Defaults of Options.
+const (
+	DefaultWeeks = 12
+	DefaultTop   = 10
+	// MaxWeeks bounds the period Handler reports on.
+	MaxWeeks = 520
+)
+
+// Options selects the period and the length of the rankings of a Report.
+type Options struct {
+	// Weeks is the number of calendar weeks, starting on Monday UTC, reported up to now.
+	Weeks int
+	// Top is the number of repositories and rules ranked.
+	Top int
+	// Now is the end of the reported period.
+	Now time.Time
+}
+
+// Report summarizes tracked findings.
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Open        int       `json:"open"`
+	Resolved    int       `json:"resolved"`
+	Suppressed  int       `json:"suppressed"`
+	// MeanTimeToResolve is the mean time from first detection to resolution of the resolved
+	// findings, in hours; 0 when none is resolved.
+	MeanTimeToResolve float64 `json:"mean_time_to_resolve_hours"`
+	Weeks             []Week  `json:"weeks"`
+	// TopRepositories and TopRules rank repositories and rules by open findings.
+	TopRepositories []Count `json:"top_repositories"`
+	TopRules        []Count `json:"top_rules"`
+}
+
+// Week counts the findings first detected and resolved in the week starting on Start.
+type Week struct {
+	Start    time.Time `json:"start"`
+	New      int       `json:"new"`
+	Resolved int       `json:"resolved"`
+}
+
+// Count is the number of open findings of a repository or rule.
+type Count struct {
+	Name string `json:"name"`
+	Open int    `json:"open"`
+}
+
+// Compute summarizes findings as of opts.Now.
+func Compute(findings []store.Finding, opts Options) Report {
+	if opts.Weeks <= 0 {
+		opts.Weeks = DefaultWeeks
+	}
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	report := Report{GeneratedAt: opts.Now.UTC(), Weeks: make([]Week, opts.Weeks)}
+	current := weekStart(opts.Now)
+	for i := range report.Weeks {
+		report.Weeks[i].Start = current.AddDate(0, 0, -7*(opts.Weeks-1-i))
+	}
+
+	var resolution time.Duration
+	repositories := make(map[string]int)
+	rules := make(map[string]int)
+	for _, finding := range findings {
+		if week := report.week(finding.FirstSeen); week != nil {
+			week.New++
+		}
+		switch finding.State {
+		case store.StateOpen:
+			report.Open++
+			repositories[finding.Repository]++
+			rules[finding.RuleID]++
+		case store.StateResolved:
+			report.Resolved++
+			resolution += finding.ResolvedAt.Sub(finding.FirstSeen)
+			if week := report.week(finding.ResolvedAt); week != nil {
+				week.Resolved++
+			}
+		case store.StateSuppressed:
+			report.Suppressed++
+		}
+	}
+	if report.Resolved > 0 {
+		report.MeanTimeToResolve = resolution.Hours() / float64(report.Resolved)
+	}
+	report.TopRepositories = rank(repositories, opts.Top)
+	report.TopRules = rank(rules, opts.Top)
+	return report
+}
+
+// week returns the reported week containing t, or nil when t is outside the period.
+func (r *Report) week(t time.Time) *Week {
+	if t.IsZero() {
+		return nil
+	}
+	i := int(weekStart(t).Sub(r.Weeks[0].Start).Hours()) / (7 * 24)
+	if t.Before(r.Weeks[0].Start) || i >= len(r.Weeks) {
+		return nil
+	}
+	return &r.Weeks[i]
+}
+
+// weekStart returns midnight UTC of the Monday starting the week of t.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// rank returns the top names of counts by count, then name.
+func rank(counts map[string]int, top int) []Count {
+	ranked := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		ranked = append(ranked, Count{Name: name, Open: count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Open != ranked[j].Open {
+			return ranked[i].Open > ranked[j].Open
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	if len(ranked) > top {
+		ranked = ranked[:top]
+	}
+	return ranked
+}
+
+// Handler serves the Report of the tracked findings as JSON. The "weeks" and "top" query
+// parameters override the defaults.
+func Handler(findings store.FindingStore, logger zerolog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var opts Options
+		for name, target := range map[string]*int{"weeks": &opts.Weeks, "top": &opts.Top} {
+			value := r.URL.Query().Get(name)
+			if value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 || (name == "weeks" && n > MaxWeeks) {
+				http.Error(w, "invalid "+name+" parameter", http.StatusBadRequest)
+				return
+			}
+			*target = n
+		}
+
+		report, err := Load(r.Context(), findings, opts)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to load tracked findings")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logger.Error().Err(err).Msg("Failed to write metrics response")
+		}
+	})
+}
+
+// Load computes the Report of every finding tracked in findings.
+func Load(ctx context.Context, findings store.FindingStore, opts Options) (Report, error) {
+	all, err := findings.AllFindings(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	return Compute(all, opts), nil
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// now is a Wednesday.
+var now = time.Date(2026, 3, 18, 12, 0, 0, 0, time.UTC)
+
+func testFindings() []store.Finding {
+	monday := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	lastWeek := monday.AddDate(0, 0, -7)
+	return []store.Finding{
+		{Repository: "acme/api", RuleID: "aws-access-token", State: store.StateOpen, FirstSeen: monday},
+		{Repository: "acme/api", RuleID: "generic-api-key", State: store.StateOpen, FirstSeen: lastWeek},
+		{Repository: "acme/web", RuleID: "aws-access-token", State: store.StateOpen, FirstSeen: lastWeek},
+		{
+			Repository: "acme/web", RuleID: "generic-api-key", State: store.StateResolved,
+			FirstSeen: lastWeek, ResolvedAt: lastWeek.Add(10 * time.Hour),
+		},
+		{
+			Repository: "acme/old", RuleID: "generic-api-key", State: store.StateResolved,
+			FirstSeen: monday.AddDate(-1, 0, 0), ResolvedAt: monday.Add(time.Hour),
+		},
+		{Repository: "acme/web", RuleID: "private-key", State: store.StateSuppressed, FirstSeen: monday},
+	}
+}
+
+func TestCompute(t *testing.T) {
+	report := Compute(testFindings(), Options{Weeks: 2, Top: 1, Now: now})
+
+	assert.Equal(t, 3, report.Open)
+	assert.Equal(t, 2, report.Resolved)
+	assert.Equal(t, 1, report.Suppressed)
+	assert.InDelta(t, (10+365*24+1)/2.0, report.MeanTimeToResolve, 0.01)
+
+	require.Len(t, report.Weeks, 2)
+	assert.Equal(t, Week{Start: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), New: 3, Resolved: 1}, report.Weeks[0])
+	assert.Equal(t, Week{Start: time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), New: 2, Resolved: 1}, report.Weeks[1],
+		"Findings detected before the period are not counted as new")
+
+	assert.Equal(t, []Count{{Name: "acme/api", Open: 2}}, report.TopRepositories)
+	assert.Equal(t, []Count{{Name: "aws-access-token", Open: 2}}, report.TopRules)
+}
+
+func TestCompute_Empty(t *testing.T) {
+	report := Compute(nil, Options{Now: now})
+	assert.Len(t, report.Weeks, DefaultWeeks)
+	assert.Zero(t, report.MeanTimeToResolve)
+	assert.Empty(t, report.TopRepositories)
+}
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2026, 3, 22, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), weekStart(sunday))
+	assert.Equal(t, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), weekStart(time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestHandler(t *testing.T) {
+	findings := store.NewMemory()
+	_, err := findings.RecordScan(context.Background(), store.Scan{
+		Repository: "acme/api",
+		Complete:   true,
+		Findings:   []store.Finding{{Fingerprint: "gitguard-a", File: ".env", RuleID: "aws-access-token"}},
+		Time:       time.Now(),
+	})
+	require.NoError(t, err)
+	handler := Handler(findings, zerolog.Nop())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/metrics?weeks=4", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var report Report
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	assert.Equal(t, 1, report.Open)
+	require.Len(t, report.Weeks, 4)
+	assert.Equal(t, 1, report.Weeks[3].New)
+	assert.Equal(t, []Count{{Name: "acme/api", Open: 1}}, report.TopRepositories)
+
+	for _, query := range []string{"weeks=0", "weeks=1000", "top=x"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/metrics?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/metrics", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
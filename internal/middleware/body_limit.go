@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/omercnet/gitguard/internal/problem"
+)
+
+// MaxBodySize limits request bodies to limit bytes. Requests declaring a larger Content-Length
+// are rejected with a 413 problem details response before any of the body is read; bodies of unknown length are wrapped in
+// http.MaxBytesReader so reading past the limit fails with *http.MaxBytesError.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				problem.Write(w, r, http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge,
+					fmt.Sprintf("payload exceeds the %d byte limit", limit))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readingHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestMaxBodySize(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int64
+		body          string
+		contentLength int64
+		expected      int
+	}{
+		{"within limit", 10, "small", 5, http.StatusOK},
+		{"exactly at limit", 5, "12345", 5, http.StatusOK},
+		{"declared too large", 5, "123456", 6, http.StatusRequestEntityTooLarge},
+		{"unknown length too large", 5, "123456", -1, http.StatusRequestEntityTooLarge},
+		{"disabled limit", 0, strings.Repeat("x", 100), 100, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			rec := httptest.NewRecorder()
+
+			MaxBodySize(tt.limit)(readingHandler()).ServeHTTP(rec, req)
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
+
+func TestMaxBodySize_RejectsBeforeReading(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("123456"))
+	rec := httptest.NewRecorder()
+	MaxBodySize(5)(next).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.False(t, called, "Should not invoke the handler for oversized bodies")
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"code":"payload_too_large"`)
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/problem"
+	"github.com/rs/zerolog"
+)
+
+// DefaultHookRangesRefresh is how often GitHub's hook ranges are re-fetched by default.
+const DefaultHookRangesRefresh = time.Hour
+
+// HookAllowlist rejects webhook deliveries that do not originate from GitHub's published
+// hook IP ranges (GET /meta). It complements, and never replaces, HMAC signature verification.
+type HookAllowlist struct {
+	fetch          func(ctx context.Context) ([]string, error)
+	trustedProxies []*net.IPNet
+	refresh        time.Duration
+	logger         zerolog.Logger
+
+	mu     sync.RWMutex
+	ranges []*net.IPNet
+}
+
+// NewHookAllowlist creates an allowlist that loads hook ranges from the GitHub API at apiURL.
+// Requests arriving from trustedProxies are attributed to the client address in X-Forwarded-For.
+func NewHookAllowlist(
+	apiURL string,
+	trustedProxies []string,
+	refresh time.Duration,
+	logger zerolog.Logger,
+) (*HookAllowlist, error) {
+	proxies, err := parseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub API URL %q: %w", apiURL, err)
+	}
+	client := github.NewClient(&http.Client{Timeout: 10 * time.Second})
+	client.BaseURL = baseURL
+
+	if refresh <= 0 {
+		refresh = DefaultHookRangesRefresh
+	}
+
+	return &HookAllowlist{
+		fetch: func(ctx context.Context) ([]string, error) {
+			meta, _, err := client.Meta.Get(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch GitHub meta: %w", err)
+			}
+			return meta.Hooks, nil
+		},
+		trustedProxies: proxies,
+		refresh:        refresh,
+		logger:         logger,
+	}, nil
+}
+
+// Start loads the hook ranges and keeps refreshing them until ctx is done.
+func (a *HookAllowlist) Start(ctx context.Context) {
+	a.Refresh(ctx)
+
+	go func() {
+		ticker := time.NewTicker(a.refresh)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh re-fetches the hook ranges, keeping the previous ranges on failure.
+func (a *HookAllowlist) Refresh(ctx context.Context) {
+	cidrs, err := a.fetch(ctx)
+	if err == nil && len(cidrs) == 0 {
+		err = fmt.Errorf("GitHub meta returned no hook ranges")
+	}
+	if err != nil {
+		a.logger.Error().Err(err).Msg(constants.LogMsgHookRangesFailed)
+		return
+	}
+
+	ranges, err := parseCIDRs(cidrs)
+	if err != nil {
+		a.logger.Error().Err(err).Msg(constants.LogMsgHookRangesFailed)
+		return
+	}
+
+	a.mu.Lock()
+	a.ranges = ranges
+	a.mu.Unlock()
+
+	a.logger.Debug().Int("ranges", len(ranges)).Msg(constants.LogMsgHookRangesLoaded)
+}
+
+// Middleware wraps next, rejecting POST requests from addresses outside the hook ranges.
+// Until the ranges have been loaded once, requests are allowed so a GitHub API outage at
+// startup does not drop deliveries; signature verification still applies.
+func (a *HookAllowlist) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := a.clientIP(r)
+		if !a.allowed(ip) {
+			a.logger.Warn().
+				Str("remote_ip", ip.String()).
+				Str("delivery_id", r.Header.Get("X-GitHub-Delivery")).
+				Msg(constants.LogMsgRejectedHookSource)
+			problem.Write(w, r, http.StatusForbidden, problem.CodeSourceNotAllowed,
+				"source address is not in GitHub's hook ranges")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *HookAllowlist) allowed(ip net.IP) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.ranges == nil {
+		return true
+	}
+	return ip != nil && containsIP(a.ranges, ip)
+}
+
+// clientIP returns the peer address, or the right-most untrusted X-Forwarded-For hop when the
+// peer is a trusted proxy.
+func (a *HookAllowlist) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+
+	if ip == nil || !containsIP(a.trustedProxies, ip) {
+		return ip
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			return nil
+		}
+		if !containsIP(a.trustedProxies, hop) {
+			return hop
+		}
+	}
+	return ip
+}
+
+func containsIP(ranges []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range ranges {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDRs parses CIDR notations, accepting bare IPs as single-address ranges.
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	ranges := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", value, err)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	return ranges, nil
+}
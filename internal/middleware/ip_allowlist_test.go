@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAllowlist(t *testing.T, hooks []string, proxies []string) *HookAllowlist {
+	t.Helper()
+	trusted, err := parseCIDRs(proxies)
+	require.NoError(t, err)
+	return &HookAllowlist{
+		fetch:          func(context.Context) ([]string, error) { return hooks, nil },
+		trustedProxies: trusted,
+		refresh:        DefaultHookRangesRefresh,
+		logger:         zerolog.Nop(),
+	}
+}
+
+func serve(h http.Handler, method, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(method, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestHookAllowlist_Middleware(t *testing.T) {
+	allowlist := newTestAllowlist(t, []string{"192.30.252.0/22", "2a0a:a440::/29"}, []string{"10.0.0.0/8"})
+	allowlist.Refresh(context.Background())
+	handler := allowlist.Middleware(okHandler())
+
+	tests := []struct {
+		name         string
+		method       string
+		remoteAddr   string
+		forwardedFor string
+		expected     int
+	}{
+		{"github ipv4", http.MethodPost, "192.30.252.10:443", "", http.StatusOK},
+		{"github ipv6", http.MethodPost, "[2a0a:a440::1]:443", "", http.StatusOK},
+		{"unknown source", http.MethodPost, "203.0.113.5:443", "", http.StatusForbidden},
+		{"non-post passes", http.MethodGet, "203.0.113.5:443", "", http.StatusOK},
+		{"trusted proxy forwards github", http.MethodPost, "10.1.2.3:80", "192.30.252.10", http.StatusOK},
+		{"trusted proxy forwards unknown", http.MethodPost, "10.1.2.3:80", "203.0.113.5", http.StatusForbidden},
+		{"spoofed header behind proxy", http.MethodPost, "10.1.2.3:80", "192.30.252.10, 203.0.113.5", http.StatusForbidden},
+		{"chained trusted proxies", http.MethodPost, "10.1.2.3:80", "192.30.252.10, 10.9.9.9", http.StatusOK},
+		{"untrusted peer ignores header", http.MethodPost, "203.0.113.5:443", "192.30.252.10", http.StatusForbidden},
+		{"garbage forwarded header", http.MethodPost, "10.1.2.3:80", "not-an-ip", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, serve(handler, tt.method, tt.remoteAddr, tt.forwardedFor))
+		})
+	}
+}
+
+func TestHookAllowlist_FailsOpenUntilLoaded(t *testing.T) {
+	allowlist := newTestAllowlist(t, nil, nil)
+	allowlist.fetch = func(context.Context) ([]string, error) { return nil, errors.New("meta unavailable") }
+	allowlist.Refresh(context.Background())
+
+	handler := allowlist.Middleware(okHandler())
+	assert.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "203.0.113.5:443", ""))
+}
+
+func TestHookAllowlist_KeepsRangesOnRefreshFailure(t *testing.T) {
+	allowlist := newTestAllowlist(t, []string{"192.30.252.0/22"}, nil)
+	allowlist.Refresh(context.Background())
+
+	allowlist.fetch = func(context.Context) ([]string, error) { return nil, errors.New("meta unavailable") }
+	allowlist.Refresh(context.Background())
+
+	handler := allowlist.Middleware(okHandler())
+	assert.Equal(t, http.StatusForbidden, serve(handler, http.MethodPost, "203.0.113.5:443", ""))
+	assert.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "192.30.252.1:443", ""))
+}
+
+func TestNewHookAllowlist_FetchesMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/meta", r.URL.Path)
+		_, _ = w.Write([]byte(`{"hooks":["192.30.252.0/22"]}`))
+	}))
+	defer server.Close()
+
+	allowlist, err := NewHookAllowlist(server.URL+"/", nil, 0, zerolog.Nop())
+	require.NoError(t, err)
+	allowlist.Refresh(context.Background())
+
+	handler := allowlist.Middleware(okHandler())
+	assert.Equal(t, http.StatusOK, serve(handler, http.MethodPost, "192.30.252.1:443", ""))
+	assert.Equal(t, http.StatusForbidden, serve(handler, http.MethodPost, "198.51.100.1:443", ""))
+}
+
+func TestNewHookAllowlist_InvalidProxy(t *testing.T) {
+	_, err := NewHookAllowlist("https://api.github.com/", []string{"not-a-cidr"}, 0, zerolog.Nop())
+	assert.Error(t, err)
+}
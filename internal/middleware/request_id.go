@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/omercnet/gitguard/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// RequestIDHeader carries the request ID in responses, and in requests from callers that
+// have their own.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds request IDs taken from headers, which end up in every log line.
+const maxRequestIDLength = 128
+
+// requestIDHeaders are the headers an ID is taken from, in order: the webhook delivery IDs
+// of GitHub and GitLab, so logs can be matched with the sender's delivery log, then the
+// caller's own request ID.
+var requestIDHeaders = []string{"X-GitHub-Delivery", "X-Gitlab-Event-UUID", RequestIDHeader}
+
+// RequestID assigns every request an ID, generated unless one of the request ID headers
+// carries one, and echoes it in the X-Request-Id response header. The request context
+// carries the ID and a logger adding it as request_id to every line.
+func RequestID(logger zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := logging.WithRequestID(r.Context(), id)
+			ctx = logger.With().Str("request_id", id).Logger().WithContext(ctx)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func requestID(r *http.Request) string {
+	for _, header := range requestIDHeaders {
+		if id := r.Header.Get(header); validRequestID(id) {
+			return id
+		}
+	}
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID accepts non-empty printable ASCII IDs of bounded length.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
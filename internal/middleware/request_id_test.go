@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "github delivery",
+			headers: map[string]string{"X-GitHub-Delivery": "72d3162e", RequestIDHeader: "caller"},
+			want:    "72d3162e",
+		},
+		{name: "gitlab delivery", headers: map[string]string{"X-Gitlab-Event-UUID": "b5e3ab61"}, want: "b5e3ab61"},
+		{name: "caller request ID", headers: map[string]string{RequestIDHeader: "caller"}, want: "caller"},
+		{name: "invalid caller request ID", headers: map[string]string{RequestIDHeader: "bad id\n"}},
+		{name: "generated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			var got string
+			next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = logging.RequestID(r.Context())
+				zerolog.Ctx(r.Context()).Info().Msg("handled")
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			RequestID(zerolog.New(&logs))(next).ServeHTTP(rec, req)
+
+			if tt.want != "" {
+				assert.Equal(t, tt.want, got)
+			} else {
+				assert.Len(t, got, 32)
+			}
+			assert.Equal(t, got, rec.Header().Get(RequestIDHeader))
+			assert.True(t, strings.Contains(logs.String(), `"request_id":"`+got+`"`), logs.String())
+		})
+	}
+}
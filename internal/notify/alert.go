@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Alert is an incident raised for a single finding.
+type Alert struct {
+	// DedupKey identifies the finding across scans so repeated triggers update one incident.
+	DedupKey   string
+	Summary    string
+	Repository string
+	File       string
+	RuleID     string
+	Severity   string
+	Link       string
+}
+
+// Alerter opens and resolves incidents in an on-call system.
+type Alerter interface {
+	Trigger(ctx context.Context, alert Alert) error
+	Resolve(ctx context.Context, dedupKey string) error
+}
+
+// Fingerprint derives a stable dedup key for a finding in a repository. It is independent of
+// line numbers and commits, so the key survives unrelated edits to the file, and it only
+// includes a hash of the secret. Findings without a secret that already carry a GitGuard
+// fingerprint, as restored from the scan cache, keep it.
+func Fingerprint(repository string, finding report.Finding) string {
+	if finding.Secret == "" && strings.HasPrefix(finding.Fingerprint, fingerprintPrefix) {
+		return finding.Fingerprint
+	}
+	sum := sha256.Sum256([]byte(repository + "\x00" + finding.File + "\x00" + finding.RuleID + "\x00" + finding.Secret))
+	return fingerprintPrefix + hex.EncodeToString(sum[:16])
+}
+
+// RedactedSecret returns the secret of finding as redaction allows it to be stored and
+// reported. Findings restored from the scan cache carry their redacted secret in Match, as
+// cached with WithoutSecret.
+func RedactedSecret(finding report.Finding, redaction *redact.Policy) string {
+	if finding.Secret == "" && strings.HasPrefix(finding.Fingerprint, fingerprintPrefix) {
+		return finding.Match
+	}
+	return redaction.Secret(finding.Secret)
+}
+
+// WithoutSecret returns finding as it may be cached: identified by its GitGuard fingerprint,
+// with the secret and the lines holding it dropped and Match set to the redacted secret.
+func WithoutSecret(repository string, finding report.Finding, redaction *redact.Policy) report.Finding {
+	finding.Fingerprint = Fingerprint(repository, finding)
+	finding.Match = redaction.Secret(finding.Secret)
+	finding.Secret, finding.Line = "", ""
+	return finding
+}
+
+// fingerprintPrefix distinguishes GitGuard fingerprints from gitleaks' own.
+const fingerprintPrefix = "gitguard-"
+
+// AlertManager keeps track of the incidents opened per repository so alerts can be resolved
+// once their finding no longer shows up in a full scan. State is kept in memory; after a
+// restart, incidents that are still firing are re-triggered under the same dedup key.
+type AlertManager struct {
+	alerter Alerter
+
+	mu   sync.Mutex
+	open map[string]map[string]bool
+}
+
+// NewAlertManager creates a manager delivering alerts through alerter.
+func NewAlertManager(alerter Alerter) *AlertManager {
+	return &AlertManager{
+		alerter: alerter,
+		open:    make(map[string]map[string]bool),
+	}
+}
+
+// Reconcile triggers alerts that are not open yet and resolves open alerts of the repository
+// that are no longer present.
+func (m *AlertManager) Reconcile(ctx context.Context, repository string, alerts []Alert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.open[repository]
+	current := make(map[string]bool, len(alerts))
+	var errs []error
+
+	for _, alert := range alerts {
+		if current[alert.DedupKey] {
+			continue
+		}
+		if previous[alert.DedupKey] {
+			current[alert.DedupKey] = true
+			continue
+		}
+		if err := m.alerter.Trigger(ctx, alert); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		current[alert.DedupKey] = true
+	}
+
+	for key := range previous {
+		if current[key] {
+			continue
+		}
+		if err := m.alerter.Resolve(ctx, key); err != nil {
+			// Keep it open so the next scan retries the resolution.
+			current[key] = true
+			errs = append(errs, err)
+		}
+	}
+
+	if len(current) == 0 {
+		delete(m.open, repository)
+	} else {
+		m.open[repository] = current
+	}
+	return errors.Join(errs...)
+}
+
+// MultiAlerter fans alerts out to several on-call systems.
+type MultiAlerter []Alerter
+
+// Trigger opens the alert in every system.
+func (m MultiAlerter) Trigger(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, a := range m {
+		if err := a.Trigger(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Resolve closes the alert in every system.
+func (m MultiAlerter) Resolve(ctx context.Context, dedupKey string) error {
+	var errs []error
+	for _, a := range m {
+		if err := a.Resolve(ctx, dedupKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
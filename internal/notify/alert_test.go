@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+type recordingAlerter struct {
+	triggered []string
+	resolved  []string
+	failNext  bool
+}
+
+func (r *recordingAlerter) Trigger(_ context.Context, alert Alert) error {
+	r.triggered = append(r.triggered, alert.DedupKey)
+	return nil
+}
+
+func (r *recordingAlerter) Resolve(_ context.Context, dedupKey string) error {
+	if r.failNext {
+		r.failNext = false
+		return errors.New("unavailable")
+	}
+	r.resolved = append(r.resolved, dedupKey)
+	return nil
+}
+
+func TestFingerprint(t *testing.T) {
+	finding := report.Finding{File: "a.env", RuleID: "private-key", Secret: "secret", StartLine: 1}
+	moved := finding
+	moved.StartLine = 20
+
+	assert.Equal(t, Fingerprint("o/r", finding), Fingerprint("o/r", moved), "Should not depend on line numbers")
+	assert.NotEqual(t, Fingerprint("o/r", finding), Fingerprint("o/other", finding))
+	assert.NotContains(t, Fingerprint("o/r", finding), "secret")
+}
+
+func TestAlertManager_Reconcile(t *testing.T) {
+	alerter := &recordingAlerter{}
+	manager := NewAlertManager(alerter)
+	ctx := context.Background()
+
+	require.NoError(t, manager.Reconcile(ctx, "o/r", []Alert{{DedupKey: "a"}, {DedupKey: "b"}, {DedupKey: "a"}}))
+	assert.Equal(t, []string{"a", "b"}, alerter.triggered)
+
+	require.NoError(t, manager.Reconcile(ctx, "o/r", []Alert{{DedupKey: "b"}}))
+	assert.Equal(t, []string{"a", "b"}, alerter.triggered, "Should not re-trigger open alerts")
+	assert.Equal(t, []string{"a"}, alerter.resolved, "Should resolve cleared findings")
+
+	alerter.failNext = true
+	assert.Error(t, manager.Reconcile(ctx, "o/r", nil))
+	require.NoError(t, manager.Reconcile(ctx, "o/r", nil), "Should retry failed resolutions")
+	assert.Equal(t, []string{"a", "b"}, alerter.resolved)
+}
+
+func TestPagerDuty_TriggerAndResolve(t *testing.T) {
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	pd := NewPagerDuty("routing-key")
+	pd.URL = server.URL
+
+	require.NoError(t, pd.Trigger(context.Background(), Alert{DedupKey: "k", Summary: "leak", Repository: "o/r"}))
+	require.NoError(t, pd.Resolve(context.Background(), "k"))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "trigger", events[0].EventAction)
+	assert.Equal(t, "routing-key", events[0].RoutingKey)
+	assert.Equal(t, "k", events[0].DedupKey)
+	assert.Equal(t, "critical", events[0].Payload.Severity)
+	assert.Equal(t, "resolve", events[1].EventAction)
+	assert.Nil(t, events[1].Payload)
+}
+
+func TestOpsgenie_TriggerAndResolve(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GenieKey api-key", r.Header.Get("Authorization"))
+		paths = append(paths, r.URL.RequestURI())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	og := NewOpsgenie("api-key", server.URL+"/")
+
+	require.NoError(t, og.Trigger(context.Background(), Alert{DedupKey: "k", Summary: "leak"}))
+	require.NoError(t, og.Resolve(context.Background(), "k"))
+
+	assert.Equal(t, []string{"/v2/alerts", "/v2/alerts/k/close?identifierType=alias"}, paths)
+}
@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Chat message formats.
+const (
+	FormatSlack   = "slack"
+	FormatTeams   = "teams"
+	FormatDiscord = "discord"
+)
+
+// discordColor is the embed color used for findings (GitHub's danger red).
+const discordColor = 0xCF222E
+
+// Chat posts human-readable messages for scans with findings to a chat incoming webhook.
+// Clean scans are not posted to keep channels quiet.
+type Chat struct {
+	format  string
+	webhook *Webhook
+}
+
+// NewChat creates a chat notifier posting messages in format to url.
+func NewChat(format, url string) (*Chat, error) {
+	switch format {
+	case FormatSlack, FormatTeams, FormatDiscord:
+	default:
+		return nil, fmt.Errorf("unsupported chat format %q", format)
+	}
+	return &Chat{format: format, webhook: NewWebhook(url, "")}, nil
+}
+
+// Notify posts the event if the scan found secrets.
+func (c *Chat) Notify(ctx context.Context, event Event) error {
+	if event.Findings.Total == 0 {
+		return nil
+	}
+
+	var payload any
+	switch c.format {
+	case FormatTeams:
+		payload = teamsMessage(event)
+	case FormatDiscord:
+		payload = discordMessage(event)
+	default:
+		payload = slackMessage(event)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", c.format, err)
+	}
+	return c.webhook.post(ctx, event.Type, body)
+}
+
+// Router sends events to the notifier configured for the event's installation, falling back
+// to Default. A nil route drops the event.
+type Router struct {
+	Default        Notifier
+	ByInstallation map[int64]Notifier
+}
+
+// Notify delivers the event to the installation's notifier.
+func (r *Router) Notify(ctx context.Context, event Event) error {
+	notifier, ok := r.ByInstallation[event.Installation]
+	if !ok {
+		notifier = r.Default
+	}
+	if notifier == nil {
+		return nil
+	}
+	return notifier.Notify(ctx, event)
+}
+
+func chatTitle(event Event) string {
+	return fmt.Sprintf("GitGuard found %d potential secret(s) in %s", event.Findings.Total, event.Repository)
+}
+
+// chatFacts lists the event attributes shown in chat messages, in display order.
+func chatFacts(event Event) [][2]string {
+	facts := [][2]string{{"Repository", event.Repository}}
+	if event.Ref != "" {
+		facts = append(facts, [2]string{"Branch", strings.TrimPrefix(event.Ref, "refs/heads/")})
+	}
+	if event.Commit != "" {
+		facts = append(facts, [2]string{"Commit", shortCommit(event.Commit)})
+	}
+	if event.Findings.HighestSeverity != "" {
+		facts = append(facts, [2]string{"Highest severity", event.Findings.HighestSeverity})
+	}
+	if len(event.Findings.ByRule) > 0 {
+		rules := make([]string, 0, len(event.Findings.ByRule))
+		for rule, count := range event.Findings.ByRule {
+			rules = append(rules, fmt.Sprintf("%s (%d)", rule, count))
+		}
+		sort.Strings(rules)
+		facts = append(facts, [2]string{"Rules", strings.Join(rules, ", ")})
+	}
+	return facts
+}
+
+// chatLink returns the most specific link for reviewing the event.
+func chatLink(event Event) string {
+	for _, link := range []string{event.Links.CheckRun, event.Links.Issue, event.Links.Commit, event.Links.Repository} {
+		if link != "" {
+			return link
+		}
+	}
+	return ""
+}
+
+func slackMessage(event Event) map[string]any {
+	lines := []string{"*" + chatTitle(event) + "*"}
+	for _, fact := range chatFacts(event) {
+		lines = append(lines, fmt.Sprintf("%s: %s", fact[0], fact[1]))
+	}
+	if link := chatLink(event); link != "" {
+		lines = append(lines, fmt.Sprintf("<%s|View results>", link))
+	}
+	return map[string]any{"text": strings.Join(lines, "\n")}
+}
+
+func teamsMessage(event Event) map[string]any {
+	facts := make([]map[string]string, 0)
+	for _, fact := range chatFacts(event) {
+		facts = append(facts, map[string]string{"title": fact[0], "value": fact[1]})
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []map[string]any{
+			{"type": "TextBlock", "text": chatTitle(event), "weight": "Bolder", "size": "Medium", "wrap": true},
+			{"type": "FactSet", "facts": facts},
+		},
+	}
+	if link := chatLink(event); link != "" {
+		card["actions"] = []map[string]string{{"type": "Action.OpenUrl", "title": "View results", "url": link}}
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
+
+func discordMessage(event Event) map[string]any {
+	fields := make([]map[string]any, 0)
+	for _, fact := range chatFacts(event) {
+		fields = append(fields, map[string]any{"name": fact[0], "value": fact[1], "inline": true})
+	}
+
+	embed := map[string]any{
+		"title":  chatTitle(event),
+		"color":  discordColor,
+		"fields": fields,
+	}
+	if link := chatLink(event); link != "" {
+		embed["url"] = link
+	}
+	if !event.Timestamp.IsZero() {
+		embed["timestamp"] = event.Timestamp.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	return map[string]any{"username": "GitGuard", "embeds": []map[string]any{embed}}
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chatEvent() Event {
+	return Event{
+		Type:       EventScanCompleted,
+		Repository: "octo/repo",
+		Ref:        "refs/heads/main",
+		Commit:     "0123456789abcdef",
+		Findings: Summary{
+			Total:           2,
+			HighestSeverity: "critical",
+			ByRule:          map[string]int{"private-key": 1, "aws-access-token": 1},
+		},
+		Links: Links{CheckRun: "https://github.com/octo/repo/runs/1"},
+	}
+}
+
+func postChat(t *testing.T, format string, event Event) (map[string]any, int) {
+	t.Helper()
+
+	var (
+		payload  map[string]any
+		requests int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	chat, err := NewChat(format, server.URL)
+	require.NoError(t, err)
+	require.NoError(t, chat.Notify(context.Background(), event))
+	return payload, requests
+}
+
+func TestChat_Slack(t *testing.T) {
+	payload, _ := postChat(t, FormatSlack, chatEvent())
+
+	text := payload["text"].(string)
+	assert.Contains(t, text, "GitGuard found 2 potential secret(s) in octo/repo")
+	assert.Contains(t, text, "Rules: aws-access-token (1), private-key (1)")
+	assert.Contains(t, text, "<https://github.com/octo/repo/runs/1|View results>")
+}
+
+func TestChat_Teams(t *testing.T) {
+	payload, _ := postChat(t, FormatTeams, chatEvent())
+
+	attachment := payload["attachments"].([]any)[0].(map[string]any)
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", attachment["contentType"])
+	card := attachment["content"].(map[string]any)
+	assert.Equal(t, "AdaptiveCard", card["type"])
+	action := card["actions"].([]any)[0].(map[string]any)
+	assert.Equal(t, "https://github.com/octo/repo/runs/1", action["url"])
+}
+
+func TestChat_Discord(t *testing.T) {
+	payload, _ := postChat(t, FormatDiscord, chatEvent())
+
+	embed := payload["embeds"].([]any)[0].(map[string]any)
+	assert.Equal(t, "GitGuard found 2 potential secret(s) in octo/repo", embed["title"])
+	assert.Equal(t, "https://github.com/octo/repo/runs/1", embed["url"])
+	assert.NotEmpty(t, embed["fields"])
+}
+
+func TestChat_SkipsCleanScans(t *testing.T) {
+	event := chatEvent()
+	event.Findings = Summary{}
+
+	_, requests := postChat(t, FormatSlack, event)
+	assert.Zero(t, requests, "Should not post clean scans")
+}
+
+func TestNewChat_InvalidFormat(t *testing.T) {
+	_, err := NewChat("irc", "https://example.com")
+	assert.Error(t, err)
+}
+
+func TestRouter(t *testing.T) {
+	var routed []string
+	named := func(name string) Notifier {
+		return notifierFunc(func(context.Context, Event) error { routed = append(routed, name); return nil })
+	}
+	router := &Router{Default: named("default"), ByInstallation: map[int64]Notifier{42: named("team")}}
+
+	require.NoError(t, router.Notify(context.Background(), Event{Installation: 42}))
+	require.NoError(t, router.Notify(context.Background(), Event{Installation: 7}))
+	assert.Equal(t, []string{"team", "default"}, routed)
+
+	assert.NoError(t, (&Router{}).Notify(context.Background(), Event{}), "Should drop events without a route")
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultJiraIssueType is the type of Jira issues filed when none is configured.
+const DefaultJiraIssueType = "Task"
+
+// Ticket is a request to track work, such as rotating exposed secrets, in a ticketing system.
+type Ticket struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// Ticketer files tickets in a ticketing system and returns the ticket's URL.
+type Ticketer interface {
+	File(ctx context.Context, ticket Ticket) (string, error)
+}
+
+// Jira files tickets as issues of a Jira project through the Jira REST API, authenticating
+// with a user's email and API token.
+type Jira struct {
+	URL       string
+	User      string
+	Token     string
+	Project   string
+	IssueType string
+	Client    *http.Client
+}
+
+// NewJira creates a Jira ticketer for the site at baseURL. An empty issueType selects
+// DefaultJiraIssueType.
+func NewJira(baseURL, user, token, project, issueType string) *Jira {
+	if issueType == "" {
+		issueType = DefaultJiraIssueType
+	}
+	return &Jira{
+		URL:       strings.TrimSuffix(baseURL, "/"),
+		User:      user,
+		Token:     token,
+		Project:   project,
+		IssueType: issueType,
+		Client:    &http.Client{Timeout: defaultWebhookTimeout},
+	}
+}
+
+type jiraIssue struct {
+	Fields jiraFields `json:"fields"`
+}
+
+type jiraFields struct {
+	Project     jiraKey  `json:"project"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	IssueType   jiraName `json:"issuetype"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+type jiraKey struct {
+	Key string `json:"key"`
+}
+
+type jiraName struct {
+	Name string `json:"name"`
+}
+
+// File creates the issue and returns its URL on the Jira site.
+func (j *Jira) File(ctx context.Context, ticket Ticket) (string, error) {
+	body, err := json.Marshal(jiraIssue{Fields: jiraFields{
+		Project:     jiraKey{Key: j.Project},
+		Summary:     ticket.Title,
+		Description: ticket.Body,
+		IssueType:   jiraName{Name: j.IssueType},
+		Labels:      ticket.Labels,
+	}})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Jira issue: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.URL+"/rest/api/2/issue", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Jira request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(j.User, j.Token)
+
+	resp, err := j.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send Jira request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("jira request failed with status %d", resp.StatusCode)
+	}
+	var created jiraKey
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to read the created Jira issue: %w", err)
+	}
+	if created.Key == "" {
+		return "", fmt.Errorf("jira response has no issue key")
+	}
+	return j.URL + "/browse/" + created.Key, nil
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJira_File(t *testing.T) {
+	var issue jiraIssue
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/issue", r.URL.Path)
+		user, token, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "bot@example.com", user)
+		assert.Equal(t, "token", token)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&issue))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "10001", "key": "SEC-42"}`))
+	}))
+	defer server.Close()
+
+	jira := NewJira(server.URL+"/", "bot@example.com", "token", "SEC", "")
+	url, err := jira.File(context.Background(), Ticket{Title: "Rotate", Body: "Details", Labels: []string{"gitguard"}})
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/browse/SEC-42", url)
+	assert.Equal(t, "SEC", issue.Fields.Project.Key)
+	assert.Equal(t, DefaultJiraIssueType, issue.Fields.IssueType.Name)
+	assert.Equal(t, "Rotate", issue.Fields.Summary)
+	assert.Equal(t, []string{"gitguard"}, issue.Fields.Labels)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	_, err = NewJira(failing.URL, "bot@example.com", "token", "SEC", "Bug").File(context.Background(), Ticket{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,198 @@
+// Package notify delivers GitGuard scan events to external systems.
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/redact"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Event types.
+const (
+	EventScanCompleted = "scan.completed"
+)
+
+// Finding ages, relative to the window within which findings count as new.
+const (
+	AgeNew          = "new"
+	AgeLongStanding = "long_standing"
+)
+
+// Scan kinds.
+const (
+	ScanCommit         = "commit"
+	ScanFullRepository = "full_repository"
+)
+
+// Event describes something GitGuard did. Secret values are never part of an event.
+type Event struct {
+	Type          string       `json:"type"`
+	Scan          string       `json:"scan"`
+	Repository    string       `json:"repository"`
+	Private       bool         `json:"private,omitempty"`
+	Installation  int64        `json:"installation_id,omitempty"`
+	Ref           string       `json:"ref,omitempty"`
+	DefaultBranch string       `json:"default_branch,omitempty"`
+	Commit        string       `json:"commit,omitempty"`
+	Conclusion    string       `json:"conclusion,omitempty"`
+	Findings      Summary      `json:"findings"`
+	Details       []Finding    `json:"details,omitempty"`
+	Transitions   []Transition `json:"transitions,omitempty"`
+	Links         Links        `json:"links"`
+	Timestamp     time.Time    `json:"timestamp"`
+	// RequestID identifies the webhook delivery or request that triggered the scan.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Finding locates a single finding of a scan.
+type Finding struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	RuleID      string `json:"rule_id"`
+	Severity    string `json:"severity"`
+	Fingerprint string `json:"fingerprint"`
+	// Secret is the secret as the redaction policy allows it to be reported, if at all.
+	Secret string `json:"secret,omitempty"`
+	// Commit, Author and Date identify the commit that introduced the finding, when known.
+	Commit string `json:"commit,omitempty"`
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+	// Branches are the branches the finding was detected on, when findings are deduplicated
+	// across branches.
+	Branches []string `json:"branches,omitempty"`
+	// FirstSeen and Age tell when the finding was first seen and whether that makes it new or
+	// long-standing, once the event is aged.
+	FirstSeen time.Time `json:"first_seen,omitzero"`
+	Age       string    `json:"age,omitempty"`
+}
+
+// Transition reports a tracked finding changing lifecycle state, e.g. from open to resolved.
+// New findings have an empty From state.
+type Transition struct {
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file"`
+	RuleID      string `json:"rule_id"`
+	From        string `json:"from,omitempty"`
+	To          string `json:"to"`
+}
+
+// Summary aggregates the findings of a scan.
+type Summary struct {
+	Total           int            `json:"total"`
+	HighestSeverity string         `json:"highest_severity,omitempty"`
+	BySeverity      map[string]int `json:"by_severity,omitempty"`
+	ByRule          map[string]int `json:"by_rule,omitempty"`
+	// New and LongStanding count the findings of each age once the event is aged.
+	New          int `json:"new,omitempty"`
+	LongStanding int `json:"long_standing,omitempty"`
+}
+
+// Links points to where the results can be reviewed on GitHub.
+type Links struct {
+	Repository string `json:"repository,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	CheckRun   string `json:"check_run,omitempty"`
+	Issue      string `json:"issue,omitempty"`
+}
+
+// Notifier delivers events to an external system.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi fans an event out to several notifiers.
+type Multi []Notifier
+
+// Notify delivers the event to every notifier, returning the combined errors.
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the notifiers that hold connections, returning the combined errors.
+func (m Multi) Close() error {
+	var errs []error
+	for _, n := range m {
+		if closer, ok := n.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Summarize aggregates findings by severity and rule. A nil classifier rates every finding high.
+func Summarize(findings []report.Finding, classifier *severity.Classifier) Summary {
+	summary := Summary{Total: len(findings)}
+	if len(findings) == 0 {
+		return summary
+	}
+
+	summary.HighestSeverity = classifier.Max(findings).String()
+	summary.BySeverity = make(map[string]int)
+	for level, count := range classifier.Counts(findings) {
+		summary.BySeverity[level.String()] = count
+	}
+	summary.ByRule = make(map[string]int)
+	for _, finding := range findings {
+		summary.ByRule[finding.RuleID]++
+	}
+	return summary
+}
+
+// Details converts findings into their notification form, identified by Fingerprint, with
+// their secrets redacted by redaction; a nil redaction leaves secrets out.
+func Details(
+	repository string, findings []report.Finding, classifier *severity.Classifier, redaction *redact.Policy,
+) []Finding {
+	if len(findings) == 0 {
+		return nil
+	}
+	details := make([]Finding, 0, len(findings))
+	for _, finding := range findings {
+		details = append(details, Finding{
+			File:        finding.File,
+			Line:        finding.StartLine,
+			RuleID:      finding.RuleID,
+			Severity:    classifier.Classify(finding).String(),
+			Fingerprint: Fingerprint(repository, finding),
+			Secret:      RedactedSecret(finding, redaction),
+			Commit:      finding.Commit,
+			Author:      finding.Author,
+			Date:        finding.Date,
+		})
+	}
+	return details
+}
+
+// Age annotates the details of the event with when their finding was first seen, from
+// firstSeen by fingerprint or else now, and with AgeNew when that is less than newWithin ago
+// and AgeLongStanding otherwise, counting both in the summary.
+func (e *Event) Age(firstSeen map[string]time.Time, newWithin time.Duration, now time.Time) {
+	e.Findings.New, e.Findings.LongStanding = 0, 0
+	for i := range e.Details {
+		seen, ok := firstSeen[e.Details[i].Fingerprint]
+		if !ok || seen.IsZero() || seen.After(now) {
+			seen = now
+		}
+		e.Details[i].FirstSeen = seen
+		if now.Sub(seen) < newWithin {
+			e.Details[i].Age = AgeNew
+			e.Findings.New++
+		} else {
+			e.Details[i].Age = AgeLongStanding
+			e.Findings.LongStanding++
+		}
+	}
+}
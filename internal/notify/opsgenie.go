@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultOpsgenieURL is the Opsgenie API endpoint for US accounts; EU accounts use
+// https://api.eu.opsgenie.com.
+const DefaultOpsgenieURL = "https://api.opsgenie.com"
+
+// Opsgenie raises alerts through the Opsgenie Alert API, using the dedup key as alias.
+type Opsgenie struct {
+	APIKey string
+	URL    string
+	Client *http.Client
+}
+
+// NewOpsgenie creates an Opsgenie alerter. An empty apiURL selects DefaultOpsgenieURL.
+func NewOpsgenie(apiKey, apiURL string) *Opsgenie {
+	if apiURL == "" {
+		apiURL = DefaultOpsgenieURL
+	}
+	return &Opsgenie{
+		APIKey: apiKey,
+		URL:    strings.TrimSuffix(apiURL, "/"),
+		Client: &http.Client{Timeout: defaultWebhookTimeout},
+	}
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// Trigger creates the alert; Opsgenie deduplicates open alerts with the same alias.
+func (o *Opsgenie) Trigger(ctx context.Context, alert Alert) error {
+	details := map[string]string{
+		"repository": alert.Repository,
+		"file":       alert.File,
+		"rule":       alert.RuleID,
+		"severity":   alert.Severity,
+	}
+	if alert.Link != "" {
+		details["link"] = alert.Link
+	}
+	return o.send(ctx, "/v2/alerts", opsgenieAlert{
+		Message:  alert.Summary,
+		Alias:    alert.DedupKey,
+		Source:   "GitGuard",
+		Priority: "P1",
+		Tags:     []string{"gitguard", alert.RuleID},
+		Details:  details,
+	})
+}
+
+// Resolve closes the alert with the dedupKey alias.
+func (o *Opsgenie) Resolve(ctx context.Context, dedupKey string) error {
+	path := "/v2/alerts/" + url.PathEscape(dedupKey) + "/close?identifierType=alias"
+	return o.send(ctx, path, map[string]string{"source": "GitGuard", "note": "Finding no longer present"})
+}
+
+func (o *Opsgenie) send(ctx context.Context, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode Opsgenie request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Opsgenie request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.APIKey)
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Opsgenie request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("opsgenie request failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty raises incidents through the PagerDuty Events API v2.
+type PagerDuty struct {
+	RoutingKey string
+	URL        string
+	Client     *http.Client
+}
+
+// NewPagerDuty creates a PagerDuty alerter for the integration's routing key.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		RoutingKey: routingKey,
+		URL:        DefaultPagerDutyURL,
+		Client:     &http.Client{Timeout: defaultWebhookTimeout},
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Trigger opens (or updates) the incident for the alert's dedup key.
+func (p *PagerDuty) Trigger(ctx context.Context, alert Alert) error {
+	event := pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.DedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:   alert.Summary,
+			Source:    alert.Repository,
+			Severity:  "critical",
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Component: alert.File,
+			Class:     alert.RuleID,
+			CustomDetails: map[string]string{
+				"severity": alert.Severity,
+				"file":     alert.File,
+				"rule":     alert.RuleID,
+			},
+		},
+	}
+	if alert.Link != "" {
+		event.Links = []pagerDutyLink{{Href: alert.Link, Text: "GitGuard finding"}}
+	}
+	return p.send(ctx, event)
+}
+
+// Resolve resolves the incident for dedupKey.
+func (p *PagerDuty) Resolve(ctx context.Context, dedupKey string) error {
+	return p.send(ctx, pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "resolve",
+		DedupKey:    dedupKey,
+	})
+}
+
+func (p *PagerDuty) send(ctx context.Context, event pagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode PagerDuty event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create PagerDuty request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send PagerDuty %s event: %w", event.EventAction, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PagerDuty %s event failed with status %d", event.EventAction, resp.StatusCode)
+	}
+	return nil
+}
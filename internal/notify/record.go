@@ -0,0 +1,104 @@
+package notify
+
+import "time"
+
+// Record kinds.
+const (
+	KindAudit   = "audit"
+	KindFinding = "finding"
+)
+
+// Per-finding record types.
+const (
+	EventFindingDetected     = "finding.detected"
+	EventFindingStateChanged = "finding.state_changed"
+)
+
+// Record is a flat, self-contained form of an event for streaming exports: either an audit
+// record of something GitGuard did, or one finding of a scan.
+type Record struct {
+	Kind       string    `json:"kind"`
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Repository string    `json:"repository"`
+	Ref        string    `json:"ref,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	Scan       string    `json:"scan,omitempty"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	Summary    *Summary  `json:"summary,omitempty"`
+	Finding    *Finding  `json:"finding,omitempty"`
+	State      string    `json:"state,omitempty"`
+	PrevState  string    `json:"previous_state,omitempty"`
+	Link       string    `json:"link,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// Records expands an event into its audit record followed by one record per finding and one
+// per lifecycle transition of a previously tracked finding.
+func Records(event Event) []Record {
+	ts := event.Timestamp
+	if ts.IsZero() {
+		ts = time.Now().UTC()
+	}
+	link := event.Links.CheckRun
+	if link == "" {
+		link = event.Links.Issue
+	}
+
+	summary := event.Findings
+	records := make([]Record, 0, 1+len(event.Details))
+	records = append(records, Record{
+		Kind:       KindAudit,
+		Type:       event.Type,
+		Time:       ts,
+		Repository: event.Repository,
+		Ref:        event.Ref,
+		Commit:     event.Commit,
+		Scan:       event.Scan,
+		Conclusion: event.Conclusion,
+		Summary:    &summary,
+		Link:       link,
+		RequestID:  event.RequestID,
+	})
+
+	for i := range event.Details {
+		records = append(records, Record{
+			Kind:       KindFinding,
+			Type:       EventFindingDetected,
+			Time:       ts,
+			Repository: event.Repository,
+			Ref:        event.Ref,
+			Commit:     event.Commit,
+			Scan:       event.Scan,
+			Finding:    &event.Details[i],
+			Link:       link,
+			RequestID:  event.RequestID,
+		})
+	}
+
+	for _, transition := range event.Transitions {
+		if transition.From == "" {
+			// New findings are already reported as detected.
+			continue
+		}
+		records = append(records, Record{
+			Kind:       KindFinding,
+			Type:       EventFindingStateChanged,
+			Time:       ts,
+			Repository: event.Repository,
+			Ref:        event.Ref,
+			Commit:     event.Commit,
+			Scan:       event.Scan,
+			Finding: &Finding{
+				File:        transition.File,
+				RuleID:      transition.RuleID,
+				Fingerprint: transition.Fingerprint,
+			},
+			State:     transition.To,
+			PrevState: transition.From,
+			Link:      link,
+			RequestID: event.RequestID,
+		})
+	}
+	return records
+}
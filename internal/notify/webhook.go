@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook headers set on every delivery.
+const (
+	HeaderEvent     = "X-GitGuard-Event"
+	HeaderSignature = "X-GitGuard-Signature-256"
+
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// Webhook POSTs events as JSON to an HTTP endpoint. When a secret is configured the body is
+// signed with HMAC-SHA256 and the signature sent as "sha256=<hex>", like GitHub webhooks.
+type Webhook struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// NewWebhook creates a webhook notifier for url, signing deliveries with secret if non-empty.
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{
+		URL:    url,
+		Secret: secret,
+		Client: &http.Client{Timeout: defaultWebhookTimeout},
+	}
+}
+
+// Notify delivers the event to the endpoint.
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	return w.post(ctx, event.Type, body)
+}
+
+func (w *Webhook) post(ctx context.Context, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, eventType)
+	if w.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(w.Secret, body))
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with status %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
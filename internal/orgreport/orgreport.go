@@ -0,0 +1,290 @@
+// Package orgreport publishes a markdown report of each organization's tracked findings to a
+// private repository on a schedule, committing one file per organization per week so the
+// repository's history is an audit trail of the organization's exposure. With an SLA, the
+// report flags the open findings past it.
+package orgreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/export"
+	"github.com/omercnet/gitguard/internal/metrics"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Defaults of Publisher.
+const (
+	// DefaultInterval publishes the report of the week once a week.
+	DefaultInterval = 7 * 24 * time.Hour
+	// MaxFindings bounds the open findings a report lists; every finding is counted.
+	MaxFindings = 200
+)
+
+// ErrPublicRepository is returned when the report repository is not private: reports list
+// where secrets were committed across the organization.
+var ErrPublicRepository = errors.New("the report repository must be private")
+
+// Publisher publishes the weekly report of each organization to a repository through the
+// contents API, as the App's installation on the repository. A report published again in
+// the same week updates the week's file.
+type Publisher struct {
+	Clients    githubapp.ClientCreator
+	Findings   store.FindingStore
+	Classifier *severity.Classifier
+	// SLA, when set, flags the open findings past the window of their severity.
+	SLA severity.SLA
+	// Repository is the full name, owner/name, of the private repository reports are
+	// committed to.
+	Repository    string
+	Organizations []string
+	// Interval is the time between two publications; DefaultInterval when zero.
+	Interval time.Duration
+	// Now returns the current time; time.Now when nil.
+	Now func() time.Time
+}
+
+// Start publishes the reports now and then every Interval in the background, logging
+// failures, until ctx is done.
+func (p *Publisher) Start(ctx context.Context, logger zerolog.Logger) {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			p.PublishAll(ctx, logger)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PublishAll publishes the report of every organization, logging each outcome. An
+// organization failing does not stop the others.
+func (p *Publisher) PublishAll(ctx context.Context, logger zerolog.Logger) {
+	for _, org := range p.Organizations {
+		orgLogger := logger.With().Str("organization", org).Str("repository", p.Repository).Logger()
+		path, err := p.Publish(ctx, org)
+		if err != nil {
+			orgLogger.Error().Err(err).Msg("Failed to publish organization report")
+			continue
+		}
+		orgLogger.Info().Str("path", path).Msg("Organization report published")
+	}
+}
+
+// Publish commits the current report of org to the report repository and returns its path.
+func (p *Publisher) Publish(ctx context.Context, org string) (string, error) {
+	now := p.now()
+	findings, err := export.Load(ctx, p.Findings, export.Filter{Org: org})
+	if err != nil {
+		return "", fmt.Errorf("failed to load tracked findings: %w", err)
+	}
+
+	owner, repo, ok := strings.Cut(p.Repository, "/")
+	if !ok {
+		return "", fmt.Errorf("report repository %q must be a full name, owner/name", p.Repository)
+	}
+	client, err := p.client(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the report repository: %w", err)
+	}
+	if !repository.GetPrivate() {
+		return "", ErrPublicRepository
+	}
+
+	path := Path(org, now)
+	content := Markdown(org, findings, p.Classifier, p.SLA, now)
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.Ptr(fmt.Sprintf("Update %s secret scanning report for %s", org, Week(now))),
+		Content: []byte(content),
+	}
+	existing, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, path, nil)
+	switch {
+	case err == nil:
+		opts.SHA = existing.SHA
+		_, _, err = client.Repositories.UpdateFile(ctx, owner, repo, path, opts)
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		opts.Message = github.Ptr(fmt.Sprintf("Add %s secret scanning report for %s", org, Week(now)))
+		_, _, err = client.Repositories.CreateFile(ctx, owner, repo, path, opts)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to commit %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// client returns a client of the App's installation on the report repository.
+func (p *Publisher) client(ctx context.Context, owner, repo string) (*github.Client, error) {
+	appClient, err := p.Clients.NewAppClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	installation, _, err := appClient.Apps.FindRepositoryInstallation(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the installation on the report repository: %w", err)
+	}
+	client, err := p.Clients.NewInstallationClient(installation.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	return client, nil
+}
+
+func (p *Publisher) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// Week returns the ISO 8601 week of t, such as 2026-W42.
+func Week(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// Path returns the path of the report of org for the week of t: one directory per
+// organization and one file per week.
+func Path(org string, t time.Time) string {
+	return fmt.Sprintf("%s/%s.md", strings.ToLower(org), Week(t))
+}
+
+// Markdown renders the report of org's tracked findings as of now: state counts, the mean
+// time to resolve, the repositories and rules with the most open findings, the open findings
+// past their SLA, when sla is set, and at most MaxFindings open findings. Secrets only appear
+// as stored, masked or hashed according to the redaction policy; raw secrets are never
+// stored.
+func Markdown(
+	org string, findings []store.Finding, classifier *severity.Classifier, sla severity.SLA, now time.Time,
+) string {
+	summary := metrics.Compute(findings, metrics.Options{Now: now})
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Secret scanning report: %s\n\n", org)
+	fmt.Fprintf(&b, "Week %s, generated %s.\n\n", Week(now), now.UTC().Format("2006-01-02 15:04 MST"))
+
+	b.WriteString("| Open | Resolved | Suppressed | Mean time to resolve |\n|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %.1f h |\n", summary.Open, summary.Resolved, summary.Suppressed,
+		summary.MeanTimeToResolve)
+	writeCounts(&b, "Repositories with the most open findings", "Repository", summary.TopRepositories)
+	writeCounts(&b, "Rules with the most open findings", "Rule", summary.TopRules)
+	if len(sla) > 0 {
+		writeBreaches(&b, Breached(findings, classifier, sla, now), classifier, sla, now)
+	}
+
+	b.WriteString("\n## Open findings\n\n")
+	if summary.Open == 0 {
+		b.WriteString("No open findings.\n")
+		return b.String()
+	}
+	b.WriteString("| Repository | File | Line | Rule | Severity | First seen | Secret |\n")
+	b.WriteString("|---|---|---:|---|---|---|---|\n")
+	listed := 0
+	for _, finding := range findings {
+		if finding.State != store.StateOpen {
+			continue
+		}
+		if listed == MaxFindings {
+			fmt.Fprintf(&b, "\n…and %d more open findings.\n", summary.Open-listed)
+			break
+		}
+		listed++
+		fmt.Fprintf(&b, "| %s | %s | %d | %s | %s | %s | %s |\n",
+			cell(finding.Repository), code(finding.File), finding.Line, cell(finding.RuleID), level(classifier, finding),
+			finding.FirstSeen.UTC().Format(time.DateOnly), code(finding.Secret))
+	}
+	return b.String()
+}
+
+// Breached returns the open findings past the SLA window of their severity at now, the most
+// overdue first.
+func Breached(
+	findings []store.Finding, classifier *severity.Classifier, sla severity.SLA, now time.Time,
+) []store.Finding {
+	var breached []store.Finding
+	for _, finding := range findings {
+		if finding.State == store.StateOpen && sla.Breached(level(classifier, finding), finding.FirstSeen, now) {
+			breached = append(breached, finding)
+		}
+	}
+	slices.SortStableFunc(breached, func(a, b store.Finding) int {
+		dueA, _ := sla.Due(level(classifier, a), a.FirstSeen)
+		dueB, _ := sla.Due(level(classifier, b), b.FirstSeen)
+		return dueA.Compare(dueB)
+	})
+	return breached
+}
+
+// writeBreaches writes the open findings past their SLA, at most MaxFindings of them.
+func writeBreaches(
+	b *strings.Builder, breached []store.Finding, classifier *severity.Classifier, sla severity.SLA, now time.Time,
+) {
+	b.WriteString("\n## Past their SLA\n\n")
+	if len(breached) == 0 {
+		b.WriteString("No open finding is past its SLA.\n")
+		return
+	}
+	fmt.Fprintf(b, "**%d open findings are past their SLA.**\n\n", len(breached))
+	b.WriteString("| Repository | File | Line | Rule | Severity | Due | Overdue |\n")
+	b.WriteString("|---|---|---:|---|---|---|---:|\n")
+	for i, finding := range breached {
+		if i == MaxFindings {
+			fmt.Fprintf(b, "\n…and %d more findings past their SLA.\n", len(breached)-i)
+			break
+		}
+		rating := level(classifier, finding)
+		due, _ := sla.Due(rating, finding.FirstSeen)
+		fmt.Fprintf(b, "| %s | %s | %d | %s | %s | %s | %.1f d |\n",
+			cell(finding.Repository), code(finding.File), finding.Line, cell(finding.RuleID), rating,
+			due.UTC().Format(time.DateOnly), now.Sub(due).Hours()/24)
+	}
+}
+
+// level returns the severity of a tracked finding.
+func level(classifier *severity.Classifier, finding store.Finding) severity.Level {
+	return classifier.Classify(report.Finding{RuleID: finding.RuleID, File: finding.File})
+}
+
+// writeCounts writes a ranking table, or nothing when it is empty.
+func writeCounts(b *strings.Builder, title, name string, counts []metrics.Count) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n| %s | Open |\n|---|---:|\n", title, name)
+	for _, count := range counts {
+		fmt.Fprintf(b, "| %s | %d |\n", cell(count.Name), count.Open)
+	}
+}
+
+// cell escapes a value for a markdown table cell.
+func cell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", " ").Replace(value)
+}
+
+// code formats a value as inline code in a table cell, or leaves the cell empty.
+func code(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(cell(value), "`", "'") + "`"
+}
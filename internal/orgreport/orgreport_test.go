@@ -0,0 +1,162 @@
+package orgreport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClientCreator returns clients of a fake GitHub API.
+type testClientCreator struct {
+	githubapp.ClientCreator
+	baseURL string
+}
+
+func (c testClientCreator) NewInstallationClient(int64) (*github.Client, error) {
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(c.baseURL + "/")
+	return client, nil
+}
+
+func (c testClientCreator) NewAppClient() (*github.Client, error) {
+	return c.NewInstallationClient(0)
+}
+
+var now = time.Date(2026, time.October, 16, 9, 30, 0, 0, time.UTC)
+
+func TestMarkdown(t *testing.T) {
+	classifier := severity.NewClassifier(map[string]severity.Level{"aws-access-token": severity.Critical})
+	findings := []store.Finding{
+		{Repository: "acme/api", File: "config.yml", Line: 3, RuleID: "aws-access-token", State: store.StateOpen,
+			FirstSeen: now.AddDate(0, 0, -2), Secret: "AKIA****************"},
+		{Repository: "acme/api", File: "a|b.env", Line: 1, RuleID: "generic-api-key", State: store.StateOpen,
+			FirstSeen: now},
+		{Repository: "acme/web", File: "main.go", RuleID: "github-pat", State: store.StateResolved,
+			FirstSeen: now.Add(-10 * time.Hour), ResolvedAt: now},
+	}
+
+	markdown := Markdown("acme", findings, classifier, nil, now)
+	assert.Contains(t, markdown, "# Secret scanning report: acme")
+	assert.Contains(t, markdown, "Week 2026-W42")
+	assert.Contains(t, markdown, "| 2 | 1 | 0 | 10.0 h |")
+	assert.Contains(t, markdown, "| acme/api | 2 |")
+	assert.Contains(t, markdown,
+		"| acme/api | `config.yml` | 3 | aws-access-token | critical | 2026-10-14 | `AKIA****************` |")
+	assert.Contains(t, markdown, "`a\\|b.env`")
+	assert.NotContains(t, markdown, "github-pat |", "Resolved findings are counted, not listed")
+	assert.NotContains(t, markdown, "SLA", "Reports without an SLA flag no finding")
+
+	assert.Contains(t, Markdown("acme", nil, classifier, nil, now), "No open findings.")
+}
+
+func TestMarkdown_SLA(t *testing.T) {
+	classifier := severity.NewClassifier(map[string]severity.Level{
+		"aws-access-token": severity.Critical, "generic-api-key": severity.Low,
+	})
+	sla := severity.SLA{severity.Critical: 24 * time.Hour, severity.Low: 30 * 24 * time.Hour}
+	findings := []store.Finding{
+		{Repository: "acme/api", File: "old.yml", Line: 1, RuleID: "aws-access-token", State: store.StateOpen,
+			FirstSeen: now.AddDate(0, 0, -5)},
+		{Repository: "acme/api", File: "new.yml", Line: 2, RuleID: "aws-access-token", State: store.StateOpen,
+			FirstSeen: now.Add(-12 * time.Hour)},
+		{Repository: "acme/web", File: "app.env", Line: 3, RuleID: "aws-access-token", State: store.StateOpen,
+			FirstSeen: now.AddDate(0, 0, -2)},
+		{Repository: "acme/web", File: "key.txt", Line: 4, RuleID: "generic-api-key", State: store.StateOpen,
+			FirstSeen: now.AddDate(0, 0, -10)},
+		{Repository: "acme/web", File: "gone.env", RuleID: "aws-access-token", State: store.StateResolved,
+			FirstSeen: now.AddDate(0, 0, -9), ResolvedAt: now},
+		{Repository: "acme/web", File: "pat.txt", RuleID: "github-pat", State: store.StateOpen,
+			FirstSeen: now.AddDate(-1, 0, 0)},
+	}
+
+	breached := Breached(findings, classifier, sla, now)
+	require.Len(t, breached, 2, "Only open findings with an SLA past their window breach it")
+	assert.Equal(t, "old.yml", breached[0].File, "The most overdue finding comes first")
+	assert.Equal(t, "app.env", breached[1].File)
+
+	markdown := Markdown("acme", findings, classifier, sla, now)
+	assert.Contains(t, markdown, "**2 open findings are past their SLA.**")
+	assert.Contains(t, markdown, "| acme/api | `old.yml` | 1 | aws-access-token | critical | 2026-10-12 | 4.0 d |")
+	assert.Contains(t, Markdown("acme", findings[1:2], classifier, sla, now), "No open finding is past its SLA.")
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "acme/2026-W42.md", Path("Acme", now))
+	assert.Equal(t, "acme/2026-W53.md", Path("acme", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	private := true
+	var committed github.RepositoryContentFileOptions
+	var method string
+	existing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/repos/acme/security-reports/installation":
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		case r.URL.Path == "/repos/acme/security-reports":
+			_ = json.NewEncoder(w).Encode(github.Repository{Private: github.Ptr(private)})
+		case r.URL.Path == "/repos/acme/security-reports/contents/acme/2026-W42.md" && r.Method == http.MethodGet:
+			if !existing {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"type": "file", "sha": "abc123"}`))
+		case r.URL.Path == "/repos/acme/security-reports/contents/acme/2026-W42.md" && r.Method == http.MethodPut:
+			method = r.Method
+			committed = github.RepositoryContentFileOptions{}
+			_ = json.NewDecoder(r.Body).Decode(&committed)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	findings := store.NewMemory()
+	_, err := findings.RecordScan(context.Background(), store.Scan{
+		Repository: "acme/api", Complete: true, Time: now,
+		Findings: []store.Finding{{Fingerprint: "f1", File: "config.yml", RuleID: "aws-access-token", Line: 3}},
+	})
+	require.NoError(t, err)
+	publisher := &Publisher{
+		Clients:    testClientCreator{baseURL: server.URL},
+		Findings:   findings,
+		Classifier: severity.NewClassifier(nil),
+		Repository: "acme/security-reports",
+		Now:        func() time.Time { return now },
+	}
+
+	path, err := publisher.Publish(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme/2026-W42.md", path)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Contains(t, string(committed.Content), "`config.yml` | 3 | aws-access-token")
+	assert.Contains(t, committed.GetMessage(), "Add acme")
+	assert.Nil(t, committed.SHA)
+
+	// The week's report is updated in place.
+	existing = true
+	_, err = publisher.Publish(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", committed.GetSHA())
+	assert.Contains(t, committed.GetMessage(), "Update acme")
+
+	// Reports are never committed to public repositories.
+	private = false
+	_, err = publisher.Publish(context.Background(), "acme")
+	assert.ErrorIs(t, err, ErrPublicRepository)
+}
@@ -0,0 +1,188 @@
+// Package output renders scan findings for the command line as JSON, SARIF or a table, and
+// tracked findings for export as CSV, JSON or SARIF. Raw secrets are never written.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Output formats.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatTable, FormatJSON, FormatSARIF}
+
+// Write renders the findings in the given format. version is reported as the SARIF tool
+// version.
+func Write(w io.Writer, format, version string, findings []report.Finding, classifier *severity.Classifier) error {
+	switch format {
+	case FormatTable:
+		return writeTable(w, findings, classifier)
+	case FormatJSON:
+		details := notify.Details("", findings, classifier, nil)
+		if details == nil {
+			details = []notify.Finding{}
+		}
+		return writeJSON(w, details)
+	case FormatSARIF:
+		return writeJSON(w, sarifLog(version, findings, classifier))
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of %v", format, Formats)
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeTable(w io.Writer, findings []report.Finding, classifier *severity.Classifier) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No secrets found")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tLINE\tRULE\tSEVERITY")
+	for _, finding := range findings {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", finding.File, finding.StartLine, finding.RuleID, classifier.Classify(finding))
+	}
+	return tw.Flush()
+}
+
+// SARIF 2.1.0, as consumed by GitHub code scanning and most CI systems.
+type (
+	sarif struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Version        string      `json:"version,omitempty"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID              string            `json:"ruleId"`
+		Level               string            `json:"level"`
+		Message             sarifMessage      `json:"message"`
+		Locations           []sarifLocation   `json:"locations"`
+		PartialFingerprints map[string]string `json:"partialFingerprints"`
+		Properties          map[string]string `json:"properties,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           sarifRegion   `json:"region"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+)
+
+func sarifLog(version string, findings []report.Finding, classifier *severity.Classifier) sarif {
+	run := newSARIFRun(version)
+	rules := make(map[string]string)
+	for _, finding := range findings {
+		rules[finding.RuleID] = finding.Description
+		level := classifier.Classify(finding)
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  finding.RuleID,
+			Level:   sarifLevel(level),
+			Message: sarifMessage{Text: fmt.Sprintf("Potential %s secret (%s severity)", finding.RuleID, level)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: finding.File},
+				Region: sarifRegion{
+					StartLine:   max(finding.StartLine, 1),
+					StartColumn: finding.StartColumn,
+					EndLine:     finding.EndLine,
+					EndColumn:   finding.EndColumn,
+				},
+			}}},
+			PartialFingerprints: map[string]string{"gitguard/v1": notify.Fingerprint("", finding)},
+		})
+	}
+
+	return newSARIFLog(run, rules)
+}
+
+func newSARIFRun(version string) sarifRun {
+	return sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "GitGuard",
+			InformationURI: "https://github.com/omercnet/gitguard",
+			Version:        version,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+}
+
+// newSARIFLog completes run with rules, their descriptions by rule ID, sorted by ID.
+func newSARIFLog(run sarifRun, rules map[string]string) sarif {
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		description := rules[id]
+		if description == "" {
+			description = id
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: description}})
+	}
+
+	return sarif{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(level severity.Level) string {
+	switch {
+	case level >= severity.High:
+		return "error"
+	case level == severity.Medium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,128 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// FormatCSV renders tracked findings as CSV, for spreadsheets.
+const FormatCSV = "csv"
+
+// TrackedFormats lists the formats tracked findings are exported in.
+var TrackedFormats = []string{FormatCSV, FormatJSON, FormatSARIF}
+
+// TrackedFinding is a tracked finding with its severity.
+type TrackedFinding struct {
+	store.Finding
+	Severity string `json:"severity"`
+}
+
+// csvHeader names the columns of the CSV export.
+var csvHeader = []string{
+	"repository", "file", "line", "rule_id", "severity", "state", "fingerprint", "first_seen", "last_seen", "resolved_at",
+	"secret",
+}
+
+// WriteTracked renders tracked findings, which hold secrets only masked or hashed, in the given
+// format. version is reported as the SARIF tool version.
+func WriteTracked(
+	w io.Writer, format, version string, findings []store.Finding, classifier *severity.Classifier,
+) error {
+	switch format {
+	case FormatCSV:
+		return writeTrackedCSV(w, findings, classifier)
+	case FormatJSON:
+		tracked := make([]TrackedFinding, 0, len(findings))
+		for _, finding := range findings {
+			tracked = append(tracked, TrackedFinding{Finding: finding, Severity: classify(classifier, finding).String()})
+		}
+		return writeJSON(w, tracked)
+	case FormatSARIF:
+		return writeJSON(w, trackedSARIFLog(version, findings, classifier))
+	default:
+		return fmt.Errorf("unknown export format %q, expected one of %v", format, TrackedFormats)
+	}
+}
+
+// classify rates a tracked finding by its rule and file, as its scan did.
+func classify(classifier *severity.Classifier, finding store.Finding) severity.Level {
+	return classifier.Classify(report.Finding{RuleID: finding.RuleID, File: finding.File})
+}
+
+func writeTrackedCSV(w io.Writer, findings []store.Finding, classifier *severity.Classifier) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		record := []string{
+			finding.Repository, finding.File, strconv.Itoa(finding.Line), finding.RuleID,
+			classify(classifier, finding).String(), string(finding.State), finding.Fingerprint,
+			csvTime(finding.FirstSeen), csvTime(finding.LastSeen), csvTime(finding.ResolvedAt), finding.Secret,
+		}
+		for i, value := range record {
+			record[i] = csvCell(value)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// csvCell quotes values spreadsheets would evaluate as formulas, such as file names starting
+// with "=", which come from scanned repositories.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// trackedSARIFLog reports tracked findings as SARIF results, with their repository, state and
+// first and last detection as result properties.
+func trackedSARIFLog(version string, findings []store.Finding, classifier *severity.Classifier) sarif {
+	run := newSARIFRun(version)
+	rules := make(map[string]string)
+	for _, finding := range findings {
+		rules[finding.RuleID] = ""
+		level := classify(classifier, finding)
+		properties := map[string]string{
+			"repository": finding.Repository,
+			"state":      string(finding.State),
+			"firstSeen":  csvTime(finding.FirstSeen),
+			"lastSeen":   csvTime(finding.LastSeen),
+		}
+		if !finding.ResolvedAt.IsZero() {
+			properties["resolvedAt"] = csvTime(finding.ResolvedAt)
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  finding.RuleID,
+			Level:   sarifLevel(level),
+			Message: sarifMessage{Text: fmt.Sprintf("Potential %s secret (%s severity)", finding.RuleID, level)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: finding.File},
+				Region:           sarifRegion{StartLine: max(finding.Line, 1)},
+			}}},
+			PartialFingerprints: map[string]string{"gitguard/v1": finding.Fingerprint},
+			Properties:          properties,
+		})
+	}
+	return newSARIFLog(run, rules)
+}
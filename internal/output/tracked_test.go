@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/omercnet/gitguard/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var trackedFindings = []store.Finding{
+	{
+		Repository: "acme/api", Fingerprint: "gitguard-a", File: "=HYPERLINK(\"x\").env", Line: 4,
+		RuleID: "generic-api-key", State: store.StateResolved,
+		FirstSeen:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		LastSeen:   time.Date(2026, 2, 2, 3, 4, 5, 0, time.UTC),
+		ResolvedAt: time.Date(2026, 3, 2, 3, 4, 5, 0, time.UTC),
+	},
+	{
+		Repository: "acme/web", Fingerprint: "gitguard-b", File: "main.tf", RuleID: "aws-access-token",
+		State: store.StateOpen,
+	},
+}
+
+func TestWriteTrackedCSV(t *testing.T) {
+	classifier := severity.NewClassifier(map[string]severity.Level{"generic-api-key": severity.Low})
+	var buf bytes.Buffer
+	require.NoError(t, WriteTracked(&buf, FormatCSV, "1.0.0", trackedFindings, classifier))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{
+		"acme/api", "'=HYPERLINK(\"x\").env", "4", "generic-api-key", "low", "resolved", "gitguard-a",
+		"2026-01-02T03:04:05Z", "2026-02-02T03:04:05Z", "2026-03-02T03:04:05Z", "",
+	}, records[1], "Formulas are quoted")
+	assert.Empty(t, records[2][9], "Open findings have no resolution time")
+}
+
+func TestWriteTrackedJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteTracked(&buf, FormatJSON, "1.0.0", trackedFindings, nil))
+
+	var tracked []TrackedFinding
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &tracked))
+	require.Len(t, tracked, 2)
+	assert.Equal(t, "acme/web", tracked[1].Repository)
+	assert.Equal(t, "high", tracked[1].Severity)
+}
+
+func TestWriteTrackedSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteTracked(&buf, FormatSARIF, "1.0.0", trackedFindings, nil))
+
+	var log sarif
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs, 1)
+	results := log.Runs[0].Results
+	require.Len(t, results, 2)
+	assert.Equal(t, "acme/api", results[0].Properties["repository"])
+	assert.Equal(t, "2026-03-02T03:04:05Z", results[0].Properties["resolvedAt"])
+	assert.NotContains(t, results[1].Properties, "resolvedAt")
+	assert.Equal(t, 1, results[1].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, 2)
+}
+
+func TestWriteTrackedUnknownFormat(t *testing.T) {
+	assert.Error(t, WriteTracked(&bytes.Buffer{}, "xml", "1.0.0", trackedFindings, nil))
+}
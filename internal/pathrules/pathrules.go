@@ -0,0 +1,123 @@
+// Package pathrules adjusts findings by file path, so noisy directories can be tuned without
+// ignoring them entirely.
+package pathrules
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Override adjusts findings in files matching any of its path globs.
+type Override struct {
+	// Paths are globs matched against repository-relative file paths. "**" matches any
+	// number of directories; a glob without a slash matches file names in any directory.
+	Paths []string
+	// Rules limits the override to these rule IDs; empty applies it to every rule.
+	Rules []string
+	// Disable drops matching findings.
+	Disable bool
+	// Severity, unless None, replaces the severity of matching findings.
+	Severity severity.Level
+}
+
+// Set is an ordered list of overrides. When several overrides match a finding, the first
+// one setting a severity wins. A nil Set leaves findings unchanged.
+type Set struct {
+	overrides []Override
+}
+
+// New validates the overrides' globs and returns a Set.
+func New(overrides []Override) (*Set, error) {
+	for _, override := range overrides {
+		if len(override.Paths) == 0 {
+			return nil, fmt.Errorf("path override for rules %v has no paths", override.Rules)
+		}
+		for _, pattern := range override.Paths {
+			for _, segment := range strings.Split(pattern, "/") {
+				if _, err := path.Match(segment, ""); err != nil {
+					return nil, fmt.Errorf("invalid path glob %q: %w", pattern, err)
+				}
+			}
+		}
+	}
+	return &Set{overrides: overrides}, nil
+}
+
+// Filter returns the findings that are not disabled by an override.
+func (s *Set) Filter(findings []report.Finding) []report.Finding {
+	if s == nil {
+		return findings
+	}
+	return slices.DeleteFunc(findings, func(finding report.Finding) bool {
+		for _, override := range s.overrides {
+			if override.Disable && override.matches(finding) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Severity returns the severity an override assigns to the finding, if any.
+func (s *Set) Severity(finding report.Finding) (severity.Level, bool) {
+	if s == nil {
+		return severity.None, false
+	}
+	for _, override := range s.overrides {
+		if override.Severity != severity.None && override.matches(finding) {
+			return override.Severity, true
+		}
+	}
+	return severity.None, false
+}
+
+func (o Override) matches(finding report.Finding) bool {
+	if len(o.Rules) > 0 && !slices.Contains(o.Rules, finding.RuleID) {
+		return false
+	}
+	for _, pattern := range o.Paths {
+		if Match(pattern, finding.File) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a slash-separated file path matches the glob.
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(strings.TrimPrefix(name, "/"), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Trailing "**" matches everything below the directory.
+			if len(pattern) == 1 {
+				return len(name) > 0
+			}
+			for i := range name {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
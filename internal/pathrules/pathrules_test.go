@@ -0,0 +1,72 @@
+package pathrules
+
+import (
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/severity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"testdata/**", "testdata/keys.txt", true},
+		{"testdata/**", "testdata/nested/keys.txt", true},
+		{"testdata/**", "src/testdata/keys.txt", false},
+		{"**/testdata/**", "src/testdata/keys.txt", true},
+		{"infra/*.tf", "infra/main.tf", true},
+		{"infra/*.tf", "infra/modules/main.tf", false},
+		{"*.pem", "certs/server.pem", true},
+		{"*.pem", "server.pem", true},
+		{"/docs/**/*.md", "docs/a/b/readme.md", true},
+		{"docs/**/*.md", "docs/readme.md", true},
+		{"docs/**/*.md", "docs/readme.txt", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, Match(tt.pattern, tt.name), "%s against %s", tt.pattern, tt.name)
+	}
+}
+
+func TestSet(t *testing.T) {
+	set, err := New([]Override{
+		{Paths: []string{"testdata/**"}, Rules: []string{"generic-api-key"}, Disable: true},
+		{Paths: []string{"infra/**"}, Severity: severity.Critical},
+		{Paths: []string{"**"}, Rules: []string{"generic-api-key"}, Severity: severity.Medium},
+	})
+	require.NoError(t, err)
+
+	findings := set.Filter([]report.Finding{
+		{File: "testdata/fixture.env", RuleID: "generic-api-key"},
+		{File: "testdata/fixture.env", RuleID: "aws-access-token"},
+		{File: "infra/main.tf", RuleID: "generic-api-key"},
+	})
+	require.Len(t, findings, 2)
+	assert.Equal(t, "aws-access-token", findings[0].RuleID)
+
+	level, ok := set.Severity(findings[1])
+	assert.True(t, ok)
+	assert.Equal(t, severity.Critical, level, "First matching override should win")
+
+	level, ok = set.Severity(report.Finding{File: "src/app.go", RuleID: "generic-api-key"})
+	assert.True(t, ok)
+	assert.Equal(t, severity.Medium, level)
+
+	_, ok = set.Severity(report.Finding{File: "src/app.go", RuleID: "aws-access-token"})
+	assert.False(t, ok)
+
+	var none *Set
+	assert.Len(t, none.Filter(findings), 2, "A nil set should keep every finding")
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New([]Override{{Paths: []string{"testdata/[abc"}}})
+	assert.Error(t, err)
+
+	_, err = New([]Override{{Rules: []string{"generic-api-key"}, Disable: true}})
+	assert.Error(t, err)
+}
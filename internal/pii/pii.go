@@ -0,0 +1,154 @@
+// Package pii detects personal and internal data, such as email dumps, private IP addresses,
+// database connection strings with credentials and national ID numbers. These findings are
+// reported apart from secrets, so secret detection stays signal-rich.
+package pii
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Rule IDs of PII findings. They share RulePrefix so they are reported as one category.
+const (
+	RulePrefix           = "pii-"
+	RuleEmailDump        = RulePrefix + "email-dump"
+	RulePrivateIP        = RulePrefix + "private-ip"
+	RuleConnectionString = RulePrefix + "connection-string"
+	RuleNationalID       = RulePrefix + "national-id"
+)
+
+// Tag is set on every PII finding.
+const Tag = "pii"
+
+// EmailDumpThreshold is the number of distinct email addresses from which a file is reported
+// as an email dump. Fewer addresses, e.g. maintainers in a README, are not reported.
+const EmailDumpThreshold = 10
+
+var (
+	email = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.)+[A-Za-z]{2,}\b`)
+
+	privateIP = regexp.MustCompile(
+		`\b(10\.\d{1,3}\.\d{1,3}\.\d{1,3}|172\.(1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3})\b`)
+
+	// connectionString matches URLs of common databases and brokers with a user and password.
+	connectionString = regexp.MustCompile(
+		`\b(postgres(ql)?|mysql|mongodb(\+srv)?|rediss?|amqps?|mssql|sqlserver)://[^:@/\s]+:([^@/\s]+)@[^\s'"]+`)
+
+	// ssn matches US social security numbers, which are the national ID format with a fixed
+	// layout common enough in test fixtures and exports to be worth flagging.
+	ssn = regexp.MustCompile(`\b(\d{3})-(\d{2})-(\d{4})\b`)
+
+	// exampleDomains are reserved for documentation and never identify a person.
+	exampleDomains = []string{"example.com", "example.org", "example.net"}
+)
+
+// IsPIIFinding reports whether finding was reported by this package.
+func IsPIIFinding(finding report.Finding) bool {
+	return strings.HasPrefix(finding.RuleID, RulePrefix)
+}
+
+// Split separates PII findings from the other findings.
+func Split(findings []report.Finding) (secrets, personal []report.Finding) {
+	for _, finding := range findings {
+		if IsPIIFinding(finding) {
+			personal = append(personal, finding)
+		} else {
+			secrets = append(secrets, finding)
+		}
+	}
+	return secrets, personal
+}
+
+// Detect returns the PII findings in content. Line numbers start at 1.
+func Detect(content string) []report.Finding {
+	var findings []report.Finding
+	emails := make(map[string]bool)
+	var firstEmail report.Finding
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNumber := i + 1
+
+		for _, loc := range email.FindAllStringIndex(line, -1) {
+			address := strings.ToLower(line[loc[0]:loc[1]])
+			if isExampleAddress(address) || emails[address] {
+				continue
+			}
+			if len(emails) == 0 {
+				firstEmail = finding(RuleEmailDump, "File contains a list of email addresses", lineNumber, loc, line, "")
+			}
+			emails[address] = true
+		}
+
+		for _, loc := range privateIP.FindAllStringIndex(line, -1) {
+			ip := line[loc[0]:loc[1]]
+			if net.ParseIP(ip) == nil {
+				continue
+			}
+			findings = append(findings, finding(RulePrivateIP, "Private network address", lineNumber, loc, line, ip))
+		}
+
+		for _, loc := range connectionString.FindAllStringSubmatchIndex(line, -1) {
+			password := line[loc[8]:loc[9]]
+			if isPlaceholder(password) {
+				continue
+			}
+			findings = append(findings, finding(RuleConnectionString,
+				"Connection string with embedded credentials", lineNumber, loc[:2], line, password))
+		}
+
+		for _, loc := range ssn.FindAllStringSubmatchIndex(line, -1) {
+			if !validSSN(line[loc[2]:loc[3]], line[loc[4]:loc[5]], line[loc[6]:loc[7]]) {
+				continue
+			}
+			findings = append(findings, finding(RuleNationalID,
+				"National ID number", lineNumber, loc[:2], line, line[loc[0]:loc[1]]))
+		}
+	}
+
+	if len(emails) >= EmailDumpThreshold {
+		findings = append(findings, firstEmail)
+	}
+	return findings
+}
+
+func isExampleAddress(address string) bool {
+	for _, domain := range exampleDomains {
+		if strings.HasSuffix(address, "@"+domain) || strings.HasSuffix(address, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholder reports whether a connection string password is a template variable or
+// an obvious placeholder rather than a credential.
+func isPlaceholder(password string) bool {
+	lower := strings.ToLower(password)
+	return strings.ContainsAny(password[:1], "$%{<[") ||
+		strings.Contains(lower, "password") ||
+		strings.Trim(lower, "x*") == ""
+}
+
+// validSSN rejects numbers that are never issued: area 000, 666 or 9xx, group 00 and
+// serial 0000.
+func validSSN(area, group, serial string) bool {
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+func finding(ruleID, description string, line int, loc []int, text, secret string) report.Finding {
+	return report.Finding{
+		RuleID:      ruleID,
+		Description: description,
+		StartLine:   line,
+		EndLine:     line,
+		StartColumn: loc[0] + 1,
+		EndColumn:   loc[1],
+		Line:        text,
+		Match:       text[loc[0]:loc[1]],
+		Secret:      secret,
+		Tags:        []string{Tag},
+	}
+}
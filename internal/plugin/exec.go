@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// DefaultTimeout bounds each run of an Exec detector.
+const DefaultTimeout = 10 * time.Second
+
+// Request is written as JSON to the standard input of an Exec detector.
+type Request struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Finding is one element of the JSON array an Exec detector writes to standard output.
+// Lines are 1-based.
+type Finding struct {
+	RuleID      string   `json:"rule_id"`
+	Description string   `json:"description"`
+	Line        int      `json:"line"`
+	StartColumn int      `json:"start_column"`
+	EndColumn   int      `json:"end_column"`
+	Match       string   `json:"match"`
+	Secret      string   `json:"secret"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Exec runs a command for every scanned file. Failing commands, including commands that
+// time out or write anything but a JSON array of findings, are logged and find nothing, so
+// a broken scanner never blocks the other detectors.
+type Exec struct {
+	Command []string
+	Timeout time.Duration
+	Logger  zerolog.Logger
+}
+
+// ScanContent implements Detector.
+func (e *Exec) ScanContent(path, content string) []report.Finding {
+	findings, err := e.run(path, content)
+	if err != nil {
+		e.Logger.Warn().Err(err).Str("file", path).Msg(constants.LogMsgPluginFailed)
+		return nil
+	}
+	return findings
+}
+
+func (e *Exec) run(path, content string) ([]report.Finding, error) {
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	input, err := json.Marshal(Request{Path: path, Content: content})
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...) // #nosec G204 -- The command is configured by the operator
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", e.Command[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var found []Finding
+	if err := json.Unmarshal(stdout.Bytes(), &found); err != nil {
+		return nil, fmt.Errorf("%s: invalid output: %w", e.Command[0], err)
+	}
+	findings := make([]report.Finding, 0, len(found))
+	for _, f := range found {
+		findings = append(findings, report.Finding{
+			RuleID:      f.RuleID,
+			Description: f.Description,
+			StartLine:   f.Line,
+			EndLine:     f.Line,
+			StartColumn: f.StartColumn,
+			EndColumn:   f.EndColumn,
+			Match:       f.Match,
+			Secret:      f.Secret,
+			Tags:        f.Tags,
+		})
+	}
+	return findings, nil
+}
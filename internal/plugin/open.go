@@ -0,0 +1,36 @@
+package plugin
+
+import (
+	"fmt"
+	goplugin "plugin"
+)
+
+// Symbol is the name of the variable a Go plugin exports its detector as, e.g.
+//
+//	var Detector plugin.Detector = acmeScanner{}
+//
+// Go plugins must be built with the same Go version and module versions as GitGuard.
+const Symbol = "Detector"
+
+// Open loads the detector exported by the Go plugin at path.
+func Open(path string) (Detector, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup(Symbol)
+	if err != nil {
+		return nil, err
+	}
+	switch d := symbol.(type) {
+	case *Detector:
+		if *d == nil {
+			return nil, fmt.Errorf("%s is nil", Symbol)
+		}
+		return *d, nil
+	case Detector:
+		return d, nil
+	default:
+		return nil, fmt.Errorf("%s is a %T, not a plugin.Detector", Symbol, symbol)
+	}
+}
@@ -0,0 +1,108 @@
+// Package plugin hooks additional detectors, e.g. proprietary scanners, into GitGuard's
+// scans. Their findings are classified, filtered and reported like gitleaks findings.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Detector scans the content of a single file. path is the file's path in the repository;
+// findings need not set File, which callers fill in.
+type Detector interface {
+	ScanContent(path, content string) []report.Finding
+}
+
+// DetectorFunc adapts a function to the Detector interface.
+type DetectorFunc func(path, content string) []report.Finding
+
+// ScanContent calls f.
+func (f DetectorFunc) ScanContent(path, content string) []report.Finding {
+	return f(path, content)
+}
+
+// Set runs several detectors. A nil Set finds nothing.
+type Set []Detector
+
+// ScanContent returns the findings of every detector in the set.
+func (s Set) ScanContent(path, content string) []report.Finding {
+	var findings []report.Finding
+	for _, d := range s {
+		findings = append(findings, d.ScanContent(path, content)...)
+	}
+	return findings
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]Detector{}
+)
+
+// Register makes a detector compiled into the binary available to every scan. It is meant
+// to be called from the init function of a package imported by cmd/gitguard/plugins.go, and
+// panics when name is registered twice.
+func Register(name string, d Detector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("plugin: detector " + name + " registered twice")
+	}
+	registry[name] = d
+}
+
+// Spec configures a detector loaded at runtime, either a Go plugin or a command.
+type Spec struct {
+	Name string
+	// Path is a Go plugin (.so) exporting a Detector variable.
+	Path string
+	// Command runs once per file; see Exec.
+	Command []string
+	// Timeout bounds each run of Command; zero uses DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Validate checks that exactly one of Path and Command is set.
+func (s Spec) Validate() error {
+	switch {
+	case s.Name == "":
+		return errors.New("detector plugin without a name")
+	case (s.Path == "") == (len(s.Command) == 0):
+		return fmt.Errorf("detector plugin %s: set either path or command", s.Name)
+	}
+	return nil
+}
+
+// Load returns the registered detectors followed by the detectors of specs.
+func Load(specs []Spec, logger zerolog.Logger) (Set, error) {
+	registryMu.Lock()
+	set := make(Set, 0, len(registry)+len(specs))
+	for _, d := range registry {
+		set = append(set, d)
+	}
+	registryMu.Unlock()
+
+	for _, spec := range specs {
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+		if spec.Path != "" {
+			d, err := Open(spec.Path)
+			if err != nil {
+				return nil, fmt.Errorf("detector plugin %s: %w", spec.Name, err)
+			}
+			set = append(set, d)
+			continue
+		}
+		set = append(set, &Exec{
+			Command: spec.Command,
+			Timeout: spec.Timeout,
+			Logger:  logger.With().Str("plugin", spec.Name).Logger(),
+		})
+	}
+	return set, nil
+}
@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestSet(t *testing.T) {
+	found := func(rule string) Detector {
+		return DetectorFunc(func(path, _ string) []report.Finding {
+			return []report.Finding{{RuleID: rule, File: path}}
+		})
+	}
+	findings := Set{found("a"), found("b")}.ScanContent("main.go", "")
+	require.Len(t, findings, 2)
+	assert.Equal(t, "b", findings[1].RuleID)
+
+	var none Set
+	assert.Empty(t, none.ScanContent("main.go", ""))
+}
+
+func TestLoad(t *testing.T) {
+	Register("test-registered", DetectorFunc(func(string, string) []report.Finding { return nil }))
+	assert.Panics(t, func() { Register("test-registered", Set{}) })
+
+	set, err := Load([]Spec{{Name: "scanner", Command: []string{"true"}}}, zerolog.Nop())
+	require.NoError(t, err)
+	require.Len(t, set, 2, "Registered detectors should come first")
+	assert.IsType(t, &Exec{}, set[1])
+
+	_, err = Load([]Spec{{Name: "both", Path: "acme.so", Command: []string{"acme"}}}, zerolog.Nop())
+	assert.ErrorContains(t, err, "either path or command")
+	_, err = Load([]Spec{{Command: []string{"acme"}}}, zerolog.Nop())
+	assert.Error(t, err)
+	_, err = Load([]Spec{{Name: "missing", Path: "/nonexistent/acme.so"}}, zerolog.Nop())
+	assert.ErrorContains(t, err, "missing")
+}
+
+func TestExec(t *testing.T) {
+	// The script echoes the path it was asked to scan back as the match.
+	script := `path=$(sed 's/.*"path":"\([^"]*\)".*/\1/')
+echo "[{\"rule_id\": \"acme-token\", \"line\": 3, \"start_column\": 5, \"end_column\": 9, \"match\": \"$path\"}]"`
+	e := &Exec{Command: []string{"sh", "-c", script}, Logger: zerolog.Nop()}
+	findings := e.ScanContent("config/app.env", "token=acme")
+	require.Len(t, findings, 1)
+	assert.Equal(t, report.Finding{
+		RuleID: "acme-token", StartLine: 3, EndLine: 3, StartColumn: 5, EndColumn: 9, Match: "config/app.env",
+	}, findings[0])
+
+	for name, command := range map[string][]string{
+		"failure":        {"sh", "-c", "echo broken >&2; exit 1"},
+		"invalid output": {"sh", "-c", "echo not json"},
+		"missing":        {"/nonexistent/acme-scan"},
+	} {
+		e := &Exec{Command: command, Logger: zerolog.Nop()}
+		assert.Empty(t, e.ScanContent("app.env", "token"), name)
+	}
+
+	slow := &Exec{Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond, Logger: zerolog.Nop()}
+	start := time.Now()
+	assert.Empty(t, slow.ScanContent("app.env", "token"))
+	assert.Less(t, time.Since(start), 4*time.Second, "Timed out commands should be killed")
+}
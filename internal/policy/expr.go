@@ -0,0 +1,391 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expressions are a small subset of CEL: literals (integers, quoted strings, true, false and
+// lists), dotted variable names, the operators ! && || == != < <= > >= and in, parentheses,
+// and the string methods startsWith, endsWith, contains and matches.
+
+// node is a parsed expression.
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type (
+	literal  struct{ value any }
+	variable struct{ name string }
+	list     struct{ items []node }
+	not      struct{ operand node }
+	binary   struct {
+		op          string
+		left, right node
+	}
+	method struct {
+		name     string
+		receiver node
+		arg      node
+	}
+)
+
+var methods = []string{"startsWith", "endsWith", "contains", "matches"}
+
+// compile parses source, accepting only the variables in known.
+func compile(source string, known map[string]any) (node, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, known: known}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return n, nil
+}
+
+// tokenize splits source into identifiers, numbers, quoted strings and operators.
+func tokenize(source string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(source[i+1:], source[i])
+			if end < 0 {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, source[i:i+end+2])
+			i += end + 2
+		case unicode.IsLetter(c) || c == '_' || unicode.IsDigit(c):
+			j := i
+			for j < len(source) && (unicode.IsLetter(rune(source[j])) || unicode.IsDigit(rune(source[j])) || source[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		default:
+			if i+1 < len(source) && slices.Contains([]string{"&&", "||", "==", "!=", "<=", ">="}, source[i:i+2]) {
+				tokens = append(tokens, source[i:i+2])
+				i += 2
+				continue
+			}
+			if !strings.ContainsRune("!<>().,[]", c) {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+	known  map[string]any
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) expect(token string) error {
+	if got := p.next(); got != token {
+		if got == "" {
+			return fmt.Errorf("expected %q at end of expression", token)
+		}
+		return fmt.Errorf("expected %q, got %q", token, got)
+	}
+	return nil
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	for err == nil && p.peek() == "||" {
+		p.next()
+		var right node
+		if right, err = p.and(); err == nil {
+			left = binary{op: "||", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.comparison()
+	for err == nil && p.peek() == "&&" {
+		p.next()
+		var right node
+		if right, err = p.comparison(); err == nil {
+			left = binary{op: "&&", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) comparison() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.peek(); slices.Contains([]string{"==", "!=", "<", "<=", ">", ">=", "in"}, op) {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return binary{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek() == "!" {
+		p.next()
+		operand, err := p.unary()
+		return not{operand: operand}, err
+	}
+	return p.postfix()
+}
+
+// postfix parses a primary expression followed by method calls.
+func (p *parser) postfix() (node, error) {
+	n, err := p.primary()
+	for err == nil && p.peek() == "." {
+		p.next()
+		name := p.next()
+		if !slices.Contains(methods, name) {
+			return nil, fmt.Errorf("unknown method %q", name)
+		}
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		var arg node
+		if arg, err = p.or(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		n = method{name: name, receiver: n, arg: arg}
+	}
+	return n, err
+}
+
+func (p *parser) primary() (node, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of expression")
+	case token == "(":
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	case token == "[":
+		var items []node
+		for p.peek() != "]" {
+			item, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+		return list{items: items}, p.expect("]")
+	case token[0] == '"' || token[0] == '\'':
+		return literal{value: token[1 : len(token)-1]}, nil
+	case unicode.IsDigit(rune(token[0])):
+		n, err := strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token)
+		}
+		return literal{value: n}, nil
+	case token == "true" || token == "false":
+		return literal{value: token == "true"}, nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		name := token
+		// Variables are dotted names; a dot followed by a method name starts a call.
+		for p.peek() == "." && p.pos+1 < len(p.tokens) && !slices.Contains(methods, p.tokens[p.pos+1]) {
+			p.next()
+			name += "." + p.next()
+		}
+		if _, ok := p.known[name]; !ok {
+			return nil, fmt.Errorf("unknown variable %q", name)
+		}
+		return variable{name: name}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+}
+
+func (n literal) eval(map[string]any) (any, error) { return n.value, nil }
+
+func (n variable) eval(vars map[string]any) (any, error) { return vars[n.name], nil }
+
+func (n list) eval(vars map[string]any) (any, error) {
+	items := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+func (n not) eval(vars map[string]any) (any, error) {
+	v, err := evalBool(n.operand, vars)
+	return !v, err
+}
+
+func (n binary) eval(vars map[string]any) (any, error) {
+	switch n.op {
+	case "&&", "||":
+		left, err := evalBool(n.left, vars)
+		if err != nil || left == (n.op == "||") {
+			return left, err
+		}
+		return evalBool(n.right, vars)
+	}
+
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		items, ok := right.([]any)
+		if !ok {
+			return nil, fmt.Errorf("right side of in is %T, not a list", right)
+		}
+		return slices.ContainsFunc(items, func(item any) bool { return equal(left, item) }), nil
+	}
+	return compare(n.op, left, right)
+}
+
+func (n method) eval(vars map[string]any) (any, error) {
+	receiver, err := n.receiver.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	arg, err := n.arg.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	s, ok1 := receiver.(string)
+	a, ok2 := arg.(string)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("%s needs strings, got %T and %T", n.name, receiver, arg)
+	}
+	switch n.name {
+	case "startsWith":
+		return strings.HasPrefix(s, a), nil
+	case "endsWith":
+		return strings.HasSuffix(s, a), nil
+	case "contains":
+		return strings.Contains(s, a), nil
+	default:
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return nil, fmt.Errorf("matches: %w", err)
+		}
+		return re.MatchString(s), nil
+	}
+}
+
+func evalBool(n node, vars map[string]any) (bool, error) {
+	v, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a bool, got %T", v)
+	}
+	return b, nil
+}
+
+func equal(left, right any) bool {
+	if l, ok := left.([]any); ok {
+		r, ok := right.([]any)
+		return ok && slices.EqualFunc(l, r, equal)
+	}
+	return left == right
+}
+
+func compare(op string, left, right any) (bool, error) {
+	var cmp int
+	switch l := left.(type) {
+	case int64:
+		r, ok := right.(int64)
+		if !ok {
+			return false, fmt.Errorf("cannot compare int with %T", right)
+		}
+		cmp = compareOrdered(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot compare string with %T", right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false, fmt.Errorf("cannot order %T", left)
+	}
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func compareOrdered(l, r int64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,173 @@
+// Package policy decides how scan results are reported from user-supplied rules: the check
+// run conclusion, whether a full scan opens an issue and whether notifications are sent.
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/omercnet/gitguard/internal/severity"
+)
+
+// Rule applies its outcome to scans for which When evaluates to true. Unset outcomes keep the
+// default behaviour.
+type Rule struct {
+	Name string
+	// When is a CEL-style boolean expression over the scan, e.g.
+	// `severity >= high && !repo.private`. See Variables for what it can refer to. An empty
+	// expression matches every scan.
+	When string
+	// Conclusion, when set, replaces the check run conclusion of scans with findings.
+	Conclusion string
+	// Issue, when set, decides whether a full scan with findings opens a security issue.
+	Issue *bool
+	// Notify, when set, decides whether the scan's notification is sent.
+	Notify *bool
+}
+
+// Decision is the outcome of evaluating a Set against a scan.
+type Decision struct {
+	// Rule names the matching rule; empty when no rule matched.
+	Rule string
+	// Conclusion replaces the check run conclusion when not empty.
+	Conclusion string
+	Issue      bool
+	Notify     bool
+}
+
+// Variables lists the names expressions can refer to, with an example value of each type.
+// Severities compare as ordered integers, so `severity >= high` holds for high and critical.
+// findings.new counts the findings first seen within the configured window, and
+// findings.old the long-standing others; without a window every finding is new.
+var Variables = map[string]any{
+	"repo.name":           "",
+	"repo.private":        false,
+	"repo.default_branch": "",
+	"branch":              "",
+	"scan":                "",
+	"findings.total":      int64(0),
+	"findings.low":        int64(0),
+	"findings.medium":     int64(0),
+	"findings.high":       int64(0),
+	"findings.critical":   int64(0),
+	"findings.new":        int64(0),
+	"findings.old":        int64(0),
+	"severity":            int64(0),
+	"rules":               []any{},
+	"none":                int64(severity.None),
+	"low":                 int64(severity.Low),
+	"medium":              int64(severity.Medium),
+	"high":                int64(severity.High),
+	"critical":            int64(severity.Critical),
+}
+
+var conclusions = []string{
+	constants.ConclusionSuccess,
+	constants.ConclusionFailure,
+	constants.ConclusionNeutral,
+	constants.ConclusionActionRequired,
+}
+
+type compiledRule struct {
+	Rule
+	when node
+}
+
+// Set is an ordered list of rules; the first matching rule decides. A nil Set keeps the
+// default behaviour for every scan.
+type Set struct {
+	rules []compiledRule
+}
+
+// New compiles the rules, failing on invalid expressions or conclusions.
+func New(rules []Rule) (*Set, error) {
+	set := &Set{rules: make([]compiledRule, 0, len(rules))}
+	for i, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if rule.Conclusion != "" && !slices.Contains(conclusions, rule.Conclusion) {
+			return nil, fmt.Errorf("rule %s: unknown conclusion %q", name, rule.Conclusion)
+		}
+		var when node = literal{value: true}
+		if rule.When != "" {
+			var err error
+			if when, err = compile(rule.When, Variables); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", name, err)
+			}
+		}
+		rule.Name = name
+		set.rules = append(set.rules, compiledRule{Rule: rule, when: when})
+	}
+	return set, nil
+}
+
+// Decide evaluates the rules against a completed scan. Rules whose expression fails to
+// evaluate, e.g. comparing a string with a number, are skipped and reported in the error.
+func (s *Set) Decide(event notify.Event) (Decision, error) {
+	decision := Decision{Issue: true, Notify: true}
+	if s == nil {
+		return decision, nil
+	}
+
+	vars := variables(event)
+	var errs []string
+	for _, rule := range s.rules {
+		matched, err := evalBool(rule.when, vars)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("rule %s: %v", rule.Name, err))
+			continue
+		}
+		if !matched {
+			continue
+		}
+		decision.Rule = rule.Name
+		decision.Conclusion = rule.Conclusion
+		if rule.Issue != nil {
+			decision.Issue = *rule.Issue
+		}
+		if rule.Notify != nil {
+			decision.Notify = *rule.Notify
+		}
+		break
+	}
+	if len(errs) > 0 {
+		return decision, fmt.Errorf("policy evaluation failed: %s", strings.Join(errs, "; "))
+	}
+	return decision, nil
+}
+
+// variables returns the values of Variables for event.
+func variables(event notify.Event) map[string]any {
+	highest, _ := severity.Parse(event.Findings.HighestSeverity)
+	rules := make([]any, 0, len(event.Findings.ByRule))
+	for rule := range event.Findings.ByRule {
+		rules = append(rules, rule)
+	}
+
+	vars := make(map[string]any, len(Variables))
+	for name, value := range Variables {
+		vars[name] = value
+	}
+	vars["repo.name"] = event.Repository
+	vars["repo.private"] = event.Private
+	vars["repo.default_branch"] = event.DefaultBranch
+	vars["branch"] = strings.TrimPrefix(event.Ref, constants.BranchRefPrefix)
+	vars["scan"] = event.Scan
+	vars["findings.total"] = int64(event.Findings.Total)
+	vars["findings.new"] = int64(event.Findings.New)
+	vars["findings.old"] = int64(event.Findings.LongStanding)
+	if event.Findings.New+event.Findings.LongStanding == 0 {
+		vars["findings.new"] = int64(event.Findings.Total)
+	}
+	for _, level := range []severity.Level{severity.Low, severity.Medium, severity.High, severity.Critical} {
+		vars["findings."+level.String()] = int64(event.Findings.BySeverity[level.String()])
+	}
+	vars["severity"] = int64(highest)
+	vars["rules"] = rules
+	return vars
+}
@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpressions(t *testing.T) {
+	event := notify.Event{
+		Scan:          notify.ScanCommit,
+		Repository:    "acme/api",
+		Private:       true,
+		Ref:           "refs/heads/release/1.2",
+		DefaultBranch: "main",
+		Findings: notify.Summary{
+			Total:           3,
+			HighestSeverity: "high",
+			BySeverity:      map[string]int{"high": 1, "low": 2},
+			ByRule:          map[string]int{"aws-access-token": 1, "generic-api-key": 2},
+			New:             1,
+			LongStanding:    2,
+		},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`severity >= high`, true},
+		{`severity == critical`, false},
+		{`findings.total > 2 && findings.low == 2`, true},
+		{`findings.critical > 0 || repo.private`, true},
+		{`!repo.private`, false},
+		{`branch == repo.default_branch`, false},
+		{`branch.startsWith("release/")`, true},
+		{`repo.name.matches('^acme/(api|web)$')`, true},
+		{`repo.name in ["acme/web", "acme/api"]`, true},
+		{`"private-key" in rules`, false},
+		{`"aws-access-token" in rules && scan == "commit"`, true},
+		{`(severity < high || repo.private) && !(findings.total == 0)`, true},
+		{`findings.new == 1 && findings.old == 2`, true},
+	}
+	vars := variables(event)
+	for _, tt := range tests {
+		n, err := compile(tt.expr, Variables)
+		require.NoError(t, err, tt.expr)
+		got, err := evalBool(n, vars)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, got, tt.expr)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		`severity >=`,
+		`visibility == "public"`,
+		`branch.lower()`,
+		`(severity > low`,
+		`"unterminated`,
+		`severity > low extra`,
+		`findings.total = 1`,
+	} {
+		_, err := compile(expr, Variables)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestSetDecide(t *testing.T) {
+	no := false
+	set, err := New([]Rule{
+		{Name: "internal", When: `repo.private && severity <= medium`, Conclusion: "neutral", Issue: &no},
+		{Name: "quiet forks", When: `repo.name.startsWith("fork/")`, Notify: &no},
+		{Name: "broken", When: `repo.name > 1`},
+		{When: `severity == critical`, Conclusion: "action_required"},
+	})
+	require.NoError(t, err)
+
+	decision, err := set.Decide(notify.Event{Private: true, Findings: notify.Summary{HighestSeverity: "low"}})
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Rule: "internal", Conclusion: "neutral", Issue: false, Notify: true}, decision)
+
+	decision, err = set.Decide(notify.Event{Repository: "fork/api", Findings: notify.Summary{HighestSeverity: "high"}})
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Rule: "quiet forks", Issue: true, Notify: false}, decision)
+
+	decision, err = set.Decide(notify.Event{Repository: "acme/api", Findings: notify.Summary{HighestSeverity: "critical"}})
+	assert.Error(t, err, "Evaluation errors should be reported")
+	assert.Equal(t, Decision{Rule: "#4", Conclusion: "action_required", Issue: true, Notify: true}, decision)
+
+	aged, err := New([]Rule{{Name: "legacy", When: `findings.new == 0`, Conclusion: "success"}})
+	require.NoError(t, err)
+	decision, err = aged.Decide(notify.Event{Findings: notify.Summary{Total: 2, LongStanding: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", decision.Rule, "Scans with long-standing findings only should match")
+	decision, err = aged.Decide(notify.Event{Findings: notify.Summary{Total: 2}})
+	require.NoError(t, err)
+	assert.Empty(t, decision.Rule, "Findings of scans that are not aged should count as new")
+
+	var unset *Set
+	decision, err = unset.Decide(notify.Event{})
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Issue: true, Notify: true}, decision)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New([]Rule{{Name: "typo", When: `severity > low`, Conclusion: "fail"}})
+	assert.ErrorContains(t, err, "typo")
+
+	_, err = New([]Rule{{When: `severity >`}})
+	assert.ErrorContains(t, err, "#1")
+
+	set, err := New([]Rule{{Conclusion: "neutral"}})
+	require.NoError(t, err)
+	decision, err := set.Decide(notify.Event{})
+	require.NoError(t, err)
+	assert.Equal(t, "neutral", decision.Conclusion, "An empty expression should match every scan")
+}
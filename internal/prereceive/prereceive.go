@@ -0,0 +1,146 @@
+// Package prereceive scans the commits of a push from a git pre-receive or pre-push hook, and
+// the staged changes of a commit from a pre-commit hook, so secrets can be rejected before
+// any ref is updated or any commit is made.
+package prereceive
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	gitleaksscm "github.com/zricethezav/gitleaks/v8/cmd/scm"
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/detect"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+// zeroSHA is the object name git passes for a ref that does not exist on one side of the
+// update.
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// Update is one ref update of a push: a line of the pre-receive hook's standard input.
+type Update struct {
+	Old string
+	New string
+	Ref string
+}
+
+// Deletes reports whether the update deletes the ref.
+func (u Update) Deletes() bool {
+	return strings.Trim(u.New, "0") == ""
+}
+
+// ParseUpdates reads the "<old> <new> <ref>" lines git writes to a pre-receive hook.
+func ParseUpdates(r io.Reader) ([]Update, error) {
+	var updates []Update
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid pre-receive line %q: expected <old> <new> <ref>", line)
+		}
+		updates = append(updates, Update{Old: fields[0], New: fields[1], Ref: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pre-receive input: %w", err)
+	}
+	return updates, nil
+}
+
+// ParsePushUpdates reads the "<local ref> <local sha> <remote ref> <remote sha>" lines git
+// writes to a pre-push hook, as updates of the remote refs.
+func ParsePushUpdates(r io.Reader) ([]Update, error) {
+	var updates []Update
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf(
+				"invalid pre-push line %q: expected <local ref> <local sha> <remote ref> <remote sha>", line)
+		}
+		updates = append(updates, Update{Old: fields[3], New: fields[1], Ref: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pre-push input: %w", err)
+	}
+	return updates, nil
+}
+
+// Scan runs the detector over the commits added by the updates to the repository in dir:
+// the commits reachable from the new ref values but not from any existing ref. Git's
+// quarantine environment is inherited, so pushed objects are visible before they are
+// accepted.
+func Scan(ctx context.Context, cfg config.Config, dir string, updates []Update) ([]report.Finding, error) {
+	return scanCommits(ctx, cfg, dir, updates, "--all")
+}
+
+// ScanPush runs the detector over the commits a pre-push hook's updates send from the
+// repository in dir: those reachable from the pushed values but not from any remote-tracking
+// ref.
+func ScanPush(ctx context.Context, cfg config.Config, dir string, updates []Update) ([]report.Finding, error) {
+	return scanCommits(ctx, cfg, dir, updates, "--remotes")
+}
+
+// ScanStaged runs the detector over the lines the staged changes of the repository in dir
+// add, as the next commit would.
+func ScanStaged(ctx context.Context, cfg config.Config, dir string) ([]report.Finding, error) {
+	cmd, err := sources.NewGitDiffCmd(dir, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged changes: %w", err)
+	}
+	findings, err := detectGit(ctx, cfg, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan staged changes: %w", err)
+	}
+	return findings, nil
+}
+
+// scanCommits scans the commits reachable from the updates' new values but not from the
+// refs selected by known, a git rev-list option such as --all.
+func scanCommits(
+	ctx context.Context, cfg config.Config, dir string, updates []Update, known string,
+) ([]report.Finding, error) {
+	var revisions []string
+	for _, update := range updates {
+		if !update.Deletes() {
+			revisions = append(revisions, update.New)
+		}
+	}
+	if len(revisions) == 0 {
+		return nil, nil
+	}
+
+	cmd, err := sources.NewGitLogCmd(dir, strings.Join(revisions, " ")+" --not "+known)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pushed commits: %w", err)
+	}
+	findings, err := detectGit(ctx, cfg, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pushed commits: %w", err)
+	}
+	return findings, nil
+}
+
+// detectGit runs the detector over the output of a git log or diff command.
+func detectGit(ctx context.Context, cfg config.Config, cmd *sources.GitCmd) ([]report.Finding, error) {
+	// Detectors accumulate findings, so every scan gets its own.
+	d := detect.NewDetector(cfg)
+	return d.DetectSource(ctx, &sources.Git{
+		Cmd:             cmd,
+		Config:          &d.Config,
+		Remote:          &sources.RemoteInfo{Platform: gitleaksscm.NoPlatform},
+		Sema:            d.Sema,
+		MaxArchiveDepth: d.MaxArchiveDepth,
+	})
+}
@@ -0,0 +1,49 @@
+// Package problem writes RFC 9457 problem details (application/problem+json) error responses,
+// so webhook senders and debugging tools see why a delivery was rejected.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType is the media type of problem details responses.
+const ContentType = "application/problem+json"
+
+// Machine-readable error codes.
+const (
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeInvalidSignature = "invalid_signature"
+	CodeInvalidRequest   = "invalid_request"
+	CodeSourceNotAllowed = "source_not_allowed"
+	CodeOverCapacity     = "over_capacity"
+	CodeInternal         = "internal_error"
+)
+
+// Details is a problem details body, extended with a stable error code and the delivery ID
+// so a failed delivery can be matched with the server logs.
+type Details struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Status     int    `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Code       string `json:"code"`
+	DeliveryID string `json:"delivery_id,omitempty"`
+}
+
+// Write responds with a problem details body. The delivery ID is taken from the
+// X-GitHub-Delivery request header.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	body := Details{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     detail,
+		Code:       code,
+		DeliveryID: r.Header.Get("X-GitHub-Delivery"),
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,33 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	rec := httptest.NewRecorder()
+
+	Write(rec, req, http.StatusBadRequest, CodeInvalidSignature, "payload signature check failed")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+
+	var body Details
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, Details{
+		Type:       "about:blank",
+		Title:      "Bad Request",
+		Status:     http.StatusBadRequest,
+		Detail:     "payload signature check failed",
+		Code:       CodeInvalidSignature,
+		DeliveryID: "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+	}, body)
+}
@@ -0,0 +1,516 @@
+// Package ratelimit budgets GitHub API usage per installation. It records the rate limit
+// GitHub reports on every response and schedules scans so that no installation holds more
+// than its share of the concurrent scans, higher-priority scans take free slots first and
+// low-priority scans are deferred while an installation's budget is low.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+)
+
+// Rate limit headers GitHub sends on every API response.
+const (
+	headerLimit     = "X-RateLimit-Limit"
+	headerRemaining = "X-RateLimit-Remaining"
+	headerReset     = "X-RateLimit-Reset"
+)
+
+// Priority orders scans competing for scan slots and an installation's budget. A free slot
+// goes to the highest-priority waiting scan that may run.
+type Priority int
+
+const (
+	// High is for scans someone is waiting on, such as pull request scans and pushes to the
+	// default branch. They only wait for the budget once it is exhausted.
+	High Priority = iota
+	// Normal is for scans reported as check runs that block no one, such as pushes to other
+	// branches. They wait for the budget like high-priority scans.
+	Normal
+	// Low is for scans that can wait for the rate limit to reset, such as full repository
+	// and organization scans. They wait while less than the reserve of the budget remains.
+	Low
+
+	priorities = int(Low) + 1
+)
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	switch p {
+	case High:
+		return "high"
+	case Normal:
+		return "normal"
+	case Low:
+		return "low"
+	}
+	return "priority(" + strconv.Itoa(int(p)) + ")"
+}
+
+// Budget is the rate limit of an installation as last reported by GitHub.
+type Budget struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// exhausted reports whether the budget left for priority is used up at now.
+func (b Budget) exhausted(priority Priority, reserve float64, now time.Time) bool {
+	if b.Limit <= 0 || !now.Before(b.Reset) {
+		return false
+	}
+	if priority < Low {
+		return b.Remaining <= 0
+	}
+	return float64(b.Remaining) < reserve*float64(b.Limit)
+}
+
+// ErrSaturated is returned by Acquire when as many scans as the scheduler queues are already
+// waiting.
+var ErrSaturated = errors.New("scan queue is full")
+
+// ErrShed is returned by Acquire to a waiting scan dropped from a full queue to make room for
+// a higher-priority one.
+var ErrShed = errors.New("scan dropped from the full queue for a higher-priority scan")
+
+// Scheduler admits scans per installation. A nil Scheduler admits every scan immediately.
+type Scheduler struct {
+	slots           int
+	perInstallation int
+	reserve         float64
+	maxQueued       int
+	now             func() time.Time
+
+	mu      sync.Mutex
+	running int
+	active  map[int64]int
+	budgets map[int64]Budget
+	// changed is closed and replaced whenever a slot is released or a budget is recorded.
+	changed chan struct{}
+	// queued holds the waiting scans by priority, oldest first, so the newest can be shed.
+	queued   [priorities][]*waiter
+	rejected int64
+	shed     int64
+}
+
+// waiter is a scan waiting in Acquire.
+type waiter struct {
+	installationID int64
+	priority       Priority
+	// dropped is closed when the scan is shed.
+	dropped chan struct{}
+	removed bool
+}
+
+// Stats describe the load of a Scheduler.
+type Stats struct {
+	// Running is the number of scans holding a slot, and Queued the number waiting for one.
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+	// QueuedByPriority breaks Queued down by the name of the scans' priority.
+	QueuedByPriority map[string]int `json:"queued_by_priority,omitempty"`
+	// MaxQueued is the number of scans that may wait; 0 means no limit.
+	MaxQueued int `json:"max_queued"`
+	// Rejected counts the scans refused because the queue was full, and Shed the scans
+	// dropped from it to make room for higher-priority ones.
+	Rejected int64 `json:"rejected"`
+	Shed     int64 `json:"shed"`
+}
+
+// NewScheduler creates a Scheduler running at most slots scans at once, of which one
+// installation may hold the fraction share; slots of 0 means no limit. Low-priority scans
+// wait while less than the fraction reserve of an installation's rate limit remains.
+func NewScheduler(slots int, share, reserve float64) *Scheduler {
+	perInstallation := 0
+	if slots > 0 {
+		perInstallation = max(1, int(math.Ceil(float64(slots)*share)))
+	}
+	return &Scheduler{
+		slots:           slots,
+		perInstallation: perInstallation,
+		reserve:         reserve,
+		now:             time.Now,
+		active:          make(map[int64]int),
+		budgets:         make(map[int64]Budget),
+		changed:         make(chan struct{}),
+	}
+}
+
+// SetMaxQueued bounds the scans waiting in Acquire to n; 0 removes the bound. Once n scans
+// wait, a scan takes the place of the newest waiting scan of the lowest priority below its
+// own, and scans that find no place fail with ErrSaturated instead of waiting.
+func (s *Scheduler) SetMaxQueued(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxQueued = n
+}
+
+// Stats returns the scheduler's current load.
+func (s *Scheduler) Stats() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := Stats{
+		Running:   s.running,
+		Queued:    s.queuedCount(),
+		MaxQueued: s.maxQueued,
+		Rejected:  s.rejected,
+		Shed:      s.shed,
+	}
+	for priority, waiters := range s.queued {
+		if len(waiters) > 0 {
+			if stats.QueuedByPriority == nil {
+				stats.QueuedByPriority = make(map[string]int)
+			}
+			stats.QueuedByPriority[Priority(priority).String()] = len(waiters)
+		}
+	}
+	return stats
+}
+
+// queuedCount returns the number of waiting scans. The caller holds s.mu.
+func (s *Scheduler) queuedCount() int {
+	count := 0
+	for _, waiters := range s.queued {
+		count += len(waiters)
+	}
+	return count
+}
+
+// Acquire waits until a scan of installationID with priority may run and returns the
+// function releasing its slot. It returns ctx's error if ctx ends first, ErrSaturated if
+// the queue is full and ErrShed if the scan is dropped from it.
+func (s *Scheduler) Acquire(ctx context.Context, installationID int64, priority Priority) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	deferred := false
+	var queued *waiter
+	defer func() {
+		if queued != nil {
+			s.dequeue(queued)
+		}
+	}()
+	for {
+		s.mu.Lock()
+		reset, admitted := s.admit(installationID, priority)
+		if !admitted && queued == nil {
+			var err error
+			if queued, err = s.enqueue(installationID, priority); err != nil {
+				maxQueued := s.maxQueued
+				s.mu.Unlock()
+				zerolog.Ctx(ctx).Warn().
+					Int64("installation_id", installationID).
+					Int("max_queued", maxQueued).
+					Msg(constants.LogMsgScanQueueFull)
+				return nil, err
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		if admitted {
+			var once sync.Once
+			return func() { once.Do(func() { s.release(installationID) }) }, nil
+		}
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if !reset.IsZero() {
+			if !deferred {
+				zerolog.Ctx(ctx).Info().
+					Int64("installation_id", installationID).
+					Time("reset", reset).
+					Msg(constants.LogMsgScanDeferred)
+				deferred = true
+			}
+			timer = time.NewTimer(reset.Sub(s.now()))
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return nil, ctx.Err()
+		case <-queued.dropped:
+			stopTimer(timer)
+			zerolog.Ctx(ctx).Warn().Int64("installation_id", installationID).Msg(constants.LogMsgScanShed)
+			return nil, ErrShed
+		case <-changed:
+		case <-expired:
+		}
+		stopTimer(timer)
+	}
+}
+
+// enqueue records a scan of installationID with priority as waiting, shedding the newest
+// waiting scan of the lowest priority below its own when the queue is full. The caller holds
+// s.mu.
+func (s *Scheduler) enqueue(installationID int64, priority Priority) (*waiter, error) {
+	if s.full() {
+		lowest, ok := s.sheddable(priority)
+		if !ok {
+			s.rejected++
+			return nil, ErrSaturated
+		}
+		waiters := s.queued[lowest]
+		newest := waiters[len(waiters)-1]
+		s.queued[lowest] = waiters[:len(waiters)-1]
+		newest.removed = true
+		close(newest.dropped)
+		s.shed++
+	}
+
+	w := &waiter{installationID: installationID, priority: priority, dropped: make(chan struct{})}
+	s.queued[priority] = append(s.queued[priority], w)
+	return w, nil
+}
+
+// full reports whether as many scans as the scheduler queues are waiting. The caller holds
+// s.mu.
+func (s *Scheduler) full() bool {
+	return s.maxQueued > 0 && s.queuedCount() >= s.maxQueued
+}
+
+// sheddable returns the lowest priority below priority that has waiting scans, whose newest
+// a scan with priority may take the place of in a full queue. The caller holds s.mu.
+func (s *Scheduler) sheddable(priority Priority) (Priority, bool) {
+	for lowest := Priority(priorities - 1); lowest > priority; lowest-- {
+		if len(s.queued[lowest]) > 0 {
+			return lowest, true
+		}
+	}
+	return 0, false
+}
+
+// Admissible reports whether Acquire would now admit or queue a scan of installationID with
+// priority, without taking a slot or a place in the queue, so that callers can refuse work
+// up front rather than have its scan fail with ErrSaturated later. Scans it refuses are
+// counted as rejected.
+func (s *Scheduler) Admissible(installationID int64, priority Priority) bool {
+	if s == nil {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.full() {
+		return true
+	}
+	if _, ok := s.runnable(installationID, priority); ok {
+		return true
+	}
+	if _, ok := s.sheddable(priority); ok {
+		return true
+	}
+	s.rejected++
+	return false
+}
+
+// dequeue removes a scan that stopped waiting from the queue, unless it was shed.
+func (s *Scheduler) dequeue(w *waiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.removed {
+		return
+	}
+	w.removed = true
+	s.queued[w.priority] = slices.DeleteFunc(s.queued[w.priority], func(queued *waiter) bool { return queued == w })
+}
+
+// TryAcquire admits a scan of installationID with priority if it may run now, without
+// waiting, and returns the function releasing its slot.
+func (s *Scheduler) TryAcquire(installationID int64, priority Priority) (func(), bool) {
+	if s == nil {
+		return func() {}, true
+	}
+
+	s.mu.Lock()
+	_, admitted := s.admit(installationID, priority)
+	s.mu.Unlock()
+	if !admitted {
+		return nil, false
+	}
+	var once sync.Once
+	return func() { once.Do(func() { s.release(installationID) }) }, true
+}
+
+// admit takes a slot for installationID if a scan with priority may run. When its budget
+// does not allow it, it returns the time the budget resets.
+func (s *Scheduler) admit(installationID int64, priority Priority) (time.Time, bool) {
+	reset, ok := s.runnable(installationID, priority)
+	if !ok {
+		return reset, false
+	}
+	s.running++
+	s.active[installationID]++
+	return time.Time{}, true
+}
+
+// runnable reports whether a scan of installationID with priority may run: a slot is free,
+// no higher-priority scan waiting for it may run and its budget allows priority. When the
+// budget does not, it returns the time the budget resets. The caller holds s.mu.
+func (s *Scheduler) runnable(installationID int64, priority Priority) (time.Time, bool) {
+	if budget, ok := s.budgets[installationID]; ok && budget.exhausted(priority, s.reserve, s.now()) {
+		return budget.Reset, false
+	}
+	if s.slots > 0 && (s.running >= s.slots || s.active[installationID] >= s.perInstallation ||
+		s.waitingAbove(priority)) {
+		return time.Time{}, false
+	}
+	return time.Time{}, true
+}
+
+// waitingAbove reports whether a waiting scan of a higher priority than priority may take a
+// free slot, which it then gets first. The caller holds s.mu.
+func (s *Scheduler) waitingAbove(priority Priority) bool {
+	now := s.now()
+	for _, waiters := range s.queued[:priority] {
+		for _, w := range waiters {
+			budget, ok := s.budgets[w.installationID]
+			if s.active[w.installationID] < s.perInstallation &&
+				(!ok || !budget.exhausted(w.priority, s.reserve, now)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Scheduler) release(installationID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running--
+	if s.active[installationID]--; s.active[installationID] <= 0 {
+		delete(s.active, installationID)
+	}
+	s.notify()
+}
+
+// notify wakes every waiting Acquire. The caller holds s.mu.
+func (s *Scheduler) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Budget returns the last rate limit recorded for installationID.
+func (s *Scheduler) Budget(installationID int64) (Budget, bool) {
+	if s == nil {
+		return Budget{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	budget, ok := s.budgets[installationID]
+	return budget, ok
+}
+
+// Observe records the rate limit headers of a response to installationID. Responses without
+// them, such as those served from the HTTP cache, are ignored.
+func (s *Scheduler) Observe(installationID int64, header http.Header) {
+	limit, err := strconv.Atoi(header.Get(headerLimit))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get(headerRemaining))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get(headerReset), 10, 64)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budgets[installationID] = Budget{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}
+	s.notify()
+}
+
+// Transport returns a RoundTripper recording the rate limit of installationID from the
+// responses of next.
+func (s *Scheduler) Transport(installationID int64, next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if resp != nil {
+			s.Observe(installationID, resp.Header)
+		}
+		return resp, err
+	})
+}
+
+// Clients wraps cc so that installation clients report their rate limit to s. It returns cc
+// itself when s is nil.
+func (s *Scheduler) Clients(cc githubapp.ClientCreator) githubapp.ClientCreator {
+	if s == nil {
+		return cc
+	}
+	return &clientCreator{ClientCreator: cc, scheduler: s}
+}
+
+type clientCreator struct {
+	githubapp.ClientCreator
+	scheduler *Scheduler
+}
+
+// NewInstallationClient returns an installation client whose responses are recorded in the
+// installation's budget.
+func (c *clientCreator) NewInstallationClient(installationID int64) (*github.Client, error) {
+	client, err := c.ClientCreator.NewInstallationClient(installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := client.Client()
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	httpClient.Transport = c.scheduler.Transport(installationID, next)
+
+	tracked := github.NewClient(httpClient)
+	tracked.BaseURL = client.BaseURL
+	tracked.UploadURL = client.UploadURL
+	tracked.UserAgent = client.UserAgent
+	return tracked, nil
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Handler serves the scheduler's Stats as JSON.
+func Handler(s *Scheduler, logger zerolog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.Stats()); err != nil {
+			logger.Error().Err(err).Msg("Failed to write scan queue response")
+		}
+	})
+}
@@ -0,0 +1,349 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rateLimitHeader returns the headers GitHub sends for a budget.
+func rateLimitHeader(limit, remaining int, reset time.Time) http.Header {
+	header := http.Header{}
+	header.Set(headerLimit, strconv.Itoa(limit))
+	header.Set(headerRemaining, strconv.Itoa(remaining))
+	header.Set(headerReset, strconv.FormatInt(reset.Unix(), 10))
+	return header
+}
+
+// acquired reports whether Acquire admits the scan before a short timeout.
+func acquired(s *Scheduler, installationID int64, priority Priority) (func(), bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	release, err := s.Acquire(ctx, installationID, priority)
+	return release, err == nil
+}
+
+func TestScheduler_NilAdmitsEverything(t *testing.T) {
+	var s *Scheduler
+	release, err := s.Acquire(context.Background(), 1, Low)
+	require.NoError(t, err)
+	release()
+
+	_, ok := s.Budget(1)
+	assert.False(t, ok)
+}
+
+func TestScheduler_FairShare(t *testing.T) {
+	s := NewScheduler(4, 0.5, 0.1)
+
+	first, ok := acquired(s, 1, High)
+	require.True(t, ok)
+	_, ok = acquired(s, 1, High)
+	require.True(t, ok)
+	_, ok = acquired(s, 1, High)
+	assert.False(t, ok, "An installation should not hold more than its share of the slots")
+
+	_, ok = acquired(s, 2, High)
+	assert.True(t, ok, "Other installations should still be admitted")
+
+	first()
+	first()
+	_, ok = acquired(s, 1, High)
+	assert.True(t, ok, "A released slot should be available again; releasing twice is a no-op")
+	_, ok = acquired(s, 2, High)
+	require.True(t, ok)
+	_, ok = acquired(s, 3, High)
+	assert.False(t, ok, "All slots are taken")
+}
+
+func TestScheduler_TryAcquire(t *testing.T) {
+	var none *Scheduler
+	_, ok := none.TryAcquire(1, High)
+	assert.True(t, ok)
+
+	s := NewScheduler(2, 1, 0.1)
+	first, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+	_, ok = s.TryAcquire(1, High)
+	require.True(t, ok)
+	_, ok = s.TryAcquire(1, High)
+	assert.False(t, ok, "TryAcquire should not wait for a slot")
+
+	first()
+	_, ok = s.TryAcquire(1, High)
+	assert.True(t, ok, "A released slot should be available again")
+}
+
+func TestScheduler_DefersLowPriority(t *testing.T) {
+	s := NewScheduler(0, 1, 0.1)
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	s.Observe(1, rateLimitHeader(5000, 400, now.Add(time.Hour)))
+	budget, ok := s.Budget(1)
+	require.True(t, ok)
+	assert.Equal(t, Budget{Limit: 5000, Remaining: 400, Reset: now.Add(time.Hour)}, budget)
+
+	_, ok = acquired(s, 1, Low)
+	assert.False(t, ok, "Full scans should wait while less than the reserve remains")
+	_, ok = acquired(s, 1, High)
+	assert.True(t, ok, "Commit scans should run until the budget is exhausted")
+	_, ok = acquired(s, 1, Normal)
+	assert.True(t, ok, "Commit scans should run until the budget is exhausted")
+	_, ok = acquired(s, 2, Low)
+	assert.True(t, ok, "Other installations have their own budget")
+
+	s.Observe(1, rateLimitHeader(5000, 0, now.Add(time.Hour)))
+	_, ok = acquired(s, 1, High)
+	assert.False(t, ok, "No scan should start with an exhausted budget")
+
+	now = now.Add(time.Hour)
+	_, ok = acquired(s, 1, Low)
+	assert.True(t, ok, "The budget is replenished once it resets")
+}
+
+func TestScheduler_WakesOnBudgetChange(t *testing.T) {
+	s := NewScheduler(0, 1, 0.1)
+	s.Observe(1, rateLimitHeader(5000, 10, time.Now().Add(time.Hour)))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(context.Background(), 1, Low)
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("The scan should be deferred")
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.Observe(1, rateLimitHeader(5000, 5000, time.Now().Add(time.Hour)))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("The scan should start once the budget is replenished")
+	}
+}
+
+func TestScheduler_ObserveIgnoresMissingHeaders(t *testing.T) {
+	s := NewScheduler(0, 1, 0.1)
+	s.Observe(1, http.Header{})
+	_, ok := s.Budget(1)
+	assert.False(t, ok)
+}
+
+type fakeCreator struct {
+	githubapp.ClientCreator
+	baseURL *url.URL
+}
+
+func (c fakeCreator) NewInstallationClient(int64) (*github.Client, error) {
+	client := github.NewClient(nil)
+	client.BaseURL = c.baseURL
+	return client, nil
+}
+
+func TestScheduler_Clients(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for name, values := range rateLimitHeader(5000, 4321, reset) {
+			w.Header()[name] = values
+		}
+		_, _ = w.Write([]byte(`{"full_name": "owner/repo"}`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	creator := fakeCreator{baseURL: baseURL}
+	var none *Scheduler
+	assert.Equal(t, githubapp.ClientCreator(creator), none.Clients(creator))
+
+	s := NewScheduler(0, 1, 0.1)
+	client, err := s.Clients(creator).NewInstallationClient(7)
+	require.NoError(t, err)
+	_, _, err = client.Repositories.Get(context.Background(), "owner", "repo")
+	require.NoError(t, err)
+
+	budget, ok := s.Budget(7)
+	require.True(t, ok)
+	assert.Equal(t, Budget{Limit: 5000, Remaining: 4321, Reset: reset}, budget)
+}
+
+// waitQueued waits until n scans wait in s.
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return s.Stats().Queued == n }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_MaxQueued(t *testing.T) {
+	s := NewScheduler(1, 1, 0.1)
+	s.SetMaxQueued(2)
+	release, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+
+	// wait queues a scan, which releases its slot as soon as it is admitted.
+	wait := func(priority Priority) chan error {
+		done := make(chan error, 1)
+		go func() {
+			release, err := s.Acquire(context.Background(), 1, priority)
+			if err == nil {
+				release()
+			}
+			done <- err
+		}()
+		return done
+	}
+	low := wait(Low)
+	waitQueued(t, s, 1)
+	high := wait(High)
+	waitQueued(t, s, 2)
+
+	_, err := s.Acquire(context.Background(), 1, Low)
+	require.ErrorIs(t, err, ErrSaturated, "A low-priority scan should not wait in a full queue")
+
+	second := wait(High)
+	require.ErrorIs(t, <-low, ErrShed, "A high-priority scan should take the place of a low-priority one")
+	waitQueued(t, s, 2)
+
+	_, err = s.Acquire(context.Background(), 1, High)
+	require.ErrorIs(t, err, ErrSaturated, "A high-priority scan should not shed another high-priority one")
+	assert.Equal(t, Stats{
+		Running: 1, Queued: 2, QueuedByPriority: map[string]int{"high": 2}, MaxQueued: 2, Rejected: 2, Shed: 1,
+	}, s.Stats())
+
+	release()
+	require.NoError(t, <-high)
+	require.NoError(t, <-second)
+	assert.Equal(t, Stats{MaxQueued: 2, Rejected: 2, Shed: 1}, s.Stats(), "Admitted scans should leave the queue")
+}
+
+func TestScheduler_Admissible(t *testing.T) {
+	s := NewScheduler(1, 1, 0.1)
+	s.SetMaxQueued(1)
+	assert.True(t, s.Admissible(1, Low), "A scan should be admissible while a slot is free")
+	release, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+	assert.True(t, s.Admissible(1, Low), "A scan should be admissible while the queue has room")
+
+	queued := make(chan error, 1)
+	go func() {
+		release, err := s.Acquire(context.Background(), 1, Low)
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+	waitQueued(t, s, 1)
+
+	assert.False(t, s.Admissible(1, Low), "A scan should not be admissible in a full queue")
+	assert.True(t, s.Admissible(1, High), "A scan should be admissible when it can shed a lower-priority one")
+	assert.Equal(t, Stats{
+		Running: 1, Queued: 1, QueuedByPriority: map[string]int{"low": 1}, MaxQueued: 1, Rejected: 1,
+	}, s.Stats(), "Admissible should neither queue nor shed scans")
+
+	var nilScheduler *Scheduler
+	assert.True(t, nilScheduler.Admissible(1, Low))
+
+	release()
+	require.NoError(t, <-queued)
+}
+
+func TestScheduler_HigherPriorityFirst(t *testing.T) {
+	s := NewScheduler(1, 1, 0.1)
+	release, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+
+	admitted := make(chan Priority, 3)
+	for i, priority := range []Priority{Low, Normal, High} {
+		go func() {
+			release, err := s.Acquire(context.Background(), 1, priority)
+			if assert.NoError(t, err) {
+				admitted <- priority
+				release()
+			}
+		}()
+		waitQueued(t, s, i+1)
+	}
+	assert.Equal(t, map[string]int{"high": 1, "normal": 1, "low": 1}, s.Stats().QueuedByPriority)
+	_, ok = s.TryAcquire(2, Low)
+	assert.False(t, ok, "Scans should not take a slot before higher-priority scans waiting for it")
+
+	release()
+	assert.Equal(t, []Priority{High, Normal, Low}, []Priority{<-admitted, <-admitted, <-admitted})
+}
+
+func TestScheduler_BlockedHigherPriority(t *testing.T) {
+	s := NewScheduler(2, 0.5, 0.1)
+	release, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _, _ = s.Acquire(ctx, 1, High) }()
+	waitQueued(t, s, 1)
+	_, ok = s.TryAcquire(2, Low)
+	assert.True(t, ok, "A higher-priority scan over its installation's share should not hold back others")
+}
+
+func TestPriority_String(t *testing.T) {
+	assert.Equal(t, "high", High.String())
+	assert.Equal(t, "normal", Normal.String())
+	assert.Equal(t, "low", Low.String())
+	assert.Equal(t, "priority(7)", Priority(7).String())
+}
+
+func TestScheduler_CanceledScanLeavesQueue(t *testing.T) {
+	s := NewScheduler(1, 1, 0.1)
+	s.SetMaxQueued(1)
+	release, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+	defer release()
+
+	_, ok = acquired(s, 1, Low)
+	require.False(t, ok)
+	_, ok = acquired(s, 1, Low)
+	assert.False(t, ok)
+	assert.Equal(t, Stats{Running: 1, MaxQueued: 1}, s.Stats(), "Scans that stop waiting should leave the queue")
+}
+
+func TestScheduler_UnboundedQueue(t *testing.T) {
+	s := NewScheduler(1, 1, 0.1)
+	release, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+	defer release()
+
+	for range 3 {
+		_, ok = acquired(s, 1, Low)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, Stats{Running: 1}, s.Stats())
+}
+
+func TestHandler(t *testing.T) {
+	s := NewScheduler(2, 1, 0.1)
+	s.SetMaxQueued(5)
+	release, ok := s.TryAcquire(1, High)
+	require.True(t, ok)
+	defer release()
+
+	rec := httptest.NewRecorder()
+	Handler(s, zerolog.Nop()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/queue", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"running":1,"queued":0,"max_queued":5,"rejected":0,"shed":0}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	Handler(s, zerolog.Nop()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/queue", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,62 @@
+// Package redact decides how secret values appear in stored and reported findings: not at
+// all, as a masked preview or as a SHA-256 hash. Raw secrets are never stored.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// hashPrefix names the hash function of hashed secrets.
+const hashPrefix = "sha256:"
+
+// MinMaskPercent is the smallest share of a secret masked previews hide. gitleaks keeps the
+// rest, so lower percentages would store and report most or all of the raw secret.
+const MinMaskPercent = 50
+
+// Policy redacts secrets. A nil Policy leaves them out entirely.
+type Policy struct {
+	mode    string
+	percent uint
+}
+
+// New returns the policy of mode, constants.RedactionMask, RedactionHash or RedactionNone,
+// or nil when secrets are left out. Masked previews hide percent of each secret, as
+// gitleaks' own redaction does, raised to MinMaskPercent and capped at 100.
+func New(mode string, percent int) *Policy {
+	switch mode {
+	case constants.RedactionMask, constants.RedactionHash:
+		return &Policy{mode: mode, percent: uint(min(max(percent, MinMaskPercent), 100))} // #nosec G115 -- clamped to 50..100
+	}
+	return nil
+}
+
+// Secret returns secret as it may be stored and reported, or an empty string when the
+// policy leaves secrets out.
+func (p *Policy) Secret(secret string) string {
+	if p == nil || secret == "" {
+		return ""
+	}
+	if p.mode == constants.RedactionHash {
+		sum := sha256.Sum256([]byte(secret))
+		return hashPrefix + hex.EncodeToString(sum[:])
+	}
+	finding := report.Finding{Secret: secret}
+	finding.Redact(p.percent)
+	return finding.Secret
+}
+
+// String identifies the policy, for cache keys of redacted results.
+func (p *Policy) String() string {
+	if p == nil {
+		return constants.RedactionNone
+	}
+	if p.mode == constants.RedactionMask {
+		return fmt.Sprintf("%s%d", p.mode, p.percent)
+	}
+	return p.mode
+}
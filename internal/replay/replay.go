@@ -0,0 +1,187 @@
+// Package replay records webhook deliveries to disk as fixtures and replays them, signed,
+// against a running server, so the full pipeline can be exercised with real payloads.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/rs/zerolog"
+)
+
+// Headers of webhook deliveries.
+const (
+	EventHeader     = "X-GitHub-Event"
+	DeliveryHeader  = "X-GitHub-Delivery"
+	SignatureHeader = "X-Hub-Signature-256"
+)
+
+// recordedHeaders are the delivery headers fixtures keep besides the event and delivery ID:
+// those naming the App a delivery is addressed to. Signatures are not kept; replays are
+// signed with the receiving server's secret.
+var recordedHeaders = []string{
+	"X-GitHub-Hook-Installation-Target-Type",
+	"X-GitHub-Hook-Installation-Target-ID",
+}
+
+// Fixture is a recorded webhook delivery.
+type Fixture struct {
+	Event    string            `json:"event"`
+	Delivery string            `json:"delivery"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Payload  json.RawMessage   `json:"payload"`
+}
+
+// Load reads the fixture at path.
+func Load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- Fixtures are read from paths the developer gives
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	if fixture.Event == "" || len(fixture.Payload) == 0 {
+		return nil, fmt.Errorf("invalid fixture %s: event and payload are required", path)
+	}
+	return &fixture, nil
+}
+
+// Save writes the fixture to dir as <event>-<delivery>.json and returns its path.
+func (f *Fixture) Save(dir string) (string, error) {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fileName(f.Event)+"-"+fileName(f.Delivery)+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// fileName keeps the characters of a header value that are safe in a file name.
+func fileName(value string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return -1
+	}, value)
+	if safe == "" {
+		return "unknown"
+	}
+	return safe
+}
+
+// Request builds the delivery of the fixture to url, signed with secret when it is set.
+func (f *Fixture) Request(ctx context.Context, url, secret string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(f.Payload))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range f.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, f.Event)
+	if f.Delivery != "" {
+		req.Header.Set(DeliveryHeader, f.Delivery)
+	}
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, f.Payload))
+	}
+	return req, nil
+}
+
+// Sign returns the X-Hub-Signature-256 value of payload for secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Record saves the JSON webhook deliveries the next handler accepts to dir. Deliveries it
+// rejects, such as those with invalid signatures, and bodies it does not read entirely are
+// not recorded. Failures to save are logged and never fail the delivery.
+func Record(dir string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			event := r.Header.Get(EventHeader)
+			if event == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body := &recordingBody{ReadCloser: r.Body}
+			r.Body = body
+			status := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(status, r)
+			if status.status >= http.StatusBadRequest || !body.complete || !json.Valid(body.data.Bytes()) {
+				return
+			}
+
+			fixture := &Fixture{
+				Event:    event,
+				Delivery: r.Header.Get(DeliveryHeader),
+				Payload:  json.RawMessage(body.data.Bytes()),
+			}
+			for _, name := range recordedHeaders {
+				if value := r.Header.Get(name); value != "" {
+					if fixture.Headers == nil {
+						fixture.Headers = make(map[string]string, len(recordedHeaders))
+					}
+					fixture.Headers[name] = value
+				}
+			}
+			logger := zerolog.Ctx(r.Context())
+			path, err := fixture.Save(dir)
+			if err != nil {
+				logger.Warn().Err(err).Msg(constants.LogMsgWebhookRecordFailed)
+				return
+			}
+			logger.Debug().Str("fixture", path).Msg(constants.LogMsgWebhookRecorded)
+		})
+	}
+}
+
+// recordingBody keeps what is read from a request body, and whether it was read to the end.
+type recordingBody struct {
+	io.ReadCloser
+	data     bytes.Buffer
+	complete bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.data.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.complete = true
+	}
+	return n, err
+}
+
+// statusWriter keeps the status of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
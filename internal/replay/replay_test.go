@@ -0,0 +1,123 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const payload = `{"ref": "refs/heads/main", "installation": {"id": 42}}`
+
+func delivery(t *testing.T, event string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if event != "" {
+		req.Header.Set(EventHeader, event)
+	}
+	req.Header.Set(DeliveryHeader, "../d-1")
+	req.Header.Set("X-GitHub-Hook-Installation-Target-Type", "integration")
+	req.Header.Set("X-GitHub-Hook-Installation-Target-ID", "7")
+	req.Header.Set(SignatureHeader, "sha256=recorded")
+	return req
+}
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		status   int
+		read     bool
+		recorded bool
+	}{
+		{"accepted", "push", http.StatusOK, true, true},
+		{"rejected", "push", http.StatusBadRequest, true, false},
+		{"unread", "push", http.StatusOK, false, false},
+		{"not a delivery", "", http.StatusOK, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.read {
+					_, _ = io.ReadAll(r.Body)
+				}
+				w.WriteHeader(tt.status)
+			})
+			w := httptest.NewRecorder()
+			Record(dir)(next).ServeHTTP(w, delivery(t, tt.event))
+			assert.Equal(t, tt.status, w.Code)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			if !tt.recorded {
+				assert.Empty(t, entries)
+				return
+			}
+			require.Len(t, entries, 1)
+			assert.Equal(t, "push-d-1.json", entries[0].Name())
+			fixture, err := Load(filepath.Join(dir, entries[0].Name()))
+			require.NoError(t, err)
+			assert.Equal(t, "push", fixture.Event)
+			assert.Equal(t, "../d-1", fixture.Delivery)
+			assert.JSONEq(t, payload, string(fixture.Payload))
+			assert.Equal(t, map[string]string{
+				"X-GitHub-Hook-Installation-Target-Type": "integration",
+				"X-GitHub-Hook-Installation-Target-ID":   "7",
+			}, fixture.Headers, "Signatures are not recorded")
+		})
+	}
+}
+
+func TestFixtureRequest(t *testing.T) {
+	fixture := &Fixture{
+		Event:    "push",
+		Delivery: "d-1",
+		Headers:  map[string]string{"X-GitHub-Hook-Installation-Target-ID": "7"},
+		Payload:  []byte(payload),
+	}
+	req, err := fixture.Request(context.Background(), "http://localhost:8080/", "s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, "push", req.Header.Get(EventHeader))
+	assert.Equal(t, "d-1", req.Header.Get(DeliveryHeader))
+	assert.Equal(t, "7", req.Header.Get("X-GitHub-Hook-Installation-Target-ID"))
+
+	body, err := github.ValidatePayload(req, []byte("s3cret"))
+	require.NoError(t, err, "Replays are signed with the server's secret")
+	assert.JSONEq(t, payload, string(body))
+
+	req, err = fixture.Request(context.Background(), "http://localhost:8080/", "")
+	require.NoError(t, err)
+	assert.Empty(t, req.Header.Get(SignatureHeader))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path, err := (&Fixture{Event: "push", Delivery: "d-1", Payload: []byte(payload)}).Save(dir)
+	require.NoError(t, err)
+	fixture, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "push", fixture.Event)
+
+	for name, content := range map[string]string{
+		"invalid.json":    "{",
+		"no-event.json":   `{"payload": {}}`,
+		"no-payload.json": `{"event": "push"}`,
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := Load(path)
+		assert.Error(t, err, name)
+	}
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+// Package reposcope decides which repositories GitGuard scans, so organizations can scope it
+// without changing the App's repository access.
+package reposcope
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Rules include or exclude repositories by glob. Globs are matched case-insensitively
+// against "owner/name"; a glob without a slash matches the repository name of any owner.
+type Rules struct {
+	// Include, when not empty, limits scans to matching repositories.
+	Include []string
+	// Exclude skips matching repositories, even when included.
+	Exclude []string
+	// SkipArchived skips archived repositories.
+	SkipArchived bool
+	// SkipForks skips forks, whose secrets are usually the upstream repository's.
+	SkipForks bool
+}
+
+// Repository is a repository to be scanned.
+type Repository struct {
+	// FullName is "owner/name".
+	FullName string
+	Archived bool
+	Fork     bool
+}
+
+// Scope holds the default rules and per-installation rules, which replace the defaults for
+// their installation. A nil Scope allows every repository.
+type Scope struct {
+	defaults      Rules
+	installations map[int64]Rules
+}
+
+// New validates the globs and returns a Scope.
+func New(defaults Rules, installations map[int64]Rules) (*Scope, error) {
+	for _, rules := range append([]Rules{defaults}, mapValues(installations)...) {
+		for _, pattern := range slices.Concat(rules.Include, rules.Exclude) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid repository glob %q: %w", pattern, err)
+			}
+		}
+	}
+	return &Scope{defaults: defaults, installations: installations}, nil
+}
+
+// Allowed reports whether a repository of an installation is scanned. Installation 0 uses
+// the default rules.
+func (s *Scope) Allowed(installation int64, repo Repository) bool {
+	if s == nil {
+		return true
+	}
+	rules, ok := s.installations[installation]
+	if !ok {
+		rules = s.defaults
+	}
+	if rules.SkipArchived && repo.Archived || rules.SkipForks && repo.Fork {
+		return false
+	}
+	fullName := strings.ToLower(repo.FullName)
+	if len(rules.Include) > 0 && !matchesAny(rules.Include, fullName) {
+		return false
+	}
+	return !matchesAny(rules.Exclude, fullName)
+}
+
+func matchesAny(patterns []string, fullName string) bool {
+	_, name, _ := strings.Cut(fullName, "/")
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		subject := fullName
+		if !strings.Contains(pattern, "/") {
+			subject = name
+		}
+		if ok, _ := path.Match(pattern, subject); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func mapValues(m map[int64]Rules) []Rules {
+	values := make([]Rules, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
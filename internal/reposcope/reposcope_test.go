@@ -0,0 +1,108 @@
+package reposcope
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeAllowed(t *testing.T) {
+	scope, err := New(
+		Rules{Include: []string{"prod-*", "acme/platform"}, Exclude: []string{"acme/prod-legacy"}},
+		map[int64]Rules{7: {Exclude: []string{"sandbox/*"}}},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		installation int64
+		repo         string
+		want         bool
+	}{
+		{installation: 1, repo: "acme/prod-api", want: true},
+		{installation: 1, repo: "Other/PROD-web", want: true},
+		{installation: 1, repo: "acme/platform", want: true},
+		{installation: 1, repo: "acme/prod-legacy", want: false},
+		{installation: 1, repo: "acme/docs", want: false},
+		{installation: 7, repo: "acme/docs", want: true},
+		{installation: 7, repo: "sandbox/prod-api", want: false},
+	}
+	for _, tt := range tests {
+		repo := Repository{FullName: tt.repo}
+		assert.Equal(t, tt.want, scope.Allowed(tt.installation, repo), "%d %s", tt.installation, tt.repo)
+	}
+
+	var unscoped *Scope
+	assert.True(t, unscoped.Allowed(1, Repository{FullName: "acme/docs"}))
+}
+
+func TestScopeSkipsArchivedAndForks(t *testing.T) {
+	scope, err := New(
+		Rules{SkipArchived: true, SkipForks: true},
+		map[int64]Rules{7: {SkipArchived: true}},
+	)
+	require.NoError(t, err)
+
+	assert.True(t, scope.Allowed(1, Repository{FullName: "acme/api"}))
+	assert.False(t, scope.Allowed(1, Repository{FullName: "acme/api", Archived: true}))
+	assert.False(t, scope.Allowed(1, Repository{FullName: "acme/api", Fork: true}))
+	assert.True(t, scope.Allowed(7, Repository{FullName: "acme/api", Fork: true}), "Installation rules override the defaults")
+	assert.False(t, scope.Allowed(7, Repository{FullName: "acme/api", Archived: true}))
+}
+
+func TestNewInvalidGlob(t *testing.T) {
+	_, err := New(Rules{Exclude: []string{"acme/[prod"}}, nil)
+	assert.ErrorContains(t, err, "invalid repository glob")
+}
+
+func TestSetting(t *testing.T) {
+	setting, err := NewSetting(20, []SettingOverride{
+		{Repositories: []string{"acme/monorepo"}, Value: 5},
+		{Repositories: []string{"legacy-*"}, Value: 0},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, setting.For("acme/monorepo"))
+	assert.Equal(t, 0, setting.For("acme/legacy-app"))
+	assert.Equal(t, 20, setting.For("acme/api"))
+
+	var unset *Setting
+	assert.Equal(t, 0, unset.For("acme/api"))
+
+	_, err = NewSetting(0, []SettingOverride{{Value: 1}})
+	assert.Error(t, err)
+}
+
+func TestRollout(t *testing.T) {
+	none, err := NewRollout(0, []SettingOverride{{Repositories: []string{"acme/payments-*"}, Value: 1}})
+	require.NoError(t, err)
+	assert.True(t, none.Includes("acme/payments-api"))
+	assert.False(t, none.Includes("acme/api"))
+
+	all, err := NewRollout(100, []SettingOverride{{Repositories: []string{"legacy-*"}, Value: 0}})
+	require.NoError(t, err)
+	assert.False(t, all.Includes("acme/legacy-app"))
+	assert.True(t, all.Includes("acme/api"))
+
+	// Raising the percentage only adds repositories.
+	half, err := NewRollout(50, nil)
+	require.NoError(t, err)
+	included := 0
+	for i := range 200 {
+		name := fmt.Sprintf("acme/repo-%d", i)
+		if half.Includes(name) {
+			included++
+			assert.Less(t, Bucket(name), 50)
+		}
+		assert.Equal(t, Bucket(name), Bucket(strings.ToUpper(name)))
+	}
+	assert.InDelta(t, 100, included, 30)
+
+	var unset *Rollout
+	assert.True(t, unset.Includes("acme/api"))
+
+	_, err = NewRollout(101, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package reposcope
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// Rollout selects a stable percentage of repositories, so a behavior can be ramped up
+// gradually. Repositories are bucketed by a hash of their name, so raising the percentage
+// only adds repositories. Overrides with a non-zero value always include their repositories
+// and those with zero always exclude them; the first matching override wins. A nil Rollout
+// includes every repository.
+type Rollout struct {
+	percent   int
+	overrides *Setting
+}
+
+// NewRollout validates the percentage and the overrides' globs and returns a Rollout.
+func NewRollout(percent int, overrides []SettingOverride) (*Rollout, error) {
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("rollout percentage %d must be between 0 and 100", percent)
+	}
+	// Unmatched repositories get -1, telling them apart from excluding overrides.
+	setting, err := NewSetting(-1, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return &Rollout{percent: percent, overrides: setting}, nil
+}
+
+// Includes reports whether a repository, "owner/name", is part of the rollout.
+func (r *Rollout) Includes(fullName string) bool {
+	if r == nil {
+		return true
+	}
+	if value := r.overrides.For(fullName); value >= 0 {
+		return value > 0
+	}
+	return Bucket(fullName) < r.percent
+}
+
+// Bucket returns the rollout bucket of a repository, between 0 and 99. It only depends on
+// the lowercase name, so it is the same on every replica and across restarts.
+func Bucket(fullName string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.ToLower(fullName)))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,50 @@
+package reposcope
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SettingOverride sets a value for repositories matching any of its globs, matched like
+// Rules globs.
+type SettingOverride struct {
+	Repositories []string
+	Value        int
+}
+
+// Setting is a numeric setting that can be overridden per repository. The first matching
+// override wins. A nil Setting is 0 for every repository.
+type Setting struct {
+	defaultValue int
+	overrides    []SettingOverride
+}
+
+// NewSetting validates the overrides' globs and returns a Setting.
+func NewSetting(defaultValue int, overrides []SettingOverride) (*Setting, error) {
+	for _, override := range overrides {
+		if len(override.Repositories) == 0 {
+			return nil, fmt.Errorf("repository override with value %d has no repositories", override.Value)
+		}
+		for _, pattern := range override.Repositories {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid repository glob %q: %w", pattern, err)
+			}
+		}
+	}
+	return &Setting{defaultValue: defaultValue, overrides: overrides}, nil
+}
+
+// For returns the value for a repository, "owner/name".
+func (s *Setting) For(fullName string) int {
+	if s == nil {
+		return 0
+	}
+	fullName = strings.ToLower(fullName)
+	for _, override := range s.overrides {
+		if matchesAny(override.Repositories, fullName) {
+			return override.Value
+		}
+	}
+	return s.defaultValue
+}
@@ -0,0 +1,272 @@
+// Package gitlab implements the scm provider for GitLab: push hooks, commit statuses and
+// merge request notes through the REST API v4.
+package gitlab
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/scm"
+)
+
+const (
+	// DefaultURL is the GitLab instance used when none is configured.
+	DefaultURL = "https://gitlab.com"
+
+	// HeaderToken carries the webhook secret token.
+	HeaderToken = "X-Gitlab-Token" // #nosec G101 -- This is a header name, not a secret
+	// HeaderEvent names the webhook event.
+	HeaderEvent = "X-Gitlab-Event"
+	// PushHook is the event name of push webhooks.
+	PushHook = "Push Hook"
+
+	// zeroSHA is the "after" commit of a push deleting a branch.
+	zeroSHA = "0000000000000000000000000000000000000000"
+
+	requestTimeout = 30 * time.Second
+	perPage        = 100
+	// maxDescription is GitLab's limit on commit status descriptions.
+	maxDescription = 255
+)
+
+// Commit status states.
+const (
+	statePending = "pending"
+	stateRunning = "running"
+	stateSuccess = "success"
+	stateFailed  = "failed"
+)
+
+// Provider talks to a GitLab instance with a personal, group or project access token.
+type Provider struct {
+	baseURL *url.URL
+	token   string
+	secret  string
+	client  *http.Client
+}
+
+// New creates a GitLab provider. webhookSecret is the secret token configured on the webhook.
+func New(baseURL, token, webhookSecret string) (*Provider, error) {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/api/v4/")
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab URL %q", baseURL)
+	}
+	return &Provider{
+		baseURL: u,
+		token:   token,
+		secret:  webhookSecret,
+		client:  &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// Name implements scm.Provider.
+func (p *Provider) Name() string {
+	return "gitlab"
+}
+
+// pushHook is the subset of a GitLab push hook used by GitGuard.
+type pushHook struct {
+	ObjectKind string `json:"object_kind"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	Ref        string `json:"ref"`
+	Project    struct {
+		ID                int64  `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+		DefaultBranch     string `json:"default_branch"`
+	} `json:"project"`
+	Commits []struct {
+		ID string `json:"id"`
+	} `json:"commits"`
+}
+
+// ParsePush implements scm.Provider.
+func (p *Provider) ParsePush(r *http.Request) (*scm.Push, error) {
+	token := r.Header.Get(HeaderToken)
+	if p.secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(p.secret)) != 1 {
+		return nil, scm.ErrUnauthorized
+	}
+	if r.Header.Get(HeaderEvent) != PushHook {
+		return nil, nil
+	}
+
+	var hook pushHook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		return nil, fmt.Errorf("failed to decode GitLab push hook: %w", err)
+	}
+	if hook.ObjectKind != "push" || hook.After == zeroSHA {
+		return nil, nil
+	}
+
+	push := &scm.Push{
+		Repository: scm.Repository{
+			ID:            strconv.FormatInt(hook.Project.ID, 10),
+			FullName:      hook.Project.PathWithNamespace,
+			DefaultBranch: hook.Project.DefaultBranch,
+			WebURL:        hook.Project.WebURL,
+		},
+		Ref:    hook.Ref,
+		Before: hook.Before,
+		After:  hook.After,
+	}
+	for _, commit := range hook.Commits {
+		push.Commits = append(push.Commits, commit.ID)
+	}
+	return push, nil
+}
+
+// ChangedFiles implements scm.Provider.
+func (p *Provider) ChangedFiles(ctx context.Context, repo scm.Repository, sha string) ([]scm.File, error) {
+	var files []scm.File
+	for page := "1"; page != ""; {
+		query := url.Values{"per_page": {strconv.Itoa(perPage)}, "page": {page}}
+		var diffs []struct {
+			NewPath     string `json:"new_path"`
+			DeletedFile bool   `json:"deleted_file"`
+		}
+		resp, err := p.do(ctx, http.MethodGet, p.projectPath(repo, "repository", "commits", sha, "diff"), query, nil, &diffs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get diff of commit %s: %w", sha, err)
+		}
+		for _, diff := range diffs {
+			files = append(files, scm.File{Path: diff.NewPath, Removed: diff.DeletedFile})
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return files, nil
+}
+
+// FileContent implements scm.Provider.
+func (p *Provider) FileContent(ctx context.Context, repo scm.Repository, sha, path string) (string, error) {
+	var content strings.Builder
+	_, err := p.do(ctx, http.MethodGet, p.projectPath(repo, "repository", "files", path, "raw"), url.Values{"ref": {sha}}, nil, &content)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s at %s: %w", path, sha, err)
+	}
+	return content.String(), nil
+}
+
+// StartScan implements scm.Provider.
+func (p *Provider) StartScan(ctx context.Context, repo scm.Repository, sha string) error {
+	return p.setStatus(ctx, repo, sha, stateRunning, constants.CheckRunTitleInProgress)
+}
+
+// Report implements scm.Provider. Failing results are also posted as a note on the merge
+// requests containing the commit.
+func (p *Provider) Report(ctx context.Context, repo scm.Repository, sha string, result scm.Result) error {
+	state := stateSuccess
+	if result.Conclusion == constants.ConclusionFailure || result.Conclusion == constants.ConclusionActionRequired {
+		state = stateFailed
+	}
+	if err := p.setStatus(ctx, repo, sha, state, result.Title); err != nil {
+		return err
+	}
+	if state != stateFailed {
+		return nil
+	}
+
+	var mergeRequests []struct {
+		IID int64 `json:"iid"`
+	}
+	if _, err := p.do(ctx, http.MethodGet, p.projectPath(repo, "repository", "commits", sha, "merge_requests"), nil, nil, &mergeRequests); err != nil {
+		return fmt.Errorf("failed to list merge requests of commit %s: %w", sha, err)
+	}
+	body := map[string]string{"body": fmt.Sprintf("### %s\n\n%s\n\nCommit: %s", result.Title, result.Summary, sha)}
+	for _, mr := range mergeRequests {
+		path := p.projectPath(repo, "merge_requests", strconv.FormatInt(mr.IID, 10), "notes")
+		if _, err := p.do(ctx, http.MethodPost, path, nil, body, nil); err != nil {
+			return fmt.Errorf("failed to comment on merge request !%d: %w", mr.IID, err)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) setStatus(ctx context.Context, repo scm.Repository, sha, state, description string) error {
+	if len(description) > maxDescription {
+		description = description[:maxDescription]
+	}
+	body := map[string]string{
+		"state":       state,
+		"name":        constants.CheckRunName,
+		"description": description,
+	}
+	if _, err := p.do(ctx, http.MethodPost, p.projectPath(repo, "statuses", sha), nil, body, nil); err != nil {
+		return fmt.Errorf("failed to set commit status: %w", err)
+	}
+	return nil
+}
+
+// projectPath builds an API path below the project, escaping each segment; file paths are
+// a single segment in the GitLab API.
+func (p *Provider) projectPath(repo scm.Repository, segments ...string) string {
+	escaped := []string{"projects", url.PathEscape(repo.ID)}
+	for _, segment := range segments {
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+	return strings.Join(escaped, "/")
+}
+
+// do sends an API request. JSON responses are decoded into out; a *strings.Builder receives
+// the raw body.
+func (p *Provider) do(ctx context.Context, method, path string, query url.Values, in, out any) (*http.Response, error) {
+	u, err := url.Parse(p.baseURL.String() + path)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(string(data))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", p.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("GitLab API %s %s returned %s", method, path, resp.Status)
+	}
+	switch out := out.(type) {
+	case nil:
+		_, _ = io.Copy(io.Discard, resp.Body)
+	case *strings.Builder:
+		if _, err := io.Copy(out, resp.Body); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,145 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/omercnet/gitguard/internal/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pushPayload = `{
+  "object_kind": "push",
+  "before": "95790bf891e76fee5e1747ab589903a6a1f80f22",
+  "after": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+  "ref": "refs/heads/main",
+  "project": {
+    "id": 15,
+    "path_with_namespace": "group/project",
+    "web_url": "https://gitlab.example.com/group/project",
+    "default_branch": "main"
+  },
+  "commits": [{"id": "b6568db1bc1dcd7f8b4d5a946b0b91f9dacd7327"}, {"id": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7"}]
+}`
+
+func newHook(token, event, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(body))
+	req.Header.Set(HeaderToken, token)
+	req.Header.Set(HeaderEvent, event)
+	return req
+}
+
+func TestParsePush(t *testing.T) {
+	p, err := New("", "token", "secret")
+	require.NoError(t, err)
+
+	push, err := p.ParsePush(newHook("secret", PushHook, pushPayload))
+	require.NoError(t, err)
+	require.NotNil(t, push)
+	assert.Equal(t, scm.Repository{
+		ID:            "15",
+		FullName:      "group/project",
+		DefaultBranch: "main",
+		WebURL:        "https://gitlab.example.com/group/project",
+	}, push.Repository)
+	assert.Equal(t, "refs/heads/main", push.Ref)
+	assert.Len(t, push.Commits, 2)
+
+	_, err = p.ParsePush(newHook("wrong", PushHook, pushPayload))
+	assert.True(t, errors.Is(err, scm.ErrUnauthorized))
+
+	push, err = p.ParsePush(newHook("secret", "Merge Request Hook", `{"object_kind":"merge_request"}`))
+	assert.NoError(t, err)
+	assert.Nil(t, push)
+
+	deleted := strings.Replace(pushPayload, `"after": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7"`, `"after": "`+zeroSHA+`"`, 1)
+	push, err = p.ParsePush(newHook("secret", PushHook, deleted))
+	assert.NoError(t, err)
+	assert.Nil(t, push, "Branch deletions are not scanned")
+
+	_, err = p.ParsePush(newHook("secret", PushHook, "{"))
+	assert.Error(t, err)
+}
+
+func TestProvider_API(t *testing.T) {
+	var statuses []map[string]string
+	var notes []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/projects/15/repository/commits/abc/diff", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("PRIVATE-TOKEN"))
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			_, _ = w.Write([]byte(`[{"new_path":"config/app.env"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"new_path":"old.txt","deleted_file":true}]`))
+	})
+	mux.HandleFunc("GET /api/v4/projects/15/repository/files/{path}/raw", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ref") != "abc" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "/api/v4/projects/15/repository/files/config%2Fapp.env/raw", r.URL.EscapedPath())
+		_, _ = w.Write([]byte("TOKEN=value"))
+	})
+	mux.HandleFunc("POST /api/v4/projects/15/statuses/abc", func(_ http.ResponseWriter, r *http.Request) {
+		var status map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		statuses = append(statuses, status)
+	})
+	mux.HandleFunc("GET /api/v4/projects/15/repository/commits/abc/merge_requests", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"iid":7}]`))
+	})
+	mux.HandleFunc("POST /api/v4/projects/15/merge_requests/7/notes", func(_ http.ResponseWriter, r *http.Request) {
+		var note map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&note))
+		notes = append(notes, note["body"])
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	p, err := New(server.URL, "token", "secret")
+	require.NoError(t, err)
+	ctx := context.Background()
+	repo := scm.Repository{ID: "15", FullName: "group/project"}
+
+	files, err := p.ChangedFiles(ctx, repo, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, []scm.File{{Path: "config/app.env"}, {Path: "old.txt", Removed: true}}, files)
+
+	content, err := p.FileContent(ctx, repo, "abc", "config/app.env")
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN=value", content)
+
+	require.NoError(t, p.StartScan(ctx, repo, "abc"))
+	require.NoError(t, p.Report(ctx, repo, "abc", scm.Result{Conclusion: constants.ConclusionNeutral, Title: "Low"}))
+	assert.Empty(t, notes, "Passing results are not commented")
+
+	require.NoError(t, p.Report(ctx, repo, "abc", scm.Result{
+		Conclusion: constants.ConclusionFailure,
+		Title:      constants.CheckRunTitleSecrets,
+		Summary:    "Found 1 potential secret(s)",
+		Findings:   1,
+	}))
+	require.Len(t, statuses, 3)
+	assert.Equal(t, []string{stateRunning, stateSuccess, stateFailed},
+		[]string{statuses[0]["state"], statuses[1]["state"], statuses[2]["state"]})
+	assert.Equal(t, constants.CheckRunName, statuses[0]["name"])
+	require.Len(t, notes, 1)
+	assert.Contains(t, notes[0], "Found 1 potential secret(s)")
+
+	_, err = p.FileContent(ctx, repo, "missing", "nope")
+	assert.Error(t, err)
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	_, err := New("not a url", "token", "secret")
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+// Package scm abstracts the source code hosts GitGuard guards: parsing their push webhooks,
+// retrieving the files changed by a commit and reporting scan results back.
+package scm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrUnauthorized is returned when a webhook fails authentication.
+var ErrUnauthorized = errors.New("webhook authentication failed")
+
+// Repository identifies a repository on a provider.
+type Repository struct {
+	// ID is the provider's identifier used in API calls, e.g. a GitLab project ID.
+	ID            string
+	FullName      string
+	DefaultBranch string
+	WebURL        string
+}
+
+// Push is a push webhook.
+type Push struct {
+	Repository Repository
+	Ref        string
+	Before     string
+	After      string
+	// Commits are the SHAs of the pushed commits, oldest first.
+	Commits []string
+}
+
+// File is a file changed by a commit.
+type File struct {
+	Path    string
+	Removed bool
+}
+
+// Result is the outcome of scanning a commit.
+type Result struct {
+	// Conclusion is one of the constants.Conclusion* values.
+	Conclusion string
+	Title      string
+	Summary    string
+	Findings   int
+}
+
+// Provider connects the scanning core to a source code host.
+type Provider interface {
+	// Name identifies the provider in logs and notifications.
+	Name() string
+	// ParsePush authenticates a webhook request and parses it. Requests that are not push
+	// events return a nil push and no error.
+	ParsePush(r *http.Request) (*Push, error)
+	// ChangedFiles lists the files changed by a commit.
+	ChangedFiles(ctx context.Context, repo Repository, sha string) ([]File, error)
+	// FileContent returns the content of a file at a commit.
+	FileContent(ctx context.Context, repo Repository, sha, path string) (string, error)
+	// StartScan marks a commit as being scanned.
+	StartScan(ctx context.Context, repo Repository, sha string) error
+	// Report publishes the result of scanning a commit.
+	Report(ctx context.Context, repo Repository, sha string, result Result) error
+}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/awsauth"
+)
+
+const (
+	awsService     = "secretsmanager"
+	awsTarget      = "secretsmanager.GetSecretValue"
+	awsContentType = "application/x-amz-json-1.1"
+)
+
+// awsProvider reads secrets from AWS Secrets Manager using static credentials from the environment.
+// References are a secret name or ARN with an optional "#key" for JSON secrets.
+type awsProvider struct {
+	client   *http.Client
+	endpoint string
+	signer   *awsauth.Signer
+}
+
+func newAWSProvider(client *http.Client) (*awsProvider, error) {
+	region, err := requireEnv(AWSRegionEnv, BackendAWS)
+	if err != nil {
+		return nil, err
+	}
+	accessKeyID, err := requireEnv(AWSAccessKeyIDEnv, BackendAWS)
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := requireEnv(AWSSecretKeyEnv, BackendAWS)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := os.Getenv(AWSEndpointURLEnv)
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", awsService, region)
+	}
+
+	return &awsProvider{
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		signer: &awsauth.Signer{
+			Service:      awsService,
+			Region:       region,
+			AccessKeyID:  accessKeyID,
+			SecretKey:    secretKey,
+			SessionToken: os.Getenv(AWSSessionTokenEnv),
+		},
+	}, nil
+}
+
+// Fetch calls GetSecretValue for the referenced secret.
+func (p *awsProvider) Fetch(ctx context.Context, ref string) (string, error) {
+	secretID, key := splitRef(ref)
+
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+	req.Header.Set("Content-Type", awsContentType)
+	req.Header.Set("X-Amz-Target", awsTarget)
+	p.signer.Sign(req, payload)
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	return selectKey(resp.SecretString, key, ref)
+}
@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// gcpProvider reads secrets from GCP Secret Manager.
+// References have the form "projects/<project>/secrets/<name>/versions/<version>[#key]".
+// Access tokens come from GCP_ACCESS_TOKEN or, when unset, the metadata server (GCE/GKE workload identity).
+type gcpProvider struct {
+	client   *http.Client
+	baseURL  string
+	tokenURL string
+}
+
+func newGCPProvider(client *http.Client) *gcpProvider {
+	return &gcpProvider{
+		client:   client,
+		baseURL:  gcpSecretManagerURL,
+		tokenURL: gcpMetadataTokenURL,
+	}
+}
+
+// Fetch accesses a secret version in GCP Secret Manager.
+func (p *gcpProvider) Fetch(ctx context.Context, ref string) (string, error) {
+	name, key := splitRef(ref)
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	return selectKey(string(data), key, ref)
+}
+
+func (p *gcpProvider) accessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv(GCPAccessTokenEnv); token != "" {
+		return token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build metadata token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to decode metadata token: %w", err)
+	}
+	return token.AccessToken, nil
+}
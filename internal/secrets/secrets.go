@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Supported secret manager backends.
+const (
+	BackendVault = "vault"
+	BackendAWS   = "aws"
+	BackendGCP   = "gcp"
+)
+
+const (
+	// Environment variable names used by the backends.
+	VaultAddrEnv         = "VAULT_ADDR"
+	VaultTokenEnv        = "VAULT_TOKEN" // #nosec G101 -- This is an env var name, not a secret
+	VaultNamespaceEnv    = "VAULT_NAMESPACE"
+	AWSRegionEnv         = "AWS_REGION"
+	AWSAccessKeyIDEnv    = "AWS_ACCESS_KEY_ID"
+	AWSSecretKeyEnv      = "AWS_SECRET_ACCESS_KEY" // #nosec G101 -- This is an env var name, not a secret
+	AWSSessionTokenEnv   = "AWS_SESSION_TOKEN"     // #nosec G101 -- This is an env var name, not a secret
+	AWSEndpointURLEnv    = "AWS_ENDPOINT_URL"
+	GCPAccessTokenEnv    = "GCP_ACCESS_TOKEN" // #nosec G101 -- This is an env var name, not a secret
+	defaultClientTimeout = 10 * time.Second
+	maxResponseBytes     = 1 << 20
+
+	// Error messages.
+	ErrUnknownBackend   = "unknown secrets backend %q"
+	ErrMissingSetting   = "%s is required for the %s secrets backend"
+	ErrFetchSecret      = "failed to fetch secret %q: %w"
+	ErrUnexpectedStatus = "unexpected status %d from %s"
+	ErrSecretKeyMissing = "key %q not found in secret %q"
+)
+
+// Provider fetches secret values from an external secret manager.
+type Provider interface {
+	// Fetch returns the secret identified by ref. The format of ref is backend specific;
+	// a "#key" suffix selects a field of a structured (JSON/KV) secret.
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+// NewProvider creates the provider for the named backend, configured from the environment.
+func NewProvider(backend string) (Provider, error) {
+	client := &http.Client{Timeout: defaultClientTimeout}
+
+	switch strings.ToLower(backend) {
+	case BackendVault:
+		return newVaultProvider(client)
+	case BackendAWS:
+		return newAWSProvider(client)
+	case BackendGCP:
+		return newGCPProvider(client), nil
+	default:
+		return nil, fmt.Errorf(ErrUnknownBackend, backend)
+	}
+}
+
+// splitRef splits a "name#key" reference into its name and optional key.
+func splitRef(ref string) (string, string) {
+	name, key, _ := strings.Cut(ref, "#")
+	return name, key
+}
+
+// selectKey extracts key from a JSON object secret, or returns the raw value when key is empty.
+func selectKey(raw, key, ref string) (string, error) {
+	if key == "" {
+		return raw, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+	return lookupKey(fields, key, ref)
+}
+
+func lookupKey(fields map[string]any, key, ref string) (string, error) {
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf(ErrSecretKeyMissing, key, ref)
+	}
+	return value, nil
+}
+
+// doRequest executes req and returns the response body, failing on non-2xx statuses.
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", req.URL.Host, err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf(ErrUnexpectedStatus, resp.StatusCode, req.URL.Host)
+	}
+	return body, nil
+}
+
+func requireEnv(name, backend string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf(ErrMissingSetting, name, backend)
+	}
+	return value, nil
+}
@@ -0,0 +1,117 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omercnet/gitguard/internal/awsauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider_UnknownBackend(t *testing.T) {
+	_, err := NewProvider("keychain")
+	assert.Error(t, err, "Should reject unknown backends")
+}
+
+func TestNewProvider_MissingSettings(t *testing.T) {
+	t.Setenv(VaultAddrEnv, "")
+	t.Setenv(AWSRegionEnv, "")
+
+	_, err := NewProvider(BackendVault)
+	assert.ErrorContains(t, err, VaultAddrEnv)
+
+	_, err = NewProvider(BackendAWS)
+	assert.ErrorContains(t, err, AWSRegionEnv)
+}
+
+func TestVaultProvider_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		switch r.URL.Path {
+		case "/v1/secret/data/gitguard":
+			_, _ = w.Write([]byte(`{"data":{"data":{"webhook_secret":"kv2-secret"},"metadata":{"version":3}}}`))
+		case "/v1/kv/gitguard":
+			_, _ = w.Write([]byte(`{"data":{"value":"kv1-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv(VaultAddrEnv, server.URL)
+	t.Setenv(VaultTokenEnv, "vault-token")
+
+	provider, err := NewProvider(BackendVault)
+	require.NoError(t, err)
+
+	secret, err := provider.Fetch(context.Background(), "secret/data/gitguard#webhook_secret")
+	require.NoError(t, err)
+	assert.Equal(t, "kv2-secret", secret)
+
+	secret, err = provider.Fetch(context.Background(), "kv/gitguard")
+	require.NoError(t, err)
+	assert.Equal(t, "kv1-secret", secret, "Should default to the value key")
+
+	_, err = provider.Fetch(context.Background(), "secret/data/gitguard#missing")
+	assert.Error(t, err, "Should fail on missing keys")
+
+	_, err = provider.Fetch(context.Background(), "secret/data/other#value")
+	assert.Error(t, err, "Should fail on non-2xx responses")
+}
+
+func TestAWSProvider_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, awsTarget, r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, awsauth.Algorithm+" Credential=AKIDEXAMPLE/"), auth)
+		assert.Contains(t, auth, "/us-east-1/secretsmanager/aws4_request")
+		assert.Contains(t, auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target")
+		_, _ = w.Write([]byte(`{"SecretString":"{\"private_key\":\"pem-data\"}"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(AWSRegionEnv, "us-east-1")
+	t.Setenv(AWSAccessKeyIDEnv, "AKIDEXAMPLE")
+	t.Setenv(AWSSecretKeyEnv, "example-secret")
+	t.Setenv(AWSSessionTokenEnv, "session")
+	t.Setenv(AWSEndpointURLEnv, server.URL)
+
+	provider, err := NewProvider(BackendAWS)
+	require.NoError(t, err)
+
+	secret, err := provider.Fetch(context.Background(), "gitguard/credentials#private_key")
+	require.NoError(t, err)
+	assert.Equal(t, "pem-data", secret)
+}
+
+func TestGCPProvider_Fetch(t *testing.T) {
+	payload := base64.StdEncoding.EncodeToString([]byte("gcp-secret"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_, _ = w.Write([]byte(`{"access_token":"metadata-token"}`))
+			return
+		}
+		assert.Equal(t, "Bearer metadata-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/projects/p/secrets/webhook/versions/latest:access", r.URL.Path)
+		_, _ = w.Write([]byte(`{"payload":{"data":"` + payload + `"}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(GCPAccessTokenEnv, "")
+	provider := &gcpProvider{
+		client:   server.Client(),
+		baseURL:  server.URL + "/",
+		tokenURL: server.URL + "/token",
+	}
+
+	secret, err := provider.Fetch(context.Background(), "projects/p/secrets/webhook")
+	require.NoError(t, err)
+	assert.Equal(t, "gcp-secret", secret)
+}
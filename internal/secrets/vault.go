@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// vaultProvider reads secrets from a HashiCorp Vault KV (v1 or v2) engine.
+// References have the form "<mount>/data/<path>#<key>" for KV v2 or "<mount>/<path>#<key>" for KV v1.
+type vaultProvider struct {
+	client    *http.Client
+	addr      string
+	token     string
+	namespace string
+}
+
+func newVaultProvider(client *http.Client) (*vaultProvider, error) {
+	addr, err := requireEnv(VaultAddrEnv, BackendVault)
+	if err != nil {
+		return nil, err
+	}
+	token, err := requireEnv(VaultTokenEnv, BackendVault)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vaultProvider{
+		client:    client,
+		addr:      strings.TrimSuffix(addr, "/"),
+		token:     token,
+		namespace: os.Getenv(VaultNamespaceEnv),
+	}, nil
+}
+
+// Fetch reads a field from a Vault KV secret.
+func (p *vaultProvider) Fetch(ctx context.Context, ref string) (string, error) {
+	path, key := splitRef(ref)
+	if key == "" {
+		key = "value"
+	}
+
+	url := p.addr + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf(ErrFetchSecret, ref, err)
+	}
+
+	// KV v2 nests the secret fields under data.data.
+	fields := resp.Data
+	if nested, ok := resp.Data["data"].(map[string]any); ok {
+		if _, hasMeta := resp.Data["metadata"]; hasMeta {
+			fields = nested
+		}
+	}
+
+	return lookupKey(fields, key, ref)
+}
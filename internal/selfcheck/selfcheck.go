@@ -0,0 +1,278 @@
+// Package selfcheck verifies that the GitHub App can authenticate and that its installations
+// grant the permissions GitGuard needs, so misconfigured installs are reported at startup
+// instead of failing on their first webhook.
+package selfcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// Permission access levels.
+const (
+	Read  = "read"
+	Write = "write"
+)
+
+// RequiredPermissions are the permissions every scan needs: check runs for results,
+// contents to read changed files and issues for findings.
+var RequiredPermissions = map[string]string{
+	"checks":   Write,
+	"contents": Read,
+	"issues":   Write,
+}
+
+// AppClientCreator creates clients authenticated as the App; *keyring.KeyRing implements it.
+type AppClientCreator interface {
+	NewAppClient() (*github.Client, error)
+}
+
+// Problem is a missing or insufficient permission, or a suspended installation.
+type Problem struct {
+	InstallationID int64  `json:"installation_id"`
+	Account        string `json:"account"`
+	Permission     string `json:"permission,omitempty"`
+	Granted        string `json:"granted,omitempty"`
+	Required       string `json:"required,omitempty"`
+	Suspended      bool   `json:"suspended,omitempty"`
+	// SettingsURL is where the account accepts updated permissions.
+	SettingsURL string `json:"settings_url,omitempty"`
+}
+
+// String describes the problem and how to fix it.
+func (p Problem) String() string {
+	if p.Suspended {
+		return fmt.Sprintf("installation %d on %s is suspended; unsuspend it at %s",
+			p.InstallationID, p.Account, p.SettingsURL)
+	}
+	granted := p.Granted
+	if granted == "" {
+		granted = "none"
+	}
+	return fmt.Sprintf("installation %d on %s grants %s:%s but %s:%s is required; "+
+		"add the permission in the App settings and accept it at %s",
+		p.InstallationID, p.Account, p.Permission, granted, p.Permission, p.Required, p.SettingsURL)
+}
+
+// Report is the result of a self-check.
+type Report struct {
+	App           string    `json:"app"`
+	Installations int       `json:"installations"`
+	Problems      []Problem `json:"problems,omitempty"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// DefaultDependencyTimeout bounds each dependency ping when no timeout is configured.
+const DefaultDependencyTimeout = 2 * time.Second
+
+// Pinger is a backend, such as the scan cache's Redis or the event bus, whose availability
+// readiness probes check.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Dependency is a named backend checked by readiness probes.
+type Dependency struct {
+	Name   string
+	Pinger Pinger
+}
+
+// DependencyStatus is the result of pinging a dependency.
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Checker runs self-checks against the GitHub API.
+type Checker struct {
+	Clients AppClientCreator
+	// Required maps permission names to the minimum access level; nil uses RequiredPermissions.
+	Required map[string]string
+	// TTL caches the last result of Cached, so readiness probes do not spend API rate limit.
+	TTL time.Duration
+	// Dependencies, when set, are pinged on every readiness probe; the probe fails when one
+	// of them does not answer.
+	Dependencies []Dependency
+	// DependencyTimeout bounds each ping; zero uses DefaultDependencyTimeout.
+	DependencyTimeout time.Duration
+
+	mu     sync.Mutex
+	report *Report
+	err    error
+}
+
+// Check authenticates as the App, lists its installations and verifies their permissions.
+// An error means the App itself is misconfigured; installation problems are in the report.
+func (c *Checker) Check(ctx context.Context) (*Report, error) {
+	client, err := c.Clients.NewAppClient()
+	if err != nil {
+		return nil, fmt.Errorf("create app client: %w", err)
+	}
+	app, _, err := client.Apps.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("authenticate as app: %w", err)
+	}
+
+	report := &Report{App: app.GetSlug(), CheckedAt: time.Now()}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		installations, resp, err := client.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list installations: %w", err)
+		}
+		for _, installation := range installations {
+			report.Installations++
+			report.Problems = append(report.Problems, c.checkInstallation(installation)...)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return report, nil
+}
+
+// Cached returns the last result when it is younger than the TTL and runs Check otherwise.
+func (c *Checker) Cached(ctx context.Context) (*Report, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.report != nil && time.Since(c.report.CheckedAt) < c.TTL {
+		return c.report, c.err
+	}
+	report, err := c.Check(ctx)
+	if err != nil {
+		report = &Report{CheckedAt: time.Now()}
+	}
+	c.report, c.err = report, err
+	return report, err
+}
+
+func (c *Checker) checkInstallation(installation *github.Installation) []Problem {
+	base := Problem{
+		InstallationID: installation.GetID(),
+		Account:        installation.GetAccount().GetLogin(),
+		SettingsURL:    installation.GetHTMLURL(),
+	}
+	if installation.SuspendedAt != nil {
+		problem := base
+		problem.Suspended = true
+		return []Problem{problem}
+	}
+
+	granted := permissionLevels(installation.GetPermissions())
+	required := c.Required
+	if required == nil {
+		required = RequiredPermissions
+	}
+	names := make([]string, 0, len(required))
+	for name := range required {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []Problem
+	for _, name := range names {
+		if satisfies(granted[name], required[name]) {
+			continue
+		}
+		problem := base
+		problem.Permission = name
+		problem.Granted = granted[name]
+		problem.Required = required[name]
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// permissionLevels maps permission names, as in the API, to their granted access level.
+func permissionLevels(permissions *github.InstallationPermissions) map[string]string {
+	levels := map[string]string{}
+	if permissions == nil {
+		return levels
+	}
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return levels
+	}
+	_ = json.Unmarshal(data, &levels)
+	return levels
+}
+
+// satisfies reports whether a granted access level meets the required one; write implies read.
+func satisfies(granted, required string) bool {
+	return granted == required || granted == Write && required == Read
+}
+
+// PingDependencies pings every dependency concurrently and returns their statuses in order.
+func (c *Checker) PingDependencies(ctx context.Context) []DependencyStatus {
+	timeout := c.DependencyTimeout
+	if timeout <= 0 {
+		timeout = DefaultDependencyTimeout
+	}
+	statuses := make([]DependencyStatus, len(c.Dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range c.Dependencies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := dependency.Pinger.Ping(pingCtx)
+			statuses[i] = DependencyStatus{
+				Name: dependency.Name, Status: "ok", LatencyMS: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				statuses[i].Status, statuses[i].Error = "unavailable", err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// readiness is the /readyz response body.
+type readiness struct {
+	Status       string             `json:"status"`
+	Error        string             `json:"error,omitempty"`
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+	*Report
+}
+
+// ServeHTTP serves the cached self-check as a readiness probe: 503 when the App cannot
+// authenticate or a dependency does not answer, and 200 otherwise, with status "degraded"
+// when installations have problems.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report, err := c.Cached(r.Context())
+	body := readiness{Status: "ok", Report: report, Dependencies: c.PingDependencies(r.Context())}
+	code := http.StatusOK
+	var failed []string
+	for _, status := range body.Dependencies {
+		if status.Error != "" {
+			failed = append(failed, status.Name)
+		}
+	}
+	switch {
+	case err != nil:
+		body.Status, body.Error, code = "unavailable", err.Error(), http.StatusServiceUnavailable
+	case len(failed) > 0:
+		body.Status, code = "unavailable", http.StatusServiceUnavailable
+		body.Error = "unavailable dependencies: " + strings.Join(failed, ", ")
+	case len(report.Problems) > 0:
+		body.Status = "degraded"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
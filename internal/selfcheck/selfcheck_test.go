@@ -0,0 +1,149 @@
+package selfcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClients struct {
+	baseURL string
+}
+
+func (f fakeClients) NewAppClient() (*github.Client, error) {
+	client := github.NewClient(nil)
+	u, err := url.Parse(f.baseURL + "/")
+	if err != nil {
+		return nil, err
+	}
+	client.BaseURL = u
+	return client, nil
+}
+
+func newGitHub(t *testing.T, installations string) (*httptest.Server, *int) {
+	t.Helper()
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /app", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"id": 1, "slug": "gitguard"}`)
+	})
+	mux.HandleFunc("GET /app/installations", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, installations)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestCheck(t *testing.T) {
+	server, _ := newGitHub(t, `[
+		{"id": 1, "account": {"login": "good"}, "permissions": {"checks": "write", "contents": "write", "issues": "write"}},
+		{"id": 2, "account": {"login": "readonly"}, "html_url": "https://github.com/settings/installations/2",
+		 "permissions": {"checks": "read", "contents": "read"}},
+		{"id": 3, "account": {"login": "paused"}, "suspended_at": "2024-01-01T00:00:00Z"}
+	]`)
+
+	checker := &Checker{Clients: fakeClients{server.URL}}
+	report, err := checker.Check(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "gitguard", report.App)
+	assert.Equal(t, 3, report.Installations)
+	require.Len(t, report.Problems, 3)
+	assert.Equal(t, Problem{
+		InstallationID: 2, Account: "readonly", Permission: "checks", Granted: Read, Required: Write,
+		SettingsURL: "https://github.com/settings/installations/2",
+	}, report.Problems[0])
+	assert.Equal(t, "issues", report.Problems[1].Permission)
+	assert.Empty(t, report.Problems[1].Granted)
+	assert.Contains(t, report.Problems[1].String(), "grants issues:none but issues:write is required")
+	assert.True(t, report.Problems[2].Suspended)
+}
+
+func TestCheckAuthenticationFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := (&Checker{Clients: fakeClients{server.URL}}).Check(context.Background())
+	assert.ErrorContains(t, err, "authenticate as app")
+}
+
+func TestCachedReusesResult(t *testing.T) {
+	server, calls := newGitHub(t, `[]`)
+
+	checker := &Checker{Clients: fakeClients{server.URL}, TTL: time.Minute}
+	for range 3 {
+		_, err := checker.Cached(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, *calls)
+}
+
+func TestServeHTTP(t *testing.T) {
+	server, _ := newGitHub(t, `[{"id": 2, "account": {"login": "readonly"}, "permissions": {"contents": "read"}}]`)
+
+	rec := httptest.NewRecorder()
+	(&Checker{Clients: fakeClients{server.URL}}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"degraded"`)
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+	rec = httptest.NewRecorder()
+	(&Checker{Clients: fakeClients{down.URL}}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"unavailable"`)
+}
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error { return f(ctx) }
+
+func TestServeHTTPDependencies(t *testing.T) {
+	server, _ := newGitHub(t, `[]`)
+	healthy := pingerFunc(func(context.Context) error { return nil })
+	hanging := pingerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	checker := &Checker{
+		Clients:           fakeClients{server.URL},
+		Dependencies:      []Dependency{{Name: "scan_cache", Pinger: healthy}, {Name: "event_bus", Pinger: hanging}},
+		DependencyTimeout: 10 * time.Millisecond,
+	}
+	rec := httptest.NewRecorder()
+	checker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var body struct {
+		Status       string             `json:"status"`
+		Error        string             `json:"error"`
+		Dependencies []DependencyStatus `json:"dependencies"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "unavailable", body.Status)
+	assert.Equal(t, "unavailable dependencies: event_bus", body.Error)
+	require.Len(t, body.Dependencies, 2)
+	assert.Equal(t, "ok", body.Dependencies[0].Status)
+	assert.Equal(t, "event_bus", body.Dependencies[1].Name)
+	assert.Contains(t, body.Dependencies[1].Error, "deadline exceeded")
+
+	checker.Dependencies = checker.Dependencies[:1]
+	rec = httptest.NewRecorder()
+	checker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"scan_cache","status":"ok"`)
+}
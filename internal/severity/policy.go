@@ -0,0 +1,28 @@
+package severity
+
+import "github.com/omercnet/gitguard/internal/constants"
+
+// Policy maps the highest severity found by a scan to a check run conclusion.
+// The zero value reproduces the binary behaviour: success when clean, failure otherwise.
+type Policy struct {
+	// NeutralMax makes scans whose findings are all at or below this severity conclude
+	// neutral instead of failure. None disables the neutral conclusion.
+	NeutralMax Level
+	// ActionRequiredMin makes scans with any finding at or above this severity conclude
+	// action_required. None disables the action_required conclusion.
+	ActionRequiredMin Level
+}
+
+// Conclusion returns the check run conclusion for a scan whose most severe finding is highest.
+func (p Policy) Conclusion(highest Level) string {
+	switch {
+	case highest == None:
+		return constants.ConclusionSuccess
+	case p.ActionRequiredMin != None && highest >= p.ActionRequiredMin:
+		return constants.ConclusionActionRequired
+	case p.NeutralMax != None && highest <= p.NeutralMax:
+		return constants.ConclusionNeutral
+	default:
+		return constants.ConclusionFailure
+	}
+}
@@ -0,0 +1,131 @@
+package severity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// Level is the severity assigned to a finding.
+type Level int
+
+// Severity levels, ordered from least to most severe. None is used to disable thresholds.
+const (
+	None Level = iota
+	Low
+	Medium
+	High
+	Critical
+)
+
+var levelNames = map[Level]string{
+	None:     "none",
+	Low:      "low",
+	Medium:   "medium",
+	High:     "high",
+	Critical: "critical",
+}
+
+// String returns the lowercase name of the level.
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Parse converts a level name (case-insensitive) to a Level. An empty string parses as None.
+func Parse(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return None, nil
+	}
+	for level, levelName := range levelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return None, fmt.Errorf("unknown severity %q", name)
+}
+
+// defaultRules assigns severities to well-known gitleaks rules. Generic rules are the main
+// source of false positives and default to low; private keys are always critical. Findings
+// in GitHub Actions workflows run with repository credentials and are always high.
+var defaultRules = map[string]Level{
+	"generic-api-key": Low,
+	"private-key":     Critical,
+
+	"github-actions-hardcoded-secret":            High,
+	"github-actions-pull-request-target-secrets": High,
+}
+
+// TagVerified is set on findings whose secret was validated, such as private keys that
+// parse. They are rated Critical whatever their rule's severity.
+const TagVerified = "verified"
+
+// Overrider assigns severities to individual findings, e.g. by file path.
+type Overrider interface {
+	Severity(finding report.Finding) (Level, bool)
+}
+
+// Classifier assigns a severity to findings based on their rule ID.
+type Classifier struct {
+	// Default is the severity of rules without an explicit mapping.
+	Default Level
+	// Rules maps gitleaks rule IDs to severities, overriding the built-in defaults.
+	Rules map[string]Level
+	// Overrides, when set, takes precedence over the rule mapping.
+	Overrides Overrider
+}
+
+// NewClassifier returns a classifier defaulting to High with the given rule overrides.
+func NewClassifier(rules map[string]Level) *Classifier {
+	return &Classifier{Default: High, Rules: rules}
+}
+
+// Classify returns the severity of a finding. A nil classifier rates every finding High.
+func (c *Classifier) Classify(finding report.Finding) Level {
+	if c == nil {
+		return High
+	}
+	if c.Overrides != nil {
+		if level, ok := c.Overrides.Severity(finding); ok {
+			return level
+		}
+	}
+	if slices.Contains(finding.Tags, TagVerified) {
+		return Critical
+	}
+	if level, ok := c.Rules[finding.RuleID]; ok {
+		return level
+	}
+	if level, ok := defaultRules[finding.RuleID]; ok {
+		return level
+	}
+	if c.Default == None {
+		return High
+	}
+	return c.Default
+}
+
+// Max returns the highest severity among findings, or None when there are no findings.
+func (c *Classifier) Max(findings []report.Finding) Level {
+	highest := None
+	for _, finding := range findings {
+		if level := c.Classify(finding); level > highest {
+			highest = level
+		}
+	}
+	return highest
+}
+
+// Counts returns how many findings fall into each severity.
+func (c *Classifier) Counts(findings []report.Finding) map[Level]int {
+	counts := make(map[Level]int)
+	for _, finding := range findings {
+		counts[c.Classify(finding)]++
+	}
+	return counts
+}
@@ -0,0 +1,138 @@
+package severity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Level
+		wantErr  bool
+	}{
+		{"", None, false},
+		{"low", Low, false},
+		{"MEDIUM", Medium, false},
+		{" high ", High, false},
+		{"critical", Critical, false},
+		{"severe", None, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			level, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, level)
+		})
+	}
+}
+
+func TestLevel_String(t *testing.T) {
+	assert.Equal(t, "critical", Critical.String())
+	assert.Equal(t, "none", None.String())
+	assert.Equal(t, "level(42)", Level(42).String())
+}
+
+func TestClassifier_Classify(t *testing.T) {
+	classifier := NewClassifier(map[string]Level{"aws-access-token": Critical, "generic-api-key": Medium})
+
+	assert.Equal(t, Critical, classifier.Classify(report.Finding{RuleID: "aws-access-token"}))
+	assert.Equal(t, Medium, classifier.Classify(report.Finding{RuleID: "generic-api-key"}),
+		"Configured rules should override built-in defaults")
+	assert.Equal(t, Critical, classifier.Classify(report.Finding{RuleID: "private-key"}))
+	assert.Equal(t, High, classifier.Classify(report.Finding{RuleID: "slack-webhook"}))
+	assert.Equal(t, Critical, classifier.Classify(report.Finding{RuleID: "generic-api-key", Tags: []string{TagVerified}}),
+		"Verified findings should be critical whatever their rule")
+
+	classifier.Default = Low
+	assert.Equal(t, Low, classifier.Classify(report.Finding{RuleID: "slack-webhook"}))
+
+	var nilClassifier *Classifier
+	assert.Equal(t, High, nilClassifier.Classify(report.Finding{RuleID: "generic-api-key"}))
+}
+
+type overriderFunc func(report.Finding) (Level, bool)
+
+func (f overriderFunc) Severity(finding report.Finding) (Level, bool) {
+	return f(finding)
+}
+
+func TestClassifier_Overrides(t *testing.T) {
+	classifier := NewClassifier(map[string]Level{"generic-api-key": Medium})
+	classifier.Overrides = overriderFunc(func(finding report.Finding) (Level, bool) {
+		return Critical, finding.File == "infra/main.tf"
+	})
+
+	assert.Equal(t, Critical, classifier.Classify(report.Finding{RuleID: "generic-api-key", File: "infra/main.tf"}))
+	assert.Equal(t, Medium, classifier.Classify(report.Finding{RuleID: "generic-api-key", File: "src/app.go"}))
+}
+
+func TestClassifier_MaxAndCounts(t *testing.T) {
+	classifier := NewClassifier(nil)
+	findings := []report.Finding{
+		{RuleID: "generic-api-key"},
+		{RuleID: "generic-api-key"},
+		{RuleID: "github-pat"},
+	}
+
+	assert.Equal(t, High, classifier.Max(findings))
+	assert.Equal(t, None, classifier.Max(nil))
+	assert.Equal(t, map[Level]int{Low: 2, High: 1}, classifier.Counts(findings))
+}
+
+func TestPolicy_Conclusion(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		highest  Level
+		expected string
+	}{
+		{"clean", Policy{}, None, constants.ConclusionSuccess},
+		{"default policy fails", Policy{}, Low, constants.ConclusionFailure},
+		{"low findings neutral", Policy{NeutralMax: Low}, Low, constants.ConclusionNeutral},
+		{"above neutral max fails", Policy{NeutralMax: Low}, Medium, constants.ConclusionFailure},
+		{"critical action required", Policy{ActionRequiredMin: Critical}, Critical, constants.ConclusionActionRequired},
+		{"below action required fails", Policy{ActionRequiredMin: Critical}, High, constants.ConclusionFailure},
+		{"action required wins overlap", Policy{NeutralMax: High, ActionRequiredMin: High}, High,
+			constants.ConclusionActionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Conclusion(tt.highest))
+		})
+	}
+}
+
+func TestSLA(t *testing.T) {
+	sla := SLA{Critical: 24 * time.Hour, High: 7 * 24 * time.Hour}
+	seen := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	due, ok := sla.Due(Critical, seen)
+	require.True(t, ok)
+	assert.Equal(t, seen.Add(24*time.Hour), due)
+	_, ok = sla.Due(Low, seen)
+	assert.False(t, ok, "Severities without a window have no SLA")
+
+	assert.True(t, sla.Breached(Critical, seen, seen.Add(25*time.Hour)))
+	assert.False(t, sla.Breached(High, seen, seen.Add(25*time.Hour)))
+	assert.False(t, sla.Breached(Low, seen, seen.Add(365*24*time.Hour)))
+	assert.False(t, SLA(nil).Breached(Critical, seen, seen.Add(365*24*time.Hour)))
+}
+
+func TestClassifyWorkflowRules(t *testing.T) {
+	classifier := &Classifier{Default: Low}
+	for _, ruleID := range []string{"github-actions-hardcoded-secret", "github-actions-pull-request-target-secrets"} {
+		assert.Equal(t, High, classifier.Classify(report.Finding{RuleID: ruleID}), ruleID)
+	}
+}
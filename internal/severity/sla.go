@@ -0,0 +1,24 @@
+package severity
+
+import "time"
+
+// SLA is how long findings of each severity may stay open. Severities without a window have
+// no SLA.
+type SLA map[Level]time.Duration
+
+// Due returns when findings of level first seen at since must be resolved, and whether level
+// has an SLA.
+func (s SLA) Due(level Level, since time.Time) (time.Time, bool) {
+	window, ok := s[level]
+	if !ok || window <= 0 {
+		return time.Time{}, false
+	}
+	return since.Add(window), true
+}
+
+// Breached reports whether a finding of level first seen at since is past its due time at
+// now.
+func (s SLA) Breached(level Level, since, now time.Time) bool {
+	due, ok := s.Due(level, since)
+	return ok && now.After(due)
+}
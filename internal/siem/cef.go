@@ -0,0 +1,68 @@
+package siem
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omercnet/gitguard/internal/notify"
+)
+
+// cefSeverity maps GitGuard severities to the CEF 0-10 scale.
+var cefSeverity = map[string]int{
+	"none":     0,
+	"low":      3,
+	"medium":   5,
+	"high":     8,
+	"critical": 10,
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+// FormatCEFRecord renders a record in ArcSight Common Event Format.
+func FormatCEFRecord(record notify.Record, version string) string {
+	var signature, name, level string
+	ext := []string{
+		"rt=" + fmt.Sprint(record.Time.UnixMilli()),
+		"cs1Label=repository", "cs1=" + cefExtensionEscaper.Replace(record.Repository),
+		"cs2Label=commit", "cs2=" + cefExtensionEscaper.Replace(record.Commit),
+		"cs3Label=ref", "cs3=" + cefExtensionEscaper.Replace(record.Ref),
+	}
+
+	if record.Finding != nil {
+		signature = record.Finding.RuleID
+		name = "Secret detected"
+		level = record.Finding.Severity
+		ext = append(ext,
+			"filePath="+cefExtensionEscaper.Replace(record.Finding.File),
+			"cn1Label=line", fmt.Sprintf("cn1=%d", record.Finding.Line),
+			"cs4Label=fingerprint", "cs4="+cefExtensionEscaper.Replace(record.Finding.Fingerprint),
+		)
+	} else {
+		signature = record.Type
+		name = "Scan completed"
+		if record.Summary != nil {
+			level = record.Summary.HighestSeverity
+			ext = append(ext, fmt.Sprintf("cnt=%d", record.Summary.Total))
+		}
+		if record.Conclusion != "" {
+			ext = append(ext, "outcome="+cefExtensionEscaper.Replace(record.Conclusion))
+		}
+	}
+	if record.Link != "" {
+		ext = append(ext, "request="+cefExtensionEscaper.Replace(record.Link))
+	}
+	if record.RequestID != "" {
+		ext = append(ext, "externalId="+cefExtensionEscaper.Replace(record.RequestID))
+	}
+
+	return fmt.Sprintf("CEF:0|GitGuard|GitGuard|%s|%s|%s|%d|%s",
+		cefHeaderEscaper.Replace(version),
+		cefHeaderEscaper.Replace(signature),
+		cefHeaderEscaper.Replace(name),
+		cefSeverity[level],
+		strings.Join(ext, " "),
+	)
+}
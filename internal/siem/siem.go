@@ -0,0 +1,83 @@
+// Package siem exports GitGuard activity to security information and event management systems.
+package siem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/notify"
+)
+
+// Output formats.
+const (
+	FormatJSON = "json"
+	FormatCEF  = "cef"
+)
+
+// Message is a formatted record ready to be sent.
+type Message struct {
+	Time time.Time
+	Body []byte
+	// JSON reports whether Body is a JSON document rather than text.
+	JSON bool
+}
+
+// Sink delivers formatted messages to a SIEM endpoint.
+type Sink interface {
+	Send(ctx context.Context, messages []Message) error
+	// Close releases the sink's connections once it is replaced. Messages sent afterwards
+	// are still delivered, without keeping a connection open.
+	Close() error
+}
+
+// Exporter is a notify.Notifier that converts events into records and streams them to a sink.
+type Exporter struct {
+	format  string
+	version string
+	sink    Sink
+}
+
+// NewExporter creates an exporter writing records in format to sink. Version is reported as
+// the product version in CEF headers.
+func NewExporter(format, version string, sink Sink) (*Exporter, error) {
+	switch format {
+	case "":
+		format = FormatJSON
+	case FormatJSON, FormatCEF:
+	default:
+		return nil, fmt.Errorf("unsupported SIEM format %q", format)
+	}
+	return &Exporter{format: format, version: version, sink: sink}, nil
+}
+
+// Notify exports an audit record for the event followed by one record per finding.
+func (e *Exporter) Notify(ctx context.Context, event notify.Event) error {
+	records := notify.Records(event)
+	messages := make([]Message, 0, len(records))
+	for _, record := range records {
+		message, err := e.formatRecord(record)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, message)
+	}
+	return e.sink.Send(ctx, messages)
+}
+
+// Close closes the exporter's sink.
+func (e *Exporter) Close() error {
+	return e.sink.Close()
+}
+
+func (e *Exporter) formatRecord(record notify.Record) (Message, error) {
+	if e.format == FormatCEF {
+		return Message{Time: record.Time, Body: []byte(FormatCEFRecord(record, e.version))}, nil
+	}
+	body, err := json.Marshal(record)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to encode SIEM record: %w", err)
+	}
+	return Message{Time: record.Time, Body: body, JSON: true}, nil
+}
@@ -0,0 +1,199 @@
+package siem
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omercnet/gitguard/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() notify.Event {
+	return notify.Event{
+		Type:       notify.EventScanCompleted,
+		Scan:       notify.ScanCommit,
+		Repository: "octo/repo",
+		Ref:        "refs/heads/main",
+		Commit:     "abc123",
+		Conclusion: "failure",
+		Findings:   notify.Summary{Total: 1, HighestSeverity: "critical"},
+		Details: []notify.Finding{
+			{File: "keys/id=rsa", Line: 3, RuleID: "private-key", Severity: "critical", Fingerprint: "gitguard-1"},
+		},
+		Links:     notify.Links{CheckRun: "https://github.com/octo/repo/runs/1"},
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+	}
+}
+
+type recordingSink struct {
+	messages []Message
+}
+
+func (s *recordingSink) Send(_ context.Context, messages []Message) error {
+	s.messages = append(s.messages, messages...)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestExporter_JSON(t *testing.T) {
+	sink := &recordingSink{}
+	exporter, err := NewExporter("", "1.0.0", sink)
+	require.NoError(t, err)
+
+	require.NoError(t, exporter.Notify(context.Background(), testEvent()))
+	require.Len(t, sink.messages, 2)
+
+	var record notify.Record
+	require.NoError(t, json.Unmarshal(sink.messages[1].Body, &record))
+	assert.True(t, sink.messages[1].JSON)
+	assert.Equal(t, "keys/id=rsa", record.Finding.File)
+}
+
+func TestExporter_InvalidFormat(t *testing.T) {
+	_, err := NewExporter("xml", "1.0.0", &recordingSink{})
+	assert.Error(t, err)
+}
+
+func TestFormatCEFRecord(t *testing.T) {
+	event := testEvent()
+	event.RequestID = "delivery-1"
+	records := notify.Records(event)
+
+	audit := FormatCEFRecord(records[0], "1.0.0")
+	assert.True(t, strings.HasPrefix(audit, "CEF:0|GitGuard|GitGuard|1.0.0|scan.completed|Scan completed|10|"))
+	assert.Contains(t, audit, "cnt=1")
+	assert.Contains(t, audit, "outcome=failure")
+	assert.Contains(t, audit, "externalId=delivery-1")
+
+	finding := FormatCEFRecord(records[1], "1.0.0")
+	assert.True(t, strings.HasPrefix(finding, "CEF:0|GitGuard|GitGuard|1.0.0|private-key|Secret detected|10|"))
+	assert.Contains(t, finding, `filePath=keys/id\=rsa`, "Should escape extension values")
+	assert.Contains(t, finding, "cn1=3")
+}
+
+func TestSyslog_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	sink, err := NewSyslog("tcp://" + listener.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), []Message{
+		{Time: time.Unix(0, 0), Body: []byte("first")},
+		{Time: time.Unix(0, 0), Body: []byte("second")},
+	}))
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case line := <-lines:
+			assert.True(t, strings.HasPrefix(line, "<133>1 1970-01-01T00:00:00Z "))
+			assert.True(t, strings.HasSuffix(line, " gitguard - - - "+want))
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for syslog line")
+		}
+	}
+}
+
+func TestSyslog_Close(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// Each connection reports its lines once the sink closes it.
+	connections := make(chan []string, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var lines []string
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines = append(lines, scanner.Text())
+				}
+				connections <- lines
+			}()
+		}
+	}()
+	closedWith := func(want int) {
+		t.Helper()
+		select {
+		case lines := <-connections:
+			assert.Len(t, lines, want)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the syslog connection to close")
+		}
+	}
+
+	sink, err := NewSyslog("tcp://" + listener.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), []Message{{Time: time.Unix(0, 0), Body: []byte("open")}}))
+	require.NoError(t, sink.Close())
+	closedWith(1)
+
+	require.NoError(t, sink.Send(context.Background(), []Message{
+		{Time: time.Unix(0, 0), Body: []byte("late")},
+		{Time: time.Unix(0, 0), Body: []byte("later")},
+	}))
+	closedWith(2)
+}
+
+func TestNewSyslog_InvalidAddress(t *testing.T) {
+	for _, address := range []string{"siem:514", "http://siem:514", "udp://"} {
+		_, err := NewSyslog(address)
+		assert.Error(t, err, address)
+	}
+}
+
+func TestSplunk_Send(t *testing.T) {
+	var events []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/collector/event", r.URL.Path)
+		assert.Equal(t, "Splunk token", r.Header.Get("Authorization"))
+		decoder := json.NewDecoder(r.Body)
+		for decoder.More() {
+			var event map[string]any
+			require.NoError(t, decoder.Decode(&event))
+			events = append(events, event)
+		}
+	}))
+	defer server.Close()
+
+	sink := NewSplunk(server.URL+"/", "token")
+	require.NoError(t, sink.Send(context.Background(), []Message{
+		{Time: time.Unix(10, 0), Body: []byte(`{"kind":"audit"}`), JSON: true},
+		{Time: time.Unix(10, 0), Body: []byte("CEF:0|GitGuard")},
+	}))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "gitguard:json", events[0]["sourcetype"])
+	assert.Equal(t, map[string]any{"kind": "audit"}, events[0]["event"])
+	assert.Equal(t, "gitguard:cef", events[1]["sourcetype"])
+	assert.Equal(t, "CEF:0|GitGuard", events[1]["event"])
+	assert.InDelta(t, 10.0, events[1]["time"], 0.001)
+}
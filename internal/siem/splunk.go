@@ -0,0 +1,88 @@
+package siem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	splunkEventPath = "/services/collector/event"
+	splunkTimeout   = 10 * time.Second
+)
+
+// Splunk sends messages to a Splunk HTTP Event Collector, batching each export into one request.
+type Splunk struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+// NewSplunk creates a HEC sink. baseURL may be the collector host or the full event endpoint.
+func NewSplunk(baseURL, token string) *Splunk {
+	endpoint := strings.TrimSuffix(baseURL, "/")
+	if !strings.HasSuffix(endpoint, splunkEventPath) {
+		endpoint += splunkEventPath
+	}
+	return &Splunk{
+		URL:    endpoint,
+		Token:  token,
+		Client: &http.Client{Timeout: splunkTimeout},
+	}
+}
+
+// Close closes the idle connections of the collector's client.
+func (s *Splunk) Close() error {
+	s.Client.CloseIdleConnections()
+	return nil
+}
+
+type splunkEvent struct {
+	Time       float64 `json:"time"`
+	Source     string  `json:"source"`
+	Sourcetype string  `json:"sourcetype"`
+	Event      any     `json:"event"`
+}
+
+// Send posts the messages as concatenated HEC events.
+func (s *Splunk) Send(ctx context.Context, messages []Message) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, message := range messages {
+		event := splunkEvent{
+			Time:       float64(message.Time.UnixMilli()) / 1000,
+			Source:     "gitguard",
+			Sourcetype: "gitguard:cef",
+			Event:      string(message.Body),
+		}
+		if message.JSON {
+			event.Sourcetype = "gitguard:json"
+			event.Event = json.RawMessage(message.Body)
+		}
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode Splunk event: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create Splunk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Splunk "+s.Token)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send events to Splunk: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("splunk HEC responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package siem
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// syslogPriority is facility local0 (16) with severity notice (5).
+	syslogPriority = 16*8 + 5
+	syslogTimeout  = 10 * time.Second
+)
+
+// Syslog sends messages as RFC 5424 syslog lines over UDP or TCP. TCP messages are
+// newline-delimited; the connection is re-established after write errors.
+type Syslog struct {
+	network  string
+	address  string
+	hostname string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// NewSyslog creates a syslog sink for an address like "udp://siem:514" or "tcp://siem:601".
+func NewSyslog(address string) (*Syslog, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog address %q: %w", address, err)
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return nil, fmt.Errorf("invalid syslog address %q: scheme must be udp or tcp", address)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid syslog address %q: missing host", address)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &Syslog{network: u.Scheme, address: u.Host, hostname: hostname}, nil
+}
+
+// Send writes each message as one syslog line.
+func (s *Syslog) Send(ctx context.Context, messages []Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		// Scans still running on a replaced configuration connect for their own messages.
+		defer func() { _ = s.disconnect() }()
+	}
+
+	for _, message := range messages {
+		line := fmt.Sprintf("<%d>1 %s %s gitguard - - - %s\n",
+			syslogPriority, message.Time.UTC().Format(time.RFC3339Nano), s.hostname, message.Body)
+		if err := s.write(ctx, []byte(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Syslog) write(ctx context.Context, line []byte) error {
+	if s.conn == nil {
+		dialer := net.Dialer{Timeout: syslogTimeout}
+		conn, err := dialer.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog %s: %w", s.address, err)
+		}
+		s.conn = conn
+	}
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+	if _, err := s.conn.Write(line); err != nil {
+		_ = s.disconnect()
+		return fmt.Errorf("failed to write to syslog %s: %w", s.address, err)
+	}
+	return nil
+}
+
+// Close closes the connection to syslog. Later messages are sent over a connection closed
+// once they are written.
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.disconnect()
+}
+
+func (s *Syslog) disconnect() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
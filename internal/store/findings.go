@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+)
+
+// State is the lifecycle state of a tracked finding.
+type State string
+
+// Finding lifecycle states.
+const (
+	// StateOpen findings were detected by the latest scan of their path.
+	StateOpen State = "open"
+	// StateResolved findings were no longer detected by a later scan of their path.
+	StateResolved State = "resolved"
+	// StateSuppressed findings were dismissed by an administrator and stay suppressed
+	// whether or not they are detected again.
+	StateSuppressed State = "suppressed"
+)
+
+// Finding is a tracked finding, identified by its fingerprint within a repository.
+type Finding struct {
+	Fingerprint string    `json:"fingerprint"`
+	Repository  string    `json:"repository"`
+	File        string    `json:"file"`
+	RuleID      string    `json:"rule_id"`
+	Line        int       `json:"line"`
+	State       State     `json:"state"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	ResolvedAt  time.Time `json:"resolved_at,omitzero"`
+	// Secret is the secret as a masked preview or a hash, when the redaction policy allows;
+	// raw secrets are never stored.
+	Secret string `json:"secret,omitempty"`
+}
+
+// Transition records a finding changing state.
+type Transition struct {
+	Finding Finding `json:"finding"`
+	From    State   `json:"from,omitempty"`
+	To      State   `json:"to"`
+}
+
+// Scan is the outcome of a scan to record.
+type Scan struct {
+	Repository string
+	// Paths are the files that were scanned. Open findings in these files that were not
+	// detected are resolved.
+	Paths []string
+	// Complete marks a scan of every file in the repository; Paths is then ignored.
+	Complete bool
+	Findings []Finding
+	Time     time.Time
+}
+
+// Occurrence lists the branches a finding was detected on, so a secret pushed to several
+// branches is tracked as a single finding.
+type Occurrence struct {
+	Fingerprint string    `json:"fingerprint"`
+	Branches    []string  `json:"branches"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// FindingStore tracks the lifecycle of findings.
+type FindingStore interface {
+	// RecordScan records the findings of a scan and returns the resulting state transitions.
+	// New findings open with an empty From state.
+	RecordScan(ctx context.Context, scan Scan) ([]Transition, error)
+	// Findings returns the tracked findings of a repository, ordered by file and rule.
+	Findings(ctx context.Context, repository string) ([]Finding, error)
+	// AllFindings returns the tracked findings of every repository, ordered by repository,
+	// file and rule.
+	AllFindings(ctx context.Context) ([]Finding, error)
+	// SetState changes the state of a tracked finding, or returns ErrNotFound.
+	SetState(ctx context.Context, repository, fingerprint string, state State) (Transition, error)
+	// RecordOccurrences records that findings of a repository, by fingerprint, were detected
+	// on branch. It returns the occurrences the fingerprints had before, by fingerprint;
+	// fingerprints detected for the first time have none.
+	RecordOccurrences(
+		ctx context.Context, repository, branch string, fingerprints []string, at time.Time,
+	) (map[string]Occurrence, error)
+}
+
+// RecordScan implements FindingStore.
+func (f *File) RecordScan(_ context.Context, scan Scan) ([]Transition, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tracked := f.state.Findings[scan.Repository]
+	if tracked == nil {
+		tracked = make(map[string]Finding)
+		f.state.Findings[scan.Repository] = tracked
+	}
+
+	var transitions []Transition
+	detected := make(map[string]bool, len(scan.Findings))
+	for _, finding := range scan.Findings {
+		detected[finding.Fingerprint] = true
+		existing, ok := tracked[finding.Fingerprint]
+		switch {
+		case !ok:
+			finding.Repository = scan.Repository
+			finding.State = StateOpen
+			finding.FirstSeen = scan.Time
+		case existing.State == StateResolved:
+			finding.Repository = scan.Repository
+			finding.State = StateOpen
+			finding.FirstSeen = existing.FirstSeen
+		default:
+			existing.File, existing.Line, existing.Secret = finding.File, finding.Line, finding.Secret
+			finding = existing
+		}
+		finding.LastSeen = scan.Time
+		if !ok || existing.State != finding.State {
+			transitions = append(transitions, Transition{Finding: finding, From: existing.State, To: finding.State})
+		}
+		tracked[finding.Fingerprint] = finding
+	}
+
+	for fingerprint, finding := range tracked {
+		if finding.State != StateOpen || detected[fingerprint] {
+			continue
+		}
+		if !scan.Complete && !slices.Contains(scan.Paths, finding.File) {
+			continue
+		}
+		finding.State = StateResolved
+		finding.ResolvedAt = scan.Time
+		tracked[fingerprint] = finding
+		transitions = append(transitions, Transition{Finding: finding, From: StateOpen, To: StateResolved})
+	}
+
+	sortTransitions(transitions)
+	return transitions, f.save()
+}
+
+// Findings implements FindingStore.
+func (f *File) Findings(_ context.Context, repository string) ([]Finding, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	findings := make([]Finding, 0, len(f.state.Findings[repository]))
+	for _, finding := range f.state.Findings[repository] {
+		findings = append(findings, finding)
+	}
+	sort.Slice(findings, func(i, j int) bool { return lessFinding(findings[i], findings[j]) })
+	return findings, nil
+}
+
+// AllFindings implements FindingStore.
+func (f *File) AllFindings(_ context.Context) ([]Finding, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var findings []Finding
+	for _, tracked := range f.state.Findings {
+		for _, finding := range tracked {
+			findings = append(findings, finding)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Repository != findings[j].Repository {
+			return findings[i].Repository < findings[j].Repository
+		}
+		return lessFinding(findings[i], findings[j])
+	})
+	return findings, nil
+}
+
+// SetState implements FindingStore.
+func (f *File) SetState(_ context.Context, repository, fingerprint string, state State) (Transition, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	finding, ok := f.state.Findings[repository][fingerprint]
+	if !ok {
+		return Transition{}, ErrNotFound
+	}
+	transition := Transition{From: finding.State, To: state}
+	finding.State = state
+	f.state.Findings[repository][fingerprint] = finding
+	transition.Finding = finding
+	return transition, f.save()
+}
+
+// RecordOccurrences implements FindingStore.
+func (f *File) RecordOccurrences(
+	_ context.Context, repository, branch string, fingerprints []string, at time.Time,
+) (map[string]Occurrence, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tracked := f.state.Occurrences[repository]
+	if tracked == nil {
+		tracked = make(map[string]Occurrence)
+		f.state.Occurrences[repository] = tracked
+	}
+
+	previous := make(map[string]Occurrence)
+	for _, fingerprint := range fingerprints {
+		occurrence, ok := tracked[fingerprint]
+		if ok {
+			occurrence.Branches = slices.Clone(occurrence.Branches)
+			previous[fingerprint] = occurrence
+		} else {
+			occurrence = Occurrence{Fingerprint: fingerprint, FirstSeen: at}
+		}
+		if !slices.Contains(occurrence.Branches, branch) {
+			occurrence.Branches = append(slices.Clone(occurrence.Branches), branch)
+			slices.Sort(occurrence.Branches)
+		}
+		occurrence.LastSeen = at
+		tracked[fingerprint] = occurrence
+	}
+	return previous, f.save()
+}
+
+func sortTransitions(transitions []Transition) {
+	sort.Slice(transitions, func(i, j int) bool {
+		return lessFinding(transitions[i].Finding, transitions[j].Finding)
+	})
+}
+
+func lessFinding(a, b Finding) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.RuleID != b.RuleID {
+		return a.RuleID < b.RuleID
+	}
+	return a.Fingerprint < b.Fingerprint
+}
@@ -0,0 +1,174 @@
+// Package store persists the GitGuard state that has to survive restarts, such as finding
+// baselines and finding lifecycle states. Only finding fingerprints are stored, never secret values.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Baseline is the set of findings grandfathered in a repository.
+type Baseline struct {
+	Repository   string    `json:"repository"`
+	Fingerprints []string  `json:"fingerprints"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Contains reports whether the fingerprint is part of the baseline.
+func (b *Baseline) Contains(fingerprint string) bool {
+	return b != nil && slices.Contains(b.Fingerprints, fingerprint)
+}
+
+// Store persists GitGuard state.
+type Store interface {
+	FindingStore
+
+	// Baseline returns the repository's baseline, or ErrNotFound.
+	Baseline(ctx context.Context, repository string) (*Baseline, error)
+	// SaveBaseline creates or replaces a repository's baseline.
+	SaveBaseline(ctx context.Context, baseline Baseline) error
+	// DeleteBaseline removes a repository's baseline, if any.
+	DeleteBaseline(ctx context.Context, repository string) error
+}
+
+// state is the document persisted by File.
+type state struct {
+	Baselines   map[string]Baseline              `json:"baselines"`
+	Findings    map[string]map[string]Finding    `json:"findings"`
+	Occurrences map[string]map[string]Occurrence `json:"occurrences,omitempty"`
+}
+
+// File is a Store kept in memory and persisted as a JSON document on local disk. It is meant
+// for single-instance deployments.
+type File struct {
+	path string
+
+	mu    sync.Mutex
+	state state
+}
+
+// NewMemory returns a Store that is not persisted.
+func NewMemory() *File {
+	return &File{state: state{
+		Baselines:   make(map[string]Baseline),
+		Findings:    make(map[string]map[string]Finding),
+		Occurrences: make(map[string]map[string]Occurrence),
+	}}
+}
+
+// Open loads the store persisted at path, creating it on first write.
+func Open(path string) (*File, error) {
+	f := NewMemory()
+	f.path = path
+
+	data, err := os.ReadFile(path) // #nosec G304 -- Path comes from operator configuration.
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &f.state); err != nil {
+		return nil, fmt.Errorf("failed to decode store %s: %w", path, err)
+	}
+	if f.state.Baselines == nil {
+		f.state.Baselines = make(map[string]Baseline)
+	}
+	if f.state.Findings == nil {
+		f.state.Findings = make(map[string]map[string]Finding)
+	}
+	if f.state.Occurrences == nil {
+		f.state.Occurrences = make(map[string]map[string]Occurrence)
+	}
+	return f, nil
+}
+
+// Ping checks that the directory the store is persisted in is available, e.g. that its volume
+// is mounted. Stores that are not persisted are always available.
+func (f *File) Ping(context.Context) error {
+	if f.path == "" {
+		return nil
+	}
+	dir := filepath.Dir(f.path)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("store directory unavailable: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("store directory unavailable: %s is not a directory", dir)
+	}
+	return nil
+}
+
+// Baseline implements Store.
+func (f *File) Baseline(_ context.Context, repository string) (*Baseline, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	baseline, ok := f.state.Baselines[repository]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	baseline.Fingerprints = slices.Clone(baseline.Fingerprints)
+	return &baseline, nil
+}
+
+// SaveBaseline implements Store.
+func (f *File) SaveBaseline(_ context.Context, baseline Baseline) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	baseline.Fingerprints = slices.Clone(baseline.Fingerprints)
+	slices.Sort(baseline.Fingerprints)
+	baseline.Fingerprints = slices.Compact(baseline.Fingerprints)
+	f.state.Baselines[baseline.Repository] = baseline
+	return f.save()
+}
+
+// DeleteBaseline implements Store.
+func (f *File) DeleteBaseline(_ context.Context, repository string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.state.Baselines, repository)
+	return f.save()
+}
+
+// save writes the state atomically. Callers must hold mu.
+func (f *File) save() error {
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(f.state)
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write store %s: %w", f.path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write store %s: %w", f.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write store %s: %w", f.path, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write store %s: %w", f.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Baseline(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gitguard.json")
+
+	f, err := Open(path)
+	require.NoError(t, err)
+
+	_, err = f.Baseline(ctx, "owner/repo")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, f.SaveBaseline(ctx, Baseline{
+		Repository:   "owner/repo",
+		Fingerprints: []string{"gitguard-b", "gitguard-a", "gitguard-b"},
+		CreatedAt:    created,
+	}))
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	baseline, err := reopened.Baseline(ctx, "owner/repo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gitguard-a", "gitguard-b"}, baseline.Fingerprints)
+	assert.True(t, baseline.CreatedAt.Equal(created))
+	assert.True(t, baseline.Contains("gitguard-a"))
+	assert.False(t, baseline.Contains("gitguard-c"))
+
+	require.NoError(t, reopened.DeleteBaseline(ctx, "owner/repo"))
+	reopened, err = Open(path)
+	require.NoError(t, err)
+	_, err = reopened.Baseline(ctx, "owner/repo")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestNewMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	require.NoError(t, m.SaveBaseline(ctx, Baseline{Repository: "owner/repo", Fingerprints: []string{"gitguard-a"}}))
+	baseline, err := m.Baseline(ctx, "owner/repo")
+	require.NoError(t, err)
+	assert.True(t, baseline.Contains("gitguard-a"))
+
+	var missing *Baseline
+	assert.False(t, missing.Contains("gitguard-a"))
+}
+
+func TestFile_RecordScan(t *testing.T) {
+	ctx := context.Background()
+	f := NewMemory()
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	config := Finding{Fingerprint: "gitguard-a", File: "config.yml", RuleID: "generic-api-key", Line: 3}
+	env := Finding{Fingerprint: "gitguard-b", File: ".env", RuleID: "aws-access-token", Line: 1}
+
+	transitions, err := f.RecordScan(ctx, Scan{Repository: "owner/repo", Complete: true, Findings: []Finding{config, env}, Time: first})
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, State(""), transitions[0].From)
+	assert.Equal(t, StateOpen, transitions[0].To)
+
+	// A scan of another path leaves both findings open.
+	transitions, err = f.RecordScan(ctx, Scan{Repository: "owner/repo", Paths: []string{"README.md"}, Time: second})
+	require.NoError(t, err)
+	assert.Empty(t, transitions)
+
+	// Scanning config.yml without detecting its finding resolves it.
+	transitions, err = f.RecordScan(ctx, Scan{Repository: "owner/repo", Paths: []string{"config.yml"}, Time: second})
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, "gitguard-a", transitions[0].Finding.Fingerprint)
+	assert.Equal(t, StateResolved, transitions[0].To)
+	assert.Equal(t, second, transitions[0].Finding.ResolvedAt)
+
+	// Suppressed findings are neither resolved nor reopened.
+	_, err = f.SetState(ctx, "owner/repo", "gitguard-b", StateSuppressed)
+	require.NoError(t, err)
+	transitions, err = f.RecordScan(ctx, Scan{Repository: "owner/repo", Complete: true, Findings: []Finding{config}, Time: second})
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, StateResolved, transitions[0].From, "A resolved finding detected again should reopen")
+	assert.Equal(t, StateOpen, transitions[0].To)
+
+	findings, err := f.Findings(ctx, "owner/repo")
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, StateSuppressed, findings[0].State)
+	assert.Equal(t, first, findings[1].FirstSeen)
+
+	// Open findings detected again take the secret as last redacted.
+	config.Secret = "sha256:abc"
+	_, err = f.RecordScan(ctx, Scan{Repository: "owner/repo", Complete: true, Findings: []Finding{config}, Time: second})
+	require.NoError(t, err)
+	findings, err = f.Findings(ctx, "owner/repo")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", findings[1].Secret)
+
+	_, err = f.SetState(ctx, "owner/repo", "gitguard-missing", StateSuppressed)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = f.RecordScan(ctx, Scan{Repository: "another/repo", Complete: true, Findings: []Finding{env}, Time: second})
+	require.NoError(t, err)
+	all, err := f.AllFindings(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "another/repo", all[0].Repository)
+	assert.Equal(t, findings, all[1:])
+}
+
+func TestFile_RecordOccurrences(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gitguard.json")
+	f, err := Open(path)
+	require.NoError(t, err)
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	previous, err := f.RecordOccurrences(ctx, "owner/repo", "main", []string{"gitguard-a"}, first)
+	require.NoError(t, err)
+	assert.Empty(t, previous, "Findings detected for the first time have no occurrences")
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	later := first.Add(time.Hour)
+	previous, err = reopened.RecordOccurrences(ctx, "owner/repo", "feature", []string{"gitguard-a", "gitguard-b"}, later)
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+	assert.Equal(t, []string{"main"}, previous["gitguard-a"].Branches)
+	assert.True(t, previous["gitguard-a"].FirstSeen.Equal(first))
+
+	previous, err = reopened.RecordOccurrences(ctx, "owner/repo", "feature", []string{"gitguard-a"}, later)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feature", "main"}, previous["gitguard-a"].Branches)
+
+	previous, err = reopened.RecordOccurrences(ctx, "owner/other", "main", []string{"gitguard-a"}, later)
+	require.NoError(t, err)
+	assert.Empty(t, previous, "Occurrences are tracked per repository")
+}
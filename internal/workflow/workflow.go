@@ -0,0 +1,166 @@
+// Package workflow detects secrets and secret exposure in GitHub Actions workflow files,
+// which run with repository credentials and are identifiable by path.
+package workflow
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule IDs of workflow findings. They share RulePrefix so they are reported as one category.
+const (
+	RulePrefix               = "github-actions-"
+	RuleHardcodedSecret      = RulePrefix + "hardcoded-secret"
+	RulePullRequestTarget    = RulePrefix + "pull-request-target-secrets"
+	minHardcodedSecretLength = 8
+)
+
+// Tag is set on every workflow finding.
+const Tag = "github-actions"
+
+var (
+	// credentialKey matches env and input names that hold credentials.
+	credentialKey = regexp.MustCompile(`(?i)(token|passw(or)?d|secret|api[_-]?key|access[_-]?key|private[_-]?key|credential)`)
+
+	// untrustedRefs are expressions resolving to the pull request's head, i.e. code
+	// controlled by the pull request author.
+	untrustedRefs = []string{"github.event.pull_request.head.", "github.head_ref"}
+)
+
+// IsWorkflow reports whether name is a GitHub Actions workflow file.
+func IsWorkflow(name string) bool {
+	dir, file := path.Split(name)
+	ext := path.Ext(file)
+	return dir == ".github/workflows/" && (ext == ".yml" || ext == ".yaml")
+}
+
+// IsWorkflowFinding reports whether finding was reported by this package.
+func IsWorkflowFinding(finding report.Finding) bool {
+	return strings.HasPrefix(finding.RuleID, RulePrefix)
+}
+
+// Detect returns the workflow findings in content when name is a workflow file:
+// credentials written as literal values of env variables and action inputs, and
+// pull_request_target workflows that check out the pull request's code while using secrets.
+// Files that are not valid YAML have no findings.
+func Detect(name, content string) []report.Finding {
+	if !IsWorkflow(name) {
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+
+	var findings []report.Finding
+	var untrusted *yaml.Node
+	usesSecrets := false
+	walk(root, func(key, value *yaml.Node) {
+		if value.Kind != yaml.ScalarNode {
+			return
+		}
+		if strings.Contains(value.Value, "secrets.") && !strings.Contains(value.Value, "secrets.GITHUB_TOKEN") {
+			usesSecrets = true
+		}
+		if untrusted == nil && containsAny(value.Value, untrustedRefs) {
+			untrusted = value
+		}
+		if key != nil && isHardcodedSecret(key.Value, value.Value) {
+			findings = append(findings, finding(name, RuleHardcodedSecret,
+				"Credential hardcoded in a GitHub Actions workflow instead of referencing a secret",
+				value, key.Value+": "+value.Value, value.Value))
+		}
+	})
+
+	if untrusted != nil && usesSecrets && triggeredBy(root, "pull_request_target") {
+		findings = append(findings, finding(name, RulePullRequestTarget,
+			"pull_request_target workflow runs pull request code with access to repository secrets",
+			untrusted, untrusted.Value, ""))
+	}
+	return findings
+}
+
+// walk calls fn for every mapping value with its key, and for every sequence item with a
+// nil key.
+func walk(node *yaml.Node, fn func(key, value *yaml.Node)) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			fn(node.Content[i], node.Content[i+1])
+			walk(node.Content[i+1], fn)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			fn(nil, item)
+			walk(item, fn)
+		}
+	}
+}
+
+// isHardcodedSecret reports whether a credential-named key has a literal value rather than
+// an expression.
+func isHardcodedSecret(key, value string) bool {
+	return credentialKey.MatchString(key) &&
+		len(value) >= minHardcodedSecretLength &&
+		!strings.Contains(value, "${{") &&
+		!strings.ContainsAny(value, " \n")
+}
+
+// triggeredBy reports whether the workflow runs on event.
+func triggeredBy(root *yaml.Node, event string) bool {
+	if root.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "on" {
+			continue
+		}
+		on := root.Content[i+1]
+		switch on.Kind {
+		case yaml.ScalarNode:
+			return on.Value == event
+		case yaml.SequenceNode:
+			for _, item := range on.Content {
+				if item.Value == event {
+					return true
+				}
+			}
+		case yaml.MappingNode:
+			for j := 0; j < len(on.Content); j += 2 {
+				if on.Content[j].Value == event {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func finding(file, ruleID, description string, node *yaml.Node, match, secret string) report.Finding {
+	return report.Finding{
+		RuleID:      ruleID,
+		Description: description,
+		File:        file,
+		StartLine:   node.Line,
+		EndLine:     node.Line,
+		StartColumn: node.Column,
+		EndColumn:   node.Column + len(node.Value),
+		Match:       match,
+		Secret:      secret,
+		Tags:        []string{Tag},
+	}
+}
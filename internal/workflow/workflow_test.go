@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsWorkflow(t *testing.T) {
+	assert.True(t, IsWorkflow(".github/workflows/ci.yml"))
+	assert.True(t, IsWorkflow(".github/workflows/release.yaml"))
+	assert.False(t, IsWorkflow(".github/workflows/nested/ci.yml"))
+	assert.False(t, IsWorkflow(".github/dependabot.yml"))
+	assert.False(t, IsWorkflow("workflows/ci.yml"))
+}
+
+func TestDetectHardcodedSecret(t *testing.T) {
+	content := `on: push
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    env:
+      DEPLOY_TOKEN: d3pl0y-t0k3n-value
+      API_KEY: ${{ secrets.API_KEY }}
+      NODE_ENV: production
+    steps:
+      - uses: docker/login-action@v3
+        with:
+          username: deploy
+          password: hunter2hunter2
+      - run: echo done
+`
+	findings := Detect(".github/workflows/deploy.yml", content)
+	require.Len(t, findings, 2)
+
+	assert.Equal(t, RuleHardcodedSecret, findings[0].RuleID)
+	assert.Equal(t, "d3pl0y-t0k3n-value", findings[0].Secret)
+	assert.Equal(t, 6, findings[0].StartLine)
+	assert.Equal(t, ".github/workflows/deploy.yml", findings[0].File)
+	assert.Equal(t, []string{Tag}, findings[0].Tags)
+	assert.True(t, IsWorkflowFinding(findings[0]))
+
+	assert.Equal(t, "hunter2hunter2", findings[1].Secret)
+	assert.Equal(t, 13, findings[1].StartLine)
+
+	assert.Empty(t, Detect("deploy.yml", content), "Only workflow files are checked")
+}
+
+func TestDetectPullRequestTarget(t *testing.T) {
+	vulnerable := `on:
+  pull_request_target:
+    types: [opened]
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          ref: ${{ github.event.pull_request.head.sha }}
+      - run: make test
+        env:
+          NPM_TOKEN: ${{ secrets.NPM_TOKEN }}
+`
+	findings := Detect(".github/workflows/test.yml", vulnerable)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RulePullRequestTarget, findings[0].RuleID)
+	assert.Equal(t, 10, findings[0].StartLine)
+	assert.Empty(t, findings[0].Secret)
+
+	for name, content := range map[string]string{
+		"pull_request trigger": `on: [pull_request]
+jobs:
+  test:
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          ref: ${{ github.event.pull_request.head.sha }}
+      - run: make test
+        env:
+          NPM_TOKEN: ${{ secrets.NPM_TOKEN }}
+`,
+		"base checkout": `on: pull_request_target
+jobs:
+  label:
+    steps:
+      - uses: actions/labeler@v5
+        with:
+          repo-token: ${{ secrets.LABELER_TOKEN }}
+`,
+		"invalid YAML": "on: [pull_request_target\n",
+	} {
+		assert.Empty(t, Detect(".github/workflows/test.yml", content), name)
+	}
+}